@@ -7,10 +7,13 @@ import (
 	"github.com/bezjen/shortener/internal/config/db"
 	"github.com/bezjen/shortener/internal/handler"
 	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/middleware"
+	"github.com/bezjen/shortener/internal/model"
 	"github.com/bezjen/shortener/internal/repository"
 	"github.com/bezjen/shortener/internal/router"
 	"github.com/bezjen/shortener/internal/service"
 	"log"
+	"net"
 	"net/http"
 	_ "net/http/pprof"
 	"os/signal"
@@ -49,11 +52,97 @@ func main() {
 	}(storage)
 	urlShortener := service.NewURLShortener(storage, shortenerLogger)
 	defer urlShortener.Close()
+	if cfg.TrashRetention > 0 {
+		urlShortener.StartTrashPurge(cfg.TrashRetention)
+	}
+	urlShortener.EnableQuotas(model.QuotaLimits{
+		LinksPerDay:     cfg.QuotaLinksPerDay,
+		ActiveLinks:     cfg.QuotaActiveLinks,
+		BatchSize:       cfg.QuotaBatchSize,
+		DeleteBatchSize: cfg.QuotaDeleteBatchSize,
+	})
+	urlShortener.EnablePhishingChecks(service.NewPhishingDetector(cfg.PhishingBrands, cfg.PhishingTLDs))
+	urlShortener.SetURLCanonicalizer(service.NewURLCanonicalizer(cfg.DropTrackingParams))
+	if cfg.FetchMetadata {
+		metadataFetcher := service.NewMetadataFetcher(storage, shortenerLogger)
+		defer metadataFetcher.Close()
+		urlShortener.EnableMetadataEnrichment(metadataFetcher)
+	}
+	if cfg.HealthCheckInterval > 0 {
+		healthChecker := service.NewHealthChecker(storage, shortenerLogger,
+			cfg.HealthCheckConcurrency, cfg.HealthCheckHostDelay)
+		healthChecker.Start(cfg.HealthCheckInterval)
+		defer healthChecker.Close()
+	}
 	authorizer := service.NewAuthorizer([]byte(cfg.SecretKey), shortenerLogger)
+	if len(cfg.JWTKeys) > 0 {
+		keySet, err := service.LoadKeySet(cfg.JWTKeys, cfg.JWTActiveKey)
+		if err != nil {
+			log.Fatalf("Error during token keys initialization: %v", err)
+		}
+		authorizer = service.NewKeySetAuthorizer(keySet, []byte(cfg.SecretKey), shortenerLogger)
+	}
+	authorizer.EnableRevocation(storage)
+	authorizer.SetAdminUsers(cfg.AdminUsers)
 	auditService := service.NewShortenerAuditService(shortenerLogger)
 	auditService.ConfigureObservers(cfg)
+	serviceHosts := cfg.ServiceHosts()
 	shortenerHandler := handler.NewShortenerHandler(cfg, shortenerLogger, urlShortener, auditService)
-	shortenerRouter := router.NewRouter(shortenerLogger, authorizer, *shortenerHandler)
+	if cfg.URLPolicyFile != "" {
+		urlPolicy := service.NewURLPolicy(cfg.URLSchemes, serviceHosts, shortenerLogger)
+		if err := urlPolicy.LoadDomainLists(cfg.URLPolicyFile); err != nil {
+			log.Fatalf("Error during url policy initialization: %v", err)
+		}
+		urlPolicy.StartReload(cfg.URLPolicyFile)
+		defer urlPolicy.Close()
+		shortenerHandler.SetURLPolicy(urlPolicy)
+	}
+	if cfg.BotSignaturesFile != "" {
+		botDetector := service.NewBotDetector(shortenerLogger)
+		if err := botDetector.LoadSignatures(cfg.BotSignaturesFile); err != nil {
+			log.Fatalf("Error during bot signatures initialization: %v", err)
+		}
+		botDetector.StartReload(cfg.BotSignaturesFile)
+		defer botDetector.Close()
+		shortenerHandler.SetBotDetector(botDetector)
+	}
+	accountService := service.NewUserAccountService(storage, shortenerLogger)
+	apiKeyService := service.NewUserAPIKeyService(storage, shortenerLogger)
+	var oidcService service.OIDCService
+	if cfg.OIDCIssuer != "" {
+		oidcService = service.NewIssuerOIDCService(cfg, shortenerLogger)
+	}
+	accountHandler := handler.NewAccountHandler(shortenerLogger, accountService, apiKeyService, oidcService, authorizer)
+	adminService := service.NewShortenerAdminService(storage, authorizer, auditService, shortenerLogger)
+	adminHandler := handler.NewAdminHandler(shortenerLogger, adminService)
+	var trustedSubnet *net.IPNet
+	if cfg.TrustedSubnet != "" {
+		_, trustedSubnet, err = net.ParseCIDR(cfg.TrustedSubnet)
+		if err != nil {
+			log.Fatalf("Error during trusted subnet parsing: %v", err)
+		}
+	}
+	var rateLimitStore middleware.RateLimitStore = middleware.NewInMemoryRateLimitStore()
+	if cfg.RateLimitShared {
+		sharedStore, ok := storage.(middleware.RateLimitStore)
+		if !ok {
+			log.Fatalf("Shared rate limits require database storage")
+		}
+		rateLimitStore = sharedStore
+	}
+	rateLimiter := middleware.NewRateLimitMiddleware(rateLimitStore,
+		model.RateLimit{RequestsPerMinute: cfg.RateLimitCreate, Burst: cfg.RateLimitCreateBurst},
+		model.RateLimit{RequestsPerMinute: cfg.RateLimitRedirect, Burst: cfg.RateLimitRedirectBurst},
+		trustedSubnet, shortenerLogger)
+	bodyLimits := middleware.BodyLimits{
+		Default:            cfg.MaxBodySize,
+		Batch:              cfg.MaxBatchBodySize,
+		Decompressed:       cfg.MaxDecompressedSize,
+		DecompressionRatio: cfg.MaxDecompressionRatio,
+	}
+	shortenerRouter := router.NewRouter(shortenerLogger, authorizer, apiKeyService, oidcService, adminService,
+		urlShortener, *shortenerHandler, *accountHandler, *adminHandler, trustedSubnet, serviceHosts, rateLimiter,
+		bodyLimits)
 
 	server := &http.Server{
 		Addr:    cfg.ServerAddr,
@@ -3,6 +3,8 @@ package config
 
 import (
 	"log"
+	"net/url"
+	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -10,15 +12,62 @@ import (
 
 // Config holds all application configuration settings.
 type Config struct {
-	ServerAddr      string `mapstructure:"server_address" json:"server_address"`
-	BaseURL         string `mapstructure:"base_url" json:"base_url"`
-	LogLevel        string `mapstructure:"log_level" json:"log_level"`
-	FileStoragePath string `mapstructure:"file_storage_path" json:"file_storage_path"`
-	DatabaseDSN     string `mapstructure:"database_dsn" json:"database_dsn"`
-	SecretKey       string `mapstructure:"secret_key" json:"secret_key"`
-	AuditFile       string `mapstructure:"audit_file" json:"audit_file"`
-	AuditURL        string `mapstructure:"audit_url" json:"audit_url"`
-	EnableHTTPS     bool   `mapstructure:"enable_https" json:"enable_https"`
+	ServerAddr             string        `mapstructure:"server_address" json:"server_address"`
+	BaseURL                string        `mapstructure:"base_url" json:"base_url"`
+	LogLevel               string        `mapstructure:"log_level" json:"log_level"`
+	FileStoragePath        string        `mapstructure:"file_storage_path" json:"file_storage_path"`
+	DatabaseDSN            string        `mapstructure:"database_dsn" json:"database_dsn"`
+	SecretKey              string        `mapstructure:"secret_key" json:"secret_key"`
+	AuditFile              string        `mapstructure:"audit_file" json:"audit_file"`
+	AuditURL               string        `mapstructure:"audit_url" json:"audit_url"`
+	EnableHTTPS            bool          `mapstructure:"enable_https" json:"enable_https"`
+	MaxURLLength           int           `mapstructure:"max_url_length" json:"max_url_length"`
+	TrashRetention         time.Duration `mapstructure:"trash_retention" json:"trash_retention"`
+	Domains                []string      `mapstructure:"domains" json:"domains"`
+	JWTKeys                []string      `mapstructure:"jwt_keys" json:"jwt_keys"`
+	JWTActiveKey           string        `mapstructure:"jwt_active_key" json:"jwt_active_key"`
+	AdminUsers             []string      `mapstructure:"admin_users" json:"admin_users"`
+	OIDCIssuer             string        `mapstructure:"oidc_issuer" json:"oidc_issuer"`
+	OIDCClientID           string        `mapstructure:"oidc_client_id" json:"oidc_client_id"`
+	OIDCSecret             string        `mapstructure:"oidc_client_secret" json:"oidc_client_secret"`
+	OIDCRedirectURL        string        `mapstructure:"oidc_redirect_url" json:"oidc_redirect_url"`
+	TrustedSubnet          string        `mapstructure:"trusted_subnet" json:"trusted_subnet"`
+	QuotaLinksPerDay       int64         `mapstructure:"quota_links_per_day" json:"quota_links_per_day"`
+	QuotaActiveLinks       int64         `mapstructure:"quota_active_links" json:"quota_active_links"`
+	QuotaBatchSize         int64         `mapstructure:"quota_batch_size" json:"quota_batch_size"`
+	QuotaDeleteBatchSize   int64         `mapstructure:"quota_delete_batch_size" json:"quota_delete_batch_size"`
+	RateLimitCreate        int64         `mapstructure:"rate_limit_create" json:"rate_limit_create"`
+	RateLimitCreateBurst   int64         `mapstructure:"rate_limit_create_burst" json:"rate_limit_create_burst"`
+	RateLimitRedirect      int64         `mapstructure:"rate_limit_redirect" json:"rate_limit_redirect"`
+	RateLimitRedirectBurst int64         `mapstructure:"rate_limit_redirect_burst" json:"rate_limit_redirect_burst"`
+	RateLimitShared        bool          `mapstructure:"rate_limit_shared" json:"rate_limit_shared"`
+	URLSchemes             []string      `mapstructure:"url_schemes" json:"url_schemes"`
+	URLPolicyFile          string        `mapstructure:"url_policy_file" json:"url_policy_file"`
+	PhishingBrands         []string      `mapstructure:"phishing_brands" json:"phishing_brands"`
+	PhishingTLDs           []string      `mapstructure:"phishing_tlds" json:"phishing_tlds"`
+	DropTrackingParams     bool          `mapstructure:"drop_tracking_params" json:"drop_tracking_params"`
+	HealthCheckInterval    time.Duration `mapstructure:"health_check_interval" json:"health_check_interval"`
+	HealthCheckConcurrency int           `mapstructure:"health_check_concurrency" json:"health_check_concurrency"`
+	HealthCheckHostDelay   time.Duration `mapstructure:"health_check_host_delay" json:"health_check_host_delay"`
+	FetchMetadata          bool          `mapstructure:"fetch_metadata" json:"fetch_metadata"`
+	BotSignaturesFile      string        `mapstructure:"bot_signatures_file" json:"bot_signatures_file"`
+	MaxBodySize            int64         `mapstructure:"max_body_size" json:"max_body_size"`
+	MaxBatchBodySize       int64         `mapstructure:"max_batch_body_size" json:"max_batch_body_size"`
+	MaxDecompressedSize    int64         `mapstructure:"max_decompressed_size" json:"max_decompressed_size"`
+	MaxDecompressionRatio  int64         `mapstructure:"max_decompression_ratio" json:"max_decompression_ratio"`
+	LookupGlobal           bool          `mapstructure:"lookup_global" json:"lookup_global"`
+}
+
+// ServiceHosts returns the hosts short URLs are served on: the vanity domains and the base URL host.
+//
+// Returns:
+//   - []string: hosts of the service, with port if the base URL has one
+func (c Config) ServiceHosts() []string {
+	hosts := append([]string(nil), c.Domains...)
+	if baseURL, err := url.Parse(c.BaseURL); err == nil && baseURL.Host != "" {
+		hosts = append(hosts, baseURL.Host)
+	}
+	return hosts
 }
 
 // AppConfig is the global application configuration instance.
@@ -49,6 +98,41 @@ func ParseConfig() {
 		pflag.String("audit-file", "", "path to audit file")
 		pflag.String("audit-url", "", "audit url")
 		pflag.BoolP("s", "s", false, "enable https")
+		pflag.Int("max-url-length", 0, "maximum length of original url (0 means default)")
+		pflag.Duration("trash-retention", 0, "how long deleted urls are kept before purging (0 keeps forever)")
+		pflag.StringSlice("domains", nil, "additional vanity domains served next to base url host")
+		pflag.StringSlice("jwt-keys", nil, "token signing and verification key files in kid=path format")
+		pflag.String("jwt-active-key", "", "kid of the key signing new tokens")
+		pflag.StringSlice("admin-users", nil, "user ids issued tokens with the admin role")
+		pflag.String("oidc-issuer", "", "openid connect issuer url, empty disables oidc login")
+		pflag.String("oidc-client-id", "", "openid connect client id")
+		pflag.String("oidc-client-secret", "", "openid connect client secret")
+		pflag.String("oidc-redirect-url", "", "openid connect callback url")
+		pflag.StringP("t", "t", "", "trusted subnet in CIDR notation of internal stats clients and X-Real-IP proxies")
+		pflag.Int64("quota-links-per-day", 0, "links a user may create per UTC day (0 means unlimited)")
+		pflag.Int64("quota-active-links", 0, "links of a user that are not deleted (0 means unlimited)")
+		pflag.Int64("quota-batch-size", 0, "links a single batch request may create (0 means unlimited)")
+		pflag.Int64("quota-delete-batch-size", 0, "links a single batch request may delete (0 means unlimited)")
+		pflag.Int64("rate-limit-create", 0, "requests per minute creating links per user and client ip (0 means unlimited)")
+		pflag.Int64("rate-limit-create-burst", 0, "burst of requests creating links (0 means the per minute limit)")
+		pflag.Int64("rate-limit-redirect", 0, "redirects per minute per client ip (0 means unlimited)")
+		pflag.Int64("rate-limit-redirect-burst", 0, "burst of redirects (0 means the per minute limit)")
+		pflag.Bool("rate-limit-shared", false, "keep rate limits in the database shared by all replicas")
+		pflag.StringSlice("url-schemes", nil, "schemes allowed in shortened urls (empty means http and https)")
+		pflag.String("url-policy-file", "", "path to json file with domain allow and deny lists, reloaded on change")
+		pflag.StringSlice("phishing-brands", nil, "brand names whose look-alike domains put links under review")
+		pflag.StringSlice("phishing-tlds", nil, "top-level domains that put links under review (empty means defaults)")
+		pflag.Bool("drop-tracking-params", false, "ignore utm_* and click id query parameters when deduplicating urls")
+		pflag.Duration("health-check-interval", 0, "how often link destinations are checked (0 disables checks)")
+		pflag.Int("health-check-concurrency", 0, "maximum number of link destinations checked at once (0 means default)")
+		pflag.Duration("health-check-host-delay", 0, "minimum time between health checks of one host (0 means default)")
+		pflag.Bool("fetch-metadata", false, "fetch title, description and preview image of new link destinations")
+		pflag.String("bot-signatures-file", "", "path to JSON file with bot user agent signatures, reloaded on change")
+		pflag.Int64("max-body-size", 0, "maximum request body size in bytes (0 means default)")
+		pflag.Int64("max-batch-body-size", 0, "maximum request body size in bytes of batch routes (0 means default)")
+		pflag.Int64("max-decompressed-size", 0, "maximum decompressed size in bytes of gzip request bodies (0 means default)")
+		pflag.Int64("max-decompression-ratio", 0, "maximum decompression ratio of gzip request bodies (0 means default)")
+		pflag.Bool("lookup-global", false, "find links of all users by original url, not only links of the caller")
 		pflag.StringP("config", "c", "", "path to config file")
 	}
 
@@ -66,6 +150,41 @@ func ParseConfig() {
 	bindFlag("audit_file", "audit-file")
 	bindFlag("audit_url", "audit-url")
 	bindFlag("enable_https", "s")
+	bindFlag("max_url_length", "max-url-length")
+	bindFlag("trash_retention", "trash-retention")
+	bindFlag("domains", "domains")
+	bindFlag("jwt_keys", "jwt-keys")
+	bindFlag("jwt_active_key", "jwt-active-key")
+	bindFlag("admin_users", "admin-users")
+	bindFlag("oidc_issuer", "oidc-issuer")
+	bindFlag("oidc_client_id", "oidc-client-id")
+	bindFlag("oidc_client_secret", "oidc-client-secret")
+	bindFlag("oidc_redirect_url", "oidc-redirect-url")
+	bindFlag("trusted_subnet", "t")
+	bindFlag("quota_links_per_day", "quota-links-per-day")
+	bindFlag("quota_active_links", "quota-active-links")
+	bindFlag("quota_batch_size", "quota-batch-size")
+	bindFlag("quota_delete_batch_size", "quota-delete-batch-size")
+	bindFlag("rate_limit_create", "rate-limit-create")
+	bindFlag("rate_limit_create_burst", "rate-limit-create-burst")
+	bindFlag("rate_limit_redirect", "rate-limit-redirect")
+	bindFlag("rate_limit_redirect_burst", "rate-limit-redirect-burst")
+	bindFlag("rate_limit_shared", "rate-limit-shared")
+	bindFlag("url_schemes", "url-schemes")
+	bindFlag("url_policy_file", "url-policy-file")
+	bindFlag("phishing_brands", "phishing-brands")
+	bindFlag("phishing_tlds", "phishing-tlds")
+	bindFlag("drop_tracking_params", "drop-tracking-params")
+	bindFlag("health_check_interval", "health-check-interval")
+	bindFlag("health_check_concurrency", "health-check-concurrency")
+	bindFlag("health_check_host_delay", "health-check-host-delay")
+	bindFlag("fetch_metadata", "fetch-metadata")
+	bindFlag("bot_signatures_file", "bot-signatures-file")
+	bindFlag("max_body_size", "max-body-size")
+	bindFlag("max_batch_body_size", "max-batch-body-size")
+	bindFlag("max_decompressed_size", "max-decompressed-size")
+	bindFlag("max_decompression_ratio", "max-decompression-ratio")
+	bindFlag("lookup_global", "lookup-global")
 
 	// 4. Bind Environment Variables
 	viper.AutomaticEnv()
@@ -78,6 +197,41 @@ func ParseConfig() {
 	bindEnv("audit_file", "AUDIT_FILE")
 	bindEnv("audit_url", "AUDIT_URL")
 	bindEnv("enable_https", "ENABLE_HTTPS")
+	bindEnv("max_url_length", "MAX_URL_LENGTH")
+	bindEnv("trash_retention", "TRASH_RETENTION")
+	bindEnv("domains", "DOMAINS")
+	bindEnv("jwt_keys", "JWT_KEYS")
+	bindEnv("jwt_active_key", "JWT_ACTIVE_KEY")
+	bindEnv("admin_users", "ADMIN_USERS")
+	bindEnv("oidc_issuer", "OIDC_ISSUER")
+	bindEnv("oidc_client_id", "OIDC_CLIENT_ID")
+	bindEnv("oidc_client_secret", "OIDC_CLIENT_SECRET")
+	bindEnv("oidc_redirect_url", "OIDC_REDIRECT_URL")
+	bindEnv("trusted_subnet", "TRUSTED_SUBNET")
+	bindEnv("quota_links_per_day", "QUOTA_LINKS_PER_DAY")
+	bindEnv("quota_active_links", "QUOTA_ACTIVE_LINKS")
+	bindEnv("quota_batch_size", "QUOTA_BATCH_SIZE")
+	bindEnv("quota_delete_batch_size", "QUOTA_DELETE_BATCH_SIZE")
+	bindEnv("rate_limit_create", "RATE_LIMIT_CREATE")
+	bindEnv("rate_limit_create_burst", "RATE_LIMIT_CREATE_BURST")
+	bindEnv("rate_limit_redirect", "RATE_LIMIT_REDIRECT")
+	bindEnv("rate_limit_redirect_burst", "RATE_LIMIT_REDIRECT_BURST")
+	bindEnv("rate_limit_shared", "RATE_LIMIT_SHARED")
+	bindEnv("url_schemes", "URL_SCHEMES")
+	bindEnv("url_policy_file", "URL_POLICY_FILE")
+	bindEnv("phishing_brands", "PHISHING_BRANDS")
+	bindEnv("phishing_tlds", "PHISHING_TLDS")
+	bindEnv("drop_tracking_params", "DROP_TRACKING_PARAMS")
+	bindEnv("health_check_interval", "HEALTH_CHECK_INTERVAL")
+	bindEnv("health_check_concurrency", "HEALTH_CHECK_CONCURRENCY")
+	bindEnv("health_check_host_delay", "HEALTH_CHECK_HOST_DELAY")
+	bindEnv("fetch_metadata", "FETCH_METADATA")
+	bindEnv("bot_signatures_file", "BOT_SIGNATURES_FILE")
+	bindEnv("max_body_size", "MAX_BODY_SIZE")
+	bindEnv("max_batch_body_size", "MAX_BATCH_BODY_SIZE")
+	bindEnv("max_decompressed_size", "MAX_DECOMPRESSED_SIZE")
+	bindEnv("max_decompression_ratio", "MAX_DECOMPRESSION_RATIO")
+	bindEnv("lookup_global", "LOOKUP_GLOBAL")
 	bindEnv("config", "CONFIG")
 
 	// 5. Load Config File
@@ -97,6 +251,25 @@ func ParseConfig() {
 	if err := viper.Unmarshal(&AppConfig); err != nil {
 		log.Fatalf("Unable to decode into struct: %v", err)
 	}
+	// Unset slice flags are decoded as empty slices, keep "not configured" as nil
+	if len(AppConfig.Domains) == 0 {
+		AppConfig.Domains = nil
+	}
+	if len(AppConfig.JWTKeys) == 0 {
+		AppConfig.JWTKeys = nil
+	}
+	if len(AppConfig.AdminUsers) == 0 {
+		AppConfig.AdminUsers = nil
+	}
+	if len(AppConfig.URLSchemes) == 0 {
+		AppConfig.URLSchemes = nil
+	}
+	if len(AppConfig.PhishingBrands) == 0 {
+		AppConfig.PhishingBrands = nil
+	}
+	if len(AppConfig.PhishingTLDs) == 0 {
+		AppConfig.PhishingTLDs = nil
+	}
 }
 
 func bindFlag(key, flagName string) {
@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -392,6 +394,327 @@ func TestParseFlags(t *testing.T) {
 				EnableHTTPS:     false,
 			},
 		},
+		{
+			name: "Env for max url length",
+			args: []string{"shortener.exe"},
+			env: map[string]string{
+				"MAX_URL_LENGTH": "4096",
+			},
+			expectedConfig: Config{
+				ServerAddr:   "localhost:8080",
+				BaseURL:      "http://localhost:8080",
+				LogLevel:     "info",
+				MaxURLLength: 4096,
+			},
+		},
+		{
+			name: "Flag for max url length",
+			args: []string{"shortener.exe", "--max-url-length=2048"},
+			env:  map[string]string{},
+			expectedConfig: Config{
+				ServerAddr:   "localhost:8080",
+				BaseURL:      "http://localhost:8080",
+				LogLevel:     "info",
+				MaxURLLength: 2048,
+			},
+		},
+		{
+			name: "Env for trash retention",
+			args: []string{"shortener.exe"},
+			env: map[string]string{
+				"TRASH_RETENTION": "720h",
+			},
+			expectedConfig: Config{
+				ServerAddr:     "localhost:8080",
+				BaseURL:        "http://localhost:8080",
+				LogLevel:       "info",
+				TrashRetention: 720 * time.Hour,
+			},
+		},
+		{
+			name: "Flag for trash retention",
+			args: []string{"shortener.exe", "--trash-retention=24h"},
+			env:  map[string]string{},
+			expectedConfig: Config{
+				ServerAddr:     "localhost:8080",
+				BaseURL:        "http://localhost:8080",
+				LogLevel:       "info",
+				TrashRetention: 24 * time.Hour,
+			},
+		},
+		{
+			name: "Env for domains",
+			args: []string{"shortener.exe"},
+			env: map[string]string{
+				"DOMAINS": "go.brand.com,brand.link",
+			},
+			expectedConfig: Config{
+				ServerAddr: "localhost:8080",
+				BaseURL:    "http://localhost:8080",
+				LogLevel:   "info",
+				Domains:    []string{"go.brand.com", "brand.link"},
+			},
+		},
+		{
+			name: "Flag for domains",
+			args: []string{"shortener.exe", "--domains=go.brand.com", "--domains=brand.link"},
+			env:  map[string]string{},
+			expectedConfig: Config{
+				ServerAddr: "localhost:8080",
+				BaseURL:    "http://localhost:8080",
+				LogLevel:   "info",
+				Domains:    []string{"go.brand.com", "brand.link"},
+			},
+		},
+		{
+			name: "Env for jwt keys",
+			args: []string{"shortener.exe"},
+			env: map[string]string{
+				"JWT_KEYS":       "2026-09=/keys/old.pem,2026-10=/keys/new.pem",
+				"JWT_ACTIVE_KEY": "2026-10",
+			},
+			expectedConfig: Config{
+				ServerAddr:   "localhost:8080",
+				BaseURL:      "http://localhost:8080",
+				LogLevel:     "info",
+				JWTKeys:      []string{"2026-09=/keys/old.pem", "2026-10=/keys/new.pem"},
+				JWTActiveKey: "2026-10",
+			},
+		},
+		{
+			name: "Flag for jwt keys",
+			args: []string{"shortener.exe", "--jwt-keys=2026-10=/keys/new.pem", "--jwt-active-key=2026-10"},
+			env:  map[string]string{},
+			expectedConfig: Config{
+				ServerAddr:   "localhost:8080",
+				BaseURL:      "http://localhost:8080",
+				LogLevel:     "info",
+				JWTKeys:      []string{"2026-10=/keys/new.pem"},
+				JWTActiveKey: "2026-10",
+			},
+		},
+		{
+			name: "Env for admin users",
+			args: []string{"shortener.exe"},
+			env:  map[string]string{"ADMIN_USERS": "admin-1,admin-2"},
+			expectedConfig: Config{
+				ServerAddr: "localhost:8080",
+				BaseURL:    "http://localhost:8080",
+				LogLevel:   "info",
+				AdminUsers: []string{"admin-1", "admin-2"},
+			},
+		},
+		{
+			name: "Flag for admin users",
+			args: []string{"shortener.exe", "--admin-users=admin-1"},
+			env:  map[string]string{},
+			expectedConfig: Config{
+				ServerAddr: "localhost:8080",
+				BaseURL:    "http://localhost:8080",
+				LogLevel:   "info",
+				AdminUsers: []string{"admin-1"},
+			},
+		},
+		{
+			name: "Env for oidc",
+			args: []string{"shortener.exe"},
+			env: map[string]string{
+				"OIDC_ISSUER":        "https://accounts.example.com",
+				"OIDC_CLIENT_ID":     "shortener",
+				"OIDC_CLIENT_SECRET": "client-secret",
+				"OIDC_REDIRECT_URL":  "https://short.example.com/api/auth/oidc/callback",
+			},
+			expectedConfig: Config{
+				ServerAddr:      "localhost:8080",
+				BaseURL:         "http://localhost:8080",
+				LogLevel:        "info",
+				OIDCIssuer:      "https://accounts.example.com",
+				OIDCClientID:    "shortener",
+				OIDCSecret:      "client-secret",
+				OIDCRedirectURL: "https://short.example.com/api/auth/oidc/callback",
+			},
+		},
+		{
+			name: "Flag for oidc",
+			args: []string{"shortener.exe", "--oidc-issuer=https://accounts.example.com", "--oidc-client-id=shortener"},
+			env:  map[string]string{},
+			expectedConfig: Config{
+				ServerAddr:   "localhost:8080",
+				BaseURL:      "http://localhost:8080",
+				LogLevel:     "info",
+				OIDCIssuer:   "https://accounts.example.com",
+				OIDCClientID: "shortener",
+			},
+		},
+		{
+			name: "Env for trusted subnet",
+			args: []string{"shortener.exe"},
+			env:  map[string]string{"TRUSTED_SUBNET": "10.0.0.0/8"},
+			expectedConfig: Config{
+				ServerAddr:    "localhost:8080",
+				BaseURL:       "http://localhost:8080",
+				LogLevel:      "info",
+				TrustedSubnet: "10.0.0.0/8",
+			},
+		},
+		{
+			name: "Flag for trusted subnet",
+			args: []string{"shortener.exe", "-t", "192.168.1.0/24"},
+			env:  map[string]string{},
+			expectedConfig: Config{
+				ServerAddr:    "localhost:8080",
+				BaseURL:       "http://localhost:8080",
+				LogLevel:      "info",
+				TrustedSubnet: "192.168.1.0/24",
+			},
+		},
+		{
+			name: "Env for quotas",
+			args: []string{"shortener.exe"},
+			env:  map[string]string{"QUOTA_LINKS_PER_DAY": "100", "QUOTA_ACTIVE_LINKS": "1000"},
+			expectedConfig: Config{
+				ServerAddr:       "localhost:8080",
+				BaseURL:          "http://localhost:8080",
+				LogLevel:         "info",
+				QuotaLinksPerDay: 100,
+				QuotaActiveLinks: 1000,
+			},
+		},
+		{
+			name: "Flags for batch quotas",
+			args: []string{"shortener.exe", "--quota-batch-size", "50", "--quota-delete-batch-size", "20"},
+			env:  map[string]string{},
+			expectedConfig: Config{
+				ServerAddr:           "localhost:8080",
+				BaseURL:              "http://localhost:8080",
+				LogLevel:             "info",
+				QuotaBatchSize:       50,
+				QuotaDeleteBatchSize: 20,
+			},
+		},
+		{
+			name: "Flags for rate limits",
+			args: []string{"shortener.exe", "--rate-limit-create", "30", "--rate-limit-create-burst", "5",
+				"--rate-limit-shared"},
+			env: map[string]string{},
+			expectedConfig: Config{
+				ServerAddr:           "localhost:8080",
+				BaseURL:              "http://localhost:8080",
+				LogLevel:             "info",
+				RateLimitCreate:      30,
+				RateLimitCreateBurst: 5,
+				RateLimitShared:      true,
+			},
+		},
+		{
+			name: "Env for redirect rate limits",
+			args: []string{"shortener.exe"},
+			env:  map[string]string{"RATE_LIMIT_REDIRECT": "600", "RATE_LIMIT_REDIRECT_BURST": "100"},
+			expectedConfig: Config{
+				ServerAddr:             "localhost:8080",
+				BaseURL:                "http://localhost:8080",
+				LogLevel:               "info",
+				RateLimitRedirect:      600,
+				RateLimitRedirectBurst: 100,
+			},
+		},
+		{
+			name: "Flags for url policy",
+			args: []string{"shortener.exe", "--url-schemes=https", "--url-schemes=ftp",
+				"--url-policy-file", "/etc/policy.json"},
+			env: map[string]string{},
+			expectedConfig: Config{
+				ServerAddr:    "localhost:8080",
+				BaseURL:       "http://localhost:8080",
+				LogLevel:      "info",
+				URLSchemes:    []string{"https", "ftp"},
+				URLPolicyFile: "/etc/policy.json",
+			},
+		},
+		{
+			name: "Flags for phishing checks",
+			args: []string{"shortener.exe", "--phishing-brands=shortener,paypal", "--phishing-tlds=zip"},
+			env:  map[string]string{},
+			expectedConfig: Config{
+				ServerAddr:     "localhost:8080",
+				BaseURL:        "http://localhost:8080",
+				LogLevel:       "info",
+				PhishingBrands: []string{"shortener", "paypal"},
+				PhishingTLDs:   []string{"zip"},
+			},
+		},
+		{
+			name: "Env for dropping tracking params",
+			args: []string{"shortener.exe"},
+			env:  map[string]string{"DROP_TRACKING_PARAMS": "true"},
+			expectedConfig: Config{
+				ServerAddr:         "localhost:8080",
+				BaseURL:            "http://localhost:8080",
+				LogLevel:           "info",
+				DropTrackingParams: true,
+			},
+		},
+		{
+			name: "Flags for health checks",
+			args: []string{"shortener.exe", "--health-check-interval", "6h", "--health-check-concurrency", "8",
+				"--health-check-host-delay", "500ms"},
+			env: map[string]string{},
+			expectedConfig: Config{
+				ServerAddr:             "localhost:8080",
+				BaseURL:                "http://localhost:8080",
+				LogLevel:               "info",
+				HealthCheckInterval:    6 * time.Hour,
+				HealthCheckConcurrency: 8,
+				HealthCheckHostDelay:   500 * time.Millisecond,
+			},
+		},
+		{
+			name: "Env for metadata fetches",
+			args: []string{"shortener.exe"},
+			env:  map[string]string{"FETCH_METADATA": "true"},
+			expectedConfig: Config{
+				ServerAddr:    "localhost:8080",
+				BaseURL:       "http://localhost:8080",
+				LogLevel:      "info",
+				FetchMetadata: true,
+			},
+		},
+		{
+			name: "Flag for bot signatures file",
+			args: []string{"shortener.exe", "--bot-signatures-file", "/etc/bots.json"},
+			env:  map[string]string{},
+			expectedConfig: Config{
+				ServerAddr:        "localhost:8080",
+				BaseURL:           "http://localhost:8080",
+				LogLevel:          "info",
+				BotSignaturesFile: "/etc/bots.json",
+			},
+		},
+		{
+			name: "Flags and env for request body limits",
+			args: []string{"shortener.exe", "--max-body-size", "65536", "--max-decompression-ratio", "50"},
+			env:  map[string]string{"MAX_BATCH_BODY_SIZE": "4194304", "MAX_DECOMPRESSED_SIZE": "8388608"},
+			expectedConfig: Config{
+				ServerAddr:            "localhost:8080",
+				BaseURL:               "http://localhost:8080",
+				LogLevel:              "info",
+				MaxBodySize:           65536,
+				MaxBatchBodySize:      4194304,
+				MaxDecompressedSize:   8388608,
+				MaxDecompressionRatio: 50,
+			},
+		},
+		{
+			name: "Env for global lookup",
+			args: []string{"shortener.exe"},
+			env:  map[string]string{"LOOKUP_GLOBAL": "true"},
+			expectedConfig: Config{
+				ServerAddr:   "localhost:8080",
+				BaseURL:      "http://localhost:8080",
+				LogLevel:     "info",
+				LookupGlobal: true,
+			},
+		},
 		{
 			name: "Config file only (via -c)",
 			args: []string{"shortener.exe"}, // flag added dynamically
@@ -575,9 +898,30 @@ func TestParseFlags(t *testing.T) {
 
 			ParseConfig()
 
-			if AppConfig != tt.expectedConfig {
+			if !reflect.DeepEqual(AppConfig, tt.expectedConfig) {
 				t.Errorf("Mismatch in %s:\nExpected: %+v\nGot:      %+v", tt.name, tt.expectedConfig, AppConfig)
 			}
 		})
 	}
 }
+
+func TestConfig_ServiceHosts(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		expected []string
+	}{
+		{name: "base url only", cfg: Config{BaseURL: "http://localhost:8080"}, expected: []string{"localhost:8080"}},
+		{name: "vanity domains", cfg: Config{BaseURL: "https://short.example.com/s", Domains: []string{"go.brand.com"}},
+			expected: []string{"go.brand.com", "short.example.com"}},
+		{name: "invalid base url", cfg: Config{BaseURL: "://", Domains: []string{"go.brand.com"}},
+			expected: []string{"go.brand.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.ServiceHosts(); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected hosts %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
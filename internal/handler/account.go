@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/middleware"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/repository"
+	"github.com/bezjen/shortener/internal/service"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// AccountHandler handles HTTP requests for registered account, OIDC login and API key operations.
+type AccountHandler struct {
+	logger         *logger.Logger
+	accountService service.AccountService
+	apiKeyService  service.APIKeyService
+	oidcService    service.OIDCService
+	authorizer     service.Authorizer
+}
+
+// NewAccountHandler creates a new instance of AccountHandler.
+//
+// Parameters:
+//   - logger: logger instance for application logging
+//   - accountService: registered account service implementation
+//   - apiKeyService: API key service implementation
+//   - oidcService: OIDC login service implementation, nil disables OIDC login
+//   - authorizer: JWT authorizer issuing tokens for accounts
+//
+// Returns:
+//   - *AccountHandler: initialized HTTP handler
+func NewAccountHandler(
+	logger *logger.Logger,
+	accountService service.AccountService,
+	apiKeyService service.APIKeyService,
+	oidcService service.OIDCService,
+	authorizer service.Authorizer,
+) *AccountHandler {
+	return &AccountHandler{
+		logger:         logger,
+		accountService: accountService,
+		apiKeyService:  apiKeyService,
+		oidcService:    oidcService,
+		authorizer:     authorizer,
+	}
+}
+
+// HandleRegisterJSON handles POST requests to register an account.
+// Links created by the current anonymous user are moved to the new account.
+// Only users of shortener tokens may be anonymous, users of issuer ID tokens and API keys keep their links.
+// The token of the account replaces the anonymous token in the Authorization header and cookie.
+//
+// Request format:
+//
+//	{"login": "alice", "password": "correct horse battery staple"}
+//
+// Responses:
+//   - 201 Created: Account registered
+//   - 400 Bad Request: Invalid JSON, empty login or too short password
+//   - 413 Request Entity Too Large: Request body exceeds the size limit
+//   - 409 Conflict: Login is already taken
+//   - 500 Internal Server Error: Internal server error
+//
+// Example response:
+//
+//	HTTP/1.1 201 Created
+//	Content-Type: application/json
+//	Authorization: eyJhbGciOiJIUzI1NiIs...
+//
+//	{"user_id": "123e4567-e89b-12d3-a456-426614174000"}
+func (h *AccountHandler) HandleRegisterJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+
+	defer r.Body.Close()
+	var request model.CredentialsJSONRequest
+	if err := decodeJSONBody(r, &request); err != nil {
+		statusCode, message := jsonBodyError(err)
+		h.writeErrorResponse(rw, statusCode, message)
+		return
+	}
+
+	user, err := h.accountService.Register(r.Context(), anonymousUserID(r), request.Login, request.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrEmptyLogin), errors.Is(err, service.ErrPasswordTooShort):
+			h.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+		case errors.Is(err, repository.ErrLoginConflict):
+			h.writeErrorResponse(rw, http.StatusConflict, "login is already taken")
+		default:
+			h.logger.Error("Failed to register user", zap.Error(err))
+			h.writeErrorResponse(rw, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		}
+		return
+	}
+
+	h.writeAccountResponse(rw, http.StatusCreated, user)
+}
+
+// HandleLoginJSON handles POST requests to sign in to an account.
+// Links created by the current anonymous user are moved to the account.
+// Only users of shortener tokens may be anonymous, users of issuer ID tokens and API keys keep their links.
+// The token of the account replaces the anonymous token in the Authorization header and cookie.
+//
+// Request format:
+//
+//	{"login": "alice", "password": "correct horse battery staple"}
+//
+// Responses:
+//   - 200 OK: Signed in
+//   - 400 Bad Request: Invalid JSON
+//   - 413 Request Entity Too Large: Request body exceeds the size limit
+//   - 401 Unauthorized: Unknown login or wrong password
+//   - 500 Internal Server Error: Internal server error
+//
+// Example response:
+//
+//	HTTP/1.1 200 OK
+//	Content-Type: application/json
+//	Authorization: eyJhbGciOiJIUzI1NiIs...
+//
+//	{"user_id": "123e4567-e89b-12d3-a456-426614174000"}
+func (h *AccountHandler) HandleLoginJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+
+	defer r.Body.Close()
+	var request model.CredentialsJSONRequest
+	if err := decodeJSONBody(r, &request); err != nil {
+		statusCode, message := jsonBodyError(err)
+		h.writeErrorResponse(rw, statusCode, message)
+		return
+	}
+
+	user, err := h.accountService.Login(r.Context(), anonymousUserID(r), request.Login, request.Password)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			h.writeErrorResponse(rw, http.StatusUnauthorized, err.Error())
+			return
+		}
+		h.logger.Error("Failed to log in user", zap.Error(err))
+		h.writeErrorResponse(rw, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	h.writeAccountResponse(rw, http.StatusOK, user)
+}
+
+// HandleLogout handles POST requests to log out.
+// The token the request was authenticated with is revoked and the authentication cookie is deleted.
+// Other tokens of the user, e.g. on other devices, stay valid.
+//
+// Responses:
+//   - 204 No Content: Logged out
+//   - 400 Bad Request: Request is authenticated with an API key
+//   - 500 Internal Server Error: Internal server error
+func (h *AccountHandler) HandleLogout(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	if middleware.APIKeyFromContext(r.Context()) != nil {
+		h.writeErrorResponse(rw, http.StatusBadRequest, "api keys cannot log out, revoke the key instead")
+		return
+	}
+
+	if token := middleware.TokenFromContext(r.Context()); token != "" {
+		if err := h.authorizer.RevokeToken(r.Context(), token); err != nil {
+			h.logger.Error("Failed to revoke user token", zap.Error(err), zap.String("userID", getUserIDFromContext(r)))
+			h.writeErrorResponse(rw, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+	}
+
+	middleware.ClearAuthToken(rw)
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// HandleGetJWKS handles GET requests for the public keys verifying user tokens.
+// Other services use the keys to validate tokens issued by the shortener.
+// The key set is empty when tokens are signed with the HMAC secret key only.
+//
+// Responses:
+//   - 200 OK: Key set returned
+//
+// Example response:
+//
+//	HTTP/1.1 200 OK
+//	Content-Type: application/json
+//	Cache-Control: public, max-age=300
+//
+//	{"keys": [{"kty": "OKP", "kid": "2026-10", "use": "sig", "alg": "EdDSA", "crv": "Ed25519", "x": "11qY..."}]}
+func (h *AccountHandler) HandleGetJWKS(rw http.ResponseWriter, _ *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Cache-Control", "public, max-age=300")
+	h.writeJSONResponse(rw, http.StatusOK, h.authorizer.JWKS())
+}
+
+func (h *AccountHandler) writeAccountResponse(rw http.ResponseWriter, statusCode int, user *model.User) {
+	token, err := h.authorizer.CreateToken(user.ID)
+	if err != nil {
+		h.logger.Error("Failed to create user token", zap.Error(err), zap.String("userID", user.ID))
+		h.writeErrorResponse(rw, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	middleware.SetAuthToken(rw, token)
+	h.writeJSONResponse(rw, statusCode, model.AccountJSONResponse{UserID: user.ID})
+}
+
+func (h *AccountHandler) writeErrorResponse(rw http.ResponseWriter, statusCode int, error string) {
+	h.writeJSONResponse(rw, statusCode, model.ShortenJSONResponse{Error: error})
+}
+
+func (h *AccountHandler) writeJSONResponse(rw http.ResponseWriter, statusCode int, response interface{}) {
+	rw.WriteHeader(statusCode)
+	err := json.NewEncoder(rw).Encode(response)
+	if err != nil {
+		h.logger.Error("Failed to encode response", zap.Error(err))
+	}
+}
+
+// anonymousUserID returns the user of the request if its links may be claimed by an account.
+// Anonymous users are created by the shortener and carry shortener tokens, so users of issuer ID tokens
+// and API keys are never treated as anonymous even though they have no registered account.
+func anonymousUserID(r *http.Request) string {
+	switch middleware.AuthMethodFromContext(r.Context()) {
+	case middleware.AuthMethodCookie, middleware.AuthMethodHeader, middleware.AuthMethodNewUser:
+		return getUserIDFromContext(r)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,311 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/middleware"
+	"github.com/bezjen/shortener/internal/mocks"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/repository"
+	"github.com/bezjen/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleRegisterJSON(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+
+	tests := []struct {
+		name          string
+		body          string
+		mockSetup     func(*mocks.AccountService, *mocks.Authorizer)
+		expectedCode  int
+		expectedBody  string
+		expectedToken string
+	}{
+		{
+			name: "Successful registration",
+			body: `{"login":"alice","password":"secret-password"}`,
+			mockSetup: func(a *mocks.AccountService, auth *mocks.Authorizer) {
+				a.On("Register", mock.Anything, "anonymous-user", "alice", "secret-password").
+					Return(model.NewUser("account-1", "alice", "hash"), nil)
+				auth.On("CreateToken", "account-1").Return("account-token", nil)
+			},
+			expectedCode:  http.StatusCreated,
+			expectedBody:  `{"user_id":"account-1"}` + "\n",
+			expectedToken: "account-token",
+		},
+		{
+			name:         "Invalid JSON",
+			body:         `invalid json`,
+			mockSetup:    func(a *mocks.AccountService, auth *mocks.Authorizer) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"incorrect json"}` + "\n",
+		},
+		{
+			name: "Short password",
+			body: `{"login":"alice","password":"short"}`,
+			mockSetup: func(a *mocks.AccountService, auth *mocks.Authorizer) {
+				a.On("Register", mock.Anything, "anonymous-user", "alice", "short").
+					Return(nil, service.ErrPasswordTooShort)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"password must be at least 8 characters long"}` + "\n",
+		},
+		{
+			name: "Login taken",
+			body: `{"login":"alice","password":"secret-password"}`,
+			mockSetup: func(a *mocks.AccountService, auth *mocks.Authorizer) {
+				a.On("Register", mock.Anything, "anonymous-user", "alice", "secret-password").
+					Return(nil, repository.ErrLoginConflict)
+			},
+			expectedCode: http.StatusConflict,
+			expectedBody: `{"error":"login is already taken"}` + "\n",
+		},
+		{
+			name: "Storage error",
+			body: `{"login":"alice","password":"secret-password"}`,
+			mockSetup: func(a *mocks.AccountService, auth *mocks.Authorizer) {
+				a.On("Register", mock.Anything, "anonymous-user", "alice", "secret-password").
+					Return(nil, errors.New("db error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"error":"Internal Server Error"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAccounts := new(mocks.AccountService)
+			mockAuthorizer := new(mocks.Authorizer)
+			tt.mockSetup(mockAccounts, mockAuthorizer)
+			h := NewAccountHandler(testLogger, mockAccounts, nil, nil, mockAuthorizer)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/user/register", bytes.NewBufferString(tt.body))
+			ctx := context.WithValue(req.Context(), middleware.UserIDKey, "anonymous-user")
+			req = req.WithContext(context.WithValue(ctx, middleware.AuthMethodKey, middleware.AuthMethodCookie))
+			rr := httptest.NewRecorder()
+			middleware.SetAuthToken(rr, "anonymous-token")
+
+			h.HandleRegisterJSON(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+			if tt.expectedToken != "" {
+				assert.Equal(t, tt.expectedToken, rr.Header().Get("Authorization"))
+				cookies := rr.Result().Cookies()
+				assert.Len(t, cookies, 1)
+				assert.Equal(t, tt.expectedToken, cookies[0].Value)
+			}
+			mockAccounts.AssertExpectations(t)
+			mockAuthorizer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleLoginJSON(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+
+	tests := []struct {
+		name         string
+		body         string
+		mockSetup    func(*mocks.AccountService, *mocks.Authorizer)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name: "Successful login",
+			body: `{"login":"alice","password":"secret-password"}`,
+			mockSetup: func(a *mocks.AccountService, auth *mocks.Authorizer) {
+				a.On("Login", mock.Anything, "anonymous-user", "alice", "secret-password").
+					Return(model.NewUser("account-1", "alice", "hash"), nil)
+				auth.On("CreateToken", "account-1").Return("account-token", nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"user_id":"account-1"}` + "\n",
+		},
+		{
+			name: "Wrong password",
+			body: `{"login":"alice","password":"wrong-password"}`,
+			mockSetup: func(a *mocks.AccountService, auth *mocks.Authorizer) {
+				a.On("Login", mock.Anything, "anonymous-user", "alice", "wrong-password").
+					Return(nil, service.ErrInvalidCredentials)
+			},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `{"error":"invalid login or password"}` + "\n",
+		},
+		{
+			name: "Token error",
+			body: `{"login":"alice","password":"secret-password"}`,
+			mockSetup: func(a *mocks.AccountService, auth *mocks.Authorizer) {
+				a.On("Login", mock.Anything, "anonymous-user", "alice", "secret-password").
+					Return(model.NewUser("account-1", "alice", "hash"), nil)
+				auth.On("CreateToken", "account-1").Return("", service.ErrSign)
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"error":"Internal Server Error"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAccounts := new(mocks.AccountService)
+			mockAuthorizer := new(mocks.Authorizer)
+			tt.mockSetup(mockAccounts, mockAuthorizer)
+			h := NewAccountHandler(testLogger, mockAccounts, nil, nil, mockAuthorizer)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/user/login", bytes.NewBufferString(tt.body))
+			ctx := context.WithValue(req.Context(), middleware.UserIDKey, "anonymous-user")
+			req = req.WithContext(context.WithValue(ctx, middleware.AuthMethodKey, middleware.AuthMethodCookie))
+			rr := httptest.NewRecorder()
+
+			h.HandleLoginJSON(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+			mockAccounts.AssertExpectations(t)
+			mockAuthorizer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleLoginJSON_ClaimsAnonymousUsersOnly(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+
+	tests := []struct {
+		name          string
+		authMethod    middleware.AuthMethod
+		claimedUserID string
+	}{
+		{name: "Cookie", authMethod: middleware.AuthMethodCookie, claimedUserID: "current-user"},
+		{name: "Authorization header", authMethod: middleware.AuthMethodHeader, claimedUserID: "current-user"},
+		{name: "New user", authMethod: middleware.AuthMethodNewUser, claimedUserID: "current-user"},
+		{name: "Issuer ID token", authMethod: middleware.AuthMethodIssuer, claimedUserID: ""},
+		{name: "API key", authMethod: middleware.AuthMethodAPIKey, claimedUserID: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAccounts := new(mocks.AccountService)
+			mockAccounts.On("Login", mock.Anything, tt.claimedUserID, "alice", "secret-password").
+				Return(model.NewUser("account-1", "alice", "hash"), nil)
+			mockAuthorizer := new(mocks.Authorizer)
+			mockAuthorizer.On("CreateToken", "account-1").Return("account-token", nil)
+			h := NewAccountHandler(testLogger, mockAccounts, nil, nil, mockAuthorizer)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/user/login",
+				bytes.NewBufferString(`{"login":"alice","password":"secret-password"}`))
+			ctx := context.WithValue(req.Context(), middleware.UserIDKey, "current-user")
+			req = req.WithContext(context.WithValue(ctx, middleware.AuthMethodKey, tt.authMethod))
+			rr := httptest.NewRecorder()
+
+			h.HandleLoginJSON(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			mockAccounts.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleGetJWKS(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockAuthorizer := new(mocks.Authorizer)
+	mockAuthorizer.On("JWKS").Return(model.JWKS{Keys: []model.JWK{{
+		KeyType:   "OKP",
+		KeyID:     "2026-10",
+		Use:       "sig",
+		Algorithm: "EdDSA",
+		Curve:     "Ed25519",
+		X:         "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo",
+	}}})
+	h := NewAccountHandler(testLogger, nil, nil, nil, mockAuthorizer)
+
+	rr := httptest.NewRecorder()
+	h.HandleGetJWKS(rr, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `{"keys":[{"kty":"OKP","kid":"2026-10","use":"sig","alg":"EdDSA","crv":"Ed25519",`+
+		`"x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}]}`+"\n", rr.Body.String())
+}
+
+func TestHandleLogout(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+
+	tests := []struct {
+		name         string
+		token        string
+		apiKey       *model.APIKey
+		mockSetup    func(*mocks.Authorizer)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:  "revokes current token",
+			token: "current-token",
+			mockSetup: func(a *mocks.Authorizer) {
+				a.On("RevokeToken", mock.Anything, "current-token").Return(nil)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "new user without token",
+			mockSetup:    func(a *mocks.Authorizer) {},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "api key",
+			apiKey:       &model.APIKey{ID: "key-1", UserID: "user-1"},
+			mockSetup:    func(a *mocks.Authorizer) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"api keys cannot log out, revoke the key instead"}` + "\n",
+		},
+		{
+			name:  "revocation error",
+			token: "current-token",
+			mockSetup: func(a *mocks.Authorizer) {
+				a.On("RevokeToken", mock.Anything, "current-token").Return(errors.New("db error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"error":"Internal Server Error"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthorizer := new(mocks.Authorizer)
+			tt.mockSetup(mockAuthorizer)
+			h := NewAccountHandler(testLogger, nil, nil, nil, mockAuthorizer)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/user/logout", nil)
+			ctx := context.WithValue(req.Context(), middleware.UserIDKey, "user-1")
+			if tt.token != "" {
+				ctx = context.WithValue(ctx, middleware.TokenKey, tt.token)
+			}
+			if tt.apiKey != nil {
+				ctx = context.WithValue(ctx, middleware.APIKeyKey, tt.apiKey)
+			}
+			rr := httptest.NewRecorder()
+			middleware.SetAuthToken(rr, "refreshed-token")
+
+			h.HandleLogout(rr, req.WithContext(ctx))
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+			if tt.expectedCode == http.StatusNoContent {
+				assert.Empty(t, rr.Header().Get("Authorization"))
+				cookies := rr.Result().Cookies()
+				if assert.Len(t, cookies, 1) {
+					assert.Equal(t, middleware.CookieName, cookies[0].Name)
+					assert.Empty(t, cookies[0].Value)
+				}
+			}
+			mockAuthorizer.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,439 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/repository"
+	"github.com/bezjen/shortener/internal/service"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+// AdminHandler handles HTTP requests of administrators moderating links and users.
+// Routes must be restricted to administrators by the router, except abuse reports sent by visitors.
+type AdminHandler struct {
+	logger       *logger.Logger
+	adminService service.AdminService
+}
+
+// NewAdminHandler creates a new instance of AdminHandler.
+//
+// Parameters:
+//   - logger: logger instance for application logging
+//   - adminService: admin service implementation
+//
+// Returns:
+//   - *AdminHandler: initialized HTTP handler
+func NewAdminHandler(logger *logger.Logger, adminService service.AdminService) *AdminHandler {
+	return &AdminHandler{
+		logger:       logger,
+		adminService: adminService,
+	}
+}
+
+// HandleSearchURLsJSON handles GET requests to search links of all users.
+// Query parameters code, destination and owner filter by exact short URL,
+// destination substring and owner ID, under_review=true keeps links flagged by phishing heuristics only,
+// limit and offset paginate the result.
+//
+// Responses:
+//   - 200 OK: Links found
+//   - 204 No Content: No link matches
+//   - 400 Bad Request: Invalid limit, offset or under_review
+//   - 500 Internal Server Error: Internal server error
+//
+// Example request:
+//
+//	GET /api/admin/urls?destination=example.com&limit=10 HTTP/1.1
+//
+// Example response:
+//
+//	HTTP/1.1 200 OK
+//	Content-Type: application/json
+//
+//	[{"short_url": "abc123", "original_url": "https://example.com", "user_id": "user-123",
+//	  "is_deleted": false, "is_disabled": false}]
+func (h *AdminHandler) HandleSearchURLsJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	query := r.URL.Query()
+	filter := model.URLSearchFilter{
+		ShortURL:    query.Get("code"),
+		OriginalURL: query.Get("destination"),
+		UserID:      query.Get("owner"),
+	}
+	var err error
+	if filter.Limit, err = parseQueryInt(query.Get("limit")); err != nil {
+		h.writeErrorResponse(rw, http.StatusBadRequest, "invalid limit")
+		return
+	}
+	if filter.Offset, err = parseQueryInt(query.Get("offset")); err != nil {
+		h.writeErrorResponse(rw, http.StatusBadRequest, "invalid offset")
+		return
+	}
+	if underReview := query.Get("under_review"); underReview != "" {
+		if filter.UnderReview, err = strconv.ParseBool(underReview); err != nil {
+			h.writeErrorResponse(rw, http.StatusBadRequest, "invalid under_review")
+			return
+		}
+	}
+
+	urls, err := h.adminService.SearchURLs(r.Context(), getUserIDFromContext(r), filter)
+	if err != nil {
+		h.handleAdminError(rw, err, "Failed to search urls")
+		return
+	}
+	if len(urls) == 0 {
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+	h.writeJSONResponse(rw, http.StatusOK, urls)
+}
+
+// HandleDisableURL handles POST requests to disable a link.
+// The optional domain query parameter selects a vanity domain.
+//
+// Responses:
+//   - 204 No Content: Link disabled
+//   - 404 Not Found: Link does not exist
+//   - 500 Internal Server Error: Internal server error
+func (h *AdminHandler) HandleDisableURL(rw http.ResponseWriter, r *http.Request) {
+	h.setURLDisabled(rw, r, true)
+}
+
+// HandleEnableURL handles POST requests to enable a disabled link again.
+// The optional domain query parameter selects a vanity domain.
+//
+// Responses:
+//   - 204 No Content: Link enabled
+//   - 404 Not Found: Link does not exist
+//   - 500 Internal Server Error: Internal server error
+func (h *AdminHandler) HandleEnableURL(rw http.ResponseWriter, r *http.Request) {
+	h.setURLDisabled(rw, r, false)
+}
+
+// HandleClearURLReview handles POST requests to clear a link flagged by phishing heuristics.
+// The link redirects directly again instead of showing the warning page.
+// The optional domain query parameter selects a vanity domain.
+//
+// Responses:
+//   - 204 No Content: Link cleared
+//   - 404 Not Found: Link does not exist
+//   - 500 Internal Server Error: Internal server error
+func (h *AdminHandler) HandleClearURLReview(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	shortURL := chi.URLParam(r, "shortURL")
+	domain := r.URL.Query().Get("domain")
+
+	if err := h.adminService.ClearURLReview(r.Context(), getUserIDFromContext(r), domain, shortURL); err != nil {
+		h.handleAdminError(rw, err, "Failed to clear url review")
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// HandleBanUser handles POST requests to ban a user.
+// Links of the user stop resolving and writes of the user are rejected.
+//
+// Responses:
+//   - 204 No Content: User banned
+//   - 500 Internal Server Error: Internal server error
+func (h *AdminHandler) HandleBanUser(rw http.ResponseWriter, r *http.Request) {
+	h.setUserBanned(rw, r, true)
+}
+
+// HandleUnbanUser handles DELETE requests to lift the ban of a user.
+//
+// Responses:
+//   - 204 No Content: Ban lifted
+//   - 500 Internal Server Error: Internal server error
+func (h *AdminHandler) HandleUnbanUser(rw http.ResponseWriter, r *http.Request) {
+	h.setUserBanned(rw, r, false)
+}
+
+// HandleGetUserUsageJSON handles GET requests for the usage of a user.
+//
+// Responses:
+//   - 200 OK: Usage returned
+//   - 500 Internal Server Error: Internal server error
+//
+// Example response:
+//
+//	HTTP/1.1 200 OK
+//	Content-Type: application/json
+//
+//	{"user_id": "user-123", "links": 42, "deleted_links": 3, "disabled_links": 1, "api_keys": 2, "is_banned": false}
+func (h *AdminHandler) HandleGetUserUsageJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	userID := chi.URLParam(r, "userID")
+
+	usage, err := h.adminService.GetUserUsage(r.Context(), getUserIDFromContext(r), userID)
+	if err != nil {
+		h.handleAdminError(rw, err, "Failed to get user usage")
+		return
+	}
+	h.writeJSONResponse(rw, http.StatusOK, usage)
+}
+
+// HandleRevokeUserTokens handles POST requests to revoke all tokens of a user.
+// Tokens issued to the user afterwards stay valid.
+//
+// Responses:
+//   - 204 No Content: Tokens revoked
+//   - 500 Internal Server Error: Internal server error
+func (h *AdminHandler) HandleRevokeUserTokens(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	userID := chi.URLParam(r, "userID")
+
+	if err := h.adminService.RevokeUserTokens(r.Context(), getUserIDFromContext(r), userID); err != nil {
+		h.handleAdminError(rw, err, "Failed to revoke user tokens")
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// HandlePutUserQuotaJSON handles PUT requests to assign quota limits to a user.
+// The limits replace the default limits of the user, zero limits are unlimited.
+//
+// Request format:
+//
+//	{"links_per_day": 100, "active_links": 1000, "batch_size": 50, "delete_batch_size": 50}
+//
+// Responses:
+//   - 204 No Content: Limits assigned
+//   - 400 Bad Request: Invalid JSON or negative limits
+//   - 413 Request Entity Too Large: Request body exceeds the size limit
+//   - 500 Internal Server Error: Internal server error
+func (h *AdminHandler) HandlePutUserQuotaJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	userID := chi.URLParam(r, "userID")
+
+	defer r.Body.Close()
+	var limits model.QuotaLimits
+	if err := decodeJSONBody(r, &limits); err != nil {
+		statusCode, message := jsonBodyError(err)
+		h.writeErrorResponse(rw, statusCode, message)
+		return
+	}
+
+	err := h.adminService.SetUserQuotaLimits(r.Context(), getUserIDFromContext(r), userID, limits)
+	if err != nil {
+		h.handleAdminError(rw, err, "Failed to set user quota")
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// HandlePostReportJSON handles POST requests of visitors reporting an abusive link.
+// The report waits in the moderation queue. The optional domain query parameter selects a vanity domain.
+//
+// Request format:
+//
+//	{"reason": "phishing", "details": "Imitates the login page of my bank"}
+//
+// Responses:
+//   - 201 Created: Report queued
+//   - 400 Bad Request: Invalid JSON, unknown reason or details too long
+//   - 413 Request Entity Too Large: Request body exceeds the size limit
+//   - 404 Not Found: Link does not exist
+//   - 500 Internal Server Error: Internal server error
+//
+// Example response:
+//
+//	HTTP/1.1 201 Created
+//	Content-Type: application/json
+//
+//	{"id": "123e4567-e89b-12d3-a456-426614174000", "short_url": "abc123", "reason": "phishing",
+//	 "details": "Imitates the login page of my bank", "status": "open", "created_at": "2026-10-18T12:00:00Z"}
+func (h *AdminHandler) HandlePostReportJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	shortURL := chi.URLParam(r, "shortURL")
+	domain := r.URL.Query().Get("domain")
+
+	defer r.Body.Close()
+	var request model.AbuseReportRequest
+	if err := decodeJSONBody(r, &request); err != nil {
+		statusCode, message := jsonBodyError(err)
+		h.writeErrorResponse(rw, statusCode, message)
+		return
+	}
+
+	report, err := h.adminService.ReportURL(r.Context(), getUserIDFromContext(r), domain, shortURL, request)
+	if err != nil {
+		h.handleAdminError(rw, err, "Failed to report url")
+		return
+	}
+	h.writeJSONResponse(rw, http.StatusCreated, report)
+}
+
+// HandleGetAbuseReportsJSON handles GET requests for the moderation queue of abuse reports, oldest first.
+// Query parameter status selects open, dismissed or actioned reports, open by default,
+// limit and offset paginate the result.
+//
+// Responses:
+//   - 200 OK: Reports found
+//   - 204 No Content: No report matches
+//   - 400 Bad Request: Invalid status, limit or offset
+//   - 500 Internal Server Error: Internal server error
+//
+// Example request:
+//
+//	GET /api/admin/reports?status=open&limit=10 HTTP/1.1
+func (h *AdminHandler) HandleGetAbuseReportsJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	query := r.URL.Query()
+	filter := model.AbuseReportFilter{Status: model.ReportStatus(query.Get("status"))}
+	var err error
+	if filter.Limit, err = parseQueryInt(query.Get("limit")); err != nil {
+		h.writeErrorResponse(rw, http.StatusBadRequest, "invalid limit")
+		return
+	}
+	if filter.Offset, err = parseQueryInt(query.Get("offset")); err != nil {
+		h.writeErrorResponse(rw, http.StatusBadRequest, "invalid offset")
+		return
+	}
+
+	reports, err := h.adminService.GetAbuseReports(r.Context(), getUserIDFromContext(r), filter)
+	if err != nil {
+		h.handleAdminError(rw, err, "Failed to get abuse reports")
+		return
+	}
+	if len(reports) == 0 {
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+	h.writeJSONResponse(rw, http.StatusOK, reports)
+}
+
+// HandleDismissAbuseReport handles POST requests to dismiss an open abuse report.
+// The reported link keeps resolving.
+//
+// Responses:
+//   - 204 No Content: Report dismissed
+//   - 404 Not Found: No open report has the identifier
+//   - 500 Internal Server Error: Internal server error
+func (h *AdminHandler) HandleDismissAbuseReport(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	reportID := chi.URLParam(r, "reportID")
+
+	if err := h.adminService.DismissAbuseReport(r.Context(), getUserIDFromContext(r), reportID); err != nil {
+		h.handleReportError(rw, err, "Failed to dismiss abuse report")
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// HandleTakeDownReportedURLJSON handles POST requests to take down the link of an abuse report.
+// The link answers with a takedown page, 451 for legal takedowns and 410 otherwise,
+// and all open reports of the link are resolved.
+//
+// Request format:
+//
+//	{"reason": "legal"}
+//
+// Responses:
+//   - 204 No Content: Link taken down
+//   - 400 Bad Request: Invalid JSON or unknown reason
+//   - 413 Request Entity Too Large: Request body exceeds the size limit
+//   - 404 Not Found: Report does not exist
+//   - 500 Internal Server Error: Internal server error
+func (h *AdminHandler) HandleTakeDownReportedURLJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	reportID := chi.URLParam(r, "reportID")
+
+	defer r.Body.Close()
+	var request model.TakedownRequest
+	if err := decodeJSONBody(r, &request); err != nil {
+		statusCode, message := jsonBodyError(err)
+		h.writeErrorResponse(rw, statusCode, message)
+		return
+	}
+
+	err := h.adminService.TakeDownReportedURL(r.Context(), getUserIDFromContext(r), reportID, request.Reason)
+	if err != nil {
+		h.handleReportError(rw, err, "Failed to take down reported url")
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AdminHandler) setURLDisabled(rw http.ResponseWriter, r *http.Request, disabled bool) {
+	rw.Header().Set("Content-Type", "application/json")
+	shortURL := chi.URLParam(r, "shortURL")
+	domain := r.URL.Query().Get("domain")
+
+	err := h.adminService.SetURLDisabled(r.Context(), getUserIDFromContext(r), domain, shortURL, disabled)
+	if err != nil {
+		h.handleAdminError(rw, err, "Failed to moderate url")
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AdminHandler) setUserBanned(rw http.ResponseWriter, r *http.Request, banned bool) {
+	rw.Header().Set("Content-Type", "application/json")
+	userID := chi.URLParam(r, "userID")
+
+	if err := h.adminService.SetUserBanned(r.Context(), getUserIDFromContext(r), userID, banned); err != nil {
+		h.handleAdminError(rw, err, "Failed to moderate user")
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AdminHandler) handleAdminError(rw http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidSearchLimit), errors.Is(err, service.ErrEmptyUserID),
+		errors.Is(err, service.ErrInvalidQuotaLimits), errors.Is(err, service.ErrInvalidReportReason),
+		errors.Is(err, service.ErrReportDetailsTooLong), errors.Is(err, service.ErrInvalidReportStatus),
+		errors.Is(err, service.ErrInvalidTakedownReason):
+		h.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+	case errors.Is(err, repository.ErrNotFound):
+		h.writeErrorResponse(rw, http.StatusNotFound, "short url not found")
+	default:
+		h.logger.Error(message, zap.Error(err))
+		h.writeErrorResponse(rw, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+	}
+}
+
+func (h *AdminHandler) handleReportError(rw http.ResponseWriter, err error, message string) {
+	if errors.Is(err, repository.ErrNotFound) {
+		h.writeErrorResponse(rw, http.StatusNotFound, "report not found")
+		return
+	}
+	h.handleAdminError(rw, err, message)
+}
+
+func (h *AdminHandler) writeErrorResponse(rw http.ResponseWriter, statusCode int, error string) {
+	h.writeJSONResponse(rw, statusCode, model.ShortenJSONResponse{Error: error})
+}
+
+func (h *AdminHandler) writeJSONResponse(rw http.ResponseWriter, statusCode int, response interface{}) {
+	rw.WriteHeader(statusCode)
+	err := json.NewEncoder(rw).Encode(response)
+	if err != nil {
+		h.logger.Error("Failed to encode response", zap.Error(err))
+	}
+}
+
+// parseQueryInt parses an optional non-negative integer query parameter.
+//
+// Parameters:
+//   - value: query parameter value, empty for zero
+//
+// Returns:
+//   - int: parsed value
+//   - error: error if the value is not a non-negative integer
+func parseQueryInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, errors.New("invalid integer")
+	}
+	return n, nil
+}
@@ -0,0 +1,534 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/middleware"
+	"github.com/bezjen/shortener/internal/mocks"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/repository"
+	"github.com/bezjen/shortener/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newAdminRequest builds a request of the admin-1 administrator with chi URL parameters.
+func newAdminRequest(method string, target string, params map[string]string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	rctx := chi.NewRouteContext()
+	for key, value := range params {
+		rctx.URLParams.Add(key, value)
+	}
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, middleware.UserIDKey, "admin-1")
+	return req.WithContext(ctx)
+}
+
+func TestHandleSearchURLsJSON(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+
+	tests := []struct {
+		name         string
+		target       string
+		mockSetup    func(*mocks.AdminService)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:   "found",
+			target: "/api/admin/urls?code=abc123&destination=example&owner=user-1&limit=10&offset=5",
+			mockSetup: func(m *mocks.AdminService) {
+				m.On("SearchURLs", mock.Anything, "admin-1", model.URLSearchFilter{
+					ShortURL: "abc123", OriginalURL: "example", UserID: "user-1", Limit: 10, Offset: 5,
+				}).Return([]model.AdminURL{{ShortURL: "abc123", OriginalURL: "https://example.com", UserID: "user-1"}}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"short_url":"abc123","original_url":"https://example.com","user_id":"user-1",` +
+				`"is_deleted":false,"is_disabled":false}]`,
+		},
+		{
+			name:   "nothing found",
+			target: "/api/admin/urls",
+			mockSetup: func(m *mocks.AdminService) {
+				m.On("SearchURLs", mock.Anything, "admin-1", model.URLSearchFilter{}).Return(nil, nil)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:   "under review",
+			target: "/api/admin/urls?under_review=true",
+			mockSetup: func(m *mocks.AdminService) {
+				m.On("SearchURLs", mock.Anything, "admin-1", model.URLSearchFilter{UnderReview: true}).
+					Return([]model.AdminURL{{ShortURL: "abc123", OriginalURL: "https://paypa1.com",
+						ReviewReasons: []model.ReviewReason{model.ReviewHomoglyphBrand}}}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"short_url":"abc123","original_url":"https://paypa1.com","user_id":"","is_deleted":false,` +
+				`"is_disabled":false,"review_reasons":["homoglyph_brand"]}]`,
+		},
+		{
+			name:         "invalid under review",
+			target:       "/api/admin/urls?under_review=maybe",
+			mockSetup:    func(m *mocks.AdminService) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"invalid under_review"}`,
+		},
+		{
+			name:         "invalid limit",
+			target:       "/api/admin/urls?limit=ten",
+			mockSetup:    func(m *mocks.AdminService) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"invalid limit"}`,
+		},
+		{
+			name:         "negative offset",
+			target:       "/api/admin/urls?offset=-1",
+			mockSetup:    func(m *mocks.AdminService) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"invalid offset"}`,
+		},
+		{
+			name:   "limit out of range",
+			target: "/api/admin/urls?limit=5000",
+			mockSetup: func(m *mocks.AdminService) {
+				m.On("SearchURLs", mock.Anything, "admin-1", model.URLSearchFilter{Limit: 5000}).
+					Return(nil, service.ErrInvalidSearchLimit)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"` + service.ErrInvalidSearchLimit.Error() + `"}`,
+		},
+		{
+			name:   "storage error",
+			target: "/api/admin/urls?owner=user-1",
+			mockSetup: func(m *mocks.AdminService) {
+				m.On("SearchURLs", mock.Anything, "admin-1", model.URLSearchFilter{UserID: "user-1"}).
+					Return(nil, errors.New("db error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAdmin := new(mocks.AdminService)
+			tt.mockSetup(mockAdmin)
+			h := NewAdminHandler(testLogger, mockAdmin)
+			rr := httptest.NewRecorder()
+
+			h.HandleSearchURLsJSON(rr, newAdminRequest(http.MethodGet, tt.target, nil))
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			}
+			mockAdmin.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleDisableEnableURL(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockAdmin := new(mocks.AdminService)
+	mockAdmin.On("SetURLDisabled", mock.Anything, "admin-1", "go.brand.com", "abc123", true).Return(nil)
+	mockAdmin.On("SetURLDisabled", mock.Anything, "admin-1", "", "abc123", false).Return(nil)
+	mockAdmin.On("SetURLDisabled", mock.Anything, "admin-1", "", "missing", true).Return(repository.ErrNotFound)
+	h := NewAdminHandler(testLogger, mockAdmin)
+
+	rr := httptest.NewRecorder()
+	h.HandleDisableURL(rr, newAdminRequest(http.MethodPost, "/api/admin/urls/abc123/disable?domain=go.brand.com",
+		map[string]string{"shortURL": "abc123"}))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = httptest.NewRecorder()
+	h.HandleEnableURL(rr, newAdminRequest(http.MethodPost, "/api/admin/urls/abc123/enable",
+		map[string]string{"shortURL": "abc123"}))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = httptest.NewRecorder()
+	h.HandleDisableURL(rr, newAdminRequest(http.MethodPost, "/api/admin/urls/missing/disable",
+		map[string]string{"shortURL": "missing"}))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.JSONEq(t, `{"error":"short url not found"}`, rr.Body.String())
+
+	mockAdmin.AssertExpectations(t)
+}
+
+func TestHandleClearURLReview(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockAdmin := new(mocks.AdminService)
+	mockAdmin.On("ClearURLReview", mock.Anything, "admin-1", "go.brand.com", "abc123").Return(nil)
+	mockAdmin.On("ClearURLReview", mock.Anything, "admin-1", "", "missing").Return(repository.ErrNotFound)
+	h := NewAdminHandler(testLogger, mockAdmin)
+
+	rr := httptest.NewRecorder()
+	h.HandleClearURLReview(rr, newAdminRequest(http.MethodPost, "/api/admin/urls/abc123/clear-review?domain=go.brand.com",
+		map[string]string{"shortURL": "abc123"}))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = httptest.NewRecorder()
+	h.HandleClearURLReview(rr, newAdminRequest(http.MethodPost, "/api/admin/urls/missing/clear-review",
+		map[string]string{"shortURL": "missing"}))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	mockAdmin.AssertExpectations(t)
+}
+
+func TestHandleBanUnbanUser(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockAdmin := new(mocks.AdminService)
+	mockAdmin.On("SetUserBanned", mock.Anything, "admin-1", "user-2", true).Return(nil)
+	mockAdmin.On("SetUserBanned", mock.Anything, "admin-1", "user-2", false).Return(errors.New("db error"))
+	h := NewAdminHandler(testLogger, mockAdmin)
+	params := map[string]string{"userID": "user-2"}
+
+	rr := httptest.NewRecorder()
+	h.HandleBanUser(rr, newAdminRequest(http.MethodPost, "/api/admin/users/user-2/ban", params))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = httptest.NewRecorder()
+	h.HandleUnbanUser(rr, newAdminRequest(http.MethodDelete, "/api/admin/users/user-2/ban", params))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	mockAdmin.AssertExpectations(t)
+}
+
+func TestHandleGetUserUsageJSON(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockAdmin := new(mocks.AdminService)
+	mockAdmin.On("GetUserUsage", mock.Anything, "admin-1", "user-2").
+		Return(&model.UserUsage{UserID: "user-2", Links: 4, DeletedLinks: 1, APIKeys: 2}, nil)
+	h := NewAdminHandler(testLogger, mockAdmin)
+
+	rr := httptest.NewRecorder()
+	h.HandleGetUserUsageJSON(rr, newAdminRequest(http.MethodGet, "/api/admin/users/user-2/usage",
+		map[string]string{"userID": "user-2"}))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"user_id":"user-2","links":4,"deleted_links":1,"disabled_links":0,"api_keys":2,"is_banned":false}`,
+		rr.Body.String())
+	mockAdmin.AssertExpectations(t)
+}
+
+func TestHandleRevokeUserTokens(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+
+	tests := []struct {
+		name         string
+		revokeErr    error
+		expectedCode int
+	}{
+		{name: "success", expectedCode: http.StatusNoContent},
+		{name: "storage error", revokeErr: errors.New("db error"), expectedCode: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAdmin := new(mocks.AdminService)
+			mockAdmin.On("RevokeUserTokens", mock.Anything, "admin-1", "user-2").Return(tt.revokeErr)
+			h := NewAdminHandler(testLogger, mockAdmin)
+			rr := httptest.NewRecorder()
+
+			h.HandleRevokeUserTokens(rr, newAdminRequest(http.MethodPost, "/api/admin/users/user-2/revoke-tokens",
+				map[string]string{"userID": "user-2"}))
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			mockAdmin.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlePutUserQuotaJSON(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	limits := model.QuotaLimits{LinksPerDay: 100, ActiveLinks: 1000}
+
+	tests := []struct {
+		name         string
+		body         string
+		mockSetup    func(*mocks.AdminService)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name: "success",
+			body: `{"links_per_day":100,"active_links":1000}`,
+			mockSetup: func(m *mocks.AdminService) {
+				m.On("SetUserQuotaLimits", mock.Anything, "admin-1", "user-2", limits).Return(nil)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "incorrect json",
+			body:         `{"links_per_day":`,
+			mockSetup:    func(m *mocks.AdminService) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"incorrect json"}`,
+		},
+		{
+			name: "negative limits",
+			body: `{"links_per_day":-1}`,
+			mockSetup: func(m *mocks.AdminService) {
+				m.On("SetUserQuotaLimits", mock.Anything, "admin-1", "user-2", model.QuotaLimits{LinksPerDay: -1}).
+					Return(service.ErrInvalidQuotaLimits)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAdmin := new(mocks.AdminService)
+			tt.mockSetup(mockAdmin)
+			h := NewAdminHandler(testLogger, mockAdmin)
+			req := newAdminRequest(http.MethodPut, "/api/admin/users/user-2/quota", map[string]string{"userID": "user-2"})
+			req.Body = io.NopCloser(strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+
+			h.HandlePutUserQuotaJSON(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			}
+			mockAdmin.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlePostReportJSON(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	request := model.AbuseReportRequest{Reason: model.ReportPhishing, Details: "fake bank login"}
+
+	tests := []struct {
+		name         string
+		target       string
+		body         string
+		mockSetup    func(*mocks.AdminService)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:   "success",
+			target: "/api/report/abc123?domain=go.brand.com",
+			body:   `{"reason":"phishing","details":"fake bank login"}`,
+			mockSetup: func(m *mocks.AdminService) {
+				m.On("ReportURL", mock.Anything, "admin-1", "go.brand.com", "abc123", request).
+					Return(&model.AbuseReport{ID: "report-1", Domain: "go.brand.com", ShortURL: "abc123",
+						Reason: model.ReportPhishing, Status: model.ReportOpen}, nil)
+			},
+			expectedCode: http.StatusCreated,
+			expectedBody: `{"id":"report-1","domain":"go.brand.com","short_url":"abc123","reason":"phishing",` +
+				`"status":"open","created_at":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			name:         "incorrect json",
+			target:       "/api/report/abc123",
+			body:         `{"reason":`,
+			mockSetup:    func(m *mocks.AdminService) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"incorrect json"}`,
+		},
+		{
+			name:   "unknown reason",
+			target: "/api/report/abc123",
+			body:   `{"reason":"annoying"}`,
+			mockSetup: func(m *mocks.AdminService) {
+				m.On("ReportURL", mock.Anything, "admin-1", "", "abc123", model.AbuseReportRequest{Reason: "annoying"}).
+					Return(nil, service.ErrInvalidReportReason)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"reason must be one of phishing, malware, spam, illegal or other"}`,
+		},
+		{
+			name:   "link not found",
+			target: "/api/report/abc123",
+			body:   `{"reason":"spam"}`,
+			mockSetup: func(m *mocks.AdminService) {
+				m.On("ReportURL", mock.Anything, "admin-1", "", "abc123", model.AbuseReportRequest{Reason: "spam"}).
+					Return(nil, repository.ErrNotFound)
+			},
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"error":"short url not found"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAdmin := new(mocks.AdminService)
+			tt.mockSetup(mockAdmin)
+			h := NewAdminHandler(testLogger, mockAdmin)
+			req := newAdminRequest(http.MethodPost, tt.target, map[string]string{"shortURL": "abc123"})
+			req.Body = io.NopCloser(strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+
+			h.HandlePostReportJSON(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			mockAdmin.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleGetAbuseReportsJSON(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+
+	tests := []struct {
+		name         string
+		target       string
+		mockSetup    func(*mocks.AdminService)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:   "reports found",
+			target: "/api/admin/reports?status=actioned&limit=10&offset=5",
+			mockSetup: func(m *mocks.AdminService) {
+				m.On("GetAbuseReports", mock.Anything, "admin-1",
+					model.AbuseReportFilter{Status: model.ReportActioned, Limit: 10, Offset: 5}).
+					Return([]model.AbuseReport{{ID: "report-1", ShortURL: "abc123", Reason: model.ReportSpam,
+						Status: model.ReportActioned, ResolvedBy: "admin-2"}}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"id":"report-1","short_url":"abc123","reason":"spam","status":"actioned",` +
+				`"created_at":"0001-01-01T00:00:00Z","resolved_by":"admin-2"}]`,
+		},
+		{
+			name:   "no reports",
+			target: "/api/admin/reports",
+			mockSetup: func(m *mocks.AdminService) {
+				m.On("GetAbuseReports", mock.Anything, "admin-1", model.AbuseReportFilter{}).Return(nil, nil)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "invalid limit",
+			target:       "/api/admin/reports?limit=ten",
+			mockSetup:    func(m *mocks.AdminService) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"invalid limit"}`,
+		},
+		{
+			name:   "invalid status",
+			target: "/api/admin/reports?status=closed",
+			mockSetup: func(m *mocks.AdminService) {
+				m.On("GetAbuseReports", mock.Anything, "admin-1", model.AbuseReportFilter{Status: "closed"}).
+					Return(nil, service.ErrInvalidReportStatus)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"status must be one of open, dismissed or actioned"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAdmin := new(mocks.AdminService)
+			tt.mockSetup(mockAdmin)
+			h := NewAdminHandler(testLogger, mockAdmin)
+			rr := httptest.NewRecorder()
+
+			h.HandleGetAbuseReportsJSON(rr, newAdminRequest(http.MethodGet, tt.target, nil))
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			}
+			mockAdmin.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleDismissAbuseReport(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockAdmin := new(mocks.AdminService)
+	mockAdmin.On("DismissAbuseReport", mock.Anything, "admin-1", "report-1").Return(nil)
+	mockAdmin.On("DismissAbuseReport", mock.Anything, "admin-1", "missing").Return(repository.ErrNotFound)
+	h := NewAdminHandler(testLogger, mockAdmin)
+
+	rr := httptest.NewRecorder()
+	h.HandleDismissAbuseReport(rr, newAdminRequest(http.MethodPost, "/api/admin/reports/report-1/dismiss",
+		map[string]string{"reportID": "report-1"}))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = httptest.NewRecorder()
+	h.HandleDismissAbuseReport(rr, newAdminRequest(http.MethodPost, "/api/admin/reports/missing/dismiss",
+		map[string]string{"reportID": "missing"}))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.JSONEq(t, `{"error":"report not found"}`, rr.Body.String())
+
+	mockAdmin.AssertExpectations(t)
+}
+
+func TestHandleTakeDownReportedURLJSON(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+
+	tests := []struct {
+		name         string
+		body         string
+		mockSetup    func(*mocks.AdminService)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name: "success",
+			body: `{"reason":"legal"}`,
+			mockSetup: func(m *mocks.AdminService) {
+				m.On("TakeDownReportedURL", mock.Anything, "admin-1", "report-1", model.TakedownLegal).Return(nil)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "incorrect json",
+			body:         `{"reason":`,
+			mockSetup:    func(m *mocks.AdminService) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"incorrect json"}`,
+		},
+		{
+			name: "unknown reason",
+			body: `{"reason":"spam"}`,
+			mockSetup: func(m *mocks.AdminService) {
+				m.On("TakeDownReportedURL", mock.Anything, "admin-1", "report-1", model.TakedownReason("spam")).
+					Return(service.ErrInvalidTakedownReason)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"takedown reason must be abuse or legal"}`,
+		},
+		{
+			name: "report not found",
+			body: `{"reason":"abuse"}`,
+			mockSetup: func(m *mocks.AdminService) {
+				m.On("TakeDownReportedURL", mock.Anything, "admin-1", "report-1", model.TakedownAbuse).
+					Return(repository.ErrNotFound)
+			},
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"error":"report not found"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAdmin := new(mocks.AdminService)
+			tt.mockSetup(mockAdmin)
+			h := NewAdminHandler(testLogger, mockAdmin)
+			req := newAdminRequest(http.MethodPost, "/api/admin/reports/report-1/takedown",
+				map[string]string{"reportID": "report-1"})
+			req.Body = io.NopCloser(strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+
+			h.HandleTakeDownReportedURLJSON(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			}
+			mockAdmin.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"errors"
+	"github.com/bezjen/shortener/internal/middleware"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/repository"
+	"github.com/bezjen/shortener/internal/service"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// HandlePostAPIKeyJSON handles POST requests to create an API key of the user.
+// The secret key is returned only in this response.
+// Keys cannot be managed with API keys, only with the user token.
+//
+// Request format:
+//
+//	{"name": "CI pipeline", "scopes": ["read", "write"], "rate_limit": 60}
+//
+// Responses:
+//   - 201 Created: Key created successfully
+//   - 400 Bad Request: Invalid JSON, empty name, unknown scopes or invalid rate limit
+//   - 413 Request Entity Too Large: Request body exceeds the size limit
+//   - 401 Unauthorized: User not authenticated
+//   - 403 Forbidden: Request is authenticated with an API key
+//   - 500 Internal Server Error: Internal server error
+//
+// Example response:
+//
+//	HTTP/1.1 201 Created
+//	Content-Type: application/json
+//
+//	{"id": "123e4567-e89b-12d3-a456-426614174000", "name": "CI pipeline", "prefix": "shk_3q2-",
+//	 "scopes": ["read", "write"], "rate_limit": 60, "created_at": "2026-10-18T10:00:00Z",
+//	 "key": "shk_3q2-Xb0sV1c9kQy7m8RZbVn4JwQmL0cH5pT2aYdF6eGh"}
+func (h *AccountHandler) HandlePostAPIKeyJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	userID, ok := h.apiKeyOwner(rw, r)
+	if !ok {
+		return
+	}
+
+	defer r.Body.Close()
+	var request model.APIKeyJSONRequest
+	if err := decodeJSONBody(r, &request); err != nil {
+		statusCode, message := jsonBodyError(err)
+		h.writeErrorResponse(rw, statusCode, message)
+		return
+	}
+
+	key, secret, err := h.apiKeyService.CreateAPIKey(r.Context(), userID, request.Name, request.Scopes, request.RateLimit)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrEmptyAPIKeyName),
+			errors.Is(err, service.ErrInvalidAPIKeyScope),
+			errors.Is(err, service.ErrInvalidAPIKeyRateLimit):
+			h.writeErrorResponse(rw, http.StatusBadRequest, err.Error())
+		default:
+			h.logger.Error("Failed to create api key", zap.Error(err), zap.String("userID", userID))
+			h.writeErrorResponse(rw, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		}
+		return
+	}
+
+	h.writeJSONResponse(rw, http.StatusCreated, model.APIKeyJSONResponse{APIKey: *key, Key: secret})
+}
+
+// HandleGetAPIKeysJSON handles GET requests to list API keys of the user.
+// Secret keys are never listed, only their prefixes.
+//
+// Responses:
+//   - 200 OK: Keys retrieved successfully
+//   - 204 No Content: User has no keys
+//   - 401 Unauthorized: User not authenticated
+//   - 403 Forbidden: Request is authenticated with an API key
+//   - 500 Internal Server Error: Internal server error
+//
+// Example response:
+//
+//	HTTP/1.1 200 OK
+//	Content-Type: application/json
+//
+//	[{"id": "123e4567-e89b-12d3-a456-426614174000", "name": "CI pipeline", "prefix": "shk_3q2-",
+//	  "scopes": ["read", "write"], "rate_limit": 60, "created_at": "2026-10-18T10:00:00Z",
+//	  "last_used_at": "2026-10-18T12:30:00Z"}]
+func (h *AccountHandler) HandleGetAPIKeysJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	userID, ok := h.apiKeyOwner(rw, r)
+	if !ok {
+		return
+	}
+
+	keys, err := h.apiKeyService.GetUserAPIKeys(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to get api keys", zap.Error(err), zap.String("userID", userID))
+		h.writeErrorResponse(rw, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	if len(keys) == 0 {
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	h.writeJSONResponse(rw, http.StatusOK, keys)
+}
+
+// HandleDeleteAPIKey handles DELETE requests to revoke an API key of the user.
+// Requests made with the key are rejected right after revocation.
+//
+// Responses:
+//   - 204 No Content: Key revoked
+//   - 401 Unauthorized: User not authenticated
+//   - 403 Forbidden: Request is authenticated with an API key
+//   - 404 Not Found: User has no such key
+//   - 500 Internal Server Error: Internal server error
+func (h *AccountHandler) HandleDeleteAPIKey(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	userID, ok := h.apiKeyOwner(rw, r)
+	if !ok {
+		return
+	}
+	keyID := chi.URLParam(r, "keyID")
+
+	err := h.apiKeyService.RevokeAPIKey(r.Context(), userID, keyID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			h.writeErrorResponse(rw, http.StatusNotFound, "api key not found")
+			return
+		}
+		h.logger.Error("Failed to revoke api key", zap.Error(err), zap.String("userID", userID))
+		h.writeErrorResponse(rw, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// apiKeyOwner returns the user managing API keys and writes an error response
+// when the request is not allowed to manage keys.
+func (h *AccountHandler) apiKeyOwner(rw http.ResponseWriter, r *http.Request) (string, bool) {
+	userID := getUserIDFromContext(r)
+	if userID == "" {
+		h.writeErrorResponse(rw, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return "", false
+	}
+	if middleware.APIKeyFromContext(r.Context()) != nil {
+		h.writeErrorResponse(rw, http.StatusForbidden, "api keys cannot manage api keys")
+		return "", false
+	}
+	return userID, true
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/middleware"
+	"github.com/bezjen/shortener/internal/mocks"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/repository"
+	"github.com/bezjen/shortener/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func apiKeyRequest(method string, target string, body string, userID string, key *model.APIKey) *http.Request {
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	ctx := context.WithValue(req.Context(), middleware.UserIDKey, userID)
+	if key != nil {
+		ctx = context.WithValue(ctx, middleware.APIKeyKey, key)
+	}
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("keyID", "key-1")
+	ctx = context.WithValue(ctx, chi.RouteCtxKey, routeCtx)
+	return req.WithContext(ctx)
+}
+
+func TestHandlePostAPIKeyJSON(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	createdAt := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		body         string
+		key          *model.APIKey
+		mockSetup    func(*mocks.APIKeyService)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name: "created",
+			body: `{"name": "CI", "scopes": ["read", "write"], "rate_limit": 30}`,
+			mockSetup: func(m *mocks.APIKeyService) {
+				m.On("CreateAPIKey", mock.Anything, "user-1", "CI", []model.APIKeyScope{model.ScopeRead, model.ScopeWrite}, 30).
+					Return(&model.APIKey{
+						ID:        "key-1",
+						UserID:    "user-1",
+						Name:      "CI",
+						Prefix:    "shk_abcd",
+						KeyHash:   "hash",
+						Scopes:    []model.APIKeyScope{model.ScopeRead, model.ScopeWrite},
+						RateLimit: 30,
+						CreatedAt: createdAt,
+					}, "shk_abcdsecret", nil)
+			},
+			expectedCode: http.StatusCreated,
+			expectedBody: `{"id":"key-1","name":"CI","prefix":"shk_abcd","scopes":["read","write"],"rate_limit":30,` +
+				`"created_at":"2026-10-18T10:00:00Z","key":"shk_abcdsecret"}` + "\n",
+		},
+		{
+			name:         "invalid json",
+			body:         `{`,
+			mockSetup:    func(m *mocks.APIKeyService) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"incorrect json"}` + "\n",
+		},
+		{
+			name: "invalid scope",
+			body: `{"name": "CI", "scopes": ["admin"]}`,
+			mockSetup: func(m *mocks.APIKeyService) {
+				m.On("CreateAPIKey", mock.Anything, "user-1", "CI", []model.APIKeyScope{"admin"}, 0).
+					Return(nil, "", service.ErrInvalidAPIKeyScope)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"api key scopes must be read, write or delete"}` + "\n",
+		},
+		{
+			name:         "request made with api key",
+			body:         `{"name": "CI", "scopes": ["delete"]}`,
+			key:          &model.APIKey{ID: "key-0", Scopes: []model.APIKeyScope{model.ScopeWrite}},
+			mockSetup:    func(m *mocks.APIKeyService) {},
+			expectedCode: http.StatusForbidden,
+			expectedBody: `{"error":"api keys cannot manage api keys"}` + "\n",
+		},
+		{
+			name: "storage error",
+			body: `{"name": "CI", "scopes": ["read"]}`,
+			mockSetup: func(m *mocks.APIKeyService) {
+				m.On("CreateAPIKey", mock.Anything, "user-1", "CI", []model.APIKeyScope{model.ScopeRead}, 0).
+					Return(nil, "", errors.New("db error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"error":"Internal Server Error"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPIKeys := new(mocks.APIKeyService)
+			tt.mockSetup(mockAPIKeys)
+			h := NewAccountHandler(testLogger, nil, mockAPIKeys, nil, nil)
+
+			rr := httptest.NewRecorder()
+			h.HandlePostAPIKeyJSON(rr, apiKeyRequest(http.MethodPost, "/api/user/keys", tt.body, "user-1", tt.key))
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+			mockAPIKeys.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleGetAPIKeysJSON(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	createdAt := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+
+	mockAPIKeys := new(mocks.APIKeyService)
+	mockAPIKeys.On("GetUserAPIKeys", mock.Anything, "user-1").Return([]model.APIKey{{
+		ID:         "key-1",
+		UserID:     "user-1",
+		Name:       "CI",
+		Prefix:     "shk_abcd",
+		KeyHash:    "hash",
+		Scopes:     []model.APIKeyScope{model.ScopeRead},
+		RateLimit:  60,
+		CreatedAt:  createdAt,
+		LastUsedAt: &createdAt,
+	}}, nil)
+	mockAPIKeys.On("GetUserAPIKeys", mock.Anything, "user-2").Return(nil, nil)
+	h := NewAccountHandler(testLogger, nil, mockAPIKeys, nil, nil)
+
+	rr := httptest.NewRecorder()
+	h.HandleGetAPIKeysJSON(rr, apiKeyRequest(http.MethodGet, "/api/user/keys", "", "user-1", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `[{"id":"key-1","name":"CI","prefix":"shk_abcd","scopes":["read"],"rate_limit":60,`+
+		`"created_at":"2026-10-18T10:00:00Z","last_used_at":"2026-10-18T10:00:00Z"}]`+"\n", rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	h.HandleGetAPIKeysJSON(rr, apiKeyRequest(http.MethodGet, "/api/user/keys", "", "user-2", nil))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = httptest.NewRecorder()
+	h.HandleGetAPIKeysJSON(rr, apiKeyRequest(http.MethodGet, "/api/user/keys", "", "", nil))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestHandleDeleteAPIKey(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+
+	mockAPIKeys := new(mocks.APIKeyService)
+	mockAPIKeys.On("RevokeAPIKey", mock.Anything, "user-1", "key-1").Return(nil)
+	mockAPIKeys.On("RevokeAPIKey", mock.Anything, "user-2", "key-1").Return(repository.ErrNotFound)
+	mockAPIKeys.On("RevokeAPIKey", mock.Anything, "user-3", "key-1").Return(errors.New("db error"))
+	h := NewAccountHandler(testLogger, nil, mockAPIKeys, nil, nil)
+
+	tests := []struct {
+		userID       string
+		key          *model.APIKey
+		expectedCode int
+	}{
+		{userID: "user-1", expectedCode: http.StatusNoContent},
+		{userID: "user-2", expectedCode: http.StatusNotFound},
+		{userID: "user-3", expectedCode: http.StatusInternalServerError},
+		{userID: "user-1", key: &model.APIKey{ID: "key-1"}, expectedCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		h.HandleDeleteAPIKey(rr, apiKeyRequest(http.MethodDelete, "/api/user/keys/key-1", "", tt.userID, tt.key))
+		assert.Equal(t, tt.expectedCode, rr.Code, "user %s", tt.userID)
+	}
+	mockAPIKeys.AssertNumberOfCalls(t, "RevokeAPIKey", 3)
+}
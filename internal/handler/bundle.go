@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"github.com/bezjen/shortener/internal/model"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// maxBundleLinks defines the maximum number of links in a single bundle.
+	maxBundleLinks = 50
+	// defaultBundleTitle is used as the page title when a bundle is created without one.
+	defaultBundleTitle = "Links"
+	// bundleLinkParam is the query parameter selecting the followed link of a bundle by its display index.
+	bundleLinkParam = "link"
+)
+
+// bundlePageTemplate renders the landing page of a bundle.
+// Links point back to the bundle with the link index, so that the followed destination can be audited.
+var bundlePageTemplate = newHTMLPage("bundle", `
+{{- define "body"}}
+<h1>{{.Title}}</h1>
+<ul>
+{{- range $i, $link := .Links}}
+<li><a href="?link={{$i}}" rel="noopener noreferrer">{{$link.Title}}</a></li>
+{{- end}}
+</ul>
+{{- end}}`)
+
+// writeBundlePage renders the bundle landing page with 200 OK status.
+//
+// Parameters:
+//   - rw: HTTP response writer
+//   - bundle: bundle to render
+func (h *ShortenerHandler) writeBundlePage(rw http.ResponseWriter, bundle *model.Bundle) {
+	h.renderHTMLPage(rw, http.StatusOK, bundlePageTemplate, bundle)
+}
+
+// bundleLinkByIndex returns the bundle destination selected by the value of the link query parameter.
+//
+// Parameters:
+//   - bundle: bundle the link belongs to
+//   - index: display index of the link as sent in the query parameter
+//
+// Returns:
+//   - *model.BundleLink: selected link
+//   - bool: false if the index is not a number or out of range
+func bundleLinkByIndex(bundle *model.Bundle, index string) (*model.BundleLink, bool) {
+	i, err := strconv.Atoi(index)
+	if err != nil || i < 0 || i >= len(bundle.Links) {
+		return nil, false
+	}
+	return &bundle.Links[i], true
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// errUnknownDomain is returned when a short URL is created on a domain that is not configured.
+var errUnknownDomain = errors.New("unknown domain")
+
+// domainByHost maps the request Host header to the domain short URLs are looked up in.
+// Hosts that are not configured as vanity domains resolve to the base URL domain.
+//
+// Parameters:
+//   - host: request host, optionally with port
+//
+// Returns:
+//   - string: configured vanity domain, empty for the base URL domain
+func (h *ShortenerHandler) domainByHost(host string) string {
+	host = strings.ToLower(host)
+	hostname := host
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		hostname = name
+	}
+	for _, domain := range h.cfg.Domains {
+		domain = strings.ToLower(domain)
+		if domain == host || domain == hostname {
+			return domain
+		}
+	}
+	return ""
+}
+
+// chosenDomain validates the domain chosen by the user at creation.
+// An empty value or the base URL host selects the base URL domain.
+//
+// Parameters:
+//   - requested: domain from the request
+//
+// Returns:
+//   - string: configured vanity domain, empty for the base URL domain
+//   - error: errUnknownDomain if the domain is not configured
+func (h *ShortenerHandler) chosenDomain(requested string) (string, error) {
+	if requested == "" {
+		return "", nil
+	}
+	if baseURL, err := url.Parse(h.cfg.BaseURL); err == nil && strings.EqualFold(baseURL.Host, requested) {
+		return "", nil
+	}
+	for _, domain := range h.cfg.Domains {
+		if strings.EqualFold(domain, requested) {
+			return strings.ToLower(domain), nil
+		}
+	}
+	return "", errUnknownDomain
+}
+
+// buildFullURL builds the public short URL on its domain.
+// Vanity domains replace the host name of the base URL and keep its scheme, port and path prefix.
+//
+// Parameters:
+//   - domain: domain the short URL belongs to, empty for the base URL domain
+//   - shortURL: short URL identifier
+//
+// Returns:
+//   - string: full short URL
+//   - error: error if the base URL cannot be parsed
+func (h *ShortenerHandler) buildFullURL(domain string, shortURL string) (string, error) {
+	if domain == "" {
+		return url.JoinPath(h.cfg.BaseURL, shortURL)
+	}
+	baseURL, err := url.Parse(h.cfg.BaseURL)
+	if err != nil {
+		return "", err
+	}
+	if baseURL.Scheme == "" {
+		baseURL.Scheme = "http"
+	}
+	port := baseURL.Port()
+	baseURL.Host = domain
+	if port != "" {
+		baseURL.Host = net.JoinHostPort(domain, port)
+	}
+	return baseURL.JoinPath(shortURL).String(), nil
+}
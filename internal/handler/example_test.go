@@ -29,7 +29,7 @@ func ExampleShortenerHandler_HandlePostShortURLTextPlain() {
 	auditService := &mocks.AuditService{}
 
 	// Setup mock expectations
-	shortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "https://example.com/very-long-url-path").
+	shortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "", "https://example.com/very-long-url-path").
 		Return("abc123", nil)
 	auditService.On("NotifyAll", mock.Anything).Return()
 
@@ -70,7 +70,7 @@ func ExampleShortenerHandler_HandlePostShortURLJSON() {
 	auditService := &mocks.AuditService{}
 
 	// Setup mock expectations
-	shortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "https://example.com/very-long-url").
+	shortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "", "https://example.com/very-long-url").
 		Return("def456", nil)
 	auditService.On("NotifyAll", mock.Anything).Return()
 
@@ -123,7 +123,7 @@ func ExampleShortenerHandler_HandlePostShortURLBatchJSON() {
 		{CorrelationID: "2", ShortURL: "short2"},
 	}
 
-	shortener.On("GenerateShortURLPartBatch", mock.Anything, mock.Anything, batchRequest).
+	shortener.On("GenerateShortURLPartBatch", mock.Anything, mock.Anything, "", batchRequest).
 		Return(expectedResponse, nil)
 	auditService.On("NotifyAll", mock.Anything).Return()
 
@@ -207,7 +207,7 @@ func ExampleShortenerHandler_HandleDeleteShortURLsBatchJSON() {
 
 	// Setup mock expectations
 	urlsToDelete := []string{"abc123", "def456"}
-	shortener.On("DeleteUserShortURLsBatch", mock.Anything, "test-user", urlsToDelete).Return(nil)
+	shortener.On("DeleteUserShortURLsBatch", mock.Anything, "test-user", "", urlsToDelete).Return(nil)
 
 	h := handler.NewShortenerHandler(cfg, testLogger, shortener, auditService)
 
@@ -18,15 +18,25 @@ import (
 	"time"
 )
 
+// defaultMaxURLLength is the original URL length limit used when max_url_length is not configured.
+const defaultMaxURLLength = 8192
+
+// errURLTooLong is returned by validateURL when the original URL exceeds the configured length limit.
+var errURLTooLong = errors.New("url is too long")
+
 // ShortenerHandler handles HTTP requests for URL shortening operations.
 type ShortenerHandler struct {
 	cfg          config.Config
 	logger       *logger.Logger
 	shortener    service.Shortener
 	auditService service.AuditService
+	urlPolicy    *service.URLPolicy
+	botDetector  *service.BotDetector
 }
 
 // NewShortenerHandler creates a new instance of ShortenerHandler.
+// Destination URLs are checked by a policy allowing configured schemes and rejecting private targets
+// and links to the base URL host and vanity domains. Bots following links are detected by default signatures.
 //
 // Parameters:
 //   - cfg: application configuration settings
@@ -47,16 +57,38 @@ func NewShortenerHandler(
 		logger:       logger,
 		shortener:    shortener,
 		auditService: auditService,
+		urlPolicy:    service.NewURLPolicy(cfg.URLSchemes, cfg.ServiceHosts(), logger),
+		botDetector:  service.NewBotDetector(logger),
 	}
 }
 
+// SetURLPolicy replaces the destination URL policy, e.g. with one that has domain lists loaded.
+//
+// Parameters:
+//   - policy: policy checking destination URLs of all create requests
+func (h *ShortenerHandler) SetURLPolicy(policy *service.URLPolicy) {
+	h.urlPolicy = policy
+}
+
+// SetBotDetector replaces the bot detector, e.g. with one that has a signatures file loaded.
+//
+// Parameters:
+//   - detector: detector classifying requests following short URLs
+func (h *ShortenerHandler) SetBotDetector(detector *service.BotDetector) {
+	h.botDetector = detector
+}
+
 // HandlePostShortURLTextPlain handles POST requests to create short URLs from plain text.
 // Accepts the original URL in the request body as text/plain.
+// The optional domain query parameter selects one of the configured vanity domains.
 //
 // Responses:
 //   - 201 Created: Short URL successfully created
-//   - 409 Conflict: URL was already shortened previously
-//   - 400 Bad Request: Invalid URL format
+//   - 409 Conflict: URL was already shortened previously or is reserved by another user
+//   - 400 Bad Request: Invalid URL format, URL longer than max_url_length, URL violating the URL policy
+//     or unknown domain
+//   - 413 Request Entity Too Large: Request body exceeds the size limit
+//   - 403 Forbidden: Daily or active links quota of the user exceeded
 //   - 500 Internal Server Error: Internal server error
 //
 // Example request:
@@ -77,6 +109,10 @@ func (h *ShortenerHandler) HandlePostShortURLTextPlain(rw http.ResponseWriter, r
 	defer r.Body.Close()
 
 	bodyString, err := h.readBody(r)
+	if statusCode, message, ok := bodyReadError(err); ok {
+		http.Error(rw, message, statusCode)
+		return
+	}
 	if err != nil {
 		h.logger.Error("Failed to read body", zap.Error(err))
 		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
@@ -84,30 +120,44 @@ func (h *ShortenerHandler) HandlePostShortURLTextPlain(rw http.ResponseWriter, r
 	}
 
 	if err = h.validateURL(bodyString); err != nil {
-		http.Error(rw, "incorrect url", http.StatusBadRequest)
+		http.Error(rw, validationErrorMessage(err), http.StatusBadRequest)
+		return
+	}
+	domain, err := h.chosenDomain(r.URL.Query().Get("domain"))
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	shortURL, err := h.shortener.GenerateShortURLPart(r.Context(), userID, bodyString)
+	shortURL, err := h.shortener.GenerateShortURLPart(r.Context(), userID, domain, bodyString)
 	if err != nil {
-		h.handleGenerationError(rw, err, bodyString)
+		h.handleGenerationError(rw, err, domain, bodyString)
 		return
 	}
 
 	h.auditEvent(model.ActionShorten, userID, bodyString)
-	h.writeTextResponse(rw, http.StatusCreated, shortURL)
+	h.writeTextResponse(rw, http.StatusCreated, domain, shortURL)
 }
 
 // HandleGetShortURLRedirect handles GET requests to redirect to original URLs.
-// Looks up the original URL by short identifier and performs redirect.
+// Looks up the original URL by short identifier in the domain of the request Host header
+// and performs redirect. Follows by bots are audited as bot follows, link preview fetchers
+// of chats and social networks get a page with Open Graph tags of the destination instead of a redirect.
 //
 // Path parameters:
 //   - shortURL: Short URL identifier in the URL path
 //
 // Responses:
-//   - 307 Temporary Redirect: Successful redirect to original URL
-//   - 410 Gone: Short URL has been deleted
+//   - 307 Temporary Redirect: Successful redirect to original URL, or to the bundle link selected
+//     by the link query parameter
+//   - 200 OK: HTML landing page when the short URL is a bundle, a warning page
+//     when the link or a destination of the bundle was flagged by phishing heuristics and waits for review,
+//     or an Open Graph page for link preview fetchers
+//   - 410 Gone: Short URL has been deleted, or disabled by an administrator or for a banned owner,
+//     with an HTML takedown page for disabled links
+//   - 451 Unavailable For Legal Reasons: HTML takedown page of a link taken down on a legal demand
 //   - 400 Bad Request: Missing or invalid short URL parameter
+//   - 404 Not Found: Bundle link selected by the link query parameter does not exist
 //   - 500 Internal Server Error: Internal server error
 //
 // Example request:
@@ -125,10 +175,12 @@ func (h *ShortenerHandler) HandleGetShortURLRedirect(rw http.ResponseWriter, r *
 		return
 	}
 
-	resultURL, err := h.shortener.GetURLByShortURLPart(r.Context(), shortURL)
+	domain := h.domainByHost(r.Host)
+	resultURL, err := h.shortener.GetURLByShortURLPart(r.Context(), domain, shortURL)
 	if err != nil {
 		h.logger.Error("Failed to get url by short url",
 			zap.Error(err),
+			zap.String("domain", domain),
 			zap.String("shortURL", shortURL),
 		)
 		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
@@ -139,8 +191,31 @@ func (h *ShortenerHandler) HandleGetShortURLRedirect(rw http.ResponseWriter, r *
 		rw.WriteHeader(http.StatusGone)
 		return
 	}
+	if resultURL.IsDisabled {
+		h.writeTakedownPage(rw, resultURL)
+		return
+	}
+
+	bot, isBot := h.botDetector.Detect(r.UserAgent())
+	if resultURL.IsBundle {
+		if resultURL.IsUnderReview() {
+			h.writeWarningPage(rw, resultURL)
+			return
+		}
+		h.handleBundlePage(rw, r, domain, shortURL, bot, isBot)
+		return
+	}
 
-	h.auditEvent(model.ActionFollow, getUserIDFromContext(r), resultURL.OriginalURL)
+	if resultURL.IsUnderReview() {
+		h.writeWarningPage(rw, resultURL)
+		return
+	}
+	h.auditFollow(r, bot, isBot, resultURL.OriginalURL)
+	if isBot && bot.Unfurler {
+		h.writeUnfurlPage(rw, resultURL)
+		return
+	}
+	rw.Header().Set("Vary", "User-Agent")
 	rw.Header().Set("Content-Type", "text/plain")
 	rw.Header().Set("Location", resultURL.OriginalURL)
 	rw.WriteHeader(http.StatusTemporaryRedirect)
@@ -151,12 +226,15 @@ func (h *ShortenerHandler) HandleGetShortURLRedirect(rw http.ResponseWriter, r *
 //
 // Request format:
 //
-//	{"url": "https://example.com/very-long-url"}
+//	{"url": "https://example.com/very-long-url", "domain": "go.brand.com"}
 //
 // Responses:
 //   - 201 Created: Short URL successfully created
-//   - 409 Conflict: URL was already shortened previously
-//   - 400 Bad Request: Invalid JSON or URL format
+//   - 409 Conflict: URL was already shortened previously or is reserved by another user
+//   - 400 Bad Request: Invalid JSON, URL format, URL longer than max_url_length, URL violating the URL policy
+//     or unknown domain
+//   - 413 Request Entity Too Large: Request body exceeds the size limit
+//   - 403 Forbidden: Daily or active links quota of the user exceeded
 //   - 500 Internal Server Error: Internal server error
 //
 // Example response:
@@ -171,26 +249,33 @@ func (h *ShortenerHandler) HandlePostShortURLJSON(rw http.ResponseWriter, r *htt
 
 	defer r.Body.Close()
 	var request model.ShortenJSONRequest
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, "incorrect json")
+	if err := decodeJSONBody(r, &request); err != nil {
+		statusCode, message := jsonBodyError(err)
+		h.writeShortenJSONErrorResponse(rw, statusCode, message)
 		return
 	}
 	if err := h.validateURL(request.URL); err != nil {
-		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, "incorrect url")
+		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, validationErrorMessage(err))
 		return
 	}
-	shortURL, err := h.shortener.GenerateShortURLPart(r.Context(), userID, request.URL)
+	domain, err := h.chosenDomain(request.Domain)
 	if err != nil {
-		h.handleJSONGenerationError(rw, err, request.URL)
+		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, err.Error())
+		return
+	}
+	shortURL, err := h.shortener.GenerateShortURLPart(r.Context(), userID, domain, request.URL)
+	if err != nil {
+		h.handleJSONGenerationError(rw, err, domain, request.URL)
 		return
 	}
 
 	h.auditEvent(model.ActionShorten, userID, request.URL)
-	h.writeShortenJSONSuccessResponse(rw, http.StatusCreated, shortURL)
+	h.writeShortenJSONSuccessResponse(rw, http.StatusCreated, domain, shortURL)
 }
 
 // HandlePostShortURLBatchJSON handles POST requests for batch URL shortening.
 // Accepts multiple URLs with correlation IDs and returns shortened versions.
+// The optional domain query parameter selects one of the configured vanity domains.
 //
 // Request format:
 //
@@ -201,7 +286,11 @@ func (h *ShortenerHandler) HandlePostShortURLJSON(rw http.ResponseWriter, r *htt
 //
 // Responses:
 //   - 201 Created: Batch processing completed successfully
-//   - 400 Bad Request: Invalid JSON or URL format
+//   - 400 Bad Request: Invalid JSON, URL format, URL longer than max_url_length, URL violating the URL policy
+//     or unknown domain
+//   - 413 Request Entity Too Large: Request body exceeds the size limit
+//   - 403 Forbidden: Batch size, daily or active links quota of the user exceeded
+//   - 409 Conflict: One of the URLs is reserved by another user
 //   - 500 Internal Server Error: Internal server error
 //
 // Example response:
@@ -219,18 +308,38 @@ func (h *ShortenerHandler) HandlePostShortURLBatchJSON(rw http.ResponseWriter, r
 
 	request, err := h.decodeBatchRequest(r)
 	if err != nil {
-		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, "incorrect json")
+		statusCode, message := jsonBodyError(err)
+		h.writeShortenJSONErrorResponse(rw, statusCode, message)
 		return
 	}
 
 	for _, requestItem := range request {
 		if err = h.validateURL(requestItem.OriginalURL); err != nil {
+			var violationErr *service.URLPolicyViolationError
+			if errors.Is(err, errURLTooLong) || errors.As(err, &violationErr) {
+				h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest,
+					err.Error()+" for correlation_id "+requestItem.CorrelationID)
+				return
+			}
 			h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, "incorrect url "+requestItem.OriginalURL)
 			return
 		}
 	}
+	domain, err := h.chosenDomain(r.URL.Query().Get("domain"))
+	if err != nil {
+		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	shortURLs, err := h.shortener.GenerateShortURLPartBatch(r.Context(), userID, request)
+	shortURLs, err := h.shortener.GenerateShortURLPartBatch(r.Context(), userID, domain, request)
+	if errors.Is(err, repository.ErrURLReserved) {
+		h.writeShortenJSONErrorResponse(rw, http.StatusConflict, "url is reserved")
+		return
+	}
+	if isQuotaExceeded(err) {
+		h.writeShortenJSONErrorResponse(rw, http.StatusForbidden, err.Error())
+		return
+	}
 	if err != nil {
 		h.logger.Error("Failed to generate short URLs batch",
 			zap.Error(err),
@@ -239,12 +348,96 @@ func (h *ShortenerHandler) HandlePostShortURLBatchJSON(rw http.ResponseWriter, r
 		return
 	}
 
-	response := h.buildBatchResponse(shortURLs)
+	response := h.buildBatchResponse(domain, shortURLs)
 	h.writeJSONResponse(rw, http.StatusCreated, response)
 }
 
+// HandlePostBundleJSON handles POST requests to create a bundle of links.
+// A bundle is a short URL resolving to a page listing several titled destinations.
+//
+// Request format:
+//
+//	{
+//	  "title": "Conference materials",
+//	  "domain": "go.brand.com",
+//	  "links": [
+//	    {"title": "Slides", "url": "https://example.com/slides.pdf", "position": 1},
+//	    {"title": "Recording", "url": "https://example.com/video", "position": 2}
+//	  ]
+//	}
+//
+// Responses:
+//   - 201 Created: Bundle successfully created
+//   - 400 Bad Request: Invalid JSON, no links, too many links, invalid link URL, link URL violating the URL policy
+//     or unknown domain
+//   - 413 Request Entity Too Large: Request body exceeds the size limit
+//   - 403 Forbidden: Daily or active links quota of the user exceeded
+//   - 500 Internal Server Error: Internal server error
+//
+// Example response:
+//
+//	HTTP/1.1 201 Created
+//	Content-Type: application/json
+//
+//	{"result": "http://localhost:8080/abc123def"}
+func (h *ShortenerHandler) HandlePostBundleJSON(rw http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	rw.Header().Set("Content-Type", "application/json")
+
+	defer r.Body.Close()
+	var request model.BundleJSONRequest
+	if err := decodeJSONBody(r, &request); err != nil {
+		statusCode, message := jsonBodyError(err)
+		h.writeShortenJSONErrorResponse(rw, statusCode, message)
+		return
+	}
+	if request.Title == "" {
+		request.Title = defaultBundleTitle
+	}
+	if len(request.Links) == 0 {
+		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, "bundle has no links")
+		return
+	}
+	if len(request.Links) > maxBundleLinks {
+		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, "too many links in bundle")
+		return
+	}
+	for i, link := range request.Links {
+		if err := h.validateURL(link.URL); err != nil {
+			h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, validationErrorMessage(err)+" "+link.URL)
+			return
+		}
+		if link.Title == "" {
+			request.Links[i].Title = link.URL
+		}
+	}
+
+	domain, err := h.chosenDomain(request.Domain)
+	if err != nil {
+		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	shortURL, err := h.shortener.GenerateBundle(r.Context(), userID, domain, request.Title, request.Links)
+	if isQuotaExceeded(err) {
+		h.writeShortenJSONErrorResponse(rw, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to generate bundle", zap.Error(err))
+		h.writeShortenJSONErrorResponse(rw, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	for _, link := range request.Links {
+		h.auditEvent(model.ActionShorten, userID, link.URL)
+	}
+	h.writeShortenJSONSuccessResponse(rw, http.StatusCreated, domain, shortURL)
+}
+
 // HandleGetUserURLsJSON handles GET requests to retrieve user's URLs.
-// Returns all URLs created by the authenticated user.
+// Returns all URLs created by the authenticated user with the last health check
+// and the fetched title, description and preview image of their destinations.
 //
 // Responses:
 //   - 200 OK: User URLs retrieved successfully
@@ -258,7 +451,9 @@ func (h *ShortenerHandler) HandlePostShortURLBatchJSON(rw http.ResponseWriter, r
 //	Content-Type: application/json
 //
 //	[
-//	  {"short_url": "http://localhost:8080/abc123", "original_url": "https://example.com/url1"},
+//	  {"short_url": "http://localhost:8080/abc123", "original_url": "https://example.com/url1",
+//	   "health": {"status": "broken", "status_code": 404, "checked_at": "2026-10-18T12:00:00Z"},
+//	   "metadata": {"title": "Example Domain", "fetched_at": "2026-10-18T12:00:00Z"}},
 //	  {"short_url": "http://localhost:8080/def456", "original_url": "https://example.com/url2"}
 //	]
 func (h *ShortenerHandler) HandleGetUserURLsJSON(rw http.ResponseWriter, r *http.Request) {
@@ -287,8 +482,82 @@ func (h *ShortenerHandler) HandleGetUserURLsJSON(rw http.ResponseWriter, r *http
 	h.writeJSONResponse(rw, http.StatusOK, response)
 }
 
+// HandleGetLookupJSON handles GET requests finding the existing short URL of an original URL.
+// The original URL is passed in the url query parameter and canonicalized like destinations of new links.
+// Only links of the authenticated user are found, unless lookup_global is enabled. No link is created.
+//
+// Query parameters:
+//   - url: original URL to look up
+//   - domain: vanity domain of the link, empty for the base URL domain
+//
+// Responses:
+//   - 200 OK: Link to the original URL found
+//   - 400 Bad Request: Missing or invalid URL, or unknown domain
+//   - 401 Unauthorized: User not authenticated
+//   - 404 Not Found: No link to the original URL exists, or the link is disabled or held for review
+//   - 500 Internal Server Error: Internal server error
+//
+// Example request:
+//
+//	GET /api/lookup?url=https%3A%2F%2Fexample.com%2Furl1
+//
+// Example response:
+//
+//	HTTP/1.1 200 OK
+//	Content-Type: application/json
+//
+//	{"short_url": "http://localhost:8080/abc123", "original_url": "https://example.com/url1"}
+func (h *ShortenerHandler) HandleGetLookupJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	userID := getUserIDFromContext(r)
+	if userID == "" {
+		h.writeShortenJSONErrorResponse(rw, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+
+	originalURL := r.URL.Query().Get("url")
+	if len(originalURL) > h.maxURLLength() {
+		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, errURLTooLong.Error())
+		return
+	}
+	if _, err := url.ParseRequestURI(originalURL); err != nil {
+		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, "incorrect url")
+		return
+	}
+	domain, err := h.chosenDomain(r.URL.Query().Get("domain"))
+	if err != nil {
+		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, err.Error())
+		return
+	}
+	ownerID := userID
+	if h.cfg.LookupGlobal {
+		ownerID = ""
+	}
+
+	foundURL, err := h.shortener.LookupShortURLPart(r.Context(), ownerID, domain, originalURL)
+	if errors.Is(err, repository.ErrNotFound) {
+		h.writeShortenJSONErrorResponse(rw, http.StatusNotFound, "short url not found")
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to look up short url", zap.Error(err), zap.String("userID", userID))
+		h.writeShortenJSONErrorResponse(rw, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	fullShortURL, err := h.buildFullURL(foundURL.Domain, foundURL.ShortURL)
+	if err != nil {
+		h.logger.Error("Failed to build result url", zap.Error(err))
+		h.writeShortenJSONErrorResponse(rw, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	h.writeJSONResponse(rw, http.StatusOK, model.NewUserURLResponseItem(fullShortURL, foundURL.OriginalURL))
+}
+
 // HandleDeleteShortURLsBatchJSON handles DELETE requests to mark URLs as deleted.
-// Accepts a list of short URLs to mark as deleted (async processing).
+// Accepts a list of short URLs of one domain to mark as deleted (async processing).
+//
+// Query parameters:
+//   - domain: vanity domain of the links, empty for the base URL domain
 //
 // Request format:
 //
@@ -296,7 +565,9 @@ func (h *ShortenerHandler) HandleGetUserURLsJSON(rw http.ResponseWriter, r *http
 //
 // Responses:
 //   - 202 Accepted: Deletion request accepted for processing
-//   - 400 Bad Request: Invalid JSON format
+//   - 400 Bad Request: Invalid JSON format or unknown domain
+//   - 413 Request Entity Too Large: Request body exceeds the size limit
+//   - 403 Forbidden: Delete batch size quota of the user exceeded
 //   - 429 Too Many Requests: Deletion queue is full
 //
 // Example request:
@@ -309,18 +580,30 @@ func (h *ShortenerHandler) HandleDeleteShortURLsBatchJSON(rw http.ResponseWriter
 	userID := getUserIDFromContext(r)
 	rw.Header().Set("Content-Type", "application/json")
 
+	domain, err := h.chosenDomain(r.URL.Query().Get("domain"))
+	if err != nil {
+		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	defer r.Body.Close()
 	var shortURLs []string
-	if err := json.NewDecoder(r.Body).Decode(&shortURLs); err != nil {
-		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, "incorrect json")
+	if err = decodeJSONBody(r, &shortURLs); err != nil {
+		statusCode, message := jsonBodyError(err)
+		h.writeShortenJSONErrorResponse(rw, statusCode, message)
 		return
 	}
 
-	err := h.shortener.DeleteUserShortURLsBatch(r.Context(), userID, shortURLs)
+	err = h.shortener.DeleteUserShortURLsBatch(r.Context(), userID, domain, shortURLs)
+	if isQuotaExceeded(err) {
+		h.writeShortenJSONErrorResponse(rw, http.StatusForbidden, err.Error())
+		return
+	}
 	if err != nil {
 		h.logger.Error("Failed to delete short URLs for user",
 			zap.Error(err),
 			zap.String("userID", userID),
+			zap.String("domain", domain),
 			zap.Strings("shortURLs", shortURLs),
 		)
 		h.writeShortenJSONErrorResponse(rw, http.StatusTooManyRequests, http.StatusText(http.StatusTooManyRequests))
@@ -330,6 +613,132 @@ func (h *ShortenerHandler) HandleDeleteShortURLsBatchJSON(rw http.ResponseWriter
 	rw.WriteHeader(http.StatusAccepted)
 }
 
+// HandleGetUserTrashURLsJSON handles GET requests to retrieve user's deleted URLs.
+// Returns all URLs of the authenticated user that are in the trash and not purged yet.
+//
+// Responses:
+//   - 200 OK: Deleted URLs retrieved successfully
+//   - 204 No Content: User's trash is empty
+//   - 401 Unauthorized: User not authenticated
+//   - 500 Internal Server Error: Internal server error
+//
+// Example response:
+//
+//	HTTP/1.1 200 OK
+//	Content-Type: application/json
+//
+//	[
+//	  {"short_url": "http://localhost:8080/abc123", "original_url": "https://example.com/url1",
+//	   "deleted_at": "2025-09-01T10:00:00Z"}
+//	]
+func (h *ShortenerHandler) HandleGetUserTrashURLsJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	userID := getUserIDFromContext(r)
+	if userID == "" {
+		h.writeShortenJSONErrorResponse(rw, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+
+	deletedURLs, err := h.shortener.GetDeletedURLsByUserID(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to get deleted urls for user", zap.Error(err), zap.String("userID", userID))
+		h.writeShortenJSONErrorResponse(rw, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	if len(deletedURLs) == 0 {
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	response := h.buildTrashURLsResponse(deletedURLs)
+	h.writeJSONResponse(rw, http.StatusOK, response)
+}
+
+// HandleRestoreShortURLsBatchJSON handles POST requests to restore URLs from the trash.
+// Accepts a list of short URLs of one domain to restore. URLs of other users are ignored.
+//
+// Query parameters:
+//   - domain: vanity domain of the links, empty for the base URL domain
+//
+// Request format:
+//
+//	["abc123def", "xyz456ghi"]
+//
+// Responses:
+//   - 204 No Content: URLs restored
+//   - 400 Bad Request: Invalid JSON format or unknown domain
+//   - 413 Request Entity Too Large: Request body exceeds the size limit
+//   - 401 Unauthorized: User not authenticated
+//   - 500 Internal Server Error: Internal server error
+//
+// Example request:
+//
+//	POST /api/user/urls/restore HTTP/1.1
+//	Content-Type: application/json
+//
+//	["abc123def", "xyz456ghi"]
+func (h *ShortenerHandler) HandleRestoreShortURLsBatchJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	userID := getUserIDFromContext(r)
+	if userID == "" {
+		h.writeShortenJSONErrorResponse(rw, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+
+	domain, err := h.chosenDomain(r.URL.Query().Get("domain"))
+	if err != nil {
+		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defer r.Body.Close()
+	var shortURLs []string
+	if err = decodeJSONBody(r, &shortURLs); err != nil {
+		statusCode, message := jsonBodyError(err)
+		h.writeShortenJSONErrorResponse(rw, statusCode, message)
+		return
+	}
+
+	err = h.shortener.RestoreUserShortURLsBatch(r.Context(), userID, domain, shortURLs)
+	if err != nil {
+		h.logger.Error("Failed to restore short URLs for user",
+			zap.Error(err),
+			zap.String("userID", userID),
+			zap.String("domain", domain),
+			zap.Strings("shortURLs", shortURLs),
+		)
+		h.writeShortenJSONErrorResponse(rw, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// HandleGetInternalStatsJSON handles GET requests for service statistics.
+// Must be restricted to the trusted subnet by the router.
+//
+// Responses:
+//   - 200 OK: Statistics returned
+//   - 500 Internal Server Error: Internal server error
+//
+// Example response:
+//
+//	HTTP/1.1 200 OK
+//	Content-Type: application/json
+//
+//	{"urls": 1024, "users": 37}
+func (h *ShortenerHandler) HandleGetInternalStatsJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	stats, err := h.shortener.GetStats(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to get stats", zap.Error(err))
+		h.writeShortenJSONErrorResponse(rw, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	h.writeJSONResponse(rw, http.StatusOK, stats)
+}
+
 // HandlePingRepository handles health check requests to verify storage connectivity.
 //
 // Responses:
@@ -348,6 +757,44 @@ func (h *ShortenerHandler) HandlePingRepository(rw http.ResponseWriter, r *http.
 	rw.WriteHeader(http.StatusOK)
 }
 
+func (h *ShortenerHandler) handleBundlePage(rw http.ResponseWriter,
+	r *http.Request,
+	domain string,
+	shortURL string,
+	bot model.BotSignature,
+	isBot bool,
+) {
+	bundle, err := h.shortener.GetBundleByShortURLPart(r.Context(), domain, shortURL)
+	if err != nil {
+		h.logger.Error("Failed to get bundle by short url",
+			zap.Error(err),
+			zap.String("domain", domain),
+			zap.String("shortURL", shortURL),
+		)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if !r.URL.Query().Has(bundleLinkParam) {
+		if isBot && bot.Unfurler {
+			h.writeBundleUnfurlPage(rw, bundle)
+			return
+		}
+		rw.Header().Set("Vary", "User-Agent")
+		h.writeBundlePage(rw, bundle)
+		return
+	}
+	link, ok := bundleLinkByIndex(bundle, r.URL.Query().Get(bundleLinkParam))
+	if !ok {
+		http.Error(rw, "bundle link not found", http.StatusNotFound)
+		return
+	}
+	h.auditFollow(r, bot, isBot, link.URL)
+	rw.Header().Set("Content-Type", "text/plain")
+	rw.Header().Set("Location", link.URL)
+	rw.WriteHeader(http.StatusTemporaryRedirect)
+}
+
 func (h *ShortenerHandler) readBody(r *http.Request) (string, error) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -357,18 +804,34 @@ func (h *ShortenerHandler) readBody(r *http.Request) (string, error) {
 }
 
 func (h *ShortenerHandler) validateURL(rawURL string) error {
-	_, err := url.ParseRequestURI(rawURL)
-	return err
+	if len(rawURL) > h.maxURLLength() {
+		return errURLTooLong
+	}
+	if _, err := url.ParseRequestURI(rawURL); err != nil {
+		return err
+	}
+	return h.urlPolicy.Check(rawURL)
 }
 
-func (h *ShortenerHandler) buildFullURL(shortURL string) (string, error) {
-	return url.JoinPath(h.cfg.BaseURL, shortURL)
+func (h *ShortenerHandler) maxURLLength() int {
+	if h.cfg.MaxURLLength > 0 {
+		return h.cfg.MaxURLLength
+	}
+	return defaultMaxURLLength
 }
 
-func (h *ShortenerHandler) handleGenerationError(rw http.ResponseWriter, err error, originalURL string) {
+func validationErrorMessage(err error) string {
+	var violationErr *service.URLPolicyViolationError
+	if errors.Is(err, errURLTooLong) || errors.As(err, &violationErr) {
+		return err.Error()
+	}
+	return "incorrect url"
+}
+
+func (h *ShortenerHandler) handleGenerationError(rw http.ResponseWriter, err error, domain string, originalURL string) {
 	var uniqueURLErr *repository.ErrURLConflict
 	if errors.As(err, &uniqueURLErr) {
-		resultURL, err := h.buildFullURL(uniqueURLErr.ShortURL)
+		resultURL, err := h.buildFullURL(domain, uniqueURLErr.ShortURL)
 		if err != nil {
 			h.logger.Error("Failed to build result url", zap.Error(err))
 			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
@@ -379,6 +842,14 @@ func (h *ShortenerHandler) handleGenerationError(rw http.ResponseWriter, err err
 		rw.Write([]byte(resultURL))
 		return
 	}
+	if errors.Is(err, repository.ErrURLReserved) {
+		http.Error(rw, "url is reserved", http.StatusConflict)
+		return
+	}
+	if isQuotaExceeded(err) {
+		http.Error(rw, err.Error(), http.StatusForbidden)
+		return
+	}
 
 	h.logger.Error("Failed to generate short URL",
 		zap.Error(err),
@@ -387,10 +858,22 @@ func (h *ShortenerHandler) handleGenerationError(rw http.ResponseWriter, err err
 	http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 }
 
-func (h *ShortenerHandler) handleJSONGenerationError(rw http.ResponseWriter, err error, originalURL string) {
+func (h *ShortenerHandler) handleJSONGenerationError(rw http.ResponseWriter,
+	err error,
+	domain string,
+	originalURL string,
+) {
 	var uniqueURLErr *repository.ErrURLConflict
 	if errors.As(err, &uniqueURLErr) {
-		h.writeShortenJSONSuccessResponse(rw, http.StatusConflict, uniqueURLErr.ShortURL)
+		h.writeShortenJSONSuccessResponse(rw, http.StatusConflict, domain, uniqueURLErr.ShortURL)
+		return
+	}
+	if errors.Is(err, repository.ErrURLReserved) {
+		h.writeShortenJSONErrorResponse(rw, http.StatusConflict, "url is reserved")
+		return
+	}
+	if isQuotaExceeded(err) {
+		h.writeShortenJSONErrorResponse(rw, http.StatusForbidden, err.Error())
 		return
 	}
 
@@ -401,8 +884,8 @@ func (h *ShortenerHandler) handleJSONGenerationError(rw http.ResponseWriter, err
 	h.writeShortenJSONErrorResponse(rw, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
 }
 
-func (h *ShortenerHandler) writeTextResponse(rw http.ResponseWriter, statusCode int, shortURL string) {
-	resultURL, err := h.buildFullURL(shortURL)
+func (h *ShortenerHandler) writeTextResponse(rw http.ResponseWriter, statusCode int, domain string, shortURL string) {
+	resultURL, err := h.buildFullURL(domain, shortURL)
 	if err != nil {
 		h.logger.Error("Failed to build result url", zap.Error(err))
 		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
@@ -415,18 +898,21 @@ func (h *ShortenerHandler) writeTextResponse(rw http.ResponseWriter, statusCode
 
 func (h *ShortenerHandler) decodeBatchRequest(r *http.Request) ([]model.ShortenBatchRequestItem, error) {
 	var request []model.ShortenBatchRequestItem
-	err := json.NewDecoder(r.Body).Decode(&request)
+	err := decodeJSONBody(r, &request)
 	return request, err
 }
 
-func (h *ShortenerHandler) buildBatchResponse(shortURLs []model.ShortenBatchResponseItem) []model.ShortenBatchResponseItem {
+func (h *ShortenerHandler) buildBatchResponse(domain string,
+	shortURLs []model.ShortenBatchResponseItem,
+) []model.ShortenBatchResponseItem {
 	var response []model.ShortenBatchResponseItem
 	for _, shortURL := range shortURLs {
-		fullShortURL, err := h.buildFullURL(shortURL.ShortURL)
+		fullShortURL, err := h.buildFullURL(domain, shortURL.ShortURL)
 		if err != nil {
 			h.logger.Error("Failed to generate full short URL",
 				zap.Error(err),
 				zap.String("baseURL", h.cfg.BaseURL),
+				zap.String("domain", domain),
 				zap.String("shortURL", shortURL.ShortURL),
 				zap.String("correlationID", shortURL.CorrelationID),
 			)
@@ -440,22 +926,49 @@ func (h *ShortenerHandler) buildBatchResponse(shortURLs []model.ShortenBatchResp
 func (h *ShortenerHandler) buildUserURLsResponse(userURLs []model.URL) []model.UserURLResponseItem {
 	var response []model.UserURLResponseItem
 	for _, userURL := range userURLs {
-		fullShortURL, err := h.buildFullURL(userURL.ShortURL)
+		fullShortURL, err := h.buildFullURL(userURL.Domain, userURL.ShortURL)
 		if err != nil {
 			h.logger.Error("Failed to generate full short URL",
 				zap.Error(err),
 				zap.String("baseURL", h.cfg.BaseURL),
+				zap.String("domain", userURL.Domain),
 				zap.String("shortURL", userURL.ShortURL),
 			)
 			continue
 		}
-		response = append(response, *model.NewUserURLResponseItem(fullShortURL, userURL.OriginalURL))
+		item := model.NewUserURLResponseItem(fullShortURL, userURL.OriginalURL)
+		item.Health = userURL.Health
+		item.Metadata = userURL.Metadata
+		response = append(response, *item)
+	}
+	return response
+}
+
+func (h *ShortenerHandler) buildTrashURLsResponse(deletedURLs []model.URL) []model.TrashURLResponseItem {
+	var response []model.TrashURLResponseItem
+	for _, deletedURL := range deletedURLs {
+		fullShortURL, err := h.buildFullURL(deletedURL.Domain, deletedURL.ShortURL)
+		if err != nil {
+			h.logger.Error("Failed to generate full short URL",
+				zap.Error(err),
+				zap.String("baseURL", h.cfg.BaseURL),
+				zap.String("domain", deletedURL.Domain),
+				zap.String("shortURL", deletedURL.ShortURL),
+			)
+			continue
+		}
+		response = append(response,
+			*model.NewTrashURLResponseItem(fullShortURL, deletedURL.OriginalURL, deletedURL.DeletedAt))
 	}
 	return response
 }
 
-func (h *ShortenerHandler) writeShortenJSONSuccessResponse(rw http.ResponseWriter, statusCode int, shortURL string) {
-	fullURL, err := h.buildFullURL(shortURL)
+func (h *ShortenerHandler) writeShortenJSONSuccessResponse(rw http.ResponseWriter,
+	statusCode int,
+	domain string,
+	shortURL string,
+) {
+	fullURL, err := h.buildFullURL(domain, shortURL)
 	if err != nil {
 		h.logger.Error("Failed to build full URL", zap.Error(err))
 		h.writeShortenJSONErrorResponse(rw, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
@@ -493,3 +1006,48 @@ func (h *ShortenerHandler) auditEvent(action model.AuditAction, userID string, u
 	event := model.NewAuditEvent(time.Now().Unix(), action, userID, url)
 	h.auditService.NotifyAll(*event)
 }
+
+// auditFollow notifies audit observers of a followed destination, as a bot follow for bot traffic.
+// Only destinations the request is sent to are audited, warning pages of links under review are not.
+//
+// Parameters:
+//   - r: HTTP request following the link
+//   - bot: signature of the detected bot
+//   - isBot: true if the request comes from a bot
+//   - url: followed destination URL
+func (h *ShortenerHandler) auditFollow(r *http.Request, bot model.BotSignature, isBot bool, url string) {
+	if isBot {
+		h.auditBotFollow(bot.Name, getUserIDFromContext(r), url)
+		return
+	}
+	h.auditEvent(model.ActionFollow, getUserIDFromContext(r), url)
+}
+
+// auditBotFollow records a short URL fetched by a bot.
+// Bot follows are audited with their own action, so that they do not count as follows.
+//
+// Parameters:
+//   - bot: name of the matching bot signature
+//   - userID: identifier of the authenticated user, usually empty
+//   - url: original URL of the link
+func (h *ShortenerHandler) auditBotFollow(bot string, userID string, url string) {
+	if h.auditService == nil {
+		return
+	}
+
+	event := model.NewAuditEvent(time.Now().Unix(), model.ActionBotFollow, userID, url)
+	event.Bot = bot
+	h.auditService.NotifyAll(*event)
+}
+
+// isQuotaExceeded reports whether the error is a quota of the user plan being exceeded.
+//
+// Parameters:
+//   - err: error returned by the shortener
+//
+// Returns:
+//   - bool: true for *service.QuotaExceededError
+func isQuotaExceeded(err error) bool {
+	var quotaErr *service.QuotaExceededError
+	return errors.As(err, &quotaErr)
+}
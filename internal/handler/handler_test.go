@@ -10,14 +10,17 @@ import (
 	"github.com/bezjen/shortener/internal/mocks"
 	"github.com/bezjen/shortener/internal/model"
 	"github.com/bezjen/shortener/internal/repository"
+	"github.com/bezjen/shortener/internal/service"
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 )
 
 func testConfig() config.Config {
@@ -33,11 +36,11 @@ func TestHandleGetShortURLRedirect(t *testing.T) {
 	testCfg := testConfig()
 	testLogger, _ := logger.NewLogger("debug")
 	mockShortener := new(mocks.Shortener)
-	mockShortener.On("GetURLByShortURLPart", mock.Anything, "qwerty12").
+	mockShortener.On("GetURLByShortURLPart", mock.Anything, "", "qwerty12").
 		Return(model.NewURL("qwerty12", "https://practicum.yandex.ru/"), nil)
 	deletedURL := model.NewURL("qwerty13", "https://practicum.yandex1.ru/")
 	deletedURL.IsDeleted = true
-	mockShortener.On("GetURLByShortURLPart", mock.Anything, "qwerty13").Return(deletedURL, nil)
+	mockShortener.On("GetURLByShortURLPart", mock.Anything, "", "qwerty13").Return(deletedURL, nil)
 	mockAudit := new(mocks.AuditService)
 	mockAudit.On("NotifyAll", mock.Anything).Return(nil)
 	h := NewShortenerHandler(testCfg, testLogger, mockShortener, mockAudit)
@@ -106,7 +109,7 @@ func TestHandlePostShortURLTextPlain(t *testing.T) {
 	testCfg := testConfig()
 	testLogger, _ := logger.NewLogger("debug")
 	mockShortener := new(mocks.Shortener)
-	mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "https://practicum.yandex.ru/").
+	mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "", "https://practicum.yandex.ru/").
 		Return("qwerty12", nil)
 	mockAudit := new(mocks.AuditService)
 	mockAudit.On("NotifyAll", mock.Anything).Return(nil)
@@ -158,7 +161,7 @@ func TestHandlePostShortURLJSON(t *testing.T) {
 	testCfg := testConfig()
 	testLogger, _ := logger.NewLogger("debug")
 	mockShortener := new(mocks.Shortener)
-	mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "https://practicum.yandex.ru/").
+	mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "", "https://practicum.yandex.ru/").
 		Return("qwerty12", nil)
 	mockAudit := new(mocks.AuditService)
 	mockAudit.On("NotifyAll", mock.Anything).Return(nil)
@@ -192,6 +195,27 @@ func TestHandlePostShortURLJSON(t *testing.T) {
 			expectedCode: http.StatusBadRequest,
 			expectedBody: `{"error":"incorrect url"}` + "\n",
 		},
+		{
+			name:         "Unknown field",
+			contentType:  "application/json",
+			body:         `{"url":"https://practicum.yandex.ru/","alias":"mine"}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"incorrect json: unknown field \"alias\""}` + "\n",
+		},
+		{
+			name:         "Field of wrong type",
+			contentType:  "application/json",
+			body:         `{"url":42}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"incorrect json: unexpected number in field \"url\""}` + "\n",
+		},
+		{
+			name:         "Data after JSON value",
+			contentType:  "application/json",
+			body:         `{"url":"https://practicum.yandex.ru/"} {"url":"https://example.com/"}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"incorrect json: unexpected data after json value"}` + "\n",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -213,11 +237,49 @@ func TestHandlePostShortURLJSON(t *testing.T) {
 	}
 }
 
+func TestHandlePost_RequestBodyTooLarge(t *testing.T) {
+	testCfg := testConfig()
+	testLogger, _ := logger.NewLogger("debug")
+	h := NewShortenerHandler(testCfg, testLogger, new(mocks.Shortener), new(mocks.AuditService))
+
+	tests := []struct {
+		name         string
+		body         string
+		handler      http.HandlerFunc
+		expectedBody string
+	}{
+		{
+			name:         "JSON",
+			body:         `{"url":"https://practicum.yandex.ru/` + strings.Repeat("a", 100) + `"}`,
+			handler:      h.HandlePostShortURLJSON,
+			expectedBody: `{"error":"request body too large, limit is 64 bytes"}` + "\n",
+		},
+		{
+			name:         "text",
+			body:         "https://practicum.yandex.ru/" + strings.Repeat("a", 100),
+			handler:      h.HandlePostShortURLTextPlain,
+			expectedBody: "request body too large, limit is 64 bytes\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			req.Body = http.MaxBytesReader(rr, req.Body, 64)
+
+			tt.handler(rr, req)
+
+			assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+		})
+	}
+}
+
 func TestHandlePostShortURLBatchJSON(t *testing.T) {
 	testCfg := testConfig()
 	testLogger, _ := logger.NewLogger("debug")
 	mockShortener := new(mocks.Shortener)
-	mockShortener.On("GenerateShortURLPartBatch", mock.Anything, mock.Anything,
+	mockShortener.On("GenerateShortURLPartBatch", mock.Anything, mock.Anything, "",
 		[]model.ShortenBatchRequestItem{*model.NewShortenBatchRequestItem(
 			"123", "https://practicum.yandex.ru/"),
 		}).Return(
@@ -306,6 +368,36 @@ func TestHandleGetUserURLsJSON(t *testing.T) {
 			expectedBody: `[{"short_url":"http://localhost:8080/qwerty12","original_url":"https://example.com/page1"},{"short_url":"http://localhost:8080/qwerty34","original_url":"https://example.com/page2"}]` + "\n",
 			expectJSON:   true,
 		},
+		{
+			name: "URLs with health checks and metadata",
+			mockSetup: func(m *mocks.Shortener) {
+				checked := *model.NewURL("qwerty12", "https://example.com/gone")
+				checked.Health = &model.URLHealth{
+					Status:     model.HealthBroken,
+					StatusCode: http.StatusNotFound,
+					CheckedAt:  time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+				}
+				fetched := *model.NewURL("qwerty34", "https://example.com/new")
+				fetched.Metadata = &model.URLMetadata{
+					Title:     "New page",
+					Image:     "https://example.com/og.png",
+					FetchedAt: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+				}
+				m.On("GetURLsByUserID", mock.Anything, "user123").Return(
+					[]model.URL{checked, fetched, *model.NewURL("qwerty56", "https://example.com/other")}, nil)
+			},
+			setupRequest: func(req *http.Request) {
+				ctx := context.WithValue(req.Context(), middleware.UserIDKey, "user123")
+				*req = *req.WithContext(ctx)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"short_url":"http://localhost:8080/qwerty12","original_url":"https://example.com/gone",` +
+				`"health":{"status":"broken","status_code":404,"checked_at":"2026-10-18T12:00:00Z"}},` +
+				`{"short_url":"http://localhost:8080/qwerty34","original_url":"https://example.com/new",` +
+				`"metadata":{"title":"New page","image":"https://example.com/og.png","fetched_at":"2026-10-18T12:00:00Z"}},` +
+				`{"short_url":"http://localhost:8080/qwerty56","original_url":"https://example.com/other"}]` + "\n",
+			expectJSON: true,
+		},
 		{
 			name: "No URLs for user - 204 No Content",
 			mockSetup: func(m *mocks.Shortener) {
@@ -377,6 +469,112 @@ func TestHandleGetUserURLsJSON(t *testing.T) {
 	}
 }
 
+func TestHandleGetLookupJSON(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	found := &model.URL{ShortURL: "qwerty12", Domain: "go.brand.com", OriginalURL: "https://example.com/a"}
+
+	tests := []struct {
+		name         string
+		query        string
+		userID       string
+		lookupGlobal bool
+		mockSetup    func(m *mocks.Shortener)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:   "Link of the user",
+			query:  "url=" + url.QueryEscape("https://example.com/a") + "&domain=GO.brand.com",
+			userID: "user123",
+			mockSetup: func(m *mocks.Shortener) {
+				m.On("LookupShortURLPart", mock.Anything, "user123", "go.brand.com", "https://example.com/a").
+					Return(found, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"short_url":"http://go.brand.com:8080/qwerty12","original_url":"https://example.com/a"}` + "\n",
+		},
+		{
+			name:         "Link of any user",
+			query:        "url=" + url.QueryEscape("https://example.com/a"),
+			userID:       "user123",
+			lookupGlobal: true,
+			mockSetup: func(m *mocks.Shortener) {
+				m.On("LookupShortURLPart", mock.Anything, "", "", "https://example.com/a").
+					Return(model.NewURL("qwerty12", "https://example.com/a"), nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"short_url":"http://localhost:8080/qwerty12","original_url":"https://example.com/a"}` + "\n",
+		},
+		{
+			name:   "No link",
+			query:  "url=" + url.QueryEscape("https://example.com/b"),
+			userID: "user123",
+			mockSetup: func(m *mocks.Shortener) {
+				m.On("LookupShortURLPart", mock.Anything, "user123", "", "https://example.com/b").
+					Return(nil, repository.ErrNotFound)
+			},
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"error":"short url not found"}` + "\n",
+		},
+		{
+			name:   "Storage error",
+			query:  "url=" + url.QueryEscape("https://example.com/b"),
+			userID: "user123",
+			mockSetup: func(m *mocks.Shortener) {
+				m.On("LookupShortURLPart", mock.Anything, "user123", "", "https://example.com/b").
+					Return(nil, errors.New("database error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"error":"Internal Server Error"}` + "\n",
+		},
+		{
+			name:         "Missing URL",
+			userID:       "user123",
+			mockSetup:    func(m *mocks.Shortener) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"incorrect url"}` + "\n",
+		},
+		{
+			name:         "Unknown domain",
+			query:        "url=" + url.QueryEscape("https://example.com/a") + "&domain=brand.link",
+			userID:       "user123",
+			mockSetup:    func(m *mocks.Shortener) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"unknown domain"}` + "\n",
+		},
+		{
+			name:         "Unauthorized",
+			query:        "url=" + url.QueryEscape("https://example.com/a"),
+			mockSetup:    func(m *mocks.Shortener) {},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `{"error":"Unauthorized"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg := testConfig()
+			testCfg.Domains = []string{"go.brand.com"}
+			testCfg.LookupGlobal = tt.lookupGlobal
+			mockShortener := new(mocks.Shortener)
+			tt.mockSetup(mockShortener)
+			h := NewShortenerHandler(testCfg, testLogger, mockShortener, new(mocks.AuditService))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/lookup?"+tt.query, nil)
+			if tt.userID != "" {
+				req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, tt.userID))
+			}
+			rr := httptest.NewRecorder()
+
+			h.HandleGetLookupJSON(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+			mockShortener.AssertExpectations(t)
+		})
+	}
+}
+
 func TestHandlePingRepository(t *testing.T) {
 	testCfg := testConfig()
 	testLogger, _ := logger.NewLogger("debug")
@@ -422,6 +620,51 @@ func TestHandlePingRepository(t *testing.T) {
 	}
 }
 
+func TestHandleGetInternalStatsJSON(t *testing.T) {
+	testCfg := testConfig()
+	testLogger, _ := logger.NewLogger("debug")
+
+	tests := []struct {
+		name         string
+		mockSetup    func(*mocks.Shortener)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name: "Stats returned",
+			mockSetup: func(m *mocks.Shortener) {
+				m.On("GetStats", mock.Anything).Return(&model.Stats{URLs: 1024, Users: 37}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"urls":1024,"users":37}`,
+		},
+		{
+			name: "Storage error",
+			mockSetup: func(m *mocks.Shortener) {
+				m.On("GetStats", mock.Anything).Return(nil, errors.New("connection failed"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"error":"Internal Server Error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockShortener := new(mocks.Shortener)
+			tt.mockSetup(mockShortener)
+			h := NewShortenerHandler(testCfg, testLogger, mockShortener, new(mocks.AuditService))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/internal/stats", nil)
+			rr := httptest.NewRecorder()
+
+			h.HandleGetInternalStatsJSON(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+		})
+	}
+}
+
 // BenchmarkHandlePostShortURLTextPlain измеряет производительность текстового POST
 func BenchmarkHandlePostShortURLTextPlain(b *testing.B) {
 	testCfg := testConfig()
@@ -434,7 +677,7 @@ func BenchmarkHandlePostShortURLTextPlain(b *testing.B) {
 		mockShortener := new(mocks.Shortener)
 		mockAudit := new(mocks.AuditService)
 
-		mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "https://practicum.yandex.ru/").
+		mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "", "https://practicum.yandex.ru/").
 			Return("qwerty12", nil)
 		mockAudit.On("NotifyAll", mock.Anything).Return()
 
@@ -464,7 +707,7 @@ func BenchmarkHandleGetShortURLRedirect(b *testing.B) {
 		mockAudit := new(mocks.AuditService)
 
 		url := model.NewURL("qwerty12", "https://practicum.yandex.ru/")
-		mockShortener.On("GetURLByShortURLPart", mock.Anything, "qwerty12").Return(url, nil)
+		mockShortener.On("GetURLByShortURLPart", mock.Anything, "", "qwerty12").Return(url, nil)
 		mockAudit.On("NotifyAll", mock.Anything).Return()
 
 		h := NewShortenerHandler(testCfg, testLogger, mockShortener, mockAudit)
@@ -495,7 +738,7 @@ func BenchmarkHandlePostShortURLJSON(b *testing.B) {
 		mockShortener := new(mocks.Shortener)
 		mockAudit := new(mocks.AuditService)
 
-		mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "https://practicum.yandex.ru/").
+		mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "", "https://practicum.yandex.ru/").
 			Return("qwerty12", nil)
 		mockAudit.On("NotifyAll", mock.Anything).Return()
 
@@ -527,7 +770,7 @@ func BenchmarkHandlePostShortURLBatchJSON(b *testing.B) {
 		mockShortener := new(mocks.Shortener)
 		mockAudit := new(mocks.AuditService)
 
-		mockShortener.On("GenerateShortURLPartBatch", mock.Anything, mock.Anything,
+		mockShortener.On("GenerateShortURLPartBatch", mock.Anything, mock.Anything, "",
 			[]model.ShortenBatchRequestItem{*model.NewShortenBatchRequestItem(
 				"123", "https://practicum.yandex.ru/"),
 			}).Return(
@@ -590,7 +833,7 @@ func TestHandlePostShortURLTextPlain_URLConflict(t *testing.T) {
 	conflictErr := &repository.ErrURLConflict{
 		ShortURL: "existing123",
 	}
-	mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "https://conflict.example.com").
+	mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "", "https://conflict.example.com").
 		Return("", conflictErr)
 
 	mockAudit := new(mocks.AuditService)
@@ -616,7 +859,7 @@ func TestHandlePostShortURLTextPlain_GenerationError(t *testing.T) {
 	mockShortener := new(mocks.Shortener)
 
 	// Мокируем общую ошибку генерации
-	mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "https://error.example.com").
+	mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "", "https://error.example.com").
 		Return("", errors.New("database error"))
 
 	mockAudit := new(mocks.AuditService)
@@ -643,7 +886,7 @@ func TestHandlePostShortURLJSON_URLConflict(t *testing.T) {
 	conflictErr := &repository.ErrURLConflict{
 		ShortURL: "existing456",
 	}
-	mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "https://conflict.example.com").
+	mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "", "https://conflict.example.com").
 		Return("", conflictErr)
 
 	mockAudit := new(mocks.AuditService)
@@ -673,7 +916,7 @@ func TestHandlePostShortURLJSON_GenerationError(t *testing.T) {
 	testLogger, _ := logger.NewLogger("debug")
 	mockShortener := new(mocks.Shortener)
 
-	mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "https://error.example.com").
+	mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "", "https://error.example.com").
 		Return("", errors.New("storage unavailable"))
 
 	mockAudit := new(mocks.AuditService)
@@ -698,6 +941,28 @@ func TestHandlePostShortURLJSON_GenerationError(t *testing.T) {
 	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
 }
 
+func TestHandlePost_QuotaExceeded(t *testing.T) {
+	testCfg := testConfig()
+	testLogger, _ := logger.NewLogger("debug")
+	mockShortener := new(mocks.Shortener)
+	quotaErr := &service.QuotaExceededError{Quota: model.QuotaLinksPerDay, Limit: 100}
+	mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "", "https://example.com").
+		Return("", quotaErr)
+	h := NewShortenerHandler(testCfg, testLogger, mockShortener, new(mocks.AuditService))
+
+	rr := httptest.NewRecorder()
+	h.HandlePostShortURLTextPlain(rr, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("https://example.com")))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, "links_per_day quota of 100 exceeded\n", rr.Body.String())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/shorten", strings.NewReader(`{"url":"https://example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	h.HandlePostShortURLJSON(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.JSONEq(t, `{"error":"links_per_day quota of 100 exceeded"}`, rr.Body.String())
+}
+
 func TestHandlePostShortURLTextPlain_URLConflict_BuildFullURLError(t *testing.T) {
 	// Создаем конфиг с невалидным BaseURL для тестирования ошибки построения полного URL
 	invalidCfg := config.Config{
@@ -714,7 +979,7 @@ func TestHandlePostShortURLTextPlain_URLConflict_BuildFullURLError(t *testing.T)
 	conflictErr := &repository.ErrURLConflict{
 		ShortURL: "existing123",
 	}
-	mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "https://conflict.example.com").
+	mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "", "https://conflict.example.com").
 		Return("", conflictErr)
 
 	mockAudit := new(mocks.AuditService)
@@ -750,7 +1015,7 @@ func TestHandlePostShortURLJSON_URLConflict_BuildFullURLError(t *testing.T) {
 	conflictErr := &repository.ErrURLConflict{
 		ShortURL: "existing456",
 	}
-	mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "https://conflict.example.com").
+	mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "", "https://conflict.example.com").
 		Return("", conflictErr)
 
 	mockAudit := new(mocks.AuditService)
@@ -777,10 +1042,12 @@ func TestHandlePostShortURLJSON_URLConflict_BuildFullURLError(t *testing.T) {
 
 func TestHandleDeleteShortURLsBatchJSON(t *testing.T) {
 	testCfg := testConfig()
+	testCfg.Domains = []string{"go.brand.com"}
 	testLogger, _ := logger.NewLogger("debug")
 
 	tests := []struct {
 		name         string
+		target       string
 		body         string
 		userID       string
 		mockSetup    func(*mocks.Shortener)
@@ -792,12 +1059,33 @@ func TestHandleDeleteShortURLsBatchJSON(t *testing.T) {
 			body:   `["abc123", "def456"]`,
 			userID: "user123",
 			mockSetup: func(m *mocks.Shortener) {
-				m.On("DeleteUserShortURLsBatch", mock.Anything, "user123", []string{"abc123", "def456"}).
+				m.On("DeleteUserShortURLsBatch", mock.Anything, "user123", "", []string{"abc123", "def456"}).
+					Return(nil)
+			},
+			expectedCode: http.StatusAccepted,
+			expectedBody: "",
+		},
+		{
+			name:   "Vanity domain",
+			target: "/api/user/urls?domain=go.brand.com",
+			body:   `["abc123"]`,
+			userID: "user123",
+			mockSetup: func(m *mocks.Shortener) {
+				m.On("DeleteUserShortURLsBatch", mock.Anything, "user123", "go.brand.com", []string{"abc123"}).
 					Return(nil)
 			},
 			expectedCode: http.StatusAccepted,
 			expectedBody: "",
 		},
+		{
+			name:         "Unknown domain",
+			target:       "/api/user/urls?domain=evil.com",
+			body:         `["abc123"]`,
+			userID:       "user123",
+			mockSetup:    func(m *mocks.Shortener) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"unknown domain"}` + "\n",
+		},
 		{
 			name:         "Invalid JSON",
 			body:         `invalid json`,
@@ -811,7 +1099,7 @@ func TestHandleDeleteShortURLsBatchJSON(t *testing.T) {
 			body:   `["abc123", "def456"]`,
 			userID: "user123",
 			mockSetup: func(m *mocks.Shortener) {
-				m.On("DeleteUserShortURLsBatch", mock.Anything, "user123", []string{"abc123", "def456"}).
+				m.On("DeleteUserShortURLsBatch", mock.Anything, "user123", "", []string{"abc123", "def456"}).
 					Return(errors.New("queue full"))
 			},
 			expectedCode: http.StatusTooManyRequests,
@@ -834,7 +1122,11 @@ func TestHandleDeleteShortURLsBatchJSON(t *testing.T) {
 			mockAudit := new(mocks.AuditService)
 			h := NewShortenerHandler(testCfg, testLogger, mockShortener, mockAudit)
 
-			req := httptest.NewRequest(http.MethodDelete, "/api/user/urls", bytes.NewBufferString(tt.body))
+			target := tt.target
+			if target == "" {
+				target = "/api/user/urls"
+			}
+			req := httptest.NewRequest(http.MethodDelete, target, bytes.NewBufferString(tt.body))
 			req.Header.Set("Content-Type", "application/json")
 			ctx := context.WithValue(req.Context(), middleware.UserIDKey, tt.userID)
 			req = req.WithContext(ctx)
@@ -848,133 +1140,1537 @@ func TestHandleDeleteShortURLsBatchJSON(t *testing.T) {
 
 			assert.Equal(t, tt.expectedCode, res.StatusCode)
 			assert.Equal(t, tt.expectedBody, string(resBody))
+			mockShortener.AssertExpectations(t)
 		})
 	}
 }
 
-func TestReadBody(t *testing.T) {
+func TestHandleGetUserTrashURLsJSON(t *testing.T) {
 	testCfg := testConfig()
 	testLogger, _ := logger.NewLogger("debug")
-	h := NewShortenerHandler(testCfg, testLogger, nil, nil)
+	deletedAt := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
 
 	tests := []struct {
-		name        string
-		body        string
-		want        string
-		expectError bool
+		name         string
+		userID       string
+		mockSetup    func(*mocks.Shortener)
+		expectedCode int
+		expectedBody string
 	}{
 		{
-			name:        "Valid body",
-			body:        "test content",
-			want:        "test content",
-			expectError: false,
+			name:   "Trash with deleted URLs",
+			userID: "user123",
+			mockSetup: func(m *mocks.Shortener) {
+				m.On("GetDeletedURLsByUserID", mock.Anything, "user123").Return(
+					[]model.URL{{ShortURL: "qwerty12", OriginalURL: "https://example.com/page1", IsDeleted: true, DeletedAt: deletedAt}},
+					nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"short_url":"http://localhost:8080/qwerty12","original_url":"https://example.com/page1","deleted_at":"2025-09-01T10:00:00Z"}]` + "\n",
 		},
 		{
-			name:        "Empty body",
-			body:        "",
-			want:        "",
-			expectError: false,
+			name:   "Empty trash",
+			userID: "user123",
+			mockSetup: func(m *mocks.Shortener) {
+				m.On("GetDeletedURLsByUserID", mock.Anything, "user123").Return([]model.URL{}, nil)
+			},
+			expectedCode: http.StatusNoContent,
+			expectedBody: "",
+		},
+		{
+			name:         "Unauthorized",
+			userID:       "",
+			mockSetup:    func(m *mocks.Shortener) {},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `{"error":"Unauthorized"}` + "\n",
+		},
+		{
+			name:   "Storage error",
+			userID: "user123",
+			mockSetup: func(m *mocks.Shortener) {
+				m.On("GetDeletedURLsByUserID", mock.Anything, "user123").Return(nil, errors.New("db error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"error":"Internal Server Error"}` + "\n",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(tt.body))
-			result, err := h.readBody(req)
+			mockShortener := new(mocks.Shortener)
+			tt.mockSetup(mockShortener)
+			h := NewShortenerHandler(testCfg, testLogger, mockShortener, nil)
 
-			if tt.expectError {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tt.want, result)
-			}
+			req := httptest.NewRequest(http.MethodGet, "/api/user/urls/trash", nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, tt.userID))
+			rr := httptest.NewRecorder()
+
+			h.HandleGetUserTrashURLsJSON(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
 		})
 	}
 }
 
-func TestValidateURL(t *testing.T) {
+func TestHandleRestoreShortURLsBatchJSON(t *testing.T) {
 	testCfg := testConfig()
+	testCfg.Domains = []string{"go.brand.com"}
 	testLogger, _ := logger.NewLogger("debug")
-	h := NewShortenerHandler(testCfg, testLogger, nil, nil)
 
 	tests := []struct {
-		name    string
-		url     string
-		wantErr bool
+		name         string
+		target       string
+		body         string
+		userID       string
+		mockSetup    func(*mocks.Shortener)
+		expectedCode int
+		expectedBody string
 	}{
 		{
-			name:    "Valid HTTP URL",
-			url:     "http://example.com",
-			wantErr: false,
+			name:   "Successful restore",
+			body:   `["abc123", "def456"]`,
+			userID: "user123",
+			mockSetup: func(m *mocks.Shortener) {
+				m.On("RestoreUserShortURLsBatch", mock.Anything, "user123", "", []string{"abc123", "def456"}).
+					Return(nil)
+			},
+			expectedCode: http.StatusNoContent,
+			expectedBody: "",
 		},
 		{
-			name:    "Valid HTTPS URL",
-			url:     "https://example.com",
-			wantErr: false,
+			name:   "Vanity domain",
+			target: "/api/user/urls/restore?domain=go.brand.com",
+			body:   `["abc123"]`,
+			userID: "user123",
+			mockSetup: func(m *mocks.Shortener) {
+				m.On("RestoreUserShortURLsBatch", mock.Anything, "user123", "go.brand.com", []string{"abc123"}).
+					Return(nil)
+			},
+			expectedCode: http.StatusNoContent,
+			expectedBody: "",
 		},
 		{
-			name:    "Invalid URL",
-			url:     "not-a-url",
-			wantErr: true,
+			name:         "Unknown domain",
+			target:       "/api/user/urls/restore?domain=evil.com",
+			body:         `["abc123"]`,
+			userID:       "user123",
+			mockSetup:    func(m *mocks.Shortener) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"unknown domain"}` + "\n",
 		},
 		{
-			name:    "Empty URL",
-			url:     "",
-			wantErr: true,
-		},
+			name:         "Invalid JSON",
+			body:         `invalid json`,
+			userID:       "user123",
+			mockSetup:    func(m *mocks.Shortener) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"incorrect json"}` + "\n",
+		},
+		{
+			name:         "Unauthorized",
+			body:         `["abc123"]`,
+			userID:       "",
+			mockSetup:    func(m *mocks.Shortener) {},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `{"error":"Unauthorized"}` + "\n",
+		},
+		{
+			name:   "Storage error",
+			body:   `["abc123"]`,
+			userID: "user123",
+			mockSetup: func(m *mocks.Shortener) {
+				m.On("RestoreUserShortURLsBatch", mock.Anything, "user123", "", []string{"abc123"}).
+					Return(errors.New("db error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"error":"Internal Server Error"}` + "\n",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := h.validateURL(tt.url)
-			if tt.wantErr {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
+			mockShortener := new(mocks.Shortener)
+			tt.mockSetup(mockShortener)
+			h := NewShortenerHandler(testCfg, testLogger, mockShortener, nil)
+
+			target := tt.target
+			if target == "" {
+				target = "/api/user/urls/restore"
 			}
+			req := httptest.NewRequest(http.MethodPost, target, bytes.NewBufferString(tt.body))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, tt.userID))
+			rr := httptest.NewRecorder()
+
+			h.HandleRestoreShortURLsBatchJSON(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+			mockShortener.AssertExpectations(t)
 		})
 	}
 }
 
-func TestBuildFullURL(t *testing.T) {
+func TestHandlePost_URLReserved(t *testing.T) {
+	testCfg := testConfig()
+	testLogger, _ := logger.NewLogger("debug")
+	mockShortener := new(mocks.Shortener)
+	mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "", "https://reserved.example.com").
+		Return("", repository.ErrURLReserved)
+	h := NewShortenerHandler(testCfg, testLogger, mockShortener, nil)
+
+	t.Run("text/plain", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString("https://reserved.example.com"))
+		rr := httptest.NewRecorder()
+
+		h.HandlePostShortURLTextPlain(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Equal(t, "url is reserved\n", rr.Body.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten", bytes.NewBufferString(`{"url":"https://reserved.example.com"}`))
+		rr := httptest.NewRecorder()
+
+		h.HandlePostShortURLJSON(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Equal(t, `{"error":"url is reserved"}`+"\n", rr.Body.String())
+	})
+}
+
+func TestHandlePostBundleJSON(t *testing.T) {
 	testCfg := testConfig()
 	testLogger, _ := logger.NewLogger("debug")
-	h := NewShortenerHandler(testCfg, testLogger, nil, nil)
 
 	tests := []struct {
-		name        string
-		baseURL     string
-		shortURL    string
-		want        string
-		expectError bool
+		name         string
+		body         string
+		mockSetup    func(*mocks.Shortener)
+		expectedCode int
+		expectedBody string
 	}{
 		{
-			name:        "Valid URL combination",
-			baseURL:     "http://localhost:8080",
-			shortURL:    "abc123",
-			want:        "http://localhost:8080/abc123",
-			expectError: false,
+			name: "Simple positive case",
+			body: `{"title":"Conference","links":[{"title":"Slides","url":"https://example.com/slides","position":2},` +
+				`{"url":"https://example.com/video","position":1}]}`,
+			mockSetup: func(m *mocks.Shortener) {
+				m.On("GenerateBundle", mock.Anything, "user123", "", "Conference", []model.BundleLink{
+					{Title: "Slides", URL: "https://example.com/slides", Position: 2},
+					{Title: "https://example.com/video", URL: "https://example.com/video", Position: 1},
+				}).Return("qwerty12", nil)
+			},
+			expectedCode: http.StatusCreated,
+			expectedBody: `{"result":"http://localhost:8080/qwerty12"}` + "\n",
 		},
 		{
-			name:        "Empty short URL",
-			baseURL:     "http://localhost:8080",
-			shortURL:    "",
-			want:        "http://localhost:8080",
-			expectError: false,
+			name:         "Incorrect JSON",
+			body:         `incorrect_JSON`,
+			mockSetup:    func(m *mocks.Shortener) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"incorrect json"}` + "\n",
+		},
+		{
+			name:         "No links",
+			body:         `{"title":"Conference","links":[]}`,
+			mockSetup:    func(m *mocks.Shortener) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"bundle has no links"}` + "\n",
+		},
+		{
+			name:         "Incorrect link URL",
+			body:         `{"title":"Conference","links":[{"title":"Bad","url":"incorrect_URL"}]}`,
+			mockSetup:    func(m *mocks.Shortener) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"incorrect url incorrect_URL"}` + "\n",
+		},
+		{
+			name: "Generation error",
+			body: `{"links":[{"title":"Slides","url":"https://example.com/slides"}]}`,
+			mockSetup: func(m *mocks.Shortener) {
+				m.On("GenerateBundle", mock.Anything, "user123", "", "Links", mock.Anything).
+					Return("", errors.New("db error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"error":"Internal Server Error"}` + "\n",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			h.cfg.BaseURL = tt.baseURL
-			result, err := h.buildFullURL(tt.shortURL)
+			mockShortener := new(mocks.Shortener)
+			tt.mockSetup(mockShortener)
+			mockAudit := new(mocks.AuditService)
+			mockAudit.On("NotifyAll", mock.Anything).Return()
+			h := NewShortenerHandler(testCfg, testLogger, mockShortener, mockAudit)
 
-			if tt.expectError {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tt.want, result)
+			req := httptest.NewRequest(http.MethodPost, "/api/bundle", bytes.NewBufferString(tt.body))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "user123"))
+			rr := httptest.NewRecorder()
+
+			h.HandlePostBundleJSON(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+		})
+	}
+}
+
+func TestHandleGetShortURLRedirect_Bundle(t *testing.T) {
+	testCfg := testConfig()
+	testLogger, _ := logger.NewLogger("debug")
+	mockShortener := new(mocks.Shortener)
+	mockShortener.On("GetURLByShortURLPart", mock.Anything, "", "bundle12").
+		Return(&model.URL{ShortURL: "bundle12", IsBundle: true}, nil)
+	mockShortener.On("GetBundleByShortURLPart", mock.Anything, "", "bundle12").
+		Return(model.NewBundle("bundle12", "Conference <2025>", []model.BundleLink{
+			{Title: "Slides", URL: "https://example.com/slides"},
+			{Title: "Evil", URL: "javascript:alert(1)"},
+		}), nil)
+	mockAudit := new(mocks.AuditService)
+	mockAudit.On("NotifyAll", mock.Anything).Return()
+	h := NewShortenerHandler(testCfg, testLogger, mockShortener, mockAudit)
+
+	req := httptest.NewRequest(http.MethodGet, "/bundle12", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("shortURL", "bundle12")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+
+	h.HandleGetShortURLRedirect(rr, req)
+
+	body := rr.Body.String()
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+	assert.Empty(t, rr.Header().Get("Location"))
+	assert.Contains(t, body, "<title>Conference &lt;2025&gt;</title>")
+	assert.Contains(t, body, `<a href="?link=0" rel="noopener noreferrer">Slides</a>`)
+	assert.NotContains(t, body, "javascript:alert(1)")
+	mockAudit.AssertNotCalled(t, "NotifyAll", mock.Anything)
+}
+
+func TestHandleGetShortURLRedirect_BundleUnderReview(t *testing.T) {
+	testCfg := testConfig()
+	testLogger, _ := logger.NewLogger("debug")
+	mockShortener := new(mocks.Shortener)
+	mockShortener.On("GetURLByShortURLPart", mock.Anything, "", "bundle12").
+		Return(&model.URL{ShortURL: "bundle12", IsBundle: true,
+			ReviewReasons: []model.ReviewReason{model.ReviewIPHost}}, nil)
+	mockAudit := new(mocks.AuditService)
+	h := NewShortenerHandler(testCfg, testLogger, mockShortener, mockAudit)
+
+	for _, target := range []string{"/bundle12", "/bundle12?link=0"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("shortURL", "bundle12")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		rr := httptest.NewRecorder()
+
+		h.HandleGetShortURLRedirect(rr, req)
+
+		body := rr.Body.String()
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Get("Location"))
+		assert.Contains(t, body, "This link may be unsafe")
+		assert.Contains(t, body, reviewReasonDescriptions[model.ReviewIPHost])
+		assert.NotContains(t, body, "Continue anyway")
+	}
+	mockShortener.AssertNotCalled(t, "GetBundleByShortURLPart", mock.Anything, mock.Anything, mock.Anything)
+	mockAudit.AssertNotCalled(t, "NotifyAll", mock.Anything)
+}
+
+func TestHandleGetShortURLRedirect_BundleLink(t *testing.T) {
+	tests := []struct {
+		name             string
+		link             string
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{
+			name:             "Follow bundle link",
+			link:             "1",
+			expectedStatus:   http.StatusTemporaryRedirect,
+			expectedLocation: "https://example.com/video",
+		},
+		{
+			name:           "Link index out of range",
+			link:           "2",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Link index not a number",
+			link:           "slides",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg := testConfig()
+			testLogger, _ := logger.NewLogger("debug")
+			mockShortener := new(mocks.Shortener)
+			mockShortener.On("GetURLByShortURLPart", mock.Anything, "", "bundle12").
+				Return(&model.URL{ShortURL: "bundle12", IsBundle: true}, nil)
+			mockShortener.On("GetBundleByShortURLPart", mock.Anything, "", "bundle12").
+				Return(model.NewBundle("bundle12", "Conference", []model.BundleLink{
+					{Title: "Slides", URL: "https://example.com/slides"},
+					{Title: "Video", URL: "https://example.com/video"},
+				}), nil)
+			mockAudit := new(mocks.AuditService)
+			mockAudit.On("NotifyAll", mock.Anything).Return()
+			h := NewShortenerHandler(testCfg, testLogger, mockShortener, mockAudit)
+
+			req := httptest.NewRequest(http.MethodGet, "/bundle12?link="+tt.link, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("shortURL", "bundle12")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rr := httptest.NewRecorder()
+
+			h.HandleGetShortURLRedirect(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedLocation, rr.Header().Get("Location"))
+			if tt.expectedLocation == "" {
+				mockAudit.AssertNotCalled(t, "NotifyAll", mock.Anything)
+				return
+			}
+			mockAudit.AssertCalled(t, "NotifyAll", mock.MatchedBy(func(event model.AuditEvent) bool {
+				return event.Action == model.ActionFollow && event.URL == tt.expectedLocation
+			}))
+		})
+	}
+}
+
+func TestHandleGetShortURLRedirect_BundleBots(t *testing.T) {
+	testCfg := testConfig()
+	testLogger, _ := logger.NewLogger("debug")
+	mockShortener := new(mocks.Shortener)
+	mockShortener.On("GetURLByShortURLPart", mock.Anything, "", "bundle12").
+		Return(&model.URL{ShortURL: "bundle12", IsBundle: true}, nil)
+	mockShortener.On("GetBundleByShortURLPart", mock.Anything, "", "bundle12").
+		Return(model.NewBundle("bundle12", "Conference", []model.BundleLink{
+			{Title: "Slides", URL: "https://example.com/slides"},
+			{Title: "Video", URL: "https://example.com/video"},
+		}), nil)
+
+	t.Run("unfurler gets open graph page of bundle", func(t *testing.T) {
+		mockAudit := new(mocks.AuditService)
+		h := NewShortenerHandler(testCfg, testLogger, mockShortener, mockAudit)
+
+		req := httptest.NewRequest(http.MethodGet, "/bundle12", nil)
+		req.Header.Set("User-Agent", "Twitterbot/1.0")
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("shortURL", "bundle12")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		rr := httptest.NewRecorder()
+
+		h.HandleGetShortURLRedirect(rr, req)
+
+		body := rr.Body.String()
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "User-Agent", rr.Header().Get("Vary"))
+		assert.Contains(t, body, `<meta property="og:url" content="http://localhost:8080/bundle12">`)
+		assert.Contains(t, body, `<meta property="og:title" content="Conference">`)
+		assert.Contains(t, body, `<meta property="og:description" content="Slides, Video">`)
+		assert.NotContains(t, body, "?link=")
+		mockAudit.AssertNotCalled(t, "NotifyAll", mock.Anything)
+	})
+
+	t.Run("bot following bundle link is audited as bot", func(t *testing.T) {
+		mockAudit := new(mocks.AuditService)
+		mockAudit.On("NotifyAll", mock.MatchedBy(func(event model.AuditEvent) bool {
+			return event.Action == model.ActionBotFollow && event.Bot == "Googlebot" &&
+				event.URL == "https://example.com/video"
+		})).Return().Once()
+		h := NewShortenerHandler(testCfg, testLogger, mockShortener, mockAudit)
+
+		req := httptest.NewRequest(http.MethodGet, "/bundle12?link=1", nil)
+		req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)")
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("shortURL", "bundle12")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		rr := httptest.NewRecorder()
+
+		h.HandleGetShortURLRedirect(rr, req)
+
+		assert.Equal(t, http.StatusTemporaryRedirect, rr.Code)
+		assert.Equal(t, "https://example.com/video", rr.Header().Get("Location"))
+		mockAudit.AssertExpectations(t)
+	})
+}
+
+func TestHandleGetShortURLRedirect_UnderReview(t *testing.T) {
+	testCfg := testConfig()
+	testLogger, _ := logger.NewLogger("debug")
+	mockShortener := new(mocks.Shortener)
+	mockShortener.On("GetURLByShortURLPart", mock.Anything, "", "qwerty12").
+		Return(&model.URL{
+			ShortURL:      "qwerty12",
+			OriginalURL:   "https://user@paypa1.tk/?a=1&b=<2>",
+			ReviewReasons: []model.ReviewReason{model.ReviewEmbeddedCredentials, model.ReviewSuspiciousTLD},
+		}, nil)
+	mockAudit := new(mocks.AuditService)
+	h := NewShortenerHandler(testCfg, testLogger, mockShortener, mockAudit)
+
+	req := httptest.NewRequest(http.MethodGet, "/qwerty12", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("shortURL", "qwerty12")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+
+	h.HandleGetShortURLRedirect(rr, req)
+
+	body := rr.Body.String()
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+	assert.Empty(t, rr.Header().Get("Location"))
+	assert.Contains(t, body, "This link may be unsafe")
+	assert.Contains(t, body, "contains a user name or password")
+	assert.Contains(t, body, "domain zone often used for phishing")
+	assert.Contains(t, body, `<a href="https://user@paypa1.tk/?a=1&amp;b=%3c2%3e" rel="noopener noreferrer nofollow">`)
+	assert.NotContains(t, body, "<2>")
+	mockAudit.AssertNotCalled(t, "NotifyAll", mock.Anything)
+}
+
+func TestHandleGetShortURLRedirect_Bots(t *testing.T) {
+	testCfg := testConfig()
+	testLogger, _ := logger.NewLogger("debug")
+	mockShortener := new(mocks.Shortener)
+	mockShortener.On("GetURLByShortURLPart", mock.Anything, "", "qwerty12").
+		Return(&model.URL{
+			ShortURL:    "qwerty12",
+			OriginalURL: "https://example.com/article",
+			Metadata: &model.URLMetadata{
+				Title:       "Article <title>",
+				Description: "About things",
+				Image:       "https://example.com/preview.png",
+			},
+		}, nil)
+	mockShortener.On("GetURLByShortURLPart", mock.Anything, "", "qwerty13").
+		Return(&model.URL{ShortURL: "qwerty13", OriginalURL: "https://example.org/page"}, nil)
+	mockShortener.On("GetURLByShortURLPart", mock.Anything, "", "qwerty14").
+		Return(&model.URL{
+			ShortURL:      "qwerty14",
+			OriginalURL:   "https://paypa1.tk/",
+			ReviewReasons: []model.ReviewReason{model.ReviewSuspiciousTLD},
+		}, nil)
+
+	tests := []struct {
+		name          string
+		shortURL      string
+		userAgent     string
+		wantBot       string
+		wantCode      int
+		wantLocation  string
+		wantContains  []string
+		wantNoContain []string
+	}{
+		{
+			name:         "crawler is redirected",
+			shortURL:     "qwerty12",
+			userAgent:    "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			wantBot:      "Googlebot",
+			wantCode:     http.StatusTemporaryRedirect,
+			wantLocation: "https://example.com/article",
+		},
+		{
+			name:      "unfurler gets open graph page",
+			shortURL:  "qwerty12",
+			userAgent: "Twitterbot/1.0",
+			wantBot:   "Twitter",
+			wantCode:  http.StatusOK,
+			wantContains: []string{
+				`<meta property="og:url" content="http://localhost:8080/qwerty12">`,
+				`<meta property="og:title" content="Article &lt;title&gt;">`,
+				`<meta property="og:description" content="About things">`,
+				`<meta property="og:image" content="https://example.com/preview.png">`,
+				`<meta name="twitter:card" content="summary_large_image">`,
+				`<a href="https://example.com/article" rel="noopener noreferrer">`,
+			},
+		},
+		{
+			name:      "unfurler without metadata",
+			shortURL:  "qwerty13",
+			userAgent: "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)",
+			wantBot:   "Slack",
+			wantCode:  http.StatusOK,
+			wantContains: []string{
+				`<meta property="og:title" content="example.org">`,
+				`<meta name="twitter:card" content="summary">`,
+			},
+			wantNoContain: []string{"og:description", "og:image"},
+		},
+		{
+			name:          "unfurler gets warning page of links under review",
+			shortURL:      "qwerty14",
+			userAgent:     "TelegramBot",
+			wantCode:      http.StatusOK,
+			wantContains:  []string{"This link may be unsafe"},
+			wantNoContain: []string{"og:title"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAudit := new(mocks.AuditService)
+			if tt.wantBot != "" {
+				mockAudit.On("NotifyAll", mock.MatchedBy(func(event model.AuditEvent) bool {
+					return event.Action == model.ActionBotFollow && event.Bot == tt.wantBot
+				})).Return().Once()
+			}
+			h := NewShortenerHandler(testCfg, testLogger, mockShortener, mockAudit)
+
+			req := httptest.NewRequest(http.MethodGet, "/"+tt.shortURL, nil)
+			req.Header.Set("User-Agent", tt.userAgent)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("shortURL", tt.shortURL)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rr := httptest.NewRecorder()
+
+			h.HandleGetShortURLRedirect(rr, req)
+
+			body := rr.Body.String()
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Equal(t, tt.wantLocation, rr.Header().Get("Location"))
+			for _, fragment := range tt.wantContains {
+				assert.Contains(t, body, fragment)
+			}
+			for _, fragment := range tt.wantNoContain {
+				assert.NotContains(t, body, fragment)
 			}
+			mockAudit.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleGetShortURLRedirect_Takedown(t *testing.T) {
+	testCfg := testConfig()
+	testLogger, _ := logger.NewLogger("debug")
+	mockShortener := new(mocks.Shortener)
+	mockShortener.On("GetURLByShortURLPart", mock.Anything, "", "qwerty14").
+		Return(&model.URL{ShortURL: "qwerty14", OriginalURL: "https://practicum.yandex2.ru/", IsDisabled: true}, nil)
+	mockShortener.On("GetURLByShortURLPart", mock.Anything, "", "qwerty15").
+		Return(&model.URL{ShortURL: "qwerty15", OriginalURL: "https://practicum.yandex3.ru/", IsDisabled: true,
+			Takedown: model.TakedownAbuse}, nil)
+	mockShortener.On("GetURLByShortURLPart", mock.Anything, "", "qwerty16").
+		Return(&model.URL{ShortURL: "qwerty16", OriginalURL: "https://practicum.yandex4.ru/", IsDisabled: true,
+			Takedown: model.TakedownLegal}, nil)
+	mockAudit := new(mocks.AuditService)
+	h := NewShortenerHandler(testCfg, testLogger, mockShortener, mockAudit)
+
+	tests := []struct {
+		name          string
+		path          string
+		expectedCode  int
+		expectedTitle string
+	}{
+		{
+			name:          "Disabled url",
+			path:          "qwerty14",
+			expectedCode:  http.StatusGone,
+			expectedTitle: "Link disabled",
+		},
+		{
+			name:          "Taken down for abuse",
+			path:          "qwerty15",
+			expectedCode:  http.StatusGone,
+			expectedTitle: "Link disabled",
+		},
+		{
+			name:          "Taken down for legal reasons",
+			path:          "qwerty16",
+			expectedCode:  http.StatusUnavailableForLegalReasons,
+			expectedTitle: "Link unavailable for legal reasons",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/"+tt.path, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("shortURL", tt.path)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rr := httptest.NewRecorder()
+
+			h.HandleGetShortURLRedirect(rr, req)
+
+			body := rr.Body.String()
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+			assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+			assert.Empty(t, rr.Header().Get("Location"))
+			assert.Contains(t, body, "<h1>"+tt.expectedTitle+"</h1>")
+			assert.NotContains(t, body, "practicum")
+		})
+	}
+	mockAudit.AssertNotCalled(t, "NotifyAll", mock.Anything)
+}
+
+func TestReadBody(t *testing.T) {
+	testCfg := testConfig()
+	testLogger, _ := logger.NewLogger("debug")
+	h := NewShortenerHandler(testCfg, testLogger, nil, nil)
+
+	tests := []struct {
+		name        string
+		body        string
+		want        string
+		expectError bool
+	}{
+		{
+			name:        "Valid body",
+			body:        "test content",
+			want:        "test content",
+			expectError: false,
+		},
+		{
+			name:        "Empty body",
+			body:        "",
+			want:        "",
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(tt.body))
+			result, err := h.readBody(req)
+
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, result)
+			}
+		})
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	testCfg := testConfig()
+	testLogger, _ := logger.NewLogger("debug")
+	h := NewShortenerHandler(testCfg, testLogger, nil, nil)
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{
+			name:    "Valid HTTP URL",
+			url:     "http://example.com",
+			wantErr: false,
+		},
+		{
+			name:    "Valid HTTPS URL",
+			url:     "https://example.com",
+			wantErr: false,
+		},
+		{
+			name:    "Invalid URL",
+			url:     "not-a-url",
+			wantErr: true,
+		},
+		{
+			name:    "Empty URL",
+			url:     "",
+			wantErr: true,
+		},
+		{
+			name:    "Long URL within default limit",
+			url:     "https://example.com/" + strings.Repeat("a", 4000),
+			wantErr: false,
+		},
+		{
+			name:    "URL longer than default limit",
+			url:     "https://example.com/" + strings.Repeat("a", defaultMaxURLLength),
+			wantErr: true,
+		},
+		{
+			name:    "Javascript URL",
+			url:     "javascript:alert(1)",
+			wantErr: true,
+		},
+		{
+			name:    "Private target",
+			url:     "http://10.0.0.1/admin",
+			wantErr: true,
+		},
+		{
+			name:    "Self link",
+			url:     "http://localhost:8080/qwerty12",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := h.validateURL(tt.url)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestHandlePost_URLPolicyViolation(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockShortener := new(mocks.Shortener)
+	h := NewShortenerHandler(testConfig(), testLogger, mockShortener, new(mocks.AuditService))
+
+	rr := httptest.NewRecorder()
+	h.HandlePostShortURLTextPlain(rr, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("file:///etc/passwd")))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "url violates scheme policy: scheme file is not allowed\n", rr.Body.String())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/shorten/batch",
+		strings.NewReader(`[{"correlation_id":"1","original_url":"https://example.com"},`+
+			`{"correlation_id":"2","original_url":"http://192.168.0.1"}]`))
+	rr = httptest.NewRecorder()
+	h.HandlePostShortURLBatchJSON(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.JSONEq(t, `{"error":"url violates private_target policy: 192.168.0.1 is not a public host for correlation_id 2"}`,
+		rr.Body.String())
+	mockShortener.AssertNotCalled(t, "GenerateShortURLPartBatch", mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything)
+}
+
+func TestValidateURL_MaxURLLength(t *testing.T) {
+	testCfg := testConfig()
+	testCfg.MaxURLLength = 30
+	testLogger, _ := logger.NewLogger("debug")
+	h := NewShortenerHandler(testCfg, testLogger, nil, nil)
+
+	assert.NoError(t, h.validateURL("https://example.com/short"))
+	assert.ErrorIs(t, h.validateURL("https://example.com/much-too-long-path"), errURLTooLong)
+}
+
+func TestHandlePost_URLTooLong(t *testing.T) {
+	testCfg := testConfig()
+	testCfg.MaxURLLength = 30
+	testLogger, _ := logger.NewLogger("debug")
+	mockShortener := new(mocks.Shortener)
+	h := NewShortenerHandler(testCfg, testLogger, mockShortener, nil)
+	longURL := "https://example.com/much-too-long-path"
+
+	t.Run("text/plain", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(longURL))
+		rr := httptest.NewRecorder()
+
+		h.HandlePostShortURLTextPlain(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, "url is too long\n", rr.Body.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten", bytes.NewBufferString(`{"url":"`+longURL+`"}`))
+		rr := httptest.NewRecorder()
+
+		h.HandlePostShortURLJSON(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, `{"error":"url is too long"}`+"\n", rr.Body.String())
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		body := `[{"correlation_id":"1","original_url":"` + longURL + `"}]`
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten/batch", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+
+		h.HandlePostShortURLBatchJSON(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, `{"error":"url is too long for correlation_id 1"}`+"\n", rr.Body.String())
+	})
+
+	mockShortener.AssertNotCalled(t, "GenerateShortURLPart", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockShortener.AssertNotCalled(t, "GenerateShortURLPartBatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBuildFullURL(t *testing.T) {
+	testCfg := testConfig()
+	testLogger, _ := logger.NewLogger("debug")
+	h := NewShortenerHandler(testCfg, testLogger, nil, nil)
+
+	tests := []struct {
+		name        string
+		baseURL     string
+		domain      string
+		shortURL    string
+		want        string
+		expectError bool
+	}{
+		{
+			name:        "Valid URL combination",
+			baseURL:     "http://localhost:8080",
+			shortURL:    "abc123",
+			want:        "http://localhost:8080/abc123",
+			expectError: false,
+		},
+		{
+			name:        "Empty short URL",
+			baseURL:     "http://localhost:8080",
+			shortURL:    "",
+			want:        "http://localhost:8080",
+			expectError: false,
+		},
+		{
+			name:        "Vanity domain",
+			baseURL:     "http://localhost:8080",
+			domain:      "go.brand.com",
+			shortURL:    "abc123",
+			want:        "http://go.brand.com:8080/abc123",
+			expectError: false,
+		},
+		{
+			name:        "Vanity domain uses base URL scheme and path prefix",
+			baseURL:     "https://short.example.com/s",
+			domain:      "brand.link",
+			shortURL:    "abc123",
+			want:        "https://brand.link/s/abc123",
+			expectError: false,
+		},
+		{
+			name:        "Vanity domain without base URL port",
+			baseURL:     "https://short.example.com",
+			domain:      "brand.link",
+			shortURL:    "abc123",
+			want:        "https://brand.link/abc123",
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h.cfg.BaseURL = tt.baseURL
+			result, err := h.buildFullURL(tt.domain, tt.shortURL)
+
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, result)
+			}
+		})
+	}
+}
+
+func TestDomainByHost(t *testing.T) {
+	testCfg := testConfig()
+	testCfg.Domains = []string{"Go.Brand.com", "brand.link:8443"}
+	testLogger, _ := logger.NewLogger("debug")
+	h := NewShortenerHandler(testCfg, testLogger, nil, nil)
+
+	tests := []struct {
+		name string
+		host string
+		want string
+	}{
+		{name: "Base URL host", host: "localhost:8080", want: ""},
+		{name: "Unknown host", host: "example.com", want: ""},
+		{name: "Vanity domain", host: "go.brand.com", want: "go.brand.com"},
+		{name: "Vanity domain with port", host: "GO.BRAND.COM:8080", want: "go.brand.com"},
+		{name: "Vanity domain configured with port", host: "brand.link:8443", want: "brand.link:8443"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, h.domainByHost(tt.host))
+		})
+	}
+}
+
+func TestChosenDomain(t *testing.T) {
+	testCfg := testConfig()
+	testCfg.Domains = []string{"go.brand.com", "brand.link"}
+	testLogger, _ := logger.NewLogger("debug")
+	h := NewShortenerHandler(testCfg, testLogger, nil, nil)
+
+	tests := []struct {
+		name      string
+		requested string
+		want      string
+		wantErr   error
+	}{
+		{name: "Not chosen", requested: "", want: ""},
+		{name: "Base URL host", requested: "localhost:8080", want: ""},
+		{name: "Vanity domain", requested: "Brand.Link", want: "brand.link"},
+		{name: "Unknown domain", requested: "evil.com", wantErr: errUnknownDomain},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			domain, err := h.chosenDomain(tt.requested)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, domain)
+		})
+	}
+}
+
+func TestHandlePostShortURLJSON_Domain(t *testing.T) {
+	testCfg := testConfig()
+	testCfg.Domains = []string{"go.brand.com"}
+	testLogger, _ := logger.NewLogger("debug")
+	mockShortener := new(mocks.Shortener)
+	mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "go.brand.com", "https://brand.com/promo").
+		Return("qwerty12", nil)
+	mockAudit := new(mocks.AuditService)
+	mockAudit.On("NotifyAll", mock.Anything).Return()
+	h := NewShortenerHandler(testCfg, testLogger, mockShortener, mockAudit)
+
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "Configured domain",
+			body:         `{"url":"https://brand.com/promo","domain":"go.brand.com"}`,
+			expectedCode: http.StatusCreated,
+			expectedBody: `{"result":"http://go.brand.com:8080/qwerty12"}` + "\n",
+		},
+		{
+			name:         "Unknown domain",
+			body:         `{"url":"https://brand.com/promo","domain":"evil.com"}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"unknown domain"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/shorten", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+
+			h.HandlePostShortURLJSON(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+		})
+	}
+}
+
+func TestHandlePostShortURLTextPlain_Domain(t *testing.T) {
+	testCfg := testConfig()
+	testCfg.Domains = []string{"brand.link"}
+	testLogger, _ := logger.NewLogger("debug")
+	mockShortener := new(mocks.Shortener)
+	mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "brand.link", "https://brand.com/promo").
+		Return("qwerty12", nil)
+	mockAudit := new(mocks.AuditService)
+	mockAudit.On("NotifyAll", mock.Anything).Return()
+	h := NewShortenerHandler(testCfg, testLogger, mockShortener, mockAudit)
+
+	req := httptest.NewRequest(http.MethodPost, "/?domain=brand.link", strings.NewReader("https://brand.com/promo"))
+	rr := httptest.NewRecorder()
+	h.HandlePostShortURLTextPlain(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "http://brand.link:8080/qwerty12", rr.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/?domain=evil.com", strings.NewReader("https://brand.com/promo"))
+	rr = httptest.NewRecorder()
+	h.HandlePostShortURLTextPlain(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "unknown domain\n", rr.Body.String())
+}
+
+func TestHandleGetShortURLRedirect_Domain(t *testing.T) {
+	testCfg := testConfig()
+	testCfg.Domains = []string{"go.brand.com"}
+	testLogger, _ := logger.NewLogger("debug")
+	mockShortener := new(mocks.Shortener)
+	mockShortener.On("GetURLByShortURLPart", mock.Anything, "go.brand.com", "qwerty12").
+		Return(&model.URL{ShortURL: "qwerty12", Domain: "go.brand.com", OriginalURL: "https://brand.com/"}, nil)
+	mockShortener.On("GetURLByShortURLPart", mock.Anything, "", "qwerty12").
+		Return(model.NewURL("qwerty12", "https://practicum.yandex.ru/"), nil)
+	mockAudit := new(mocks.AuditService)
+	mockAudit.On("NotifyAll", mock.Anything).Return()
+	h := NewShortenerHandler(testCfg, testLogger, mockShortener, mockAudit)
+
+	tests := []struct {
+		name             string
+		host             string
+		expectedLocation string
+	}{
+		{name: "Vanity domain host", host: "go.brand.com", expectedLocation: "https://brand.com/"},
+		{name: "Base URL host", host: "localhost:8080", expectedLocation: "https://practicum.yandex.ru/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/qwerty12", nil)
+			req.Host = tt.host
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("shortURL", "qwerty12")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rr := httptest.NewRecorder()
+
+			h.HandleGetShortURLRedirect(rr, req)
+
+			assert.Equal(t, http.StatusTemporaryRedirect, rr.Code)
+			assert.Equal(t, tt.expectedLocation, rr.Header().Get("Location"))
+		})
+	}
+}
+
+func workspaceRequest(method string, target string, body string, userID string, params map[string]string) *http.Request {
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	rctx := chi.NewRouteContext()
+	for key, value := range params {
+		rctx.URLParams.Add(key, value)
+	}
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, middleware.UserIDKey, userID)
+	return req.WithContext(ctx)
+}
+
+func TestHandlePostWorkspaceJSON(t *testing.T) {
+	testCfg := testConfig()
+	testLogger, _ := logger.NewLogger("debug")
+
+	tests := []struct {
+		name         string
+		body         string
+		userID       string
+		mockSetup    func(*mocks.Shortener)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:   "Successful creation",
+			body:   `{"name":" Marketing "}`,
+			userID: "user123",
+			mockSetup: func(m *mocks.Shortener) {
+				m.On("CreateWorkspace", mock.Anything, "user123", "Marketing").
+					Return(&model.Workspace{ID: "ws1", Name: "Marketing", Role: model.RoleOwner}, nil)
+			},
+			expectedCode: http.StatusCreated,
+			expectedBody: `{"id":"ws1","name":"Marketing","role":"owner"}` + "\n",
+		},
+		{
+			name:         "Empty name",
+			body:         `{"name":"  "}`,
+			userID:       "user123",
+			mockSetup:    func(m *mocks.Shortener) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"workspace name is empty"}` + "\n",
+		},
+		{
+			name:         "Unauthorized",
+			body:         `{"name":"Marketing"}`,
+			userID:       "",
+			mockSetup:    func(m *mocks.Shortener) {},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `{"error":"Unauthorized"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockShortener := new(mocks.Shortener)
+			tt.mockSetup(mockShortener)
+			h := NewShortenerHandler(testCfg, testLogger, mockShortener, nil)
+
+			req := workspaceRequest(http.MethodPost, "/api/workspaces", tt.body, tt.userID, nil)
+			rr := httptest.NewRecorder()
+
+			h.HandlePostWorkspaceJSON(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+		})
+	}
+}
+
+func TestHandleGetWorkspacesJSON(t *testing.T) {
+	testCfg := testConfig()
+	testLogger, _ := logger.NewLogger("debug")
+
+	mockShortener := new(mocks.Shortener)
+	mockShortener.On("GetUserWorkspaces", mock.Anything, "user123").
+		Return([]model.Workspace{{ID: "ws1", Name: "Marketing", Role: model.RoleEditor}}, nil)
+	mockShortener.On("GetUserWorkspaces", mock.Anything, "lonely-user").Return(nil, nil)
+	h := NewShortenerHandler(testCfg, testLogger, mockShortener, nil)
+
+	rr := httptest.NewRecorder()
+	h.HandleGetWorkspacesJSON(rr, workspaceRequest(http.MethodGet, "/api/workspaces", "", "user123", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `[{"id":"ws1","name":"Marketing","role":"editor"}]`+"\n", rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	h.HandleGetWorkspacesJSON(rr, workspaceRequest(http.MethodGet, "/api/workspaces", "", "lonely-user", nil))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+func TestHandlePutWorkspaceMemberJSON(t *testing.T) {
+	testCfg := testConfig()
+	testLogger, _ := logger.NewLogger("debug")
+
+	tests := []struct {
+		name         string
+		body         string
+		mockSetup    func(*mocks.Shortener)
+		expectedCode int
+	}{
+		{
+			name: "Member saved",
+			body: `{"user_id":"user456","role":"editor"}`,
+			mockSetup: func(m *mocks.Shortener) {
+				m.On("SetWorkspaceMember", mock.Anything, "user123",
+					model.WorkspaceMember{WorkspaceID: "ws1", UserID: "user456", Role: model.RoleEditor}).Return(nil)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "Empty user ID",
+			body:         `{"role":"editor"}`,
+			mockSetup:    func(m *mocks.Shortener) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "Not an owner",
+			body: `{"user_id":"user456","role":"editor"}`,
+			mockSetup: func(m *mocks.Shortener) {
+				m.On("SetWorkspaceMember", mock.Anything, "user123", mock.Anything).
+					Return(service.ErrWorkspaceForbidden)
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name: "Invalid role",
+			body: `{"user_id":"user456","role":"admin"}`,
+			mockSetup: func(m *mocks.Shortener) {
+				m.On("SetWorkspaceMember", mock.Anything, "user123", mock.Anything).
+					Return(service.ErrInvalidWorkspaceRole)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "Last owner",
+			body: `{"user_id":"user123","role":"viewer"}`,
+			mockSetup: func(m *mocks.Shortener) {
+				m.On("SetWorkspaceMember", mock.Anything, "user123", mock.Anything).
+					Return(service.ErrLastWorkspaceOwner)
+			},
+			expectedCode: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockShortener := new(mocks.Shortener)
+			tt.mockSetup(mockShortener)
+			h := NewShortenerHandler(testCfg, testLogger, mockShortener, nil)
+
+			req := workspaceRequest(http.MethodPut, "/api/workspaces/ws1/members", tt.body, "user123",
+				map[string]string{"workspaceID": "ws1"})
+			rr := httptest.NewRecorder()
+
+			h.HandlePutWorkspaceMemberJSON(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+		})
+	}
+}
+
+func TestHandleDeleteWorkspaceMember(t *testing.T) {
+	testCfg := testConfig()
+	testLogger, _ := logger.NewLogger("debug")
+	mockShortener := new(mocks.Shortener)
+	mockShortener.On("RemoveWorkspaceMember", mock.Anything, "user123", "ws1", "user456").Return(nil)
+	h := NewShortenerHandler(testCfg, testLogger, mockShortener, nil)
+
+	req := workspaceRequest(http.MethodDelete, "/api/workspaces/ws1/members/user456", "", "user123",
+		map[string]string{"workspaceID": "ws1", "userID": "user456"})
+	rr := httptest.NewRecorder()
+
+	h.HandleDeleteWorkspaceMember(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	mockShortener.AssertExpectations(t)
+}
+
+func TestHandlePostWorkspaceShortURLJSON(t *testing.T) {
+	testCfg := testConfig()
+	testLogger, _ := logger.NewLogger("debug")
+
+	tests := []struct {
+		name         string
+		body         string
+		mockSetup    func(*mocks.Shortener, *mocks.AuditService)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name: "Link created",
+			body: `{"url":"https://example.com"}`,
+			mockSetup: func(m *mocks.Shortener, a *mocks.AuditService) {
+				m.On("GenerateWorkspaceShortURLPart", mock.Anything, "user123", "ws1", "", "https://example.com").
+					Return("abc123", nil)
+				a.On("NotifyAll", mock.Anything).Return()
+			},
+			expectedCode: http.StatusCreated,
+			expectedBody: `{"result":"http://localhost:8080/abc123"}` + "\n",
+		},
+		{
+			name: "Viewer is forbidden",
+			body: `{"url":"https://example.com"}`,
+			mockSetup: func(m *mocks.Shortener, a *mocks.AuditService) {
+				m.On("GenerateWorkspaceShortURLPart", mock.Anything, "user123", "ws1", "", "https://example.com").
+					Return("", service.ErrWorkspaceForbidden)
+			},
+			expectedCode: http.StatusForbidden,
+			expectedBody: `{"error":"workspace access forbidden"}` + "\n",
+		},
+		{
+			name: "URL already exists",
+			body: `{"url":"https://example.com"}`,
+			mockSetup: func(m *mocks.Shortener, a *mocks.AuditService) {
+				m.On("GenerateWorkspaceShortURLPart", mock.Anything, "user123", "ws1", "", "https://example.com").
+					Return("", &repository.ErrURLConflict{ShortURL: "existing", Err: "Original URL already exists"})
+			},
+			expectedCode: http.StatusConflict,
+			expectedBody: `{"result":"http://localhost:8080/existing"}` + "\n",
+		},
+		{
+			name:         "Invalid URL",
+			body:         `{"url":"invalid-url"}`,
+			mockSetup:    func(m *mocks.Shortener, a *mocks.AuditService) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"incorrect url"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockShortener := new(mocks.Shortener)
+			mockAudit := new(mocks.AuditService)
+			tt.mockSetup(mockShortener, mockAudit)
+			h := NewShortenerHandler(testCfg, testLogger, mockShortener, mockAudit)
+
+			req := workspaceRequest(http.MethodPost, "/api/workspaces/ws1/shorten", tt.body, "user123",
+				map[string]string{"workspaceID": "ws1"})
+			rr := httptest.NewRecorder()
+
+			h.HandlePostWorkspaceShortURLJSON(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+			mockAudit.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleGetWorkspaceURLsJSON(t *testing.T) {
+	testCfg := testConfig()
+	testLogger, _ := logger.NewLogger("debug")
+	mockShortener := new(mocks.Shortener)
+	mockShortener.On("GetURLsByWorkspaceID", mock.Anything, "user123", "ws1").
+		Return([]model.URL{{ShortURL: "abc123", OriginalURL: "https://example.com", WorkspaceID: "ws1"}}, nil)
+	mockShortener.On("GetURLsByWorkspaceID", mock.Anything, "stranger", "ws1").
+		Return(nil, service.ErrWorkspaceForbidden)
+	h := NewShortenerHandler(testCfg, testLogger, mockShortener, nil)
+
+	rr := httptest.NewRecorder()
+	h.HandleGetWorkspaceURLsJSON(rr, workspaceRequest(http.MethodGet, "/api/workspaces/ws1/urls", "", "user123",
+		map[string]string{"workspaceID": "ws1"}))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `[{"short_url":"http://localhost:8080/abc123","original_url":"https://example.com"}]`+"\n",
+		rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	h.HandleGetWorkspaceURLsJSON(rr, workspaceRequest(http.MethodGet, "/api/workspaces/ws1/urls", "", "stranger",
+		map[string]string{"workspaceID": "ws1"}))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestHandleDeleteWorkspaceURLsJSON(t *testing.T) {
+	testCfg := testConfig()
+	testCfg.Domains = []string{"go.brand.com"}
+	testLogger, _ := logger.NewLogger("debug")
+	mockShortener := new(mocks.Shortener)
+	mockShortener.On("DeleteWorkspaceShortURLsBatch", mock.Anything, "user123", "ws1", "", []string{"abc123"}).
+		Return(nil)
+	mockShortener.On("DeleteWorkspaceShortURLsBatch", mock.Anything, "user123", "ws1", "go.brand.com",
+		[]string{"abc123"}).Return(nil)
+	h := NewShortenerHandler(testCfg, testLogger, mockShortener, nil)
+
+	rr := httptest.NewRecorder()
+	h.HandleDeleteWorkspaceURLsJSON(rr, workspaceRequest(http.MethodDelete, "/api/workspaces/ws1/urls",
+		`["abc123"]`, "user123", map[string]string{"workspaceID": "ws1"}))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = httptest.NewRecorder()
+	h.HandleDeleteWorkspaceURLsJSON(rr, workspaceRequest(http.MethodDelete, "/api/workspaces/ws1/urls",
+		`invalid json`, "user123", map[string]string{"workspaceID": "ws1"}))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = httptest.NewRecorder()
+	h.HandleDeleteWorkspaceURLsJSON(rr, workspaceRequest(http.MethodDelete, "/api/workspaces/ws1/urls?domain=go.brand.com",
+		`["abc123"]`, "user123", map[string]string{"workspaceID": "ws1"}))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = httptest.NewRecorder()
+	h.HandleDeleteWorkspaceURLsJSON(rr, workspaceRequest(http.MethodDelete, "/api/workspaces/ws1/urls?domain=evil.com",
+		`["abc123"]`, "user123", map[string]string{"workspaceID": "ws1"}))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockShortener.AssertExpectations(t)
+}
+
+func TestHandleGetWorkspaceTrashURLsJSON(t *testing.T) {
+	testCfg := testConfig()
+	testLogger, _ := logger.NewLogger("debug")
+	deletedAt := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	mockShortener := new(mocks.Shortener)
+	mockShortener.On("GetDeletedURLsByWorkspaceID", mock.Anything, "user123", "ws1").
+		Return([]model.URL{{ShortURL: "abc123", OriginalURL: "https://example.com", WorkspaceID: "ws1",
+			IsDeleted: true, DeletedAt: deletedAt}}, nil)
+	mockShortener.On("GetDeletedURLsByWorkspaceID", mock.Anything, "stranger", "ws1").
+		Return(nil, service.ErrWorkspaceForbidden)
+	h := NewShortenerHandler(testCfg, testLogger, mockShortener, nil)
+
+	rr := httptest.NewRecorder()
+	h.HandleGetWorkspaceTrashURLsJSON(rr, workspaceRequest(http.MethodGet, "/api/workspaces/ws1/urls/trash", "",
+		"user123", map[string]string{"workspaceID": "ws1"}))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `[{"short_url":"http://localhost:8080/abc123","original_url":"https://example.com",`+
+		`"deleted_at":"2026-10-18T10:00:00Z"}]`+"\n", rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	h.HandleGetWorkspaceTrashURLsJSON(rr, workspaceRequest(http.MethodGet, "/api/workspaces/ws1/urls/trash", "",
+		"stranger", map[string]string{"workspaceID": "ws1"}))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestHandleRestoreWorkspaceURLsJSON(t *testing.T) {
+	testCfg := testConfig()
+	testCfg.Domains = []string{"go.brand.com"}
+	testLogger, _ := logger.NewLogger("debug")
+	mockShortener := new(mocks.Shortener)
+	mockShortener.On("RestoreWorkspaceShortURLsBatch", mock.Anything, "user123", "ws1", "", []string{"abc123"}).
+		Return(nil)
+	mockShortener.On("RestoreWorkspaceShortURLsBatch", mock.Anything, "user123", "ws1", "go.brand.com",
+		[]string{"abc123"}).Return(nil)
+	mockShortener.On("RestoreWorkspaceShortURLsBatch", mock.Anything, "viewer", "ws1", "", []string{"abc123"}).
+		Return(service.ErrWorkspaceForbidden)
+	h := NewShortenerHandler(testCfg, testLogger, mockShortener, nil)
+
+	rr := httptest.NewRecorder()
+	h.HandleRestoreWorkspaceURLsJSON(rr, workspaceRequest(http.MethodPost, "/api/workspaces/ws1/urls/restore",
+		`["abc123"]`, "user123", map[string]string{"workspaceID": "ws1"}))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = httptest.NewRecorder()
+	h.HandleRestoreWorkspaceURLsJSON(rr, workspaceRequest(http.MethodPost, "/api/workspaces/ws1/urls/restore",
+		`["abc123"]`, "viewer", map[string]string{"workspaceID": "ws1"}))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = httptest.NewRecorder()
+	h.HandleRestoreWorkspaceURLsJSON(rr, workspaceRequest(http.MethodPost, "/api/workspaces/ws1/urls/restore",
+		`invalid json`, "user123", map[string]string{"workspaceID": "ws1"}))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = httptest.NewRecorder()
+	h.HandleRestoreWorkspaceURLsJSON(rr, workspaceRequest(http.MethodPost,
+		"/api/workspaces/ws1/urls/restore?domain=go.brand.com", `["abc123"]`, "user123",
+		map[string]string{"workspaceID": "ws1"}))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = httptest.NewRecorder()
+	h.HandleRestoreWorkspaceURLsJSON(rr, workspaceRequest(http.MethodPost,
+		"/api/workspaces/ws1/urls/restore?domain=evil.com", `["abc123"]`, "user123",
+		map[string]string{"workspaceID": "ws1"}))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockShortener.AssertExpectations(t)
+}
+
+func TestHandlePatchWorkspaceURLJSON(t *testing.T) {
+	testCfg := testConfig()
+	testLogger, _ := logger.NewLogger("debug")
+
+	tests := []struct {
+		name         string
+		body         string
+		mockSetup    func(*mocks.Shortener, *mocks.AuditService)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name: "Change original URL",
+			body: `{"url":"https://example.com/new"}`,
+			mockSetup: func(m *mocks.Shortener, a *mocks.AuditService) {
+				m.On("UpdateWorkspaceShortURL", mock.Anything, "user123", "ws1", "", "abc123", "https://example.com/new").
+					Return(nil)
+				a.On("NotifyAll", mock.MatchedBy(func(event model.AuditEvent) bool {
+					return event.Action == model.ActionEdit && event.URL == "https://example.com/new"
+				})).Return()
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"result":"http://localhost:8080/abc123"}` + "\n",
+		},
+		{
+			name: "Viewer is forbidden",
+			body: `{"url":"https://example.com/new"}`,
+			mockSetup: func(m *mocks.Shortener, a *mocks.AuditService) {
+				m.On("UpdateWorkspaceShortURL", mock.Anything, "user123", "ws1", "", "abc123", "https://example.com/new").
+					Return(service.ErrWorkspaceForbidden)
+			},
+			expectedCode: http.StatusForbidden,
+			expectedBody: `{"error":"workspace access forbidden"}` + "\n",
+		},
+		{
+			name: "Link not in workspace",
+			body: `{"url":"https://example.com/new"}`,
+			mockSetup: func(m *mocks.Shortener, a *mocks.AuditService) {
+				m.On("UpdateWorkspaceShortURL", mock.Anything, "user123", "ws1", "", "abc123", "https://example.com/new").
+					Return(repository.ErrNotFound)
+			},
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"error":"short url not found"}` + "\n",
+		},
+		{
+			name: "Original URL already shortened",
+			body: `{"url":"https://example.com/new"}`,
+			mockSetup: func(m *mocks.Shortener, a *mocks.AuditService) {
+				m.On("UpdateWorkspaceShortURL", mock.Anything, "user123", "ws1", "", "abc123", "https://example.com/new").
+					Return(&repository.ErrURLConflict{ShortURL: "existing1"})
+			},
+			expectedCode: http.StatusConflict,
+			expectedBody: `{"result":"http://localhost:8080/existing1"}` + "\n",
+		},
+		{
+			name:         "Invalid URL",
+			body:         `{"url":"invalid-url"}`,
+			mockSetup:    func(m *mocks.Shortener, a *mocks.AuditService) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"incorrect url"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockShortener := new(mocks.Shortener)
+			mockAudit := new(mocks.AuditService)
+			tt.mockSetup(mockShortener, mockAudit)
+			h := NewShortenerHandler(testCfg, testLogger, mockShortener, mockAudit)
+
+			req := workspaceRequest(http.MethodPatch, "/api/workspaces/ws1/urls/abc123", tt.body, "user123",
+				map[string]string{"workspaceID": "ws1", "shortURL": "abc123"})
+			rr := httptest.NewRecorder()
+
+			h.HandlePatchWorkspaceURLJSON(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+			mockAudit.AssertExpectations(t)
 		})
 	}
 }
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/service"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+)
+
+const (
+	// oidcLoginCookieName is the name of the cookie keeping a started OIDC login until the callback.
+	oidcLoginCookieName = "oidc_login"
+	// oidcLoginCookiePath limits the OIDC login cookie to the OIDC routes.
+	oidcLoginCookiePath = "/api/auth/oidc"
+	// oidcLoginMaxAge defines how long a started OIDC login stays valid in seconds.
+	oidcLoginMaxAge = 600
+)
+
+// HandleOIDCLogin handles GET requests starting OpenID Connect login against the configured issuer.
+// The user is redirected to the issuer, state, PKCE verifier and nonce are kept in a short-lived cookie.
+//
+// Responses:
+//   - 302 Found: Redirect to the issuer
+//   - 404 Not Found: OIDC login is not configured
+//   - 500 Internal Server Error: Issuer discovery failed
+func (h *AccountHandler) HandleOIDCLogin(rw http.ResponseWriter, r *http.Request) {
+	if h.oidcService == nil {
+		rw.Header().Set("Content-Type", "application/json")
+		h.writeErrorResponse(rw, http.StatusNotFound, "oidc login is not configured")
+		return
+	}
+
+	login, err := h.oidcService.StartLogin(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to start oidc login", zap.Error(err))
+		rw.Header().Set("Content-Type", "application/json")
+		h.writeErrorResponse(rw, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	setOIDCLoginCookie(rw, strings.Join([]string{login.State, login.CodeVerifier, login.Nonce}, "."), oidcLoginMaxAge)
+	http.Redirect(rw, r, login.AuthURL, http.StatusFound)
+}
+
+// HandleOIDCCallback handles GET requests the issuer redirects the user to after login.
+// The authorization code is exchanged for an ID token, whose subject is mapped to a user ID.
+// The token of the user replaces the current token in the Authorization header and cookie.
+//
+// Responses:
+//   - 200 OK: Signed in
+//   - 400 Bad Request: Missing code, or state does not match the started login
+//   - 401 Unauthorized: Issuer denied the login, or the ID token is invalid
+//   - 404 Not Found: OIDC login is not configured
+//   - 500 Internal Server Error: Internal server error
+//
+// Example response:
+//
+//	HTTP/1.1 200 OK
+//	Content-Type: application/json
+//	Authorization: eyJhbGciOiJIUzI1NiIs...
+//
+//	{"user_id": "6f1c2a4e-0b9d-5e57-a1c3-2f1e0d9b8c7a"}
+func (h *AccountHandler) HandleOIDCCallback(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	if h.oidcService == nil {
+		h.writeErrorResponse(rw, http.StatusNotFound, "oidc login is not configured")
+		return
+	}
+
+	var state, codeVerifier, nonce string
+	if cookie, err := r.Cookie(oidcLoginCookieName); err == nil {
+		parts := strings.Split(cookie.Value, ".")
+		if len(parts) == 3 {
+			state, codeVerifier, nonce = parts[0], parts[1], parts[2]
+		}
+	}
+	// The login can be finished only once
+	setOIDCLoginCookie(rw, "", -1)
+
+	query := r.URL.Query()
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(query.Get("state"))) != 1 {
+		h.writeErrorResponse(rw, http.StatusBadRequest, "invalid oidc state")
+		return
+	}
+	if issuerErr := query.Get("error"); issuerErr != "" {
+		h.writeErrorResponse(rw, http.StatusUnauthorized, "oidc login failed: "+issuerErr)
+		return
+	}
+	code := query.Get("code")
+	if code == "" {
+		h.writeErrorResponse(rw, http.StatusBadRequest, "missing authorization code")
+		return
+	}
+
+	userID, err := h.oidcService.FinishLogin(r.Context(), code, codeVerifier, nonce)
+	if err != nil {
+		if errors.Is(err, service.ErrOIDCExchange) || errors.Is(err, service.ErrInvalidIDToken) {
+			h.writeErrorResponse(rw, http.StatusUnauthorized, err.Error())
+			return
+		}
+		h.logger.Error("Failed to finish oidc login", zap.Error(err))
+		h.writeErrorResponse(rw, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	h.writeAccountResponse(rw, http.StatusOK, &model.User{ID: userID})
+}
+
+// setOIDCLoginCookie sets or, with a negative max age, deletes the OIDC login cookie.
+func setOIDCLoginCookie(rw http.ResponseWriter, value string, maxAge int) {
+	http.SetCookie(rw, &http.Cookie{
+		Name:     oidcLoginCookieName,
+		Value:    value,
+		Path:     oidcLoginCookiePath,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"errors"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/mocks"
+	"github.com/bezjen/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleOIDCLogin(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockOIDC := new(mocks.OIDCService)
+	mockOIDC.On("StartLogin", mock.Anything).Return(&service.OIDCLogin{
+		AuthURL:      "https://accounts.example.com/authorize?state=state-1",
+		State:        "state-1",
+		CodeVerifier: "verifier-1",
+		Nonce:        "nonce-1",
+	}, nil)
+	h := NewAccountHandler(testLogger, nil, nil, mockOIDC, nil)
+
+	rr := httptest.NewRecorder()
+	h.HandleOIDCLogin(rr, httptest.NewRequest(http.MethodGet, "/api/auth/oidc/login", nil))
+
+	assert.Equal(t, http.StatusFound, rr.Code)
+	assert.Equal(t, "https://accounts.example.com/authorize?state=state-1", rr.Header().Get("Location"))
+	cookies := rr.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, "oidc_login", cookies[0].Name)
+		assert.Equal(t, "state-1.verifier-1.nonce-1", cookies[0].Value)
+		assert.True(t, cookies[0].HttpOnly)
+	}
+	mockOIDC.AssertExpectations(t)
+}
+
+func TestHandleOIDCLogin_NotConfigured(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	h := NewAccountHandler(testLogger, nil, nil, nil, nil)
+
+	rr := httptest.NewRecorder()
+	h.HandleOIDCLogin(rr, httptest.NewRequest(http.MethodGet, "/api/auth/oidc/login", nil))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, `{"error":"oidc login is not configured"}`+"\n", rr.Body.String())
+}
+
+func TestHandleOIDCCallback(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+
+	tests := []struct {
+		name         string
+		query        string
+		cookie       string
+		mockSetup    func(*mocks.OIDCService, *mocks.Authorizer)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:   "success",
+			query:  "?code=code-1&state=state-1",
+			cookie: "state-1.verifier-1.nonce-1",
+			mockSetup: func(o *mocks.OIDCService, a *mocks.Authorizer) {
+				o.On("FinishLogin", mock.Anything, "code-1", "verifier-1", "nonce-1").Return("oidc-user", nil)
+				a.On("CreateToken", "oidc-user").Return("oidc-token", nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"user_id":"oidc-user"}` + "\n",
+		},
+		{
+			name:         "missing login cookie",
+			query:        "?code=code-1&state=state-1",
+			mockSetup:    func(o *mocks.OIDCService, a *mocks.Authorizer) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"invalid oidc state"}` + "\n",
+		},
+		{
+			name:         "state mismatch",
+			query:        "?code=code-1&state=state-2",
+			cookie:       "state-1.verifier-1.nonce-1",
+			mockSetup:    func(o *mocks.OIDCService, a *mocks.Authorizer) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"invalid oidc state"}` + "\n",
+		},
+		{
+			name:         "issuer denied login",
+			query:        "?error=access_denied&state=state-1",
+			cookie:       "state-1.verifier-1.nonce-1",
+			mockSetup:    func(o *mocks.OIDCService, a *mocks.Authorizer) {},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `{"error":"oidc login failed: access_denied"}` + "\n",
+		},
+		{
+			name:         "missing code",
+			query:        "?state=state-1",
+			cookie:       "state-1.verifier-1.nonce-1",
+			mockSetup:    func(o *mocks.OIDCService, a *mocks.Authorizer) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"missing authorization code"}` + "\n",
+		},
+		{
+			name:   "invalid id token",
+			query:  "?code=code-1&state=state-1",
+			cookie: "state-1.verifier-1.nonce-1",
+			mockSetup: func(o *mocks.OIDCService, a *mocks.Authorizer) {
+				o.On("FinishLogin", mock.Anything, "code-1", "verifier-1", "nonce-1").
+					Return("", service.ErrInvalidIDToken)
+			},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `{"error":"invalid id token"}` + "\n",
+		},
+		{
+			name:   "issuer unavailable",
+			query:  "?code=code-1&state=state-1",
+			cookie: "state-1.verifier-1.nonce-1",
+			mockSetup: func(o *mocks.OIDCService, a *mocks.Authorizer) {
+				o.On("FinishLogin", mock.Anything, "code-1", "verifier-1", "nonce-1").
+					Return("", errors.New("connection refused"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"error":"Internal Server Error"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockOIDC := new(mocks.OIDCService)
+			mockAuthorizer := new(mocks.Authorizer)
+			tt.mockSetup(mockOIDC, mockAuthorizer)
+			h := NewAccountHandler(testLogger, nil, nil, mockOIDC, mockAuthorizer)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/auth/oidc/callback"+tt.query, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "oidc_login", Value: tt.cookie})
+			}
+			rr := httptest.NewRecorder()
+
+			h.HandleOIDCCallback(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+			if tt.expectedCode == http.StatusOK {
+				assert.Equal(t, "oidc-token", rr.Header().Get("Authorization"))
+			}
+			mockOIDC.AssertExpectations(t)
+			mockAuthorizer.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"go.uber.org/zap"
+	"html/template"
+	"net/http"
+)
+
+// basePageTemplate is the scaffold of HTML pages served instead of redirects.
+// Pages fill the head block with their meta tags and the body block with their content,
+// the title block defaults to the Title field of the page data.
+var basePageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+{{- block "head" .}}{{end}}
+<title>{{block "title" .}}{{.Title}}{{end}}</title>
+</head>
+<body>
+{{- block "body" .}}{{end}}
+</body>
+</html>
+`))
+
+// newHTMLPage creates a page template from the scaffold and the definitions of its blocks.
+//
+// Parameters:
+//   - name: name of the page used in logs
+//   - blocks: definitions of the head, title and body blocks
+//
+// Returns:
+//   - *template.Template: page template ready for renderHTMLPage
+func newHTMLPage(name string, blocks string) *template.Template {
+	return template.Must(template.Must(basePageTemplate.Clone()).New(name).Parse(blocks))
+}
+
+// renderHTMLPage writes an HTML page that must not be cached, e.g. because the link can be disabled or edited.
+// Rendering errors are logged only, the status is already sent.
+//
+// Parameters:
+//   - rw: HTTP response writer
+//   - status: HTTP status code of the response
+//   - tmpl: page template created by newHTMLPage
+//   - data: data rendered by the template
+func (h *ShortenerHandler) renderHTMLPage(rw http.ResponseWriter, status int, tmpl *template.Template, data any) {
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.WriteHeader(status)
+	if err := tmpl.ExecuteTemplate(rw, basePageTemplate.Name(), data); err != nil {
+		h.logger.Error("Failed to render page", zap.Error(err), zap.String("page", tmpl.Name()))
+	}
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bezjen/shortener/internal/middleware"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// incorrectJSONMessage is the response message of request bodies that are not valid JSON.
+const incorrectJSONMessage = "incorrect json"
+
+// errTrailingJSON indicates data after the JSON value of a request body.
+var errTrailingJSON = errors.New("unexpected data after json value")
+
+// decodeJSONBody decodes the JSON request body into v.
+// Unknown object fields and data after the JSON value are rejected.
+//
+// Parameters:
+//   - r: HTTP request with the JSON body
+//   - v: pointer to the value to decode into
+//
+// Returns:
+//   - error: error if the body cannot be read or is not a valid JSON value of the expected shape
+func decodeJSONBody(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	_, err := decoder.Token()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if _, _, ok := bodyReadError(err); ok {
+		return err
+	}
+	return errTrailingJSON
+}
+
+// bodyReadError maps an error of reading a request body to a response status and message.
+// Bodies exceeding the size limits are reported with 413 Request Entity Too Large, malformed GZIP bodies
+// with 400 Bad Request.
+//
+// Parameters:
+//   - err: error returned by reading the body
+//
+// Returns:
+//   - int: HTTP status code of the response
+//   - string: response message
+//   - bool: false if the error is not caused by the body, e.g. a closed connection
+func bodyReadError(err error) (int, string, bool) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("request body too large, limit is %d bytes", maxBytesErr.Limit), true
+	case errors.Is(err, middleware.ErrDecompressedBodyTooLarge),
+		errors.Is(err, middleware.ErrDecompressionRatioExceeded):
+		return http.StatusRequestEntityTooLarge, err.Error(), true
+	case errors.Is(err, middleware.ErrInvalidGzipBody):
+		return http.StatusBadRequest, middleware.ErrInvalidGzipBody.Error(), true
+	default:
+		return 0, "", false
+	}
+}
+
+// jsonBodyError maps an error of decodeJSONBody to a response status and message.
+// JSON of unexpected shape is reported with the offending field, syntax errors as incorrect json.
+//
+// Parameters:
+//   - err: error returned by decodeJSONBody
+//
+// Returns:
+//   - int: HTTP status code of the response
+//   - string: response message
+func jsonBodyError(err error) (int, string) {
+	if statusCode, message, ok := bodyReadError(err); ok {
+		return statusCode, message
+	}
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return http.StatusBadRequest,
+			fmt.Sprintf("%s: unexpected %s in field %q", incorrectJSONMessage, typeErr.Value, typeErr.Field)
+	case errors.As(err, &typeErr):
+		return http.StatusBadRequest, fmt.Sprintf("%s: unexpected %s", incorrectJSONMessage, typeErr.Value)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return http.StatusBadRequest, incorrectJSONMessage + ": " + strings.TrimPrefix(err.Error(), "json: ")
+	case errors.Is(err, errTrailingJSON):
+		return http.StatusBadRequest, incorrectJSONMessage + ": " + errTrailingJSON.Error()
+	default:
+		return http.StatusBadRequest, incorrectJSONMessage
+	}
+}
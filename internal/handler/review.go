@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"github.com/bezjen/shortener/internal/model"
+	"net/http"
+)
+
+// reviewReasonDescriptions explains the phishing heuristics to visitors of the warning page.
+var reviewReasonDescriptions = map[model.ReviewReason]string{
+	model.ReviewPunycodeBrand:       "The address uses international characters to imitate a well-known name.",
+	model.ReviewHomoglyphBrand:      "The address uses look-alike characters to imitate a well-known name.",
+	model.ReviewSuspiciousTLD:       "The address belongs to a domain zone often used for phishing.",
+	model.ReviewIPHost:              "The address points to a bare IP address instead of a named site.",
+	model.ReviewEmbeddedCredentials: "The address contains a user name or password that can hide the real site.",
+}
+
+// warningPageTemplate renders the page shown instead of redirecting to a link under review.
+// Bundles under review have no single destination and are not shown until reviewed.
+// html/template escapes the destination and rejects unsafe URL schemes in href attributes.
+var warningPageTemplate = newHTMLPage("warning", `
+{{- define "head"}}
+<meta name="robots" content="noindex">
+{{- end}}
+{{- define "title"}}Suspicious link{{end}}
+{{- define "body"}}
+<h1>This link may be unsafe</h1>
+<p>The link you followed is waiting for review because it looks like phishing.
+Do not enter passwords or payment details unless you trust the site.</p>
+<ul>
+{{- range .Reasons}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- if .OriginalURL}}
+<p>Destination: <code>{{.OriginalURL}}</code></p>
+<p><a href="{{.OriginalURL}}" rel="noopener noreferrer nofollow">Continue anyway</a></p>
+{{- end}}
+{{- end}}`)
+
+// warningPage holds the data rendered by warningPageTemplate.
+type warningPage struct {
+	OriginalURL string
+	Reasons     []string
+}
+
+// writeWarningPage renders the warning page of a link under review with 200 OK status.
+//
+// Parameters:
+//   - rw: HTTP response writer
+//   - url: link under review
+func (h *ShortenerHandler) writeWarningPage(rw http.ResponseWriter, url *model.URL) {
+	page := warningPage{OriginalURL: url.OriginalURL}
+	for _, reason := range url.ReviewReasons {
+		if description, ok := reviewReasonDescriptions[reason]; ok {
+			page.Reasons = append(page.Reasons, description)
+		}
+	}
+	h.renderHTMLPage(rw, http.StatusOK, warningPageTemplate, page)
+}
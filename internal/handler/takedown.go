@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"github.com/bezjen/shortener/internal/model"
+	"net/http"
+)
+
+// takedownPageTemplate renders the page shown instead of redirecting to a disabled link.
+// The destination is left out on purpose, so that the page does not lead visitors to it.
+var takedownPageTemplate = newHTMLPage("takedown", `
+{{- define "head"}}
+<meta name="robots" content="noindex">
+{{- end}}
+{{- define "body"}}
+<h1>{{.Title}}</h1>
+<p>{{.Message}}</p>
+{{- end}}`)
+
+// takedownPage holds the data rendered by takedownPageTemplate.
+type takedownPage struct {
+	Title   string
+	Message string
+}
+
+// writeTakedownPage renders the takedown page of a disabled link.
+// Links taken down on a legal demand answer 451 Unavailable For Legal Reasons, other disabled links 410 Gone.
+//
+// Parameters:
+//   - rw: HTTP response writer
+//   - url: disabled link
+func (h *ShortenerHandler) writeTakedownPage(rw http.ResponseWriter, url *model.URL) {
+	statusCode := http.StatusGone
+	page := takedownPage{
+		Title:   "Link disabled",
+		Message: "This link was disabled for violating the terms of service and no longer redirects.",
+	}
+	if url.Takedown == model.TakedownLegal {
+		statusCode = http.StatusUnavailableForLegalReasons
+		page = takedownPage{
+			Title:   "Link unavailable for legal reasons",
+			Message: "This link was taken down in response to a legal demand and no longer redirects.",
+		}
+	}
+	h.renderHTMLPage(rw, statusCode, takedownPageTemplate, page)
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"github.com/bezjen/shortener/internal/model"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// unfurlPageTemplate renders the page shown to link preview fetchers of chats and social networks.
+// It describes the destination with Open Graph tags, so that previews show the destination page
+// rather than a redirect, and links to the destination for people sent here by mistake.
+// Bundles are described by their title and link titles and link to their landing page.
+var unfurlPageTemplate = newHTMLPage("unfurl", `
+{{- define "head"}}
+<meta name="robots" content="noindex">
+<meta property="og:type" content="website">
+<meta property="og:url" content="{{.ShortURL}}">
+<meta property="og:title" content="{{.Title}}">
+{{- with .Description}}
+<meta property="og:description" content="{{.}}">
+<meta name="description" content="{{.}}">
+{{- end}}
+{{- with .Image}}
+<meta property="og:image" content="{{.}}">
+<meta name="twitter:card" content="summary_large_image">
+{{- else}}
+<meta name="twitter:card" content="summary">
+{{- end}}
+{{- with .SiteName}}
+<meta property="og:site_name" content="{{.}}">
+{{- end}}
+{{- end}}
+{{- define "body"}}
+<p><a href="{{.OriginalURL}}" rel="noopener noreferrer">{{.Title}}</a></p>
+{{- end}}`)
+
+// unfurlPage holds the data rendered by unfurlPageTemplate.
+type unfurlPage struct {
+	ShortURL    string
+	OriginalURL string
+	Title       string
+	Description string
+	Image       string
+	SiteName    string
+}
+
+// writeUnfurlPage renders the Open Graph page of a link for a link preview fetcher.
+// The fetched metadata of the destination is used if available, the destination host is the title otherwise.
+//
+// Parameters:
+//   - rw: HTTP response writer
+//   - link: link to describe
+func (h *ShortenerHandler) writeUnfurlPage(rw http.ResponseWriter, link *model.URL) {
+	page := unfurlPage{OriginalURL: link.OriginalURL, Title: link.OriginalURL}
+	if shortURL, err := h.buildFullURL(link.Domain, link.ShortURL); err == nil {
+		page.ShortURL = shortURL
+	}
+	if parsed, err := url.Parse(link.OriginalURL); err == nil && parsed.Host != "" {
+		page.Title = parsed.Host
+	}
+	if metadata := link.Metadata; metadata != nil {
+		if metadata.Title != "" {
+			page.Title = metadata.Title
+		}
+		page.Description = metadata.Description
+		page.Image = metadata.Image
+		page.SiteName = metadata.SiteName
+	}
+	rw.Header().Set("Vary", "User-Agent")
+	h.renderHTMLPage(rw, http.StatusOK, unfurlPageTemplate, page)
+}
+
+// writeBundleUnfurlPage renders the Open Graph page of a bundle for a link preview fetcher.
+// The bundle title is the title, the titles of the bundle links are the description.
+//
+// Parameters:
+//   - rw: HTTP response writer
+//   - bundle: bundle to describe
+func (h *ShortenerHandler) writeBundleUnfurlPage(rw http.ResponseWriter, bundle *model.Bundle) {
+	page := unfurlPage{Title: bundle.Title}
+	if shortURL, err := h.buildFullURL(bundle.Domain, bundle.ShortURL); err == nil {
+		page.ShortURL = shortURL
+		page.OriginalURL = shortURL
+	}
+	titles := make([]string, 0, len(bundle.Links))
+	for _, link := range bundle.Links {
+		titles = append(titles, link.Title)
+	}
+	page.Description = strings.Join(titles, ", ")
+	rw.Header().Set("Vary", "User-Agent")
+	h.renderHTMLPage(rw, http.StatusOK, unfurlPageTemplate, page)
+}
@@ -0,0 +1,536 @@
+package handler
+
+import (
+	"errors"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/repository"
+	"github.com/bezjen/shortener/internal/service"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+)
+
+// HandlePostWorkspaceJSON handles POST requests to create a workspace.
+// The authenticated user becomes the owner of the new workspace.
+//
+// Request format:
+//
+//	{"name": "Marketing"}
+//
+// Responses:
+//   - 201 Created: Workspace created
+//   - 400 Bad Request: Invalid JSON or empty name
+//   - 413 Request Entity Too Large: Request body exceeds the size limit
+//   - 401 Unauthorized: User not authenticated
+//   - 500 Internal Server Error: Internal server error
+//
+// Example response:
+//
+//	HTTP/1.1 201 Created
+//	Content-Type: application/json
+//
+//	{"id": "123e4567-e89b-12d3-a456-426614174000", "name": "Marketing", "role": "owner"}
+func (h *ShortenerHandler) HandlePostWorkspaceJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	userID := getUserIDFromContext(r)
+	if userID == "" {
+		h.writeShortenJSONErrorResponse(rw, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+
+	defer r.Body.Close()
+	var request model.WorkspaceJSONRequest
+	if err := decodeJSONBody(r, &request); err != nil {
+		statusCode, message := jsonBodyError(err)
+		h.writeShortenJSONErrorResponse(rw, statusCode, message)
+		return
+	}
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, "workspace name is empty")
+		return
+	}
+
+	workspace, err := h.shortener.CreateWorkspace(r.Context(), userID, name)
+	if err != nil {
+		h.handleWorkspaceError(rw, err, userID, "")
+		return
+	}
+
+	h.writeJSONResponse(rw, http.StatusCreated, workspace)
+}
+
+// HandleGetWorkspacesJSON handles GET requests to list workspaces of the user.
+//
+// Responses:
+//   - 200 OK: Workspaces retrieved successfully
+//   - 204 No Content: User is not a member of any workspace
+//   - 401 Unauthorized: User not authenticated
+//   - 500 Internal Server Error: Internal server error
+//
+// Example response:
+//
+//	HTTP/1.1 200 OK
+//	Content-Type: application/json
+//
+//	[{"id": "123e4567-e89b-12d3-a456-426614174000", "name": "Marketing", "role": "editor"}]
+func (h *ShortenerHandler) HandleGetWorkspacesJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	userID := getUserIDFromContext(r)
+	if userID == "" {
+		h.writeShortenJSONErrorResponse(rw, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+
+	workspaces, err := h.shortener.GetUserWorkspaces(r.Context(), userID)
+	if err != nil {
+		h.handleWorkspaceError(rw, err, userID, "")
+		return
+	}
+
+	if len(workspaces) == 0 {
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	h.writeJSONResponse(rw, http.StatusOK, workspaces)
+}
+
+// HandleGetWorkspaceMembersJSON handles GET requests to list members of a workspace.
+// Available to every member of the workspace.
+//
+// Responses:
+//   - 200 OK: Members retrieved successfully
+//   - 401 Unauthorized: User not authenticated
+//   - 403 Forbidden: User is not a member of the workspace
+//   - 500 Internal Server Error: Internal server error
+//
+// Example response:
+//
+//	HTTP/1.1 200 OK
+//	Content-Type: application/json
+//
+//	[{"workspace_id": "123e4567-e89b-12d3-a456-426614174000", "user_id": "user-123", "role": "owner"}]
+func (h *ShortenerHandler) HandleGetWorkspaceMembersJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	userID := getUserIDFromContext(r)
+	if userID == "" {
+		h.writeShortenJSONErrorResponse(rw, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+	workspaceID := chi.URLParam(r, "workspaceID")
+
+	members, err := h.shortener.GetWorkspaceMembers(r.Context(), userID, workspaceID)
+	if err != nil {
+		h.handleWorkspaceError(rw, err, userID, workspaceID)
+		return
+	}
+
+	h.writeJSONResponse(rw, http.StatusOK, members)
+}
+
+// HandlePutWorkspaceMemberJSON handles PUT requests to add a member or change a member's role.
+// Available to owners of the workspace only.
+//
+// Request format:
+//
+//	{"user_id": "user-123", "role": "editor"}
+//
+// Responses:
+//   - 204 No Content: Member saved
+//   - 400 Bad Request: Invalid JSON, empty user ID or unknown role
+//   - 413 Request Entity Too Large: Request body exceeds the size limit
+//   - 401 Unauthorized: User not authenticated
+//   - 403 Forbidden: User is not an owner of the workspace
+//   - 409 Conflict: Change would leave the workspace without an owner
+//   - 500 Internal Server Error: Internal server error
+func (h *ShortenerHandler) HandlePutWorkspaceMemberJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	userID := getUserIDFromContext(r)
+	if userID == "" {
+		h.writeShortenJSONErrorResponse(rw, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+	workspaceID := chi.URLParam(r, "workspaceID")
+
+	defer r.Body.Close()
+	var member model.WorkspaceMember
+	if err := decodeJSONBody(r, &member); err != nil {
+		statusCode, message := jsonBodyError(err)
+		h.writeShortenJSONErrorResponse(rw, statusCode, message)
+		return
+	}
+	if member.UserID == "" {
+		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, "user id is empty")
+		return
+	}
+	member.WorkspaceID = workspaceID
+
+	err := h.shortener.SetWorkspaceMember(r.Context(), userID, member)
+	if err != nil {
+		h.handleWorkspaceError(rw, err, userID, workspaceID)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// HandleDeleteWorkspaceMember handles DELETE requests to remove a member from a workspace.
+// Owners may remove any member, other members may only remove themselves.
+//
+// Responses:
+//   - 204 No Content: Member removed
+//   - 401 Unauthorized: User not authenticated
+//   - 403 Forbidden: User is not allowed to remove the member
+//   - 409 Conflict: Member is the last owner of the workspace
+//   - 500 Internal Server Error: Internal server error
+func (h *ShortenerHandler) HandleDeleteWorkspaceMember(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	userID := getUserIDFromContext(r)
+	if userID == "" {
+		h.writeShortenJSONErrorResponse(rw, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+	workspaceID := chi.URLParam(r, "workspaceID")
+	memberID := chi.URLParam(r, "userID")
+
+	err := h.shortener.RemoveWorkspaceMember(r.Context(), userID, workspaceID, memberID)
+	if err != nil {
+		h.handleWorkspaceError(rw, err, userID, workspaceID)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// HandlePostWorkspaceShortURLJSON handles POST requests to create a short URL owned by a workspace.
+// Available to editors and owners of the workspace.
+//
+// Request format:
+//
+//	{"url": "https://example.com/very/long/url", "domain": "go.brand.com"}
+//
+// Responses:
+//   - 201 Created: Short URL created successfully
+//   - 400 Bad Request: Invalid JSON, URL format, URL longer than max_url_length, URL violating the URL policy
+//     or unknown domain
+//   - 413 Request Entity Too Large: Request body exceeds the size limit
+//   - 401 Unauthorized: User not authenticated
+//   - 403 Forbidden: User is not an editor or owner of the workspace, or links quota of the user exceeded
+//   - 409 Conflict: URL already exists or is reserved
+//   - 500 Internal Server Error: Internal server error
+//
+// Example response:
+//
+//	HTTP/1.1 201 Created
+//	Content-Type: application/json
+//
+//	{"result": "http://localhost:8080/abc123def"}
+func (h *ShortenerHandler) HandlePostWorkspaceShortURLJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	userID := getUserIDFromContext(r)
+	if userID == "" {
+		h.writeShortenJSONErrorResponse(rw, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+	workspaceID := chi.URLParam(r, "workspaceID")
+
+	defer r.Body.Close()
+	var request model.ShortenJSONRequest
+	if err := decodeJSONBody(r, &request); err != nil {
+		statusCode, message := jsonBodyError(err)
+		h.writeShortenJSONErrorResponse(rw, statusCode, message)
+		return
+	}
+	if err := h.validateURL(request.URL); err != nil {
+		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, validationErrorMessage(err))
+		return
+	}
+	domain, err := h.chosenDomain(request.Domain)
+	if err != nil {
+		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, err.Error())
+		return
+	}
+	shortURL, err := h.shortener.GenerateWorkspaceShortURLPart(r.Context(), userID, workspaceID, domain, request.URL)
+	if errors.Is(err, service.ErrWorkspaceForbidden) {
+		h.handleWorkspaceError(rw, err, userID, workspaceID)
+		return
+	}
+	if err != nil {
+		h.handleJSONGenerationError(rw, err, domain, request.URL)
+		return
+	}
+
+	h.auditEvent(model.ActionShorten, userID, request.URL)
+	h.writeShortenJSONSuccessResponse(rw, http.StatusCreated, domain, shortURL)
+}
+
+// HandleGetWorkspaceURLsJSON handles GET requests to retrieve URLs of a workspace.
+// Available to every member of the workspace.
+//
+// Responses:
+//   - 200 OK: Workspace URLs retrieved successfully
+//   - 204 No Content: Workspace has no shortened URLs
+//   - 401 Unauthorized: User not authenticated
+//   - 403 Forbidden: User is not a member of the workspace
+//   - 500 Internal Server Error: Internal server error
+//
+// Example response:
+//
+//	HTTP/1.1 200 OK
+//	Content-Type: application/json
+//
+//	[{"short_url": "http://localhost:8080/abc123", "original_url": "https://example.com/url1"}]
+func (h *ShortenerHandler) HandleGetWorkspaceURLsJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	userID := getUserIDFromContext(r)
+	if userID == "" {
+		h.writeShortenJSONErrorResponse(rw, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+	workspaceID := chi.URLParam(r, "workspaceID")
+
+	workspaceURLs, err := h.shortener.GetURLsByWorkspaceID(r.Context(), userID, workspaceID)
+	if err != nil {
+		h.handleWorkspaceError(rw, err, userID, workspaceID)
+		return
+	}
+
+	if len(workspaceURLs) == 0 {
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	response := h.buildUserURLsResponse(workspaceURLs)
+	h.writeJSONResponse(rw, http.StatusOK, response)
+}
+
+// HandleDeleteWorkspaceURLsJSON handles DELETE requests to move URLs of a workspace to the trash.
+// Available to editors and owners of the workspace. URLs outside the workspace or the domain are ignored.
+//
+// Query parameters:
+//   - domain: vanity domain of the links, empty for the base URL domain
+//
+// Request format:
+//
+//	["abc123def", "xyz456ghi"]
+//
+// Responses:
+//   - 204 No Content: URLs deleted
+//   - 400 Bad Request: Invalid JSON format or unknown domain
+//   - 413 Request Entity Too Large: Request body exceeds the size limit
+//   - 401 Unauthorized: User not authenticated
+//   - 403 Forbidden: User is not an editor or owner of the workspace, or delete batch size quota exceeded
+//   - 500 Internal Server Error: Internal server error
+func (h *ShortenerHandler) HandleDeleteWorkspaceURLsJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	userID := getUserIDFromContext(r)
+	if userID == "" {
+		h.writeShortenJSONErrorResponse(rw, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+	workspaceID := chi.URLParam(r, "workspaceID")
+
+	domain, err := h.chosenDomain(r.URL.Query().Get("domain"))
+	if err != nil {
+		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defer r.Body.Close()
+	var shortURLs []string
+	if err = decodeJSONBody(r, &shortURLs); err != nil {
+		statusCode, message := jsonBodyError(err)
+		h.writeShortenJSONErrorResponse(rw, statusCode, message)
+		return
+	}
+
+	err = h.shortener.DeleteWorkspaceShortURLsBatch(r.Context(), userID, workspaceID, domain, shortURLs)
+	if err != nil {
+		h.handleWorkspaceError(rw, err, userID, workspaceID)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// HandleGetWorkspaceTrashURLsJSON handles GET requests to retrieve deleted URLs of a workspace.
+// Available to every member of the workspace.
+//
+// Responses:
+//   - 200 OK: Deleted URLs retrieved successfully
+//   - 204 No Content: Workspace trash is empty
+//   - 401 Unauthorized: User not authenticated
+//   - 403 Forbidden: User is not a member of the workspace
+//   - 500 Internal Server Error: Internal server error
+//
+// Example response:
+//
+//	HTTP/1.1 200 OK
+//	Content-Type: application/json
+//
+//	[
+//	  {"short_url": "http://localhost:8080/abc123", "original_url": "https://example.com/url1",
+//	   "deleted_at": "2025-09-01T10:00:00Z"}
+//	]
+func (h *ShortenerHandler) HandleGetWorkspaceTrashURLsJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	userID := getUserIDFromContext(r)
+	if userID == "" {
+		h.writeShortenJSONErrorResponse(rw, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+	workspaceID := chi.URLParam(r, "workspaceID")
+
+	deletedURLs, err := h.shortener.GetDeletedURLsByWorkspaceID(r.Context(), userID, workspaceID)
+	if err != nil {
+		h.handleWorkspaceError(rw, err, userID, workspaceID)
+		return
+	}
+
+	if len(deletedURLs) == 0 {
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	response := h.buildTrashURLsResponse(deletedURLs)
+	h.writeJSONResponse(rw, http.StatusOK, response)
+}
+
+// HandleRestoreWorkspaceURLsJSON handles POST requests to restore URLs of a workspace from the trash.
+// Available to editors and owners of the workspace. URLs outside the workspace or the domain are ignored.
+//
+// Query parameters:
+//   - domain: vanity domain of the links, empty for the base URL domain
+//
+// Request format:
+//
+//	["abc123def", "xyz456ghi"]
+//
+// Responses:
+//   - 204 No Content: URLs restored
+//   - 400 Bad Request: Invalid JSON format or unknown domain
+//   - 413 Request Entity Too Large: Request body exceeds the size limit
+//   - 401 Unauthorized: User not authenticated
+//   - 403 Forbidden: User is not an editor or owner of the workspace
+//   - 500 Internal Server Error: Internal server error
+func (h *ShortenerHandler) HandleRestoreWorkspaceURLsJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	userID := getUserIDFromContext(r)
+	if userID == "" {
+		h.writeShortenJSONErrorResponse(rw, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+	workspaceID := chi.URLParam(r, "workspaceID")
+
+	domain, err := h.chosenDomain(r.URL.Query().Get("domain"))
+	if err != nil {
+		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defer r.Body.Close()
+	var shortURLs []string
+	if err = decodeJSONBody(r, &shortURLs); err != nil {
+		statusCode, message := jsonBodyError(err)
+		h.writeShortenJSONErrorResponse(rw, statusCode, message)
+		return
+	}
+
+	err = h.shortener.RestoreWorkspaceShortURLsBatch(r.Context(), userID, workspaceID, domain, shortURLs)
+	if err != nil {
+		h.handleWorkspaceError(rw, err, userID, workspaceID)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// HandlePatchWorkspaceURLJSON handles PATCH requests to change the original URL of a workspace link.
+// Available to editors and owners of the workspace, regardless of who created the link.
+// The short URL keeps its identifier, the domain in the request selects the domain of the link.
+//
+// Request format:
+//
+//	{"url": "https://example.com/new/url", "domain": "go.brand.com"}
+//
+// Responses:
+//   - 200 OK: Original URL changed
+//   - 400 Bad Request: Invalid JSON, URL format, URL longer than max_url_length, URL violating the URL policy
+//     or unknown domain
+//   - 413 Request Entity Too Large: Request body exceeds the size limit
+//   - 401 Unauthorized: User not authenticated
+//   - 403 Forbidden: User is not an editor or owner of the workspace
+//   - 404 Not Found: Workspace has no such link, or the link is deleted, disabled or a bundle
+//   - 409 Conflict: URL already exists or is reserved
+//   - 500 Internal Server Error: Internal server error
+//
+// Example response:
+//
+//	HTTP/1.1 200 OK
+//	Content-Type: application/json
+//
+//	{"result": "http://localhost:8080/abc123def"}
+func (h *ShortenerHandler) HandlePatchWorkspaceURLJSON(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	userID := getUserIDFromContext(r)
+	if userID == "" {
+		h.writeShortenJSONErrorResponse(rw, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+	workspaceID := chi.URLParam(r, "workspaceID")
+	shortURL := chi.URLParam(r, "shortURL")
+
+	defer r.Body.Close()
+	var request model.ShortenJSONRequest
+	if err := decodeJSONBody(r, &request); err != nil {
+		statusCode, message := jsonBodyError(err)
+		h.writeShortenJSONErrorResponse(rw, statusCode, message)
+		return
+	}
+	if err := h.validateURL(request.URL); err != nil {
+		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, validationErrorMessage(err))
+		return
+	}
+	domain, err := h.chosenDomain(request.Domain)
+	if err != nil {
+		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, err.Error())
+		return
+	}
+	err = h.shortener.UpdateWorkspaceShortURL(r.Context(), userID, workspaceID, domain, shortURL, request.URL)
+	switch {
+	case errors.Is(err, service.ErrWorkspaceForbidden):
+		h.handleWorkspaceError(rw, err, userID, workspaceID)
+		return
+	case errors.Is(err, repository.ErrNotFound):
+		h.writeShortenJSONErrorResponse(rw, http.StatusNotFound, "short url not found")
+		return
+	case err != nil:
+		h.handleJSONGenerationError(rw, err, domain, request.URL)
+		return
+	}
+
+	h.auditEvent(model.ActionEdit, userID, request.URL)
+	h.writeShortenJSONSuccessResponse(rw, http.StatusOK, domain, shortURL)
+}
+
+func (h *ShortenerHandler) handleWorkspaceError(rw http.ResponseWriter, err error, userID string, workspaceID string) {
+	switch {
+	case errors.Is(err, service.ErrWorkspaceForbidden):
+		h.writeShortenJSONErrorResponse(rw, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrInvalidWorkspaceRole):
+		h.writeShortenJSONErrorResponse(rw, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrLastWorkspaceOwner):
+		h.writeShortenJSONErrorResponse(rw, http.StatusConflict, err.Error())
+	case isQuotaExceeded(err):
+		h.writeShortenJSONErrorResponse(rw, http.StatusForbidden, err.Error())
+	default:
+		h.logger.Error("Failed to process workspace request",
+			zap.Error(err),
+			zap.String("userID", userID),
+			zap.String("workspaceID", workspaceID),
+		)
+		h.writeShortenJSONErrorResponse(rw, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+	}
+}
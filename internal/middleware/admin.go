@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/service"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// AdminMiddleware restricts routes to administrators and rejects writes of banned users.
+type AdminMiddleware struct {
+	adminService service.AdminService
+	logger       *logger.Logger
+}
+
+// NewAdminMiddleware creates a new AdminMiddleware instance.
+//
+// Parameters:
+//   - adminService: admin service looking up banned users
+//   - logger: logger instance for ban lookup errors
+//
+// Returns:
+//   - *AdminMiddleware: initialized admin middleware
+func NewAdminMiddleware(adminService service.AdminService, logger *logger.Logger) *AdminMiddleware {
+	return &AdminMiddleware{
+		adminService: adminService,
+		logger:       logger,
+	}
+}
+
+// RequireAdmin wraps an HTTP handler so that only administrators can call it.
+// Administrators are recognized by the admin role claim of their token, API keys never carry it.
+// Must run after authentication, requests of other users get 403 Forbidden.
+//
+// Parameters:
+//   - h: HTTP handler to wrap
+//
+// Returns:
+//   - http.Handler: wrapped handler that requires an administrator
+func (m *AdminMiddleware) RequireAdmin(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if RoleFromContext(r.Context()) != service.RoleAdmin {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// RejectBanned wraps an HTTP handler so that banned users cannot change data.
+// GET, HEAD and OPTIONS requests are always served, other requests of banned users get 403 Forbidden.
+// Must run after authentication.
+//
+// Parameters:
+//   - h: HTTP handler to wrap
+//
+// Returns:
+//   - http.Handler: wrapped handler that rejects writes of banned users
+func (m *AdminMiddleware) RejectBanned(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := r.Context().Value(UserIDKey).(string)
+		if userID == "" || requiredAPIKeyScope(r.Method) == model.ScopeRead {
+			h.ServeHTTP(w, r)
+			return
+		}
+		banned, err := m.adminService.IsUserBanned(r.Context(), userID)
+		if err != nil {
+			m.logger.Error("Failed to check user ban", zap.Error(err), zap.String("userID", userID))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if banned {
+			http.Error(w, "User is banned", http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type mockAdminService struct {
+	service.AdminService
+	banned map[string]bool
+	err    error
+}
+
+func (m *mockAdminService) IsUserBanned(_ context.Context, userID string) (bool, error) {
+	return m.banned[userID], m.err
+}
+
+func TestRequireAdmin(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	middleware := NewAdminMiddleware(&mockAdminService{}, testLogger)
+	handler := middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name         string
+		userID       string
+		role         string
+		expectedCode int
+	}{
+		{name: "admin", userID: "admin-1", role: service.RoleAdmin, expectedCode: http.StatusNoContent},
+		{name: "regular user", userID: "user-1", expectedCode: http.StatusForbidden},
+		{name: "unknown role", userID: "user-1", role: "editor", expectedCode: http.StatusForbidden},
+		{name: "no user", userID: "", expectedCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/users/user-2/revoke-tokens", nil)
+			ctx := req.Context()
+			if tt.userID != "" {
+				ctx = context.WithValue(ctx, UserIDKey, tt.userID)
+			}
+			if tt.role != "" {
+				ctx = context.WithValue(ctx, RoleKey, tt.role)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req.WithContext(ctx))
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+}
+
+func TestRejectBanned(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+
+	tests := []struct {
+		name         string
+		method       string
+		userID       string
+		storageErr   error
+		expectedCode int
+	}{
+		{name: "banned user write", method: http.MethodPost, userID: "banned-1", expectedCode: http.StatusForbidden},
+		{name: "banned user delete", method: http.MethodDelete, userID: "banned-1", expectedCode: http.StatusForbidden},
+		{name: "banned user read", method: http.MethodGet, userID: "banned-1", expectedCode: http.StatusNoContent},
+		{name: "regular user write", method: http.MethodPost, userID: "user-1", expectedCode: http.StatusNoContent},
+		{name: "no user", method: http.MethodPost, expectedCode: http.StatusNoContent},
+		{
+			name:         "storage error",
+			method:       http.MethodPost,
+			userID:       "user-1",
+			storageErr:   errors.New("db error"),
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adminService := &mockAdminService{banned: map[string]bool{"banned-1": true}, err: tt.storageErr}
+			handler := NewAdminMiddleware(adminService, testLogger).RejectBanned(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusNoContent)
+				}))
+			req := httptest.NewRequest(tt.method, "/api/shorten", nil)
+			if tt.userID != "" {
+				req = req.WithContext(context.WithValue(req.Context(), UserIDKey, tt.userID))
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+}
@@ -3,11 +3,17 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/model"
 	"github.com/bezjen/shortener/internal/service"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // userIDKey is the context key type for storing user ID in request context.
@@ -20,99 +26,381 @@ const (
 
 	// UserIDKey is the context key for storing and retrieving user ID.
 	UserIDKey userIDKey = "userID"
+
+	// APIKeyHeader is the name of the header carrying API keys of programmatic clients.
+	APIKeyHeader = "X-API-Key"
+
+	// APIKeyKey is the context key for storing and retrieving the API key of the request.
+	APIKeyKey userIDKey = "apiKey"
+
+	// TokenKey is the context key for storing and retrieving the token the request was authenticated with.
+	TokenKey userIDKey = "token"
+
+	// RoleKey is the context key for storing and retrieving the role claim of the request token.
+	RoleKey userIDKey = "role"
+
+	// AuthMethodKey is the context key for storing and retrieving how the request was authenticated.
+	AuthMethodKey userIDKey = "authMethod"
+
+	// TokenRefreshWindow defines how long before expiration tokens are re-issued.
+	TokenRefreshWindow = 7 * 24 * time.Hour
+)
+
+// AuthPolicy defines how a route group authenticates requests.
+type AuthPolicy int
+
+// Authentication policies of route groups.
+const (
+	// AuthNone skips authentication, e.g. for health checks and public keys.
+	AuthNone AuthPolicy = iota
+
+	// AuthOptional authenticates valid credentials and serves other requests without a user.
+	AuthOptional
+
+	// AuthRequired rejects requests without valid credentials with 401 Unauthorized.
+	AuthRequired
+
+	// AuthAnonymousCreate creates a new user for requests without valid credentials.
+	// Meant for write endpoints creating links only.
+	AuthAnonymousCreate
+)
+
+// AuthMethod identifies how the user of a request was authenticated.
+type AuthMethod string
+
+// Authentication methods of requests.
+const (
+	// AuthMethodHeader means a shortener token in the Authorization header.
+	AuthMethodHeader AuthMethod = "header"
+
+	// AuthMethodIssuer means an ID token of the OIDC issuer in the Authorization header.
+	AuthMethodIssuer AuthMethod = "issuer"
+
+	// AuthMethodCookie means a token in the authentication cookie, sent by browsers automatically.
+	AuthMethodCookie AuthMethod = "cookie"
+
+	// AuthMethodAPIKey means an API key in the X-API-Key header.
+	AuthMethodAPIKey AuthMethod = "api_key"
+
+	// AuthMethodNewUser means an anonymous user created for the request.
+	AuthMethodNewUser AuthMethod = "new_user"
 )
 
 // AuthMiddleware provides JWT-based authentication for HTTP requests.
-// It handles both Authorization header and cookie-based authentication.
+// It handles Authorization header, cookie and API key authentication.
 type AuthMiddleware struct {
-	authorizer service.Authorizer
-	logger     *logger.Logger
+	authorizer    service.Authorizer
+	apiKeyService service.APIKeyService
+	oidcService   service.OIDCService
+	logger        *logger.Logger
 }
 
 // NewAuthMiddleware creates a new AuthMiddleware instance.
 //
 // Parameters:
 //   - authorizer: JWT authorizer service for token validation and creation
+//   - apiKeyService: API key service authenticating programmatic clients, nil disables API keys
+//   - oidcService: OIDC service validating ID tokens of the issuer, nil disables issuer tokens
 //   - logger: logger instance for authentication events
 //
 // Returns:
 //   - *AuthMiddleware: initialized authentication middleware
-func NewAuthMiddleware(authorizer service.Authorizer, logger *logger.Logger) *AuthMiddleware {
+func NewAuthMiddleware(authorizer service.Authorizer,
+	apiKeyService service.APIKeyService,
+	oidcService service.OIDCService,
+	logger *logger.Logger,
+) *AuthMiddleware {
 	return &AuthMiddleware{
-		authorizer: authorizer,
-		logger:     logger,
+		authorizer:    authorizer,
+		apiKeyService: apiKeyService,
+		oidcService:   oidcService,
+		logger:        logger,
 	}
 }
 
-// WithAuth wraps an HTTP handler with JWT authentication.
-// Supports both Authorization header and cookie-based authentication.
-// Creates new user ID and token for unauthenticated requests.
+// WithAuth wraps an HTTP handler with JWT authentication using the AuthAnonymousCreate policy.
 //
 // Parameters:
 //   - h: HTTP handler to wrap
 //
 // Returns:
-//   - http.Handler: wrapped handler that requires authentication
+//   - http.Handler: wrapped handler that creates users for anonymous requests
 func (m *AuthMiddleware) WithAuth(h http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var userID string
-		var err error
-
-		// Check Authorization header first
-		authHeader := r.Header.Get("Authorization")
-		if authHeader != "" {
-			userID, err = m.authorizer.ValidateToken(authHeader)
-			if err != nil {
-				http.Error(w, "Invalid auth header", http.StatusUnauthorized)
+	return m.WithPolicy(AuthAnonymousCreate)(h)
+}
+
+// WithPolicy returns middleware authenticating requests according to the policy.
+// Supports both Authorization header and cookie-based authentication.
+// The Authorization header carries a shortener token, raw or with the Bearer scheme,
+// or a Bearer ID token of the configured OIDC issuer.
+// Requests with the X-API-Key header are authenticated by the key instead
+// and get neither a token nor a cookie.
+// An invalid Authorization header is always rejected, while an invalid cookie
+// is rejected only by the AuthRequired policy and ignored otherwise.
+// Tokens are re-issued only when they expire within TokenRefreshWindow.
+//
+// Parameters:
+//   - policy: authentication policy of the wrapped routes
+//
+// Returns:
+//   - func(http.Handler) http.Handler: middleware applying the policy
+func (m *AuthMiddleware) WithPolicy(policy AuthPolicy) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		if policy == AuthNone {
+			return h
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if secret := r.Header.Get(APIKeyHeader); secret != "" {
+				m.serveWithAPIKey(h, w, r, secret)
+				return
+			}
+
+			userID, token, method, ok := m.authenticate(w, r, policy)
+			if !ok {
 				return
 			}
-		}
 
-		// If no header, check cookie
-		if userID == "" {
-			cookie, cookieErr := r.Cookie(CookieName)
-			if cookieErr == nil {
-				userID, cookieErr = m.authorizer.ValidateToken(cookie.Value)
-				if cookieErr != nil {
-					http.Error(w, "Invalid cookie", http.StatusUnauthorized)
+			switch {
+			case userID != "":
+				m.refreshToken(w, userID, token)
+			case policy == AuthRequired:
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			case policy == AuthAnonymousCreate:
+				userID, ok = m.createAnonymousUser(w)
+				if !ok {
 					return
 				}
+				method = AuthMethodNewUser
 			}
-		}
 
-		// If still no user ID, create new user
-		if userID == "" {
-			userID, err = generateNewUserID()
-			if err != nil {
-				m.logger.Error("Failed to generate new user ID", zap.Error(err))
-				http.Error(w, "Internal server error", http.StatusInternalServerError)
+			if userID == "" {
+				h.ServeHTTP(w, r)
 				return
 			}
-			m.logger.Infoln("New user created", zap.String("userID", userID))
-		}
+			// Set user ID, authentication method and presented token in context
+			ctx := context.WithValue(r.Context(), UserIDKey, userID)
+			ctx = context.WithValue(ctx, AuthMethodKey, method)
+			if token != "" {
+				ctx = context.WithValue(ctx, TokenKey, token)
+				if role := service.TokenRole(token); role != "" {
+					ctx = context.WithValue(ctx, RoleKey, role)
+				}
+			}
+			h.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
 
-		// Create new token and update cookie
-		newToken, err := m.authorizer.CreateToken(userID)
+// authenticate validates the token from the Authorization header or the cookie.
+// Writes an error response when the request must be rejected.
+//
+// Parameters:
+//   - w: HTTP response writer
+//   - r: HTTP request
+//   - policy: authentication policy of the route
+//
+// Returns:
+//   - string: user ID, empty when the request carries no valid token
+//   - string: validated shortener token, empty for issuer tokens
+//   - AuthMethod: where the valid token came from
+//   - bool: false if the request was rejected
+func (m *AuthMiddleware) authenticate(w http.ResponseWriter,
+	r *http.Request,
+	policy AuthPolicy,
+) (string, string, AuthMethod, bool) {
+	// Check Authorization header first
+	if authHeader := r.Header.Get("Authorization"); authHeader != "" {
+		token := authHeader
+		if scheme, credentials, found := strings.Cut(authHeader, " "); found && strings.EqualFold(scheme, "Bearer") {
+			token = strings.TrimSpace(credentials)
+		}
+		if m.oidcService != nil && m.oidcService.IssuedBy(token) {
+			userID, err := m.oidcService.ValidateIDToken(r.Context(), token)
+			if err != nil {
+				http.Error(w, "Invalid auth header", http.StatusUnauthorized)
+				return "", "", "", false
+			}
+			// Issuer tokens are neither refreshed nor revoked by the shortener
+			return userID, "", AuthMethodIssuer, true
+		}
+		userID, err := m.authorizer.ValidateToken(r.Context(), token)
 		if err != nil {
-			m.logger.Error("Failed to create user token", zap.Error(err), zap.String("userID", userID))
+			http.Error(w, "Invalid auth header", http.StatusUnauthorized)
+			return "", "", "", false
+		}
+		return userID, token, AuthMethodHeader, true
+	}
+
+	// If no header, check cookie
+	cookie, err := r.Cookie(CookieName)
+	if err != nil {
+		return "", "", "", true
+	}
+	userID, err := m.authorizer.ValidateToken(r.Context(), cookie.Value)
+	if err != nil {
+		if policy == AuthRequired {
+			http.Error(w, "Invalid cookie", http.StatusUnauthorized)
+			return "", "", "", false
+		}
+		// Stale cookies must not lock users out of public routes
+		m.logger.Debugln("Invalid cookie ignored", zap.String("path", r.URL.Path))
+		return "", "", "", true
+	}
+	return userID, cookie.Value, AuthMethodCookie, true
+}
+
+// createAnonymousUser creates a new user and sends its token.
+// Writes an error response when the token cannot be created.
+//
+// Parameters:
+//   - w: HTTP response writer
+//
+// Returns:
+//   - string: new user ID
+//   - bool: false if the request was rejected
+func (m *AuthMiddleware) createAnonymousUser(w http.ResponseWriter) (string, bool) {
+	userID, err := generateNewUserID()
+	if err != nil {
+		m.logger.Error("Failed to generate new user ID", zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return "", false
+	}
+
+	newToken, err := m.authorizer.CreateToken(userID)
+	if err != nil {
+		m.logger.Error("Failed to create user token", zap.Error(err), zap.String("userID", userID))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return "", false
+	}
+	SetAuthToken(w, newToken)
+	m.logger.Infoln("New user created", zap.String("userID", userID))
+	return userID, true
+}
+
+// refreshToken sends a new token when the valid token expires within TokenRefreshWindow.
+// The request is served with the old token when the new one cannot be created.
+//
+// Parameters:
+//   - w: HTTP response writer
+//   - userID: user ID of the token
+//   - token: valid shortener token of the request, empty for issuer tokens
+func (m *AuthMiddleware) refreshToken(w http.ResponseWriter, userID string, token string) {
+	if token == "" {
+		return
+	}
+	expiresAt, ok := service.TokenExpiresAt(token)
+	if !ok || time.Until(expiresAt) > TokenRefreshWindow {
+		return
+	}
+	newToken, err := m.authorizer.CreateToken(userID)
+	if err != nil {
+		m.logger.Error("Failed to refresh user token", zap.Error(err), zap.String("userID", userID))
+		return
+	}
+	SetAuthToken(w, newToken)
+}
+
+// serveWithAPIKey authenticates a request by its API key and checks the key scope for the request method.
+// GET, HEAD and OPTIONS requests need the read scope, DELETE requests the delete scope, others the write scope.
+//
+// Parameters:
+//   - h: HTTP handler to call for authenticated requests
+//   - w: HTTP response writer
+//   - r: HTTP request
+//   - secret: API key sent by the client
+func (m *AuthMiddleware) serveWithAPIKey(h http.Handler, w http.ResponseWriter, r *http.Request, secret string) {
+	if m.apiKeyService == nil {
+		http.Error(w, "Invalid API key", http.StatusUnauthorized)
+		return
+	}
+	key, err := m.apiKeyService.AuthenticateAPIKey(r.Context(), secret)
+	if err != nil {
+		var rateLimitErr *service.APIKeyRateLimitError
+		switch {
+		case errors.Is(err, service.ErrInvalidAPIKey):
+			http.Error(w, "Invalid API key", http.StatusUnauthorized)
+		case errors.As(err, &rateLimitErr):
+			retryAfter := int(math.Ceil(rateLimitErr.RetryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+			http.Error(w, "API key rate limit exceeded", http.StatusTooManyRequests)
+		default:
+			m.logger.Error("Failed to authenticate API key", zap.Error(err))
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
 		}
-		updateCookie(w, newToken)
+		return
+	}
 
-		// Set Authorization header and user ID in context
-		w.Header().Set("Authorization", newToken)
-		ctx := context.WithValue(r.Context(), UserIDKey, userID)
-		h.ServeHTTP(w, r.WithContext(ctx))
-	})
+	scope := requiredAPIKeyScope(r.Method)
+	if !key.HasScope(scope) {
+		http.Error(w, "API key lacks "+string(scope)+" scope", http.StatusForbidden)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), UserIDKey, key.UserID)
+	ctx = context.WithValue(ctx, AuthMethodKey, AuthMethodAPIKey)
+	ctx = context.WithValue(ctx, APIKeyKey, key)
+	h.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// APIKeyFromContext returns the API key the request was authenticated with.
+//
+// Parameters:
+//   - ctx: request context
+//
+// Returns:
+//   - *model.APIKey: API key, nil for requests authenticated by token or cookie
+func APIKeyFromContext(ctx context.Context) *model.APIKey {
+	key, _ := ctx.Value(APIKeyKey).(*model.APIKey)
+	return key
+}
+
+// TokenFromContext returns the token the request was authenticated with.
+//
+// Parameters:
+//   - ctx: request context
+//
+// Returns:
+//   - string: token from the Authorization header or cookie, empty for new users and API keys
+func TokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(TokenKey).(string)
+	return token
+}
+
+// AuthMethodFromContext returns how the user of the request was authenticated.
+//
+// Parameters:
+//   - ctx: request context
+//
+// Returns:
+//   - AuthMethod: authentication method, empty for requests without a user
+func AuthMethodFromContext(ctx context.Context) AuthMethod {
+	method, _ := ctx.Value(AuthMethodKey).(AuthMethod)
+	return method
+}
+
+// RoleFromContext returns the role claim of the token the request was authenticated with.
+//
+// Parameters:
+//   - ctx: request context
+//
+// Returns:
+//   - string: role of the token, empty for regular users, new users, issuer tokens and API keys
+func RoleFromContext(ctx context.Context) string {
+	role, _ := ctx.Value(RoleKey).(string)
+	return role
 }
 
-// updateCookie sets or updates the authentication cookie with the new token.
+// SetAuthToken sends the token in the Authorization header and the authentication cookie.
+// A token set earlier while handling the same request is replaced,
+// which lets handlers switch the request to another user, e.g. after login.
 //
 // Parameters:
 //   - w: HTTP response writer
-//   - newToken: JWT token to set in the cookie
-func updateCookie(w http.ResponseWriter, newToken string) {
+//   - newToken: JWT token to send
+func SetAuthToken(w http.ResponseWriter, newToken string) {
+	w.Header().Set("Authorization", newToken)
+	removeAuthCookie(w)
 	http.SetCookie(w, &http.Cookie{
 		Name:     CookieName,
 		Value:    newToken,
@@ -124,6 +412,57 @@ func updateCookie(w http.ResponseWriter, newToken string) {
 	})
 }
 
+// ClearAuthToken drops a token set earlier while handling the request
+// and tells the client to delete the authentication cookie, e.g. after logout.
+//
+// Parameters:
+//   - w: HTTP response writer
+func ClearAuthToken(w http.ResponseWriter) {
+	w.Header().Del("Authorization")
+	removeAuthCookie(w)
+	http.SetCookie(w, &http.Cookie{
+		Name:     CookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// removeAuthCookie removes the authentication cookie set earlier from the response headers.
+//
+// Parameters:
+//   - w: HTTP response writer
+func removeAuthCookie(w http.ResponseWriter) {
+	var cookies []string
+	for _, cookie := range w.Header().Values("Set-Cookie") {
+		if !strings.HasPrefix(cookie, CookieName+"=") {
+			cookies = append(cookies, cookie)
+		}
+	}
+	w.Header()["Set-Cookie"] = cookies
+}
+
+// requiredAPIKeyScope returns the API key scope needed for a request method.
+//
+// Parameters:
+//   - method: HTTP request method
+//
+// Returns:
+//   - model.APIKeyScope: read for safe methods, delete for DELETE, write otherwise
+func requiredAPIKeyScope(method string) model.APIKeyScope {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return model.ScopeRead
+	case http.MethodDelete:
+		return model.ScopeDelete
+	default:
+		return model.ScopeWrite
+	}
+}
+
 // generateNewUserID creates a new unique user identifier using UUID.
 //
 // Returns:
@@ -1,11 +1,17 @@
 package middleware
 
 import (
+	"context"
 	"errors"
 	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/service"
+	"github.com/golang-jwt/jwt/v5"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 type mockAuthorizer struct {
@@ -22,7 +28,7 @@ func (m *mockAuthorizer) CreateToken(userID string) (string, error) {
 	return "token_" + userID, nil
 }
 
-func (m *mockAuthorizer) ValidateToken(token string) (string, error) {
+func (m *mockAuthorizer) ValidateToken(_ context.Context, token string) (string, error) {
 	if m.validateError {
 		return "", errors.New("validation error")
 	}
@@ -32,10 +38,54 @@ func (m *mockAuthorizer) ValidateToken(token string) (string, error) {
 	return "", errors.New("invalid token")
 }
 
+func (m *mockAuthorizer) RevokeToken(_ context.Context, _ string) error {
+	return nil
+}
+
+func (m *mockAuthorizer) RevokeUserTokens(_ context.Context, _ string) error {
+	return nil
+}
+
+func (m *mockAuthorizer) JWKS() model.JWKS {
+	return model.JWKS{}
+}
+
+type mockAPIKeyService struct {
+	service.APIKeyService
+	keys    map[string]*model.APIKey
+	limited bool
+}
+
+func (m *mockAPIKeyService) AuthenticateAPIKey(_ context.Context, secret string) (*model.APIKey, error) {
+	if m.limited {
+		return nil, &service.APIKeyRateLimitError{RetryAfter: 1500 * time.Millisecond}
+	}
+	if key, exists := m.keys[secret]; exists {
+		return key, nil
+	}
+	return nil, service.ErrInvalidAPIKey
+}
+
+type mockOIDCService struct {
+	service.OIDCService
+	validTokens map[string]string
+}
+
+func (m *mockOIDCService) IssuedBy(token string) bool {
+	return strings.HasPrefix(token, "issuer_")
+}
+
+func (m *mockOIDCService) ValidateIDToken(_ context.Context, token string) (string, error) {
+	if userID, exists := m.validTokens[token]; exists {
+		return userID, nil
+	}
+	return "", service.ErrInvalidIDToken
+}
+
 func TestNewAuthMiddleware(t *testing.T) {
 	logger, _ := logger.NewLogger("debug")
 	authorizer := &mockAuthorizer{}
-	middleware := NewAuthMiddleware(authorizer, logger)
+	middleware := NewAuthMiddleware(authorizer, nil, nil, logger)
 
 	if middleware == nil || middleware.authorizer != authorizer {
 		t.Error("Expected authorizer to be set")
@@ -45,7 +95,7 @@ func TestNewAuthMiddleware(t *testing.T) {
 func TestWithAuth_NoCredentials(t *testing.T) {
 	logger, _ := logger.NewLogger("debug")
 	authorizer := &mockAuthorizer{}
-	middleware := NewAuthMiddleware(authorizer, logger)
+	middleware := NewAuthMiddleware(authorizer, nil, nil, logger)
 
 	req := httptest.NewRequest("GET", "/", nil)
 	rr := httptest.NewRecorder()
@@ -75,7 +125,7 @@ func TestWithAuth_ValidHeader(t *testing.T) {
 	authorizer := &mockAuthorizer{
 		validTokens: map[string]string{"valid_token": "user123"},
 	}
-	middleware := NewAuthMiddleware(authorizer, logger)
+	middleware := NewAuthMiddleware(authorizer, nil, nil, logger)
 
 	req := httptest.NewRequest("GET", "/", nil)
 	req.Header.Set("Authorization", "valid_token")
@@ -99,15 +149,16 @@ func TestWithAuth_ValidCookie(t *testing.T) {
 	authorizer := &mockAuthorizer{
 		validTokens: map[string]string{"cookie_token": "user456"},
 	}
-	middleware := NewAuthMiddleware(authorizer, logger)
+	middleware := NewAuthMiddleware(authorizer, nil, nil, logger)
 
 	req := httptest.NewRequest("GET", "/", nil)
 	req.AddCookie(&http.Cookie{Name: CookieName, Value: "cookie_token"})
 	rr := httptest.NewRecorder()
 
-	var capturedUserID string
+	var capturedUserID, capturedToken string
 	handler := middleware.WithAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		capturedUserID = r.Context().Value(UserIDKey).(string)
+		capturedToken = TokenFromContext(r.Context())
 		w.WriteHeader(http.StatusOK)
 	}))
 
@@ -116,12 +167,15 @@ func TestWithAuth_ValidCookie(t *testing.T) {
 	if capturedUserID != "user456" {
 		t.Errorf("Expected user456, got %s", capturedUserID)
 	}
+	if capturedToken != "cookie_token" {
+		t.Errorf("Expected cookie_token in context, got %s", capturedToken)
+	}
 }
 
 func TestWithAuth_InvalidHeader(t *testing.T) {
 	logger, _ := logger.NewLogger("debug")
 	authorizer := &mockAuthorizer{validateError: true}
-	middleware := NewAuthMiddleware(authorizer, logger)
+	middleware := NewAuthMiddleware(authorizer, nil, nil, logger)
 
 	req := httptest.NewRequest("GET", "/", nil)
 	req.Header.Set("Authorization", "invalid_token")
@@ -141,7 +195,7 @@ func TestWithAuth_InvalidHeader(t *testing.T) {
 func TestWithAuth_TokenCreationError(t *testing.T) {
 	logger, _ := logger.NewLogger("debug")
 	authorizer := &mockAuthorizer{createFail: true}
-	middleware := NewAuthMiddleware(authorizer, logger)
+	middleware := NewAuthMiddleware(authorizer, nil, nil, logger)
 
 	req := httptest.NewRequest("GET", "/", nil)
 	rr := httptest.NewRecorder()
@@ -157,14 +211,319 @@ func TestWithAuth_TokenCreationError(t *testing.T) {
 	}
 }
 
-func TestUpdateCookie(t *testing.T) {
+func TestWithPolicy(t *testing.T) {
+	logger, _ := logger.NewLogger("debug")
+
+	tests := []struct {
+		name           string
+		policy         AuthPolicy
+		cookie         string
+		expectedCode   int
+		expectedUserID string
+		expectedMethod AuthMethod
+		expectCookie   bool
+	}{
+		{name: "none skips valid cookie", policy: AuthNone, cookie: "valid_token", expectedCode: http.StatusOK},
+		{name: "none ignores invalid cookie", policy: AuthNone, cookie: "stale_token", expectedCode: http.StatusOK},
+		{name: "optional without credentials", policy: AuthOptional, expectedCode: http.StatusOK},
+		{name: "optional with valid cookie", policy: AuthOptional, cookie: "valid_token",
+			expectedCode: http.StatusOK, expectedUserID: "user123", expectedMethod: AuthMethodCookie},
+		{name: "optional ignores invalid cookie", policy: AuthOptional, cookie: "stale_token", expectedCode: http.StatusOK},
+		{name: "required without credentials", policy: AuthRequired, expectedCode: http.StatusUnauthorized},
+		{name: "required with invalid cookie", policy: AuthRequired, cookie: "stale_token",
+			expectedCode: http.StatusUnauthorized},
+		{name: "required with valid cookie", policy: AuthRequired, cookie: "valid_token",
+			expectedCode: http.StatusOK, expectedUserID: "user123", expectedMethod: AuthMethodCookie},
+		{name: "anonymous create replaces invalid cookie", policy: AuthAnonymousCreate, cookie: "stale_token",
+			expectedCode: http.StatusOK, expectCookie: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authorizer := &mockAuthorizer{validTokens: map[string]string{"valid_token": "user123"}}
+			middleware := NewAuthMiddleware(authorizer, nil, nil, logger)
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: CookieName, Value: tt.cookie})
+			}
+			rr := httptest.NewRecorder()
+
+			var capturedUserID string
+			var capturedMethod AuthMethod
+			handler := middleware.WithPolicy(tt.policy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				capturedUserID, _ = r.Context().Value(UserIDKey).(string)
+				capturedMethod = AuthMethodFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}))
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+			if tt.expectCookie {
+				if capturedUserID == "" || rr.Header().Get("Set-Cookie") == "" || capturedMethod != AuthMethodNewUser {
+					t.Error("Expected new user with cookie")
+				}
+				return
+			}
+			if capturedUserID != tt.expectedUserID {
+				t.Errorf("Expected user %q, got %q", tt.expectedUserID, capturedUserID)
+			}
+			if capturedMethod != tt.expectedMethod {
+				t.Errorf("Expected auth method %q, got %q", tt.expectedMethod, capturedMethod)
+			}
+			if rr.Header().Get("Set-Cookie") != "" {
+				t.Errorf("Expected no cookie, got %q", rr.Header().Get("Set-Cookie"))
+			}
+		})
+	}
+}
+
+func TestWithPolicy_RefreshesTokenNearExpiry(t *testing.T) {
+	logger, _ := logger.NewLogger("debug")
+	secretKey := []byte("test-secret-key")
+	authorizer := service.NewAuthorizer(secretKey, logger)
+	middleware := NewAuthMiddleware(authorizer, nil, nil, logger)
+
+	freshToken, err := authorizer.CreateToken("user123")
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+	expiringToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, service.ShortenerClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now().Add(-29 * 24 * time.Hour)),
+		},
+		UserID: "user123",
+	}).SignedString(secretKey)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		token         string
+		expectRefresh bool
+	}{
+		{name: "fresh token", token: freshToken, expectRefresh: false},
+		{name: "token close to expiry", token: expiringToken, expectRefresh: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", tt.token)
+			rr := httptest.NewRecorder()
+
+			handler := middleware.WithPolicy(AuthRequired)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", rr.Code)
+			}
+			refreshed := rr.Header().Get("Authorization")
+			if tt.expectRefresh && (refreshed == "" || refreshed == tt.token) {
+				t.Error("Expected refreshed token")
+			}
+			if !tt.expectRefresh && refreshed != "" {
+				t.Errorf("Expected no refreshed token, got %q", refreshed)
+			}
+		})
+	}
+}
+
+func TestWithPolicy_BearerTokens(t *testing.T) {
+	logger, _ := logger.NewLogger("debug")
+	authorizer := &mockAuthorizer{validTokens: map[string]string{"valid_token": "user123"}}
+	oidc := &mockOIDCService{validTokens: map[string]string{"issuer_token": "oidc-user"}}
+	middleware := NewAuthMiddleware(authorizer, nil, oidc, logger)
+
+	tests := []struct {
+		name           string
+		header         string
+		expectedCode   int
+		expectedUserID string
+		expectedToken  string
+		expectedMethod AuthMethod
+	}{
+		{name: "raw shortener token", header: "valid_token", expectedCode: http.StatusOK,
+			expectedUserID: "user123", expectedToken: "valid_token", expectedMethod: AuthMethodHeader},
+		{name: "bearer shortener token", header: "Bearer valid_token", expectedCode: http.StatusOK,
+			expectedUserID: "user123", expectedToken: "valid_token", expectedMethod: AuthMethodHeader},
+		{name: "bearer issuer token", header: "bearer issuer_token", expectedCode: http.StatusOK,
+			expectedUserID: "oidc-user", expectedMethod: AuthMethodIssuer},
+		{name: "invalid issuer token", header: "Bearer issuer_forged", expectedCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", tt.header)
+			rr := httptest.NewRecorder()
+
+			var capturedUserID, capturedToken string
+			var capturedMethod AuthMethod
+			handler := middleware.WithPolicy(AuthRequired)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				capturedUserID, _ = r.Context().Value(UserIDKey).(string)
+				capturedToken = TokenFromContext(r.Context())
+				capturedMethod = AuthMethodFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}))
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+			if capturedUserID != tt.expectedUserID {
+				t.Errorf("Expected user %q, got %q", tt.expectedUserID, capturedUserID)
+			}
+			if capturedToken != tt.expectedToken {
+				t.Errorf("Expected token %q, got %q", tt.expectedToken, capturedToken)
+			}
+			if capturedMethod != tt.expectedMethod {
+				t.Errorf("Expected method %q, got %q", tt.expectedMethod, capturedMethod)
+			}
+		})
+	}
+}
+
+func TestWithAuth_APIKey(t *testing.T) {
+	logger, _ := logger.NewLogger("debug")
+	apiKeys := &mockAPIKeyService{keys: map[string]*model.APIKey{
+		"shk_reader": {ID: "key-1", UserID: "user789", Scopes: []model.APIKeyScope{model.ScopeRead}},
+	}}
+	middleware := NewAuthMiddleware(&mockAuthorizer{}, apiKeys, nil, logger)
+
+	tests := []struct {
+		name         string
+		method       string
+		secret       string
+		expectedCode int
+	}{
+		{name: "read with read scope", method: http.MethodGet, secret: "shk_reader", expectedCode: http.StatusOK},
+		{name: "write without write scope", method: http.MethodPost, secret: "shk_reader", expectedCode: http.StatusForbidden},
+		{name: "delete without delete scope", method: http.MethodDelete, secret: "shk_reader", expectedCode: http.StatusForbidden},
+		{name: "unknown key", method: http.MethodGet, secret: "shk_unknown", expectedCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			req.Header.Set(APIKeyHeader, tt.secret)
+			rr := httptest.NewRecorder()
+
+			handler := middleware.WithAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Context().Value(UserIDKey) != "user789" {
+					t.Errorf("Expected user789 in context, got %v", r.Context().Value(UserIDKey))
+				}
+				if key := APIKeyFromContext(r.Context()); key == nil || key.ID != "key-1" {
+					t.Errorf("Expected key-1 in context, got %v", key)
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+			if rr.Header().Get("Set-Cookie") != "" || rr.Header().Get("Authorization") != "" {
+				t.Error("API key requests should not get a token")
+			}
+		})
+	}
+}
+
+func TestWithAuth_APIKeyRateLimited(t *testing.T) {
+	logger, _ := logger.NewLogger("debug")
+	middleware := NewAuthMiddleware(&mockAuthorizer{}, &mockAPIKeyService{limited: true}, nil, logger)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(APIKeyHeader, "shk_reader")
 	rr := httptest.NewRecorder()
-	updateCookie(rr, "test_token")
+
+	handler := middleware.WithAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Handler should not be called")
+	}))
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429, got %d", rr.Code)
+	}
+	if rr.Header().Get("Retry-After") != "2" {
+		t.Errorf("Expected Retry-After 2, got %q", rr.Header().Get("Retry-After"))
+	}
+}
+
+func TestWithAuth_APIKeysDisabled(t *testing.T) {
+	logger, _ := logger.NewLogger("debug")
+	middleware := NewAuthMiddleware(&mockAuthorizer{}, nil, nil, logger)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(APIKeyHeader, "shk_reader")
+	rr := httptest.NewRecorder()
+
+	handler := middleware.WithAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Handler should not be called")
+	}))
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", rr.Code)
+	}
+}
+
+func TestSetAuthToken(t *testing.T) {
+	rr := httptest.NewRecorder()
+	SetAuthToken(rr, "test_token")
 
 	cookieHeader := rr.Header().Get("Set-Cookie")
 	if cookieHeader == "" {
 		t.Error("Expected cookie to be set")
 	}
+	if rr.Header().Get("Authorization") != "test_token" {
+		t.Errorf("Expected Authorization header test_token, got %q", rr.Header().Get("Authorization"))
+	}
+}
+
+func TestSetAuthToken_ReplacesToken(t *testing.T) {
+	rr := httptest.NewRecorder()
+	http.SetCookie(rr, &http.Cookie{Name: "other", Value: "kept"})
+	SetAuthToken(rr, "anonymous_token")
+	SetAuthToken(rr, "account_token")
+
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 2 {
+		t.Fatalf("Expected 2 cookies, got %d", len(cookies))
+	}
+	if cookies[0].Name != "other" || cookies[1].Value != "account_token" {
+		t.Errorf("Unexpected cookies: %v", cookies)
+	}
+	if rr.Header().Get("Authorization") != "account_token" {
+		t.Errorf("Expected Authorization header account_token, got %q", rr.Header().Get("Authorization"))
+	}
+}
+
+func TestClearAuthToken(t *testing.T) {
+	rr := httptest.NewRecorder()
+	SetAuthToken(rr, "refreshed_token")
+	ClearAuthToken(rr)
+
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("Expected 1 cookie, got %d", len(cookies))
+	}
+	if cookies[0].Name != CookieName || cookies[0].Value != "" || cookies[0].MaxAge >= 0 {
+		t.Errorf("Expected expired auth cookie, got %v", cookies[0])
+	}
+	if rr.Header().Get("Authorization") != "" {
+		t.Errorf("Expected no Authorization header, got %q", rr.Header().Get("Authorization"))
+	}
 }
 
 func TestGenerateNewUserID(t *testing.T) {
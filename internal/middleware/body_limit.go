@@ -0,0 +1,180 @@
+// Package middleware provides HTTP middleware components for the URL shortening service.
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// Default limits of request bodies applied when BodyLimits leaves them zero.
+const (
+	// defaultMaxBodySize is the maximum body size of routes accepting a single object.
+	defaultMaxBodySize = 1 << 20
+
+	// defaultMaxBatchBodySize is the maximum body size of routes accepting lists of links.
+	defaultMaxBatchBodySize = 16 << 20
+
+	// defaultMaxDecompressedBodySize is the maximum size of a request body after GZIP decompression.
+	defaultMaxDecompressedBodySize = 16 << 20
+
+	// defaultMaxDecompressionRatio is the maximum ratio of decompressed to compressed request body size.
+	defaultMaxDecompressionRatio = 100
+)
+
+// BodyLimits defines the maximum sizes of request bodies. Zero values select the defaults.
+// Default and Batch limit bodies as received, compressed or not, the other limits apply to decompressed bodies.
+type BodyLimits struct {
+	// Default is the maximum body size in bytes of routes accepting a single object.
+	Default int64
+	// Batch is the maximum body size in bytes of routes accepting lists of links.
+	Batch int64
+	// Decompressed is the maximum size in bytes of a GZIP-compressed request body after decompression.
+	Decompressed int64
+	// DecompressionRatio is the maximum ratio of decompressed to compressed request body size.
+	DecompressionRatio int64
+}
+
+// withDefaults returns the limits with zero values replaced by the defaults.
+//
+// Returns:
+//   - BodyLimits: limits ready for use
+func (l BodyLimits) withDefaults() BodyLimits {
+	if l.Default <= 0 {
+		l.Default = defaultMaxBodySize
+	}
+	if l.Batch <= 0 {
+		l.Batch = defaultMaxBatchBodySize
+	}
+	if l.Decompressed <= 0 {
+		l.Decompressed = defaultMaxDecompressedBodySize
+	}
+	if l.DecompressionRatio <= 0 {
+		l.DecompressionRatio = defaultMaxDecompressionRatio
+	}
+	return l
+}
+
+// BodyLimitMiddleware limits the size of request bodies as received.
+// It has to run before GZIP decompression, so that compressed bodies are limited as well.
+// Reading past the limit fails with *http.MaxBytesError, which handlers report as 413 Request Entity Too Large.
+type BodyLimitMiddleware struct {
+	limits BodyLimits
+}
+
+// NewBodyLimitMiddleware creates a new BodyLimitMiddleware instance.
+//
+// Parameters:
+//   - limits: maximum sizes of request bodies, zero values select the defaults
+//
+// Returns:
+//   - *BodyLimitMiddleware: initialized body limit middleware
+func NewBodyLimitMiddleware(limits BodyLimits) *BodyLimitMiddleware {
+	return &BodyLimitMiddleware{limits: limits.withDefaults()}
+}
+
+// limitedBodyKey is the context key type for the limited body of a request.
+type limitedBodyKey struct{}
+
+// limitedBody is a request body as received, failing with *http.MaxBytesError once it exceeds the limit.
+// It is kept in the request context, so that routes can replace the limit after the body was wrapped
+// by GZIP decompression. Reads stop at the limit rather than past it, so that the read-ahead
+// of the decompressor does not exceed a limit a route raises later.
+type limitedBody struct {
+	io.ReadCloser
+	w     http.ResponseWriter
+	limit int64
+	n     int64
+	err   error
+}
+
+// setLimit replaces the limit of the body. Bytes already read count against the new limit.
+//
+// Parameters:
+//   - limit: maximum body size in bytes
+func (b *limitedBody) setLimit(limit int64) {
+	b.limit = limit
+}
+
+// Read reads from the underlying body up to the limit.
+//
+// Parameters:
+//   - p: byte slice to read into
+//
+// Returns:
+//   - int: number of bytes read
+//   - error: *http.MaxBytesError if the body exceeds the limit, error of the underlying body otherwise
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if b.n >= b.limit {
+		// A byte past the limit is read through http.MaxBytesReader, which makes the server close
+		// the connection instead of reading the rest of an oversized body.
+		_, err := http.MaxBytesReader(b.w, b.ReadCloser, 0).Read(p[:1])
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			err = &http.MaxBytesError{Limit: b.limit}
+		}
+		b.err = err
+		return 0, err
+	}
+	if remaining := b.limit - b.n; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+// WithBodyLimit wraps an HTTP handler with the default limit of request bodies.
+//
+// Parameters:
+//   - h: HTTP handler to wrap
+//
+// Returns:
+//   - http.Handler: wrapped handler with limited request bodies
+func (m *BodyLimitMiddleware) WithBodyLimit(h http.Handler) http.Handler {
+	return m.withLimit(m.limits.Default, h)
+}
+
+// WithBatchBodyLimit wraps an HTTP handler accepting lists of links with the batch limit of request bodies.
+// It replaces the default limit applied by WithBodyLimit.
+//
+// Parameters:
+//   - h: HTTP handler to wrap
+//
+// Returns:
+//   - http.Handler: wrapped handler with limited request bodies
+func (m *BodyLimitMiddleware) WithBatchBodyLimit(h http.Handler) http.Handler {
+	return m.withLimit(m.limits.Batch, h)
+}
+
+// withLimit wraps an HTTP handler with a limit of request bodies.
+//
+// Parameters:
+//   - limit: maximum body size in bytes
+//   - h: HTTP handler to wrap
+//
+// Returns:
+//   - http.Handler: wrapped handler with limited request bodies
+func (m *BodyLimitMiddleware) withLimit(limit int64, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if body, ok := r.Context().Value(limitedBodyKey{}).(*limitedBody); ok {
+			body.setLimit(limit)
+			h.ServeHTTP(w, r)
+			return
+		}
+		if r.Body == nil || r.Body == http.NoBody {
+			h.ServeHTTP(w, r)
+			return
+		}
+		body := &limitedBody{ReadCloser: r.Body, w: w, limit: limit}
+		r.Body = body
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), limitedBodyKey{}, body)))
+	})
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	m := NewBodyLimitMiddleware(BodyLimits{Default: 8, Batch: 16})
+
+	tests := []struct {
+		name      string
+		body      string
+		batch     bool
+		wantLimit int64
+	}{
+		{name: "within default limit", body: "12345678"},
+		{name: "over default limit", body: "123456789", wantLimit: 8},
+		{name: "batch limit replaces default limit", body: "123456789", batch: true},
+		{name: "over batch limit", body: strings.Repeat("1", 17), batch: true, wantLimit: 16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var readErr error
+			var read string
+			var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				read, readErr = string(body), err
+			})
+			if tt.batch {
+				handler = m.WithBatchBodyLimit(handler)
+			}
+			handler = m.WithBodyLimit(handler)
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if tt.wantLimit == 0 {
+				if readErr != nil || read != tt.body {
+					t.Errorf("expected body %q to be read, got %q and error %v", tt.body, read, readErr)
+				}
+				return
+			}
+			var maxBytesErr *http.MaxBytesError
+			if !errors.As(readErr, &maxBytesErr) || maxBytesErr.Limit != tt.wantLimit {
+				t.Errorf("expected limit %d to be exceeded, got error %v", tt.wantLimit, readErr)
+			}
+		})
+	}
+}
+
+func TestNewBodyLimitMiddleware_Defaults(t *testing.T) {
+	m := NewBodyLimitMiddleware(BodyLimits{})
+
+	expected := BodyLimits{
+		Default:            defaultMaxBodySize,
+		Batch:              defaultMaxBatchBodySize,
+		Decompressed:       defaultMaxDecompressedBodySize,
+		DecompressionRatio: defaultMaxDecompressionRatio,
+	}
+	if m.limits != expected {
+		t.Errorf("expected default limits %+v, got %+v", expected, m.limits)
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"github.com/bezjen/shortener/internal/logger"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithCSRFProtection(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	handler := NewCSRFMiddleware([]string{"App.Example.com"}, testLogger).WithCSRFProtection(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+
+	tests := []struct {
+		name         string
+		method       string
+		authMethod   AuthMethod
+		origin       string
+		referer      string
+		expectedCode int
+	}{
+		{name: "same origin", method: http.MethodPost, authMethod: AuthMethodCookie,
+			origin: "http://short.example.com", expectedCode: http.StatusNoContent},
+		{name: "trusted host", method: http.MethodDelete, authMethod: AuthMethodCookie,
+			origin: "https://app.example.com", expectedCode: http.StatusNoContent},
+		{name: "foreign origin", method: http.MethodPost, authMethod: AuthMethodCookie,
+			origin: "https://evil.com", expectedCode: http.StatusForbidden},
+		{name: "opaque origin", method: http.MethodPost, authMethod: AuthMethodCookie,
+			origin: "null", expectedCode: http.StatusForbidden},
+		{name: "same origin referer", method: http.MethodPost, authMethod: AuthMethodCookie,
+			referer: "http://short.example.com/page", expectedCode: http.StatusNoContent},
+		{name: "foreign referer", method: http.MethodPut, authMethod: AuthMethodCookie,
+			referer: "https://evil.com/page", expectedCode: http.StatusForbidden},
+		{name: "origin wins over referer", method: http.MethodPost, authMethod: AuthMethodCookie,
+			origin: "https://evil.com", referer: "http://short.example.com/page", expectedCode: http.StatusForbidden},
+		{name: "no origin and referer", method: http.MethodPost, authMethod: AuthMethodCookie,
+			expectedCode: http.StatusNoContent},
+		{name: "safe method", method: http.MethodGet, authMethod: AuthMethodCookie,
+			origin: "https://evil.com", expectedCode: http.StatusNoContent},
+		{name: "authorization header", method: http.MethodPost, authMethod: AuthMethodHeader,
+			origin: "https://evil.com", expectedCode: http.StatusNoContent},
+		{name: "api key", method: http.MethodPost, authMethod: AuthMethodAPIKey,
+			origin: "https://evil.com", expectedCode: http.StatusNoContent},
+		{name: "new user", method: http.MethodPost, authMethod: AuthMethodNewUser,
+			origin: "https://evil.com", expectedCode: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "http://short.example.com/api/shorten", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.referer != "" {
+				req.Header.Set("Referer", tt.referer)
+			}
+			req = req.WithContext(context.WithValue(req.Context(), AuthMethodKey, tt.authMethod))
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+}
@@ -2,32 +2,57 @@
 package middleware
 
 import (
+	"errors"
+	"fmt"
 	"github.com/bezjen/shortener/internal/compress"
 	"github.com/bezjen/shortener/internal/logger"
 	"go.uber.org/zap"
+	"io"
 	"net/http"
 	"strings"
 )
 
+// minDecompressionRatioCheckSize is the decompressed size below which the decompression ratio is not checked,
+// so that small bodies of repeated characters are accepted.
+const minDecompressionRatioCheckSize = 64 << 10
+
+// Errors of reading GZIP-compressed request bodies.
+var (
+	// ErrInvalidGzipBody indicates a request body that is not a valid GZIP stream.
+	ErrInvalidGzipBody = errors.New("invalid gzip body")
+
+	// ErrDecompressedBodyTooLarge indicates a request body exceeding the decompressed size limit.
+	ErrDecompressedBodyTooLarge = errors.New("decompressed request body too large")
+
+	// ErrDecompressionRatioExceeded indicates a request body compressed suspiciously well, like a gzip bomb.
+	ErrDecompressionRatioExceeded = errors.New("request body decompression ratio too high")
+)
+
 // GzipMiddleware provides GZIP compression and decompression for HTTP requests.
 // It automatically compresses responses and decompresses requests when appropriate.
 type GzipMiddleware struct {
 	logger *logger.Logger
+	limits BodyLimits
 }
 
 // NewGzipMiddleware creates a new GzipMiddleware instance.
 //
 // Parameters:
 //   - logger: logger instance for logging compression events
+//   - limits: limits of decompressed request bodies, zero values select the defaults
 //
 // Returns:
 //   - *GzipMiddleware: initialized GZIP middleware
-func NewGzipMiddleware(logger *logger.Logger) *GzipMiddleware {
-	return &GzipMiddleware{logger: logger}
+func NewGzipMiddleware(logger *logger.Logger, limits BodyLimits) *GzipMiddleware {
+	return &GzipMiddleware{logger: logger, limits: limits.withDefaults()}
 }
 
 // WithGzipRequestDecompression wraps an HTTP handler with GZIP request decompression.
 // Automatically decompresses request bodies with Content-Encoding: gzip header.
+// Requests with a malformed GZIP header are rejected with 400 Bad Request. Reading the body fails with
+// ErrDecompressedBodyTooLarge or ErrDecompressionRatioExceeded once the decompressed body exceeds the limits.
+// The compressed body is limited by BodyLimitMiddleware, which has to run before this middleware,
+// so that streams decompressing to nothing, like a series of empty GZIP members, are limited as well.
 //
 // Parameters:
 //   - h: HTTP handler to wrap
@@ -41,10 +66,17 @@ func (m *GzipMiddleware) WithGzipRequestDecompression(h http.Handler) http.Handl
 			return
 		}
 
-		gr, err := compress.NewGzipReader(r.Body)
+		compressed := &countingReader{ReadCloser: r.Body}
+		gr, err := compress.NewGzipReader(compressed)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, fmt.Sprintf("request body too large, limit is %d bytes", maxBytesErr.Limit),
+				http.StatusRequestEntityTooLarge)
+			return
+		}
 		if err != nil {
-			m.logger.Error("Failed to decompress request body", zap.Error(err))
-			w.WriteHeader(http.StatusInternalServerError)
+			m.logger.Infoln("Rejected request body with invalid gzip header", zap.Error(err))
+			http.Error(w, ErrInvalidGzipBody.Error(), http.StatusBadRequest)
 			return
 		}
 		defer func(gr *compress.GzipReader) {
@@ -53,7 +85,12 @@ func (m *GzipMiddleware) WithGzipRequestDecompression(h http.Handler) http.Handl
 				m.logger.Error("Failed to close gzip reader", zap.Error(err))
 			}
 		}(gr)
-		r.Body = gr
+		r.Body = &decompressedBody{
+			ReadCloser: gr,
+			compressed: compressed,
+			maxSize:    m.limits.Decompressed,
+			maxRatio:   m.limits.DecompressionRatio,
+		}
 		h.ServeHTTP(w, r)
 	})
 }
@@ -88,3 +125,61 @@ func (m *GzipMiddleware) WithGzipResponseCompression(h http.Handler) http.Handle
 		h.ServeHTTP(gw, r)
 	})
 }
+
+// countingReader counts the bytes read from a request body.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+// Read reads from the underlying body and counts the bytes read.
+//
+// Parameters:
+//   - p: byte slice to read into
+//
+// Returns:
+//   - int: number of bytes read
+//   - error: error if reading fails
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// decompressedBody is a GZIP-decompressed request body enforcing the size and ratio limits.
+type decompressedBody struct {
+	io.ReadCloser
+	compressed *countingReader
+	n          int64
+	maxSize    int64
+	maxRatio   int64
+}
+
+// Read reads decompressed data, failing once the decompressed body exceeds the limits.
+// Errors of the GZIP stream are wrapped with ErrInvalidGzipBody, *http.MaxBytesError of the compressed body
+// is returned as is.
+//
+// Parameters:
+//   - p: byte slice to read into
+//
+// Returns:
+//   - int: number of bytes read
+//   - error: error if reading fails or the limits are exceeded
+func (b *decompressedBody) Read(p []byte) (int, error) {
+	if remaining := b.maxSize - b.n + 1; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if b.n > b.maxSize {
+		return 0, fmt.Errorf("%w, limit is %d bytes", ErrDecompressedBodyTooLarge, b.maxSize)
+	}
+	if b.n > minDecompressionRatioCheckSize && b.n > b.compressed.n*b.maxRatio {
+		return 0, fmt.Errorf("%w, limit is %d", ErrDecompressionRatioExceeded, b.maxRatio)
+	}
+	var maxBytesErr *http.MaxBytesError
+	if err != nil && !errors.Is(err, io.EOF) && !errors.As(err, &maxBytesErr) {
+		return n, fmt.Errorf("%w: %v", ErrInvalidGzipBody, err)
+	}
+	return n, err
+}
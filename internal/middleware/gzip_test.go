@@ -3,8 +3,10 @@ package middleware
 import (
 	"bytes"
 	"compress/gzip"
+	"errors"
 	"github.com/bezjen/shortener/internal/logger"
 	"io"
+	"math/rand"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -42,7 +44,7 @@ func TestWithGzipRequestDecompression(t *testing.T) {
 		},
 	}
 	testLogger, _ := logger.NewLogger("debug")
-	m := NewGzipMiddleware(testLogger)
+	m := NewGzipMiddleware(testLogger, BodyLimits{})
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -91,7 +93,7 @@ func TestWithGzipResponseCompression(t *testing.T) {
 		},
 	}
 	testLogger, _ := logger.NewLogger("debug")
-	m := NewGzipMiddleware(testLogger)
+	m := NewGzipMiddleware(testLogger, BodyLimits{})
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -130,3 +132,120 @@ func TestWithGzipResponseCompression(t *testing.T) {
 		})
 	}
 }
+
+func TestWithGzipRequestDecompression_Limits(t *testing.T) {
+	compress := func(content []byte) []byte {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write(content)
+		gz.Close()
+		return buf.Bytes()
+	}
+	random := make([]byte, 256<<10)
+	rand.New(rand.NewSource(1)).Read(random)
+	valid := compress([]byte("compressed text"))
+
+	tests := []struct {
+		name         string
+		body         []byte
+		limits       BodyLimits
+		expectedCode int
+		expectedErr  error
+	}{
+		{
+			name:         "within limits",
+			body:         compress(random),
+			limits:       BodyLimits{Decompressed: 512 << 10},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "small body of repeated characters",
+			body:         compress(bytes.Repeat([]byte("a"), 32<<10)),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "decompressed size exceeded",
+			body:         compress(random),
+			limits:       BodyLimits{Decompressed: 128 << 10},
+			expectedCode: http.StatusOK,
+			expectedErr:  ErrDecompressedBodyTooLarge,
+		},
+		{
+			name:         "gzip bomb",
+			body:         compress(make([]byte, 8<<20)),
+			limits:       BodyLimits{Decompressed: 64 << 20},
+			expectedCode: http.StatusOK,
+			expectedErr:  ErrDecompressionRatioExceeded,
+		},
+		{
+			name:         "corrupt stream",
+			body:         append(valid[:len(valid)-8:len(valid)-8], 0, 0, 0, 0, 0, 0, 0, 0),
+			expectedCode: http.StatusOK,
+			expectedErr:  ErrInvalidGzipBody,
+		},
+		{
+			name:         "invalid header",
+			body:         []byte("not gzip"),
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	testLogger, _ := logger.NewLogger("debug")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var readErr error
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, readErr = io.ReadAll(r.Body)
+			})
+			req := httptest.NewRequest(http.MethodPost, "http://localhost:8080", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Encoding", "gzip")
+			rec := httptest.NewRecorder()
+
+			NewGzipMiddleware(testLogger, tt.limits).WithGzipRequestDecompression(handler).ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedCode {
+				t.Errorf("expected status %d, got %d", tt.expectedCode, rec.Code)
+			}
+			if tt.expectedErr == nil && readErr != nil {
+				t.Errorf("unexpected error %v", readErr)
+			}
+			if tt.expectedErr != nil && !errors.Is(readErr, tt.expectedErr) {
+				t.Errorf("expected error %v, got %v", tt.expectedErr, readErr)
+			}
+		})
+	}
+}
+
+func TestWithGzipRequestDecompression_BodyLimit(t *testing.T) {
+	var body bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		gz := gzip.NewWriter(&body)
+		gz.Close()
+	}
+	testLogger, _ := logger.NewLogger("debug")
+	limits := BodyLimits{Default: 1024}
+
+	var read int
+	var readErr error
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data []byte
+		data, readErr = io.ReadAll(r.Body)
+		read = len(data)
+	})
+	req := httptest.NewRequest(http.MethodPost, "http://localhost:8080", &body)
+	req.Header.Set("Content-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+
+	gzipMiddleware := NewGzipMiddleware(testLogger, limits)
+	NewBodyLimitMiddleware(limits).WithBodyLimit(gzipMiddleware.WithGzipRequestDecompression(handler)).
+		ServeHTTP(rec, req)
+
+	// Empty GZIP members decompress to nothing, the compressed body is limited nonetheless.
+	var maxBytesErr *http.MaxBytesError
+	if !errors.As(readErr, &maxBytesErr) || maxBytesErr.Limit != 1024 {
+		t.Errorf("expected limit 1024 to be exceeded, got error %v", readErr)
+	}
+	if read != 0 {
+		t.Errorf("expected empty body, got %d bytes", read)
+	}
+}
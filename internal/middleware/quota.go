@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/service"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+// Headers reporting the limits of the user plan and their remaining parts.
+const (
+	// QuotaDailyLimitHeader is the number of links the user may create per UTC day.
+	QuotaDailyLimitHeader = "X-Quota-Daily-Limit"
+
+	// QuotaDailyRemainingHeader is the number of links the user may still create today.
+	QuotaDailyRemainingHeader = "X-Quota-Daily-Remaining"
+
+	// QuotaActiveLimitHeader is the number of links of the user that may exist at once.
+	QuotaActiveLimitHeader = "X-Quota-Active-Limit"
+
+	// QuotaActiveRemainingHeader is the number of links the user may still create without deleting any.
+	QuotaActiveRemainingHeader = "X-Quota-Active-Remaining"
+)
+
+// QuotaMiddleware adds remaining-quota headers to responses of authenticated users.
+type QuotaMiddleware struct {
+	quotaService service.QuotaService
+	logger       *logger.Logger
+}
+
+// NewQuotaMiddleware creates a new QuotaMiddleware instance.
+//
+// Parameters:
+//   - quotaService: service reading limits and usage of users, nil disables the headers
+//   - logger: logger instance for quota lookup errors
+//
+// Returns:
+//   - *QuotaMiddleware: initialized quota middleware
+func NewQuotaMiddleware(quotaService service.QuotaService, logger *logger.Logger) *QuotaMiddleware {
+	return &QuotaMiddleware{
+		quotaService: quotaService,
+		logger:       logger,
+	}
+}
+
+// WithQuotaHeaders wraps an HTTP handler so that responses report the remaining quota of the user.
+// The quota checked by the handler is reused through the request context, so it is read once per request
+// and counts links created by the request. Requests that did not check it read it when the handler writes
+// the response status. Unlimited quotas get no headers. Must run after authentication.
+//
+// Parameters:
+//   - h: HTTP handler to wrap
+//
+// Returns:
+//   - http.Handler: wrapped handler that adds quota headers
+func (m *QuotaMiddleware) WithQuotaHeaders(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := r.Context().Value(UserIDKey).(string)
+		if m.quotaService == nil || userID == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		r = r.WithContext(service.WithRequestQuota(r.Context()))
+		h.ServeHTTP(&quotaResponseWriter{ResponseWriter: w, middleware: m, request: r, userID: userID}, r)
+	})
+}
+
+// quotaResponseWriter wraps http.ResponseWriter to add quota headers before the status is written.
+type quotaResponseWriter struct {
+	http.ResponseWriter
+	middleware  *QuotaMiddleware
+	request     *http.Request
+	userID      string
+	wroteHeader bool
+}
+
+// Write adds quota headers when the handler writes the body without a status.
+// Implements the http.ResponseWriter interface.
+//
+// Parameters:
+//   - b: byte slice to write
+//
+// Returns:
+//   - int: number of bytes written
+//   - error: error if writing fails
+func (w *quotaResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// WriteHeader adds quota headers and writes the status code.
+// Implements the http.ResponseWriter interface.
+//
+// Parameters:
+//   - statusCode: HTTP status code to write
+func (w *quotaResponseWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.middleware.setQuotaHeaders(w.Header(), w.request, w.userID)
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// setQuotaHeaders sets headers of limited quotas of the user.
+// The quota checked by the request is used if any, otherwise it is read.
+// Lookup errors are logged only, the response is served without headers.
+//
+// Parameters:
+//   - header: response headers
+//   - r: HTTP request
+//   - userID: identifier of the user
+func (m *QuotaMiddleware) setQuotaHeaders(header http.Header, r *http.Request, userID string) {
+	quota := service.RequestQuota(r.Context(), userID)
+	if quota == nil {
+		var err error
+		quota, err = m.quotaService.GetQuota(r.Context(), userID)
+		if err != nil {
+			m.logger.Error("Failed to get user quota", zap.Error(err), zap.String("userID", userID))
+			return
+		}
+	}
+	if remaining, limited := quota.RemainingLinksToday(); limited {
+		header.Set(QuotaDailyLimitHeader, strconv.FormatInt(quota.Limits.LinksPerDay, 10))
+		header.Set(QuotaDailyRemainingHeader, strconv.FormatInt(remaining, 10))
+	}
+	if remaining, limited := quota.RemainingActiveLinks(); limited {
+		header.Set(QuotaActiveLimitHeader, strconv.FormatInt(quota.Limits.ActiveLinks, 10))
+		header.Set(QuotaActiveRemainingHeader, strconv.FormatInt(remaining, 10))
+	}
+}
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/repository"
+	"github.com/bezjen/shortener/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type mockQuotaService struct {
+	service.QuotaService
+	quota *model.Quota
+	err   error
+}
+
+func (m *mockQuotaService) GetQuota(_ context.Context, _ string) (*model.Quota, error) {
+	return m.quota, m.err
+}
+
+func TestWithQuotaHeaders(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	limited := &model.Quota{
+		Limits: model.QuotaLimits{LinksPerDay: 10, ActiveLinks: 100},
+		Usage:  model.QuotaUsage{LinksToday: 4, ActiveLinks: 60},
+	}
+
+	tests := []struct {
+		name            string
+		quotaService    service.QuotaService
+		userID          string
+		expectedHeaders map[string]string
+	}{
+		{
+			name:         "limited quota",
+			quotaService: &mockQuotaService{quota: limited},
+			userID:       "user-1",
+			expectedHeaders: map[string]string{
+				QuotaDailyLimitHeader:      "10",
+				QuotaDailyRemainingHeader:  "6",
+				QuotaActiveLimitHeader:     "100",
+				QuotaActiveRemainingHeader: "40",
+			},
+		},
+		{
+			name:         "daily quota only",
+			quotaService: &mockQuotaService{quota: &model.Quota{Limits: model.QuotaLimits{LinksPerDay: 10}}},
+			userID:       "user-1",
+			expectedHeaders: map[string]string{
+				QuotaDailyLimitHeader:      "10",
+				QuotaDailyRemainingHeader:  "10",
+				QuotaActiveLimitHeader:     "",
+				QuotaActiveRemainingHeader: "",
+			},
+		},
+		{
+			name:            "unlimited quota",
+			quotaService:    &mockQuotaService{quota: &model.Quota{}},
+			userID:          "user-1",
+			expectedHeaders: map[string]string{QuotaDailyLimitHeader: "", QuotaActiveLimitHeader: ""},
+		},
+		{
+			name:            "lookup error",
+			quotaService:    &mockQuotaService{err: errors.New("db error")},
+			userID:          "user-1",
+			expectedHeaders: map[string]string{QuotaDailyLimitHeader: "", QuotaActiveLimitHeader: ""},
+		},
+		{
+			name:            "no user",
+			quotaService:    &mockQuotaService{quota: limited},
+			expectedHeaders: map[string]string{QuotaDailyLimitHeader: "", QuotaActiveLimitHeader: ""},
+		},
+		{
+			name:            "quotas disabled",
+			userID:          "user-1",
+			expectedHeaders: map[string]string{QuotaDailyLimitHeader: "", QuotaActiveLimitHeader: ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewQuotaMiddleware(tt.quotaService, testLogger).WithQuotaHeaders(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusCreated)
+				}))
+			req := httptest.NewRequest(http.MethodPost, "/api/shorten", nil)
+			req = req.WithContext(context.WithValue(req.Context(), UserIDKey, tt.userID))
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusCreated {
+				t.Errorf("Expected status %d, got %d", http.StatusCreated, rr.Code)
+			}
+			for header, expected := range tt.expectedHeaders {
+				if got := rr.Header().Get(header); got != expected {
+					t.Errorf("Expected header %s %q, got %q", header, expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestWithQuotaHeaders_ReusesRequestQuota(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	repo := repository.NewInMemoryRepository()
+	u := service.NewURLShortener(repo, testLogger)
+	defer u.Close()
+	u.EnableQuotas(model.QuotaLimits{LinksPerDay: 10})
+	quotaService := &mockQuotaService{err: errors.New("quota read again")}
+
+	handler := NewQuotaMiddleware(quotaService, testLogger).WithQuotaHeaders(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, err := u.GenerateShortURLPart(r.Context(), "user-1", "", "https://example.com"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			w.WriteHeader(http.StatusCreated)
+		}))
+	req := httptest.NewRequest(http.MethodPost, "/api/shorten", nil)
+	req = req.WithContext(context.WithValue(req.Context(), UserIDKey, "user-1"))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if got := rr.Header().Get(QuotaDailyRemainingHeader); got != "9" {
+		t.Errorf("Expected header %s %q, got %q", QuotaDailyRemainingHeader, "9", got)
+	}
+}
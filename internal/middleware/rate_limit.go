@@ -0,0 +1,239 @@
+package middleware
+
+import (
+	"context"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/model"
+	"go.uber.org/zap"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Headers reporting the state of the rate limit bucket of the client.
+const (
+	// RateLimitLimitHeader is the number of requests the client may send in a burst.
+	RateLimitLimitHeader = "X-RateLimit-Limit"
+
+	// RateLimitRemainingHeader is the number of requests the client may still send right away.
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+)
+
+// rateLimitSweepInterval defines how often the in-memory store drops refilled buckets.
+const rateLimitSweepInterval = time.Minute
+
+// RateLimitStore defines the interface for keeping token buckets of clients.
+type RateLimitStore interface {
+	// TakeRateLimitTokens refills the buckets of the keys and takes a single token from each of them
+	// if all of them allow the request. Buckets are left untouched when any of them denies it.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - keys: identifiers of the buckets
+	//   - limit: budget of the buckets
+	//   - now: time of the request
+	//
+	// Returns:
+	//   - []model.RateLimitDecision: whether each bucket allows the request and its state, in the order of keys
+	//   - error: error if the buckets cannot be read or stored
+	TakeRateLimitTokens(ctx context.Context,
+		keys []string,
+		limit model.RateLimit,
+		now time.Time,
+	) ([]model.RateLimitDecision, error)
+}
+
+// rateLimitEntry is a bucket kept in process memory together with its budget.
+type rateLimitEntry struct {
+	bucket model.TokenBucket
+	limit  model.RateLimit
+}
+
+// InMemoryRateLimitStore keeps token buckets in process memory.
+// Every replica of the service counts requests on its own.
+type InMemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]rateLimitEntry
+	lastSweep time.Time
+}
+
+// NewInMemoryRateLimitStore creates a new InMemoryRateLimitStore instance.
+//
+// Returns:
+//   - *InMemoryRateLimitStore: empty in-memory store
+func NewInMemoryRateLimitStore() *InMemoryRateLimitStore {
+	return &InMemoryRateLimitStore{buckets: make(map[string]rateLimitEntry)}
+}
+
+// TakeRateLimitTokens refills the buckets of the keys and takes a single token from each of them
+// if all of them allow the request. Buckets that refilled completely are dropped once a minute to bound memory.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - keys: identifiers of the buckets
+//   - limit: budget of the buckets
+//   - now: time of the request
+//
+// Returns:
+//   - []model.RateLimitDecision: whether each bucket allows the request and its state, in the order of keys
+//   - error: always nil
+func (s *InMemoryRateLimitStore) TakeRateLimitTokens(_ context.Context,
+	keys []string,
+	limit model.RateLimit,
+	now time.Time,
+) ([]model.RateLimitDecision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= rateLimitSweepInterval {
+		for k, entry := range s.buckets {
+			if !now.Before(entry.bucket.FullAt(entry.limit)) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	buckets := make([]model.TokenBucket, len(keys))
+	decisions := make([]model.RateLimitDecision, len(keys))
+	allowed := true
+	for i, key := range keys {
+		buckets[i], decisions[i] = s.buckets[key].bucket.Take(limit, now)
+		allowed = allowed && decisions[i].Allowed
+	}
+	if allowed {
+		for i, key := range keys {
+			s.buckets[key] = rateLimitEntry{bucket: buckets[i], limit: limit}
+		}
+	}
+	return decisions, nil
+}
+
+// RateLimitMiddleware limits requests by token buckets of users and client IPs.
+// Creating links and following redirects have separate budgets.
+type RateLimitMiddleware struct {
+	store         RateLimitStore
+	createLimit   model.RateLimit
+	redirectLimit model.RateLimit
+	trustedSubnet *net.IPNet
+	logger        *logger.Logger
+}
+
+// NewRateLimitMiddleware creates a new RateLimitMiddleware instance.
+//
+// Parameters:
+//   - store: store keeping token buckets, in memory or shared by replicas
+//   - createLimit: budget of routes creating links, per user and per client IP
+//   - redirectLimit: budget of the redirect route, per client IP
+//   - trustedSubnet: subnet of reverse proxies whose X-Real-IP header is trusted, nil ignores the header
+//   - logger: logger instance for store errors
+//
+// Returns:
+//   - *RateLimitMiddleware: initialized rate limit middleware
+func NewRateLimitMiddleware(store RateLimitStore,
+	createLimit model.RateLimit,
+	redirectLimit model.RateLimit,
+	trustedSubnet *net.IPNet,
+	logger *logger.Logger,
+) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		store:         store,
+		createLimit:   createLimit,
+		redirectLimit: redirectLimit,
+		trustedSubnet: trustedSubnet,
+		logger:        logger,
+	}
+}
+
+// LimitCreate wraps an HTTP handler creating links or abuse reports with the create budget.
+// Requests take a token from the bucket of the user and from the bucket of the client IP,
+// so that clients dropping their cookie to get new anonymous users are limited too.
+// The client IP is taken from X-Real-IP only for connections from the trusted subnet.
+// Must run after authentication. A nil middleware passes all requests.
+//
+// Parameters:
+//   - h: HTTP handler to wrap
+//
+// Returns:
+//   - http.Handler: wrapped handler that answers 429 Too Many Requests over the budget
+func (m *RateLimitMiddleware) LimitCreate(h http.Handler) http.Handler {
+	if m == nil || !m.createLimit.Enabled() {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys := []string{"create:ip:" + proxiedClientIP(r, m.trustedSubnet).String()}
+		userID, _ := r.Context().Value(UserIDKey).(string)
+		if userID != "" {
+			keys = append(keys, "create:user:"+userID)
+		}
+		m.serveLimited(h, w, r, m.createLimit, keys)
+	})
+}
+
+// LimitRedirect wraps the redirect handler with the redirect budget of the client IP.
+// The client IP is taken from X-Real-IP only for connections from the trusted subnet.
+// A nil middleware passes all requests.
+//
+// Parameters:
+//   - h: HTTP handler to wrap
+//
+// Returns:
+//   - http.Handler: wrapped handler that answers 429 Too Many Requests over the budget
+func (m *RateLimitMiddleware) LimitRedirect(h http.Handler) http.Handler {
+	if m == nil || !m.redirectLimit.Enabled() {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.serveLimited(h, w, r, m.redirectLimit, []string{"redirect:ip:" + proxiedClientIP(r, m.trustedSubnet).String()})
+	})
+}
+
+// serveLimited takes a token from every bucket and serves the request if all of them allow it.
+// No token is taken when any bucket denies the request. The most exhausted bucket is reported in the headers.
+// Store errors are logged and the request is served, so that an unavailable shared store does not take
+// the service down.
+//
+// Parameters:
+//   - h: HTTP handler to call for allowed requests
+//   - w: HTTP response writer
+//   - r: HTTP request
+//   - limit: budget of the buckets
+//   - keys: identifiers of the buckets
+func (m *RateLimitMiddleware) serveLimited(h http.Handler,
+	w http.ResponseWriter,
+	r *http.Request,
+	limit model.RateLimit,
+	keys []string,
+) {
+	decisions, err := m.store.TakeRateLimitTokens(r.Context(), keys, limit, time.Now())
+	if err != nil {
+		m.logger.Error("Failed to take rate limit tokens", zap.Error(err), zap.Strings("keys", keys))
+		h.ServeHTTP(w, r)
+		return
+	}
+	reported := model.RateLimitDecision{Allowed: true, Limit: limit.Capacity(), Remaining: limit.Capacity()}
+	for _, decision := range decisions {
+		if !decision.Allowed {
+			if reported.Allowed || decision.RetryAfter > reported.RetryAfter {
+				reported = decision
+			}
+			continue
+		}
+		if reported.Allowed && decision.Remaining < reported.Remaining {
+			reported = decision
+		}
+	}
+
+	w.Header().Set(RateLimitLimitHeader, strconv.FormatInt(reported.Limit, 10))
+	w.Header().Set(RateLimitRemainingHeader, strconv.FormatInt(reported.Remaining, 10))
+	if !reported.Allowed {
+		retryAfter := int(math.Ceil(reported.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+		http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+		return
+	}
+	h.ServeHTTP(w, r)
+}
@@ -0,0 +1,186 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/repository"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type failingRateLimitStore struct{}
+
+func (s failingRateLimitStore) TakeRateLimitTokens(_ context.Context,
+	_ []string,
+	_ model.RateLimit,
+	_ time.Time,
+) ([]model.RateLimitDecision, error) {
+	return nil, errors.New("db error")
+}
+
+var _ RateLimitStore = (*repository.PostgresRepository)(nil)
+
+func TestInMemoryRateLimitStore(t *testing.T) {
+	store := NewInMemoryRateLimitStore()
+	limit := model.RateLimit{RequestsPerMinute: 60, Burst: 1}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	decisions, _ := store.TakeRateLimitTokens(context.Background(), []string{"a"}, limit, now)
+	if !decisions[0].Allowed {
+		t.Errorf("Expected first request allowed")
+	}
+	decisions, _ = store.TakeRateLimitTokens(context.Background(), []string{"a"}, limit, now)
+	if decisions[0].Allowed || decisions[0].RetryAfter != time.Second {
+		t.Errorf("Expected second request rejected for a second, got %+v", decisions[0])
+	}
+	decisions, _ = store.TakeRateLimitTokens(context.Background(), []string{"b", "a"}, limit, now)
+	if !decisions[0].Allowed || decisions[1].Allowed {
+		t.Errorf("Expected request rejected by the exhausted bucket only, got %+v", decisions)
+	}
+	decisions, _ = store.TakeRateLimitTokens(context.Background(), []string{"b"}, limit, now)
+	if !decisions[0].Allowed {
+		t.Errorf("Expected no token taken from buckets of a rejected request")
+	}
+
+	store.TakeRateLimitTokens(context.Background(), []string{"c"}, limit, now.Add(2*time.Minute))
+	if len(store.buckets) != 1 {
+		t.Errorf("Expected refilled buckets dropped, got %d buckets", len(store.buckets))
+	}
+}
+
+func TestLimitCreate(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	limit := model.RateLimit{RequestsPerMinute: 1}
+	handler := NewRateLimitMiddleware(NewInMemoryRateLimitStore(), limit, model.RateLimit{}, nil, testLogger).
+		LimitCreate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		}))
+
+	tests := []struct {
+		name         string
+		ip           string
+		userID       string
+		authMethod   AuthMethod
+		expectedCode int
+	}{
+		{name: "first request", ip: "10.0.0.1", userID: "user-1", authMethod: AuthMethodHeader,
+			expectedCode: http.StatusCreated},
+		{name: "same user from another ip", ip: "10.0.0.2", userID: "user-1", authMethod: AuthMethodCookie,
+			expectedCode: http.StatusTooManyRequests},
+		{name: "ip of a rejected request keeps its budget", ip: "10.0.0.2", userID: "user-4",
+			authMethod: AuthMethodHeader, expectedCode: http.StatusCreated},
+		{name: "new user from the same ip", ip: "10.0.0.1", userID: "user-2", authMethod: AuthMethodNewUser,
+			expectedCode: http.StatusTooManyRequests},
+		{name: "another user from another ip", ip: "10.0.0.3", userID: "user-3", authMethod: AuthMethodHeader,
+			expectedCode: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/shorten", nil)
+			req.RemoteAddr = tt.ip + ":1234"
+			ctx := context.WithValue(req.Context(), UserIDKey, tt.userID)
+			ctx = context.WithValue(ctx, AuthMethodKey, tt.authMethod)
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req.WithContext(ctx))
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+			if rr.Header().Get(RateLimitLimitHeader) != "1" || rr.Header().Get(RateLimitRemainingHeader) != "0" {
+				t.Errorf("Expected limit headers 1 and 0, got %q and %q",
+					rr.Header().Get(RateLimitLimitHeader), rr.Header().Get(RateLimitRemainingHeader))
+			}
+			if tt.expectedCode == http.StatusTooManyRequests && rr.Header().Get("Retry-After") != "60" {
+				t.Errorf("Expected Retry-After 60, got %q", rr.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestLimitRedirect_ClientIP(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	_, trustedSubnet, _ := net.ParseCIDR("10.0.0.0/8")
+	handler := NewRateLimitMiddleware(NewInMemoryRateLimitStore(), model.RateLimit{},
+		model.RateLimit{RequestsPerMinute: 1}, trustedSubnet, testLogger).
+		LimitRedirect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTemporaryRedirect)
+		}))
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		realIP       string
+		expectedCode int
+	}{
+		{name: "client behind trusted proxy", remoteAddr: "10.0.0.1:1234", realIP: "203.0.113.1",
+			expectedCode: http.StatusTemporaryRedirect},
+		{name: "another client behind trusted proxy", remoteAddr: "10.0.0.1:1234", realIP: "203.0.113.2",
+			expectedCode: http.StatusTemporaryRedirect},
+		{name: "same client behind trusted proxy", remoteAddr: "10.0.0.1:1234", realIP: "203.0.113.2",
+			expectedCode: http.StatusTooManyRequests},
+		{name: "direct client", remoteAddr: "198.51.100.1:1234", realIP: "203.0.113.3",
+			expectedCode: http.StatusTemporaryRedirect},
+		{name: "direct client changing header", remoteAddr: "198.51.100.1:1234", realIP: "203.0.113.4",
+			expectedCode: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set(RealIPHeader, tt.realIP)
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+}
+
+func TestLimitRedirect_Disabled(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTemporaryRedirect)
+	})
+
+	for _, m := range []*RateLimitMiddleware{
+		nil,
+		NewRateLimitMiddleware(NewInMemoryRateLimitStore(), model.RateLimit{RequestsPerMinute: 1}, model.RateLimit{},
+			nil, testLogger),
+	} {
+		handler := m.LimitRedirect(next)
+		for i := 0; i < 3; i++ {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/abc123", nil))
+			if rr.Code != http.StatusTemporaryRedirect || rr.Header().Get(RateLimitLimitHeader) != "" {
+				t.Errorf("Expected unlimited redirect, got %d with limit %q", rr.Code,
+					rr.Header().Get(RateLimitLimitHeader))
+			}
+		}
+	}
+}
+
+func TestLimitRedirect_StoreError(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	handler := NewRateLimitMiddleware(failingRateLimitStore{}, model.RateLimit{},
+		model.RateLimit{RequestsPerMinute: 1}, nil, testLogger).
+		LimitRedirect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTemporaryRedirect)
+		}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/abc123", nil))
+	if rr.Code != http.StatusTemporaryRedirect {
+		t.Errorf("Expected request served when the store fails, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// RealIPHeader is the name of the header carrying the client IP set by a reverse proxy.
+const RealIPHeader = "X-Real-IP"
+
+// TrustedSubnetMiddleware restricts routes to clients from a trusted subnet.
+type TrustedSubnetMiddleware struct {
+	subnet *net.IPNet
+}
+
+// NewTrustedSubnetMiddleware creates a new TrustedSubnetMiddleware instance.
+//
+// Parameters:
+//   - subnet: subnet allowed to call the routes, nil rejects all clients
+//
+// Returns:
+//   - *TrustedSubnetMiddleware: initialized trusted subnet middleware
+func NewTrustedSubnetMiddleware(subnet *net.IPNet) *TrustedSubnetMiddleware {
+	return &TrustedSubnetMiddleware{subnet: subnet}
+}
+
+// RequireTrustedSubnet wraps an HTTP handler so that only clients from the trusted subnet can call it.
+// The client IP is taken from the X-Real-IP header of connections from the trusted subnet, so that proxies
+// in the subnet forward their clients, and from the connection otherwise. Clients outside the subnet
+// cannot pass the check by sending the header. Other clients, and all clients without a configured subnet,
+// get 403 Forbidden.
+//
+// Parameters:
+//   - h: HTTP handler to wrap
+//
+// Returns:
+//   - http.Handler: wrapped handler that requires a trusted client
+func (m *TrustedSubnetMiddleware) RequireTrustedSubnet(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := proxiedClientIP(r, m.subnet)
+		if m.subnet == nil || ip == nil || !m.subnet.Contains(ip) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// proxiedClientIP returns the IP of the client from the X-Real-IP header for connections from trusted proxies,
+// or the IP of the connection otherwise, so that clients cannot choose their IP by sending the header.
+//
+// Parameters:
+//   - r: HTTP request
+//   - trustedSubnet: subnet of reverse proxies setting the header, nil ignores the header
+//
+// Returns:
+//   - net.IP: client IP, nil if it cannot be parsed
+func proxiedClientIP(r *http.Request, trustedSubnet *net.IPNet) net.IP {
+	ip := remoteIP(r)
+	if trustedSubnet == nil || ip == nil || !trustedSubnet.Contains(ip) {
+		return ip
+	}
+	if realIP := net.ParseIP(strings.TrimSpace(r.Header.Get(RealIPHeader))); realIP != nil {
+		return realIP
+	}
+	return ip
+}
+
+// remoteIP returns the IP of the connection the request was received on.
+//
+// Parameters:
+//   - r: HTTP request
+//
+// Returns:
+//   - net.IP: IP of the connection, nil if it cannot be parsed
+func remoteIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
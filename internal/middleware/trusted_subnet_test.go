@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireTrustedSubnet(t *testing.T) {
+	_, subnet, err := net.ParseCIDR("192.168.1.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		subnet       *net.IPNet
+		realIP       string
+		remoteAddr   string
+		expectedCode int
+	}{
+		{name: "trusted real ip from trusted proxy", subnet: subnet, realIP: "192.168.1.10",
+			remoteAddr: "192.168.1.1:1234", expectedCode: http.StatusOK},
+		{name: "spoofed real ip from untrusted connection", subnet: subnet, realIP: "192.168.1.10",
+			remoteAddr: "10.0.0.1:1234", expectedCode: http.StatusForbidden},
+		{name: "untrusted real ip", subnet: subnet, realIP: "10.0.0.1", remoteAddr: "192.168.1.10:1234",
+			expectedCode: http.StatusForbidden},
+		{name: "trusted connection", subnet: subnet, remoteAddr: "192.168.1.20:1234", expectedCode: http.StatusOK},
+		{name: "untrusted connection", subnet: subnet, remoteAddr: "10.0.0.1:1234", expectedCode: http.StatusForbidden},
+		{name: "invalid real ip from untrusted connection", subnet: subnet, realIP: "not-an-ip",
+			remoteAddr: "10.0.0.1:1234", expectedCode: http.StatusForbidden},
+		{name: "no subnet", remoteAddr: "192.168.1.20:1234", expectedCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewTrustedSubnetMiddleware(tt.subnet).RequireTrustedSubnet(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+				}))
+			req := httptest.NewRequest(http.MethodGet, "/api/internal/stats", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.realIP != "" {
+				req.Header.Set(RealIPHeader, tt.realIP)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+}
@@ -2,7 +2,12 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	model "github.com/bezjen/shortener/internal/model"
+	mock "github.com/stretchr/testify/mock"
+)
 
 // Authorizer is an autogenerated mock type for the Authorizer type
 type Authorizer struct {
@@ -37,9 +42,63 @@ func (_m *Authorizer) CreateToken(userID string) (string, error) {
 	return r0, r1
 }
 
-// ValidateToken provides a mock function with given fields: tokenString
-func (_m *Authorizer) ValidateToken(tokenString string) (string, error) {
-	ret := _m.Called(tokenString)
+// JWKS provides a mock function with no fields
+func (_m *Authorizer) JWKS() model.JWKS {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for JWKS")
+	}
+
+	var r0 model.JWKS
+	if rf, ok := ret.Get(0).(func() model.JWKS); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(model.JWKS)
+	}
+
+	return r0
+}
+
+// RevokeToken provides a mock function with given fields: ctx, tokenString
+func (_m *Authorizer) RevokeToken(ctx context.Context, tokenString string) error {
+	ret := _m.Called(ctx, tokenString)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, tokenString)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeUserTokens provides a mock function with given fields: ctx, userID
+func (_m *Authorizer) RevokeUserTokens(ctx context.Context, userID string) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeUserTokens")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ValidateToken provides a mock function with given fields: ctx, tokenString
+func (_m *Authorizer) ValidateToken(ctx context.Context, tokenString string) (string, error) {
+	ret := _m.Called(ctx, tokenString)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ValidateToken")
@@ -47,17 +106,17 @@ func (_m *Authorizer) ValidateToken(tokenString string) (string, error) {
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
-		return rf(tokenString)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, tokenString)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
-		r0 = rf(tokenString)
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, tokenString)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(tokenString)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenString)
 	} else {
 		r1 = ret.Error(1)
 	}
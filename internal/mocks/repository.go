@@ -7,6 +7,8 @@ import (
 
 	model "github.com/bezjen/shortener/internal/model"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // Repository is an autogenerated mock type for the Repository type
@@ -14,6 +16,24 @@ type Repository struct {
 	mock.Mock
 }
 
+// ClearURLReview provides a mock function with given fields: ctx, domain, shortURL
+func (_m *Repository) ClearURLReview(ctx context.Context, domain string, shortURL string) error {
+	ret := _m.Called(ctx, domain, shortURL)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClearURLReview")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, domain, shortURL)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Close provides a mock function with no fields
 func (_m *Repository) Close() error {
 	ret := _m.Called()
@@ -32,17 +52,107 @@ func (_m *Repository) Close() error {
 	return r0
 }
 
-// DeleteBatch provides a mock function with given fields: ctx, userID, shortURLs
-func (_m *Repository) DeleteBatch(ctx context.Context, userID string, shortURLs []string) error {
-	ret := _m.Called(ctx, userID, shortURLs)
+// CreateWorkspace provides a mock function with given fields: ctx, workspace, ownerID
+func (_m *Repository) CreateWorkspace(ctx context.Context, workspace model.Workspace, ownerID string) error {
+	ret := _m.Called(ctx, workspace, ownerID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWorkspace")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.Workspace, string) error); ok {
+		r0 = rf(ctx, workspace, ownerID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteAPIKey provides a mock function with given fields: ctx, userID, id
+func (_m *Repository) DeleteAPIKey(ctx context.Context, userID string, id string) error {
+	ret := _m.Called(ctx, userID, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteAPIKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, userID, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteBatch provides a mock function with given fields: ctx, domain, userID, shortURLs
+func (_m *Repository) DeleteBatch(ctx context.Context, domain string, userID string, shortURLs []string) error {
+	ret := _m.Called(ctx, domain, userID, shortURLs)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DeleteBatch")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, []string) error); ok {
-		r0 = rf(ctx, userID, shortURLs)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string) error); ok {
+		r0 = rf(ctx, domain, userID, shortURLs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteWorkspaceBatch provides a mock function with given fields: ctx, domain, workspaceID, shortURLs
+func (_m *Repository) DeleteWorkspaceBatch(ctx context.Context, domain string, workspaceID string, shortURLs []string) error {
+	ret := _m.Called(ctx, domain, workspaceID, shortURLs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteWorkspaceBatch")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string) error); ok {
+		r0 = rf(ctx, domain, workspaceID, shortURLs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteWorkspaceMember provides a mock function with given fields: ctx, workspaceID, userID
+func (_m *Repository) DeleteWorkspaceMember(ctx context.Context, workspaceID string, userID string) error {
+	ret := _m.Called(ctx, workspaceID, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteWorkspaceMember")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, workspaceID, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DismissAbuseReport provides a mock function with given fields: ctx, id, adminID, resolvedAt
+func (_m *Repository) DismissAbuseReport(ctx context.Context, id string, adminID string, resolvedAt time.Time) error {
+	ret := _m.Called(ctx, id, adminID, resolvedAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DismissAbuseReport")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) error); ok {
+		r0 = rf(ctx, id, adminID, resolvedAt)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -50,24 +160,84 @@ func (_m *Repository) DeleteBatch(ctx context.Context, userID string, shortURLs
 	return r0
 }
 
-// GetByShortURL provides a mock function with given fields: ctx, id
-func (_m *Repository) GetByShortURL(ctx context.Context, id string) (*model.URL, error) {
+// GetAPIKeyByHash provides a mock function with given fields: ctx, keyHash
+func (_m *Repository) GetAPIKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	ret := _m.Called(ctx, keyHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAPIKeyByHash")
+	}
+
+	var r0 *model.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.APIKey, error)); ok {
+		return rf(ctx, keyHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.APIKey); ok {
+		r0 = rf(ctx, keyHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, keyHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAPIKeysByUserID provides a mock function with given fields: ctx, userID
+func (_m *Repository) GetAPIKeysByUserID(ctx context.Context, userID string) ([]model.APIKey, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAPIKeysByUserID")
+	}
+
+	var r0 []model.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]model.APIKey, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.APIKey); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAbuseReport provides a mock function with given fields: ctx, id
+func (_m *Repository) GetAbuseReport(ctx context.Context, id string) (*model.AbuseReport, error) {
 	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetByShortURL")
+		panic("no return value specified for GetAbuseReport")
 	}
 
-	var r0 *model.URL
+	var r0 *model.AbuseReport
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.URL, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.AbuseReport, error)); ok {
 		return rf(ctx, id)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) *model.URL); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.AbuseReport); ok {
 		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*model.URL)
+			r0 = ret.Get(0).(*model.AbuseReport)
 		}
 	}
 
@@ -80,6 +250,126 @@ func (_m *Repository) GetByShortURL(ctx context.Context, id string) (*model.URL,
 	return r0, r1
 }
 
+// GetAbuseReports provides a mock function with given fields: ctx, filter
+func (_m *Repository) GetAbuseReports(ctx context.Context, filter model.AbuseReportFilter) ([]model.AbuseReport, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAbuseReports")
+	}
+
+	var r0 []model.AbuseReport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.AbuseReportFilter) ([]model.AbuseReport, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.AbuseReportFilter) []model.AbuseReport); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.AbuseReport)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.AbuseReportFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBundleByShortURL provides a mock function with given fields: ctx, domain, shortURL
+func (_m *Repository) GetBundleByShortURL(ctx context.Context, domain string, shortURL string) (*model.Bundle, error) {
+	ret := _m.Called(ctx, domain, shortURL)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBundleByShortURL")
+	}
+
+	var r0 *model.Bundle
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*model.Bundle, error)); ok {
+		return rf(ctx, domain, shortURL)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *model.Bundle); ok {
+		r0 = rf(ctx, domain, shortURL)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Bundle)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, domain, shortURL)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByOriginalURL provides a mock function with given fields: ctx, domain, userID, originalURL
+func (_m *Repository) GetByOriginalURL(ctx context.Context, domain string, userID string, originalURL string) (*model.URL, error) {
+	ret := _m.Called(ctx, domain, userID, originalURL)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByOriginalURL")
+	}
+
+	var r0 *model.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*model.URL, error)); ok {
+		return rf(ctx, domain, userID, originalURL)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *model.URL); ok {
+		r0 = rf(ctx, domain, userID, originalURL)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.URL)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, domain, userID, originalURL)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByShortURL provides a mock function with given fields: ctx, domain, id
+func (_m *Repository) GetByShortURL(ctx context.Context, domain string, id string) (*model.URL, error) {
+	ret := _m.Called(ctx, domain, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByShortURL")
+	}
+
+	var r0 *model.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*model.URL, error)); ok {
+		return rf(ctx, domain, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *model.URL); ok {
+		r0 = rf(ctx, domain, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.URL)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, domain, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetByUserID provides a mock function with given fields: ctx, userID
 func (_m *Repository) GetByUserID(ctx context.Context, userID string) ([]model.URL, error) {
 	ret := _m.Called(ctx, userID)
@@ -110,53 +400,962 @@ func (_m *Repository) GetByUserID(ctx context.Context, userID string) ([]model.U
 	return r0, r1
 }
 
-// Ping provides a mock function with given fields: ctx
-func (_m *Repository) Ping(ctx context.Context) error {
-	ret := _m.Called(ctx)
+// GetByWorkspaceID provides a mock function with given fields: ctx, workspaceID
+func (_m *Repository) GetByWorkspaceID(ctx context.Context, workspaceID string) ([]model.URL, error) {
+	ret := _m.Called(ctx, workspaceID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Ping")
+		panic("no return value specified for GetByWorkspaceID")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
-		r0 = rf(ctx)
+	var r0 []model.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]model.URL, error)); ok {
+		return rf(ctx, workspaceID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.URL); ok {
+		r0 = rf(ctx, workspaceID)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.URL)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, workspaceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// Save provides a mock function with given fields: ctx, userID, url
-func (_m *Repository) Save(ctx context.Context, userID string, url model.URL) error {
-	ret := _m.Called(ctx, userID, url)
+// GetDeletedByUserID provides a mock function with given fields: ctx, userID
+func (_m *Repository) GetDeletedByUserID(ctx context.Context, userID string) ([]model.URL, error) {
+	ret := _m.Called(ctx, userID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Save")
+		panic("no return value specified for GetDeletedByUserID")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, model.URL) error); ok {
-		r0 = rf(ctx, userID, url)
+	var r0 []model.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]model.URL, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.URL); ok {
+		r0 = rf(ctx, userID)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.URL)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// SaveBatch provides a mock function with given fields: ctx, userID, urls
-func (_m *Repository) SaveBatch(ctx context.Context, userID string, urls []model.URL) error {
-	ret := _m.Called(ctx, userID, urls)
+// GetDeletedByWorkspaceID provides a mock function with given fields: ctx, workspaceID
+func (_m *Repository) GetDeletedByWorkspaceID(ctx context.Context, workspaceID string) ([]model.URL, error) {
+	ret := _m.Called(ctx, workspaceID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for SaveBatch")
+		panic("no return value specified for GetDeletedByWorkspaceID")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, []model.URL) error); ok {
-		r0 = rf(ctx, userID, urls)
+	var r0 []model.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]model.URL, error)); ok {
+		return rf(ctx, workspaceID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.URL); ok {
+		r0 = rf(ctx, workspaceID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.URL)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, workspaceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetQuotaUsage provides a mock function with given fields: ctx, userID, day
+func (_m *Repository) GetQuotaUsage(ctx context.Context, userID string, day time.Time) (*model.QuotaUsage, error) {
+	ret := _m.Called(ctx, userID, day)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetQuotaUsage")
+	}
+
+	var r0 *model.QuotaUsage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) (*model.QuotaUsage, error)); ok {
+		return rf(ctx, userID, day)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) *model.QuotaUsage); ok {
+		r0 = rf(ctx, userID, day)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.QuotaUsage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time) error); ok {
+		r1 = rf(ctx, userID, day)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStats provides a mock function with given fields: ctx
+func (_m *Repository) GetStats(ctx context.Context) (*model.Stats, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetStats")
+	}
+
+	var r0 *model.Stats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*model.Stats, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *model.Stats); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Stats)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetURLsForHealthCheck provides a mock function with given fields: ctx, checkedBefore, limit
+func (_m *Repository) GetURLsForHealthCheck(ctx context.Context, checkedBefore time.Time, limit int) ([]model.URL, error) {
+	ret := _m.Called(ctx, checkedBefore, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetURLsForHealthCheck")
+	}
+
+	var r0 []model.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) ([]model.URL, error)); ok {
+		return rf(ctx, checkedBefore, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) []model.URL); ok {
+		r0 = rf(ctx, checkedBefore, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.URL)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, checkedBefore, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserByID provides a mock function with given fields: ctx, id
+func (_m *Repository) GetUserByID(ctx context.Context, id string) (*model.User, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByID")
+	}
+
+	var r0 *model.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.User, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.User); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserByLogin provides a mock function with given fields: ctx, login
+func (_m *Repository) GetUserByLogin(ctx context.Context, login string) (*model.User, error) {
+	ret := _m.Called(ctx, login)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByLogin")
+	}
+
+	var r0 *model.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.User, error)); ok {
+		return rf(ctx, login)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.User); ok {
+		r0 = rf(ctx, login)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, login)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserQuotaLimits provides a mock function with given fields: ctx, userID
+func (_m *Repository) GetUserQuotaLimits(ctx context.Context, userID string) (*model.QuotaLimits, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserQuotaLimits")
+	}
+
+	var r0 *model.QuotaLimits
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.QuotaLimits, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.QuotaLimits); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.QuotaLimits)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserUsage provides a mock function with given fields: ctx, userID
+func (_m *Repository) GetUserUsage(ctx context.Context, userID string) (*model.UserUsage, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserUsage")
+	}
+
+	var r0 *model.UserUsage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.UserUsage, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.UserUsage); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.UserUsage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWorkspaceMembers provides a mock function with given fields: ctx, workspaceID
+func (_m *Repository) GetWorkspaceMembers(ctx context.Context, workspaceID string) ([]model.WorkspaceMember, error) {
+	ret := _m.Called(ctx, workspaceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWorkspaceMembers")
+	}
+
+	var r0 []model.WorkspaceMember
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]model.WorkspaceMember, error)); ok {
+		return rf(ctx, workspaceID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.WorkspaceMember); ok {
+		r0 = rf(ctx, workspaceID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.WorkspaceMember)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, workspaceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWorkspaceRole provides a mock function with given fields: ctx, workspaceID, userID
+func (_m *Repository) GetWorkspaceRole(ctx context.Context, workspaceID string, userID string) (model.WorkspaceRole, error) {
+	ret := _m.Called(ctx, workspaceID, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWorkspaceRole")
+	}
+
+	var r0 model.WorkspaceRole
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (model.WorkspaceRole, error)); ok {
+		return rf(ctx, workspaceID, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) model.WorkspaceRole); ok {
+		r0 = rf(ctx, workspaceID, userID)
+	} else {
+		r0 = ret.Get(0).(model.WorkspaceRole)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, workspaceID, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWorkspacesByUserID provides a mock function with given fields: ctx, userID
+func (_m *Repository) GetWorkspacesByUserID(ctx context.Context, userID string) ([]model.Workspace, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWorkspacesByUserID")
+	}
+
+	var r0 []model.Workspace
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]model.Workspace, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.Workspace); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Workspace)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsTokenRevoked provides a mock function with given fields: ctx, tokenID, userID, issuedAt
+func (_m *Repository) IsTokenRevoked(ctx context.Context, tokenID string, userID string, issuedAt time.Time) (bool, error) {
+	ret := _m.Called(ctx, tokenID, userID, issuedAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsTokenRevoked")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) (bool, error)); ok {
+		return rf(ctx, tokenID, userID, issuedAt)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) bool); ok {
+		r0 = rf(ctx, tokenID, userID, issuedAt)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, time.Time) error); ok {
+		r1 = rf(ctx, tokenID, userID, issuedAt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsUserBanned provides a mock function with given fields: ctx, userID
+func (_m *Repository) IsUserBanned(ctx context.Context, userID string) (bool, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsUserBanned")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MergeUserURLs provides a mock function with given fields: ctx, fromUserID, toUserID
+func (_m *Repository) MergeUserURLs(ctx context.Context, fromUserID string, toUserID string) error {
+	ret := _m.Called(ctx, fromUserID, toUserID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MergeUserURLs")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, fromUserID, toUserID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Ping provides a mock function with given fields: ctx
+func (_m *Repository) Ping(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Ping")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PurgeDeleted provides a mock function with given fields: ctx, deletedBefore
+func (_m *Repository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	ret := _m.Called(ctx, deletedBefore)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeDeleted")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, error)); ok {
+		return rf(ctx, deletedBefore)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, deletedBefore)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, deletedBefore)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeExpiredRevocations provides a mock function with given fields: ctx, now
+func (_m *Repository) PurgeExpiredRevocations(ctx context.Context, now time.Time) (int64, error) {
+	ret := _m.Called(ctx, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeExpiredRevocations")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, error)); ok {
+		return rf(ctx, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, now)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReleaseLinks provides a mock function with given fields: ctx, userID, day, reserved, unused
+func (_m *Repository) ReleaseLinks(ctx context.Context, userID string, day time.Time, reserved int64, unused int64) error {
+	ret := _m.Called(ctx, userID, day, reserved, unused)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseLinks")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, int64, int64) error); ok {
+		r0 = rf(ctx, userID, day, reserved, unused)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReserveLinks provides a mock function with given fields: ctx, userID, day, count, limits
+func (_m *Repository) ReserveLinks(ctx context.Context, userID string, day time.Time, count int64, limits model.QuotaLimits) (*model.QuotaUsage, bool, error) {
+	ret := _m.Called(ctx, userID, day, count, limits)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReserveLinks")
+	}
+
+	var r0 *model.QuotaUsage
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, int64, model.QuotaLimits) (*model.QuotaUsage, bool, error)); ok {
+		return rf(ctx, userID, day, count, limits)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, int64, model.QuotaLimits) *model.QuotaUsage); ok {
+		r0 = rf(ctx, userID, day, count, limits)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.QuotaUsage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time, int64, model.QuotaLimits) bool); ok {
+		r1 = rf(ctx, userID, day, count, limits)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, time.Time, int64, model.QuotaLimits) error); ok {
+		r2 = rf(ctx, userID, day, count, limits)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// RestoreBatch provides a mock function with given fields: ctx, domain, userID, shortURLs
+func (_m *Repository) RestoreBatch(ctx context.Context, domain string, userID string, shortURLs []string) error {
+	ret := _m.Called(ctx, domain, userID, shortURLs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreBatch")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string) error); ok {
+		r0 = rf(ctx, domain, userID, shortURLs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RestoreWorkspaceBatch provides a mock function with given fields: ctx, domain, workspaceID, shortURLs
+func (_m *Repository) RestoreWorkspaceBatch(ctx context.Context, domain string, workspaceID string, shortURLs []string) error {
+	ret := _m.Called(ctx, domain, workspaceID, shortURLs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreWorkspaceBatch")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string) error); ok {
+		r0 = rf(ctx, domain, workspaceID, shortURLs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Save provides a mock function with given fields: ctx, userID, url
+func (_m *Repository) Save(ctx context.Context, userID string, url model.URL) error {
+	ret := _m.Called(ctx, userID, url)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Save")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.URL) error); ok {
+		r0 = rf(ctx, userID, url)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveAPIKey provides a mock function with given fields: ctx, key
+func (_m *Repository) SaveAPIKey(ctx context.Context, key model.APIKey) error {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveAPIKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.APIKey) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveAbuseReport provides a mock function with given fields: ctx, report
+func (_m *Repository) SaveAbuseReport(ctx context.Context, report model.AbuseReport) error {
+	ret := _m.Called(ctx, report)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveAbuseReport")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.AbuseReport) error); ok {
+		r0 = rf(ctx, report)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveBatch provides a mock function with given fields: ctx, userID, urls
+func (_m *Repository) SaveBatch(ctx context.Context, userID string, urls []model.URL) error {
+	ret := _m.Called(ctx, userID, urls)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveBatch")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []model.URL) error); ok {
+		r0 = rf(ctx, userID, urls)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveBundle provides a mock function with given fields: ctx, userID, bundle
+func (_m *Repository) SaveBundle(ctx context.Context, userID string, bundle model.Bundle) error {
+	ret := _m.Called(ctx, userID, bundle)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveBundle")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.Bundle) error); ok {
+		r0 = rf(ctx, userID, bundle)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveRevokedToken provides a mock function with given fields: ctx, tokenID, expiresAt
+func (_m *Repository) SaveRevokedToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	ret := _m.Called(ctx, tokenID, expiresAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveRevokedToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = rf(ctx, tokenID, expiresAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveURLHealth provides a mock function with given fields: ctx, domain, shortURL, health
+func (_m *Repository) SaveURLHealth(ctx context.Context, domain string, shortURL string, health model.URLHealth) error {
+	ret := _m.Called(ctx, domain, shortURL, health)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveURLHealth")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, model.URLHealth) error); ok {
+		r0 = rf(ctx, domain, shortURL, health)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveURLMetadata provides a mock function with given fields: ctx, domain, shortURL, metadata
+func (_m *Repository) SaveURLMetadata(ctx context.Context, domain string, shortURL string, metadata model.URLMetadata) error {
+	ret := _m.Called(ctx, domain, shortURL, metadata)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveURLMetadata")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, model.URLMetadata) error); ok {
+		r0 = rf(ctx, domain, shortURL, metadata)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveUser provides a mock function with given fields: ctx, user
+func (_m *Repository) SaveUser(ctx context.Context, user model.User) error {
+	ret := _m.Called(ctx, user)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.User) error); ok {
+		r0 = rf(ctx, user)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveUserQuotaLimits provides a mock function with given fields: ctx, userID, limits
+func (_m *Repository) SaveUserQuotaLimits(ctx context.Context, userID string, limits model.QuotaLimits) error {
+	ret := _m.Called(ctx, userID, limits)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveUserQuotaLimits")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.QuotaLimits) error); ok {
+		r0 = rf(ctx, userID, limits)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveUserTokensRevocation provides a mock function with given fields: ctx, userID, revokedBefore, expiresAt
+func (_m *Repository) SaveUserTokensRevocation(ctx context.Context, userID string, revokedBefore time.Time, expiresAt time.Time) error {
+	ret := _m.Called(ctx, userID, revokedBefore, expiresAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveUserTokensRevocation")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Time) error); ok {
+		r0 = rf(ctx, userID, revokedBefore, expiresAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveWorkspaceMember provides a mock function with given fields: ctx, member
+func (_m *Repository) SaveWorkspaceMember(ctx context.Context, member model.WorkspaceMember) error {
+	ret := _m.Called(ctx, member)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveWorkspaceMember")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.WorkspaceMember) error); ok {
+		r0 = rf(ctx, member)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SearchURLs provides a mock function with given fields: ctx, filter
+func (_m *Repository) SearchURLs(ctx context.Context, filter model.URLSearchFilter) ([]model.AdminURL, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchURLs")
+	}
+
+	var r0 []model.AdminURL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.URLSearchFilter) ([]model.AdminURL, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.URLSearchFilter) []model.AdminURL); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.AdminURL)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.URLSearchFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetURLDisabled provides a mock function with given fields: ctx, domain, shortURL, disabled
+func (_m *Repository) SetURLDisabled(ctx context.Context, domain string, shortURL string, disabled bool) error {
+	ret := _m.Called(ctx, domain, shortURL, disabled)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetURLDisabled")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, bool) error); ok {
+		r0 = rf(ctx, domain, shortURL, disabled)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetUserBanned provides a mock function with given fields: ctx, userID, banned
+func (_m *Repository) SetUserBanned(ctx context.Context, userID string, banned bool) error {
+	ret := _m.Called(ctx, userID, banned)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserBanned")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) error); ok {
+		r0 = rf(ctx, userID, banned)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TakeDownURL provides a mock function with given fields: ctx, domain, shortURL, reason, adminID, resolvedAt
+func (_m *Repository) TakeDownURL(ctx context.Context, domain string, shortURL string, reason model.TakedownReason, adminID string, resolvedAt time.Time) error {
+	ret := _m.Called(ctx, domain, shortURL, reason, adminID, resolvedAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TakeDownURL")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, model.TakedownReason, string, time.Time) error); ok {
+		r0 = rf(ctx, domain, shortURL, reason, adminID, resolvedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateAPIKeyLastUsed provides a mock function with given fields: ctx, id, usedAt
+func (_m *Repository) UpdateAPIKeyLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	ret := _m.Called(ctx, id, usedAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateAPIKeyLastUsed")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = rf(ctx, id, usedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateWorkspaceURL provides a mock function with given fields: ctx, workspaceID, url
+func (_m *Repository) UpdateWorkspaceURL(ctx context.Context, workspaceID string, url model.URL) error {
+	ret := _m.Called(ctx, workspaceID, url)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateWorkspaceURL")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.URL) error); ok {
+		r0 = rf(ctx, workspaceID, url)
 	} else {
 		r0 = ret.Error(0)
 	}
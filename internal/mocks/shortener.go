@@ -14,17 +14,47 @@ type Shortener struct {
 	mock.Mock
 }
 
-// DeleteUserShortURLsBatch provides a mock function with given fields: ctx, userID, shortURLs
-func (_m *Shortener) DeleteUserShortURLsBatch(ctx context.Context, userID string, shortURLs []string) error {
-	ret := _m.Called(ctx, userID, shortURLs)
+// CreateWorkspace provides a mock function with given fields: ctx, userID, name
+func (_m *Shortener) CreateWorkspace(ctx context.Context, userID string, name string) (*model.Workspace, error) {
+	ret := _m.Called(ctx, userID, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWorkspace")
+	}
+
+	var r0 *model.Workspace
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*model.Workspace, error)); ok {
+		return rf(ctx, userID, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *model.Workspace); ok {
+		r0 = rf(ctx, userID, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Workspace)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, userID, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteUserShortURLsBatch provides a mock function with given fields: ctx, userID, domain, shortURLs
+func (_m *Shortener) DeleteUserShortURLsBatch(ctx context.Context, userID string, domain string, shortURLs []string) error {
+	ret := _m.Called(ctx, userID, domain, shortURLs)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DeleteUserShortURLsBatch")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, []string) error); ok {
-		r0 = rf(ctx, userID, shortURLs)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string) error); ok {
+		r0 = rf(ctx, userID, domain, shortURLs)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -32,9 +62,55 @@ func (_m *Shortener) DeleteUserShortURLsBatch(ctx context.Context, userID string
 	return r0
 }
 
-// GenerateShortURLPart provides a mock function with given fields: ctx, userID, url
-func (_m *Shortener) GenerateShortURLPart(ctx context.Context, userID string, url string) (string, error) {
-	ret := _m.Called(ctx, userID, url)
+// DeleteWorkspaceShortURLsBatch provides a mock function with given fields: ctx, userID, workspaceID, domain, shortURLs
+func (_m *Shortener) DeleteWorkspaceShortURLsBatch(ctx context.Context, userID string, workspaceID string, domain string, shortURLs []string) error {
+	ret := _m.Called(ctx, userID, workspaceID, domain, shortURLs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteWorkspaceShortURLsBatch")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, []string) error); ok {
+		r0 = rf(ctx, userID, workspaceID, domain, shortURLs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GenerateBundle provides a mock function with given fields: ctx, userID, domain, title, links
+func (_m *Shortener) GenerateBundle(ctx context.Context, userID string, domain string, title string, links []model.BundleLink) (string, error) {
+	ret := _m.Called(ctx, userID, domain, title, links)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GenerateBundle")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, []model.BundleLink) (string, error)); ok {
+		return rf(ctx, userID, domain, title, links)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, []model.BundleLink) string); ok {
+		r0 = rf(ctx, userID, domain, title, links)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, []model.BundleLink) error); ok {
+		r1 = rf(ctx, userID, domain, title, links)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GenerateShortURLPart provides a mock function with given fields: ctx, userID, domain, url
+func (_m *Shortener) GenerateShortURLPart(ctx context.Context, userID string, domain string, url string) (string, error) {
+	ret := _m.Called(ctx, userID, domain, url)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GenerateShortURLPart")
@@ -42,17 +118,17 @@ func (_m *Shortener) GenerateShortURLPart(ctx context.Context, userID string, ur
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
-		return rf(ctx, userID, url)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (string, error)); ok {
+		return rf(ctx, userID, domain, url)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
-		r0 = rf(ctx, userID, url)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) string); ok {
+		r0 = rf(ctx, userID, domain, url)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(ctx, userID, url)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, userID, domain, url)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -60,9 +136,9 @@ func (_m *Shortener) GenerateShortURLPart(ctx context.Context, userID string, ur
 	return r0, r1
 }
 
-// GenerateShortURLPartBatch provides a mock function with given fields: ctx, userID, urls
-func (_m *Shortener) GenerateShortURLPartBatch(ctx context.Context, userID string, urls []model.ShortenBatchRequestItem) ([]model.ShortenBatchResponseItem, error) {
-	ret := _m.Called(ctx, userID, urls)
+// GenerateShortURLPartBatch provides a mock function with given fields: ctx, userID, domain, urls
+func (_m *Shortener) GenerateShortURLPartBatch(ctx context.Context, userID string, domain string, urls []model.ShortenBatchRequestItem) ([]model.ShortenBatchResponseItem, error) {
+	ret := _m.Called(ctx, userID, domain, urls)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GenerateShortURLPartBatch")
@@ -70,19 +146,167 @@ func (_m *Shortener) GenerateShortURLPartBatch(ctx context.Context, userID strin
 
 	var r0 []model.ShortenBatchResponseItem
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, []model.ShortenBatchRequestItem) ([]model.ShortenBatchResponseItem, error)); ok {
-		return rf(ctx, userID, urls)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []model.ShortenBatchRequestItem) ([]model.ShortenBatchResponseItem, error)); ok {
+		return rf(ctx, userID, domain, urls)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, []model.ShortenBatchRequestItem) []model.ShortenBatchResponseItem); ok {
-		r0 = rf(ctx, userID, urls)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []model.ShortenBatchRequestItem) []model.ShortenBatchResponseItem); ok {
+		r0 = rf(ctx, userID, domain, urls)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]model.ShortenBatchResponseItem)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, []model.ShortenBatchRequestItem) error); ok {
-		r1 = rf(ctx, userID, urls)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, []model.ShortenBatchRequestItem) error); ok {
+		r1 = rf(ctx, userID, domain, urls)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GenerateWorkspaceShortURLPart provides a mock function with given fields: ctx, userID, workspaceID, domain, url
+func (_m *Shortener) GenerateWorkspaceShortURLPart(ctx context.Context, userID string, workspaceID string, domain string, url string) (string, error) {
+	ret := _m.Called(ctx, userID, workspaceID, domain, url)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GenerateWorkspaceShortURLPart")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) (string, error)); ok {
+		return rf(ctx, userID, workspaceID, domain, url)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) string); ok {
+		r0 = rf(ctx, userID, workspaceID, domain, url)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string) error); ok {
+		r1 = rf(ctx, userID, workspaceID, domain, url)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBundleByShortURLPart provides a mock function with given fields: ctx, domain, shortURLPart
+func (_m *Shortener) GetBundleByShortURLPart(ctx context.Context, domain string, shortURLPart string) (*model.Bundle, error) {
+	ret := _m.Called(ctx, domain, shortURLPart)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBundleByShortURLPart")
+	}
+
+	var r0 *model.Bundle
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*model.Bundle, error)); ok {
+		return rf(ctx, domain, shortURLPart)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *model.Bundle); ok {
+		r0 = rf(ctx, domain, shortURLPart)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Bundle)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, domain, shortURLPart)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeletedURLsByUserID provides a mock function with given fields: ctx, userID
+func (_m *Shortener) GetDeletedURLsByUserID(ctx context.Context, userID string) ([]model.URL, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDeletedURLsByUserID")
+	}
+
+	var r0 []model.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]model.URL, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.URL); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.URL)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeletedURLsByWorkspaceID provides a mock function with given fields: ctx, userID, workspaceID
+func (_m *Shortener) GetDeletedURLsByWorkspaceID(ctx context.Context, userID string, workspaceID string) ([]model.URL, error) {
+	ret := _m.Called(ctx, userID, workspaceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDeletedURLsByWorkspaceID")
+	}
+
+	var r0 []model.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]model.URL, error)); ok {
+		return rf(ctx, userID, workspaceID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []model.URL); ok {
+		r0 = rf(ctx, userID, workspaceID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.URL)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, userID, workspaceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStats provides a mock function with given fields: ctx
+func (_m *Shortener) GetStats(ctx context.Context) (*model.Stats, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetStats")
+	}
+
+	var r0 *model.Stats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*model.Stats, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *model.Stats); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Stats)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -90,9 +314,9 @@ func (_m *Shortener) GenerateShortURLPartBatch(ctx context.Context, userID strin
 	return r0, r1
 }
 
-// GetURLByShortURLPart provides a mock function with given fields: ctx, shortURLPart
-func (_m *Shortener) GetURLByShortURLPart(ctx context.Context, shortURLPart string) (*model.URL, error) {
-	ret := _m.Called(ctx, shortURLPart)
+// GetURLByShortURLPart provides a mock function with given fields: ctx, domain, shortURLPart
+func (_m *Shortener) GetURLByShortURLPart(ctx context.Context, domain string, shortURLPart string) (*model.URL, error) {
+	ret := _m.Called(ctx, domain, shortURLPart)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetURLByShortURLPart")
@@ -100,19 +324,19 @@ func (_m *Shortener) GetURLByShortURLPart(ctx context.Context, shortURLPart stri
 
 	var r0 *model.URL
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.URL, error)); ok {
-		return rf(ctx, shortURLPart)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*model.URL, error)); ok {
+		return rf(ctx, domain, shortURLPart)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) *model.URL); ok {
-		r0 = rf(ctx, shortURLPart)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *model.URL); ok {
+		r0 = rf(ctx, domain, shortURLPart)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*model.URL)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, shortURLPart)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, domain, shortURLPart)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -150,6 +374,126 @@ func (_m *Shortener) GetURLsByUserID(ctx context.Context, userID string) ([]mode
 	return r0, r1
 }
 
+// GetURLsByWorkspaceID provides a mock function with given fields: ctx, userID, workspaceID
+func (_m *Shortener) GetURLsByWorkspaceID(ctx context.Context, userID string, workspaceID string) ([]model.URL, error) {
+	ret := _m.Called(ctx, userID, workspaceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetURLsByWorkspaceID")
+	}
+
+	var r0 []model.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]model.URL, error)); ok {
+		return rf(ctx, userID, workspaceID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []model.URL); ok {
+		r0 = rf(ctx, userID, workspaceID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.URL)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, userID, workspaceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserWorkspaces provides a mock function with given fields: ctx, userID
+func (_m *Shortener) GetUserWorkspaces(ctx context.Context, userID string) ([]model.Workspace, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserWorkspaces")
+	}
+
+	var r0 []model.Workspace
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]model.Workspace, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.Workspace); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Workspace)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWorkspaceMembers provides a mock function with given fields: ctx, userID, workspaceID
+func (_m *Shortener) GetWorkspaceMembers(ctx context.Context, userID string, workspaceID string) ([]model.WorkspaceMember, error) {
+	ret := _m.Called(ctx, userID, workspaceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWorkspaceMembers")
+	}
+
+	var r0 []model.WorkspaceMember
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]model.WorkspaceMember, error)); ok {
+		return rf(ctx, userID, workspaceID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []model.WorkspaceMember); ok {
+		r0 = rf(ctx, userID, workspaceID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.WorkspaceMember)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, userID, workspaceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LookupShortURLPart provides a mock function with given fields: ctx, userID, domain, originalURL
+func (_m *Shortener) LookupShortURLPart(ctx context.Context, userID string, domain string, originalURL string) (*model.URL, error) {
+	ret := _m.Called(ctx, userID, domain, originalURL)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LookupShortURLPart")
+	}
+
+	var r0 *model.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*model.URL, error)); ok {
+		return rf(ctx, userID, domain, originalURL)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *model.URL); ok {
+		r0 = rf(ctx, userID, domain, originalURL)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.URL)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, userID, domain, originalURL)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // PingRepository provides a mock function with given fields: ctx
 func (_m *Shortener) PingRepository(ctx context.Context) error {
 	ret := _m.Called(ctx)
@@ -168,6 +512,96 @@ func (_m *Shortener) PingRepository(ctx context.Context) error {
 	return r0
 }
 
+// RemoveWorkspaceMember provides a mock function with given fields: ctx, userID, workspaceID, memberID
+func (_m *Shortener) RemoveWorkspaceMember(ctx context.Context, userID string, workspaceID string, memberID string) error {
+	ret := _m.Called(ctx, userID, workspaceID, memberID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveWorkspaceMember")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, userID, workspaceID, memberID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RestoreUserShortURLsBatch provides a mock function with given fields: ctx, userID, domain, shortURLs
+func (_m *Shortener) RestoreUserShortURLsBatch(ctx context.Context, userID string, domain string, shortURLs []string) error {
+	ret := _m.Called(ctx, userID, domain, shortURLs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreUserShortURLsBatch")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string) error); ok {
+		r0 = rf(ctx, userID, domain, shortURLs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RestoreWorkspaceShortURLsBatch provides a mock function with given fields: ctx, userID, workspaceID, domain, shortURLs
+func (_m *Shortener) RestoreWorkspaceShortURLsBatch(ctx context.Context, userID string, workspaceID string, domain string, shortURLs []string) error {
+	ret := _m.Called(ctx, userID, workspaceID, domain, shortURLs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreWorkspaceShortURLsBatch")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, []string) error); ok {
+		r0 = rf(ctx, userID, workspaceID, domain, shortURLs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetWorkspaceMember provides a mock function with given fields: ctx, userID, member
+func (_m *Shortener) SetWorkspaceMember(ctx context.Context, userID string, member model.WorkspaceMember) error {
+	ret := _m.Called(ctx, userID, member)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetWorkspaceMember")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.WorkspaceMember) error); ok {
+		r0 = rf(ctx, userID, member)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateWorkspaceShortURL provides a mock function with given fields: ctx, userID, workspaceID, domain, shortURL, url
+func (_m *Shortener) UpdateWorkspaceShortURL(ctx context.Context, userID string, workspaceID string, domain string, shortURL string, url string) error {
+	ret := _m.Called(ctx, userID, workspaceID, domain, shortURL, url)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateWorkspaceShortURL")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string, string) error); ok {
+		r0 = rf(ctx, userID, workspaceID, domain, shortURL, url)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewShortener creates a new instance of Shortener. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewShortener(t interface {
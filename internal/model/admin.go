@@ -0,0 +1,122 @@
+// Package model provides data models and structures for the URL shortening service.
+package model
+
+import "time"
+
+// URLSearchFilter represents the criteria of an administrator search for links.
+// Empty criteria match all links, set criteria must all match.
+type URLSearchFilter struct {
+	// ShortURL is the exact short URL identifier to find.
+	// Example: "abc123"
+	ShortURL string
+
+	// OriginalURL is a case-insensitive substring of the destination.
+	// Example: "example.com"
+	OriginalURL string
+
+	// UserID is the identifier of the link owner.
+	// Example: "user-123"
+	UserID string
+
+	// UnderReview limits the search to links flagged by phishing heuristics.
+	// Example: true
+	UnderReview bool
+
+	// Limit is the maximum number of links returned.
+	// Example: 100
+	Limit int
+
+	// Offset is the number of matching links skipped.
+	// Example: 0
+	Offset int
+}
+
+// AdminURL represents a link as seen by administrators, including its owner and moderation state.
+//
+// Example:
+//
+//	{
+//	  "short_url": "abc123",
+//	  "original_url": "https://example.com",
+//	  "user_id": "user-123",
+//	  "is_deleted": false,
+//	  "is_disabled": true
+//	}
+type AdminURL struct {
+	// ShortURL is the shortened URL identifier.
+	// Example: "abc123"
+	ShortURL string `json:"short_url"`
+
+	// Domain is the vanity domain the short URL belongs to, empty for the base URL host.
+	// Example: "go.brand.com"
+	Domain string `json:"domain,omitempty"`
+
+	// OriginalURL is the destination of the link, empty for bundles.
+	// Example: "https://example.com"
+	OriginalURL string `json:"original_url"`
+
+	// UserID is the identifier of the user who created the link.
+	// Example: "user-123"
+	UserID string `json:"user_id"`
+
+	// WorkspaceID is the workspace owning the link, empty for links owned by a single user.
+	// Example: "123e4567-e89b-12d3-a456-426614174000"
+	WorkspaceID string `json:"workspace_id,omitempty"`
+
+	// IsDeleted indicates that the owner moved the link to the trash.
+	IsDeleted bool `json:"is_deleted"`
+
+	// IsDisabled indicates that an administrator disabled the link.
+	IsDisabled bool `json:"is_disabled"`
+
+	// Takedown is the ground the link was taken down on after an abuse report, empty for other links.
+	// Example: "legal"
+	Takedown TakedownReason `json:"takedown,omitempty"`
+
+	// IsBundle indicates that the link resolves to a bundle page.
+	IsBundle bool `json:"is_bundle,omitempty"`
+
+	// ReviewReasons are the phishing heuristics the link matched, empty once it is cleared.
+	// Example: ["homoglyph_brand"]
+	ReviewReasons []ReviewReason `json:"review_reasons,omitempty"`
+}
+
+// UserUsage represents the usage of the service by a single user.
+//
+// Example:
+//
+//	{
+//	  "user_id": "user-123",
+//	  "links": 42,
+//	  "deleted_links": 3,
+//	  "disabled_links": 1,
+//	  "api_keys": 2,
+//	  "is_banned": false
+//	}
+type UserUsage struct {
+	// UserID is the identifier of the user.
+	// Example: "user-123"
+	UserID string `json:"user_id"`
+
+	// Links is the number of links of the user that are not deleted.
+	// Example: 42
+	Links int64 `json:"links"`
+
+	// DeletedLinks is the number of links of the user in the trash.
+	// Example: 3
+	DeletedLinks int64 `json:"deleted_links"`
+
+	// DisabledLinks is the number of links of the user disabled by administrators.
+	// Example: 1
+	DisabledLinks int64 `json:"disabled_links"`
+
+	// APIKeys is the number of API keys of the user.
+	// Example: 2
+	APIKeys int64 `json:"api_keys"`
+
+	// IsBanned indicates that the user is banned.
+	IsBanned bool `json:"is_banned"`
+
+	// BannedAt is the time the user was banned, nil for users that are not banned.
+	BannedAt *time.Time `json:"banned_at,omitempty"`
+}
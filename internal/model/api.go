@@ -2,6 +2,8 @@
 // It defines request/response formats for API endpoints and data transfer objects.
 package model
 
+import "time"
+
 // ShortenJSONRequest represents the JSON request structure for URL shortening endpoint.
 // Used in POST /api/shorten endpoint.
 //
@@ -15,6 +17,88 @@ type ShortenJSONRequest struct {
 	// Required: true
 	// Example: "https://example.com/very-long-url"
 	URL string `json:"url"`
+	// Domain is the configured host the short URL is created on.
+	// Optional: the base URL host is used when empty
+	// Example: "go.brand.com"
+	Domain string `json:"domain,omitempty"`
+}
+
+// BundleJSONRequest represents the JSON request structure for bundle creation endpoint.
+// Used in POST /api/bundle endpoint.
+//
+// Example:
+//
+//	{
+//	  "title": "Conference materials",
+//	  "links": [
+//	    {"title": "Slides", "url": "https://example.com/slides.pdf", "position": 1},
+//	    {"title": "Recording", "url": "https://example.com/video", "position": 2}
+//	  ]
+//	}
+type BundleJSONRequest struct {
+	// Title is the bundle page title.
+	// Example: "Conference materials"
+	Title string `json:"title"`
+	// Links are the bundle destinations.
+	// Required: at least one link
+	Links []BundleLink `json:"links"`
+	// Domain is the configured host the bundle is created on.
+	// Optional: the base URL host is used when empty
+	// Example: "go.brand.com"
+	Domain string `json:"domain,omitempty"`
+}
+
+// WorkspaceJSONRequest represents the JSON request structure for workspace creation endpoint.
+// Used in POST /api/workspaces endpoint.
+//
+// Example:
+//
+//	{"name": "Marketing"}
+type WorkspaceJSONRequest struct {
+	// Name is the display name of the workspace.
+	// Required: true
+	// Example: "Marketing"
+	Name string `json:"name"`
+}
+
+// CredentialsJSONRequest represents the JSON request structure for registration and login endpoints.
+// Used in POST /api/user/register and POST /api/user/login endpoints.
+//
+// Example:
+//
+//	{"login": "alice", "password": "correct horse battery staple"}
+type CredentialsJSONRequest struct {
+	// Login is the unique name of the account.
+	// Required: true
+	// Example: "alice"
+	Login string `json:"login"`
+
+	// Password is the plain account password.
+	// Required: true, at least 8 characters for registration
+	Password string `json:"password"`
+}
+
+// APIKeyJSONRequest represents the JSON request structure for API key creation endpoint.
+// Used in POST /api/user/keys endpoint.
+//
+// Example:
+//
+//	{"name": "CI pipeline", "scopes": ["read", "write"], "rate_limit": 60}
+type APIKeyJSONRequest struct {
+	// Name is the label of the key.
+	// Required: true
+	// Example: "CI pipeline"
+	Name string `json:"name"`
+
+	// Scopes are the operation classes the key is allowed to perform.
+	// Required: at least one of "read", "write" and "delete"
+	// Example: ["read", "write"]
+	Scopes []APIKeyScope `json:"scopes"`
+
+	// RateLimit is the maximal number of requests per minute made with the key.
+	// Optional: the default limit is used when zero
+	// Example: 60
+	RateLimit int `json:"rate_limit,omitempty"`
 }
 
 // ShortenJSONResponse represents the JSON response structure for URL shortening endpoint.
@@ -43,6 +127,40 @@ type ShortenJSONResponse struct {
 	Error string `json:"error,omitempty"`
 }
 
+// AccountJSONResponse represents the JSON response structure for registration and login endpoints.
+// The token for the account is returned in the Authorization header and cookie.
+//
+// Example:
+//
+//	{"user_id": "123e4567-e89b-12d3-a456-426614174000"}
+type AccountJSONResponse struct {
+	// UserID is the identifier of the account.
+	// Example: "123e4567-e89b-12d3-a456-426614174000"
+	UserID string `json:"user_id"`
+}
+
+// APIKeyJSONResponse represents the JSON response structure for API key creation endpoint.
+// The key is returned only once, later listings contain only its prefix.
+//
+// Example:
+//
+//	{
+//	  "id": "123e4567-e89b-12d3-a456-426614174000",
+//	  "name": "CI pipeline",
+//	  "prefix": "shk_3q2-",
+//	  "scopes": ["read", "write"],
+//	  "rate_limit": 60,
+//	  "created_at": "2026-10-18T10:00:00Z",
+//	  "key": "shk_3q2-Xb0sV1c9kQy7m8RZbVn4JwQmL0cH5pT2aYdF6eGh"
+//	}
+type APIKeyJSONResponse struct {
+	APIKey
+
+	// Key is the secret key to send in the X-API-Key header.
+	// Example: "shk_3q2-Xb0sV1c9kQy7m8RZbVn4JwQmL0cH5pT2aYdF6eGh"
+	Key string `json:"key"`
+}
+
 // ShortenBatchRequestItem represents a single URL item in batch shortening request.
 // Used in POST /api/shorten/batch endpoint request body.
 //
@@ -90,7 +208,9 @@ type ShortenBatchResponseItem struct {
 //
 //	{
 //	  "short_url": "http://localhost:8080/abc123",
-//	  "original_url": "https://example.com/url1"
+//	  "original_url": "https://example.com/url1",
+//	  "health": {"status": "healthy", "status_code": 200, "checked_at": "2026-10-18T12:00:00Z"},
+//	  "metadata": {"title": "Example Domain", "fetched_at": "2026-10-18T12:00:00Z"}
 //	}
 type UserURLResponseItem struct {
 	// ShortURL is the shortened URL created by the user.
@@ -100,6 +220,34 @@ type UserURLResponseItem struct {
 	// OriginalURL is the original URL that was shortened.
 	// Example: "https://example.com/url1"
 	OriginalURL string `json:"original_url"`
+
+	// Health is the result of the last health check of the destination, omitted until it is checked.
+	Health *URLHealth `json:"health,omitempty"`
+
+	// Metadata describes the destination page, omitted until it is fetched.
+	Metadata *URLMetadata `json:"metadata,omitempty"`
+}
+
+// TrashURLResponseItem represents a single deleted URL item in user trash response.
+// Used in GET /api/user/urls/trash endpoint response body.
+//
+// Example:
+//
+//	{
+//	  "short_url": "http://localhost:8080/abc123",
+//	  "original_url": "https://example.com/url1",
+//	  "deleted_at": "2025-09-01T10:00:00Z"
+//	}
+type TrashURLResponseItem struct {
+	// ShortURL is the shortened URL created by the user.
+	// Example: "http://localhost:8080/abc123"
+	ShortURL string `json:"short_url"`
+	// OriginalURL is the original URL that was shortened.
+	// Example: "https://example.com/url1"
+	OriginalURL string `json:"original_url"`
+	// DeletedAt is the time when the URL was deleted.
+	// Example: "2025-09-01T10:00:00Z"
+	DeletedAt time.Time `json:"deleted_at"`
 }
 
 // NewShortenBatchRequestItem creates a new ShortenBatchRequestItem instance.
@@ -149,3 +297,21 @@ func NewUserURLResponseItem(shortURL string, originalURL string) *UserURLRespons
 		OriginalURL: originalURL,
 	}
 }
+
+// NewTrashURLResponseItem creates a new TrashURLResponseItem instance.
+// Constructor function for user trash response items.
+//
+// Parameters:
+//   - shortURL: shortened URL created by the user
+//   - originalURL: original URL that was shortened
+//   - deletedAt: time when the URL was deleted
+//
+// Returns:
+//   - *TrashURLResponseItem: initialized trash response item
+func NewTrashURLResponseItem(shortURL string, originalURL string, deletedAt time.Time) *TrashURLResponseItem {
+	return &TrashURLResponseItem{
+		ShortURL:    shortURL,
+		OriginalURL: originalURL,
+		DeletedAt:   deletedAt,
+	}
+}
@@ -0,0 +1,96 @@
+// Package model provides data models and structures for the URL shortening service.
+package model
+
+import "time"
+
+// APIKeyScope represents an operation class an API key is allowed to perform.
+type APIKeyScope string
+
+// API key scope constants.
+const (
+	// ScopeRead allows reading links and other resources.
+	ScopeRead APIKeyScope = "read"
+
+	// ScopeWrite allows creating links and other resources.
+	ScopeWrite APIKeyScope = "write"
+
+	// ScopeDelete allows deleting links and other resources.
+	ScopeDelete APIKeyScope = "delete"
+)
+
+// Valid reports whether the scope is one of the known API key scopes.
+//
+// Returns:
+//   - bool: true for read, write and delete
+func (s APIKeyScope) Valid() bool {
+	switch s {
+	case ScopeRead, ScopeWrite, ScopeDelete:
+		return true
+	}
+	return false
+}
+
+// APIKey represents a long-lived credential of a user for programmatic clients.
+// Only the hash of the secret key is stored, the key itself is shown once on creation.
+//
+// Example:
+//
+//	{
+//	  "id": "123e4567-e89b-12d3-a456-426614174000",
+//	  "name": "CI pipeline",
+//	  "prefix": "shk_3q2-",
+//	  "scopes": ["read", "write"],
+//	  "rate_limit": 60,
+//	  "created_at": "2026-10-18T10:00:00Z",
+//	  "last_used_at": "2026-10-18T12:30:00Z"
+//	}
+type APIKey struct {
+	// ID is the unique identifier of the key used to revoke it.
+	// Example: "123e4567-e89b-12d3-a456-426614174000"
+	ID string `json:"id"`
+
+	// UserID is the identifier of the user the key acts as.
+	// Example: "user-123"
+	UserID string `json:"-"`
+
+	// Name is the label of the key chosen by the user.
+	// Example: "CI pipeline"
+	Name string `json:"name"`
+
+	// Prefix is the beginning of the key helping users to recognize it.
+	// Example: "shk_3q2-"
+	Prefix string `json:"prefix"`
+
+	// KeyHash is the hex-encoded SHA-256 digest of the key.
+	KeyHash string `json:"-"`
+
+	// Scopes are the operation classes the key is allowed to perform.
+	// Example: ["read", "write"]
+	Scopes []APIKeyScope `json:"scopes"`
+
+	// RateLimit is the maximal number of requests per minute made with the key.
+	// Example: 60
+	RateLimit int `json:"rate_limit"`
+
+	// CreatedAt is the time the key was created.
+	CreatedAt time.Time `json:"created_at"`
+
+	// LastUsedAt is the time the key was last used, nil if it was never used.
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// HasScope reports whether the key is allowed to perform operations of the scope.
+//
+// Parameters:
+//   - scope: scope required for an operation
+//
+// Returns:
+//   - bool: true if the key has the scope
+func (k *APIKey) HasScope(scope APIKeyScope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestAPIKeyScopeValid(t *testing.T) {
+	for _, scope := range []APIKeyScope{ScopeRead, ScopeWrite, ScopeDelete} {
+		if !scope.Valid() {
+			t.Errorf("Expected scope %q to be valid", scope)
+		}
+	}
+	if APIKeyScope("admin").Valid() {
+		t.Error("Unknown scope should not be valid")
+	}
+}
+
+func TestAPIKeyHasScope(t *testing.T) {
+	key := APIKey{Scopes: []APIKeyScope{ScopeRead, ScopeWrite}}
+
+	if !key.HasScope(ScopeRead) || !key.HasScope(ScopeWrite) {
+		t.Error("Expected key to have read and write scopes")
+	}
+	if key.HasScope(ScopeDelete) {
+		t.Error("Key should not have delete scope")
+	}
+}
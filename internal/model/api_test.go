@@ -2,6 +2,7 @@ package model
 
 import (
 	"testing"
+	"time"
 )
 
 func TestNewShortenBatchRequestItem(t *testing.T) {
@@ -49,6 +50,24 @@ func TestNewUserURLResponseItem(t *testing.T) {
 	}
 }
 
+func TestNewTrashURLResponseItem(t *testing.T) {
+	shortURL := "http://localhost:8080/abc123"
+	originalURL := "https://example.com"
+	deletedAt := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	item := NewTrashURLResponseItem(shortURL, originalURL, deletedAt)
+
+	if item.ShortURL != shortURL {
+		t.Errorf("Expected ShortURL %s, got %s", shortURL, item.ShortURL)
+	}
+	if item.OriginalURL != originalURL {
+		t.Errorf("Expected OriginalURL %s, got %s", originalURL, item.OriginalURL)
+	}
+	if !item.DeletedAt.Equal(deletedAt) {
+		t.Errorf("Expected DeletedAt %v, got %v", deletedAt, item.DeletedAt)
+	}
+}
+
 func TestShortenJSONResponse(t *testing.T) {
 	t.Run("success response", func(t *testing.T) {
 		resp := ShortenJSONResponse{ShortURL: "http://localhost:8080/abc123"}
@@ -13,6 +13,51 @@ const (
 	// ActionFollow represents URL access actions.
 	// Recorded when a user follows a short URL to access the original URL.
 	ActionFollow AuditAction = "follow"
+
+	// ActionEdit represents changes of the original URL of a short URL.
+	// Recorded when a workspace editor points a link to a new destination.
+	ActionEdit AuditAction = "edit"
+
+	// ActionBotFollow represents short URLs fetched by bots and crawlers.
+	// Recorded instead of ActionFollow, so that follow counts include people only.
+	ActionBotFollow AuditAction = "bot_follow"
+
+	// ActionReport represents abuse reports of links.
+	// Recorded when a visitor reports a link, with the reporter as the user.
+	ActionReport AuditAction = "report"
+
+	// ActionAdminSearch represents searches for links by administrators.
+	ActionAdminSearch AuditAction = "admin_search"
+
+	// ActionAdminDisable represents links disabled by administrators.
+	ActionAdminDisable AuditAction = "admin_disable"
+
+	// ActionAdminEnable represents links re-enabled by administrators.
+	ActionAdminEnable AuditAction = "admin_enable"
+
+	// ActionAdminClearReview represents links flagged by phishing heuristics cleared by administrators.
+	ActionAdminClearReview AuditAction = "admin_clear_review"
+
+	// ActionAdminDismissReport represents abuse reports dismissed by administrators.
+	ActionAdminDismissReport AuditAction = "admin_dismiss_report"
+
+	// ActionAdminTakedown represents reported links taken down by administrators.
+	ActionAdminTakedown AuditAction = "admin_takedown"
+
+	// ActionAdminBan represents users banned by administrators.
+	ActionAdminBan AuditAction = "admin_ban"
+
+	// ActionAdminUnban represents users unbanned by administrators.
+	ActionAdminUnban AuditAction = "admin_unban"
+
+	// ActionAdminUsage represents views of user usage by administrators.
+	ActionAdminUsage AuditAction = "admin_usage"
+
+	// ActionAdminRevokeTokens represents revocations of all tokens of a user by administrators.
+	ActionAdminRevokeTokens AuditAction = "admin_revoke_tokens"
+
+	// ActionAdminQuota represents limits assigned to a user by administrators.
+	ActionAdminQuota AuditAction = "admin_quota"
 )
 
 // AuditEvent represents an auditable event in the URL shortening service.
@@ -31,7 +76,7 @@ type AuditEvent struct {
 	// Example: 1640995200
 	TS int64 `json:"ts"`
 
-	// Action is the type of action performed (shorten or follow).
+	// Action is the type of action performed, e.g. shorten or follow.
 	// Example: "shorten"
 	Action AuditAction `json:"action"`
 
@@ -44,6 +89,14 @@ type AuditEvent struct {
 	// For follow actions: the original URL being accessed.
 	// Example: "https://example.com/very-long-url"
 	URL string `json:"url"`
+
+	// Target is the link, user or search an administrator acted on. Empty for other actions.
+	// Example: "abc123"
+	Target string `json:"target,omitempty"`
+
+	// Bot is the name of the bot signature matching the request. Set for bot follow actions only.
+	// Example: "Googlebot"
+	Bot string `json:"bot,omitempty"`
 }
 
 // NewAuditEvent creates a new AuditEvent instance.
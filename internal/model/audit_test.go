@@ -38,4 +38,8 @@ func TestAuditActionConstants(t *testing.T) {
 	if ActionFollow != "follow" {
 		t.Errorf("Expected ActionFollow to be 'follow', got %s", ActionFollow)
 	}
+
+	if ActionBotFollow != "bot_follow" {
+		t.Errorf("Expected ActionBotFollow to be 'bot_follow', got %s", ActionBotFollow)
+	}
 }
@@ -0,0 +1,37 @@
+// Package model provides data models and structures for the URL shortening service.
+package model
+
+// BotSignature identifies a bot or crawler by a fragment of its User-Agent header.
+//
+// Example:
+//
+//	{"name": "Slack", "pattern": "Slackbot-LinkExpanding", "unfurler": true}
+type BotSignature struct {
+	// Name is the name of the bot recorded in audit events.
+	// Example: "Slack"
+	Name string `json:"name"`
+
+	// Pattern is the fragment of the User-Agent header, compared ignoring case.
+	// Example: "Slackbot-LinkExpanding"
+	Pattern string `json:"pattern"`
+
+	// Unfurler marks bots fetching links to show previews of them in chats and social networks.
+	// Unfurlers get a page with Open Graph tags instead of a redirect.
+	Unfurler bool `json:"unfurler,omitempty"`
+}
+
+// BotSignatures is the content of the bot signatures file.
+// Signatures are tried in order, the first matching signature classifies the request.
+//
+// Example:
+//
+//	{
+//	  "signatures": [
+//	    {"name": "Slack", "pattern": "Slackbot-LinkExpanding", "unfurler": true},
+//	    {"name": "Googlebot", "pattern": "Googlebot"}
+//	  ]
+//	}
+type BotSignatures struct {
+	// Signatures are the known bots in order of precedence.
+	Signatures []BotSignature `json:"signatures"`
+}
@@ -0,0 +1,41 @@
+// Package model provides data models and structures for the URL shortening service.
+package model
+
+import "time"
+
+// LinkHealth is the state of a link destination found by the last health check.
+type LinkHealth string
+
+// States of link destinations.
+const (
+	// HealthHealthy marks destinations answering with a 2xx status.
+	HealthHealthy LinkHealth = "healthy"
+
+	// HealthRedirected marks destinations answering with a 3xx status, e.g. moved pages.
+	HealthRedirected LinkHealth = "redirected"
+
+	// HealthBroken marks destinations answering with a 4xx or 5xx status or not answering at all.
+	HealthBroken LinkHealth = "broken"
+)
+
+// URLHealth represents the result of the last health check of a link destination.
+//
+// Example:
+//
+//	{
+//	  "status": "broken",
+//	  "status_code": 404,
+//	  "checked_at": "2026-10-18T12:00:00Z"
+//	}
+type URLHealth struct {
+	// Status is the state of the destination.
+	// Example: "broken"
+	Status LinkHealth `json:"status"`
+
+	// StatusCode is the HTTP status the destination answered with, zero if the request failed.
+	// Example: 404
+	StatusCode int `json:"status_code,omitempty"`
+
+	// CheckedAt is the time of the check.
+	CheckedAt time.Time `json:"checked_at"`
+}
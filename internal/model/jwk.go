@@ -0,0 +1,51 @@
+// Package model provides data models and structures for the URL shortening service.
+package model
+
+// JWK represents a public token verification key in JSON Web Key format (RFC 7517).
+//
+// Example:
+//
+//	{
+//	  "kty": "OKP",
+//	  "kid": "2026-10",
+//	  "use": "sig",
+//	  "alg": "EdDSA",
+//	  "crv": "Ed25519",
+//	  "x": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"
+//	}
+type JWK struct {
+	// KeyType is the key family: "RSA" or "OKP" for Ed25519 keys.
+	KeyType string `json:"kty"`
+
+	// KeyID matches the kid header of tokens signed with the key.
+	// Example: "2026-10"
+	KeyID string `json:"kid"`
+
+	// Use is the intended use of the key, always "sig".
+	Use string `json:"use"`
+
+	// Algorithm is the JWT signing algorithm of the key: "RS256" or "EdDSA".
+	Algorithm string `json:"alg"`
+
+	// Modulus is the base64url encoded modulus of RSA keys.
+	Modulus string `json:"n,omitempty"`
+
+	// Exponent is the base64url encoded public exponent of RSA keys.
+	Exponent string `json:"e,omitempty"`
+
+	// Curve is the curve of OKP keys, always "Ed25519".
+	Curve string `json:"crv,omitempty"`
+
+	// X is the base64url encoded public key of OKP keys.
+	X string `json:"x,omitempty"`
+}
+
+// JWKS represents a JSON Web Key Set published for token verification.
+//
+// Example:
+//
+//	{"keys": [{"kty": "RSA", "kid": "2026-09", "use": "sig", "alg": "RS256", "n": "0vx7...", "e": "AQAB"}]}
+type JWKS struct {
+	// Keys are the public verification keys.
+	Keys []JWK `json:"keys"`
+}
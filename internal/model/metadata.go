@@ -0,0 +1,37 @@
+// Package model provides data models and structures for the URL shortening service.
+package model
+
+import "time"
+
+// URLMetadata describes the destination page of a link, read from the title,
+// meta description and Open Graph tags of its HTML head.
+//
+// Example:
+//
+//	{
+//	  "title": "Example Domain",
+//	  "description": "This domain is for use in illustrative examples.",
+//	  "image": "https://example.com/preview.png",
+//	  "site_name": "Example",
+//	  "fetched_at": "2026-10-18T12:00:00Z"
+//	}
+type URLMetadata struct {
+	// Title is the og:title of the page, or its title element.
+	// Example: "Example Domain"
+	Title string `json:"title,omitempty"`
+
+	// Description is the og:description of the page, or its meta description.
+	// Example: "This domain is for use in illustrative examples."
+	Description string `json:"description,omitempty"`
+
+	// Image is the absolute URL of the og:image preview of the page.
+	// Example: "https://example.com/preview.png"
+	Image string `json:"image,omitempty"`
+
+	// SiteName is the og:site_name of the page.
+	// Example: "Example"
+	SiteName string `json:"site_name,omitempty"`
+
+	// FetchedAt is the time the page was fetched.
+	FetchedAt time.Time `json:"fetched_at"`
+}
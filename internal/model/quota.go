@@ -0,0 +1,105 @@
+// Package model provides data models and structures for the URL shortening service.
+package model
+
+// QuotaName identifies a limit of a user plan.
+type QuotaName string
+
+// Limits of a user plan.
+const (
+	// QuotaLinksPerDay limits links created by a user per UTC day.
+	QuotaLinksPerDay QuotaName = "links_per_day"
+
+	// QuotaActiveLinks limits links of a user that are not deleted.
+	QuotaActiveLinks QuotaName = "active_links"
+
+	// QuotaBatchSize limits links created by a single batch request.
+	QuotaBatchSize QuotaName = "batch_size"
+
+	// QuotaDeleteBatchSize limits links deleted by a single batch request.
+	QuotaDeleteBatchSize QuotaName = "delete_batch_size"
+)
+
+// QuotaLimits represents the limits of a user plan. Zero limits are unlimited.
+//
+// Example:
+//
+//	{
+//	  "links_per_day": 100,
+//	  "active_links": 1000,
+//	  "batch_size": 50,
+//	  "delete_batch_size": 50
+//	}
+type QuotaLimits struct {
+	// LinksPerDay is the number of links a user may create per UTC day.
+	// Example: 100
+	LinksPerDay int64 `json:"links_per_day"`
+
+	// ActiveLinks is the number of links of a user that are not deleted.
+	// Example: 1000
+	ActiveLinks int64 `json:"active_links"`
+
+	// BatchSize is the number of links a single batch request may create.
+	// Example: 50
+	BatchSize int64 `json:"batch_size"`
+
+	// DeleteBatchSize is the number of links a single batch request may delete.
+	// Example: 50
+	DeleteBatchSize int64 `json:"delete_batch_size"`
+}
+
+// QuotaUsage represents the counters of a user checked against the limits of the plan.
+type QuotaUsage struct {
+	// LinksToday is the number of links the user created in the current UTC day.
+	// Example: 12
+	LinksToday int64
+
+	// ActiveLinks is the number of links of the user that are not deleted.
+	// Example: 340
+	ActiveLinks int64
+}
+
+// Quota represents the limits of a user together with the current usage.
+type Quota struct {
+	// Limits are the limits of the user plan.
+	Limits QuotaLimits
+
+	// Usage are the current counters of the user.
+	Usage QuotaUsage
+}
+
+// RemainingLinksToday returns how many links the user may still create today.
+//
+// Returns:
+//   - int64: remaining links, never negative
+//   - bool: false if the daily links are unlimited
+func (q Quota) RemainingLinksToday() (int64, bool) {
+	return remaining(q.Limits.LinksPerDay, q.Usage.LinksToday)
+}
+
+// RemainingActiveLinks returns how many links the user may still keep without deleting any.
+//
+// Returns:
+//   - int64: remaining links, never negative
+//   - bool: false if the active links are unlimited
+func (q Quota) RemainingActiveLinks() (int64, bool) {
+	return remaining(q.Limits.ActiveLinks, q.Usage.ActiveLinks)
+}
+
+// remaining returns the part of a limit that is not used yet.
+//
+// Parameters:
+//   - limit: limit of the plan, zero for unlimited
+//   - used: current usage
+//
+// Returns:
+//   - int64: remaining part of the limit, never negative
+//   - bool: false if the limit is unlimited
+func remaining(limit int64, used int64) (int64, bool) {
+	if limit <= 0 {
+		return 0, false
+	}
+	if used >= limit {
+		return 0, true
+	}
+	return limit - used, true
+}
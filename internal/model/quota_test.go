@@ -0,0 +1,47 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestQuotaRemaining(t *testing.T) {
+	tests := []struct {
+		name         string
+		quota        Quota
+		wantToday    int64
+		wantTodayOk  bool
+		wantActive   int64
+		wantActiveOk bool
+	}{
+		{name: "unlimited", quota: Quota{Usage: QuotaUsage{LinksToday: 5, ActiveLinks: 50}}},
+		{
+			name: "partly used",
+			quota: Quota{
+				Limits: QuotaLimits{LinksPerDay: 10, ActiveLinks: 100},
+				Usage:  QuotaUsage{LinksToday: 4, ActiveLinks: 60},
+			},
+			wantToday: 6, wantTodayOk: true, wantActive: 40, wantActiveOk: true,
+		},
+		{
+			name: "exceeded",
+			quota: Quota{
+				Limits: QuotaLimits{LinksPerDay: 10, ActiveLinks: 100},
+				Usage:  QuotaUsage{LinksToday: 12, ActiveLinks: 100},
+			},
+			wantToday: 0, wantTodayOk: true, wantActive: 0, wantActiveOk: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			today, ok := tt.quota.RemainingLinksToday()
+			if today != tt.wantToday || ok != tt.wantTodayOk {
+				t.Errorf("Expected remaining links today %d, %v, got %d, %v", tt.wantToday, tt.wantTodayOk, today, ok)
+			}
+			active, ok := tt.quota.RemainingActiveLinks()
+			if active != tt.wantActive || ok != tt.wantActiveOk {
+				t.Errorf("Expected remaining active links %d, %v, got %d, %v", tt.wantActive, tt.wantActiveOk, active, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Package model provides data models and structures for the URL shortening service.
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// RateLimit represents the budget of a token bucket. Zero requests per minute are unlimited.
+type RateLimit struct {
+	// RequestsPerMinute is the rate the bucket refills at.
+	// Example: 60
+	RequestsPerMinute int64
+
+	// Burst is the capacity of the bucket, zero for RequestsPerMinute.
+	// Example: 10
+	Burst int64
+}
+
+// Enabled reports whether the budget limits requests at all.
+//
+// Returns:
+//   - bool: true if requests per minute are set
+func (l RateLimit) Enabled() bool {
+	return l.RequestsPerMinute > 0
+}
+
+// Capacity returns the number of requests the bucket holds when full.
+//
+// Returns:
+//   - int64: burst, or requests per minute without a burst
+func (l RateLimit) Capacity() int64 {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.RequestsPerMinute
+}
+
+// TokenBucket represents the state of a token bucket.
+// The zero value is a bucket that was never used and is full.
+type TokenBucket struct {
+	// Tokens is the number of requests left in the bucket at the time of the last update.
+	Tokens float64
+
+	// UpdatedAt is the time of the last update.
+	UpdatedAt time.Time
+}
+
+// RateLimitDecision represents the result of taking a token from a bucket.
+type RateLimitDecision struct {
+	// Allowed reports whether the request may proceed.
+	Allowed bool
+
+	// Limit is the capacity of the bucket.
+	Limit int64
+
+	// Remaining is the number of whole tokens left in the bucket.
+	Remaining int64
+
+	// RetryAfter is the time until the next token, zero for allowed requests.
+	RetryAfter time.Duration
+}
+
+// Take refills the bucket for the time passed since the last update and takes a single token.
+//
+// Parameters:
+//   - limit: budget of the bucket
+//   - now: time of the request
+//
+// Returns:
+//   - TokenBucket: new state of the bucket to store
+//   - RateLimitDecision: whether the request is allowed and the state reported to the client
+func (b TokenBucket) Take(limit RateLimit, now time.Time) (TokenBucket, RateLimitDecision) {
+	capacity := float64(limit.Capacity())
+	perSecond := float64(limit.RequestsPerMinute) / 60
+
+	tokens := capacity
+	if !b.UpdatedAt.IsZero() {
+		elapsed := max(now.Sub(b.UpdatedAt).Seconds(), 0)
+		tokens = math.Min(capacity, b.Tokens+elapsed*perSecond)
+	}
+
+	decision := RateLimitDecision{Limit: limit.Capacity()}
+	if tokens >= 1 {
+		tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = time.Duration((1 - tokens) / perSecond * float64(time.Second))
+	}
+	decision.Remaining = int64(math.Floor(tokens))
+	return TokenBucket{Tokens: tokens, UpdatedAt: now}, decision
+}
+
+// FullAt returns the time the bucket refills completely, after which dropping it changes nothing.
+//
+// Parameters:
+//   - limit: budget of the bucket
+//
+// Returns:
+//   - time.Time: time the bucket is full
+func (b TokenBucket) FullAt(limit RateLimit) time.Time {
+	missing := float64(limit.Capacity()) - b.Tokens
+	if b.UpdatedAt.IsZero() || missing <= 0 || !limit.Enabled() {
+		return b.UpdatedAt
+	}
+	perSecond := float64(limit.RequestsPerMinute) / 60
+	return b.UpdatedAt.Add(time.Duration(missing / perSecond * float64(time.Second)))
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucketTake(t *testing.T) {
+	start := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	limit := RateLimit{RequestsPerMinute: 60, Burst: 2}
+
+	tests := []struct {
+		name          string
+		bucket        TokenBucket
+		now           time.Time
+		wantAllowed   bool
+		wantRemaining int64
+		wantRetry     time.Duration
+	}{
+		{name: "new bucket is full", now: start, wantAllowed: true, wantRemaining: 1},
+		{name: "last token", bucket: TokenBucket{Tokens: 1, UpdatedAt: start}, now: start,
+			wantAllowed: true, wantRemaining: 0},
+		{name: "empty bucket", bucket: TokenBucket{Tokens: 0.25, UpdatedAt: start}, now: start,
+			wantAllowed: false, wantRemaining: 0, wantRetry: 750 * time.Millisecond},
+		{name: "refilled", bucket: TokenBucket{Tokens: 0, UpdatedAt: start}, now: start.Add(time.Second),
+			wantAllowed: true, wantRemaining: 0},
+		{name: "refill capped by burst", bucket: TokenBucket{Tokens: 0, UpdatedAt: start}, now: start.Add(time.Hour),
+			wantAllowed: true, wantRemaining: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket, decision := tt.bucket.Take(limit, tt.now)
+			if decision.Allowed != tt.wantAllowed || decision.Remaining != tt.wantRemaining ||
+				decision.RetryAfter != tt.wantRetry {
+				t.Errorf("Expected allowed %v, remaining %d, retry after %v, got %+v",
+					tt.wantAllowed, tt.wantRemaining, tt.wantRetry, decision)
+			}
+			if decision.Limit != 2 {
+				t.Errorf("Expected limit 2, got %d", decision.Limit)
+			}
+			if !bucket.UpdatedAt.Equal(tt.now) {
+				t.Errorf("Expected bucket updated at %v, got %v", tt.now, bucket.UpdatedAt)
+			}
+		})
+	}
+}
+
+func TestTokenBucketFullAt(t *testing.T) {
+	start := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	limit := RateLimit{RequestsPerMinute: 30}
+
+	if got := (TokenBucket{Tokens: 28, UpdatedAt: start}).FullAt(limit); !got.Equal(start.Add(4 * time.Second)) {
+		t.Errorf("Expected bucket full at %v, got %v", start.Add(4*time.Second), got)
+	}
+	if got := (TokenBucket{Tokens: 30, UpdatedAt: start}).FullAt(limit); !got.Equal(start) {
+		t.Errorf("Expected full bucket full at %v, got %v", start, got)
+	}
+}
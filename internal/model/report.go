@@ -0,0 +1,165 @@
+// Package model provides data models and structures for the URL shortening service.
+package model
+
+import "time"
+
+// ReportReason is the kind of abuse a visitor reports a link for.
+type ReportReason string
+
+// Kinds of abuse links can be reported for.
+const (
+	// ReportPhishing reports links to pages stealing credentials or payment details.
+	ReportPhishing ReportReason = "phishing"
+
+	// ReportMalware reports links to malicious downloads or exploit pages.
+	ReportMalware ReportReason = "malware"
+
+	// ReportSpam reports links sent in unsolicited messages.
+	ReportSpam ReportReason = "spam"
+
+	// ReportIllegal reports links to content that is unlawful, e.g. infringing copyright.
+	ReportIllegal ReportReason = "illegal"
+
+	// ReportOther reports abuse of other kinds, described in the details.
+	ReportOther ReportReason = "other"
+)
+
+// IsValid reports whether the reason is one of the known kinds of abuse.
+//
+// Returns:
+//   - bool: true for known reasons
+func (r ReportReason) IsValid() bool {
+	switch r {
+	case ReportPhishing, ReportMalware, ReportSpam, ReportIllegal, ReportOther:
+		return true
+	}
+	return false
+}
+
+// ReportStatus is the state of an abuse report in the moderation queue.
+type ReportStatus string
+
+// States of abuse reports.
+const (
+	// ReportOpen marks reports waiting for an administrator.
+	ReportOpen ReportStatus = "open"
+
+	// ReportDismissed marks reports an administrator found unfounded.
+	ReportDismissed ReportStatus = "dismissed"
+
+	// ReportActioned marks reports whose link was taken down.
+	ReportActioned ReportStatus = "actioned"
+)
+
+// TakedownReason is the ground a link was taken down on. It selects the status code of the takedown page.
+type TakedownReason string
+
+// Grounds for taking links down.
+const (
+	// TakedownAbuse takes down links abusing the service. They answer 410 Gone.
+	TakedownAbuse TakedownReason = "abuse"
+
+	// TakedownLegal takes down links on a legal demand. They answer 451 Unavailable For Legal Reasons.
+	TakedownLegal TakedownReason = "legal"
+)
+
+// IsValid reports whether the reason is one of the known grounds for takedowns.
+//
+// Returns:
+//   - bool: true for known reasons
+func (r TakedownReason) IsValid() bool {
+	return r == TakedownAbuse || r == TakedownLegal
+}
+
+// AbuseReport represents a report of an abusive link waiting in the moderation queue.
+//
+// Example:
+//
+//	{
+//	  "id": "123e4567-e89b-12d3-a456-426614174000",
+//	  "short_url": "abc123",
+//	  "reason": "phishing",
+//	  "details": "Imitates the login page of my bank",
+//	  "status": "open",
+//	  "created_at": "2026-10-18T12:00:00Z"
+//	}
+type AbuseReport struct {
+	// ID is the unique identifier of the report.
+	// Example: "123e4567-e89b-12d3-a456-426614174000"
+	ID string `json:"id"`
+
+	// Domain is the vanity domain the reported link belongs to, empty for the base URL host.
+	// Example: "go.brand.com"
+	Domain string `json:"domain,omitempty"`
+
+	// ShortURL is the identifier of the reported link.
+	// Example: "abc123"
+	ShortURL string `json:"short_url"`
+
+	// Reason is the kind of abuse reported.
+	// Example: "phishing"
+	Reason ReportReason `json:"reason"`
+
+	// Details is the free text description given by the reporter.
+	// Example: "Imitates the login page of my bank"
+	Details string `json:"details,omitempty"`
+
+	// ReporterID is the user who sent the report, empty for anonymous reporters.
+	// Example: "user-123"
+	ReporterID string `json:"reporter_id,omitempty"`
+
+	// Status is the state of the report in the moderation queue.
+	// Example: "open"
+	Status ReportStatus `json:"status"`
+
+	// CreatedAt is the time the report was sent.
+	CreatedAt time.Time `json:"created_at"`
+
+	// ResolvedBy is the administrator who resolved the report, empty for open reports.
+	// Example: "admin-1"
+	ResolvedBy string `json:"resolved_by,omitempty"`
+
+	// ResolvedAt is the time the report was resolved, nil for open reports.
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+// AbuseReportRequest represents the request body of a public abuse report.
+//
+// Example:
+//
+//	{"reason": "phishing", "details": "Imitates the login page of my bank"}
+type AbuseReportRequest struct {
+	// Reason is the kind of abuse reported.
+	// Example: "phishing"
+	Reason ReportReason `json:"reason"`
+
+	// Details is an optional free text description.
+	// Example: "Imitates the login page of my bank"
+	Details string `json:"details"`
+}
+
+// TakedownRequest represents the request body of an administrator taking a reported link down.
+//
+// Example:
+//
+//	{"reason": "legal"}
+type TakedownRequest struct {
+	// Reason is the ground for the takedown, abuse or legal.
+	// Example: "legal"
+	Reason TakedownReason `json:"reason"`
+}
+
+// AbuseReportFilter represents the criteria of an administrator listing abuse reports.
+type AbuseReportFilter struct {
+	// Status is the state of the listed reports.
+	// Example: "open"
+	Status ReportStatus
+
+	// Limit is the maximum number of reports returned.
+	// Example: 100
+	Limit int
+
+	// Offset is the number of matching reports skipped.
+	// Example: 0
+	Offset int
+}
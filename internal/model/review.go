@@ -0,0 +1,23 @@
+// Package model provides data models and structures for the URL shortening service.
+package model
+
+// ReviewReason identifies a phishing heuristic that put a link under review.
+type ReviewReason string
+
+// Phishing heuristics applied to destinations of new links.
+const (
+	// ReviewPunycodeBrand flags punycode hosts that decode to an imitation of a brand name.
+	ReviewPunycodeBrand ReviewReason = "punycode_brand"
+
+	// ReviewHomoglyphBrand flags hosts spelling a brand name with look-alike characters.
+	ReviewHomoglyphBrand ReviewReason = "homoglyph_brand"
+
+	// ReviewSuspiciousTLD flags hosts in top-level domains popular with phishing.
+	ReviewSuspiciousTLD ReviewReason = "suspicious_tld"
+
+	// ReviewIPHost flags destinations given as an IP address instead of a host name.
+	ReviewIPHost ReviewReason = "ip_host"
+
+	// ReviewEmbeddedCredentials flags destinations with a user name or password before the host.
+	ReviewEmbeddedCredentials ReviewReason = "embedded_credentials"
+)
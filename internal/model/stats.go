@@ -0,0 +1,20 @@
+// Package model provides data models and structures for the URL shortening service.
+package model
+
+// Stats represents service-wide statistics for internal monitoring.
+//
+// Example:
+//
+//	{
+//	  "urls": 1024,
+//	  "users": 37
+//	}
+type Stats struct {
+	// URLs is the number of links that are not deleted.
+	// Example: 1024
+	URLs int64 `json:"urls"`
+
+	// Users is the number of distinct users who created links.
+	// Example: 37
+	Users int64 `json:"users"`
+}
@@ -1,7 +1,10 @@
 // Package model provides data models and structures for the URL shortening service.
 package model
 
-import "github.com/google/uuid"
+import (
+	"github.com/google/uuid"
+	"time"
+)
 
 // ShortURLFileDto represents the data structure for URL storage in file-based repository.
 // Used for JSON serialization/deserialization in file storage operations.
@@ -24,13 +27,102 @@ type ShortURLFileDto struct {
 	// Example: "abc123"
 	ShortURL string `json:"short_url"`
 
+	// Domain is the vanity domain the short URL belongs to, empty for the base URL host.
+	// Example: "go.brand.com"
+	Domain string `json:"domain,omitempty"`
+
 	// OriginalURL is the original URL that was shortened.
 	// Example: "https://example.com"
 	OriginalURL string `json:"original_url"`
 
+	// CanonicalURL is the normalized form of OriginalURL compared for deduplication.
+	// Empty if the URL was not canonicalized.
+	// Example: "https://example.com/"
+	CanonicalURL string `json:"canonical_url,omitempty"`
+
 	// UserID is the identifier of the user who created the short URL.
 	// Example: "user-123"
 	UserID string `json:"user_id"`
+	// IsBundle indicates that the record is a bundle of links instead of a plain redirect.
+	IsBundle bool `json:"is_bundle,omitempty"`
+	// Title is the bundle page title. Empty for plain redirects.
+	// Example: "Conference materials"
+	Title string `json:"title,omitempty"`
+	// Links are the bundle destinations in display order. Empty for plain redirects.
+	Links []BundleLink `json:"links,omitempty"`
+	// ReviewReasons are the phishing heuristics the destination matched at creation.
+	// Example: ["suspicious_tld"]
+	ReviewReasons []ReviewReason `json:"review_reasons,omitempty"`
+}
+
+// TokenRevocationFileDto represents a token revocation in file-based repository.
+// Either a single token or all tokens of a user issued before a time are revoked.
+//
+// Example JSON:
+//
+//	{
+//	  "token_id": "5f0c6a8e-8b2d-4c1e-9a77-2f1e6b7d3c90",
+//	  "expires_at": "2026-11-17T10:00:00Z"
+//	}
+type TokenRevocationFileDto struct {
+	// TokenID is the jti claim of a revoked token, empty for revocations of a user.
+	TokenID string `json:"token_id,omitempty"`
+
+	// UserID is the identifier of the user whose tokens are revoked, empty for single tokens.
+	UserID string `json:"user_id,omitempty"`
+
+	// RevokedBefore is the time tokens of the user issued before are revoked.
+	RevokedBefore time.Time `json:"revoked_before,omitzero"`
+
+	// ExpiresAt is the time the revoked tokens have expired by and the revocation can be dropped.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// APIKeyFileDto represents a state of an API key in file-based repository.
+// The file is a log of key states, the last state of a key replaces the earlier ones.
+//
+// Example JSON:
+//
+//	{
+//	  "id": "123e4567-e89b-12d3-a456-426614174000",
+//	  "user_id": "user-123",
+//	  "name": "CI pipeline",
+//	  "prefix": "shk_3q2-",
+//	  "key_hash": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+//	  "scopes": ["read", "write"],
+//	  "rate_limit": 60,
+//	  "created_at": "2026-10-18T10:00:00Z"
+//	}
+type APIKeyFileDto struct {
+	// ID is the unique identifier of the key.
+	ID string `json:"id"`
+
+	// UserID is the identifier of the user the key acts as.
+	UserID string `json:"user_id"`
+
+	// Name is the label of the key chosen by the user.
+	Name string `json:"name"`
+
+	// Prefix is the beginning of the key helping users to recognize it.
+	Prefix string `json:"prefix"`
+
+	// KeyHash is the hex-encoded SHA-256 digest of the key.
+	KeyHash string `json:"key_hash"`
+
+	// Scopes are the operation classes the key is allowed to perform.
+	Scopes []APIKeyScope `json:"scopes"`
+
+	// RateLimit is the maximal number of requests per minute made with the key.
+	RateLimit int `json:"rate_limit"`
+
+	// CreatedAt is the time the key was created.
+	CreatedAt time.Time `json:"created_at"`
+
+	// LastUsedAt is the time the key was last used, nil if it was never used.
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+
+	// IsDeleted reports whether the key was revoked.
+	IsDeleted bool `json:"is_deleted,omitempty"`
 }
 
 // URL represents the core URL entity in the URL shortening service.
@@ -40,14 +132,112 @@ type URL struct {
 	// Example: "abc123"
 	ShortURL string
 
+	// Domain is the vanity domain the short URL belongs to.
+	// Short URL identifiers are unique per domain, empty means the base URL host.
+	// Example: "go.brand.com"
+	Domain string
+
+	// WorkspaceID is the workspace owning the short URL.
+	// Empty for links owned by a single user.
+	// Example: "123e4567-e89b-12d3-a456-426614174000"
+	WorkspaceID string
+
 	// OriginalURL is the original URL that was shortened.
 	// Example: "https://example.com"
 	OriginalURL string
 
+	// CanonicalURL is the normalized form of OriginalURL compared for deduplication.
+	// Only set when a link is created, OriginalURL is kept as given for redirects and display.
+	// Example: "https://example.com/a?a=2&b=1"
+	CanonicalURL string
+
 	// IsDeleted indicates whether the URL has been soft-deleted.
 	// Deleted URLs return 410 Gone status instead of redirecting.
 	// Default: false
 	IsDeleted bool
+	// DeletedAt is the time when the URL was moved to the trash.
+	// Zero for URLs that are not deleted.
+	DeletedAt time.Time
+	// IsBundle indicates that the short URL resolves to a bundle page instead of a redirect.
+	// OriginalURL is empty for bundles, links are retrieved separately.
+	// Default: false
+	IsBundle bool
+	// IsDisabled indicates that an administrator disabled the URL or banned its owner.
+	// Disabled URLs stop resolving.
+	// Default: false
+	IsDisabled bool
+	// Takedown is the ground an administrator took the disabled URL down on, empty for other disabled URLs.
+	// Default: empty
+	Takedown TakedownReason
+	// ReviewReasons are the phishing heuristics the destination matched at creation.
+	// Links with reasons are under review and show a warning page until an administrator clears them.
+	// Default: empty
+	ReviewReasons []ReviewReason
+	// Health is the result of the last health check of the destination, nil until it is checked.
+	// Default: nil
+	Health *URLHealth
+	// Metadata describes the destination page, nil until it is fetched.
+	// Default: nil
+	Metadata *URLMetadata
+}
+
+// DedupURL returns the form of the destination compared for deduplication.
+//
+// Returns:
+//   - string: CanonicalURL, or OriginalURL if the URL was not canonicalized
+func (u URL) DedupURL() string {
+	if u.CanonicalURL != "" {
+		return u.CanonicalURL
+	}
+	return u.OriginalURL
+}
+
+// IsUnderReview reports whether the link waits for an administrator to clear it.
+//
+// Returns:
+//   - bool: true if the destination matched phishing heuristics
+func (u URL) IsUnderReview() bool {
+	return len(u.ReviewReasons) > 0
+}
+
+// BundleLink represents a single titled destination of a bundle.
+//
+// Example:
+//
+//	{
+//	  "title": "Slides",
+//	  "url": "https://example.com/slides.pdf",
+//	  "position": 1
+//	}
+type BundleLink struct {
+	// Title is the text displayed for the link on the bundle page.
+	// Example: "Slides"
+	Title string `json:"title"`
+	// URL is the destination of the link.
+	// Example: "https://example.com/slides.pdf"
+	URL string `json:"url"`
+	// Position is an optional ordering key. Links are displayed in ascending order,
+	// links with equal positions keep the request order.
+	// Example: 1
+	Position int `json:"position,omitempty"`
+}
+
+// Bundle represents a short URL resolving to a landing page with several links.
+type Bundle struct {
+	// ShortURL is the shortened URL identifier of the bundle.
+	// Example: "abc123"
+	ShortURL string
+	// Domain is the vanity domain the bundle belongs to, empty for the base URL host.
+	// Example: "go.brand.com"
+	Domain string
+	// Title is the bundle page title.
+	// Example: "Conference materials"
+	Title string
+	// Links are the bundle destinations in display order.
+	Links []BundleLink
+	// ReviewReasons are the phishing heuristics matched by any of the destinations.
+	// Bundles with reasons are held until an administrator reviews them.
+	ReviewReasons []ReviewReason
 }
 
 // NewURL creates a new URL instance.
@@ -66,3 +256,20 @@ func NewURL(shortURL string, originalURL string) *URL {
 		IsDeleted:   false,
 	}
 }
+
+// NewBundle creates a new Bundle instance.
+//
+// Parameters:
+//   - shortURL: shortened URL identifier of the bundle
+//   - title: bundle page title
+//   - links: bundle destinations in display order
+//
+// Returns:
+//   - *Bundle: initialized bundle entity
+func NewBundle(shortURL string, title string, links []BundleLink) *Bundle {
+	return &Bundle{
+		ShortURL: shortURL,
+		Title:    title,
+		Links:    links,
+	}
+}
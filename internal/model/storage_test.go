@@ -25,6 +25,18 @@ func TestNewURL(t *testing.T) {
 	}
 }
 
+func TestURLDedupURL(t *testing.T) {
+	url := NewURL("abc123", "https://Example.com:443")
+	if got := url.DedupURL(); got != "https://Example.com:443" {
+		t.Errorf("Expected original URL without canonical form, got %s", got)
+	}
+
+	url.CanonicalURL = "https://example.com/"
+	if got := url.DedupURL(); got != "https://example.com/" {
+		t.Errorf("Expected canonical URL, got %s", got)
+	}
+}
+
 func TestShortURLFileDto(t *testing.T) {
 	id := uuid.New()
 	dto := ShortURLFileDto{
@@ -50,3 +62,22 @@ func TestShortURLFileDto(t *testing.T) {
 		t.Error("UserID should not be empty")
 	}
 }
+
+func TestNewBundle(t *testing.T) {
+	links := []BundleLink{
+		{Title: "Slides", URL: "https://example.com/slides"},
+		{Title: "Video", URL: "https://example.com/video"},
+	}
+
+	bundle := NewBundle("abc123", "Conference", links)
+
+	if bundle.ShortURL != "abc123" {
+		t.Errorf("Expected ShortURL %s, got %s", "abc123", bundle.ShortURL)
+	}
+	if bundle.Title != "Conference" {
+		t.Errorf("Expected Title %s, got %s", "Conference", bundle.Title)
+	}
+	if len(bundle.Links) != 2 {
+		t.Errorf("Expected 2 links, got %d", len(bundle.Links))
+	}
+}
@@ -0,0 +1,42 @@
+// Package model provides data models and structures for the URL shortening service.
+package model
+
+// URLPolicyRule identifies a rule of the destination URL policy.
+type URLPolicyRule string
+
+// Rules of the destination URL policy.
+const (
+	// URLPolicyScheme allows only configured URL schemes.
+	URLPolicyScheme URLPolicyRule = "scheme"
+
+	// URLPolicyDomainDenied rejects domains of the deny list.
+	URLPolicyDomainDenied URLPolicyRule = "domain_denied"
+
+	// URLPolicyDomainNotAllowed rejects domains missing from a non-empty allow list.
+	URLPolicyDomainNotAllowed URLPolicyRule = "domain_not_allowed"
+
+	// URLPolicyPrivateTarget rejects loopback, private and link-local targets.
+	URLPolicyPrivateTarget URLPolicyRule = "private_target"
+
+	// URLPolicySelfLink rejects links to the hosts of the service, which would redirect in a loop.
+	URLPolicySelfLink URLPolicyRule = "self_link"
+)
+
+// DomainLists represents the domain allow and deny lists loaded from the policy file.
+// A pattern is either a domain matching itself only, or *.domain matching its subdomains.
+//
+// Example:
+//
+//	{
+//	  "allow": ["example.com", "*.example.com"],
+//	  "deny": ["*.evil.com"]
+//	}
+type DomainLists struct {
+	// Allow lists the only domains links may point to, empty allows all domains.
+	// Example: ["example.com", "*.example.com"]
+	Allow []string `json:"allow"`
+
+	// Deny lists domains links must not point to. Deny wins over allow.
+	// Example: ["*.evil.com"]
+	Deny []string `json:"deny"`
+}
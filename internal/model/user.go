@@ -0,0 +1,43 @@
+// Package model provides data models and structures for the URL shortening service.
+package model
+
+// User represents a registered account.
+// Anonymous users are not stored, they exist only as identifiers in tokens.
+//
+// Example:
+//
+//	{
+//	  "id": "123e4567-e89b-12d3-a456-426614174000",
+//	  "login": "alice",
+//	  "password_hash": "pbkdf2-sha256$600000$c2FsdA$aGFzaA"
+//	}
+type User struct {
+	// ID is the user identifier stored in tokens and owning URLs.
+	// Example: "123e4567-e89b-12d3-a456-426614174000"
+	ID string `json:"id"`
+
+	// Login is the unique name the user signs in with.
+	// Example: "alice"
+	Login string `json:"login"`
+
+	// PasswordHash is the salted password hash in "algorithm$iterations$salt$hash" format.
+	// Example: "pbkdf2-sha256$600000$c2FsdA$aGFzaA"
+	PasswordHash string `json:"password_hash"`
+}
+
+// NewUser creates a new User instance.
+//
+// Parameters:
+//   - id: unique identifier of the user
+//   - login: unique login of the user
+//   - passwordHash: salted password hash
+//
+// Returns:
+//   - *User: initialized user entity
+func NewUser(id string, login string, passwordHash string) *User {
+	return &User{
+		ID:           id,
+		Login:        login,
+		PasswordHash: passwordHash,
+	}
+}
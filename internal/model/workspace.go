@@ -0,0 +1,105 @@
+// Package model provides data models and structures for the URL shortening service.
+package model
+
+// WorkspaceRole represents the role of a member in a workspace.
+type WorkspaceRole string
+
+// Workspace role constants, from the most to the least privileged.
+const (
+	// RoleOwner can manage members and all links of the workspace.
+	RoleOwner WorkspaceRole = "owner"
+
+	// RoleEditor can create and delete links of the workspace.
+	RoleEditor WorkspaceRole = "editor"
+
+	// RoleViewer can list links and members of the workspace.
+	RoleViewer WorkspaceRole = "viewer"
+)
+
+// workspaceRoleRanks orders workspace roles by privilege.
+var workspaceRoleRanks = map[WorkspaceRole]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleOwner:  3,
+}
+
+// Valid reports whether the role is one of the known workspace roles.
+//
+// Returns:
+//   - bool: true for owner, editor and viewer
+func (r WorkspaceRole) Valid() bool {
+	_, ok := workspaceRoleRanks[r]
+	return ok
+}
+
+// Allows reports whether the role grants at least the permissions of the required role.
+//
+// Parameters:
+//   - required: minimal role needed for an operation
+//
+// Returns:
+//   - bool: true if the role is valid and not less privileged than required
+func (r WorkspaceRole) Allows(required WorkspaceRole) bool {
+	return r.Valid() && workspaceRoleRanks[r] >= workspaceRoleRanks[required]
+}
+
+// Workspace represents a team workspace sharing ownership of links.
+//
+// Example:
+//
+//	{
+//	  "id": "123e4567-e89b-12d3-a456-426614174000",
+//	  "name": "Marketing",
+//	  "role": "owner"
+//	}
+type Workspace struct {
+	// ID is the unique identifier of the workspace.
+	// Example: "123e4567-e89b-12d3-a456-426614174000"
+	ID string `json:"id"`
+
+	// Name is the display name of the workspace.
+	// Example: "Marketing"
+	Name string `json:"name"`
+
+	// Role is the role of the user the workspace was retrieved for.
+	// Empty when the workspace is not retrieved for a specific user.
+	// Example: "owner"
+	Role WorkspaceRole `json:"role,omitempty"`
+}
+
+// WorkspaceMember represents membership of a user in a workspace.
+//
+// Example:
+//
+//	{
+//	  "user_id": "user-123",
+//	  "role": "editor"
+//	}
+type WorkspaceMember struct {
+	// WorkspaceID is the identifier of the workspace.
+	// Example: "123e4567-e89b-12d3-a456-426614174000"
+	WorkspaceID string `json:"workspace_id,omitempty"`
+
+	// UserID is the identifier of the member.
+	// Example: "user-123"
+	UserID string `json:"user_id"`
+
+	// Role is the role of the member in the workspace.
+	// Example: "editor"
+	Role WorkspaceRole `json:"role"`
+}
+
+// NewWorkspace creates a new Workspace instance.
+//
+// Parameters:
+//   - id: unique identifier of the workspace
+//   - name: display name of the workspace
+//
+// Returns:
+//   - *Workspace: initialized workspace entity
+func NewWorkspace(id string, name string) *Workspace {
+	return &Workspace{
+		ID:   id,
+		Name: name,
+	}
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestWorkspaceRoleAllows(t *testing.T) {
+	tests := []struct {
+		role     WorkspaceRole
+		required WorkspaceRole
+		want     bool
+	}{
+		{role: RoleOwner, required: RoleOwner, want: true},
+		{role: RoleOwner, required: RoleViewer, want: true},
+		{role: RoleEditor, required: RoleEditor, want: true},
+		{role: RoleEditor, required: RoleOwner, want: false},
+		{role: RoleViewer, required: RoleViewer, want: true},
+		{role: RoleViewer, required: RoleEditor, want: false},
+		{role: "admin", required: RoleViewer, want: false},
+		{role: "", required: RoleViewer, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.role.Allows(tt.required); got != tt.want {
+			t.Errorf("Expected %q.Allows(%q) to be %v, got %v", tt.role, tt.required, tt.want, got)
+		}
+	}
+}
+
+func TestWorkspaceRoleValid(t *testing.T) {
+	for _, role := range []WorkspaceRole{RoleOwner, RoleEditor, RoleViewer} {
+		if !role.Valid() {
+			t.Errorf("Expected role %q to be valid", role)
+		}
+	}
+	if WorkspaceRole("admin").Valid() {
+		t.Error("Unknown role should not be valid")
+	}
+}
+
+func TestNewWorkspace(t *testing.T) {
+	workspace := NewWorkspace("ws-1", "Marketing")
+
+	if workspace.ID != "ws-1" {
+		t.Errorf("Expected ID %s, got %s", "ws-1", workspace.ID)
+	}
+	if workspace.Name != "Marketing" {
+		t.Errorf("Expected Name %s, got %s", "Marketing", workspace.Name)
+	}
+	if workspace.Role != "" {
+		t.Errorf("New workspace should not have a role, got %s", workspace.Role)
+	}
+}
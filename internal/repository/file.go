@@ -5,23 +5,46 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/bezjen/shortener/internal/config"
 	"github.com/bezjen/shortener/internal/model"
 	"github.com/google/uuid"
 	"io"
 	"os"
+	"sort"
 	"sync"
+	"time"
 )
 
+// usersFileSuffix is appended to the storage file path to build the path of the accounts file.
+const usersFileSuffix = ".users"
+
+// revocationsFileSuffix is appended to the storage file path to build the path of the token revocations file.
+const revocationsFileSuffix = ".revocations"
+
+// apiKeysFileSuffix is appended to the storage file path to build the path of the API keys file.
+const apiKeysFileSuffix = ".apikeys"
+
 // FileRepository implements Repository interface for file-based storage.
 // It stores URL mappings in a JSON file with in-memory caching for performance.
+// Registered accounts, token revocations and API keys are stored in separate JSON files
+// next to the URL storage file.
 type FileRepository struct {
-	fileStorage   os.File
-	encoder       json.Encoder
-	decoder       json.Decoder
-	memoryStorage map[string]model.ShortURLFileDto
-	mu            *sync.RWMutex
+	fileStorage     os.File
+	encoder         json.Encoder
+	decoder         json.Decoder
+	memoryStorage   map[shortURLKey]model.ShortURLFileDto
+	originals       map[originalURLKey][]originalURLLink
+	usersPath       string
+	users           map[string]model.User
+	revocationsPath string
+	revokedTokens   map[string]time.Time
+	userRevocations map[string]userTokensRevocation
+	apiKeysPath     string
+	apiKeys         map[string]model.APIKey
+	dailyLinks      map[string]dailyLinks
+	mu              *sync.RWMutex
 }
 
 // NewFileRepository creates a new FileRepository instance.
@@ -39,40 +62,66 @@ func NewFileRepository(cfg config.Config) (*FileRepository, error) {
 		return nil, err
 	}
 	decoder := *json.NewDecoder(fileStorage)
-	memoryStorage, err := loadFileData(decoder)
+	memoryStorage, originals, err := loadFileData(decoder)
+	if err != nil {
+		return nil, err
+	}
+	usersPath := cfg.FileStoragePath + usersFileSuffix
+	users, err := loadUsersData(usersPath)
+	if err != nil {
+		return nil, err
+	}
+	revocationsPath := cfg.FileStoragePath + revocationsFileSuffix
+	revokedTokens, userRevocations, err := loadRevocationsData(revocationsPath, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	apiKeysPath := cfg.FileStoragePath + apiKeysFileSuffix
+	apiKeys, err := loadAPIKeysData(apiKeysPath)
 	if err != nil {
 		return nil, err
 	}
 	return &FileRepository{
-		fileStorage:   *fileStorage,
-		memoryStorage: memoryStorage,
-		encoder:       *json.NewEncoder(fileStorage),
-		decoder:       decoder,
-		mu:            &sync.RWMutex{},
+		fileStorage:     *fileStorage,
+		memoryStorage:   memoryStorage,
+		originals:       originals,
+		usersPath:       usersPath,
+		users:           users,
+		revocationsPath: revocationsPath,
+		revokedTokens:   revokedTokens,
+		userRevocations: userRevocations,
+		apiKeysPath:     apiKeysPath,
+		apiKeys:         apiKeys,
+		dailyLinks:      make(map[string]dailyLinks),
+		encoder:         *json.NewEncoder(fileStorage),
+		decoder:         decoder,
+		mu:              &sync.RWMutex{},
 	}, nil
 }
 
 // Save stores a URL mapping in file storage and memory cache.
-// Returns ErrShortURLConflict if the short URL already exists.
+// Returns ErrShortURLConflict if the short URL already exists in the URL domain.
 //
 // Parameters:
 //   - ctx: context for request cancellation and timeouts
-//   - userID: identifier of the user creating the URL (not used in file storage)
+//   - userID: identifier of the user creating the URL
 //   - url: URL object containing short and original URLs
 //
 // Returns:
 //   - error: error if storage operation fails or URL conflict occurs
-func (f *FileRepository) Save(_ context.Context, _ string, url model.URL) error {
+func (f *FileRepository) Save(_ context.Context, userID string, url model.URL) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
-	if _, exists := f.memoryStorage[url.ShortURL]; exists {
+	key := shortURLKey{domain: url.Domain, shortURL: url.ShortURL}
+	if _, exists := f.memoryStorage[key]; exists {
 		return ErrShortURLConflict
 	}
-	shortURLDto, err := f.saveShortURLDtoToStorage(url)
+	shortURLDto, err := f.saveShortURLDtoToStorage(userID, url)
 	if err != nil {
 		return err
 	}
-	f.memoryStorage[url.ShortURL] = *shortURLDto
+	f.memoryStorage[key] = *shortURLDto
+	indexOriginalURL(f.originals, *shortURLDto)
 	return nil
 }
 
@@ -81,52 +130,162 @@ func (f *FileRepository) Save(_ context.Context, _ string, url model.URL) error
 //
 // Parameters:
 //   - ctx: context for request cancellation and timeouts
-//   - userID: identifier of the user creating the URLs (not used in file storage)
+//   - userID: identifier of the user creating the URLs
 //   - urls: slice of URL objects to store
 //
 // Returns:
 //   - error: error if any storage operation fails or URL conflict occurs
-func (f *FileRepository) SaveBatch(_ context.Context, _ string, urls []model.URL) error {
+func (f *FileRepository) SaveBatch(_ context.Context, userID string, urls []model.URL) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 	for _, url := range urls {
-		if _, exists := f.memoryStorage[url.ShortURL]; exists {
+		if _, exists := f.memoryStorage[shortURLKey{domain: url.Domain, shortURL: url.ShortURL}]; exists {
 			return ErrShortURLConflict
 		}
 	}
-	var savedKeys []string
+	var savedKeys []shortURLKey
 	for _, url := range urls {
-		shortURLDto, err := f.saveShortURLDtoToStorage(url)
+		key := shortURLKey{domain: url.Domain, shortURL: url.ShortURL}
+		shortURLDto, err := f.saveShortURLDtoToStorage(userID, url)
 		if err != nil {
 			for _, savedKey := range savedKeys {
 				delete(f.memoryStorage, savedKey)
 			}
 			return err
 		}
-		savedKeys = append(savedKeys, url.ShortURL)
-		f.memoryStorage[url.ShortURL] = *shortURLDto
+		savedKeys = append(savedKeys, key)
+		f.memoryStorage[key] = *shortURLDto
+	}
+	for _, savedKey := range savedKeys {
+		indexOriginalURL(f.originals, f.memoryStorage[savedKey])
+	}
+	return nil
+}
+
+// SaveBundle stores a bundle of links in file storage and memory cache.
+// Bundles are stored in the same file as plain URLs.
+// Returns ErrShortURLConflict if the short identifier is taken.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user creating the bundle
+//   - bundle: bundle with short identifier, title and ordered links
+//
+// Returns:
+//   - error: error if storage operation fails or short identifier conflict occurs
+func (f *FileRepository) SaveBundle(_ context.Context, userID string, bundle model.Bundle) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := shortURLKey{domain: bundle.Domain, shortURL: bundle.ShortURL}
+	if _, exists := f.memoryStorage[key]; exists {
+		return ErrShortURLConflict
+	}
+	id, err := uuid.NewUUID()
+	if err != nil {
+		return err
+	}
+	bundleDto := model.ShortURLFileDto{
+		ID:            id,
+		ShortURL:      bundle.ShortURL,
+		Domain:        bundle.Domain,
+		UserID:        userID,
+		IsBundle:      true,
+		Title:         bundle.Title,
+		Links:         bundle.Links,
+		ReviewReasons: bundle.ReviewReasons,
+	}
+	if err = f.encoder.Encode(&bundleDto); err != nil {
+		return err
 	}
+	f.memoryStorage[key] = bundleDto
 	return nil
 }
 
-// GetByShortURL retrieves the original URL by its short identifier.
+// GetByShortURL retrieves the original URL by its short identifier within a domain.
 // Uses in-memory cache for fast lookups.
 //
 // Parameters:
 //   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URL belongs to, empty for the base URL host
 //   - shortURL: short URL identifier to look up
 //
 // Returns:
 //   - *model.URL: found URL object
 //   - error: error if URL is not found
-func (f *FileRepository) GetByShortURL(_ context.Context, shortURL string) (*model.URL, error) {
+func (f *FileRepository) GetByShortURL(_ context.Context, domain string, shortURL string) (*model.URL, error) {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
-	storedShortURLDto, exists := f.memoryStorage[shortURL]
+	storedShortURLDto, exists := f.memoryStorage[shortURLKey{domain: domain, shortURL: shortURL}]
 	if !exists {
 		return nil, ErrNotFound
 	}
-	return model.NewURL(storedShortURLDto.ShortURL, storedShortURLDto.OriginalURL), nil
+	if storedShortURLDto.IsBundle {
+		return &model.URL{ShortURL: storedShortURLDto.ShortURL, Domain: domain, IsBundle: true,
+			ReviewReasons: storedShortURLDto.ReviewReasons}, nil
+	}
+	url := model.NewURL(storedShortURLDto.ShortURL, storedShortURLDto.OriginalURL)
+	url.Domain = domain
+	url.ReviewReasons = storedShortURLDto.ReviewReasons
+	return url, nil
+}
+
+// GetByOriginalURL retrieves the earliest link to an original URL within a domain.
+// Uses the index of original URLs built from the storage file.
+// Links held for review are not returned.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - userID: owner of the link, empty to find links of any user
+//   - originalURL: original URL to look up, in the form compared for deduplication
+//
+// Returns:
+//   - *model.URL: found URL object
+//   - error: ErrNotFound if no link to the original URL exists
+func (f *FileRepository) GetByOriginalURL(_ context.Context,
+	domain string,
+	userID string,
+	originalURL string,
+) (*model.URL, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	links := f.originals[originalURLKey{domain: domain, originalURL: originalURL}]
+	shortURL, found := findOriginalURLLink(links, userID, func(shortURL string) bool {
+		return len(f.memoryStorage[shortURLKey{domain: domain, shortURL: shortURL}].ReviewReasons) == 0
+	})
+	if !found {
+		return nil, ErrNotFound
+	}
+	storedShortURLDto := f.memoryStorage[shortURLKey{domain: domain, shortURL: shortURL}]
+	url := model.NewURL(storedShortURLDto.ShortURL, storedShortURLDto.OriginalURL)
+	url.Domain = domain
+	return url, nil
+}
+
+// GetBundleByShortURL retrieves a bundle with its links by short identifier within a domain.
+// Uses in-memory cache for fast lookups.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the bundle belongs to, empty for the base URL host
+//   - shortURL: short identifier of the bundle
+//
+// Returns:
+//   - *model.Bundle: found bundle
+//   - error: ErrNotFound if bundle does not exist
+func (f *FileRepository) GetBundleByShortURL(_ context.Context,
+	domain string,
+	shortURL string,
+) (*model.Bundle, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	storedShortURLDto, exists := f.memoryStorage[shortURLKey{domain: domain, shortURL: shortURL}]
+	if !exists || !storedShortURLDto.IsBundle {
+		return nil, ErrNotFound
+	}
+	bundle := model.NewBundle(storedShortURLDto.ShortURL, storedShortURLDto.Title, storedShortURLDto.Links)
+	bundle.Domain = domain
+	return bundle, nil
 }
 
 // GetByUserID retrieves all URLs created by a specific user.
@@ -148,83 +307,1148 @@ func (f *FileRepository) GetByUserID(_ context.Context, _ string) ([]model.URL,
 //
 // Parameters:
 //   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URLs belong to, empty for the base URL host
 //   - userID: identifier of the user owning the URLs
 //   - shortURLs: slice of short URL identifiers to delete
 //
 // Returns:
 //   - error: always returns "method not implemented" error
-func (f *FileRepository) DeleteBatch(_ context.Context, _ string, _ []string) error {
+func (f *FileRepository) DeleteBatch(_ context.Context, _ string, _ string, _ []string) error {
 	return fmt.Errorf("method not implemented")
 }
 
-// Ping checks the connectivity to file storage.
-// Always returns nil for file storage as file operations are checked during initialization.
+// GetDeletedByUserID retrieves all deleted URLs of a specific user.
+// Not implemented for file storage.
 //
 // Parameters:
 //   - ctx: context for request cancellation and timeouts
+//   - userID: user identifier to look up deleted URLs for
 //
 // Returns:
-//   - error: always nil for file storage
-func (f *FileRepository) Ping(_ context.Context) error {
-	return nil
+//   - []model.URL: slice of URLs (always empty for file storage)
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) GetDeletedByUserID(_ context.Context, _ string) ([]model.URL, error) {
+	return nil, fmt.Errorf("method not implemented")
 }
 
-// Close closes the file storage and releases resources.
-// Should be called when the repository is no longer needed.
+// RestoreBatch restores multiple deleted short URLs.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URLs belong to, empty for the base URL host
+//   - userID: identifier of the user owning the URLs
+//   - shortURLs: slice of short URL identifiers to restore
 //
 // Returns:
-//   - error: error if file closing fails
-func (f *FileRepository) Close() error {
-	return f.fileStorage.Close()
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) RestoreBatch(_ context.Context, _ string, _ string, _ []string) error {
+	return fmt.Errorf("method not implemented")
 }
 
-// saveShortURLDtoToStorage writes a URL mapping to the file storage.
-// Generates a UUID for each entry and appends it as JSON line to the file.
+// PurgeDeleted permanently removes deleted URLs.
+// File storage does not support deletion, so there is nothing to purge.
 //
 // Parameters:
-//   - url: URL object to store
+//   - ctx: context for request cancellation and timeouts
+//   - deletedBefore: URLs deleted before this time are removed
 //
 // Returns:
-//   - *model.ShortURLFileDto: DTO containing stored URL data
-//   - error: error if UUID generation or file writing fails
-func (f *FileRepository) saveShortURLDtoToStorage(url model.URL) (*model.ShortURLFileDto, error) {
-	id, err := uuid.NewUUID()
+//   - int64: always 0
+//   - error: always nil
+func (f *FileRepository) PurgeDeleted(_ context.Context, _ time.Time) (int64, error) {
+	return 0, nil
+}
+
+// CreateWorkspace stores a new workspace.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - workspace: workspace with identifier and name
+//   - ownerID: identifier of the user becoming the workspace owner
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) CreateWorkspace(_ context.Context, _ model.Workspace, _ string) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// GetWorkspacesByUserID retrieves all workspaces of a user.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: user identifier to look up workspaces for
+//
+// Returns:
+//   - []model.Workspace: slice of workspaces (always empty for file storage)
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) GetWorkspacesByUserID(_ context.Context, _ string) ([]model.Workspace, error) {
+	return nil, fmt.Errorf("method not implemented")
+}
+
+// GetWorkspaceRole retrieves the role of a user in a workspace.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - workspaceID: identifier of the workspace
+//   - userID: identifier of the user
+//
+// Returns:
+//   - model.WorkspaceRole: always empty for file storage
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) GetWorkspaceRole(_ context.Context, _ string, _ string) (model.WorkspaceRole, error) {
+	return "", fmt.Errorf("method not implemented")
+}
+
+// GetWorkspaceMembers retrieves all members of a workspace.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - workspaceID: identifier of the workspace
+//
+// Returns:
+//   - []model.WorkspaceMember: slice of members (always empty for file storage)
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) GetWorkspaceMembers(_ context.Context, _ string) ([]model.WorkspaceMember, error) {
+	return nil, fmt.Errorf("method not implemented")
+}
+
+// SaveWorkspaceMember adds or updates a workspace member.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - member: workspace member with role
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) SaveWorkspaceMember(_ context.Context, _ model.WorkspaceMember) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// DeleteWorkspaceMember removes a member from a workspace.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - workspaceID: identifier of the workspace
+//   - userID: identifier of the member to remove
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) DeleteWorkspaceMember(_ context.Context, _ string, _ string) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// GetByWorkspaceID retrieves all URLs of a workspace.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - workspaceID: identifier of the workspace
+//
+// Returns:
+//   - []model.URL: slice of URLs (always empty for file storage)
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) GetByWorkspaceID(_ context.Context, _ string) ([]model.URL, error) {
+	return nil, fmt.Errorf("method not implemented")
+}
+
+// DeleteWorkspaceBatch marks multiple short URLs of a workspace as deleted.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URLs belong to, empty for the base URL host
+//   - workspaceID: identifier of the workspace owning the URLs
+//   - shortURLs: slice of short URL identifiers to delete
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) DeleteWorkspaceBatch(_ context.Context, _ string, _ string, _ []string) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// GetDeletedByWorkspaceID retrieves all deleted URLs of a workspace.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - workspaceID: identifier of the workspace
+//
+// Returns:
+//   - []model.URL: slice of URLs (always empty for file storage)
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) GetDeletedByWorkspaceID(_ context.Context, _ string) ([]model.URL, error) {
+	return nil, fmt.Errorf("method not implemented")
+}
+
+// RestoreWorkspaceBatch restores multiple deleted short URLs of a workspace.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URLs belong to, empty for the base URL host
+//   - workspaceID: identifier of the workspace owning the URLs
+//   - shortURLs: slice of short URL identifiers to restore
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) RestoreWorkspaceBatch(_ context.Context, _ string, _ string, _ []string) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// UpdateWorkspaceURL changes the original URL of a short URL owned by a workspace.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - workspaceID: identifier of the workspace owning the URL
+//   - url: URL with the new original URL
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) UpdateWorkspaceURL(_ context.Context, _ string, _ model.URL) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// SaveUser stores a registered account in the accounts file and memory cache.
+// The accounts file is created on the first registration.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - user: user with identifier, login and password hash
+//
+// Returns:
+//   - error: ErrLoginConflict if the login is taken, or file writing error
+func (f *FileRepository) SaveUser(_ context.Context, user model.User) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, existing := range f.users {
+		if existing.Login == user.Login {
+			return ErrLoginConflict
+		}
+	}
+	usersFile, err := os.OpenFile(f.usersPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	shortURLDto := model.ShortURLFileDto{
-		ID:          id,
-		ShortURL:    url.ShortURL,
-		OriginalURL: url.OriginalURL,
+	defer usersFile.Close()
+	if err = json.NewEncoder(usersFile).Encode(&user); err != nil {
+		return err
 	}
-	err = f.encoder.Encode(&shortURLDto)
-	if err != nil {
-		return nil, err
+	f.users[user.ID] = user
+	return nil
+}
+
+// GetUserByLogin retrieves a registered account by its login from memory cache.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - login: login of the account
+//
+// Returns:
+//   - *model.User: found user
+//   - error: ErrNotFound if no account has the login
+func (f *FileRepository) GetUserByLogin(_ context.Context, login string) (*model.User, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, user := range f.users {
+		if user.Login == login {
+			return &user, nil
+		}
 	}
-	return &shortURLDto, nil
+	return nil, ErrNotFound
 }
 
-// loadFileData reads existing URL mappings from file storage into memory.
-// Parses JSON lines from the file and builds an in-memory map for fast access.
+// GetUserByID retrieves a registered account by its identifier from memory cache.
 //
 // Parameters:
-//   - decoder: JSON decoder configured for the storage file
+//   - ctx: context for request cancellation and timeouts
+//   - id: identifier of the account
 //
 // Returns:
-//   - map[string]model.ShortURLFileDto: map of short URL to URL DTO
-//   - error: error if file reading or JSON parsing fails
-func loadFileData(decoder json.Decoder) (map[string]model.ShortURLFileDto, error) {
-	memoryStorage := make(map[string]model.ShortURLFileDto)
-	for {
-		var dto model.ShortURLFileDto
-		err := decoder.Decode(&dto)
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return nil, err
+//   - *model.User: found user
+//   - error: ErrNotFound if the identifier belongs to no account
+func (f *FileRepository) GetUserByID(_ context.Context, id string) (*model.User, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	user, exists := f.users[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &user, nil
+}
+
+// MergeUserURLs moves URLs of one user to another.
+// File storage does not track URL owners, so there is nothing to move.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - fromUserID: identifier of the user giving up the URLs (not used)
+//   - toUserID: identifier of the user receiving the URLs (not used)
+//
+// Returns:
+//   - error: always nil
+func (f *FileRepository) MergeUserURLs(_ context.Context, _ string, _ string) error {
+	return nil
+}
+
+// SaveAPIKey stores a new API key of a user in the API keys file and memory cache.
+// The API keys file is created on the first key.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - key: API key with identifier, owner, key hash and scopes
+//
+// Returns:
+//   - error: file writing error
+func (f *FileRepository) SaveAPIKey(_ context.Context, key model.APIKey) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.appendAPIKey(newAPIKeyFileDto(key, false)); err != nil {
+		return err
+	}
+	f.apiKeys[key.ID] = key
+	return nil
+}
+
+// GetAPIKeysByUserID retrieves all API keys of a user ordered by creation time from memory cache.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: user identifier to look up keys for
+//
+// Returns:
+//   - []model.APIKey: slice of keys of the user
+//   - error: always nil
+func (f *FileRepository) GetAPIKeysByUserID(_ context.Context, userID string) ([]model.APIKey, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	var keys []model.APIKey
+	for _, key := range f.apiKeys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+// GetAPIKeyByHash retrieves an API key by the hash of the key from memory cache.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - keyHash: hex-encoded SHA-256 digest of the key
+//
+// Returns:
+//   - *model.APIKey: found key
+//   - error: ErrNotFound if no key has the hash
+func (f *FileRepository) GetAPIKeyByHash(_ context.Context, keyHash string) (*model.APIKey, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, key := range f.apiKeys {
+		if key.KeyHash == keyHash {
+			return &key, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// DeleteAPIKey removes an API key of a user, recording the revocation in the API keys file.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user owning the key
+//   - id: identifier of the key
+//
+// Returns:
+//   - error: ErrNotFound if the user has no such key, or file writing error
+func (f *FileRepository) DeleteAPIKey(_ context.Context, userID string, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key, exists := f.apiKeys[id]
+	if !exists || key.UserID != userID {
+		return ErrNotFound
+	}
+	if err := f.appendAPIKey(newAPIKeyFileDto(key, true)); err != nil {
+		return err
+	}
+	delete(f.apiKeys, id)
+	return nil
+}
+
+// UpdateAPIKeyLastUsed records the time an API key was last used in the API keys file and memory cache.
+// Unknown keys are ignored.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - id: identifier of the key
+//   - usedAt: time the key was used
+//
+// Returns:
+//   - error: file writing error
+func (f *FileRepository) UpdateAPIKeyLastUsed(_ context.Context, id string, usedAt time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key, exists := f.apiKeys[id]
+	if !exists {
+		return nil
+	}
+	key.LastUsedAt = &usedAt
+	if err := f.appendAPIKey(newAPIKeyFileDto(key, false)); err != nil {
+		return err
+	}
+	f.apiKeys[id] = key
+	return nil
+}
+
+// SaveRevokedToken revokes a single token in the revocations file and memory cache.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - tokenID: jti claim of the token
+//   - expiresAt: expiration time of the token
+//
+// Returns:
+//   - error: file writing error
+func (f *FileRepository) SaveRevokedToken(_ context.Context, tokenID string, expiresAt time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	err := f.appendRevocation(model.TokenRevocationFileDto{TokenID: tokenID, ExpiresAt: expiresAt})
+	if err != nil {
+		return err
+	}
+	f.revokedTokens[tokenID] = expiresAt
+	return nil
+}
+
+// IsTokenRevoked reports whether a token was revoked by itself or together with all tokens of its user,
+// and the revocation has not expired yet.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - tokenID: jti claim of the token, empty for tokens without one
+//   - userID: identifier of the user the token was issued to
+//   - issuedAt: issue time of the token, zero for tokens without one
+//
+// Returns:
+//   - bool: true if the token is revoked
+//   - error: always nil
+func (f *FileRepository) IsTokenRevoked(_ context.Context,
+	tokenID string,
+	userID string,
+	issuedAt time.Time,
+) (bool, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return isTokenRevoked(f.revokedTokens, f.userRevocations, tokenID, userID, issuedAt, time.Now()), nil
+}
+
+// SaveUserTokensRevocation revokes all tokens of a user issued before the given time
+// in the revocations file and memory cache.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//   - revokedBefore: tokens issued before this time are revoked
+//   - expiresAt: time all revoked tokens have expired by
+//
+// Returns:
+//   - error: file writing error
+func (f *FileRepository) SaveUserTokensRevocation(_ context.Context,
+	userID string,
+	revokedBefore time.Time,
+	expiresAt time.Time,
+) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	err := f.appendRevocation(model.TokenRevocationFileDto{UserID: userID, RevokedBefore: revokedBefore, ExpiresAt: expiresAt})
+	if err != nil {
+		return err
+	}
+	f.userRevocations[userID] = userTokensRevocation{revokedBefore: revokedBefore, expiresAt: expiresAt}
+	return nil
+}
+
+// PurgeExpiredRevocations removes expired revocations from memory cache
+// and rewrites the revocations file with the remaining ones.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - now: revocations expiring before this time are removed
+//
+// Returns:
+//   - int64: number of removed revocations
+//   - error: file writing error
+func (f *FileRepository) PurgeExpiredRevocations(_ context.Context, now time.Time) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var purged int64
+	var remaining []model.TokenRevocationFileDto
+	for tokenID, expiresAt := range f.revokedTokens {
+		if expiresAt.Before(now) {
+			delete(f.revokedTokens, tokenID)
+			purged++
+			continue
+		}
+		remaining = append(remaining, model.TokenRevocationFileDto{TokenID: tokenID, ExpiresAt: expiresAt})
+	}
+	for userID, revocation := range f.userRevocations {
+		if revocation.expiresAt.Before(now) {
+			delete(f.userRevocations, userID)
+			purged++
+			continue
+		}
+		remaining = append(remaining, model.TokenRevocationFileDto{
+			UserID:        userID,
+			RevokedBefore: revocation.revokedBefore,
+			ExpiresAt:     revocation.expiresAt,
+		})
+	}
+	if purged == 0 {
+		return 0, nil
+	}
+
+	tmpPath := f.revocationsPath + ".tmp"
+	tmpFile, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, err
+	}
+	encoder := json.NewEncoder(tmpFile)
+	for _, revocation := range remaining {
+		if err = encoder.Encode(&revocation); err != nil {
+			tmpFile.Close()
+			return 0, err
+		}
+	}
+	if err = tmpFile.Close(); err != nil {
+		return 0, err
+	}
+	if err = os.Rename(tmpPath, f.revocationsPath); err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
+
+// SearchURLs finds links of all users for administrators.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - filter: search criteria with pagination
+//
+// Returns:
+//   - []model.AdminURL: always nil
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) SearchURLs(_ context.Context, _ model.URLSearchFilter) ([]model.AdminURL, error) {
+	return nil, fmt.Errorf("method not implemented")
+}
+
+// SetURLDisabled disables a link or enables it again.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - shortURL: short URL identifier
+//   - disabled: true to disable the link, false to enable it
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) SetURLDisabled(_ context.Context, _ string, _ string, _ bool) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// ClearURLReview clears the phishing review reasons of a link after an administrator checked it.
+// Not implemented for file storage as records are only appended.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - shortURL: short URL identifier
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) ClearURLReview(_ context.Context, _ string, _ string) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// SaveAbuseReport adds a report of an abusive link to the moderation queue.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - report: open report with identifier and reported link
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) SaveAbuseReport(_ context.Context, _ model.AbuseReport) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// GetAbuseReports lists abuse reports in a state, oldest first.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - filter: state of the reports with pagination
+//
+// Returns:
+//   - []model.AbuseReport: always nil
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) GetAbuseReports(_ context.Context, _ model.AbuseReportFilter) ([]model.AbuseReport, error) {
+	return nil, fmt.Errorf("method not implemented")
+}
+
+// GetAbuseReport retrieves a single abuse report.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - id: identifier of the report
+//
+// Returns:
+//   - *model.AbuseReport: always nil
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) GetAbuseReport(_ context.Context, _ string) (*model.AbuseReport, error) {
+	return nil, fmt.Errorf("method not implemented")
+}
+
+// DismissAbuseReport marks an open abuse report as dismissed.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - id: identifier of the report
+//   - adminID: identifier of the administrator resolving the report
+//   - resolvedAt: time of the resolution
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) DismissAbuseReport(_ context.Context, _ string, _ string, _ time.Time) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// TakeDownURL disables a link on the given ground and marks its open abuse reports as actioned.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - shortURL: short URL identifier
+//   - reason: ground for the takedown
+//   - adminID: identifier of the administrator taking the link down
+//   - resolvedAt: time of the takedown
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) TakeDownURL(_ context.Context,
+	_ string,
+	_ string,
+	_ model.TakedownReason,
+	_ string,
+	_ time.Time,
+) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// GetURLsForHealthCheck lists links whose destinations are due for a health check.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - checkedBefore: links checked at or after this time are not due
+//   - limit: maximum number of links to return
+//
+// Returns:
+//   - []model.URL: always nil
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) GetURLsForHealthCheck(_ context.Context, _ time.Time, _ int) ([]model.URL, error) {
+	return nil, fmt.Errorf("method not implemented")
+}
+
+// SaveURLHealth records the result of a destination health check.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - shortURL: short URL identifier
+//   - health: result of the check
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) SaveURLHealth(_ context.Context, _ string, _ string, _ model.URLHealth) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// SaveURLMetadata stores the title, description and preview image fetched from a link destination.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - shortURL: short URL identifier
+//   - metadata: fetched metadata
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) SaveURLMetadata(_ context.Context, _ string, _ string, _ model.URLMetadata) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// SetUserBanned bans a user or lifts the ban.
+// Not implemented for file storage as it doesn't track link ownership.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//   - banned: true to ban the user, false to lift the ban
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) SetUserBanned(_ context.Context, _ string, _ bool) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// IsUserBanned reports whether a user is banned.
+// Users cannot be banned in file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//
+// Returns:
+//   - bool: always false
+//   - error: always nil
+func (f *FileRepository) IsUserBanned(_ context.Context, _ string) (bool, error) {
+	return false, nil
+}
+
+// GetUserUsage counts links and API keys of a user.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//
+// Returns:
+//   - *model.UserUsage: always nil
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) GetUserUsage(_ context.Context, _ string) (*model.UserUsage, error) {
+	return nil, fmt.Errorf("method not implemented")
+}
+
+// GetStats counts links and distinct users who created links from memory cache.
+// File storage does not delete links, so all links are counted.
+// Links stored without a user ID are not attributed to any user.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//
+// Returns:
+//   - *model.Stats: link and user counts
+//   - error: always nil
+func (f *FileRepository) GetStats(_ context.Context) (*model.Stats, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	users := make(map[string]struct{})
+	for _, dto := range f.memoryStorage {
+		if dto.UserID != "" {
+			users[dto.UserID] = struct{}{}
+		}
+	}
+	return &model.Stats{
+		URLs:  int64(len(f.memoryStorage)),
+		Users: int64(len(users)),
+	}, nil
+}
+
+// GetQuotaUsage retrieves the quota counters of a user.
+// File storage does not delete links, so all links stored with the user ID are active.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - userID: identifier of the user
+//   - day: UTC day the created links are counted for
+//
+// Returns:
+//   - *model.QuotaUsage: quota counters of the user, zero counts for unknown users
+//   - error: always nil
+func (f *FileRepository) GetQuotaUsage(_ context.Context, userID string, day time.Time) (*model.QuotaUsage, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	usage := &model.QuotaUsage{LinksToday: f.dailyLinks[userID].linksIn(day)}
+	for _, dto := range f.memoryStorage {
+		if dto.UserID == userID {
+			usage.ActiveLinks++
+		}
+	}
+	return usage, nil
+}
+
+// ReserveLinks checks the limits of a user and counts links the user is about to create under a single lock.
+// Daily counters are kept in memory only and start from zero after a restart.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - userID: identifier of the user
+//   - day: UTC day the links are created in
+//   - count: number of links to create
+//   - limits: limits of the user, zero limits are unlimited
+//
+// Returns:
+//   - *model.QuotaUsage: usage before the reservation, pending links of other requests included
+//   - bool: false if a limit would be exceeded and nothing was reserved
+//   - error: always nil
+func (f *FileRepository) ReserveLinks(_ context.Context,
+	userID string,
+	day time.Time,
+	count int64,
+	limits model.QuotaLimits,
+) (*model.QuotaUsage, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var activeLinks int64
+	for _, dto := range f.memoryStorage {
+		if dto.UserID == userID {
+			activeLinks++
+		}
+	}
+	counter, usage, reserved := f.dailyLinks[userID].reserve(day, count, limits, activeLinks)
+	f.dailyLinks[userID] = counter
+	return usage, reserved, nil
+}
+
+// ReleaseLinks ends a reservation made by ReserveLinks.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - userID: identifier of the user
+//   - day: UTC day the links were reserved in
+//   - reserved: number of reserved links
+//   - unused: number of reserved links that were not created
+//
+// Returns:
+//   - error: always nil
+func (f *FileRepository) ReleaseLinks(_ context.Context,
+	userID string,
+	day time.Time,
+	reserved int64,
+	unused int64,
+) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.dailyLinks[userID] = f.dailyLinks[userID].release(day, reserved, unused)
+	return nil
+}
+
+// GetUserQuotaLimits retrieves the limits assigned to a single user.
+// File storage does not store limits of users, so all users have the default limits.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//
+// Returns:
+//   - *model.QuotaLimits: always nil
+//   - error: always ErrNotFound
+func (f *FileRepository) GetUserQuotaLimits(_ context.Context, _ string) (*model.QuotaLimits, error) {
+	return nil, ErrNotFound
+}
+
+// SaveUserQuotaLimits assigns limits to a single user.
+// Not implemented for file storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//   - limits: limits of the user
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (f *FileRepository) SaveUserQuotaLimits(_ context.Context, _ string, _ model.QuotaLimits) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// Ping checks the connectivity to file storage.
+// Always returns nil for file storage as file operations are checked during initialization.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//
+// Returns:
+//   - error: always nil for file storage
+func (f *FileRepository) Ping(_ context.Context) error {
+	return nil
+}
+
+// Close closes the file storage and releases resources.
+// Should be called when the repository is no longer needed.
+//
+// Returns:
+//   - error: error if file closing fails
+func (f *FileRepository) Close() error {
+	return f.fileStorage.Close()
+}
+
+// saveShortURLDtoToStorage writes a URL mapping to the file storage.
+// Generates a UUID for each entry and appends it as JSON line to the file.
+//
+// Parameters:
+//   - url: URL object to store
+//
+// Returns:
+//   - *model.ShortURLFileDto: DTO containing stored URL data
+//   - error: error if UUID generation or file writing fails
+func (f *FileRepository) saveShortURLDtoToStorage(userID string, url model.URL) (*model.ShortURLFileDto, error) {
+	id, err := uuid.NewUUID()
+	if err != nil {
+		return nil, err
+	}
+	shortURLDto := model.ShortURLFileDto{
+		ID:            id,
+		ShortURL:      url.ShortURL,
+		Domain:        url.Domain,
+		OriginalURL:   url.OriginalURL,
+		CanonicalURL:  url.CanonicalURL,
+		UserID:        userID,
+		ReviewReasons: url.ReviewReasons,
+	}
+	err = f.encoder.Encode(&shortURLDto)
+	if err != nil {
+		return nil, err
+	}
+	return &shortURLDto, nil
+}
+
+// loadFileData reads existing URL mappings from file storage into memory.
+// Parses JSON lines from the file and builds an in-memory map for fast access
+// together with the index of original URLs in creation order.
+//
+// Parameters:
+//   - decoder: JSON decoder configured for the storage file
+//
+// Returns:
+//   - map[shortURLKey]model.ShortURLFileDto: map of domain and short URL to URL DTO
+//   - map[originalURLKey][]originalURLLink: map of domain and original URL to links
+//   - error: error if file reading or JSON parsing fails
+func loadFileData(decoder json.Decoder) (map[shortURLKey]model.ShortURLFileDto,
+	map[originalURLKey][]originalURLLink,
+	error,
+) {
+	memoryStorage := make(map[shortURLKey]model.ShortURLFileDto)
+	originals := make(map[originalURLKey][]originalURLLink)
+	for {
+		var dto model.ShortURLFileDto
+		err := decoder.Decode(&dto)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, nil, err
+		}
+		memoryStorage[shortURLKey{domain: dto.Domain, shortURL: dto.ShortURL}] = dto
+		indexOriginalURL(originals, dto)
+	}
+	return memoryStorage, originals, nil
+}
+
+// indexOriginalURL adds a stored link to the index of original URLs compared for deduplication.
+// Bundles are not indexed.
+//
+// Parameters:
+//   - originals: index of original URLs to add the link to
+//   - dto: stored link
+func indexOriginalURL(originals map[originalURLKey][]originalURLLink, dto model.ShortURLFileDto) {
+	if dto.IsBundle {
+		return
+	}
+	originalURL := dto.CanonicalURL
+	if originalURL == "" {
+		originalURL = dto.OriginalURL
+	}
+	key := originalURLKey{domain: dto.Domain, originalURL: originalURL}
+	originals[key] = append(originals[key], originalURLLink{shortURL: dto.ShortURL, userID: dto.UserID})
+}
+
+// loadUsersData reads registered accounts from the accounts file.
+// A missing file means no account has been registered yet.
+//
+// Parameters:
+//   - path: path of the accounts file
+//
+// Returns:
+//   - map[string]model.User: map of user identifier to user
+//   - error: error if file reading or JSON parsing fails
+func loadUsersData(path string) (map[string]model.User, error) {
+	users := make(map[string]model.User)
+	usersFile, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return users, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer usersFile.Close()
+	decoder := json.NewDecoder(usersFile)
+	for {
+		var user model.User
+		err = decoder.Decode(&user)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		users[user.ID] = user
+	}
+	return users, nil
+}
+
+// appendRevocation writes a revocation to the end of the revocations file.
+// The revocations file is created on the first revocation. Must be called with the mutex held.
+func (f *FileRepository) appendRevocation(revocation model.TokenRevocationFileDto) error {
+	revocationsFile, err := os.OpenFile(f.revocationsPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer revocationsFile.Close()
+	return json.NewEncoder(revocationsFile).Encode(&revocation)
+}
+
+// loadRevocationsData reads token revocations from the revocations file.
+// A missing file means there are no revocations, expired revocations are skipped.
+//
+// Parameters:
+//   - path: path of the revocations file
+//   - now: current time to skip expired revocations
+//
+// Returns:
+//   - map[string]time.Time: expiration times of revoked tokens by token identifier
+//   - map[string]userTokensRevocation: revocations of all tokens by user identifier
+//   - error: error if the file cannot be read or decoded
+func loadRevocationsData(path string, now time.Time) (map[string]time.Time, map[string]userTokensRevocation, error) {
+	revokedTokens := make(map[string]time.Time)
+	userRevocations := make(map[string]userTokensRevocation)
+	revocationsFile, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return revokedTokens, userRevocations, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	defer revocationsFile.Close()
+	decoder := json.NewDecoder(revocationsFile)
+	for {
+		var revocation model.TokenRevocationFileDto
+		err = decoder.Decode(&revocation)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, nil, err
+		}
+		if revocation.ExpiresAt.Before(now) {
+			continue
+		}
+		if revocation.TokenID != "" {
+			revokedTokens[revocation.TokenID] = revocation.ExpiresAt
+		} else {
+			userRevocations[revocation.UserID] = userTokensRevocation{
+				revokedBefore: revocation.RevokedBefore,
+				expiresAt:     revocation.ExpiresAt,
+			}
+		}
+	}
+	return revokedTokens, userRevocations, nil
+}
+
+// appendAPIKey writes a state of an API key to the end of the API keys file.
+// The API keys file is created on the first key. Must be called with the mutex held.
+func (f *FileRepository) appendAPIKey(dto model.APIKeyFileDto) error {
+	apiKeysFile, err := os.OpenFile(f.apiKeysPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer apiKeysFile.Close()
+	return json.NewEncoder(apiKeysFile).Encode(&dto)
+}
+
+// newAPIKeyFileDto converts an API key to its state in the API keys file.
+//
+// Parameters:
+//   - key: API key
+//   - isDeleted: whether the state records the revocation of the key
+//
+// Returns:
+//   - model.APIKeyFileDto: state of the key
+func newAPIKeyFileDto(key model.APIKey, isDeleted bool) model.APIKeyFileDto {
+	return model.APIKeyFileDto{
+		ID:         key.ID,
+		UserID:     key.UserID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		KeyHash:    key.KeyHash,
+		Scopes:     key.Scopes,
+		RateLimit:  key.RateLimit,
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+		IsDeleted:  isDeleted,
+	}
+}
+
+// loadAPIKeysData reads API keys from the API keys file.
+// A missing file means there are no keys, the last state of every key wins and revoked keys are skipped.
+//
+// Parameters:
+//   - path: path of the API keys file
+//
+// Returns:
+//   - map[string]model.APIKey: map of key identifier to key
+//   - error: error if the file cannot be read or decoded
+func loadAPIKeysData(path string) (map[string]model.APIKey, error) {
+	apiKeys := make(map[string]model.APIKey)
+	apiKeysFile, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return apiKeys, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer apiKeysFile.Close()
+	decoder := json.NewDecoder(apiKeysFile)
+	for {
+		var dto model.APIKeyFileDto
+		err = decoder.Decode(&dto)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if dto.IsDeleted {
+			delete(apiKeys, dto.ID)
+			continue
+		}
+		apiKeys[dto.ID] = model.APIKey{
+			ID:         dto.ID,
+			UserID:     dto.UserID,
+			Name:       dto.Name,
+			Prefix:     dto.Prefix,
+			KeyHash:    dto.KeyHash,
+			Scopes:     dto.Scopes,
+			RateLimit:  dto.RateLimit,
+			CreatedAt:  dto.CreatedAt,
+			LastUsedAt: dto.LastUsedAt,
 		}
-		memoryStorage[dto.ShortURL] = dto
 	}
-	return memoryStorage, nil
+	return apiKeys, nil
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"os"
 	"testing"
+	"time"
 )
 
 func testConfig() config.Config {
@@ -38,7 +39,7 @@ func TestFileRepositorySuccess(t *testing.T) {
 			if err != nil {
 				t.Fatalf("Save failed: %v", err)
 			}
-			result, err := repo.GetByShortURL(context.TODO(), tt.url.ShortURL)
+			result, err := repo.GetByShortURL(context.TODO(), "", tt.url.ShortURL)
 			if err != nil {
 				t.Fatalf("GetByShortURL failed: %v", err)
 			}
@@ -74,7 +75,7 @@ func TestFileRepositoryErrConflict(t *testing.T) {
 	}
 
 	t.Run("Get not-existed OriginalURL (ErrNotFound)", func(t *testing.T) {
-		_, err := repo.GetByShortURL(context.TODO(), "non-existent")
+		_, err := repo.GetByShortURL(context.TODO(), "", "non-existent")
 		if !errors.Is(err, ErrNotFound) {
 			t.Errorf("got %v, want %v", err, ErrNotFound)
 		}
@@ -86,7 +87,7 @@ func TestFileRepositoryErrNotFound(t *testing.T) {
 	defer cleanup()
 
 	t.Run("Get not-existed OriginalURL (ErrNotFound)", func(t *testing.T) {
-		_, err := repo.GetByShortURL(context.TODO(), "non-existent")
+		_, err := repo.GetByShortURL(context.TODO(), "", "non-existent")
 		if !errors.Is(err, ErrNotFound) {
 			t.Errorf("got %v, want %v", err, ErrNotFound)
 		}
@@ -116,7 +117,7 @@ func TestFileRepositorySaveBatch(t *testing.T) {
 			assert.NoError(t, err)
 
 			for _, url := range tt.batch {
-				result, err := repo.GetByShortURL(context.TODO(), url.ShortURL)
+				result, err := repo.GetByShortURL(context.TODO(), "", url.ShortURL)
 				assert.NoError(t, err)
 				assert.Equal(t, &url, result)
 			}
@@ -156,11 +157,322 @@ func TestFileRepositoryDeleteBatch(t *testing.T) {
 	repo, cleanup := setupFileRepository(t)
 	defer cleanup()
 
-	err := repo.DeleteBatch(context.TODO(), "user1", []string{"qwerty12"})
+	err := repo.DeleteBatch(context.TODO(), "", "user1", []string{"qwerty12"})
 	assert.Error(t, err)
 	assert.Equal(t, "method not implemented", err.Error())
 }
 
+func TestFileRepositoryBundle(t *testing.T) {
+	repo, cleanup := setupFileRepository(t)
+	defer cleanup()
+
+	bundle := model.NewBundle("qwerty12", "Conference", []model.BundleLink{
+		{Title: "Slides", URL: "https://example.com/slides"},
+		{Title: "Video", URL: "https://example.com/video"},
+	})
+
+	err := repo.SaveBundle(context.TODO(), "user1", *bundle)
+	assert.NoError(t, err)
+
+	url, err := repo.GetByShortURL(context.TODO(), "", "qwerty12")
+	assert.NoError(t, err)
+	assert.True(t, url.IsBundle)
+
+	stored, err := repo.GetBundleByShortURL(context.TODO(), "", "qwerty12")
+	assert.NoError(t, err)
+	assert.Equal(t, bundle, stored)
+
+	err = repo.Save(context.TODO(), "user1", *model.NewURL("qwerty12", "https://example.com"))
+	assert.ErrorIs(t, err, ErrShortURLConflict)
+
+	err = repo.SaveBundle(context.TODO(), "user1", *bundle)
+	assert.ErrorIs(t, err, ErrShortURLConflict)
+
+	_, err = repo.GetBundleByShortURL(context.TODO(), "", "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	flagged := model.NewBundle("qwerty13", "Flagged", []model.BundleLink{{Title: "IP", URL: "http://1.2.3.4"}})
+	flagged.ReviewReasons = []model.ReviewReason{model.ReviewIPHost}
+	assert.NoError(t, repo.SaveBundle(context.TODO(), "user1", *flagged))
+	url, err = repo.GetByShortURL(context.TODO(), "", "qwerty13")
+	assert.NoError(t, err)
+	assert.True(t, url.IsBundle)
+	assert.Equal(t, flagged.ReviewReasons, url.ReviewReasons)
+}
+
+func TestFileRepositoryDomains(t *testing.T) {
+	repo, cleanup := setupFileRepository(t)
+	defer cleanup()
+
+	defaultURL := model.NewURL("qwerty12", "https://practicum.yandex.ru/")
+	brandURL := &model.URL{ShortURL: "qwerty12", Domain: "go.brand.com", OriginalURL: "https://brand.com/"}
+
+	assert.NoError(t, repo.Save(context.TODO(), "user1", *defaultURL))
+	assert.NoError(t, repo.Save(context.TODO(), "user1", *brandURL))
+	assert.ErrorIs(t, repo.Save(context.TODO(), "user1", *brandURL), ErrShortURLConflict)
+
+	reloaded, err := NewFileRepository(testConfig())
+	if err != nil {
+		t.Fatalf("Failed to reload repository: %v", err)
+	}
+	defer reloaded.fileStorage.Close()
+
+	result, err := reloaded.GetByShortURL(context.TODO(), "", "qwerty12")
+	assert.NoError(t, err)
+	assert.Equal(t, defaultURL, result)
+
+	result, err = reloaded.GetByShortURL(context.TODO(), "go.brand.com", "qwerty12")
+	assert.NoError(t, err)
+	assert.Equal(t, brandURL, result)
+
+	_, err = reloaded.GetByShortURL(context.TODO(), "brand.link", "qwerty12")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestFileRepositoryGetByOriginalURL(t *testing.T) {
+	repo, cleanup := setupFileRepository(t)
+	defer cleanup()
+
+	first := &model.URL{ShortURL: "qwerty12", OriginalURL: "https://Example.com", CanonicalURL: "https://example.com/"}
+	second := &model.URL{ShortURL: "qwerty13", Domain: "go.brand.com", OriginalURL: "https://example.com/"}
+	assert.NoError(t, repo.Save(context.TODO(), "user1", *first))
+	assert.NoError(t, repo.SaveBatch(context.TODO(), "user2", []model.URL{*second}))
+	assert.NoError(t, repo.SaveBundle(context.TODO(), "user1", model.Bundle{
+		ShortURL: "bundle12",
+		Links:    []model.BundleLink{{URL: "https://example.com/"}},
+	}))
+	assert.NoError(t, repo.Save(context.TODO(), "user1", model.URL{ShortURL: "qwerty14",
+		OriginalURL: "https://paypa1.tk/", ReviewReasons: []model.ReviewReason{model.ReviewSuspiciousTLD}}))
+
+	reloaded, err := NewFileRepository(testConfig())
+	if err != nil {
+		t.Fatalf("Failed to reload repository: %v", err)
+	}
+	defer reloaded.fileStorage.Close()
+
+	for _, r := range []*FileRepository{repo, reloaded} {
+		result, err := r.GetByOriginalURL(context.TODO(), "", "", "https://example.com/")
+		assert.NoError(t, err)
+		assert.Equal(t, &model.URL{ShortURL: "qwerty12", OriginalURL: "https://Example.com"}, result)
+
+		result, err = r.GetByOriginalURL(context.TODO(), "go.brand.com", "user2", "https://example.com/")
+		assert.NoError(t, err)
+		assert.Equal(t, second, result)
+
+		_, err = r.GetByOriginalURL(context.TODO(), "", "user2", "https://example.com/")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		_, err = r.GetByOriginalURL(context.TODO(), "", "", "https://paypa1.tk/")
+		assert.ErrorIs(t, err, ErrNotFound)
+	}
+}
+
+func TestFileRepositoryTrash(t *testing.T) {
+	repo, cleanup := setupFileRepository(t)
+	defer cleanup()
+
+	urls, err := repo.GetDeletedByUserID(context.TODO(), "user1")
+	assert.Error(t, err)
+	assert.Nil(t, urls)
+
+	err = repo.RestoreBatch(context.TODO(), "", "user1", []string{"qwerty12"})
+	assert.Error(t, err)
+	assert.Equal(t, "method not implemented", err.Error())
+
+	purged, err := repo.PurgeDeleted(context.TODO(), time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), purged)
+}
+
+func TestFileRepositoryWorkspaces(t *testing.T) {
+	repo, cleanup := setupFileRepository(t)
+	defer cleanup()
+
+	err := repo.CreateWorkspace(context.TODO(), *model.NewWorkspace("ws1", "Marketing"), "user1")
+	assert.Error(t, err)
+	assert.Equal(t, "method not implemented", err.Error())
+
+	workspaces, err := repo.GetWorkspacesByUserID(context.TODO(), "user1")
+	assert.Error(t, err)
+	assert.Nil(t, workspaces)
+
+	_, err = repo.GetWorkspaceRole(context.TODO(), "ws1", "user1")
+	assert.Error(t, err)
+
+	urls, err := repo.GetByWorkspaceID(context.TODO(), "ws1")
+	assert.Error(t, err)
+	assert.Nil(t, urls)
+
+	err = repo.DeleteWorkspaceBatch(context.TODO(), "", "ws1", []string{"qwerty12"})
+	assert.Error(t, err)
+
+	urls, err = repo.GetDeletedByWorkspaceID(context.TODO(), "ws1")
+	assert.Error(t, err)
+	assert.Nil(t, urls)
+
+	err = repo.RestoreWorkspaceBatch(context.TODO(), "", "ws1", []string{"qwerty12"})
+	assert.Error(t, err)
+
+	err = repo.UpdateWorkspaceURL(context.TODO(), "ws1", *model.NewURL("qwerty12", "https://practicum.yandex.ru/"))
+	assert.Error(t, err)
+}
+
+func TestFileRepositoryUsers(t *testing.T) {
+	repo, cleanup := setupFileRepository(t)
+	defer cleanup()
+
+	user := model.NewUser("user-1", "alice", "pbkdf2-sha256$1$c2FsdA$aGFzaA")
+	assert.NoError(t, repo.SaveUser(context.TODO(), *user))
+	assert.ErrorIs(t, repo.SaveUser(context.TODO(), *model.NewUser("user-2", "alice", "hash")), ErrLoginConflict)
+
+	found, err := repo.GetUserByLogin(context.TODO(), "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, user, found)
+
+	found, err = repo.GetUserByID(context.TODO(), "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, user, found)
+
+	_, err = repo.GetUserByLogin(context.TODO(), "bob")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = repo.GetUserByID(context.TODO(), "user-2")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, repo.MergeUserURLs(context.TODO(), "anonymous", "user-1"))
+
+	reloaded, err := NewFileRepository(testConfig())
+	if err != nil {
+		t.Fatalf("Failed to reload repository: %v", err)
+	}
+	defer reloaded.fileStorage.Close()
+
+	found, err = reloaded.GetUserByLogin(context.TODO(), "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, user, found)
+	assert.ErrorIs(t, reloaded.SaveUser(context.TODO(), *model.NewUser("user-3", "alice", "hash")), ErrLoginConflict)
+}
+
+func TestFileRepositoryTokenRevocations(t *testing.T) {
+	repo, cleanup := setupFileRepository(t)
+	defer cleanup()
+	now := time.Now()
+	revokedBefore := now.Truncate(time.Second)
+
+	assert.NoError(t, repo.SaveRevokedToken(context.TODO(), "token-1", now.Add(time.Hour)))
+	assert.NoError(t, repo.SaveRevokedToken(context.TODO(), "token-2", now.Add(-time.Hour)))
+	assert.NoError(t, repo.SaveUserTokensRevocation(context.TODO(), "user-1", revokedBefore, now.Add(time.Hour)))
+
+	reloaded, err := NewFileRepository(testConfig())
+	if err != nil {
+		t.Fatalf("Failed to reload repository: %v", err)
+	}
+	defer reloaded.fileStorage.Close()
+
+	revoked, err := reloaded.IsTokenRevoked(context.TODO(), "token-1", "user-2", now)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, err = reloaded.IsTokenRevoked(context.TODO(), "token-2", "user-2", now)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	revoked, err = reloaded.IsTokenRevoked(context.TODO(), "", "user-1", revokedBefore.Add(-time.Second))
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, err = reloaded.IsTokenRevoked(context.TODO(), "", "user-1", revokedBefore)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	purged, err := repo.PurgeExpiredRevocations(context.TODO(), now.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+	revoked, err = repo.IsTokenRevoked(context.TODO(), "token-1", "user-1", time.Time{})
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func TestFileRepositoryAPIKeys(t *testing.T) {
+	repo, cleanup := setupFileRepository(t)
+	defer cleanup()
+
+	createdAt := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	first := model.APIKey{ID: "key-1", UserID: "user-1", Name: "CI", Prefix: "shk_3q2-", KeyHash: "hash-1",
+		Scopes: []model.APIKeyScope{model.ScopeRead}, RateLimit: 60, CreatedAt: createdAt}
+	second := model.APIKey{ID: "key-2", UserID: "user-1", KeyHash: "hash-2", CreatedAt: createdAt.Add(time.Minute)}
+	other := model.APIKey{ID: "key-3", UserID: "user-2", KeyHash: "hash-3", CreatedAt: createdAt}
+	for _, key := range []model.APIKey{second, first, other} {
+		assert.NoError(t, repo.SaveAPIKey(context.TODO(), key))
+	}
+
+	keys, err := repo.GetAPIKeysByUserID(context.TODO(), "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.APIKey{first, second}, keys)
+
+	usedAt := createdAt.Add(time.Hour)
+	assert.NoError(t, repo.UpdateAPIKeyLastUsed(context.TODO(), "key-1", usedAt))
+	assert.NoError(t, repo.UpdateAPIKeyLastUsed(context.TODO(), "unknown", usedAt))
+	assert.ErrorIs(t, repo.DeleteAPIKey(context.TODO(), "user-2", "key-2"), ErrNotFound)
+	assert.NoError(t, repo.DeleteAPIKey(context.TODO(), "user-1", "key-2"))
+	_, err = repo.GetAPIKeyByHash(context.TODO(), "hash-2")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	reloaded, err := NewFileRepository(testConfig())
+	if err != nil {
+		t.Fatalf("Failed to reload repository: %v", err)
+	}
+	defer reloaded.fileStorage.Close()
+
+	found, err := reloaded.GetAPIKeyByHash(context.TODO(), "hash-1")
+	assert.NoError(t, err)
+	first.LastUsedAt = &usedAt
+	assert.Equal(t, &first, found)
+	_, err = reloaded.GetAPIKeyByHash(context.TODO(), "hash-2")
+	assert.ErrorIs(t, err, ErrNotFound)
+	keys, err = reloaded.GetAPIKeysByUserID(context.TODO(), "user-2")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.APIKey{other}, keys)
+}
+
+func TestFileRepositoryStats(t *testing.T) {
+	repo, cleanup := setupFileRepository(t)
+	defer cleanup()
+
+	assert.NoError(t, repo.Save(context.TODO(), "user1", model.URL{ShortURL: "qwerty12", OriginalURL: "https://a.com"}))
+	assert.NoError(t, repo.SaveBatch(context.TODO(), "user1", []model.URL{
+		{ShortURL: "qwerty13", OriginalURL: "https://b.com"},
+	}))
+	assert.NoError(t, repo.SaveBundle(context.TODO(), "user2",
+		*model.NewBundle("bundle12", "Links", []model.BundleLink{{Title: "A", URL: "https://a.com"}})))
+
+	stats, err := repo.GetStats(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, &model.Stats{URLs: 3, Users: 2}, stats)
+}
+
+func TestFileRepositoryQuota(t *testing.T) {
+	repo, cleanup := setupFileRepository(t)
+	defer cleanup()
+	today := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, repo.Save(context.TODO(), "user1", model.URL{ShortURL: "qwerty12", OriginalURL: "https://a.com"}))
+	assert.NoError(t, repo.Save(context.TODO(), "user2", model.URL{ShortURL: "qwerty13", OriginalURL: "https://b.com"}))
+	usage, reserved, err := repo.ReserveLinks(context.TODO(), "user1", today, 1, model.QuotaLimits{ActiveLinks: 2})
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+	assert.Equal(t, &model.QuotaUsage{LinksToday: 0, ActiveLinks: 1}, usage)
+	_, reserved, err = repo.ReserveLinks(context.TODO(), "user1", today, 1, model.QuotaLimits{ActiveLinks: 2})
+	assert.NoError(t, err)
+	assert.False(t, reserved)
+	assert.NoError(t, repo.ReleaseLinks(context.TODO(), "user1", today, 1, 0))
+
+	usage, err = repo.GetQuotaUsage(context.TODO(), "user1", today)
+	assert.NoError(t, err)
+	assert.Equal(t, &model.QuotaUsage{LinksToday: 1, ActiveLinks: 1}, usage)
+
+	limits, err := repo.GetUserQuotaLimits(context.TODO(), "user1")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, limits)
+	assert.Error(t, repo.SaveUserQuotaLimits(context.TODO(), "user1", model.QuotaLimits{LinksPerDay: 5}))
+}
+
 func TestFileRepositoryPing(t *testing.T) {
 	repo, cleanup := setupFileRepository(t)
 	defer cleanup()
@@ -189,10 +501,11 @@ func TestFileRepositorySaveShortURLDtoToStorage(t *testing.T) {
 		OriginalURL: "https://test.com",
 	}
 
-	dto, err := repo.saveShortURLDtoToStorage(url)
+	dto, err := repo.saveShortURLDtoToStorage("user1", url)
 	assert.NoError(t, err)
 	assert.Equal(t, url.ShortURL, dto.ShortURL)
 	assert.Equal(t, url.OriginalURL, dto.OriginalURL)
+	assert.Equal(t, "user1", dto.UserID)
 	assert.NotEmpty(t, dto.ID)
 }
 
@@ -200,6 +513,9 @@ func setupFileRepository(t *testing.T) (*FileRepository, func()) {
 	testCfg := testConfig()
 
 	_ = os.Remove(testCfg.FileStoragePath)
+	_ = os.Remove(testCfg.FileStoragePath + usersFileSuffix)
+	_ = os.Remove(testCfg.FileStoragePath + revocationsFileSuffix)
+	_ = os.Remove(testCfg.FileStoragePath + apiKeysFileSuffix)
 	repo, err := NewFileRepository(testCfg)
 	if err != nil {
 		t.Fatalf("Failed to create repository: %v", err)
@@ -214,6 +530,9 @@ func setupFileRepository(t *testing.T) (*FileRepository, func()) {
 		if err != nil {
 			t.Fatalf("Failed to remove file storage: %v", err)
 		}
+		_ = os.Remove(testCfg.FileStoragePath + usersFileSuffix)
+		_ = os.Remove(testCfg.FileStoragePath + revocationsFileSuffix)
+		_ = os.Remove(testCfg.FileStoragePath + apiKeysFileSuffix)
 	}
 
 	return repo, cleanup
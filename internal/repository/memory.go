@@ -5,15 +5,27 @@ import (
 	"context"
 	"fmt"
 	"github.com/bezjen/shortener/internal/model"
+	"sort"
 	"sync"
+	"time"
 )
 
 // InMemoryRepository implements Repository interface for in-memory storage.
 // It stores URL mappings in a concurrent map without persistence.
 // Suitable for testing and development environments.
 type InMemoryRepository struct {
-	storage map[string]string
-	mu      *sync.RWMutex
+	storage         map[shortURLKey]string
+	bundles         map[shortURLKey]model.Bundle
+	reviews         map[shortURLKey][]model.ReviewReason
+	originals       map[originalURLKey][]originalURLLink
+	creators        map[string]int64
+	dailyLinks      map[string]dailyLinks
+	quotaLimits     map[string]model.QuotaLimits
+	users           map[string]model.User
+	apiKeys         map[string]model.APIKey
+	revokedTokens   map[string]time.Time
+	userRevocations map[string]userTokensRevocation
+	mu              *sync.RWMutex
 }
 
 // NewInMemoryRepository creates a new InMemoryRepository instance.
@@ -23,28 +35,42 @@ type InMemoryRepository struct {
 //   - *InMemoryRepository: initialized in-memory repository
 func NewInMemoryRepository() *InMemoryRepository {
 	return &InMemoryRepository{
-		storage: make(map[string]string),
-		mu:      &sync.RWMutex{},
+		storage:         make(map[shortURLKey]string),
+		bundles:         make(map[shortURLKey]model.Bundle),
+		reviews:         make(map[shortURLKey][]model.ReviewReason),
+		originals:       make(map[originalURLKey][]originalURLLink),
+		creators:        make(map[string]int64),
+		dailyLinks:      make(map[string]dailyLinks),
+		quotaLimits:     make(map[string]model.QuotaLimits),
+		users:           make(map[string]model.User),
+		apiKeys:         make(map[string]model.APIKey),
+		revokedTokens:   make(map[string]time.Time),
+		userRevocations: make(map[string]userTokensRevocation),
+		mu:              &sync.RWMutex{},
 	}
 }
 
 // Save stores a URL mapping in memory.
-// Returns ErrShortURLConflict if the short URL already exists.
+// Returns ErrShortURLConflict if the short URL already exists in the URL domain.
 //
 // Parameters:
 //   - ctx: context for request cancellation and timeouts (not used)
-//   - userID: identifier of the user creating the URL (not used in memory storage)
+//   - userID: identifier of the user creating the URL, counted in statistics and quotas only
 //   - url: URL object containing short and original URLs
 //
 // Returns:
 //   - error: error if URL conflict occurs
-func (m *InMemoryRepository) Save(_ context.Context, _ string, url model.URL) error {
+func (m *InMemoryRepository) Save(_ context.Context, userID string, url model.URL) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	if _, exists := m.storage[url.ShortURL]; exists {
+	key := shortURLKey{domain: url.Domain, shortURL: url.ShortURL}
+	if m.exists(key) {
 		return ErrShortURLConflict
 	}
-	m.storage[url.ShortURL] = url.OriginalURL
+	m.storage[key] = url.OriginalURL
+	m.setReviewReasons(key, url.ReviewReasons)
+	m.indexOriginalURL(userID, url)
+	m.addCreator(userID, 1)
 	return nil
 }
 
@@ -53,43 +79,135 @@ func (m *InMemoryRepository) Save(_ context.Context, _ string, url model.URL) er
 //
 // Parameters:
 //   - ctx: context for request cancellation and timeouts (not used)
-//   - userID: identifier of the user creating the URLs (not used in memory storage)
+//   - userID: identifier of the user creating the URLs, counted in statistics and quotas only
 //   - urls: slice of URL objects to store
 //
 // Returns:
 //   - error: error if any URL conflict occurs
-func (m *InMemoryRepository) SaveBatch(_ context.Context, _ string, urls []model.URL) error {
+func (m *InMemoryRepository) SaveBatch(_ context.Context, userID string, urls []model.URL) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	for _, url := range urls {
-		if _, exists := m.storage[url.ShortURL]; exists {
+		if m.exists(shortURLKey{domain: url.Domain, shortURL: url.ShortURL}) {
 			return ErrShortURLConflict
 		}
 	}
 	for _, url := range urls {
-		m.storage[url.ShortURL] = url.OriginalURL
+		key := shortURLKey{domain: url.Domain, shortURL: url.ShortURL}
+		m.storage[key] = url.OriginalURL
+		m.setReviewReasons(key, url.ReviewReasons)
+		m.indexOriginalURL(userID, url)
 	}
+	if len(urls) > 0 {
+		m.addCreator(userID, int64(len(urls)))
+	}
+	return nil
+}
+
+// SaveBundle stores a bundle of links in memory.
+// Returns ErrShortURLConflict if the short identifier is taken by a URL or another bundle.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - userID: identifier of the user creating the bundle, counted in statistics and quotas only
+//   - bundle: bundle with short identifier, title and ordered links
+//
+// Returns:
+//   - error: error if short identifier conflict occurs
+func (m *InMemoryRepository) SaveBundle(_ context.Context, userID string, bundle model.Bundle) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := shortURLKey{domain: bundle.Domain, shortURL: bundle.ShortURL}
+	if m.exists(key) {
+		return ErrShortURLConflict
+	}
+	m.bundles[key] = bundle
+	m.setReviewReasons(key, bundle.ReviewReasons)
+	m.addCreator(userID, 1)
 	return nil
 }
 
-// GetByShortURL retrieves the original URL by its short identifier.
+// GetByShortURL retrieves the original URL by its short identifier within a domain.
 // Uses read lock for concurrent access.
 //
 // Parameters:
 //   - ctx: context for request cancellation and timeouts (not used)
+//   - domain: domain the short URL belongs to, empty for the base URL host
 //   - shortURL: short URL identifier to look up
 //
 // Returns:
 //   - *model.URL: found URL object
 //   - error: error if URL is not found
-func (m *InMemoryRepository) GetByShortURL(_ context.Context, shortURL string) (*model.URL, error) {
+func (m *InMemoryRepository) GetByShortURL(_ context.Context, domain string, shortURL string) (*model.URL, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	key := shortURLKey{domain: domain, shortURL: shortURL}
+	if _, isBundle := m.bundles[key]; isBundle {
+		return &model.URL{ShortURL: shortURL, Domain: domain, IsBundle: true, ReviewReasons: m.reviews[key]}, nil
+	}
+	storedURL, exists := m.storage[key]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	url := model.NewURL(shortURL, storedURL)
+	url.Domain = domain
+	url.ReviewReasons = m.reviews[key]
+	return url, nil
+}
+
+// GetByOriginalURL retrieves the earliest link to an original URL within a domain.
+// Links held for review are not returned.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - userID: owner of the link, empty to find links of any user
+//   - originalURL: original URL to look up, in the form compared for deduplication
+//
+// Returns:
+//   - *model.URL: found URL object
+//   - error: ErrNotFound if no link to the original URL exists
+func (m *InMemoryRepository) GetByOriginalURL(_ context.Context,
+	domain string,
+	userID string,
+	originalURL string,
+) (*model.URL, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	links := m.originals[originalURLKey{domain: domain, originalURL: originalURL}]
+	shortURL, found := findOriginalURLLink(links, userID, func(shortURL string) bool {
+		_, held := m.reviews[shortURLKey{domain: domain, shortURL: shortURL}]
+		return !held
+	})
+	if !found {
+		return nil, ErrNotFound
+	}
+	url := model.NewURL(shortURL, m.storage[shortURLKey{domain: domain, shortURL: shortURL}])
+	url.Domain = domain
+	return url, nil
+}
+
+// GetBundleByShortURL retrieves a bundle with its links by short identifier within a domain.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - domain: domain the bundle belongs to, empty for the base URL host
+//   - shortURL: short identifier of the bundle
+//
+// Returns:
+//   - *model.Bundle: found bundle
+//   - error: ErrNotFound if bundle does not exist
+func (m *InMemoryRepository) GetBundleByShortURL(_ context.Context,
+	domain string,
+	shortURL string,
+) (*model.Bundle, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	storedURL, exists := m.storage[shortURL]
+	bundle, exists := m.bundles[shortURLKey{domain: domain, shortURL: shortURL}]
 	if !exists {
 		return nil, ErrNotFound
 	}
-	return model.NewURL(shortURL, storedURL), nil
+	return &bundle, nil
 }
 
 // GetByUserID retrieves all URLs created by a specific user.
@@ -111,27 +229,859 @@ func (m *InMemoryRepository) GetByUserID(_ context.Context, _ string) ([]model.U
 //
 // Parameters:
 //   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URLs belong to, empty for the base URL host
 //   - userID: identifier of the user owning the URLs
 //   - shortURLs: slice of short URL identifiers to delete
 //
 // Returns:
 //   - error: always returns "method not implemented" error
-func (m *InMemoryRepository) DeleteBatch(_ context.Context, _ string, _ []string) error {
+func (m *InMemoryRepository) DeleteBatch(_ context.Context, _ string, _ string, _ []string) error {
 	return fmt.Errorf("method not implemented")
 }
 
-// Ping checks the connectivity to in-memory storage.
-// Always returns nil as in-memory storage is always available.
+// GetDeletedByUserID retrieves all deleted URLs of a specific user.
+// Not implemented for in-memory storage.
 //
 // Parameters:
 //   - ctx: context for request cancellation and timeouts
+//   - userID: user identifier to look up deleted URLs for
 //
 // Returns:
-//   - error: always nil for in-memory storage
-func (m *InMemoryRepository) Ping(_ context.Context) error {
+//   - []model.URL: slice of URLs (always empty for in-memory storage)
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) GetDeletedByUserID(_ context.Context, _ string) ([]model.URL, error) {
+	return nil, fmt.Errorf("method not implemented")
+}
+
+// RestoreBatch restores multiple deleted short URLs.
+// Not implemented for in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URLs belong to, empty for the base URL host
+//   - userID: identifier of the user owning the URLs
+//   - shortURLs: slice of short URL identifiers to restore
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) RestoreBatch(_ context.Context, _ string, _ string, _ []string) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// PurgeDeleted permanently removes deleted URLs.
+// In-memory storage does not support deletion, so there is nothing to purge.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - deletedBefore: URLs deleted before this time are removed
+//
+// Returns:
+//   - int64: always 0
+//   - error: always nil
+func (m *InMemoryRepository) PurgeDeleted(_ context.Context, _ time.Time) (int64, error) {
+	return 0, nil
+}
+
+// CreateWorkspace stores a new workspace.
+// Not implemented for in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - workspace: workspace with identifier and name
+//   - ownerID: identifier of the user becoming the workspace owner
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) CreateWorkspace(_ context.Context, _ model.Workspace, _ string) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// GetWorkspacesByUserID retrieves all workspaces of a user.
+// Not implemented for in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: user identifier to look up workspaces for
+//
+// Returns:
+//   - []model.Workspace: slice of workspaces (always empty for in-memory storage)
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) GetWorkspacesByUserID(_ context.Context, _ string) ([]model.Workspace, error) {
+	return nil, fmt.Errorf("method not implemented")
+}
+
+// GetWorkspaceRole retrieves the role of a user in a workspace.
+// Not implemented for in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - workspaceID: identifier of the workspace
+//   - userID: identifier of the user
+//
+// Returns:
+//   - model.WorkspaceRole: always empty for in-memory storage
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) GetWorkspaceRole(_ context.Context, _ string, _ string) (model.WorkspaceRole, error) {
+	return "", fmt.Errorf("method not implemented")
+}
+
+// GetWorkspaceMembers retrieves all members of a workspace.
+// Not implemented for in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - workspaceID: identifier of the workspace
+//
+// Returns:
+//   - []model.WorkspaceMember: slice of members (always empty for in-memory storage)
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) GetWorkspaceMembers(_ context.Context, _ string) ([]model.WorkspaceMember, error) {
+	return nil, fmt.Errorf("method not implemented")
+}
+
+// SaveWorkspaceMember adds or updates a workspace member.
+// Not implemented for in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - member: workspace member with role
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) SaveWorkspaceMember(_ context.Context, _ model.WorkspaceMember) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// DeleteWorkspaceMember removes a member from a workspace.
+// Not implemented for in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - workspaceID: identifier of the workspace
+//   - userID: identifier of the member to remove
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) DeleteWorkspaceMember(_ context.Context, _ string, _ string) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// GetByWorkspaceID retrieves all URLs of a workspace.
+// Not implemented for in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - workspaceID: identifier of the workspace
+//
+// Returns:
+//   - []model.URL: slice of URLs (always empty for in-memory storage)
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) GetByWorkspaceID(_ context.Context, _ string) ([]model.URL, error) {
+	return nil, fmt.Errorf("method not implemented")
+}
+
+// DeleteWorkspaceBatch marks multiple short URLs of a workspace as deleted.
+// Not implemented for in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URLs belong to, empty for the base URL host
+//   - workspaceID: identifier of the workspace owning the URLs
+//   - shortURLs: slice of short URL identifiers to delete
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) DeleteWorkspaceBatch(_ context.Context, _ string, _ string, _ []string) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// GetDeletedByWorkspaceID retrieves all deleted URLs of a workspace.
+// Not implemented for in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - workspaceID: identifier of the workspace
+//
+// Returns:
+//   - []model.URL: slice of URLs (always empty for in-memory storage)
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) GetDeletedByWorkspaceID(_ context.Context, _ string) ([]model.URL, error) {
+	return nil, fmt.Errorf("method not implemented")
+}
+
+// RestoreWorkspaceBatch restores multiple deleted short URLs of a workspace.
+// Not implemented for in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URLs belong to, empty for the base URL host
+//   - workspaceID: identifier of the workspace owning the URLs
+//   - shortURLs: slice of short URL identifiers to restore
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) RestoreWorkspaceBatch(_ context.Context, _ string, _ string, _ []string) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// UpdateWorkspaceURL changes the original URL of a short URL owned by a workspace.
+// Not implemented for in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - workspaceID: identifier of the workspace owning the URL
+//   - url: URL with the new original URL
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) UpdateWorkspaceURL(_ context.Context, _ string, _ model.URL) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// SaveUser stores a registered account in memory.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - user: user with identifier, login and password hash
+//
+// Returns:
+//   - error: ErrLoginConflict if the login is taken
+func (m *InMemoryRepository) SaveUser(_ context.Context, user model.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, existing := range m.users {
+		if existing.Login == user.Login {
+			return ErrLoginConflict
+		}
+	}
+	m.users[user.ID] = user
+	return nil
+}
+
+// GetUserByLogin retrieves a registered account by its login.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - login: login of the account
+//
+// Returns:
+//   - *model.User: found user
+//   - error: ErrNotFound if no account has the login
+func (m *InMemoryRepository) GetUserByLogin(_ context.Context, login string) (*model.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, user := range m.users {
+		if user.Login == login {
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// GetUserByID retrieves a registered account by its identifier.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - id: identifier of the account
+//
+// Returns:
+//   - *model.User: found user
+//   - error: ErrNotFound if the identifier belongs to no account
+func (m *InMemoryRepository) GetUserByID(_ context.Context, id string) (*model.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	user, exists := m.users[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &user, nil
+}
+
+// MergeUserURLs moves URLs of one user to another.
+// In-memory storage does not track URL owners, so there is nothing to move.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - fromUserID: identifier of the user giving up the URLs (not used)
+//   - toUserID: identifier of the user receiving the URLs (not used)
+//
+// Returns:
+//   - error: always nil
+func (m *InMemoryRepository) MergeUserURLs(_ context.Context, _ string, _ string) error {
+	return nil
+}
+
+// SaveAPIKey stores a new API key of a user in memory.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - key: API key with identifier, owner, key hash and scopes
+//
+// Returns:
+//   - error: always nil
+func (m *InMemoryRepository) SaveAPIKey(_ context.Context, key model.APIKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.apiKeys[key.ID] = key
 	return nil
 }
 
+// GetAPIKeysByUserID retrieves all API keys of a user ordered by creation time.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - userID: user identifier to look up keys for
+//
+// Returns:
+//   - []model.APIKey: slice of keys of the user
+//   - error: always nil
+func (m *InMemoryRepository) GetAPIKeysByUserID(_ context.Context, userID string) ([]model.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var keys []model.APIKey
+	for _, key := range m.apiKeys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+// GetAPIKeyByHash retrieves an API key by the hash of the key.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - keyHash: hex-encoded SHA-256 digest of the key
+//
+// Returns:
+//   - *model.APIKey: found key
+//   - error: ErrNotFound if no key has the hash
+func (m *InMemoryRepository) GetAPIKeyByHash(_ context.Context, keyHash string) (*model.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, key := range m.apiKeys {
+		if key.KeyHash == keyHash {
+			return &key, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// DeleteAPIKey removes an API key of a user from memory.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - userID: identifier of the user owning the key
+//   - id: identifier of the key
+//
+// Returns:
+//   - error: ErrNotFound if the user has no such key
+func (m *InMemoryRepository) DeleteAPIKey(_ context.Context, userID string, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, exists := m.apiKeys[id]
+	if !exists || key.UserID != userID {
+		return ErrNotFound
+	}
+	delete(m.apiKeys, id)
+	return nil
+}
+
+// UpdateAPIKeyLastUsed records the time an API key was last used.
+// Unknown keys are ignored.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - id: identifier of the key
+//   - usedAt: time the key was used
+//
+// Returns:
+//   - error: always nil
+func (m *InMemoryRepository) UpdateAPIKeyLastUsed(_ context.Context, id string, usedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, exists := m.apiKeys[id]
+	if !exists {
+		return nil
+	}
+	key.LastUsedAt = &usedAt
+	m.apiKeys[id] = key
+	return nil
+}
+
+// SaveRevokedToken revokes a single token by its identifier.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - tokenID: jti claim of the token
+//   - expiresAt: expiration time of the token
+//
+// Returns:
+//   - error: always nil
+func (m *InMemoryRepository) SaveRevokedToken(_ context.Context, tokenID string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.revokedTokens[tokenID] = expiresAt
+	return nil
+}
+
+// IsTokenRevoked reports whether a token was revoked by itself or together with all tokens of its user,
+// and the revocation has not expired yet.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - tokenID: jti claim of the token, empty for tokens without one
+//   - userID: identifier of the user the token was issued to
+//   - issuedAt: issue time of the token, zero for tokens without one
+//
+// Returns:
+//   - bool: true if the token is revoked
+//   - error: always nil
+func (m *InMemoryRepository) IsTokenRevoked(_ context.Context,
+	tokenID string,
+	userID string,
+	issuedAt time.Time,
+) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return isTokenRevoked(m.revokedTokens, m.userRevocations, tokenID, userID, issuedAt, time.Now()), nil
+}
+
+// SaveUserTokensRevocation revokes all tokens of a user issued before the given time.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - userID: identifier of the user
+//   - revokedBefore: tokens issued before this time are revoked
+//   - expiresAt: time all revoked tokens have expired by
+//
+// Returns:
+//   - error: always nil
+func (m *InMemoryRepository) SaveUserTokensRevocation(_ context.Context,
+	userID string,
+	revokedBefore time.Time,
+	expiresAt time.Time,
+) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.userRevocations[userID] = userTokensRevocation{revokedBefore: revokedBefore, expiresAt: expiresAt}
+	return nil
+}
+
+// PurgeExpiredRevocations removes revocations of tokens that have expired.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - now: revocations expiring before this time are removed
+//
+// Returns:
+//   - int64: number of removed revocations
+//   - error: always nil
+func (m *InMemoryRepository) PurgeExpiredRevocations(_ context.Context, now time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var purged int64
+	for tokenID, expiresAt := range m.revokedTokens {
+		if expiresAt.Before(now) {
+			delete(m.revokedTokens, tokenID)
+			purged++
+		}
+	}
+	for userID, revocation := range m.userRevocations {
+		if revocation.expiresAt.Before(now) {
+			delete(m.userRevocations, userID)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// SearchURLs finds links of all users for administrators.
+// Not implemented for in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - filter: search criteria with pagination
+//
+// Returns:
+//   - []model.AdminURL: always nil
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) SearchURLs(_ context.Context, _ model.URLSearchFilter) ([]model.AdminURL, error) {
+	return nil, fmt.Errorf("method not implemented")
+}
+
+// SetURLDisabled disables a link or enables it again.
+// Not implemented for in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - shortURL: short URL identifier
+//   - disabled: true to disable the link, false to enable it
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) SetURLDisabled(_ context.Context, _ string, _ string, _ bool) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// ClearURLReview clears the phishing review reasons of a link after an administrator checked it.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - shortURL: short URL identifier
+//
+// Returns:
+//   - error: ErrNotFound if the link does not exist
+func (m *InMemoryRepository) ClearURLReview(_ context.Context, domain string, shortURL string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := shortURLKey{domain: domain, shortURL: shortURL}
+	if !m.exists(key) {
+		return ErrNotFound
+	}
+	delete(m.reviews, key)
+	return nil
+}
+
+// SaveAbuseReport adds a report of an abusive link to the moderation queue.
+// Not implemented for in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - report: open report with identifier and reported link
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) SaveAbuseReport(_ context.Context, _ model.AbuseReport) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// GetAbuseReports lists abuse reports in a state, oldest first.
+// Not implemented for in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - filter: state of the reports with pagination
+//
+// Returns:
+//   - []model.AbuseReport: always nil
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) GetAbuseReports(_ context.Context, _ model.AbuseReportFilter) ([]model.AbuseReport, error) {
+	return nil, fmt.Errorf("method not implemented")
+}
+
+// GetAbuseReport retrieves a single abuse report.
+// Not implemented for in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - id: identifier of the report
+//
+// Returns:
+//   - *model.AbuseReport: always nil
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) GetAbuseReport(_ context.Context, _ string) (*model.AbuseReport, error) {
+	return nil, fmt.Errorf("method not implemented")
+}
+
+// DismissAbuseReport marks an open abuse report as dismissed.
+// Not implemented for in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - id: identifier of the report
+//   - adminID: identifier of the administrator resolving the report
+//   - resolvedAt: time of the resolution
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) DismissAbuseReport(_ context.Context, _ string, _ string, _ time.Time) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// TakeDownURL disables a link on the given ground and marks its open abuse reports as actioned.
+// Not implemented for in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - shortURL: short URL identifier
+//   - reason: ground for the takedown
+//   - adminID: identifier of the administrator taking the link down
+//   - resolvedAt: time of the takedown
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) TakeDownURL(_ context.Context,
+	_ string,
+	_ string,
+	_ model.TakedownReason,
+	_ string,
+	_ time.Time,
+) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// GetURLsForHealthCheck lists links whose destinations are due for a health check.
+// Not implemented for in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - checkedBefore: links checked at or after this time are not due
+//   - limit: maximum number of links to return
+//
+// Returns:
+//   - []model.URL: always nil
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) GetURLsForHealthCheck(_ context.Context, _ time.Time, _ int) ([]model.URL, error) {
+	return nil, fmt.Errorf("method not implemented")
+}
+
+// SaveURLHealth records the result of a destination health check.
+// Not implemented for in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - shortURL: short URL identifier
+//   - health: result of the check
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) SaveURLHealth(_ context.Context, _ string, _ string, _ model.URLHealth) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// SaveURLMetadata stores the title, description and preview image fetched from a link destination.
+// Not implemented for in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - shortURL: short URL identifier
+//   - metadata: fetched metadata
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) SaveURLMetadata(_ context.Context, _ string, _ string, _ model.URLMetadata) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// SetUserBanned bans a user or lifts the ban.
+// Not implemented for in-memory storage as it doesn't track link ownership.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//   - banned: true to ban the user, false to lift the ban
+//
+// Returns:
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) SetUserBanned(_ context.Context, _ string, _ bool) error {
+	return fmt.Errorf("method not implemented")
+}
+
+// IsUserBanned reports whether a user is banned.
+// Users cannot be banned in in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//
+// Returns:
+//   - bool: always false
+//   - error: always nil
+func (m *InMemoryRepository) IsUserBanned(_ context.Context, _ string) (bool, error) {
+	return false, nil
+}
+
+// GetUserUsage counts links and API keys of a user.
+// Not implemented for in-memory storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//
+// Returns:
+//   - *model.UserUsage: always nil
+//   - error: always returns "method not implemented" error
+func (m *InMemoryRepository) GetUserUsage(_ context.Context, _ string) (*model.UserUsage, error) {
+	return nil, fmt.Errorf("method not implemented")
+}
+
+// GetStats counts links and distinct users who created links.
+// In-memory storage does not delete links, so all links are counted.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//
+// Returns:
+//   - *model.Stats: link and user counts
+//   - error: always nil
+func (m *InMemoryRepository) GetStats(_ context.Context) (*model.Stats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return &model.Stats{
+		URLs:  int64(len(m.storage) + len(m.bundles)),
+		Users: int64(len(m.creators)),
+	}, nil
+}
+
+// GetQuotaUsage retrieves the quota counters of a user from memory.
+// In-memory storage does not delete links, so all links of the user are active.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - userID: identifier of the user
+//   - day: UTC day the created links are counted for
+//
+// Returns:
+//   - *model.QuotaUsage: quota counters of the user, zero counts for unknown users
+//   - error: always nil
+func (m *InMemoryRepository) GetQuotaUsage(_ context.Context, userID string, day time.Time) (*model.QuotaUsage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return &model.QuotaUsage{
+		LinksToday:  m.dailyLinks[userID].linksIn(day),
+		ActiveLinks: m.creators[userID],
+	}, nil
+}
+
+// ReserveLinks checks the limits of a user and counts links the user is about to create under a single lock.
+// The counter of an earlier day is replaced.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - userID: identifier of the user
+//   - day: UTC day the links are created in
+//   - count: number of links to create
+//   - limits: limits of the user, zero limits are unlimited
+//
+// Returns:
+//   - *model.QuotaUsage: usage before the reservation, pending links of other requests included
+//   - bool: false if a limit would be exceeded and nothing was reserved
+//   - error: always nil
+func (m *InMemoryRepository) ReserveLinks(_ context.Context,
+	userID string,
+	day time.Time,
+	count int64,
+	limits model.QuotaLimits,
+) (*model.QuotaUsage, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counter, usage, reserved := m.dailyLinks[userID].reserve(day, count, limits, m.creators[userID])
+	m.dailyLinks[userID] = counter
+	return usage, reserved, nil
+}
+
+// ReleaseLinks ends a reservation made by ReserveLinks.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - userID: identifier of the user
+//   - day: UTC day the links were reserved in
+//   - reserved: number of reserved links
+//   - unused: number of reserved links that were not created
+//
+// Returns:
+//   - error: always nil
+func (m *InMemoryRepository) ReleaseLinks(_ context.Context,
+	userID string,
+	day time.Time,
+	reserved int64,
+	unused int64,
+) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dailyLinks[userID] = m.dailyLinks[userID].release(day, reserved, unused)
+	return nil
+}
+
+// GetUserQuotaLimits retrieves the limits assigned to a single user from memory.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - userID: identifier of the user
+//
+// Returns:
+//   - *model.QuotaLimits: limits of the user
+//   - error: ErrNotFound if the user has the default limits
+func (m *InMemoryRepository) GetUserQuotaLimits(_ context.Context, userID string) (*model.QuotaLimits, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	limits, exists := m.quotaLimits[userID]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &limits, nil
+}
+
+// SaveUserQuotaLimits stores the limits of a single user in memory.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts (not used)
+//   - userID: identifier of the user
+//   - limits: limits of the user, zero limits are unlimited
+//
+// Returns:
+//   - error: always nil
+func (m *InMemoryRepository) SaveUserQuotaLimits(_ context.Context, userID string, limits model.QuotaLimits) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.quotaLimits[userID] = limits
+	return nil
+}
+
+// Ping checks the connectivity to in-memory storage.
+// Always returns nil as in-memory storage is always available.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//
+// Returns:
+//   - error: always nil for in-memory storage
+func (m *InMemoryRepository) Ping(_ context.Context) error {
+	return nil
+}
+
+// addCreator counts links of a user for statistics and quotas.
+// Must be called with the mutex held.
+func (m *InMemoryRepository) addCreator(userID string, links int64) {
+	if userID != "" {
+		m.creators[userID] += links
+	}
+}
+
+// setReviewReasons stores the review reasons of a link, links without reasons are not kept.
+// Must be called with the mutex held.
+func (m *InMemoryRepository) setReviewReasons(key shortURLKey, reasons []model.ReviewReason) {
+	if len(reasons) > 0 {
+		m.reviews[key] = reasons
+	}
+}
+
+// indexOriginalURL adds a link to the index of original URLs compared for deduplication.
+// Must be called with the mutex held.
+func (m *InMemoryRepository) indexOriginalURL(userID string, url model.URL) {
+	key := originalURLKey{domain: url.Domain, originalURL: url.DedupURL()}
+	m.originals[key] = append(m.originals[key], originalURLLink{shortURL: url.ShortURL, userID: userID})
+}
+
+// exists reports whether the short identifier is taken by a URL or a bundle in its domain.
+// Must be called with the mutex held.
+func (m *InMemoryRepository) exists(key shortURLKey) bool {
+	if _, exists := m.storage[key]; exists {
+		return true
+	}
+	_, exists := m.bundles[key]
+	return exists
+}
+
 // Close releases resources used by the in-memory repository.
 // For in-memory storage, this is a no-op that always returns nil.
 //
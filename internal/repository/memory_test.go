@@ -6,6 +6,7 @@ import (
 	"github.com/bezjen/shortener/internal/model"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestInMemoryRepositorySuccess(t *testing.T) {
@@ -26,7 +27,7 @@ func TestInMemoryRepositorySuccess(t *testing.T) {
 			if err != nil {
 				t.Fatalf("Save failed: %v", err)
 			}
-			result, err := repo.GetByShortURL(context.TODO(), tt.url.ShortURL)
+			result, err := repo.GetByShortURL(context.TODO(), "", tt.url.ShortURL)
 			if err != nil {
 				t.Fatalf("GetByShortURL failed: %v", err)
 			}
@@ -57,7 +58,7 @@ func TestInMemoryRepositorySaveBatchSuccess(t *testing.T) {
 				t.Fatalf("Save failed: %v", err)
 			}
 			for _, url := range tt.batch {
-				result, errRepo := repo.GetByShortURL(context.TODO(), url.ShortURL)
+				result, errRepo := repo.GetByShortURL(context.TODO(), "", url.ShortURL)
 				if errRepo != nil {
 					t.Fatalf("GetByShortURL failed: %v", errRepo)
 				}
@@ -93,7 +94,7 @@ func TestInMemoryRepositoryErrConflict(t *testing.T) {
 	}
 
 	t.Run("Get not-existed OriginalURL (ErrNotFound)", func(t *testing.T) {
-		_, err := repo.GetByShortURL(context.TODO(), "non-existent")
+		_, err := repo.GetByShortURL(context.TODO(), "", "non-existent")
 		if !errors.Is(err, ErrNotFound) {
 			t.Errorf("got %v, want %v", err, ErrNotFound)
 		}
@@ -128,7 +129,7 @@ func TestInMemoryRepositorySaveBatchErrConflict(t *testing.T) {
 	}
 
 	t.Run("Get not-existed OriginalURL (ErrNotFound)", func(t *testing.T) {
-		_, err := repo.GetByShortURL(context.TODO(), "non-existent")
+		_, err := repo.GetByShortURL(context.TODO(), "", "non-existent")
 		if !errors.Is(err, ErrNotFound) {
 			t.Errorf("got %v, want %v", err, ErrNotFound)
 		}
@@ -139,7 +140,7 @@ func TestInMemoryRepositoryErrNotFound(t *testing.T) {
 	repo := NewInMemoryRepository()
 
 	t.Run("Get not-existed OriginalURL (ErrNotFound)", func(t *testing.T) {
-		_, err := repo.GetByShortURL(context.TODO(), "non-existent")
+		_, err := repo.GetByShortURL(context.TODO(), "", "non-existent")
 		if !errors.Is(err, ErrNotFound) {
 			t.Errorf("got %v, want %v", err, ErrNotFound)
 		}
@@ -158,11 +159,359 @@ func TestInMemoryRepositoryGetByUserID(t *testing.T) {
 func TestInMemoryRepositoryDeleteBatch(t *testing.T) {
 	repo := NewInMemoryRepository()
 
-	err := repo.DeleteBatch(context.TODO(), "user1", []string{"qwerty12"})
+	err := repo.DeleteBatch(context.TODO(), "", "user1", []string{"qwerty12"})
 	assert.Error(t, err)
 	assert.Equal(t, "method not implemented", err.Error())
 }
 
+func TestInMemoryRepositoryBundle(t *testing.T) {
+	repo := NewInMemoryRepository()
+
+	bundle := model.NewBundle("qwerty12", "Conference", []model.BundleLink{
+		{Title: "Slides", URL: "https://example.com/slides"},
+		{Title: "Video", URL: "https://example.com/video"},
+	})
+
+	err := repo.SaveBundle(context.TODO(), "user1", *bundle)
+	assert.NoError(t, err)
+
+	url, err := repo.GetByShortURL(context.TODO(), "", "qwerty12")
+	assert.NoError(t, err)
+	assert.True(t, url.IsBundle)
+
+	stored, err := repo.GetBundleByShortURL(context.TODO(), "", "qwerty12")
+	assert.NoError(t, err)
+	assert.Equal(t, bundle, stored)
+
+	err = repo.Save(context.TODO(), "user1", *model.NewURL("qwerty12", "https://example.com"))
+	assert.ErrorIs(t, err, ErrShortURLConflict)
+
+	err = repo.SaveBundle(context.TODO(), "user1", *bundle)
+	assert.ErrorIs(t, err, ErrShortURLConflict)
+
+	_, err = repo.GetBundleByShortURL(context.TODO(), "", "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	flagged := model.NewBundle("qwerty13", "Flagged", []model.BundleLink{{Title: "IP", URL: "http://1.2.3.4"}})
+	flagged.ReviewReasons = []model.ReviewReason{model.ReviewIPHost}
+	assert.NoError(t, repo.SaveBundle(context.TODO(), "user1", *flagged))
+	url, err = repo.GetByShortURL(context.TODO(), "", "qwerty13")
+	assert.NoError(t, err)
+	assert.True(t, url.IsBundle)
+	assert.Equal(t, flagged.ReviewReasons, url.ReviewReasons)
+}
+
+func TestInMemoryRepositoryDomains(t *testing.T) {
+	repo := NewInMemoryRepository()
+
+	defaultURL := model.NewURL("qwerty12", "https://practicum.yandex.ru/")
+	brandURL := &model.URL{ShortURL: "qwerty12", Domain: "go.brand.com", OriginalURL: "https://brand.com/"}
+
+	assert.NoError(t, repo.Save(context.TODO(), "user1", *defaultURL))
+	assert.NoError(t, repo.Save(context.TODO(), "user1", *brandURL))
+	assert.ErrorIs(t, repo.SaveBatch(context.TODO(), "user1", []model.URL{*brandURL}), ErrShortURLConflict)
+
+	result, err := repo.GetByShortURL(context.TODO(), "", "qwerty12")
+	assert.NoError(t, err)
+	assert.Equal(t, defaultURL, result)
+
+	result, err = repo.GetByShortURL(context.TODO(), "go.brand.com", "qwerty12")
+	assert.NoError(t, err)
+	assert.Equal(t, brandURL, result)
+
+	_, err = repo.GetByShortURL(context.TODO(), "brand.link", "qwerty12")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestInMemoryRepositoryGetByOriginalURL(t *testing.T) {
+	repo := NewInMemoryRepository()
+
+	first := &model.URL{ShortURL: "qwerty12", OriginalURL: "https://Example.com", CanonicalURL: "https://example.com/"}
+	second := &model.URL{ShortURL: "qwerty13", OriginalURL: "https://example.com/"}
+	assert.NoError(t, repo.Save(context.TODO(), "user1", *first))
+	assert.NoError(t, repo.SaveBatch(context.TODO(), "user2", []model.URL{*second}))
+
+	result, err := repo.GetByOriginalURL(context.TODO(), "", "", "https://example.com/")
+	assert.NoError(t, err)
+	assert.Equal(t, &model.URL{ShortURL: "qwerty12", OriginalURL: "https://Example.com"}, result)
+
+	result, err = repo.GetByOriginalURL(context.TODO(), "", "user2", "https://example.com/")
+	assert.NoError(t, err)
+	assert.Equal(t, "qwerty13", result.ShortURL)
+
+	_, err = repo.GetByOriginalURL(context.TODO(), "", "user3", "https://example.com/")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = repo.GetByOriginalURL(context.TODO(), "go.brand.com", "", "https://example.com/")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	held := model.URL{ShortURL: "qwerty14", OriginalURL: "https://paypa1.tk/",
+		ReviewReasons: []model.ReviewReason{model.ReviewSuspiciousTLD}}
+	assert.NoError(t, repo.Save(context.TODO(), "user1", held))
+	_, err = repo.GetByOriginalURL(context.TODO(), "", "", "https://paypa1.tk/")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, repo.ClearURLReview(context.TODO(), "", "qwerty14"))
+	result, err = repo.GetByOriginalURL(context.TODO(), "", "", "https://paypa1.tk/")
+	assert.NoError(t, err)
+	assert.Equal(t, "qwerty14", result.ShortURL)
+}
+
+func TestInMemoryRepositoryTrash(t *testing.T) {
+	repo := NewInMemoryRepository()
+
+	urls, err := repo.GetDeletedByUserID(context.TODO(), "user1")
+	assert.Error(t, err)
+	assert.Nil(t, urls)
+
+	err = repo.RestoreBatch(context.TODO(), "", "user1", []string{"qwerty12"})
+	assert.Error(t, err)
+	assert.Equal(t, "method not implemented", err.Error())
+
+	purged, err := repo.PurgeDeleted(context.TODO(), time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), purged)
+}
+
+func TestInMemoryRepositoryWorkspaces(t *testing.T) {
+	repo := NewInMemoryRepository()
+
+	err := repo.CreateWorkspace(context.TODO(), *model.NewWorkspace("ws1", "Marketing"), "user1")
+	assert.Error(t, err)
+	assert.Equal(t, "method not implemented", err.Error())
+
+	workspaces, err := repo.GetWorkspacesByUserID(context.TODO(), "user1")
+	assert.Error(t, err)
+	assert.Nil(t, workspaces)
+
+	_, err = repo.GetWorkspaceRole(context.TODO(), "ws1", "user1")
+	assert.Error(t, err)
+
+	urls, err := repo.GetByWorkspaceID(context.TODO(), "ws1")
+	assert.Error(t, err)
+	assert.Nil(t, urls)
+
+	err = repo.DeleteWorkspaceBatch(context.TODO(), "", "ws1", []string{"qwerty12"})
+	assert.Error(t, err)
+
+	urls, err = repo.GetDeletedByWorkspaceID(context.TODO(), "ws1")
+	assert.Error(t, err)
+	assert.Nil(t, urls)
+
+	err = repo.RestoreWorkspaceBatch(context.TODO(), "", "ws1", []string{"qwerty12"})
+	assert.Error(t, err)
+
+	err = repo.UpdateWorkspaceURL(context.TODO(), "ws1", *model.NewURL("qwerty12", "https://practicum.yandex.ru/"))
+	assert.Error(t, err)
+}
+
+func TestInMemoryRepositoryModeration(t *testing.T) {
+	repo := NewInMemoryRepository()
+
+	urls, err := repo.SearchURLs(context.TODO(), model.URLSearchFilter{Limit: 10})
+	assert.Error(t, err)
+	assert.Nil(t, urls)
+
+	err = repo.SetURLDisabled(context.TODO(), "", "qwerty12", true)
+	assert.Equal(t, "method not implemented", err.Error())
+
+	err = repo.SetUserBanned(context.TODO(), "user1", true)
+	assert.Error(t, err)
+
+	banned, err := repo.IsUserBanned(context.TODO(), "user1")
+	assert.NoError(t, err)
+	assert.False(t, banned)
+
+	usage, err := repo.GetUserUsage(context.TODO(), "user1")
+	assert.Error(t, err)
+	assert.Nil(t, usage)
+}
+
+func TestInMemoryRepositoryUsers(t *testing.T) {
+	repo := NewInMemoryRepository()
+
+	user := model.NewUser("user-1", "alice", "pbkdf2-sha256$1$c2FsdA$aGFzaA")
+	assert.NoError(t, repo.SaveUser(context.TODO(), *user))
+	assert.ErrorIs(t, repo.SaveUser(context.TODO(), *model.NewUser("user-2", "alice", "hash")), ErrLoginConflict)
+
+	found, err := repo.GetUserByLogin(context.TODO(), "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, user, found)
+
+	found, err = repo.GetUserByID(context.TODO(), "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, user, found)
+
+	_, err = repo.GetUserByLogin(context.TODO(), "bob")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = repo.GetUserByID(context.TODO(), "user-2")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, repo.MergeUserURLs(context.TODO(), "anonymous", "user-1"))
+}
+
+func TestInMemoryRepositoryAPIKeys(t *testing.T) {
+	repo := NewInMemoryRepository()
+
+	createdAt := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	first := model.APIKey{ID: "key-1", UserID: "user-1", KeyHash: "hash-1", CreatedAt: createdAt}
+	second := model.APIKey{ID: "key-2", UserID: "user-1", KeyHash: "hash-2", CreatedAt: createdAt.Add(time.Minute)}
+	other := model.APIKey{ID: "key-3", UserID: "user-2", KeyHash: "hash-3", CreatedAt: createdAt}
+	for _, key := range []model.APIKey{second, first, other} {
+		assert.NoError(t, repo.SaveAPIKey(context.TODO(), key))
+	}
+
+	keys, err := repo.GetAPIKeysByUserID(context.TODO(), "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.APIKey{first, second}, keys)
+
+	usedAt := createdAt.Add(time.Hour)
+	assert.NoError(t, repo.UpdateAPIKeyLastUsed(context.TODO(), "key-1", usedAt))
+	found, err := repo.GetAPIKeyByHash(context.TODO(), "hash-1")
+	assert.NoError(t, err)
+	assert.Equal(t, usedAt, *found.LastUsedAt)
+
+	assert.ErrorIs(t, repo.DeleteAPIKey(context.TODO(), "user-2", "key-1"), ErrNotFound)
+	assert.NoError(t, repo.DeleteAPIKey(context.TODO(), "user-1", "key-1"))
+	_, err = repo.GetAPIKeyByHash(context.TODO(), "hash-1")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestInMemoryRepositoryTokenRevocations(t *testing.T) {
+	repo := NewInMemoryRepository()
+	now := time.Now()
+
+	assert.NoError(t, repo.SaveRevokedToken(context.TODO(), "token-1", now.Add(time.Hour)))
+	assert.NoError(t, repo.SaveRevokedToken(context.TODO(), "token-2", now.Add(-time.Hour)))
+	revoked, err := repo.IsTokenRevoked(context.TODO(), "token-1", "user-1", now)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, err = repo.IsTokenRevoked(context.TODO(), "token-2", "user-1", now)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	revokedBefore := now.Truncate(time.Second)
+	assert.NoError(t, repo.SaveUserTokensRevocation(context.TODO(), "user-1", revokedBefore, now.Add(time.Hour)))
+	revoked, err = repo.IsTokenRevoked(context.TODO(), "", "user-1", revokedBefore.Add(-time.Second))
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, err = repo.IsTokenRevoked(context.TODO(), "", "user-1", time.Time{})
+	assert.NoError(t, err)
+	assert.True(t, revoked, "Tokens without issue time should be revoked with their user")
+	revoked, err = repo.IsTokenRevoked(context.TODO(), "", "user-1", revokedBefore)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	revoked, err = repo.IsTokenRevoked(context.TODO(), "", "user-2", revokedBefore.Add(-time.Second))
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	purged, err := repo.PurgeExpiredRevocations(context.TODO(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	purged, err = repo.PurgeExpiredRevocations(context.TODO(), now.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+}
+
+func TestInMemoryRepositoryStats(t *testing.T) {
+	repo := NewInMemoryRepository()
+
+	assert.NoError(t, repo.Save(context.TODO(), "user1", model.URL{ShortURL: "qwerty12", OriginalURL: "https://a.com"}))
+	assert.NoError(t, repo.SaveBatch(context.TODO(), "user2", []model.URL{
+		{ShortURL: "qwerty13", OriginalURL: "https://b.com"},
+		{ShortURL: "qwerty14", OriginalURL: "https://c.com"},
+	}))
+	assert.NoError(t, repo.SaveBundle(context.TODO(), "user1",
+		*model.NewBundle("bundle12", "Links", []model.BundleLink{{Title: "A", URL: "https://a.com"}})))
+
+	stats, err := repo.GetStats(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, &model.Stats{URLs: 4, Users: 2}, stats)
+}
+
+func TestInMemoryRepositoryQuota(t *testing.T) {
+	repo := NewInMemoryRepository()
+	today := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	assert.NoError(t, repo.Save(context.TODO(), "user1", model.URL{ShortURL: "qwerty12", OriginalURL: "https://a.com"}))
+	assert.NoError(t, repo.SaveBatch(context.TODO(), "user1", []model.URL{
+		{ShortURL: "qwerty13", OriginalURL: "https://b.com"},
+		{ShortURL: "qwerty14", OriginalURL: "https://c.com"},
+	}))
+	planLimits := model.QuotaLimits{LinksPerDay: 5, ActiveLinks: 7}
+	usage, reserved, err := repo.ReserveLinks(context.TODO(), "user1", today, 3, planLimits)
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+	assert.Equal(t, &model.QuotaUsage{LinksToday: 0, ActiveLinks: 3}, usage)
+
+	usage, reserved, err = repo.ReserveLinks(context.TODO(), "user1", today, 2, planLimits)
+	assert.NoError(t, err)
+	assert.False(t, reserved, "pending links are counted as active links")
+	assert.Equal(t, &model.QuotaUsage{LinksToday: 3, ActiveLinks: 6}, usage)
+
+	assert.NoError(t, repo.ReleaseLinks(context.TODO(), "user1", today, 3, 0))
+	usage, err = repo.GetQuotaUsage(context.TODO(), "user1", today)
+	assert.NoError(t, err)
+	assert.Equal(t, &model.QuotaUsage{LinksToday: 3, ActiveLinks: 3}, usage)
+
+	_, reserved, err = repo.ReserveLinks(context.TODO(), "user1", today, 3, planLimits)
+	assert.NoError(t, err)
+	assert.False(t, reserved, "daily limit")
+	_, reserved, err = repo.ReserveLinks(context.TODO(), "user1", today, 2, planLimits)
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+	assert.NoError(t, repo.ReleaseLinks(context.TODO(), "user1", today, 2, 2))
+	usage, err = repo.GetQuotaUsage(context.TODO(), "user1", today)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), usage.LinksToday, "links that were not created are not counted")
+
+	usage, err = repo.GetQuotaUsage(context.TODO(), "user1", tomorrow)
+	assert.NoError(t, err)
+	assert.Equal(t, &model.QuotaUsage{LinksToday: 0, ActiveLinks: 3}, usage)
+
+	_, reserved, err = repo.ReserveLinks(context.TODO(), "user1", tomorrow, 1, planLimits)
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+	assert.NoError(t, repo.ReleaseLinks(context.TODO(), "user1", today, 3, 0), "released too late")
+	usage, err = repo.GetQuotaUsage(context.TODO(), "user1", tomorrow)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), usage.LinksToday)
+
+	limits, err := repo.GetUserQuotaLimits(context.TODO(), "user1")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, limits)
+	assert.NoError(t, repo.SaveUserQuotaLimits(context.TODO(), "user1", model.QuotaLimits{LinksPerDay: 5}))
+	limits, err = repo.GetUserQuotaLimits(context.TODO(), "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, &model.QuotaLimits{LinksPerDay: 5}, limits)
+}
+
+func TestInMemoryRepositoryReview(t *testing.T) {
+	repo := NewInMemoryRepository()
+	reasons := []model.ReviewReason{model.ReviewSuspiciousTLD}
+	assert.NoError(t, repo.Save(context.TODO(), "user1",
+		model.URL{ShortURL: "qwerty12", OriginalURL: "https://a.zip", ReviewReasons: reasons}))
+	assert.NoError(t, repo.SaveBatch(context.TODO(), "user1", []model.URL{
+		{ShortURL: "qwerty13", OriginalURL: "https://1.2.3.4", ReviewReasons: []model.ReviewReason{model.ReviewIPHost}},
+		{ShortURL: "qwerty14", OriginalURL: "https://c.com"},
+	}))
+
+	url, err := repo.GetByShortURL(context.TODO(), "", "qwerty12")
+	assert.NoError(t, err)
+	assert.Equal(t, reasons, url.ReviewReasons)
+	url, err = repo.GetByShortURL(context.TODO(), "", "qwerty13")
+	assert.NoError(t, err)
+	assert.True(t, url.IsUnderReview())
+	url, err = repo.GetByShortURL(context.TODO(), "", "qwerty14")
+	assert.NoError(t, err)
+	assert.False(t, url.IsUnderReview())
+
+	assert.NoError(t, repo.ClearURLReview(context.TODO(), "", "qwerty12"))
+	url, err = repo.GetByShortURL(context.TODO(), "", "qwerty12")
+	assert.NoError(t, err)
+	assert.False(t, url.IsUnderReview())
+	assert.ErrorIs(t, repo.ClearURLReview(context.TODO(), "", "missing"), ErrNotFound)
+}
+
 func TestInMemoryRepositoryPing(t *testing.T) {
 	repo := NewInMemoryRepository()
 
@@ -3,13 +3,18 @@ package repository
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/bezjen/shortener/internal/model"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/jackc/pgx/v5/stdlib"
+	"slices"
+	"strings"
+	"time"
 )
 
 // PostgresRepository implements Repository interface for PostgreSQL storage.
@@ -39,6 +44,9 @@ func NewPostgresRepository(databaseDSN string) (*PostgresRepository, error) {
 
 // Save stores a URL mapping in PostgreSQL database.
 // Handles unique constraint violations and returns appropriate errors.
+// Short and original URLs are unique within the URL domain, original URLs are compared in canonical form.
+// A deleted record with the same original URL is replaced by the new record only for its owner,
+// for other users ErrURLReserved is returned until the record is purged.
 //
 // Parameters:
 //   - ctx: context for request cancellation and timeouts
@@ -46,27 +54,31 @@ func NewPostgresRepository(databaseDSN string) (*PostgresRepository, error) {
 //   - url: URL object containing short and original URLs
 //
 // Returns:
-//   - error: error if database operation fails or URL conflict occurs
+//   - error: error if database operation fails, URL conflict occurs or URL is reserved
 func (p *PostgresRepository) Save(ctx context.Context, userID string, url model.URL) error {
+	originalURLHash := hashOriginalURL(url.DedupURL())
 	_, err := p.db.ExecContext(ctx,
-		"insert into t_short_url(short_url, original_url, user_id, is_deleted) values ($1, $2, $3, false)",
-		url.ShortURL, url.OriginalURL, userID)
+		"insert into t_short_url(short_url, domain, original_url, original_url_hash, user_id, workspace_id, is_deleted, "+
+			"review_reasons) values ($1, $2, $3, $4, $5, nullif($6, ''), false, $7)",
+		url.ShortURL, url.Domain, url.OriginalURL, originalURLHash, userID, url.WorkspaceID,
+		joinReviewReasons(url.ReviewReasons))
 	if err != nil {
 		if isUniqueViolation(err) {
 			var shortURL string
+			var ownerID sql.NullString
 			var isDeleted bool
 			row := p.db.QueryRowContext(ctx,
-				"select short_url, is_deleted from t_short_url where original_url = $1;",
-				url.OriginalURL)
-			errScan := row.Scan(&shortURL, &isDeleted)
+				"select short_url, user_id, is_deleted from t_short_url where domain = $1 and original_url_hash = $2;",
+				url.Domain, originalURLHash)
+			errScan := row.Scan(&shortURL, &ownerID, &isDeleted)
 			if errScan != nil {
 				return errScan
 			}
 			if isDeleted {
-				_, errUpdate := p.db.ExecContext(ctx,
-					"update t_short_url set short_url = $1, user_id = $2, is_deleted = false where original_url = $3;",
-					url.ShortURL, userID, url.OriginalURL)
-				return errUpdate
+				if ownerID.String != userID {
+					return ErrURLReserved
+				}
+				return p.replaceDeletedURL(ctx, userID, url, originalURLHash)
 			}
 			return &ErrURLConflict{ShortURL: shortURL, Err: "Original URL already exists"}
 		}
@@ -77,6 +89,7 @@ func (p *PostgresRepository) Save(ctx context.Context, userID string, url model.
 
 // SaveBatch stores multiple URL mappings in a single transaction.
 // Provides atomic batch operations - all URLs are saved or none.
+// Deleted records are replaced like in Save.
 //
 // Parameters:
 //   - ctx: context for request cancellation and timeouts
@@ -84,7 +97,7 @@ func (p *PostgresRepository) Save(ctx context.Context, userID string, url model.
 //   - urls: slice of URL objects to store
 //
 // Returns:
-//   - error: error if any database operation fails
+//   - error: error if any database operation fails or any URL is reserved by another user
 func (p *PostgresRepository) SaveBatch(ctx context.Context, userID string, urls []model.URL) error {
 	if len(urls) == 0 {
 		return nil
@@ -96,16 +109,20 @@ func (p *PostgresRepository) SaveBatch(ctx context.Context, userID string, urls
 	}
 
 	for _, url := range urls {
+		originalURLHash := hashOriginalURL(url.DedupURL())
 		_, err = p.db.ExecContext(ctx,
-			"insert into t_short_url(short_url, original_url, user_id, is_deleted) values ($1, $2, $3, false)",
-			url.ShortURL, url.OriginalURL, userID)
+			"insert into t_short_url(short_url, domain, original_url, original_url_hash, user_id, workspace_id, is_deleted, "+
+				"review_reasons) values ($1, $2, $3, $4, $5, nullif($6, ''), false, $7)",
+			url.ShortURL, url.Domain, url.OriginalURL, originalURLHash, userID, url.WorkspaceID,
+			joinReviewReasons(url.ReviewReasons))
 		if err != nil {
 			if isUniqueViolation(err) {
+				var ownerID sql.NullString
 				var isDeleted bool
 				row := p.db.QueryRowContext(ctx,
-					"select is_deleted from t_short_url where original_url = $1;",
-					url.OriginalURL)
-				errScan := row.Scan(&isDeleted)
+					"select user_id, is_deleted from t_short_url where domain = $1 and original_url_hash = $2;",
+					url.Domain, originalURLHash)
+				errScan := row.Scan(&ownerID, &isDeleted)
 				if errScan != nil {
 					errRollback := tx.Rollback()
 					if errRollback != nil {
@@ -113,11 +130,11 @@ func (p *PostgresRepository) SaveBatch(ctx context.Context, userID string, urls
 					}
 					return errScan
 				}
-				if isDeleted {
-					_, errUpdate := p.db.ExecContext(ctx,
-						"update t_short_url set short_url = $1, user_id = $2, is_deleted = false where original_url = $3;",
-						url.ShortURL, userID, url.OriginalURL)
-					if errUpdate == nil {
+				if isDeleted && ownerID.String != userID {
+					err = ErrURLReserved
+				} else if isDeleted {
+					err = p.replaceDeletedURL(ctx, userID, url, originalURLHash)
+					if err == nil {
 						continue
 					}
 				}
@@ -133,17 +150,105 @@ func (p *PostgresRepository) SaveBatch(ctx context.Context, userID string, urls
 	return tx.Commit()
 }
 
-// DeleteBatch marks multiple short URLs as deleted in a single transaction.
+// replaceDeletedURL replaces a deleted record of the user with the same original URL by a new record.
+// The deleted record is removed in the same transaction together with its abuse reports,
+// so the new record takes nothing over from the previous link: no workspace, metadata, health or takedown.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user creating the URL, the owner of the deleted record
+//   - url: URL object containing short and original URLs
+//   - originalURLHash: hash of the canonical original URL shared by both records
+//
+// Returns:
+//   - error: ErrShortURLConflict if the short identifier is taken, or database error
+func (p *PostgresRepository) replaceDeletedURL(ctx context.Context,
+	userID string,
+	url model.URL,
+	originalURLHash string,
+) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"delete from t_short_url where domain = $1 and original_url_hash = $2 and user_id = $3 and is_deleted = true",
+		url.Domain, originalURLHash, userID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		"insert into t_short_url(short_url, domain, original_url, original_url_hash, user_id, workspace_id, is_deleted, "+
+			"review_reasons) values ($1, $2, $3, $4, $5, nullif($6, ''), false, $7)",
+		url.ShortURL, url.Domain, url.OriginalURL, originalURLHash, userID, url.WorkspaceID,
+		joinReviewReasons(url.ReviewReasons))
+	if err != nil {
+		tx.Rollback()
+		if isUniqueViolation(err) {
+			return ErrShortURLConflict
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// SaveBundle stores a bundle and its links in a single transaction.
+// The bundle is stored in t_short_url next to plain URLs, links are stored in t_bundle_link.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user creating the bundle
+//   - bundle: bundle with short identifier, title and ordered links
+//
+// Returns:
+//   - error: ErrShortURLConflict if the short identifier is taken, or database error
+func (p *PostgresRepository) SaveBundle(ctx context.Context, userID string, bundle model.Bundle) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"insert into t_short_url(short_url, domain, original_url, user_id, is_deleted, is_bundle, title, "+
+			"review_reasons) values ($1, $2, '', $3, false, true, $4, $5)",
+		bundle.ShortURL, bundle.Domain, userID, bundle.Title, joinReviewReasons(bundle.ReviewReasons))
+	if err != nil {
+		tx.Rollback()
+		if isUniqueViolation(err) {
+			return ErrShortURLConflict
+		}
+		return err
+	}
+
+	for position, link := range bundle.Links {
+		_, err = tx.ExecContext(ctx,
+			"insert into t_bundle_link(short_url, domain, position, title, url) values ($1, $2, $3, $4, $5)",
+			bundle.ShortURL, bundle.Domain, position, link.Title, link.URL)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteBatch moves multiple short URLs of a user to the trash in a single transaction.
 // Uses PostgreSQL array parameter for efficient batch updates.
+// URLs owned by other users and URLs of other domains are ignored.
+// URLs belonging to a workspace are deleted only through DeleteWorkspaceBatch.
 //
 // Parameters:
 //   - ctx: context for request cancellation and timeouts
-//   - userID: identifier of the user owning the URLs (currently not used for authorization)
+//   - domain: domain the short URLs belong to, empty for the base URL host
+//   - userID: identifier of the user owning the URLs
 //   - shortURLs: slice of short URL identifiers to mark as deleted
 //
 // Returns:
 //   - error: error if database operation fails
-func (p *PostgresRepository) DeleteBatch(ctx context.Context, _ string, shortURLs []string) error {
+func (p *PostgresRepository) DeleteBatch(ctx context.Context, domain string, userID string, shortURLs []string) error {
 	if len(shortURLs) == 0 {
 		return nil
 	}
@@ -153,14 +258,17 @@ func (p *PostgresRepository) DeleteBatch(ctx context.Context, _ string, shortURL
 		return err
 	}
 
-	stmt, err := tx.PrepareContext(ctx, "update t_short_url set is_deleted = true where short_url = any($1::text[])")
+	stmt, err := tx.PrepareContext(ctx,
+		"update t_short_url set is_deleted = true, deleted_at = now() "+
+			"where domain = $1 and short_url = any($2::text[]) and user_id = $3 "+
+			"and workspace_id is null and is_deleted = false")
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 	defer stmt.Close()
 
-	_, err = stmt.ExecContext(ctx, shortURLs)
+	_, err = stmt.ExecContext(ctx, domain, shortURLs, userID)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -169,31 +277,176 @@ func (p *PostgresRepository) DeleteBatch(ctx context.Context, _ string, shortURL
 	return tx.Commit()
 }
 
-// GetByShortURL retrieves the original URL by its short identifier.
+// RestoreBatch restores multiple deleted short URLs of a user from the trash.
+// URLs owned by other users, URLs of other domains and URLs that are not deleted are ignored.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URLs belong to, empty for the base URL host
+//   - userID: identifier of the user owning the URLs
+//   - shortURLs: slice of short URL identifiers to restore
+//
+// Returns:
+//   - error: error if database operation fails
+func (p *PostgresRepository) RestoreBatch(ctx context.Context, domain string, userID string, shortURLs []string) error {
+	if len(shortURLs) == 0 {
+		return nil
+	}
+
+	_, err := p.db.ExecContext(ctx,
+		"update t_short_url set is_deleted = false, deleted_at = null "+
+			"where domain = $1 and short_url = any($2::text[]) and user_id = $3 "+
+			"and workspace_id is null and is_deleted = true",
+		domain, shortURLs, userID)
+	return err
+}
+
+// PurgeDeleted permanently removes URLs deleted before the given time.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - deletedBefore: URLs deleted before this time are removed
+//
+// Returns:
+//   - int64: number of removed records
+//   - error: error if database operation fails
+func (p *PostgresRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	result, err := p.db.ExecContext(ctx,
+		"delete from t_short_url where is_deleted = true and deleted_at < $1",
+		deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetByShortURL retrieves the original URL by its short identifier within a domain.
 // Returns the URL with deletion status.
 //
 // Parameters:
 //   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URL belongs to, empty for the base URL host
 //   - shortURL: short URL identifier to look up
 //
 // Returns:
-//   - *model.URL: found URL object with deletion status
+//   - *model.URL: found URL object with deletion status, disabled as well when its owner is banned
 //   - error: error if URL is not found or database operation fails
-func (p *PostgresRepository) GetByShortURL(ctx context.Context, shortURL string) (*model.URL, error) {
-	row := p.db.QueryRowContext(ctx, "select original_url, is_deleted from t_short_url where short_url = $1", shortURL)
+func (p *PostgresRepository) GetByShortURL(ctx context.Context, domain string, shortURL string) (*model.URL, error) {
+	row := p.db.QueryRowContext(ctx,
+		"select u.original_url, u.is_deleted, u.is_bundle, "+
+			"u.is_disabled or exists(select 1 from t_banned_user b where b.user_id = u.user_id), u.takedown, "+
+			"u.review_reasons, u.meta_title, u.meta_description, u.meta_image, u.meta_site_name, u.meta_fetched_at "+
+			"from t_short_url u where u.domain = $1 and u.short_url = $2",
+		domain, shortURL)
 	var originalURL string
 	var isDeleted bool
-	err := row.Scan(&originalURL, &isDeleted)
+	var isBundle bool
+	var isDisabled bool
+	var takedown string
+	var reviewReasons string
+	var metadata model.URLMetadata
+	var fetchedAt sql.NullTime
+	err := row.Scan(&originalURL, &isDeleted, &isBundle, &isDisabled, &takedown, &reviewReasons,
+		&metadata.Title, &metadata.Description, &metadata.Image, &metadata.SiteName, &fetchedAt)
 	if err != nil {
 		return nil, err
 	}
 	var url = model.NewURL(shortURL, originalURL)
+	url.Domain = domain
 	url.IsDeleted = isDeleted
+	url.IsBundle = isBundle
+	url.IsDisabled = isDisabled
+	url.Takedown = model.TakedownReason(takedown)
+	url.ReviewReasons = splitReviewReasons(reviewReasons)
+	if fetchedAt.Valid {
+		metadata.FetchedAt = fetchedAt.Time
+		url.Metadata = &metadata
+	}
+	return url, nil
+}
+
+// GetByOriginalURL retrieves the link to an original URL within a domain.
+// The original URL is matched by original_url_hash. Deleted links, links disabled by an administrator
+// or held for review and bundles are not returned, so that lookups only lead to links that are redirected.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - userID: owner of the link, empty to find links of any user
+//   - originalURL: original URL to look up, in the form compared for deduplication
+//
+// Returns:
+//   - *model.URL: found URL object
+//   - error: ErrNotFound if no link to the original URL exists, or error if database operation fails
+func (p *PostgresRepository) GetByOriginalURL(ctx context.Context,
+	domain string,
+	userID string,
+	originalURL string,
+) (*model.URL, error) {
+	row := p.db.QueryRowContext(ctx,
+		"select short_url, original_url from t_short_url "+
+			"where domain = $1 and original_url_hash = $2 and is_deleted = false and is_disabled = false "+
+			"and review_reasons = '' and is_bundle = false and ($3 = '' or user_id = $3)",
+		domain, hashOriginalURL(originalURL), userID)
+	url := &model.URL{Domain: domain}
+	err := row.Scan(&url.ShortURL, &url.OriginalURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
 	return url, nil
 }
 
+// GetBundleByShortURL retrieves a bundle with its links by short identifier within a domain.
+// Links are returned in display order.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the bundle belongs to, empty for the base URL host
+//   - shortURL: short identifier of the bundle
+//
+// Returns:
+//   - *model.Bundle: found bundle with links
+//   - error: error if bundle is not found or database operation fails
+func (p *PostgresRepository) GetBundleByShortURL(ctx context.Context,
+	domain string,
+	shortURL string,
+) (*model.Bundle, error) {
+	row := p.db.QueryRowContext(ctx,
+		"select coalesce(title, '') from t_short_url where domain = $1 and short_url = $2 and is_bundle = true",
+		domain, shortURL)
+	var title string
+	if err := row.Scan(&title); err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db.QueryContext(ctx,
+		"select title, url from t_bundle_link where domain = $1 and short_url = $2 order by position",
+		domain, shortURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query links for bundle %s: %w", shortURL, err)
+	}
+	defer rows.Close()
+	var links []model.BundleLink
+	for rows.Next() {
+		var link model.BundleLink
+		if err = rows.Scan(&link.Title, &link.URL); err != nil {
+			return nil, fmt.Errorf("failed to scan bundle link row: %w", err)
+		}
+		links = append(links, link)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+	bundle := model.NewBundle(shortURL, title, links)
+	bundle.Domain = domain
+	return bundle, nil
+}
+
 // GetByUserID retrieves all URLs created by a specific user.
-// Only returns non-deleted URLs for the user.
+// Only returns non-deleted URLs for the user that do not belong to a workspace.
 //
 // Parameters:
 //   - ctx: context for request cancellation and timeouts
@@ -204,7 +457,8 @@ func (p *PostgresRepository) GetByShortURL(ctx context.Context, shortURL string)
 //   - error: error if database operation fails
 func (p *PostgresRepository) GetByUserID(ctx context.Context, userID string) ([]model.URL, error) {
 	rows, err := p.db.QueryContext(ctx,
-		"select short_url, original_url from t_short_url where user_id = $1 and is_deleted = false",
+		"select "+listedURLColumns+" from t_short_url "+
+			"where user_id = $1 and workspace_id is null and is_deleted = false and is_bundle = false",
 		userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query URLs for user %s: %w", userID, err)
@@ -213,10 +467,44 @@ func (p *PostgresRepository) GetByUserID(ctx context.Context, userID string) ([]
 	var urls []model.URL
 	for rows.Next() {
 		var url model.URL
-		err = rows.Scan(&url.ShortURL, &url.OriginalURL)
+		if err = scanListedURL(rows, &url); err != nil {
+			return nil, fmt.Errorf("failed to scan URL row: %w", err)
+		}
+		urls = append(urls, url)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+	return urls, nil
+}
+
+// GetDeletedByUserID retrieves all URLs of a specific user that were moved to the trash.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: user identifier to look up deleted URLs for
+//
+// Returns:
+//   - []model.URL: slice of deleted URLs with deletion time
+//   - error: error if database operation fails
+func (p *PostgresRepository) GetDeletedByUserID(ctx context.Context, userID string) ([]model.URL, error) {
+	rows, err := p.db.QueryContext(ctx,
+		"select short_url, domain, original_url, deleted_at from t_short_url "+
+			"where user_id = $1 and workspace_id is null and is_deleted = true and is_bundle = false",
+		userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deleted URLs for user %s: %w", userID, err)
+	}
+	defer rows.Close()
+	var urls []model.URL
+	for rows.Next() {
+		var deletedAt sql.NullTime
+		url := model.URL{IsDeleted: true}
+		err = rows.Scan(&url.ShortURL, &url.Domain, &url.OriginalURL, &deletedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan URL row: %w", err)
 		}
+		url.DeletedAt = deletedAt.Time
 		urls = append(urls, url)
 	}
 	if err = rows.Err(); err != nil {
@@ -225,78 +513,1592 @@ func (p *PostgresRepository) GetByUserID(ctx context.Context, userID string) ([]
 	return urls, nil
 }
 
-// Ping checks the connectivity to PostgreSQL database.
-// Used for health checks and connection validation.
+// CreateWorkspace stores a new workspace with its owner in a single transaction.
 //
 // Parameters:
 //   - ctx: context for request cancellation and timeouts
+//   - workspace: workspace with identifier and name
+//   - ownerID: identifier of the user becoming the workspace owner
 //
 // Returns:
-//   - error: error if database is unreachable
-func (p *PostgresRepository) Ping(ctx context.Context) error {
-	return p.db.PingContext(ctx)
+//   - error: error if database operation fails
+func (p *PostgresRepository) CreateWorkspace(ctx context.Context, workspace model.Workspace, ownerID string) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"insert into t_workspace(id, name) values ($1, $2)",
+		workspace.ID, workspace.Name)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"insert into t_workspace_member(workspace_id, user_id, role) values ($1, $2, $3)",
+		workspace.ID, ownerID, model.RoleOwner)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
 }
 
-// Close closes the database connection and releases resources.
-// Should be called when the repository is no longer needed.
+// GetWorkspacesByUserID retrieves all workspaces the user is a member of.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: user identifier to look up workspaces for
 //
 // Returns:
-//   - error: error if database closing fails
-func (p *PostgresRepository) Close() error {
-	return p.db.Close()
+//   - []model.Workspace: slice of workspaces with the user's role
+//   - error: error if database operation fails
+func (p *PostgresRepository) GetWorkspacesByUserID(ctx context.Context, userID string) ([]model.Workspace, error) {
+	rows, err := p.db.QueryContext(ctx,
+		"select w.id, w.name, m.role from t_workspace w "+
+			"join t_workspace_member m on m.workspace_id = w.id where m.user_id = $1 order by w.name",
+		userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workspaces for user %s: %w", userID, err)
+	}
+	defer rows.Close()
+	var workspaces []model.Workspace
+	for rows.Next() {
+		var workspace model.Workspace
+		if err = rows.Scan(&workspace.ID, &workspace.Name, &workspace.Role); err != nil {
+			return nil, fmt.Errorf("failed to scan workspace row: %w", err)
+		}
+		workspaces = append(workspaces, workspace)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+	return workspaces, nil
 }
 
-// getShortURLByOriginalURL retrieves the short URL for a given original URL.
-// Internal helper method for conflict resolution.
+// GetWorkspaceRole retrieves the role of a user in a workspace.
 //
 // Parameters:
 //   - ctx: context for request cancellation and timeouts
-//   - originalURL: original URL to look up
+//   - workspaceID: identifier of the workspace
+//   - userID: identifier of the user
 //
 // Returns:
-//   - string: found short URL
-//   - error: error if URL is not found or database operation fails
-func (p *PostgresRepository) getShortURLByOriginalURL(ctx context.Context, originalURL string) (string, error) {
-	row := p.db.QueryRowContext(ctx, "select short_url from t_short_url where original_url = $1", originalURL)
-	var shortURL string
-	err := row.Scan(&shortURL)
+//   - model.WorkspaceRole: role of the user
+//   - error: ErrNotFound if the user is not a member, or database error
+func (p *PostgresRepository) GetWorkspaceRole(ctx context.Context,
+	workspaceID string,
+	userID string,
+) (model.WorkspaceRole, error) {
+	row := p.db.QueryRowContext(ctx,
+		"select role from t_workspace_member where workspace_id = $1 and user_id = $2",
+		workspaceID, userID)
+	var role model.WorkspaceRole
+	err := row.Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
 	if err != nil {
 		return "", err
 	}
-	return shortURL, nil
+	return role, nil
 }
 
-// isUniqueViolation checks if an error is a PostgreSQL unique constraint violation.
-// Helper function for handling duplicate key errors.
+// GetWorkspaceMembers retrieves all members of a workspace.
 //
 // Parameters:
-//   - err: error to check
+//   - ctx: context for request cancellation and timeouts
+//   - workspaceID: identifier of the workspace
 //
 // Returns:
-//   - bool: true if error is a unique constraint violation
-func isUniqueViolation(err error) bool {
-	if err == nil {
-		return false
+//   - []model.WorkspaceMember: slice of members with their roles
+//   - error: error if database operation fails
+func (p *PostgresRepository) GetWorkspaceMembers(ctx context.Context, workspaceID string) ([]model.WorkspaceMember, error) {
+	rows, err := p.db.QueryContext(ctx,
+		"select user_id, role from t_workspace_member where workspace_id = $1 order by user_id",
+		workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query members of workspace %s: %w", workspaceID, err)
 	}
-	var pgErr *pgconn.PgError
-	if errors.As(err, &pgErr) {
-		return pgErr.Code == pgerrcode.UniqueViolation
+	defer rows.Close()
+	var members []model.WorkspaceMember
+	for rows.Next() {
+		member := model.WorkspaceMember{WorkspaceID: workspaceID}
+		if err = rows.Scan(&member.UserID, &member.Role); err != nil {
+			return nil, fmt.Errorf("failed to scan workspace member row: %w", err)
+		}
+		members = append(members, member)
 	}
-	return false
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+	return members, nil
 }
 
-// ErrURLConflict represents a URL conflict error when original URL already exists.
-// Contains the existing short URL for the original URL.
-type ErrURLConflict struct {
-	ShortURL string
-	Err      string
+// SaveWorkspaceMember adds a member to a workspace or changes the role of an existing member.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - member: workspace member with role
+//
+// Returns:
+//   - error: error if database operation fails
+func (p *PostgresRepository) SaveWorkspaceMember(ctx context.Context, member model.WorkspaceMember) error {
+	_, err := p.db.ExecContext(ctx,
+		"insert into t_workspace_member(workspace_id, user_id, role) values ($1, $2, $3) "+
+			"on conflict (workspace_id, user_id) do update set role = excluded.role",
+		member.WorkspaceID, member.UserID, member.Role)
+	return err
 }
 
-// Error returns the string representation of the URL conflict error.
-// Implements the error interface.
+// DeleteWorkspaceMember removes a member from a workspace.
+// Links created by the member stay in the workspace.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - workspaceID: identifier of the workspace
+//   - userID: identifier of the member to remove
 //
 // Returns:
-//   - string: error message
-func (err *ErrURLConflict) Error() string {
-	return err.Err
+//   - error: error if database operation fails
+func (p *PostgresRepository) DeleteWorkspaceMember(ctx context.Context, workspaceID string, userID string) error {
+	_, err := p.db.ExecContext(ctx,
+		"delete from t_workspace_member where workspace_id = $1 and user_id = $2",
+		workspaceID, userID)
+	return err
+}
+
+// GetByWorkspaceID retrieves all non-deleted URLs of a workspace.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - workspaceID: identifier of the workspace
+//
+// Returns:
+//   - []model.URL: slice of URLs of the workspace
+//   - error: error if database operation fails
+func (p *PostgresRepository) GetByWorkspaceID(ctx context.Context, workspaceID string) ([]model.URL, error) {
+	rows, err := p.db.QueryContext(ctx,
+		"select "+listedURLColumns+" from t_short_url "+
+			"where workspace_id = $1 and is_deleted = false and is_bundle = false",
+		workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query URLs for workspace %s: %w", workspaceID, err)
+	}
+	defer rows.Close()
+	var urls []model.URL
+	for rows.Next() {
+		url := model.URL{WorkspaceID: workspaceID}
+		if err = scanListedURL(rows, &url); err != nil {
+			return nil, fmt.Errorf("failed to scan URL row: %w", err)
+		}
+		urls = append(urls, url)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+	return urls, nil
+}
+
+// DeleteWorkspaceBatch moves multiple short URLs of a workspace to the trash.
+// URLs of other workspaces, URLs of other domains and personal URLs are ignored.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URLs belong to, empty for the base URL host
+//   - workspaceID: identifier of the workspace owning the URLs
+//   - shortURLs: slice of short URL identifiers to delete
+//
+// Returns:
+//   - error: error if database operation fails
+func (p *PostgresRepository) DeleteWorkspaceBatch(ctx context.Context,
+	domain string,
+	workspaceID string,
+	shortURLs []string,
+) error {
+	if len(shortURLs) == 0 {
+		return nil
+	}
+
+	_, err := p.db.ExecContext(ctx,
+		"update t_short_url set is_deleted = true, deleted_at = now() "+
+			"where domain = $1 and short_url = any($2::text[]) and workspace_id = $3 and is_deleted = false",
+		domain, shortURLs, workspaceID)
+	return err
+}
+
+// GetDeletedByWorkspaceID retrieves all URLs of a workspace that were moved to the trash.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - workspaceID: identifier of the workspace
+//
+// Returns:
+//   - []model.URL: slice of deleted URLs with deletion time
+//   - error: error if database operation fails
+func (p *PostgresRepository) GetDeletedByWorkspaceID(ctx context.Context, workspaceID string) ([]model.URL, error) {
+	rows, err := p.db.QueryContext(ctx,
+		"select short_url, domain, original_url, deleted_at from t_short_url "+
+			"where workspace_id = $1 and is_deleted = true and is_bundle = false",
+		workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deleted URLs for workspace %s: %w", workspaceID, err)
+	}
+	defer rows.Close()
+	var urls []model.URL
+	for rows.Next() {
+		var deletedAt sql.NullTime
+		url := model.URL{WorkspaceID: workspaceID, IsDeleted: true}
+		err = rows.Scan(&url.ShortURL, &url.Domain, &url.OriginalURL, &deletedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan URL row: %w", err)
+		}
+		url.DeletedAt = deletedAt.Time
+		urls = append(urls, url)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+	return urls, nil
+}
+
+// RestoreWorkspaceBatch restores multiple deleted short URLs of a workspace from the trash.
+// URLs of other workspaces, URLs of other domains, personal URLs and URLs that are not deleted are ignored.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URLs belong to, empty for the base URL host
+//   - workspaceID: identifier of the workspace owning the URLs
+//   - shortURLs: slice of short URL identifiers to restore
+//
+// Returns:
+//   - error: error if database operation fails
+func (p *PostgresRepository) RestoreWorkspaceBatch(ctx context.Context,
+	domain string,
+	workspaceID string,
+	shortURLs []string,
+) error {
+	if len(shortURLs) == 0 {
+		return nil
+	}
+
+	_, err := p.db.ExecContext(ctx,
+		"update t_short_url set is_deleted = false, deleted_at = null "+
+			"where domain = $1 and short_url = any($2::text[]) and workspace_id = $3 and is_deleted = true",
+		domain, shortURLs, workspaceID)
+	return err
+}
+
+// UpdateWorkspaceURL changes the original URL of a short URL owned by a workspace.
+// Review reasons are replaced, health and metadata of the previous destination are cleared,
+// so that the background checkers pick the link up again.
+// Deleted, disabled and bundle links cannot be changed.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - workspaceID: identifier of the workspace owning the URL
+//   - url: URL with domain, short URL, new original URL, canonical form and review reasons
+//
+// Returns:
+//   - error: ErrNotFound if the workspace has no such link, *ErrURLConflict or ErrURLReserved
+//     if the original URL is already shortened on the domain, or database error
+func (p *PostgresRepository) UpdateWorkspaceURL(ctx context.Context, workspaceID string, url model.URL) error {
+	originalURLHash := hashOriginalURL(url.DedupURL())
+	result, err := p.db.ExecContext(ctx,
+		"update t_short_url set original_url = $1, original_url_hash = $2, review_reasons = $3, "+
+			"health_status = '', health_status_code = 0, health_checked_at = null, "+
+			"meta_title = '', meta_description = '', meta_image = '', meta_site_name = '', meta_fetched_at = null "+
+			"where domain = $4 and short_url = $5 and workspace_id = $6 "+
+			"and is_deleted = false and is_disabled = false and is_bundle = false",
+		url.OriginalURL, originalURLHash, joinReviewReasons(url.ReviewReasons), url.Domain, url.ShortURL, workspaceID)
+	if err != nil {
+		if !isUniqueViolation(err) {
+			return err
+		}
+		var shortURL string
+		var isDeleted bool
+		row := p.db.QueryRowContext(ctx,
+			"select short_url, is_deleted from t_short_url where domain = $1 and original_url_hash = $2",
+			url.Domain, originalURLHash)
+		if errScan := row.Scan(&shortURL, &isDeleted); errScan != nil {
+			return errScan
+		}
+		if isDeleted {
+			return ErrURLReserved
+		}
+		return &ErrURLConflict{ShortURL: shortURL, Err: "Original URL already exists"}
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SaveUser stores a registered account.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - user: user with identifier, login and password hash
+//
+// Returns:
+//   - error: ErrLoginConflict if the login is taken, or database error
+func (p *PostgresRepository) SaveUser(ctx context.Context, user model.User) error {
+	_, err := p.db.ExecContext(ctx,
+		"insert into t_user(id, login, password_hash) values ($1, $2, $3)",
+		user.ID, user.Login, user.PasswordHash)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrLoginConflict
+		}
+		return err
+	}
+	return nil
+}
+
+// GetUserByLogin retrieves a registered account by its login.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - login: login of the account
+//
+// Returns:
+//   - *model.User: found user
+//   - error: ErrNotFound if no account has the login, or database error
+func (p *PostgresRepository) GetUserByLogin(ctx context.Context, login string) (*model.User, error) {
+	row := p.db.QueryRowContext(ctx,
+		"select id, login, password_hash from t_user where login = $1",
+		login)
+	return scanUser(row)
+}
+
+// GetUserByID retrieves a registered account by its identifier.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - id: identifier of the account
+//
+// Returns:
+//   - *model.User: found user
+//   - error: ErrNotFound if the identifier belongs to no account, or database error
+func (p *PostgresRepository) GetUserByID(ctx context.Context, id string) (*model.User, error) {
+	row := p.db.QueryRowContext(ctx,
+		"select id, login, password_hash from t_user where id = $1",
+		id)
+	return scanUser(row)
+}
+
+// MergeUserURLs moves all URLs and workspace memberships of one user to another in a single transaction.
+// Memberships in workspaces the receiving user already belongs to are dropped.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - fromUserID: identifier of the user giving up the URLs
+//   - toUserID: identifier of the user receiving the URLs
+//
+// Returns:
+//   - error: error if database operation fails
+func (p *PostgresRepository) MergeUserURLs(ctx context.Context, fromUserID string, toUserID string) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"update t_short_url set user_id = $2 where user_id = $1",
+		fromUserID, toUserID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"update t_workspace_member m set user_id = $2 where m.user_id = $1 and not exists "+
+			"(select 1 from t_workspace_member o where o.workspace_id = m.workspace_id and o.user_id = $2)",
+		fromUserID, toUserID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"delete from t_workspace_member where user_id = $1",
+		fromUserID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SaveAPIKey stores a new API key of a user.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - key: API key with identifier, owner, key hash and scopes
+//
+// Returns:
+//   - error: error if database operation fails
+func (p *PostgresRepository) SaveAPIKey(ctx context.Context, key model.APIKey) error {
+	_, err := p.db.ExecContext(ctx,
+		"insert into t_api_key(id, user_id, name, prefix, key_hash, scopes, rate_limit, created_at) "+
+			"values ($1, $2, $3, $4, $5, $6, $7, $8)",
+		key.ID, key.UserID, key.Name, key.Prefix, key.KeyHash, joinAPIKeyScopes(key.Scopes), key.RateLimit, key.CreatedAt)
+	return err
+}
+
+// GetAPIKeysByUserID retrieves all API keys of a user ordered by creation time.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: user identifier to look up keys for
+//
+// Returns:
+//   - []model.APIKey: slice of keys of the user
+//   - error: error if database operation fails
+func (p *PostgresRepository) GetAPIKeysByUserID(ctx context.Context, userID string) ([]model.APIKey, error) {
+	rows, err := p.db.QueryContext(ctx,
+		"select id, user_id, name, prefix, key_hash, scopes, rate_limit, created_at, last_used_at "+
+			"from t_api_key where user_id = $1 order by created_at",
+		userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []model.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// GetAPIKeyByHash retrieves an API key by the hash of the key.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - keyHash: hex-encoded SHA-256 digest of the key
+//
+// Returns:
+//   - *model.APIKey: found key
+//   - error: ErrNotFound if no key has the hash, or database error
+func (p *PostgresRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	row := p.db.QueryRowContext(ctx,
+		"select id, user_id, name, prefix, key_hash, scopes, rate_limit, created_at, last_used_at "+
+			"from t_api_key where key_hash = $1",
+		keyHash)
+	key, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return key, err
+}
+
+// DeleteAPIKey removes an API key of a user, revoking it.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user owning the key
+//   - id: identifier of the key
+//
+// Returns:
+//   - error: ErrNotFound if the user has no such key, or database error
+func (p *PostgresRepository) DeleteAPIKey(ctx context.Context, userID string, id string) error {
+	result, err := p.db.ExecContext(ctx,
+		"delete from t_api_key where id = $1 and user_id = $2",
+		id, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpdateAPIKeyLastUsed records the time an API key was last used.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - id: identifier of the key
+//   - usedAt: time the key was used
+//
+// Returns:
+//   - error: error if database operation fails
+func (p *PostgresRepository) UpdateAPIKeyLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	_, err := p.db.ExecContext(ctx,
+		"update t_api_key set last_used_at = $2 where id = $1",
+		id, usedAt)
+	return err
+}
+
+// SaveRevokedToken revokes a single token by its identifier.
+// Revoking an already revoked token is a no-op.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - tokenID: jti claim of the token
+//   - expiresAt: expiration time of the token
+//
+// Returns:
+//   - error: error if database operation fails
+func (p *PostgresRepository) SaveRevokedToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	_, err := p.db.ExecContext(ctx,
+		"insert into t_revoked_token(token_id, expires_at) values ($1, $2) on conflict (token_id) do nothing",
+		tokenID, expiresAt)
+	return err
+}
+
+// IsTokenRevoked reports whether a token was revoked by itself or together with all tokens of its user,
+// and the revocation has not expired yet. Both revocation tables are checked in a single query.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - tokenID: jti claim of the token, empty for tokens without one
+//   - userID: identifier of the user the token was issued to
+//   - issuedAt: issue time of the token, zero for tokens without one
+//
+// Returns:
+//   - bool: true if the token is revoked
+//   - error: error if database operation fails
+func (p *PostgresRepository) IsTokenRevoked(ctx context.Context,
+	tokenID string,
+	userID string,
+	issuedAt time.Time,
+) (bool, error) {
+	row := p.db.QueryRowContext(ctx,
+		"select exists(select 1 from t_revoked_token where token_id = $1 and $1 <> '' and expires_at > now()) "+
+			"or exists(select 1 from t_user_token_revocation "+
+			"where user_id = $2 and revoked_before > $3 and expires_at > now())",
+		tokenID, userID, issuedAt)
+	var revoked bool
+	if err := row.Scan(&revoked); err != nil {
+		return false, err
+	}
+	return revoked, nil
+}
+
+// SaveUserTokensRevocation revokes all tokens of a user issued before the given time.
+// A later revocation of the same user replaces the earlier one.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//   - revokedBefore: tokens issued before this time are revoked
+//   - expiresAt: time all revoked tokens have expired by
+//
+// Returns:
+//   - error: error if database operation fails
+func (p *PostgresRepository) SaveUserTokensRevocation(ctx context.Context,
+	userID string,
+	revokedBefore time.Time,
+	expiresAt time.Time,
+) error {
+	_, err := p.db.ExecContext(ctx,
+		"insert into t_user_token_revocation(user_id, revoked_before, expires_at) values ($1, $2, $3) "+
+			"on conflict (user_id) do update set revoked_before = excluded.revoked_before, expires_at = excluded.expires_at",
+		userID, revokedBefore, expiresAt)
+	return err
+}
+
+// PurgeExpiredRevocations removes revocations of tokens that have expired in a single transaction.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - now: revocations expiring before this time are removed
+//
+// Returns:
+//   - int64: number of removed revocations
+//   - error: error if database operation fails
+func (p *PostgresRepository) PurgeExpiredRevocations(ctx context.Context, now time.Time) (int64, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int64
+	for _, query := range []string{
+		"delete from t_revoked_token where expires_at < $1",
+		"delete from t_user_token_revocation where expires_at < $1",
+	} {
+		result, err := tx.ExecContext(ctx, query, now)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		purged += affected
+	}
+
+	return purged, tx.Commit()
+}
+
+// SearchURLs finds links of all users for administrators.
+// The destination matches case-insensitively by substring, other criteria exactly.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - filter: search criteria with pagination
+//
+// Returns:
+//   - []model.AdminURL: matching links ordered by domain and short URL
+//   - error: error if database operation fails
+func (p *PostgresRepository) SearchURLs(ctx context.Context, filter model.URLSearchFilter) ([]model.AdminURL, error) {
+	var conditions []string
+	var args []any
+	if filter.ShortURL != "" {
+		args = append(args, filter.ShortURL)
+		conditions = append(conditions, fmt.Sprintf("short_url = $%d", len(args)))
+	}
+	if filter.OriginalURL != "" {
+		args = append(args, filter.OriginalURL)
+		conditions = append(conditions, fmt.Sprintf("strpos(lower(original_url), lower($%d)) > 0", len(args)))
+	}
+	if filter.UserID != "" {
+		args = append(args, filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if filter.UnderReview {
+		conditions = append(conditions, "review_reasons <> ''")
+	}
+	query := "select short_url, domain, original_url, coalesce(user_id, ''), coalesce(workspace_id, ''), " +
+		"is_deleted, is_disabled, takedown, is_bundle, review_reasons from t_short_url"
+	if len(conditions) > 0 {
+		query += " where " + strings.Join(conditions, " and ")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" order by domain, short_url limit $%d offset $%d", len(args)-1, len(args))
+
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search URLs: %w", err)
+	}
+	defer rows.Close()
+	var urls []model.AdminURL
+	for rows.Next() {
+		var url model.AdminURL
+		var takedown string
+		var reviewReasons string
+		err = rows.Scan(&url.ShortURL, &url.Domain, &url.OriginalURL, &url.UserID, &url.WorkspaceID,
+			&url.IsDeleted, &url.IsDisabled, &takedown, &url.IsBundle, &reviewReasons)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan URL row: %w", err)
+		}
+		url.Takedown = model.TakedownReason(takedown)
+		url.ReviewReasons = splitReviewReasons(reviewReasons)
+		urls = append(urls, url)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+	return urls, nil
+}
+
+// SetURLDisabled disables a link or enables it again.
+// The takedown ground of the link is cleared, the link is disabled without one.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - shortURL: short URL identifier
+//   - disabled: true to disable the link, false to enable it
+//
+// Returns:
+//   - error: ErrNotFound if the link does not exist, or database error
+func (p *PostgresRepository) SetURLDisabled(ctx context.Context, domain string, shortURL string, disabled bool) error {
+	result, err := p.db.ExecContext(ctx,
+		"update t_short_url set is_disabled = $3, takedown = '' where domain = $1 and short_url = $2",
+		domain, shortURL, disabled)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ClearURLReview clears the phishing review reasons of a link after an administrator checked it.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - shortURL: short URL identifier
+//
+// Returns:
+//   - error: ErrNotFound if the link does not exist, or database error
+func (p *PostgresRepository) ClearURLReview(ctx context.Context, domain string, shortURL string) error {
+	result, err := p.db.ExecContext(ctx,
+		"update t_short_url set review_reasons = '' where domain = $1 and short_url = $2",
+		domain, shortURL)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SaveAbuseReport adds a report of an abusive link to the moderation queue.
+// The report is inserted only if the reported link exists.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - report: open report with identifier and reported link
+//
+// Returns:
+//   - error: ErrNotFound if the reported link does not exist, or database error
+func (p *PostgresRepository) SaveAbuseReport(ctx context.Context, report model.AbuseReport) error {
+	result, err := p.db.ExecContext(ctx,
+		"insert into t_abuse_report(id, domain, short_url, reason, details, reporter_id, status, created_at) "+
+			"select $1, $2, $3, $4, $5, nullif($6, ''), $7, $8 "+
+			"where exists(select 1 from t_short_url where domain = $2 and short_url = $3)",
+		report.ID, report.Domain, report.ShortURL, report.Reason, report.Details, report.ReporterID,
+		report.Status, report.CreatedAt)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetAbuseReports lists abuse reports in a state, oldest first.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - filter: state of the reports with pagination
+//
+// Returns:
+//   - []model.AbuseReport: matching reports
+//   - error: error if database operation fails
+func (p *PostgresRepository) GetAbuseReports(ctx context.Context,
+	filter model.AbuseReportFilter,
+) ([]model.AbuseReport, error) {
+	rows, err := p.db.QueryContext(ctx,
+		"select "+abuseReportColumns+" from t_abuse_report where status = $1 "+
+			"order by created_at, id limit $2 offset $3",
+		filter.Status, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query abuse reports: %w", err)
+	}
+	defer rows.Close()
+
+	var reports []model.AbuseReport
+	for rows.Next() {
+		report, err := scanAbuseReport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan abuse report row: %w", err)
+		}
+		reports = append(reports, *report)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+	return reports, nil
+}
+
+// GetAbuseReport retrieves a single abuse report.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - id: identifier of the report
+//
+// Returns:
+//   - *model.AbuseReport: found report
+//   - error: ErrNotFound if the report does not exist, or database error
+func (p *PostgresRepository) GetAbuseReport(ctx context.Context, id string) (*model.AbuseReport, error) {
+	row := p.db.QueryRowContext(ctx,
+		"select "+abuseReportColumns+" from t_abuse_report where id = $1",
+		id)
+	report, err := scanAbuseReport(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return report, err
+}
+
+// DismissAbuseReport marks an open abuse report as dismissed.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - id: identifier of the report
+//   - adminID: identifier of the administrator resolving the report
+//   - resolvedAt: time of the resolution
+//
+// Returns:
+//   - error: ErrNotFound if no open report has the identifier, or database error
+func (p *PostgresRepository) DismissAbuseReport(ctx context.Context,
+	id string,
+	adminID string,
+	resolvedAt time.Time,
+) error {
+	result, err := p.db.ExecContext(ctx,
+		"update t_abuse_report set status = $2, resolved_by = $3, resolved_at = $4 where id = $1 and status = $5",
+		id, model.ReportDismissed, adminID, resolvedAt, model.ReportOpen)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// TakeDownURL disables a link on the given ground and marks its open abuse reports as actioned
+// in a single transaction.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - shortURL: short URL identifier
+//   - reason: ground for the takedown
+//   - adminID: identifier of the administrator taking the link down
+//   - resolvedAt: time of the takedown
+//
+// Returns:
+//   - error: ErrNotFound if the link does not exist, or database error
+func (p *PostgresRepository) TakeDownURL(ctx context.Context,
+	domain string,
+	shortURL string,
+	reason model.TakedownReason,
+	adminID string,
+	resolvedAt time.Time,
+) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		"update t_short_url set is_disabled = true, takedown = $3 where domain = $1 and short_url = $2",
+		domain, shortURL, reason)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if affected == 0 {
+		tx.Rollback()
+		return ErrNotFound
+	}
+	_, err = tx.ExecContext(ctx,
+		"update t_abuse_report set status = $3, resolved_by = $4, resolved_at = $5 "+
+			"where domain = $1 and short_url = $2 and status = $6",
+		domain, shortURL, model.ReportActioned, adminID, resolvedAt, model.ReportOpen)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetURLsForHealthCheck lists links whose destinations are due for a health check,
+// never checked links first. Deleted, disabled and bundle links are skipped.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - checkedBefore: links checked at or after this time are not due
+//   - limit: maximum number of links to return
+//
+// Returns:
+//   - []model.URL: due links with domain, short URL and destination
+//   - error: error if database operation fails
+func (p *PostgresRepository) GetURLsForHealthCheck(ctx context.Context,
+	checkedBefore time.Time,
+	limit int,
+) ([]model.URL, error) {
+	rows, err := p.db.QueryContext(ctx,
+		"select short_url, domain, original_url from t_short_url "+
+			"where is_deleted = false and is_bundle = false and is_disabled = false "+
+			"and (health_checked_at is null or health_checked_at < $1) "+
+			"order by health_checked_at nulls first limit $2",
+		checkedBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query URLs for health check: %w", err)
+	}
+	defer rows.Close()
+	var urls []model.URL
+	for rows.Next() {
+		var url model.URL
+		err = rows.Scan(&url.ShortURL, &url.Domain, &url.OriginalURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan URL row: %w", err)
+		}
+		urls = append(urls, url)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+	return urls, nil
+}
+
+// SaveURLHealth records the result of a destination health check.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - shortURL: short URL identifier
+//   - health: result of the check
+//
+// Returns:
+//   - error: ErrNotFound if the link does not exist, or database error
+func (p *PostgresRepository) SaveURLHealth(ctx context.Context,
+	domain string,
+	shortURL string,
+	health model.URLHealth,
+) error {
+	result, err := p.db.ExecContext(ctx,
+		"update t_short_url set health_status = $3, health_status_code = $4, health_checked_at = $5 "+
+			"where domain = $1 and short_url = $2",
+		domain, shortURL, health.Status, health.StatusCode, health.CheckedAt)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SaveURLMetadata stores the title, description and preview image fetched from a link destination.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - shortURL: short URL identifier
+//   - metadata: fetched metadata
+//
+// Returns:
+//   - error: ErrNotFound if the link does not exist, or database error
+func (p *PostgresRepository) SaveURLMetadata(ctx context.Context,
+	domain string,
+	shortURL string,
+	metadata model.URLMetadata,
+) error {
+	result, err := p.db.ExecContext(ctx,
+		"update t_short_url set meta_title = $3, meta_description = $4, meta_image = $5, meta_site_name = $6, "+
+			"meta_fetched_at = $7 where domain = $1 and short_url = $2",
+		domain, shortURL, metadata.Title, metadata.Description, metadata.Image, metadata.SiteName, metadata.FetchedAt)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetUserBanned bans a user or lifts the ban.
+// Banning a banned user keeps the original ban time.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//   - banned: true to ban the user, false to lift the ban
+//
+// Returns:
+//   - error: error if database operation fails
+func (p *PostgresRepository) SetUserBanned(ctx context.Context, userID string, banned bool) error {
+	query := "delete from t_banned_user where user_id = $1"
+	if banned {
+		query = "insert into t_banned_user(user_id) values ($1) on conflict (user_id) do nothing"
+	}
+	_, err := p.db.ExecContext(ctx, query, userID)
+	return err
+}
+
+// IsUserBanned reports whether a user is banned.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//
+// Returns:
+//   - bool: true if the user is banned
+//   - error: error if database operation fails
+func (p *PostgresRepository) IsUserBanned(ctx context.Context, userID string) (bool, error) {
+	row := p.db.QueryRowContext(ctx,
+		"select exists(select 1 from t_banned_user where user_id = $1)",
+		userID)
+	var banned bool
+	if err := row.Scan(&banned); err != nil {
+		return false, err
+	}
+	return banned, nil
+}
+
+// GetUserUsage counts links and API keys of a user in a single query.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//
+// Returns:
+//   - *model.UserUsage: usage of the user, zero counts for unknown users
+//   - error: error if database operation fails
+func (p *PostgresRepository) GetUserUsage(ctx context.Context, userID string) (*model.UserUsage, error) {
+	row := p.db.QueryRowContext(ctx,
+		"select count(*) filter (where not is_deleted), count(*) filter (where is_deleted), "+
+			"count(*) filter (where is_disabled), "+
+			"(select count(*) from t_api_key where user_id = $1), "+
+			"(select banned_at from t_banned_user where user_id = $1) "+
+			"from t_short_url where user_id = $1",
+		userID)
+	usage := &model.UserUsage{UserID: userID}
+	var bannedAt sql.NullTime
+	err := row.Scan(&usage.Links, &usage.DeletedLinks, &usage.DisabledLinks, &usage.APIKeys, &bannedAt)
+	if err != nil {
+		return nil, err
+	}
+	if bannedAt.Valid {
+		usage.IsBanned = true
+		usage.BannedAt = &bannedAt.Time
+	}
+	return usage, nil
+}
+
+// GetStats counts links that are not deleted and distinct users who created links in a single query.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//
+// Returns:
+//   - *model.Stats: link and user counts
+//   - error: error if database operation fails
+func (p *PostgresRepository) GetStats(ctx context.Context) (*model.Stats, error) {
+	row := p.db.QueryRowContext(ctx,
+		"select count(*) filter (where not is_deleted), count(distinct user_id) from t_short_url")
+	stats := &model.Stats{}
+	if err := row.Scan(&stats.URLs, &stats.Users); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetQuotaUsage counts links of a user created in a day and links that are not deleted in a single query.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//   - day: UTC day the created links are counted for
+//
+// Returns:
+//   - *model.QuotaUsage: quota counters of the user, zero counts for unknown users
+//   - error: error if database operation fails
+func (p *PostgresRepository) GetQuotaUsage(ctx context.Context, userID string, day time.Time) (*model.QuotaUsage, error) {
+	row := p.db.QueryRowContext(ctx,
+		"select coalesce((select links from t_user_daily_links where user_id = $1 and day = $2), 0), "+
+			"(select count(*) from t_short_url where user_id = $1 and not is_deleted)",
+		userID, day)
+	usage := &model.QuotaUsage{}
+	if err := row.Scan(&usage.LinksToday, &usage.ActiveLinks); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// ReserveLinks checks the limits of a user and counts links the user is about to create in a single transaction.
+// The daily counter row of the user is created or locked first and drops counters of earlier days,
+// so that reservations of concurrent requests of the user are made one by one. Stored links are counted
+// only after the lock is taken, so links stored by requests that already released their reservations are seen.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//   - day: UTC day the links are created in
+//   - count: number of links to create
+//   - limits: limits of the user, zero limits are unlimited
+//
+// Returns:
+//   - *model.QuotaUsage: usage before the reservation, pending links of other requests included
+//   - bool: false if a limit would be exceeded and nothing was reserved
+//   - error: error if database operation fails
+func (p *PostgresRepository) ReserveLinks(ctx context.Context,
+	userID string,
+	day time.Time,
+	count int64,
+	limits model.QuotaLimits,
+) (*model.QuotaUsage, bool, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	usage := &model.QuotaUsage{}
+	var pending int64
+	err = tx.QueryRowContext(ctx,
+		"with purged as (delete from t_user_daily_links where user_id = $1 and day < $2) "+
+			"insert into t_user_daily_links(user_id, day, links, pending) values ($1, $2, 0, 0) "+
+			"on conflict (user_id, day) do update set links = t_user_daily_links.links "+
+			"returning links, pending",
+		userID, day).Scan(&usage.LinksToday, &pending)
+	if err != nil {
+		tx.Rollback()
+		return nil, false, err
+	}
+	err = tx.QueryRowContext(ctx,
+		"select count(*) from t_short_url where user_id = $1 and not is_deleted",
+		userID).Scan(&usage.ActiveLinks)
+	if err != nil {
+		tx.Rollback()
+		return nil, false, err
+	}
+	usage.ActiveLinks += pending
+	if !canReserve(limits, *usage, count) {
+		return usage, false, tx.Rollback()
+	}
+	_, err = tx.ExecContext(ctx,
+		"update t_user_daily_links set links = links + $3, pending = pending + $3 where user_id = $1 and day = $2",
+		userID, day, count)
+	if err != nil {
+		tx.Rollback()
+		return nil, false, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, false, err
+	}
+	return usage, true, nil
+}
+
+// ReleaseLinks ends a reservation made by ReserveLinks.
+// Nothing is changed if the counter of the day was already dropped.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//   - day: UTC day the links were reserved in
+//   - reserved: number of reserved links
+//   - unused: number of reserved links that were not created
+//
+// Returns:
+//   - error: error if database operation fails
+func (p *PostgresRepository) ReleaseLinks(ctx context.Context,
+	userID string,
+	day time.Time,
+	reserved int64,
+	unused int64,
+) error {
+	_, err := p.db.ExecContext(ctx,
+		"update t_user_daily_links set links = greatest(links - $4, 0), pending = greatest(pending - $3, 0) "+
+			"where user_id = $1 and day = $2",
+		userID, day, reserved, unused)
+	return err
+}
+
+// GetUserQuotaLimits retrieves the limits assigned to a single user.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//
+// Returns:
+//   - *model.QuotaLimits: limits of the user
+//   - error: ErrNotFound if the user has the default limits, or database error
+func (p *PostgresRepository) GetUserQuotaLimits(ctx context.Context, userID string) (*model.QuotaLimits, error) {
+	row := p.db.QueryRowContext(ctx,
+		"select links_per_day, active_links, batch_size, delete_batch_size from t_user_quota where user_id = $1",
+		userID)
+	limits := &model.QuotaLimits{}
+	err := row.Scan(&limits.LinksPerDay, &limits.ActiveLinks, &limits.BatchSize, &limits.DeleteBatchSize)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+// SaveUserQuotaLimits inserts or replaces the limits of a single user.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//   - limits: limits of the user, zero limits are unlimited
+//
+// Returns:
+//   - error: error if database operation fails
+func (p *PostgresRepository) SaveUserQuotaLimits(ctx context.Context, userID string, limits model.QuotaLimits) error {
+	_, err := p.db.ExecContext(ctx,
+		"insert into t_user_quota(user_id, links_per_day, active_links, batch_size, delete_batch_size) "+
+			"values ($1, $2, $3, $4, $5) "+
+			"on conflict (user_id) do update set links_per_day = excluded.links_per_day, "+
+			"active_links = excluded.active_links, batch_size = excluded.batch_size, "+
+			"delete_batch_size = excluded.delete_batch_size",
+		userID, limits.LinksPerDay, limits.ActiveLinks, limits.BatchSize, limits.DeleteBatchSize)
+	return err
+}
+
+// TakeRateLimitTokens refills the token buckets of the keys and takes a single token from each of them
+// if all of them allow the request. Buckets are shared by all replicas using the database.
+// Every bucket row is created or locked by a single upsert, in the order of keys, so that concurrent first requests
+// cannot overwrite each other, and up to a hundred buckets that refilled completely are dropped afterwards.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - keys: identifiers of the buckets
+//   - limit: budget of the buckets
+//   - now: time of the request
+//
+// Returns:
+//   - []model.RateLimitDecision: whether each bucket allows the request and its state, in the order of keys
+//   - error: error if database operation fails
+func (p *PostgresRepository) TakeRateLimitTokens(ctx context.Context,
+	keys []string,
+	limit model.RateLimit,
+	now time.Time,
+) ([]model.RateLimitDecision, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	order := slices.Clone(keys)
+	slices.Sort(order)
+	buckets := make(map[string]model.TokenBucket, len(keys))
+	for _, key := range slices.Compact(order) {
+		var bucket model.TokenBucket
+		err = tx.QueryRowContext(ctx,
+			"insert into t_rate_limit_bucket(key, tokens, updated_at, full_at) values ($1, $2, $3, $3) "+
+				"on conflict (key) do update set key = excluded.key returning tokens, updated_at",
+			key, float64(limit.Capacity()), now).
+			Scan(&bucket.Tokens, &bucket.UpdatedAt)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		buckets[key] = bucket
+	}
+
+	taken := make([]model.TokenBucket, len(keys))
+	decisions := make([]model.RateLimitDecision, len(keys))
+	allowed := true
+	for i, key := range keys {
+		taken[i], decisions[i] = buckets[key].Take(limit, now)
+		allowed = allowed && decisions[i].Allowed
+	}
+	if allowed {
+		for i, key := range keys {
+			_, err = tx.ExecContext(ctx,
+				"update t_rate_limit_bucket set tokens = $2, updated_at = $3, full_at = $4 where key = $1",
+				key, taken[i].Tokens, taken[i].UpdatedAt, taken[i].FullAt(limit))
+			if err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"delete from t_rate_limit_bucket where key in (select key from t_rate_limit_bucket "+
+			"where full_at < $1 limit 100 for update skip locked)", now)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return decisions, tx.Commit()
+}
+
+// Ping checks the connectivity to PostgreSQL database.
+// Used for health checks and connection validation.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//
+// Returns:
+//   - error: error if database is unreachable
+func (p *PostgresRepository) Ping(ctx context.Context) error {
+	return p.db.PingContext(ctx)
+}
+
+// Close closes the database connection and releases resources.
+// Should be called when the repository is no longer needed.
+//
+// Returns:
+//   - error: error if database closing fails
+func (p *PostgresRepository) Close() error {
+	return p.db.Close()
+}
+
+// hashOriginalURL returns the hex-encoded SHA-256 digest of the original URL.
+// The digest is stored in original_url_hash and used for uniqueness checks and lookups,
+// so that original URLs of arbitrary length can be indexed.
+// New links hash the canonical form of the URL returned by model.URL.DedupURL.
+// Links stored before canonicalization was introduced keep the digest of the URL as sent, so they are found
+// only by URLs already in canonical form. They are not rewritten by a migration, because canonicalization
+// is implemented in the service and depends on its configuration.
+//
+// Parameters:
+//   - originalURL: original URL to hash
+//
+// Returns:
+//   - string: 64-character hex digest
+func hashOriginalURL(originalURL string) string {
+	sum := sha256.Sum256([]byte(originalURL))
+	return hex.EncodeToString(sum[:])
+}
+
+// joinReviewReasons encodes review reasons for the review_reasons column.
+//
+// Parameters:
+//   - reasons: review reasons of a link
+//
+// Returns:
+//   - string: comma separated reasons, empty for links not under review
+func joinReviewReasons(reasons []model.ReviewReason) string {
+	values := make([]string, len(reasons))
+	for i, reason := range reasons {
+		values[i] = string(reason)
+	}
+	return strings.Join(values, ",")
+}
+
+// splitReviewReasons decodes the review_reasons column.
+//
+// Parameters:
+//   - value: comma separated reasons
+//
+// Returns:
+//   - []model.ReviewReason: review reasons, nil for links not under review
+func splitReviewReasons(value string) []model.ReviewReason {
+	if value == "" {
+		return nil
+	}
+	var reasons []model.ReviewReason
+	for _, reason := range strings.Split(value, ",") {
+		reasons = append(reasons, model.ReviewReason(reason))
+	}
+	return reasons
+}
+
+// isUniqueViolation checks if an error is a PostgreSQL unique constraint violation.
+// Helper function for handling duplicate key errors.
+//
+// Parameters:
+//   - err: error to check
+//
+// Returns:
+//   - bool: true if error is a unique constraint violation
+func isUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgerrcode.UniqueViolation
+	}
+	return false
+}
+
+// ErrURLConflict represents a URL conflict error when original URL already exists.
+// Contains the existing short URL for the original URL.
+type ErrURLConflict struct {
+	ShortURL string
+	Err      string
+}
+
+// Error returns the string representation of the URL conflict error.
+// Implements the error interface.
+//
+// Returns:
+//   - string: error message
+func (err *ErrURLConflict) Error() string {
+	return err.Err
+}
+
+// scanUser reads a user row.
+//
+// Parameters:
+//   - row: row with id, login and password_hash columns
+//
+// Returns:
+//   - *model.User: scanned user
+//   - error: ErrNotFound if there is no row, or database error
+func scanUser(row *sql.Row) (*model.User, error) {
+	var user model.User
+	err := row.Scan(&user.ID, &user.Login, &user.PasswordHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// abuseReportColumns lists the columns read by scanAbuseReport.
+const abuseReportColumns = "id, domain, short_url, reason, details, coalesce(reporter_id, ''), status, created_at, " +
+	"coalesce(resolved_by, ''), resolved_at"
+
+// scanAbuseReport reads an abuse report row.
+//
+// Parameters:
+//   - row: row with abuseReportColumns
+//
+// Returns:
+//   - *model.AbuseReport: scanned report
+//   - error: database error
+func scanAbuseReport(row rowScanner) (*model.AbuseReport, error) {
+	var report model.AbuseReport
+	var resolvedAt sql.NullTime
+	err := row.Scan(&report.ID, &report.Domain, &report.ShortURL, &report.Reason, &report.Details,
+		&report.ReporterID, &report.Status, &report.CreatedAt, &report.ResolvedBy, &resolvedAt)
+	if err != nil {
+		return nil, err
+	}
+	if resolvedAt.Valid {
+		report.ResolvedAt = &resolvedAt.Time
+	}
+	return &report, nil
+}
+
+// listedURLColumns are the columns of links in user and workspace listings, read by scanListedURL.
+const listedURLColumns = "short_url, domain, original_url, health_status, health_status_code, health_checked_at, " +
+	"meta_title, meta_description, meta_image, meta_site_name, meta_fetched_at"
+
+// scanListedURL reads a link row with listedURLColumns.
+// Health and metadata stay nil until the destination is checked or fetched.
+//
+// Parameters:
+//   - row: row with listedURLColumns
+//   - url: link to fill, other fields are kept
+//
+// Returns:
+//   - error: database error
+func scanListedURL(row rowScanner, url *model.URL) error {
+	var health model.URLHealth
+	var metadata model.URLMetadata
+	var checkedAt, fetchedAt sql.NullTime
+	err := row.Scan(&url.ShortURL, &url.Domain, &url.OriginalURL,
+		&health.Status, &health.StatusCode, &checkedAt,
+		&metadata.Title, &metadata.Description, &metadata.Image, &metadata.SiteName, &fetchedAt)
+	if err != nil {
+		return err
+	}
+	if health.Status != "" {
+		health.CheckedAt = checkedAt.Time
+		url.Health = &health
+	}
+	if fetchedAt.Valid {
+		metadata.FetchedAt = fetchedAt.Time
+		url.Metadata = &metadata
+	}
+	return nil
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanAPIKey reads an API key row.
+//
+// Parameters:
+//   - row: row with id, user_id, name, prefix, key_hash, scopes, rate_limit, created_at and last_used_at columns
+//
+// Returns:
+//   - *model.APIKey: scanned key
+//   - error: database error
+func scanAPIKey(row rowScanner) (*model.APIKey, error) {
+	var key model.APIKey
+	var scopes string
+	var lastUsedAt sql.NullTime
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.KeyHash,
+		&scopes, &key.RateLimit, &key.CreatedAt, &lastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	key.Scopes = splitAPIKeyScopes(scopes)
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	return &key, nil
+}
+
+// joinAPIKeyScopes encodes API key scopes for the scopes column.
+//
+// Parameters:
+//   - scopes: scopes of the key
+//
+// Returns:
+//   - string: comma separated scopes
+func joinAPIKeyScopes(scopes []model.APIKeyScope) string {
+	values := make([]string, len(scopes))
+	for i, scope := range scopes {
+		values[i] = string(scope)
+	}
+	return strings.Join(values, ",")
+}
+
+// splitAPIKeyScopes decodes API key scopes stored by joinAPIKeyScopes.
+//
+// Parameters:
+//   - scopes: comma separated scopes
+//
+// Returns:
+//   - []model.APIKeyScope: scopes of the key
+func splitAPIKeyScopes(scopes string) []model.APIKeyScope {
+	if scopes == "" {
+		return nil
+	}
+	values := strings.Split(scopes, ",")
+	result := make([]model.APIKeyScope, len(values))
+	for i, value := range values {
+		result[i] = model.APIKeyScope(value)
+	}
+	return result
 }
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testDatabaseDSNEnv names the environment variable with the DSN of a scratch PostgreSQL database.
+// Integration tests are skipped when it is not set.
+const testDatabaseDSNEnv = "TEST_DATABASE_DSN"
+
+func setupPostgresIntegration(t *testing.T) (*PostgresRepository, string) {
+	dsn := os.Getenv(testDatabaseDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDatabaseDSNEnv)
+	}
+
+	m, err := migrate.New("file://../../migrations", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open migrations: %v", err)
+	}
+	if err = m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		t.Fatalf("Failed to apply migrations: %v", err)
+	}
+	m.Close()
+
+	repo, err := NewPostgresRepository(dsn)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	t.Cleanup(func() {
+		repo.Close()
+	})
+	// Every test works on its own domain, so that runs do not see each other's links
+	return repo, uuid.NewString()[:8] + ".example.com"
+}
+
+func TestPostgresIntegrationSave_ReplacesDeletedWorkspaceURL(t *testing.T) {
+	repo, domain := setupPostgresIntegration(t)
+	ctx := context.TODO()
+
+	workspace := model.Workspace{ID: uuid.NewString(), Name: "Team"}
+	assert.NoError(t, repo.CreateWorkspace(ctx, workspace, "user-1"))
+	assert.NoError(t, repo.Save(ctx, "user-1", model.URL{ShortURL: "team0001", Domain: domain,
+		WorkspaceID: workspace.ID, OriginalURL: "https://example.com/a"}))
+	assert.NoError(t, repo.SaveURLMetadata(ctx, domain, "team0001", model.URLMetadata{Title: "Team page"}))
+	assert.NoError(t, repo.DeleteWorkspaceBatch(ctx, domain, workspace.ID, []string{"team0001"}))
+
+	assert.NoError(t, repo.Save(ctx, "user-1", model.URL{ShortURL: "user0001", Domain: domain,
+		OriginalURL: "https://example.com/a"}))
+
+	workspaceURLs, err := repo.GetByWorkspaceID(ctx, workspace.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, workspaceURLs)
+	userURLs, err := repo.GetByUserID(ctx, "user-1")
+	assert.NoError(t, err)
+	var found []string
+	for _, url := range userURLs {
+		if url.Domain == domain {
+			found = append(found, url.ShortURL)
+			assert.Nil(t, url.Metadata)
+		}
+	}
+	assert.Equal(t, []string{"user0001"}, found)
+	_, err = repo.GetByShortURL(ctx, domain, "team0001")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestPostgresIntegrationSave_ReplacesDeletedReportedURL(t *testing.T) {
+	repo, domain := setupPostgresIntegration(t)
+	ctx := context.TODO()
+
+	assert.NoError(t, repo.Save(ctx, "user-1", model.URL{ShortURL: "abuse001", Domain: domain,
+		OriginalURL: "https://example.com/b"}))
+	report := model.AbuseReport{ID: uuid.NewString(), Domain: domain, ShortURL: "abuse001",
+		Reason: model.ReportSpam, Status: model.ReportOpen, CreatedAt: time.Now()}
+	assert.NoError(t, repo.SaveAbuseReport(ctx, report))
+	assert.NoError(t, repo.TakeDownURL(ctx, domain, "abuse001", model.TakedownAbuse, "admin-1", time.Now()))
+	assert.NoError(t, repo.DeleteBatch(ctx, domain, "user-1", []string{"abuse001"}))
+
+	assert.NoError(t, repo.Save(ctx, "user-1", model.URL{ShortURL: "abuse002", Domain: domain,
+		OriginalURL: "https://example.com/b"}))
+
+	url, err := repo.GetByShortURL(ctx, domain, "abuse002")
+	assert.NoError(t, err)
+	assert.False(t, url.IsDeleted)
+	assert.False(t, url.IsDisabled)
+	assert.Empty(t, url.Takedown)
+	_, err = repo.GetAbuseReport(ctx, report.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestPostgresIntegrationSaveBatch_ReplacesDeletedReportedURL(t *testing.T) {
+	repo, domain := setupPostgresIntegration(t)
+	ctx := context.TODO()
+
+	assert.NoError(t, repo.SaveBatch(ctx, "user-1", []model.URL{{ShortURL: "batch001", Domain: domain,
+		OriginalURL: "https://example.com/c"}}))
+	report := model.AbuseReport{ID: uuid.NewString(), Domain: domain, ShortURL: "batch001",
+		Reason: model.ReportPhishing, Status: model.ReportOpen, CreatedAt: time.Now()}
+	assert.NoError(t, repo.SaveAbuseReport(ctx, report))
+	assert.NoError(t, repo.DeleteBatch(ctx, domain, "user-1", []string{"batch001"}))
+
+	assert.NoError(t, repo.SaveBatch(ctx, "user-1", []model.URL{{ShortURL: "batch002", Domain: domain,
+		OriginalURL: "https://example.com/c"}}))
+
+	url, err := repo.GetByShortURL(ctx, domain, "batch002")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/c", url.OriginalURL)
+	_, err = repo.GetAbuseReport(ctx, report.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestPostgresIntegrationReserveLinks_Concurrent(t *testing.T) {
+	repo, domain := setupPostgresIntegration(t)
+	ctx := context.TODO()
+	userID := "user-" + domain
+	today := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	limits := model.QuotaLimits{LinksPerDay: 10, ActiveLinks: 5}
+
+	var wg sync.WaitGroup
+	var reservedCount atomic.Int64
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, reserved, err := repo.ReserveLinks(ctx, userID, today, 1, limits)
+			assert.NoError(t, err)
+			if !reserved {
+				return
+			}
+			reservedCount.Add(1)
+			assert.NoError(t, repo.Save(ctx, userID, model.URL{ShortURL: fmt.Sprintf("conc%04d", i), Domain: domain,
+				OriginalURL: fmt.Sprintf("https://example.com/%d", i)}))
+			assert.NoError(t, repo.ReleaseLinks(ctx, userID, today, 1, 0))
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(5), reservedCount.Load())
+	usage, err := repo.GetQuotaUsage(ctx, userID, today)
+	assert.NoError(t, err)
+	assert.Equal(t, &model.QuotaUsage{LinksToday: 5, ActiveLinks: 5}, usage)
+}
@@ -4,17 +4,30 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/bezjen/shortener/internal/model"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
+	"time"
 )
 
+// arrayValueConverter lets sqlmock accept string slices passed as postgres array parameters.
+type arrayValueConverter struct{}
+
+func (arrayValueConverter) ConvertValue(v interface{}) (driver.Value, error) {
+	if values, ok := v.([]string); ok {
+		return values, nil
+	}
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
 func setupPostgresRepository(t *testing.T) (*PostgresRepository, sqlmock.Sqlmock, func()) {
-	db, mock, err := sqlmock.New()
+	db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(arrayValueConverter{}))
 	if err != nil {
 		t.Fatalf("Failed to create sqlmock: %v", err)
 	}
@@ -45,7 +58,19 @@ func TestPostgresRepositorySave(t *testing.T) {
 			url:    *model.NewURL("qwerty12", "https://practicum.yandex.ru/"),
 			setupMock: func() {
 				mock.ExpectExec("insert into t_short_url").
-					WithArgs("qwerty12", "https://practicum.yandex.ru/", "user1").
+					WithArgs("qwerty12", "", "https://practicum.yandex.ru/", hashOriginalURL("https://practicum.yandex.ru/"), "user1", "", "").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			expectedError: nil,
+		},
+		{
+			name:   "Save canonical URL",
+			userID: "user1",
+			url: model.URL{ShortURL: "qwerty12", OriginalURL: "https://Practicum.yandex.ru:443",
+				CanonicalURL: "https://practicum.yandex.ru/"},
+			setupMock: func() {
+				mock.ExpectExec("insert into t_short_url").
+					WithArgs("qwerty12", "", "https://Practicum.yandex.ru:443", hashOriginalURL("https://practicum.yandex.ru/"), "user1", "", "").
 					WillReturnResult(sqlmock.NewResult(1, 1))
 			},
 			expectedError: nil,
@@ -57,14 +82,14 @@ func TestPostgresRepositorySave(t *testing.T) {
 			setupMock: func() {
 				// First insert fails with unique violation
 				mock.ExpectExec("insert into t_short_url").
-					WithArgs("qwerty12", "https://practicum.yandex.ru/", "user1").
+					WithArgs("qwerty12", "", "https://practicum.yandex.ru/", hashOriginalURL("https://practicum.yandex.ru/"), "user1", "", "").
 					WillReturnError(&pgconn.PgError{Code: pgerrcode.UniqueViolation})
 
 				// Then query to check if deleted
-				rows := sqlmock.NewRows([]string{"short_url", "is_deleted"}).
-					AddRow("existing123", false)
-				mock.ExpectQuery("select short_url, is_deleted from t_short_url where original_url =").
-					WithArgs("https://practicum.yandex.ru/").
+				rows := sqlmock.NewRows([]string{"short_url", "user_id", "is_deleted"}).
+					AddRow("existing123", "user2", false)
+				mock.ExpectQuery("select short_url, user_id, is_deleted from t_short_url where domain = \\$1 and original_url_hash =").
+					WithArgs("", hashOriginalURL("https://practicum.yandex.ru/")).
 					WillReturnRows(rows)
 			},
 			expectedError: &ErrURLConflict{ShortURL: "existing123", Err: "Original URL already exists"},
@@ -76,23 +101,127 @@ func TestPostgresRepositorySave(t *testing.T) {
 			setupMock: func() {
 				// First insert fails with unique violation
 				mock.ExpectExec("insert into t_short_url").
-					WithArgs("qwerty12", "https://practicum.yandex.ru/", "user1").
+					WithArgs("qwerty12", "", "https://practicum.yandex.ru/", hashOriginalURL("https://practicum.yandex.ru/"), "user1", "", "").
 					WillReturnError(&pgconn.PgError{Code: pgerrcode.UniqueViolation})
 
 				// Then query to check if deleted - returns true
-				rows := sqlmock.NewRows([]string{"short_url", "is_deleted"}).
-					AddRow("existing123", true)
-				mock.ExpectQuery("select short_url, is_deleted from t_short_url where original_url =").
-					WithArgs("https://practicum.yandex.ru/").
+				rows := sqlmock.NewRows([]string{"short_url", "user_id", "is_deleted"}).
+					AddRow("existing123", "user1", true)
+				mock.ExpectQuery("select short_url, user_id, is_deleted from t_short_url where domain = \\$1 and original_url_hash =").
+					WithArgs("", hashOriginalURL("https://practicum.yandex.ru/")).
 					WillReturnRows(rows)
 
-				// Then update the record
-				mock.ExpectExec("update t_short_url set short_url = \\$1, user_id = \\$2, is_deleted = false where original_url =").
-					WithArgs("qwerty12", "user1", "https://practicum.yandex.ru/").
+				// Then replace the deleted record by a new one
+				mock.ExpectBegin()
+				mock.ExpectExec("delete from t_short_url where domain = \\$1 and original_url_hash = \\$2 "+
+					"and user_id = \\$3 and is_deleted = true").
+					WithArgs("", hashOriginalURL("https://practicum.yandex.ru/"), "user1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("insert into t_short_url").
+					WithArgs("qwerty12", "", "https://practicum.yandex.ru/", hashOriginalURL("https://practicum.yandex.ru/"),
+						"user1", "", "").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			expectedError: nil,
+		},
+		{
+			name:   "Save with unique violation - deleted workspace URL replaced by a personal URL",
+			userID: "user1",
+			url:    *model.NewURL("qwerty12", "https://practicum.yandex.ru/"),
+			setupMock: func() {
+				mock.ExpectExec("insert into t_short_url").
+					WithArgs("qwerty12", "", "https://practicum.yandex.ru/", hashOriginalURL("https://practicum.yandex.ru/"),
+						"user1", "", "").
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.UniqueViolation})
+				mock.ExpectQuery("select short_url, user_id, is_deleted from t_short_url").
+					WithArgs("", hashOriginalURL("https://practicum.yandex.ru/")).
+					WillReturnRows(sqlmock.NewRows([]string{"short_url", "user_id", "is_deleted"}).
+						AddRow("existing123", "user1", true))
+
+				// The new record is inserted without the workspace of the deleted one
+				mock.ExpectBegin()
+				mock.ExpectExec("delete from t_short_url").
+					WithArgs("", hashOriginalURL("https://practicum.yandex.ru/"), "user1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("insert into t_short_url\\(short_url, domain, original_url, original_url_hash, user_id, "+
+					"workspace_id, is_deleted, review_reasons\\) values (.+)nullif\\(\\$6, ''\\)").
+					WithArgs("qwerty12", "", "https://practicum.yandex.ru/", hashOriginalURL("https://practicum.yandex.ru/"),
+						"user1", "", "").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			expectedError: nil,
+		},
+		{
+			name:   "Save with unique violation - short URL of the replacement taken",
+			userID: "user1",
+			url:    *model.NewURL("qwerty12", "https://practicum.yandex.ru/"),
+			setupMock: func() {
+				mock.ExpectExec("insert into t_short_url").
+					WithArgs("qwerty12", "", "https://practicum.yandex.ru/", hashOriginalURL("https://practicum.yandex.ru/"),
+						"user1", "", "").
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.UniqueViolation})
+				mock.ExpectQuery("select short_url, user_id, is_deleted from t_short_url").
+					WithArgs("", hashOriginalURL("https://practicum.yandex.ru/")).
+					WillReturnRows(sqlmock.NewRows([]string{"short_url", "user_id", "is_deleted"}).
+						AddRow("existing123", "user1", true))
+				mock.ExpectBegin()
+				mock.ExpectExec("delete from t_short_url").
+					WithArgs("", hashOriginalURL("https://practicum.yandex.ru/"), "user1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("insert into t_short_url").
+					WithArgs("qwerty12", "", "https://practicum.yandex.ru/", hashOriginalURL("https://practicum.yandex.ru/"),
+						"user1", "", "").
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.UniqueViolation})
+				mock.ExpectRollback()
+			},
+			expectedError: ErrShortURLConflict,
+		},
+		{
+			name:   "Save with unique violation - deleted URL replaced for another form of the URL",
+			userID: "user1",
+			url: model.URL{ShortURL: "qwerty12", OriginalURL: "HTTPS://Practicum.Yandex.ru/?utm_source=x",
+				CanonicalURL: "https://practicum.yandex.ru/"},
+			setupMock: func() {
+				mock.ExpectExec("insert into t_short_url").
+					WithArgs("qwerty12", "", "HTTPS://Practicum.Yandex.ru/?utm_source=x",
+						hashOriginalURL("https://practicum.yandex.ru/"), "user1", "", "").
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.UniqueViolation})
+				mock.ExpectQuery("select short_url, user_id, is_deleted from t_short_url").
+					WithArgs("", hashOriginalURL("https://practicum.yandex.ru/")).
+					WillReturnRows(sqlmock.NewRows([]string{"short_url", "user_id", "is_deleted"}).
+						AddRow("existing123", "user1", true))
+				mock.ExpectBegin()
+				mock.ExpectExec("delete from t_short_url").
+					WithArgs("", hashOriginalURL("https://practicum.yandex.ru/"), "user1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("insert into t_short_url").
+					WithArgs("qwerty12", "", "HTTPS://Practicum.Yandex.ru/?utm_source=x",
+						hashOriginalURL("https://practicum.yandex.ru/"), "user1", "", "").
 					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
 			},
 			expectedError: nil,
 		},
+		{
+			name:   "Save with unique violation - URL deleted by another user",
+			userID: "user1",
+			url:    *model.NewURL("qwerty12", "https://practicum.yandex.ru/"),
+			setupMock: func() {
+				mock.ExpectExec("insert into t_short_url").
+					WithArgs("qwerty12", "", "https://practicum.yandex.ru/", hashOriginalURL("https://practicum.yandex.ru/"), "user1", "", "").
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.UniqueViolation})
+
+				// Deleted URL stays reserved for its owner
+				rows := sqlmock.NewRows([]string{"short_url", "user_id", "is_deleted"}).
+					AddRow("existing123", "user2", true)
+				mock.ExpectQuery("select short_url, user_id, is_deleted from t_short_url where domain = \\$1 and original_url_hash =").
+					WithArgs("", hashOriginalURL("https://practicum.yandex.ru/")).
+					WillReturnRows(rows)
+			},
+			expectedError: ErrURLReserved,
+		},
 	}
 
 	for _, tt := range tests {
@@ -133,10 +262,10 @@ func TestPostgresRepositorySaveBatch(t *testing.T) {
 
 	mock.ExpectBegin()
 	mock.ExpectExec("insert into t_short_url").
-		WithArgs("qwerty12", "https://practicum.yandex.ru/", "user1").
+		WithArgs("qwerty12", "", "https://practicum.yandex.ru/", hashOriginalURL("https://practicum.yandex.ru/"), "user1", "", "").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectExec("insert into t_short_url").
-		WithArgs("qwerty13", "https://example.com/", "user1").
+		WithArgs("qwerty13", "", "https://example.com/", hashOriginalURL("https://example.com/"), "user1", "", "").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
 
@@ -145,167 +274,1474 @@ func TestPostgresRepositorySaveBatch(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestPostgresRepositoryGetByShortURL(t *testing.T) {
+func TestPostgresRepositorySaveBatch_ReplacesDeleted(t *testing.T) {
 	repo, mock, cleanup := setupPostgresRepository(t)
 	defer cleanup()
 
-	shortURL := "qwerty12"
-	originalURL := "https://practicum.yandex.ru/"
-	isDeleted := false
-
-	rows := sqlmock.NewRows([]string{"original_url", "is_deleted"}).
-		AddRow(originalURL, isDeleted)
+	batch := []model.URL{{ShortURL: "qwerty12", OriginalURL: "https://Example.com/?utm_source=x",
+		CanonicalURL: "https://example.com/"}}
 
-	mock.ExpectQuery("select original_url, is_deleted from t_short_url where short_url =").
-		WithArgs(shortURL).
-		WillReturnRows(rows)
+	mock.ExpectBegin()
+	mock.ExpectExec("insert into t_short_url").
+		WithArgs("qwerty12", "", "https://Example.com/?utm_source=x", hashOriginalURL("https://example.com/"),
+			"user1", "", "").
+		WillReturnError(&pgconn.PgError{Code: pgerrcode.UniqueViolation})
+	mock.ExpectQuery("select user_id, is_deleted from t_short_url").
+		WithArgs("", hashOriginalURL("https://example.com/")).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "is_deleted"}).AddRow("user1", true))
+	mock.ExpectBegin()
+	mock.ExpectExec("delete from t_short_url where domain = \\$1 and original_url_hash = \\$2 "+
+		"and user_id = \\$3 and is_deleted = true").
+		WithArgs("", hashOriginalURL("https://example.com/"), "user1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("insert into t_short_url").
+		WithArgs("qwerty12", "", "https://Example.com/?utm_source=x", hashOriginalURL("https://example.com/"),
+			"user1", "", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectCommit()
 
-	result, err := repo.GetByShortURL(context.TODO(), shortURL)
+	err := repo.SaveBatch(context.TODO(), "user1", batch)
 	assert.NoError(t, err)
-	assert.Equal(t, shortURL, result.ShortURL)
-	assert.Equal(t, originalURL, result.OriginalURL)
-	assert.Equal(t, isDeleted, result.IsDeleted)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestPostgresRepositoryGetByShortURL_NotFound(t *testing.T) {
+func TestPostgresRepositorySaveBatch_Reserved(t *testing.T) {
 	repo, mock, cleanup := setupPostgresRepository(t)
 	defer cleanup()
 
-	shortURL := "nonexistent"
+	batch := []model.URL{
+		*model.NewURL("qwerty12", "https://practicum.yandex.ru/"),
+	}
 
-	mock.ExpectQuery("select original_url, is_deleted from t_short_url where short_url =").
-		WithArgs(shortURL).
-		WillReturnError(sql.ErrNoRows)
+	mock.ExpectBegin()
+	mock.ExpectExec("insert into t_short_url").
+		WithArgs("qwerty12", "", "https://practicum.yandex.ru/", hashOriginalURL("https://practicum.yandex.ru/"), "user1", "", "").
+		WillReturnError(&pgconn.PgError{Code: pgerrcode.UniqueViolation})
+	mock.ExpectQuery("select user_id, is_deleted from t_short_url where domain = \\$1 and original_url_hash =").
+		WithArgs("", hashOriginalURL("https://practicum.yandex.ru/")).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "is_deleted"}).AddRow("user2", true))
+	mock.ExpectRollback()
 
-	result, err := repo.GetByShortURL(context.TODO(), shortURL)
-	assert.Error(t, err)
-	assert.Nil(t, result)
+	err := repo.SaveBatch(context.TODO(), "user1", batch)
+	assert.ErrorIs(t, err, ErrURLReserved)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestPostgresRepositoryGetByUserID(t *testing.T) {
+func TestPostgresRepositoryDeleteBatch(t *testing.T) {
 	repo, mock, cleanup := setupPostgresRepository(t)
 	defer cleanup()
 
-	userID := "user1"
-	expectedURLs := []model.URL{
-		*model.NewURL("qwerty12", "https://practicum.yandex.ru/"),
-		*model.NewURL("qwerty13", "https://example.com/"),
-	}
+	mock.ExpectBegin()
+	mock.ExpectPrepare("update t_short_url set is_deleted = true, deleted_at = now\\(\\) "+
+		"where domain = \\$1 and short_url = any\\(\\$2::text\\[\\]\\) and user_id = \\$3").
+		ExpectExec().
+		WithArgs("short.example.com", []string{"qwerty12", "qwerty13"}, "user1").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
 
-	rows := sqlmock.NewRows([]string{"short_url", "original_url"}).
-		AddRow("qwerty12", "https://practicum.yandex.ru/").
-		AddRow("qwerty13", "https://example.com/")
+	err := repo.DeleteBatch(context.TODO(), "short.example.com", "user1", []string{"qwerty12", "qwerty13"})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
 
-	mock.ExpectQuery("select short_url, original_url from t_short_url where user_id =").
-		WithArgs(userID).
+func TestPostgresRepositoryGetDeletedByUserID(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	deletedAt := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"short_url", "domain", "original_url", "deleted_at"}).
+		AddRow("qwerty12", "", "https://practicum.yandex.ru/", deletedAt)
+
+	mock.ExpectQuery("select short_url, domain, original_url, deleted_at from t_short_url where user_id = \\$1 and workspace_id is null and is_deleted = true").
+		WithArgs("user1").
 		WillReturnRows(rows)
 
-	urls, err := repo.GetByUserID(context.TODO(), userID)
+	urls, err := repo.GetDeletedByUserID(context.TODO(), "user1")
 	assert.NoError(t, err)
-	assert.Equal(t, expectedURLs, urls)
+	assert.Equal(t, []model.URL{{
+		ShortURL:    "qwerty12",
+		OriginalURL: "https://practicum.yandex.ru/",
+		IsDeleted:   true,
+		DeletedAt:   deletedAt,
+	}}, urls)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestPostgresRepositoryPing(t *testing.T) {
+func TestPostgresRepositoryRestoreBatch(t *testing.T) {
 	repo, mock, cleanup := setupPostgresRepository(t)
 	defer cleanup()
 
-	mock.ExpectPing()
+	mock.ExpectExec("update t_short_url set is_deleted = false, deleted_at = null "+
+		"where domain = \\$1 and short_url = any\\(\\$2::text\\[\\]\\) and user_id = \\$3").
+		WithArgs("short.example.com", []string{"qwerty12"}, "user1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	err := repo.Ping(context.TODO())
+	err := repo.RestoreBatch(context.TODO(), "short.example.com", "user1", []string{"qwerty12"})
 	assert.NoError(t, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestPostgresRepositoryClose(t *testing.T) {
+func TestPostgresRepositoryCreateWorkspace(t *testing.T) {
 	repo, mock, cleanup := setupPostgresRepository(t)
 	defer cleanup()
 
-	mock.ExpectClose()
+	mock.ExpectBegin()
+	mock.ExpectExec("insert into t_workspace\\(id, name\\)").
+		WithArgs("ws1", "Marketing").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("insert into t_workspace_member").
+		WithArgs("ws1", "user1", model.RoleOwner).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
-	err := repo.Close()
+	err := repo.CreateWorkspace(context.TODO(), *model.NewWorkspace("ws1", "Marketing"), "user1")
 	assert.NoError(t, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestPostgresRepositoryGetShortURLByOriginalURL(t *testing.T) {
+func TestPostgresRepositoryGetWorkspacesByUserID(t *testing.T) {
 	repo, mock, cleanup := setupPostgresRepository(t)
 	defer cleanup()
 
-	originalURL := "https://practicum.yandex.ru/"
-	expectedShortURL := "qwerty12"
+	rows := sqlmock.NewRows([]string{"id", "name", "role"}).AddRow("ws1", "Marketing", "editor")
+	mock.ExpectQuery("select w.id, w.name, m.role from t_workspace w").
+		WithArgs("user1").
+		WillReturnRows(rows)
+
+	workspaces, err := repo.GetWorkspacesByUserID(context.TODO(), "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Workspace{{ID: "ws1", Name: "Marketing", Role: model.RoleEditor}}, workspaces)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryGetWorkspaceRole(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	mock.ExpectQuery("select role from t_workspace_member where workspace_id = \\$1 and user_id = \\$2").
+		WithArgs("ws1", "user1").
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("owner"))
+	mock.ExpectQuery("select role from t_workspace_member").
+		WithArgs("ws1", "stranger").
+		WillReturnError(sql.ErrNoRows)
+
+	role, err := repo.GetWorkspaceRole(context.TODO(), "ws1", "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, model.RoleOwner, role)
+
+	_, err = repo.GetWorkspaceRole(context.TODO(), "ws1", "stranger")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryWorkspaceMembers(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	member := model.WorkspaceMember{WorkspaceID: "ws1", UserID: "user2", Role: model.RoleViewer}
+	mock.ExpectExec("insert into t_workspace_member(.+)on conflict \\(workspace_id, user_id\\) do update").
+		WithArgs("ws1", "user2", model.RoleViewer).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("select user_id, role from t_workspace_member where workspace_id = \\$1").
+		WithArgs("ws1").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "role"}).
+			AddRow("user1", "owner").
+			AddRow("user2", "viewer"))
+	mock.ExpectExec("delete from t_workspace_member where workspace_id = \\$1 and user_id = \\$2").
+		WithArgs("ws1", "user2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.SaveWorkspaceMember(context.TODO(), member))
+
+	members, err := repo.GetWorkspaceMembers(context.TODO(), "ws1")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.WorkspaceMember{
+		{WorkspaceID: "ws1", UserID: "user1", Role: model.RoleOwner},
+		member,
+	}, members)
+
+	assert.NoError(t, repo.DeleteWorkspaceMember(context.TODO(), "ws1", "user2"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// newListedURLRows creates rows with the columns of links in user and workspace listings.
+func newListedURLRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"short_url", "domain", "original_url",
+		"health_status", "health_status_code", "health_checked_at",
+		"meta_title", "meta_description", "meta_image", "meta_site_name", "meta_fetched_at"})
+}
+
+func TestPostgresRepositoryGetByWorkspaceID(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
 
-	rows := sqlmock.NewRows([]string{"short_url"}).AddRow(expectedShortURL)
-	mock.ExpectQuery("select short_url from t_short_url where original_url =").
-		WithArgs(originalURL).
+	fetchedAt := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	rows := newListedURLRows().
+		AddRow("qwerty12", "", "https://practicum.yandex.ru/", "", 0, nil,
+			"Practicum", "Online courses", "https://practicum.yandex.ru/og.png", "", fetchedAt)
+	mock.ExpectQuery("select short_url, domain, original_url, .*, meta_fetched_at from t_short_url " +
+		"where workspace_id = \\$1").
+		WithArgs("ws1").
 		WillReturnRows(rows)
 
-	shortURL, err := repo.getShortURLByOriginalURL(context.TODO(), originalURL)
+	urls, err := repo.GetByWorkspaceID(context.TODO(), "ws1")
 	assert.NoError(t, err)
-	assert.Equal(t, expectedShortURL, shortURL)
+	assert.Equal(t, []model.URL{{
+		ShortURL:    "qwerty12",
+		WorkspaceID: "ws1",
+		OriginalURL: "https://practicum.yandex.ru/",
+		Metadata: &model.URLMetadata{
+			Title:       "Practicum",
+			Description: "Online courses",
+			Image:       "https://practicum.yandex.ru/og.png",
+			FetchedAt:   fetchedAt,
+		},
+	}}, urls)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestPostgresRepositoryGetShortURLByOriginalURL_NotFound(t *testing.T) {
+func TestPostgresRepositoryDeleteWorkspaceBatch(t *testing.T) {
 	repo, mock, cleanup := setupPostgresRepository(t)
 	defer cleanup()
 
-	originalURL := "https://nonexistent.com/"
+	mock.ExpectExec("update t_short_url set is_deleted = true, deleted_at = now\\(\\) "+
+		"where domain = \\$1 and short_url = any\\(\\$2::text\\[\\]\\) and workspace_id = \\$3").
+		WithArgs("short.example.com", []string{"qwerty12"}, "ws1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	mock.ExpectQuery("select short_url from t_short_url where original_url =").
-		WithArgs(originalURL).
-		WillReturnError(sql.ErrNoRows)
+	assert.NoError(t, repo.DeleteWorkspaceBatch(context.TODO(), "short.example.com", "ws1", []string{"qwerty12"}))
+	assert.NoError(t, repo.DeleteWorkspaceBatch(context.TODO(), "short.example.com", "ws1", nil))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
 
-	shortURL, err := repo.getShortURLByOriginalURL(context.TODO(), originalURL)
-	assert.Error(t, err)
-	assert.Equal(t, "", shortURL)
+func TestPostgresRepositoryGetDeletedByWorkspaceID(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	deletedAt := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"short_url", "domain", "original_url", "deleted_at"}).
+		AddRow("qwerty12", "", "https://practicum.yandex.ru/", deletedAt)
+	mock.ExpectQuery("select short_url, domain, original_url, deleted_at from t_short_url " +
+		"where workspace_id = \\$1 and is_deleted = true").
+		WithArgs("ws1").
+		WillReturnRows(rows)
+
+	urls, err := repo.GetDeletedByWorkspaceID(context.TODO(), "ws1")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.URL{{
+		ShortURL:    "qwerty12",
+		WorkspaceID: "ws1",
+		OriginalURL: "https://practicum.yandex.ru/",
+		IsDeleted:   true,
+		DeletedAt:   deletedAt,
+	}}, urls)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestIsUniqueViolation(t *testing.T) {
+func TestPostgresRepositoryRestoreWorkspaceBatch(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	mock.ExpectExec("update t_short_url set is_deleted = false, deleted_at = null "+
+		"where domain = \\$1 and short_url = any\\(\\$2::text\\[\\]\\) and workspace_id = \\$3").
+		WithArgs("short.example.com", []string{"qwerty12"}, "ws1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.RestoreWorkspaceBatch(context.TODO(), "short.example.com", "ws1", []string{"qwerty12"}))
+	assert.NoError(t, repo.RestoreWorkspaceBatch(context.TODO(), "short.example.com", "ws1", nil))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryUpdateWorkspaceURL(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	url := model.URL{ShortURL: "qwerty12", OriginalURL: "https://Practicum.yandex.ru:443",
+		CanonicalURL: "https://practicum.yandex.ru/"}
+	hash := hashOriginalURL("https://practicum.yandex.ru/")
+
 	tests := []struct {
-		name     string
-		err      error
-		expected bool
+		name          string
+		setupMock     func()
+		expectedError error
 	}{
 		{
-			name:     "Unique violation error",
-			err:      &pgconn.PgError{Code: pgerrcode.UniqueViolation},
-			expected: true,
+			name: "Update successfully",
+			setupMock: func() {
+				mock.ExpectExec("update t_short_url set original_url = \\$1, original_url_hash = \\$2").
+					WithArgs("https://Practicum.yandex.ru:443", hash, "", "", "qwerty12", "ws1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
 		},
 		{
-			name:     "Other PgError",
-			err:      &pgconn.PgError{Code: "22000"}, // Some other error code (class 22 - data exception)
-			expected: false,
+			name: "Link not found",
+			setupMock: func() {
+				mock.ExpectExec("update t_short_url set original_url").
+					WithArgs("https://Practicum.yandex.ru:443", hash, "", "", "qwerty12", "ws1").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedError: ErrNotFound,
 		},
 		{
-			name:     "Other error",
-			err:      errors.New("some other error"),
-			expected: false,
+			name: "Original URL already shortened",
+			setupMock: func() {
+				mock.ExpectExec("update t_short_url set original_url").
+					WithArgs("https://Practicum.yandex.ru:443", hash, "", "", "qwerty12", "ws1").
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.UniqueViolation})
+				mock.ExpectQuery("select short_url, is_deleted from t_short_url where domain = \\$1").
+					WithArgs("", hash).
+					WillReturnRows(sqlmock.NewRows([]string{"short_url", "is_deleted"}).AddRow("existing1", false))
+			},
+			expectedError: &ErrURLConflict{ShortURL: "existing1", Err: "Original URL already exists"},
 		},
 		{
-			name:     "Nil error",
-			err:      nil,
-			expected: false,
+			name: "Original URL reserved by deleted link",
+			setupMock: func() {
+				mock.ExpectExec("update t_short_url set original_url").
+					WithArgs("https://Practicum.yandex.ru:443", hash, "", "", "qwerty12", "ws1").
+					WillReturnError(&pgconn.PgError{Code: pgerrcode.UniqueViolation})
+				mock.ExpectQuery("select short_url, is_deleted from t_short_url where domain = \\$1").
+					WithArgs("", hash).
+					WillReturnRows(sqlmock.NewRows([]string{"short_url", "is_deleted"}).AddRow("existing1", true))
+			},
+			expectedError: ErrURLReserved,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := isUniqueViolation(tt.err)
-			assert.Equal(t, tt.expected, result)
+			tt.setupMock()
+			err := repo.UpdateWorkspaceURL(context.TODO(), "ws1", url)
+			if tt.expectedError != nil {
+				assert.Equal(t, tt.expectedError, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
 
-func TestErrURLConflict_Error(t *testing.T) {
-	err := &ErrURLConflict{
-		ShortURL: "qwerty12",
-		Err:      "Original URL already exists",
+func TestPostgresRepositorySaveUser(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	user := model.NewUser("user-1", "alice", "hash")
+	mock.ExpectExec("insert into t_user\\(id, login, password_hash\\)").
+		WithArgs("user-1", "alice", "hash").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("insert into t_user").
+		WithArgs("user-1", "alice", "hash").
+		WillReturnError(&pgconn.PgError{Code: pgerrcode.UniqueViolation})
+
+	assert.NoError(t, repo.SaveUser(context.TODO(), *user))
+	assert.ErrorIs(t, repo.SaveUser(context.TODO(), *user), ErrLoginConflict)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryGetUser(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	columns := []string{"id", "login", "password_hash"}
+	mock.ExpectQuery("select id, login, password_hash from t_user where login = \\$1").
+		WithArgs("alice").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("user-1", "alice", "hash"))
+	mock.ExpectQuery("select id, login, password_hash from t_user where id = \\$1").
+		WithArgs("user-2").
+		WillReturnError(sql.ErrNoRows)
+
+	user, err := repo.GetUserByLogin(context.TODO(), "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, model.NewUser("user-1", "alice", "hash"), user)
+
+	_, err = repo.GetUserByID(context.TODO(), "user-2")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositorySaveAPIKey(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	createdAt := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	key := model.APIKey{
+		ID:        "key-1",
+		UserID:    "user-1",
+		Name:      "CI",
+		Prefix:    "shk_abcd",
+		KeyHash:   strings.Repeat("a", 64),
+		Scopes:    []model.APIKeyScope{model.ScopeRead, model.ScopeWrite},
+		RateLimit: 60,
+		CreatedAt: createdAt,
 	}
+	mock.ExpectExec("insert into t_api_key").
+		WithArgs("key-1", "user-1", "CI", "shk_abcd", strings.Repeat("a", 64), "read,write", 60, createdAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	errorMsg := err.Error()
-	assert.Equal(t, "Original URL already exists", errorMsg)
+	assert.NoError(t, repo.SaveAPIKey(context.TODO(), key))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryGetAPIKeys(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	createdAt := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	lastUsedAt := createdAt.Add(time.Hour)
+	columns := []string{"id", "user_id", "name", "prefix", "key_hash", "scopes", "rate_limit", "created_at", "last_used_at"}
+	mock.ExpectQuery("select (.+) from t_api_key where user_id = \\$1 order by created_at").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("key-1", "user-1", "CI", "shk_abcd", "hash-1", "read,write", 60, createdAt, lastUsedAt).
+			AddRow("key-2", "user-1", "Cleanup", "shk_efgh", "hash-2", "delete", 10, createdAt, nil))
+	mock.ExpectQuery("select (.+) from t_api_key where key_hash = \\$1").
+		WithArgs("hash-1").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("key-1", "user-1", "CI", "shk_abcd", "hash-1", "read", 60, createdAt, nil))
+	mock.ExpectQuery("select (.+) from t_api_key where key_hash = \\$1").
+		WithArgs("hash-3").
+		WillReturnError(sql.ErrNoRows)
+
+	keys, err := repo.GetAPIKeysByUserID(context.TODO(), "user-1")
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+	assert.Equal(t, []model.APIKeyScope{model.ScopeRead, model.ScopeWrite}, keys[0].Scopes)
+	assert.Equal(t, lastUsedAt, *keys[0].LastUsedAt)
+	assert.Equal(t, []model.APIKeyScope{model.ScopeDelete}, keys[1].Scopes)
+	assert.Nil(t, keys[1].LastUsedAt)
+
+	key, err := repo.GetAPIKeyByHash(context.TODO(), "hash-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", key.UserID)
+
+	_, err = repo.GetAPIKeyByHash(context.TODO(), "hash-3")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryDeleteAPIKey(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	mock.ExpectExec("delete from t_api_key where id = \\$1 and user_id = \\$2").
+		WithArgs("key-1", "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("delete from t_api_key where id = \\$1 and user_id = \\$2").
+		WithArgs("key-1", "user-2").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	usedAt := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	mock.ExpectExec("update t_api_key set last_used_at = \\$2 where id = \\$1").
+		WithArgs("key-1", usedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.DeleteAPIKey(context.TODO(), "user-1", "key-1"))
+	assert.ErrorIs(t, repo.DeleteAPIKey(context.TODO(), "user-2", "key-1"), ErrNotFound)
+	assert.NoError(t, repo.UpdateAPIKeyLastUsed(context.TODO(), "key-1", usedAt))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryTokenRevocations(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	expiresAt := time.Date(2026, 11, 17, 10, 0, 0, 0, time.UTC)
+	revokedBefore := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	mock.ExpectExec("insert into t_revoked_token\\(token_id, expires_at\\) values \\(\\$1, \\$2\\) on conflict").
+		WithArgs("token-1", expiresAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("select exists\\(select 1 from t_revoked_token where token_id = \\$1 .*\\) "+
+		"or exists\\(select 1 from t_user_token_revocation where user_id = \\$2 and revoked_before > \\$3").
+		WithArgs("token-1", "user-1", revokedBefore).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectExec("insert into t_user_token_revocation\\(user_id, revoked_before, expires_at\\)").
+		WithArgs("user-1", revokedBefore, expiresAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("select exists\\(select 1 from t_revoked_token").
+		WithArgs("", "user-2", revokedBefore).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	assert.NoError(t, repo.SaveRevokedToken(context.TODO(), "token-1", expiresAt))
+	revoked, err := repo.IsTokenRevoked(context.TODO(), "token-1", "user-1", revokedBefore)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	assert.NoError(t, repo.SaveUserTokensRevocation(context.TODO(), "user-1", revokedBefore, expiresAt))
+	revoked, err = repo.IsTokenRevoked(context.TODO(), "", "user-2", revokedBefore)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryPurgeExpiredRevocations(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectExec("delete from t_revoked_token where expires_at <").
+		WithArgs(now).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("delete from t_user_token_revocation where expires_at <").
+		WithArgs(now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	purged, err := repo.PurgeExpiredRevocations(context.TODO(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryMergeUserURLs(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("update t_short_url set user_id = \\$2 where user_id = \\$1").
+		WithArgs("anonymous", "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("update t_workspace_member m set user_id = \\$2").
+		WithArgs("anonymous", "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("delete from t_workspace_member where user_id = \\$1").
+		WithArgs("anonymous").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.MergeUserURLs(context.TODO(), "anonymous", "user-1"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryMergeUserURLs_Rollback(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("update t_short_url set user_id").
+		WithArgs("anonymous", "user-1").
+		WillReturnError(errors.New("db error"))
+	mock.ExpectRollback()
+
+	assert.Error(t, repo.MergeUserURLs(context.TODO(), "anonymous", "user-1"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryPurgeDeleted(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	deletedBefore := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+	mock.ExpectExec("delete from t_short_url where is_deleted = true and deleted_at <").
+		WithArgs(deletedBefore).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	purged, err := repo.PurgeDeleted(context.TODO(), deletedBefore)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// newShortURLRows creates rows with the columns read by GetByShortURL.
+func newShortURLRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"original_url", "is_deleted", "is_bundle", "is_disabled", "takedown",
+		"review_reasons", "meta_title", "meta_description", "meta_image", "meta_site_name", "meta_fetched_at"})
+}
+
+func TestPostgresRepositoryGetByShortURL(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	shortURL := "qwerty12"
+	originalURL := "https://practicum.yandex.ru/"
+	isDeleted := false
+	fetchedAt := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	rows := newShortURLRows().
+		AddRow(originalURL, isDeleted, false, false, "", "", "Practicum", "", "", "", fetchedAt)
+
+	mock.ExpectQuery("select u.original_url, u.is_deleted, u.is_bundle, u.is_disabled or exists\\(.*t_banned_user.*\\), "+
+		"u.takedown, u.review_reasons, u.meta_title, u.meta_description, u.meta_image, u.meta_site_name, "+
+		"u.meta_fetched_at from t_short_url u where u.domain = \\$1 and u.short_url =").
+		WithArgs("", shortURL).
+		WillReturnRows(rows)
+
+	result, err := repo.GetByShortURL(context.TODO(), "", shortURL)
+	assert.NoError(t, err)
+	assert.Equal(t, shortURL, result.ShortURL)
+	assert.Equal(t, originalURL, result.OriginalURL)
+	assert.Equal(t, isDeleted, result.IsDeleted)
+	assert.False(t, result.IsDisabled)
+	assert.False(t, result.IsUnderReview())
+	assert.Equal(t, &model.URLMetadata{Title: "Practicum", FetchedAt: fetchedAt}, result.Metadata)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryGetByShortURL_UnderReview(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	rows := newShortURLRows().
+		AddRow("https://paypa1.zip", false, false, false, "", "suspicious_tld,homoglyph_brand", "", "", "", "", nil)
+	mock.ExpectQuery("select u.original_url, u.is_deleted, u.is_bundle").
+		WithArgs("", "qwerty12").
+		WillReturnRows(rows)
+
+	result, err := repo.GetByShortURL(context.TODO(), "", "qwerty12")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.ReviewReason{model.ReviewSuspiciousTLD, model.ReviewHomoglyphBrand}, result.ReviewReasons)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryGetByShortURL_Disabled(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	rows := newShortURLRows().
+		AddRow("https://example.com", false, false, true, "legal", "", "", "", "", "", nil)
+	mock.ExpectQuery("select u.original_url, u.is_deleted, u.is_bundle").
+		WithArgs("", "qwerty12").
+		WillReturnRows(rows)
+
+	result, err := repo.GetByShortURL(context.TODO(), "", "qwerty12")
+	assert.NoError(t, err)
+	assert.True(t, result.IsDisabled)
+	assert.Equal(t, model.TakedownLegal, result.Takedown)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryGetByShortURL_NotFound(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	shortURL := "nonexistent"
+
+	mock.ExpectQuery("select u.original_url, u.is_deleted, u.is_bundle").
+		WithArgs("", shortURL).
+		WillReturnError(sql.ErrNoRows)
+
+	result, err := repo.GetByShortURL(context.TODO(), "", shortURL)
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositorySaveBundle(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	bundle := model.NewBundle("qwerty12", "Conference", []model.BundleLink{
+		{Title: "Slides", URL: "https://example.com/slides"},
+		{Title: "Video", URL: "https://example.com/video"},
+	})
+	bundle.ReviewReasons = []model.ReviewReason{model.ReviewIPHost}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("insert into t_short_url").
+		WithArgs("qwerty12", "", "user1", "Conference", string(model.ReviewIPHost)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("insert into t_bundle_link").
+		WithArgs("qwerty12", "", 0, "Slides", "https://example.com/slides").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("insert into t_bundle_link").
+		WithArgs("qwerty12", "", 1, "Video", "https://example.com/video").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := repo.SaveBundle(context.TODO(), "user1", *bundle)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositorySaveBundle_ShortURLConflict(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("insert into t_short_url").
+		WithArgs("qwerty12", "", "user1", "Conference", "").
+		WillReturnError(&pgconn.PgError{Code: pgerrcode.UniqueViolation})
+	mock.ExpectRollback()
+
+	err := repo.SaveBundle(context.TODO(), "user1", *model.NewBundle("qwerty12", "Conference", nil))
+	assert.ErrorIs(t, err, ErrShortURLConflict)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryGetBundleByShortURL(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	mock.ExpectQuery("select coalesce\\(title, ''\\) from t_short_url where domain = \\$1 and short_url = \\$2 and is_bundle = true").
+		WithArgs("", "qwerty12").
+		WillReturnRows(sqlmock.NewRows([]string{"title"}).AddRow("Conference"))
+	mock.ExpectQuery("select title, url from t_bundle_link where domain = \\$1 and short_url = \\$2 order by position").
+		WithArgs("", "qwerty12").
+		WillReturnRows(sqlmock.NewRows([]string{"title", "url"}).
+			AddRow("Slides", "https://example.com/slides").
+			AddRow("Video", "https://example.com/video"))
+
+	bundle, err := repo.GetBundleByShortURL(context.TODO(), "", "qwerty12")
+	assert.NoError(t, err)
+	assert.Equal(t, model.NewBundle("qwerty12", "Conference", []model.BundleLink{
+		{Title: "Slides", URL: "https://example.com/slides"},
+		{Title: "Video", URL: "https://example.com/video"},
+	}), bundle)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryGetByUserID(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	userID := "user1"
+	checkedAt := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	expectedURLs := []model.URL{
+		*model.NewURL("qwerty12", "https://practicum.yandex.ru/"),
+		{
+			ShortURL:    "qwerty13",
+			Domain:      "go.brand.com",
+			OriginalURL: "https://example.com/",
+			Health:      &model.URLHealth{Status: model.HealthBroken, StatusCode: 404, CheckedAt: checkedAt},
+		},
+	}
+
+	rows := newListedURLRows().
+		AddRow("qwerty12", "", "https://practicum.yandex.ru/", "", 0, nil, "", "", "", "", nil).
+		AddRow("qwerty13", "go.brand.com", "https://example.com/", "broken", 404, checkedAt, "", "", "", "", nil)
+
+	mock.ExpectQuery("select short_url, domain, original_url, health_status, health_status_code, health_checked_at, " +
+		"meta_title, meta_description, meta_image, meta_site_name, meta_fetched_at from t_short_url where user_id =").
+		WithArgs(userID).
+		WillReturnRows(rows)
+
+	urls, err := repo.GetByUserID(context.TODO(), userID)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedURLs, urls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryPing(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	mock.ExpectPing()
+
+	err := repo.Ping(context.TODO())
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryClose(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	mock.ExpectClose()
+
+	err := repo.Close()
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryGetByOriginalURL(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	originalURL := "https://practicum.yandex.ru/"
+
+	rows := sqlmock.NewRows([]string{"short_url", "original_url"}).AddRow("qwerty12", "https://Practicum.Yandex.ru")
+	mock.ExpectQuery("select short_url, original_url from t_short_url where domain = \\$1 and original_url_hash = "+
+		"\\$2 and is_deleted = false and is_disabled = false and review_reasons = '' and is_bundle = false "+
+		"and \\(\\$3 = '' or user_id = \\$3\\)").
+		WithArgs("go.brand.com", hashOriginalURL(originalURL), "user-1").
+		WillReturnRows(rows)
+
+	url, err := repo.GetByOriginalURL(context.TODO(), "go.brand.com", "user-1", originalURL)
+	assert.NoError(t, err)
+	assert.Equal(t, &model.URL{
+		ShortURL:    "qwerty12",
+		Domain:      "go.brand.com",
+		OriginalURL: "https://Practicum.Yandex.ru",
+	}, url)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryGetByOriginalURL_NotFound(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	originalURL := "https://nonexistent.com/"
+
+	mock.ExpectQuery("select short_url, original_url from t_short_url where domain = \\$1 and original_url_hash =").
+		WithArgs("", hashOriginalURL(originalURL), "").
+		WillReturnError(sql.ErrNoRows)
+
+	url, err := repo.GetByOriginalURL(context.TODO(), "", "", originalURL)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, url)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIsUniqueViolation(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "Unique violation error",
+			err:      &pgconn.PgError{Code: pgerrcode.UniqueViolation},
+			expected: true,
+		},
+		{
+			name:     "Other PgError",
+			err:      &pgconn.PgError{Code: "22000"}, // Some other error code (class 22 - data exception)
+			expected: false,
+		},
+		{
+			name:     "Other error",
+			err:      errors.New("some other error"),
+			expected: false,
+		},
+		{
+			name:     "Nil error",
+			err:      nil,
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := isUniqueViolation(tt.err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestHashOriginalURL(t *testing.T) {
+	longURL := "https://example.com/" + strings.Repeat("a", 10000)
+
+	assert.Len(t, hashOriginalURL(longURL), 64)
+	assert.Equal(t, hashOriginalURL(longURL), hashOriginalURL(longURL))
+	assert.NotEqual(t, hashOriginalURL("https://example.com/a"), hashOriginalURL("https://example.com/b"))
+	assert.Equal(t,
+		"100680ad546ce6a577f42f52df33b4cfdca756859e664b8d7de329b150d09ce9",
+		hashOriginalURL("https://example.com"))
+}
+
+func TestErrURLConflict_Error(t *testing.T) {
+	err := &ErrURLConflict{
+		ShortURL: "qwerty12",
+		Err:      "Original URL already exists",
+	}
+
+	errorMsg := err.Error()
+	assert.Equal(t, "Original URL already exists", errorMsg)
+}
+
+func TestPostgresRepositorySearchURLs(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows([]string{"short_url", "domain", "original_url", "user_id", "workspace_id",
+		"is_deleted", "is_disabled", "takedown", "is_bundle", "review_reasons"}).
+		AddRow("abc123", "", "https://example.com", "user-1", "", false, true, "abuse", false, "ip_host")
+	mock.ExpectQuery("select short_url, domain, original_url, coalesce\\(user_id, ''\\).* from t_short_url "+
+		"where strpos\\(lower\\(original_url\\), lower\\(\\$1\\)\\) > 0 and user_id = \\$2 "+
+		"and review_reasons <> '' order by domain, short_url limit \\$3 offset \\$4").
+		WithArgs("Example", "user-1", 10, 20).
+		WillReturnRows(rows)
+
+	urls, err := repo.SearchURLs(context.TODO(), model.URLSearchFilter{
+		OriginalURL: "Example",
+		UserID:      "user-1",
+		UnderReview: true,
+		Limit:       10,
+		Offset:      20,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []model.AdminURL{{
+		ShortURL:      "abc123",
+		OriginalURL:   "https://example.com",
+		UserID:        "user-1",
+		IsDisabled:    true,
+		Takedown:      model.TakedownAbuse,
+		ReviewReasons: []model.ReviewReason{model.ReviewIPHost},
+	}}, urls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositorySearchURLs_NoFilter(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	mock.ExpectQuery("select short_url, .* from t_short_url order by domain, short_url limit \\$1 offset \\$2").
+		WithArgs(100, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"short_url", "domain", "original_url", "user_id", "workspace_id",
+			"is_deleted", "is_disabled", "takedown", "is_bundle", "review_reasons"}))
+
+	urls, err := repo.SearchURLs(context.TODO(), model.URLSearchFilter{Limit: 100})
+	assert.NoError(t, err)
+	assert.Empty(t, urls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositorySetURLDisabled(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	mock.ExpectExec("update t_short_url set is_disabled = \\$3, takedown = '' where domain = \\$1 and short_url = \\$2").
+		WithArgs("", "abc123", true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("update t_short_url set is_disabled").
+		WithArgs("", "missing", false).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, repo.SetURLDisabled(context.TODO(), "", "abc123", true))
+	assert.ErrorIs(t, repo.SetURLDisabled(context.TODO(), "", "missing", false), ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryGetURLsForHealthCheck(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	checkedBefore := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"short_url", "domain", "original_url"}).
+		AddRow("abc123", "", "https://example.com/").
+		AddRow("def456", "go.brand.com", "https://example.org/")
+	mock.ExpectQuery("select short_url, domain, original_url from t_short_url "+
+		"where is_deleted = false and is_bundle = false and is_disabled = false "+
+		"and \\(health_checked_at is null or health_checked_at < \\$1\\) "+
+		"order by health_checked_at nulls first limit \\$2").
+		WithArgs(checkedBefore, 100).
+		WillReturnRows(rows)
+
+	urls, err := repo.GetURLsForHealthCheck(context.TODO(), checkedBefore, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.URL{
+		{ShortURL: "abc123", OriginalURL: "https://example.com/"},
+		{ShortURL: "def456", Domain: "go.brand.com", OriginalURL: "https://example.org/"},
+	}, urls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositorySaveURLHealth(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	health := model.URLHealth{
+		Status:     model.HealthRedirected,
+		StatusCode: 301,
+		CheckedAt:  time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+	}
+	mock.ExpectExec("update t_short_url set health_status = \\$3, health_status_code = \\$4, "+
+		"health_checked_at = \\$5 where domain = \\$1 and short_url = \\$2").
+		WithArgs("go.brand.com", "abc123", model.HealthRedirected, 301, health.CheckedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("update t_short_url set health_status").
+		WithArgs("", "missing", model.HealthRedirected, 301, health.CheckedAt).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, repo.SaveURLHealth(context.TODO(), "go.brand.com", "abc123", health))
+	assert.ErrorIs(t, repo.SaveURLHealth(context.TODO(), "", "missing", health), ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositorySaveURLMetadata(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	metadata := model.URLMetadata{
+		Title:       "Example Domain",
+		Description: "Illustrative examples",
+		Image:       "https://example.com/og.png",
+		SiteName:    "Example",
+		FetchedAt:   time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+	}
+	mock.ExpectExec("update t_short_url set meta_title = \\$3, meta_description = \\$4, meta_image = \\$5, "+
+		"meta_site_name = \\$6, meta_fetched_at = \\$7 where domain = \\$1 and short_url = \\$2").
+		WithArgs("", "abc123", "Example Domain", "Illustrative examples", "https://example.com/og.png", "Example",
+			metadata.FetchedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("update t_short_url set meta_title").
+		WithArgs("go.brand.com", "missing", "Example Domain", "Illustrative examples", "https://example.com/og.png",
+			"Example", metadata.FetchedAt).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, repo.SaveURLMetadata(context.TODO(), "", "abc123", metadata))
+	assert.ErrorIs(t, repo.SaveURLMetadata(context.TODO(), "go.brand.com", "missing", metadata), ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryClearURLReview(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	mock.ExpectExec("update t_short_url set review_reasons = '' where domain = \\$1 and short_url = \\$2").
+		WithArgs("go.brand.com", "abc123").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("update t_short_url set review_reasons").
+		WithArgs("", "missing").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, repo.ClearURLReview(context.TODO(), "go.brand.com", "abc123"))
+	assert.ErrorIs(t, repo.ClearURLReview(context.TODO(), "", "missing"), ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositorySaveAbuseReport(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	createdAt := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	report := model.AbuseReport{
+		ID:        "report-1",
+		ShortURL:  "abc123",
+		Reason:    model.ReportPhishing,
+		Details:   "fake bank login",
+		Status:    model.ReportOpen,
+		CreatedAt: createdAt,
+	}
+	mock.ExpectExec("insert into t_abuse_report\\(id, domain, short_url, reason, details, reporter_id, status, created_at\\) "+
+		"select \\$1, \\$2, \\$3, \\$4, \\$5, nullif\\(\\$6, ''\\), \\$7, \\$8 "+
+		"where exists\\(select 1 from t_short_url where domain = \\$2 and short_url = \\$3\\)").
+		WithArgs("report-1", "", "abc123", model.ReportPhishing, "fake bank login", "", model.ReportOpen, createdAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("insert into t_abuse_report").
+		WithArgs("report-2", "", "missing", model.ReportSpam, "", "user-1", model.ReportOpen, createdAt).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, repo.SaveAbuseReport(context.TODO(), report))
+	err := repo.SaveAbuseReport(context.TODO(), model.AbuseReport{
+		ID:         "report-2",
+		ShortURL:   "missing",
+		Reason:     model.ReportSpam,
+		ReporterID: "user-1",
+		Status:     model.ReportOpen,
+		CreatedAt:  createdAt,
+	})
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryGetAbuseReports(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	createdAt := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	resolvedAt := createdAt.Add(time.Hour)
+	rows := sqlmock.NewRows([]string{"id", "domain", "short_url", "reason", "details", "reporter_id", "status",
+		"created_at", "resolved_by", "resolved_at"}).
+		AddRow("report-1", "go.brand.com", "abc123", "malware", "", "user-1", "dismissed",
+			createdAt, "admin-1", resolvedAt)
+	mock.ExpectQuery("select id, domain, short_url, .* from t_abuse_report where status = \\$1 "+
+		"order by created_at, id limit \\$2 offset \\$3").
+		WithArgs(model.ReportDismissed, 10, 20).
+		WillReturnRows(rows)
+
+	reports, err := repo.GetAbuseReports(context.TODO(), model.AbuseReportFilter{
+		Status: model.ReportDismissed,
+		Limit:  10,
+		Offset: 20,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []model.AbuseReport{{
+		ID:         "report-1",
+		Domain:     "go.brand.com",
+		ShortURL:   "abc123",
+		Reason:     model.ReportMalware,
+		ReporterID: "user-1",
+		Status:     model.ReportDismissed,
+		CreatedAt:  createdAt,
+		ResolvedBy: "admin-1",
+		ResolvedAt: &resolvedAt,
+	}}, reports)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryGetAbuseReport(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	createdAt := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("select id, domain, short_url, .* from t_abuse_report where id = \\$1").
+		WithArgs("report-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "domain", "short_url", "reason", "details", "reporter_id",
+			"status", "created_at", "resolved_by", "resolved_at"}).
+			AddRow("report-1", "", "abc123", "phishing", "fake bank login", "", "open", createdAt, "", nil))
+	mock.ExpectQuery("select id, domain, short_url, .* from t_abuse_report where id = \\$1").
+		WithArgs("missing").
+		WillReturnError(sql.ErrNoRows)
+
+	report, err := repo.GetAbuseReport(context.TODO(), "report-1")
+	assert.NoError(t, err)
+	assert.Equal(t, &model.AbuseReport{
+		ID:        "report-1",
+		ShortURL:  "abc123",
+		Reason:    model.ReportPhishing,
+		Details:   "fake bank login",
+		Status:    model.ReportOpen,
+		CreatedAt: createdAt,
+	}, report)
+	_, err = repo.GetAbuseReport(context.TODO(), "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryDismissAbuseReport(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	resolvedAt := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	mock.ExpectExec("update t_abuse_report set status = \\$2, resolved_by = \\$3, resolved_at = \\$4 "+
+		"where id = \\$1 and status = \\$5").
+		WithArgs("report-1", model.ReportDismissed, "admin-1", resolvedAt, model.ReportOpen).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("update t_abuse_report set status").
+		WithArgs("resolved", model.ReportDismissed, "admin-1", resolvedAt, model.ReportOpen).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, repo.DismissAbuseReport(context.TODO(), "report-1", "admin-1", resolvedAt))
+	assert.ErrorIs(t, repo.DismissAbuseReport(context.TODO(), "resolved", "admin-1", resolvedAt), ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryTakeDownURL(t *testing.T) {
+	resolvedAt := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Link taken down", func(t *testing.T) {
+		repo, mock, cleanup := setupPostgresRepository(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("update t_short_url set is_disabled = true, takedown = \\$3 where domain = \\$1 and short_url = \\$2").
+			WithArgs("go.brand.com", "abc123", model.TakedownLegal).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("update t_abuse_report set status = \\$3, resolved_by = \\$4, resolved_at = \\$5 "+
+			"where domain = \\$1 and short_url = \\$2 and status = \\$6").
+			WithArgs("go.brand.com", "abc123", model.ReportActioned, "admin-1", resolvedAt, model.ReportOpen).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		err := repo.TakeDownURL(context.TODO(), "go.brand.com", "abc123", model.TakedownLegal, "admin-1", resolvedAt)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Link not found", func(t *testing.T) {
+		repo, mock, cleanup := setupPostgresRepository(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("update t_short_url set is_disabled = true").
+			WithArgs("", "missing", model.TakedownAbuse).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.TakeDownURL(context.TODO(), "", "missing", model.TakedownAbuse, "admin-1", resolvedAt)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Reports update error", func(t *testing.T) {
+		repo, mock, cleanup := setupPostgresRepository(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("update t_short_url set is_disabled = true").
+			WithArgs("", "abc123", model.TakedownAbuse).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("update t_abuse_report set status").
+			WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
+
+		err := repo.TakeDownURL(context.TODO(), "", "abc123", model.TakedownAbuse, "admin-1", resolvedAt)
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresRepositorySetUserBanned(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	mock.ExpectExec("insert into t_banned_user\\(user_id\\) values \\(\\$1\\) on conflict \\(user_id\\) do nothing").
+		WithArgs("user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("select exists\\(select 1 from t_banned_user where user_id = \\$1\\)").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectExec("delete from t_banned_user where user_id = \\$1").
+		WithArgs("user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.SetUserBanned(context.TODO(), "user-1", true))
+	banned, err := repo.IsUserBanned(context.TODO(), "user-1")
+	assert.NoError(t, err)
+	assert.True(t, banned)
+	assert.NoError(t, repo.SetUserBanned(context.TODO(), "user-1", false))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryGetUserUsage(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	bannedAt := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("select count\\(\\*\\) filter \\(where not is_deleted\\).* from t_short_url where user_id = \\$1").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"links", "deleted", "disabled", "api_keys", "banned_at"}).
+			AddRow(42, 3, 1, 2, bannedAt))
+
+	usage, err := repo.GetUserUsage(context.TODO(), "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, &model.UserUsage{
+		UserID:        "user-1",
+		Links:         42,
+		DeletedLinks:  3,
+		DisabledLinks: 1,
+		APIKeys:       2,
+		IsBanned:      true,
+		BannedAt:      &bannedAt,
+	}, usage)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryGetStats(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	mock.ExpectQuery("select count\\(\\*\\) filter \\(where not is_deleted\\), count\\(distinct user_id\\) from t_short_url").
+		WillReturnRows(sqlmock.NewRows([]string{"urls", "users"}).AddRow(1024, 37))
+
+	stats, err := repo.GetStats(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, &model.Stats{URLs: 1024, Users: 37}, stats)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryGetQuotaUsage(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	today := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("select coalesce\\(\\(select links from t_user_daily_links where user_id = \\$1 and day = \\$2\\), 0\\)").
+		WithArgs("user-1", today).
+		WillReturnRows(sqlmock.NewRows([]string{"links_today", "active_links"}).AddRow(12, 340))
+
+	usage, err := repo.GetQuotaUsage(context.TODO(), "user-1", today)
+	assert.NoError(t, err)
+	assert.Equal(t, &model.QuotaUsage{LinksToday: 12, ActiveLinks: 340}, usage)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryReserveLinks(t *testing.T) {
+	today := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	limits := model.QuotaLimits{LinksPerDay: 10, ActiveLinks: 100}
+	dbErr := errors.New("db error")
+	tests := []struct {
+		name          string
+		count         int64
+		setupMock     func(mock sqlmock.Sqlmock)
+		expectedUsage *model.QuotaUsage
+		expectedOK    bool
+		expectedErr   error
+	}{
+		{
+			name:  "reserved",
+			count: 3,
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("with purged as \\(delete from t_user_daily_links where user_id = \\$1 and day < \\$2\\) "+
+					"insert into t_user_daily_links(.+)returning links, pending").
+					WithArgs("user-1", today).
+					WillReturnRows(sqlmock.NewRows([]string{"links", "pending"}).AddRow(5, 2))
+				mock.ExpectQuery("select count\\(\\*\\) from t_short_url where user_id = \\$1 and not is_deleted").
+					WithArgs("user-1").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(40))
+				mock.ExpectExec("update t_user_daily_links set links = links \\+ \\$3, pending = pending \\+ \\$3").
+					WithArgs("user-1", today, int64(3)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			expectedUsage: &model.QuotaUsage{LinksToday: 5, ActiveLinks: 42},
+			expectedOK:    true,
+		},
+		{
+			name:  "daily limit exceeded",
+			count: 6,
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("insert into t_user_daily_links").
+					WithArgs("user-1", today).
+					WillReturnRows(sqlmock.NewRows([]string{"links", "pending"}).AddRow(5, 2))
+				mock.ExpectQuery("select count\\(\\*\\) from t_short_url").
+					WithArgs("user-1").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(40))
+				mock.ExpectRollback()
+			},
+			expectedUsage: &model.QuotaUsage{LinksToday: 5, ActiveLinks: 42},
+		},
+		{
+			name:  "pending links exceed active links",
+			count: 1,
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("insert into t_user_daily_links").
+					WithArgs("user-1", today).
+					WillReturnRows(sqlmock.NewRows([]string{"links", "pending"}).AddRow(5, 5))
+				mock.ExpectQuery("select count\\(\\*\\) from t_short_url").
+					WithArgs("user-1").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(95))
+				mock.ExpectRollback()
+			},
+			expectedUsage: &model.QuotaUsage{LinksToday: 5, ActiveLinks: 100},
+		},
+		{
+			name:  "database error",
+			count: 1,
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("insert into t_user_daily_links").
+					WithArgs("user-1", today).
+					WillReturnError(dbErr)
+				mock.ExpectRollback()
+			},
+			expectedErr: dbErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock, cleanup := setupPostgresRepository(t)
+			defer cleanup()
+			tt.setupMock(mock)
+
+			usage, reserved, err := repo.ReserveLinks(context.TODO(), "user-1", today, tt.count, limits)
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Equal(t, tt.expectedUsage, usage)
+			assert.Equal(t, tt.expectedOK, reserved)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresRepositoryReleaseLinks(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	today := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	mock.ExpectExec("update t_user_daily_links set links = greatest\\(links - \\$4, 0\\), "+
+		"pending = greatest\\(pending - \\$3, 0\\) where user_id = \\$1 and day = \\$2").
+		WithArgs("user-1", today, int64(3), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.ReleaseLinks(context.TODO(), "user-1", today, 3, 1))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryUserQuotaLimits(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	limits := model.QuotaLimits{LinksPerDay: 100, ActiveLinks: 1000, BatchSize: 50, DeleteBatchSize: 20}
+	mock.ExpectExec("insert into t_user_quota\\(user_id, links_per_day, active_links, batch_size, delete_batch_size\\)").
+		WithArgs("user-1", int64(100), int64(1000), int64(50), int64(20)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("select links_per_day, active_links, batch_size, delete_batch_size from t_user_quota").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"links_per_day", "active_links", "batch_size", "delete_batch_size"}).
+			AddRow(100, 1000, 50, 20))
+	mock.ExpectQuery("select links_per_day, active_links, batch_size, delete_batch_size from t_user_quota").
+		WithArgs("user-2").
+		WillReturnError(sql.ErrNoRows)
+
+	assert.NoError(t, repo.SaveUserQuotaLimits(context.TODO(), "user-1", limits))
+	saved, err := repo.GetUserQuotaLimits(context.TODO(), "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, &limits, saved)
+	_, err = repo.GetUserQuotaLimits(context.TODO(), "user-2")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryTakeRateLimitTokens(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	limit := model.RateLimit{RequestsPerMinute: 60, Burst: 10}
+	upsert := "insert into t_rate_limit_bucket\\(key, tokens, updated_at, full_at\\) " +
+		"values \\(\\$1, \\$2, \\$3, \\$3\\) " +
+		"on conflict \\(key\\) do update set key = excluded.key returning tokens, updated_at"
+	mock.ExpectBegin()
+	mock.ExpectQuery(upsert).
+		WithArgs("create:ip:10.0.0.1", float64(10), now).
+		WillReturnRows(sqlmock.NewRows([]string{"tokens", "updated_at"}).AddRow(0.5, now.Add(-time.Second)))
+	mock.ExpectQuery(upsert).
+		WithArgs("create:user:user-1", float64(10), now).
+		WillReturnRows(sqlmock.NewRows([]string{"tokens", "updated_at"}).AddRow(float64(10), now))
+	mock.ExpectExec("update t_rate_limit_bucket set tokens = \\$2, updated_at = \\$3, full_at = \\$4 where key = \\$1").
+		WithArgs("create:user:user-1", float64(9), now, now.Add(time.Second)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("update t_rate_limit_bucket").
+		WithArgs("create:ip:10.0.0.1", 0.5, now, now.Add(9500*time.Millisecond)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("delete from t_rate_limit_bucket where key in \\(select key from t_rate_limit_bucket " +
+		"where full_at < \\$1 limit 100 for update skip locked\\)").
+		WithArgs(now).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	decisions, err := repo.TakeRateLimitTokens(context.TODO(), []string{"create:user:user-1", "create:ip:10.0.0.1"},
+		limit, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.RateLimitDecision{
+		{Allowed: true, Limit: 10, Remaining: 9},
+		{Allowed: true, Limit: 10, Remaining: 0},
+	}, decisions)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryTakeRateLimitTokens_Denied(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	limit := model.RateLimit{RequestsPerMinute: 60, Burst: 10}
+	mock.ExpectBegin()
+	mock.ExpectQuery("insert into t_rate_limit_bucket").
+		WithArgs("create:ip:10.0.0.1", float64(10), now).
+		WillReturnRows(sqlmock.NewRows([]string{"tokens", "updated_at"}).AddRow(float64(0), now))
+	mock.ExpectQuery("insert into t_rate_limit_bucket").
+		WithArgs("create:user:user-1", float64(10), now).
+		WillReturnRows(sqlmock.NewRows([]string{"tokens", "updated_at"}).AddRow(float64(10), now))
+	mock.ExpectExec("delete from t_rate_limit_bucket").
+		WithArgs(now).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	decisions, err := repo.TakeRateLimitTokens(context.TODO(), []string{"create:user:user-1", "create:ip:10.0.0.1"},
+		limit, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.RateLimitDecision{
+		{Allowed: true, Limit: 10, Remaining: 9},
+		{Limit: 10, RetryAfter: time.Second},
+	}, decisions, "no bucket is updated when one of them denies the request")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepositoryTakeRateLimitTokens_Error(t *testing.T) {
+	repo, mock, cleanup := setupPostgresRepository(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("insert into t_rate_limit_bucket").WillReturnError(errors.New("db error"))
+	mock.ExpectRollback()
+
+	_, err := repo.TakeRateLimitTokens(context.TODO(), []string{"redirect:ip:10.0.0.1"},
+		model.RateLimit{RequestsPerMinute: 60}, time.Now())
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
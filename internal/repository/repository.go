@@ -8,6 +8,7 @@ import (
 	"context"
 	"errors"
 	"github.com/bezjen/shortener/internal/model"
+	"time"
 )
 
 // Common repository error types used by all storage implementations.
@@ -17,6 +18,13 @@ var (
 
 	// ErrShortURLConflict is returned when attempting to save a short URL that already exists.
 	ErrShortURLConflict = errors.New("record with short url already exists")
+
+	// ErrURLReserved is returned when the original URL belongs to a deleted record of another user.
+	// Deleted URLs stay reserved for their owner until they are purged.
+	ErrURLReserved = errors.New("original url is reserved by another user")
+
+	// ErrLoginConflict is returned when attempting to register a login that already exists.
+	ErrLoginConflict = errors.New("user with login already exists")
 )
 
 // Repository defines the interface for URL storage operations.
@@ -24,6 +32,7 @@ var (
 // basic CRUD operations, batch processing, and user-specific queries.
 type Repository interface {
 	// Save stores a single URL mapping in the repository.
+	// Short identifiers and original URLs are unique within the URL domain.
 	//
 	// Parameters:
 	//   - ctx: context for request cancellation and timeouts
@@ -46,31 +55,73 @@ type Repository interface {
 	//   - error: error if any storage operation fails
 	SaveBatch(ctx context.Context, userID string, urls []model.URL) error
 
+	// SaveBundle stores a bundle of links under its short identifier.
+	// Bundles share the short identifier namespace of their domain with plain URLs.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the user creating the bundle
+	//   - bundle: bundle with short identifier, title and ordered links
+	//
+	// Returns:
+	//   - error: ErrShortURLConflict if the short identifier is taken, or storage error
+	SaveBundle(ctx context.Context, userID string, bundle model.Bundle) error
+
 	// DeleteBatch marks multiple short URLs as deleted.
 	// The deletion should be performed asynchronously for better performance.
+	// Only short identifiers of the user within the given domain are deleted,
+	// URLs belonging to a workspace are deleted only through DeleteWorkspaceBatch.
 	//
 	// Parameters:
 	//   - ctx: context for request cancellation and timeouts
+	//   - domain: domain the short URLs belong to, empty for the base URL host
 	//   - userID: identifier of the user owning the URLs
 	//   - shortURLs: slice of short URL identifiers to delete
 	//
 	// Returns:
 	//   - error: error if deletion request cannot be processed
-	DeleteBatch(ctx context.Context, userID string, shortURLs []string) error
+	DeleteBatch(ctx context.Context, domain string, userID string, shortURLs []string) error
 
-	// GetByShortURL retrieves the original URL by its short identifier.
+	// GetByShortURL retrieves the original URL by its short identifier within a domain.
 	//
 	// Parameters:
 	//   - ctx: context for request cancellation and timeouts
+	//   - domain: domain the short URL belongs to, empty for the base URL host
 	//   - id: short URL identifier to look up
 	//
 	// Returns:
 	//   - *model.URL: found URL object
 	//   - error: error if URL is not found or lookup fails
-	GetByShortURL(ctx context.Context, id string) (*model.URL, error)
+	GetByShortURL(ctx context.Context, domain string, id string) (*model.URL, error)
+
+	// GetByOriginalURL retrieves the link to an original URL within a domain.
+	// Deleted, disabled and held for review links and bundles are not returned, like on redirects.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - domain: domain the short URL belongs to, empty for the base URL host
+	//   - userID: owner of the link, empty to find links of any user
+	//   - originalURL: original URL to look up, in the form compared for deduplication
+	//
+	// Returns:
+	//   - *model.URL: found URL object
+	//   - error: ErrNotFound if no link to the original URL exists, or error if lookup fails
+	GetByOriginalURL(ctx context.Context, domain string, userID string, originalURL string) (*model.URL, error)
+
+	// GetBundleByShortURL retrieves a bundle with its links by short identifier within a domain.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - domain: domain the bundle belongs to, empty for the base URL host
+	//   - shortURL: short identifier of the bundle
+	//
+	// Returns:
+	//   - *model.Bundle: found bundle with links in display order
+	//   - error: error if bundle is not found or lookup fails
+	GetBundleByShortURL(ctx context.Context, domain string, shortURL string) (*model.Bundle, error)
 
 	// GetByUserID retrieves all URLs created by a specific user.
-	// Should only return non-deleted URLs.
+	// Should only return non-deleted URLs that do not belong to a workspace.
 	//
 	// Parameters:
 	//   - ctx: context for request cancellation and timeouts
@@ -81,6 +132,574 @@ type Repository interface {
 	//   - error: error if lookup fails
 	GetByUserID(ctx context.Context, userID string) ([]model.URL, error)
 
+	// GetDeletedByUserID retrieves all URLs of a specific user that were moved to the trash.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: user identifier to look up deleted URLs for
+	//
+	// Returns:
+	//   - []model.URL: slice of deleted URLs with deletion time
+	//   - error: error if lookup fails
+	GetDeletedByUserID(ctx context.Context, userID string) ([]model.URL, error)
+
+	// RestoreBatch restores multiple deleted short URLs of a user from the trash.
+	// URLs owned by other users, URLs of other domains and URLs that are not deleted are ignored.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - domain: domain the short URLs belong to, empty for the base URL host
+	//   - userID: identifier of the user owning the URLs
+	//   - shortURLs: slice of short URL identifiers to restore
+	//
+	// Returns:
+	//   - error: error if restore operation fails
+	RestoreBatch(ctx context.Context, domain string, userID string, shortURLs []string) error
+
+	// PurgeDeleted permanently removes URLs deleted before the given time.
+	// Purged original URLs become available for shortening by any user.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - deletedBefore: URLs deleted before this time are removed
+	//
+	// Returns:
+	//   - int64: number of removed records
+	//   - error: error if purge operation fails
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error)
+
+	// CreateWorkspace stores a new workspace with its owner as the first member.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - workspace: workspace with identifier and name
+	//   - ownerID: identifier of the user becoming the workspace owner
+	//
+	// Returns:
+	//   - error: error if storage operation fails
+	CreateWorkspace(ctx context.Context, workspace model.Workspace, ownerID string) error
+
+	// GetWorkspacesByUserID retrieves all workspaces the user is a member of.
+	// Each workspace contains the role of the user.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: user identifier to look up workspaces for
+	//
+	// Returns:
+	//   - []model.Workspace: slice of workspaces with the user's role
+	//   - error: error if lookup fails
+	GetWorkspacesByUserID(ctx context.Context, userID string) ([]model.Workspace, error)
+
+	// GetWorkspaceRole retrieves the role of a user in a workspace.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - workspaceID: identifier of the workspace
+	//   - userID: identifier of the user
+	//
+	// Returns:
+	//   - model.WorkspaceRole: role of the user
+	//   - error: ErrNotFound if the user is not a member, or storage error
+	GetWorkspaceRole(ctx context.Context, workspaceID string, userID string) (model.WorkspaceRole, error)
+
+	// GetWorkspaceMembers retrieves all members of a workspace.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - workspaceID: identifier of the workspace
+	//
+	// Returns:
+	//   - []model.WorkspaceMember: slice of members with their roles
+	//   - error: error if lookup fails
+	GetWorkspaceMembers(ctx context.Context, workspaceID string) ([]model.WorkspaceMember, error)
+
+	// SaveWorkspaceMember adds a member to a workspace or changes the role of an existing member.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - member: workspace member with role
+	//
+	// Returns:
+	//   - error: error if storage operation fails
+	SaveWorkspaceMember(ctx context.Context, member model.WorkspaceMember) error
+
+	// DeleteWorkspaceMember removes a member from a workspace.
+	// Links created by the member stay in the workspace.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - workspaceID: identifier of the workspace
+	//   - userID: identifier of the member to remove
+	//
+	// Returns:
+	//   - error: error if storage operation fails
+	DeleteWorkspaceMember(ctx context.Context, workspaceID string, userID string) error
+
+	// GetByWorkspaceID retrieves all non-deleted URLs of a workspace.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - workspaceID: identifier of the workspace
+	//
+	// Returns:
+	//   - []model.URL: slice of URLs of the workspace
+	//   - error: error if lookup fails
+	GetByWorkspaceID(ctx context.Context, workspaceID string) ([]model.URL, error)
+
+	// DeleteWorkspaceBatch moves multiple short URLs of a workspace to the trash.
+	// URLs of other workspaces, URLs of other domains and personal URLs are ignored.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - domain: domain the short URLs belong to, empty for the base URL host
+	//   - workspaceID: identifier of the workspace owning the URLs
+	//   - shortURLs: slice of short URL identifiers to delete
+	//
+	// Returns:
+	//   - error: error if deletion fails
+	DeleteWorkspaceBatch(ctx context.Context, domain string, workspaceID string, shortURLs []string) error
+
+	// GetDeletedByWorkspaceID retrieves all URLs of a workspace that were moved to the trash.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - workspaceID: identifier of the workspace
+	//
+	// Returns:
+	//   - []model.URL: slice of deleted URLs with deletion time
+	//   - error: error if lookup fails
+	GetDeletedByWorkspaceID(ctx context.Context, workspaceID string) ([]model.URL, error)
+
+	// RestoreWorkspaceBatch restores multiple deleted short URLs of a workspace from the trash.
+	// URLs of other workspaces, URLs of other domains, personal URLs and URLs that are not deleted are ignored.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - domain: domain the short URLs belong to, empty for the base URL host
+	//   - workspaceID: identifier of the workspace owning the URLs
+	//   - shortURLs: slice of short URL identifiers to restore
+	//
+	// Returns:
+	//   - error: error if restore operation fails
+	RestoreWorkspaceBatch(ctx context.Context, domain string, workspaceID string, shortURLs []string) error
+
+	// UpdateWorkspaceURL changes the original URL of a short URL owned by a workspace.
+	// Review reasons are replaced, health and metadata of the previous destination are cleared.
+	// Deleted, disabled and bundle links cannot be changed.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - workspaceID: identifier of the workspace owning the URL
+	//   - url: URL with domain, short URL, new original URL, canonical form and review reasons
+	//
+	// Returns:
+	//   - error: ErrNotFound if the workspace has no such link, *ErrURLConflict or ErrURLReserved
+	//     if the original URL is already shortened on the domain, or error if update fails
+	UpdateWorkspaceURL(ctx context.Context, workspaceID string, url model.URL) error
+
+	// SaveUser stores a registered account.
+	// Logins are unique across all users.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - user: user with identifier, login and password hash
+	//
+	// Returns:
+	//   - error: ErrLoginConflict if the login is taken, or storage error
+	SaveUser(ctx context.Context, user model.User) error
+
+	// GetUserByLogin retrieves a registered account by its login.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - login: login of the account
+	//
+	// Returns:
+	//   - *model.User: found user
+	//   - error: ErrNotFound if no account has the login, or storage error
+	GetUserByLogin(ctx context.Context, login string) (*model.User, error)
+
+	// GetUserByID retrieves a registered account by its identifier.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - id: identifier of the account
+	//
+	// Returns:
+	//   - *model.User: found user
+	//   - error: ErrNotFound if the identifier belongs to no account, or storage error
+	GetUserByID(ctx context.Context, id string) (*model.User, error)
+
+	// MergeUserURLs moves all URLs and workspace memberships of one user to another.
+	// Used to claim links of an anonymous user for a registered account.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - fromUserID: identifier of the user giving up the URLs
+	//   - toUserID: identifier of the user receiving the URLs
+	//
+	// Returns:
+	//   - error: error if storage operation fails
+	MergeUserURLs(ctx context.Context, fromUserID string, toUserID string) error
+
+	// SaveAPIKey stores a new API key of a user.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - key: API key with identifier, owner, key hash and scopes
+	//
+	// Returns:
+	//   - error: error if storage operation fails
+	SaveAPIKey(ctx context.Context, key model.APIKey) error
+
+	// GetAPIKeysByUserID retrieves all API keys of a user.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: user identifier to look up keys for
+	//
+	// Returns:
+	//   - []model.APIKey: slice of keys of the user
+	//   - error: error if lookup fails
+	GetAPIKeysByUserID(ctx context.Context, userID string) ([]model.APIKey, error)
+
+	// GetAPIKeyByHash retrieves an API key by the hash of the key.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - keyHash: hex-encoded SHA-256 digest of the key
+	//
+	// Returns:
+	//   - *model.APIKey: found key
+	//   - error: ErrNotFound if no key has the hash, or storage error
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error)
+
+	// DeleteAPIKey removes an API key of a user, revoking it.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the user owning the key
+	//   - id: identifier of the key
+	//
+	// Returns:
+	//   - error: ErrNotFound if the user has no such key, or storage error
+	DeleteAPIKey(ctx context.Context, userID string, id string) error
+
+	// UpdateAPIKeyLastUsed records the time an API key was last used.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - id: identifier of the key
+	//   - usedAt: time the key was used
+	//
+	// Returns:
+	//   - error: error if storage operation fails
+	UpdateAPIKeyLastUsed(ctx context.Context, id string, usedAt time.Time) error
+
+	// SaveRevokedToken revokes a single token by its identifier.
+	// The revocation is kept until the token expires.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - tokenID: jti claim of the token
+	//   - expiresAt: expiration time of the token
+	//
+	// Returns:
+	//   - error: error if storage operation fails
+	SaveRevokedToken(ctx context.Context, tokenID string, expiresAt time.Time) error
+
+	// IsTokenRevoked reports whether a token was revoked by itself or together with all tokens of its user,
+	// and the revocation has not expired yet. Both revocations are checked in a single lookup.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - tokenID: jti claim of the token, empty for tokens without one
+	//   - userID: identifier of the user the token was issued to
+	//   - issuedAt: issue time of the token, zero for tokens without one
+	//
+	// Returns:
+	//   - bool: true if the token is revoked
+	//   - error: error if lookup fails
+	IsTokenRevoked(ctx context.Context, tokenID string, userID string, issuedAt time.Time) (bool, error)
+
+	// SaveUserTokensRevocation revokes all tokens of a user issued before the given time.
+	// A later revocation of the same user replaces the earlier one.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the user
+	//   - revokedBefore: tokens issued before this time are revoked
+	//   - expiresAt: time all revoked tokens have expired by
+	//
+	// Returns:
+	//   - error: error if storage operation fails
+	SaveUserTokensRevocation(ctx context.Context, userID string, revokedBefore time.Time, expiresAt time.Time) error
+
+	// PurgeExpiredRevocations removes revocations of tokens that have expired.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - now: revocations expiring before this time are removed
+	//
+	// Returns:
+	//   - int64: number of removed revocations
+	//   - error: error if purge operation fails
+	PurgeExpiredRevocations(ctx context.Context, now time.Time) (int64, error)
+
+	// SearchURLs finds links of all users for administrators.
+	// Deleted and disabled links are included.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - filter: search criteria with pagination
+	//
+	// Returns:
+	//   - []model.AdminURL: matching links ordered by domain and short URL
+	//   - error: error if lookup fails
+	SearchURLs(ctx context.Context, filter model.URLSearchFilter) ([]model.AdminURL, error)
+
+	// SetURLDisabled disables a link or enables it again.
+	// Disabled links stop resolving until they are enabled.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - domain: domain the short URL belongs to, empty for the base URL host
+	//   - shortURL: short URL identifier
+	//   - disabled: true to disable the link, false to enable it
+	//
+	// Returns:
+	//   - error: ErrNotFound if the link does not exist, or storage error
+	SetURLDisabled(ctx context.Context, domain string, shortURL string, disabled bool) error
+
+	// ClearURLReview clears the phishing review reasons of a link after an administrator checked it.
+	// The link stops showing the warning page and redirects directly.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - domain: domain the short URL belongs to, empty for the base URL host
+	//   - shortURL: short URL identifier
+	//
+	// Returns:
+	//   - error: ErrNotFound if the link does not exist, or storage error
+	ClearURLReview(ctx context.Context, domain string, shortURL string) error
+
+	// SaveAbuseReport adds a report of an abusive link to the moderation queue.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - report: open report with identifier and reported link
+	//
+	// Returns:
+	//   - error: ErrNotFound if the reported link does not exist, or storage error
+	SaveAbuseReport(ctx context.Context, report model.AbuseReport) error
+
+	// GetAbuseReports lists abuse reports in a state, oldest first.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - filter: state of the reports with pagination
+	//
+	// Returns:
+	//   - []model.AbuseReport: matching reports
+	//   - error: error if lookup fails
+	GetAbuseReports(ctx context.Context, filter model.AbuseReportFilter) ([]model.AbuseReport, error)
+
+	// GetAbuseReport retrieves a single abuse report.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - id: identifier of the report
+	//
+	// Returns:
+	//   - *model.AbuseReport: found report
+	//   - error: ErrNotFound if the report does not exist, or storage error
+	GetAbuseReport(ctx context.Context, id string) (*model.AbuseReport, error)
+
+	// DismissAbuseReport marks an open abuse report as dismissed.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - id: identifier of the report
+	//   - adminID: identifier of the administrator resolving the report
+	//   - resolvedAt: time of the resolution
+	//
+	// Returns:
+	//   - error: ErrNotFound if no open report has the identifier, or storage error
+	DismissAbuseReport(ctx context.Context, id string, adminID string, resolvedAt time.Time) error
+
+	// TakeDownURL disables a link on the given ground and marks its open abuse reports as actioned.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - domain: domain the short URL belongs to, empty for the base URL host
+	//   - shortURL: short URL identifier
+	//   - reason: ground for the takedown
+	//   - adminID: identifier of the administrator taking the link down
+	//   - resolvedAt: time of the takedown
+	//
+	// Returns:
+	//   - error: ErrNotFound if the link does not exist, or storage error
+	TakeDownURL(ctx context.Context,
+		domain string,
+		shortURL string,
+		reason model.TakedownReason,
+		adminID string,
+		resolvedAt time.Time,
+	) error
+
+	// GetURLsForHealthCheck lists links whose destinations are due for a health check,
+	// never checked links first. Deleted, disabled and bundle links are skipped.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - checkedBefore: links checked at or after this time are not due
+	//   - limit: maximum number of links to return
+	//
+	// Returns:
+	//   - []model.URL: due links with domain, short URL and destination
+	//   - error: error if lookup fails
+	GetURLsForHealthCheck(ctx context.Context, checkedBefore time.Time, limit int) ([]model.URL, error)
+
+	// SaveURLHealth records the result of a destination health check.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - domain: domain the short URL belongs to, empty for the base URL host
+	//   - shortURL: short URL identifier
+	//   - health: result of the check
+	//
+	// Returns:
+	//   - error: ErrNotFound if the link does not exist, or storage error
+	SaveURLHealth(ctx context.Context, domain string, shortURL string, health model.URLHealth) error
+
+	// SaveURLMetadata stores the title, description and preview image fetched from a link destination.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - domain: domain the short URL belongs to, empty for the base URL host
+	//   - shortURL: short URL identifier
+	//   - metadata: fetched metadata
+	//
+	// Returns:
+	//   - error: ErrNotFound if the link does not exist, or storage error
+	SaveURLMetadata(ctx context.Context, domain string, shortURL string, metadata model.URLMetadata) error
+
+	// SetUserBanned bans a user or lifts the ban.
+	// Links of banned users stop resolving until the ban is lifted.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the user
+	//   - banned: true to ban the user, false to lift the ban
+	//
+	// Returns:
+	//   - error: error if storage operation fails
+	SetUserBanned(ctx context.Context, userID string, banned bool) error
+
+	// IsUserBanned reports whether a user is banned.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the user
+	//
+	// Returns:
+	//   - bool: true if the user is banned
+	//   - error: error if lookup fails
+	IsUserBanned(ctx context.Context, userID string) (bool, error)
+
+	// GetUserUsage counts links and API keys of a user.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the user
+	//
+	// Returns:
+	//   - *model.UserUsage: usage of the user, zero counts for unknown users
+	//   - error: error if lookup fails
+	GetUserUsage(ctx context.Context, userID string) (*model.UserUsage, error)
+
+	// GetStats counts links that are not deleted and distinct users who created links.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//
+	// Returns:
+	//   - *model.Stats: link and user counts
+	//   - error: error if counting fails
+	GetStats(ctx context.Context) (*model.Stats, error)
+
+	// GetQuotaUsage retrieves the quota counters of a user.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the user
+	//   - day: UTC day the created links are counted for
+	//
+	// Returns:
+	//   - *model.QuotaUsage: links created in the day and links that are not deleted
+	//   - error: error if lookup fails
+	GetQuotaUsage(ctx context.Context, userID string, day time.Time) (*model.QuotaUsage, error)
+
+	// ReserveLinks checks the limits of a user and counts links the user is about to create in a single step,
+	// so that concurrent requests of the user cannot exceed the limits together.
+	// Reserved links are counted against the daily limit at once and stay pending until ReleaseLinks,
+	// pending links are counted as active links. Counters of earlier days are no longer needed and may be dropped.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the user
+	//   - day: UTC day the links are created in
+	//   - count: number of links to create
+	//   - limits: limits of the user, zero limits are unlimited
+	//
+	// Returns:
+	//   - *model.QuotaUsage: usage before the reservation, pending links of other requests included
+	//   - bool: false if a limit would be exceeded and nothing was reserved
+	//   - error: error if storage operation fails
+	ReserveLinks(ctx context.Context,
+		userID string,
+		day time.Time,
+		count int64,
+		limits model.QuotaLimits,
+	) (*model.QuotaUsage, bool, error)
+
+	// ReleaseLinks ends a reservation made by ReserveLinks once the links are stored or could not be stored.
+	// Links that were not created are no longer counted against the daily limit.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the user
+	//   - day: UTC day the links were reserved in
+	//   - reserved: number of reserved links
+	//   - unused: number of reserved links that were not created
+	//
+	// Returns:
+	//   - error: error if storage operation fails
+	ReleaseLinks(ctx context.Context, userID string, day time.Time, reserved int64, unused int64) error
+
+	// GetUserQuotaLimits retrieves the limits assigned to a single user.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the user
+	//
+	// Returns:
+	//   - *model.QuotaLimits: limits of the user
+	//   - error: ErrNotFound if the user has the default limits, or lookup error
+	GetUserQuotaLimits(ctx context.Context, userID string) (*model.QuotaLimits, error)
+
+	// SaveUserQuotaLimits assigns limits to a single user, replacing the default limits.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the user
+	//   - limits: limits of the user, zero limits are unlimited
+	//
+	// Returns:
+	//   - error: error if storage operation fails
+	SaveUserQuotaLimits(ctx context.Context, userID string, limits model.QuotaLimits) error
+
 	// Ping checks the connectivity to the underlying storage.
 	// Used for health checks and monitoring.
 	//
@@ -98,3 +717,167 @@ type Repository interface {
 	//   - error: error if resource cleanup fails
 	Close() error
 }
+
+// userTokensRevocation holds the revocation of all tokens of a user in map based storages.
+type userTokensRevocation struct {
+	revokedBefore time.Time
+	expiresAt     time.Time
+}
+
+// isTokenRevoked checks a token against the revocations of map based storages.
+//
+// Parameters:
+//   - revokedTokens: expiration times of revoked tokens by jti
+//   - userRevocations: revocations of all tokens by user
+//   - tokenID: jti claim of the token, empty for tokens without one
+//   - userID: identifier of the user the token was issued to
+//   - issuedAt: issue time of the token, zero for tokens without one
+//   - now: current time, expired revocations are ignored
+//
+// Returns:
+//   - bool: true if the token is revoked
+func isTokenRevoked(revokedTokens map[string]time.Time,
+	userRevocations map[string]userTokensRevocation,
+	tokenID string,
+	userID string,
+	issuedAt time.Time,
+	now time.Time,
+) bool {
+	if expiresAt, exists := revokedTokens[tokenID]; tokenID != "" && exists && expiresAt.After(now) {
+		return true
+	}
+	revocation, exists := userRevocations[userID]
+	return exists && revocation.expiresAt.After(now) && issuedAt.Before(revocation.revokedBefore)
+}
+
+// dailyLinks holds the counter of links a user created in the latest day in map based storages.
+type dailyLinks struct {
+	day     time.Time
+	links   int64
+	pending int64
+}
+
+// linksIn returns the number of links counted for the day.
+//
+// Parameters:
+//   - day: UTC day the created links are counted for
+//
+// Returns:
+//   - int64: counted links, zero if the counter belongs to another day
+func (d dailyLinks) linksIn(day time.Time) int64 {
+	if !d.day.Equal(day) {
+		return 0
+	}
+	return d.links
+}
+
+// pendingIn returns the number of links reserved in the day that are not stored yet.
+//
+// Parameters:
+//   - day: UTC day the links were reserved in
+//
+// Returns:
+//   - int64: pending links, zero if the counter belongs to another day
+func (d dailyLinks) pendingIn(day time.Time) int64 {
+	if !d.day.Equal(day) {
+		return 0
+	}
+	return d.pending
+}
+
+// reserve checks the limits and returns the counter increased by links reserved in the day.
+// Counters of earlier days are replaced.
+//
+// Parameters:
+//   - day: UTC day the links are created in
+//   - count: number of links to create
+//   - limits: limits of the user, zero limits are unlimited
+//   - activeLinks: stored links of the user that are not deleted
+//
+// Returns:
+//   - dailyLinks: increased counter, the same counter if nothing was reserved
+//   - *model.QuotaUsage: usage before the reservation
+//   - bool: false if a limit would be exceeded
+func (d dailyLinks) reserve(day time.Time,
+	count int64,
+	limits model.QuotaLimits,
+	activeLinks int64,
+) (dailyLinks, *model.QuotaUsage, bool) {
+	usage := &model.QuotaUsage{LinksToday: d.linksIn(day), ActiveLinks: activeLinks + d.pendingIn(day)}
+	if !canReserve(limits, *usage, count) {
+		return d, usage, false
+	}
+	return dailyLinks{day: day, links: usage.LinksToday + count, pending: d.pendingIn(day) + count}, usage, true
+}
+
+// release returns the counter without the released links.
+// Counters of other days are not changed.
+//
+// Parameters:
+//   - day: UTC day the links were reserved in
+//   - reserved: number of reserved links
+//   - unused: number of reserved links that were not created
+//
+// Returns:
+//   - dailyLinks: decreased counter
+func (d dailyLinks) release(day time.Time, reserved int64, unused int64) dailyLinks {
+	if !d.day.Equal(day) {
+		return d
+	}
+	return dailyLinks{day: day, links: max(d.links-unused, 0), pending: max(d.pending-reserved, 0)}
+}
+
+// canReserve reports whether links may be created without exceeding the limits.
+//
+// Parameters:
+//   - limits: limits of the user, zero limits are unlimited
+//   - usage: usage before the links are created
+//   - count: number of links to create
+//
+// Returns:
+//   - bool: true if neither the daily limit nor the active links limit would be exceeded
+func canReserve(limits model.QuotaLimits, usage model.QuotaUsage, count int64) bool {
+	quota := model.Quota{Limits: limits, Usage: usage}
+	if remaining, limited := quota.RemainingLinksToday(); limited && count > remaining {
+		return false
+	}
+	remaining, limited := quota.RemainingActiveLinks()
+	return !limited || count <= remaining
+}
+
+// shortURLKey identifies a short URL within its domain in map based storages.
+type shortURLKey struct {
+	domain   string
+	shortURL string
+}
+
+// originalURLKey identifies an original URL within its domain in map based storages.
+type originalURLKey struct {
+	domain      string
+	originalURL string
+}
+
+// originalURLLink is a link to an original URL kept in the original URL index of map based storages.
+type originalURLLink struct {
+	shortURL string
+	userID   string
+}
+
+// findOriginalURLLink finds the earliest link of a user among the links to an original URL.
+//
+// Parameters:
+//   - links: links to the original URL in creation order
+//   - userID: owner of the link, empty to accept links of any user
+//   - servable: reports whether a link is redirected to, e.g. not held for review
+//
+// Returns:
+//   - string: short URL identifier of the found link
+//   - bool: false if no link matches
+func findOriginalURLLink(links []originalURLLink, userID string, servable func(shortURL string) bool) (string, bool) {
+	for _, link := range links {
+		if (userID == "" || link.userID == userID) && servable(link.shortURL) {
+			return link.shortURL, true
+		}
+	}
+	return "", false
+}
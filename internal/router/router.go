@@ -9,59 +9,208 @@ import (
 	"github.com/bezjen/shortener/internal/service"
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"net"
 )
 
 // NewRouter creates and configures the HTTP router with all routes and middleware.
 // Sets up authentication, logging, compression, and routes for URL shortening operations.
+// Each route group authenticates requests by its own policy, so that reading links,
+// health checks and crawlers never create users.
 //
 // Parameters:
 //   - logger: logger instance for request logging
 //   - authorizer: JWT authorizer service for authentication
+//   - apiKeyService: API key service for authentication of programmatic clients
+//   - oidcService: OIDC service accepting Bearer ID tokens of the issuer, nil disables them
+//   - adminService: admin service rejecting writes of banned users
+//   - quotaService: quota service reporting remaining quotas of users, nil disables the quota headers
 //   - shortenerHandler: handler for URL shortening operations
+//   - accountHandler: handler for registration, login and API keys
+//   - adminHandler: handler for moderation by administrators
+//   - trustedSubnet: subnet allowed to read internal stats, nil rejects all clients
+//   - trustedHosts: hosts of pages allowed to send cookie-authenticated writes next to the request host
+//   - rateLimiter: rate limits of creating links and redirects, nil disables them
+//   - bodyLimits: maximum sizes of request bodies, zero values select the defaults
 //
 // Returns:
 //   - *chi.Mux: configured HTTP router
 //
 // Middleware order:
-//  1. Authentication - validates JWT tokens and sets user context
-//  2. Logging - logs request details and response metrics
-//  3. GZIP decompression - decompresses request bodies
-//  4. GZIP compression - compresses responses when supported
+//  1. Logging - logs request details and response metrics
+//  2. GZIP decompression - decompresses request bodies within the decompressed size and ratio limits
+//  3. GZIP compression - compresses responses when supported
+//  4. Body limit - limits request bodies, routes accepting lists of links allow the larger batch limit
+//  5. Authentication - validates JWT tokens or API keys by the policy of the route group
+//  6. Rate limit - limits routes creating links or reports per user and client IP, and redirects per client IP
+//  7. CSRF check - rejects cross-site writes authenticated by the cookie in route groups with users
+//  8. Ban check - rejects writes of banned users in route groups with users
+//  9. Quota headers - report remaining quotas on routes creating or deleting links
 //
-// Routes:
-//   - POST / - Create short URL from plain text
+// Routes without authentication:
 //   - GET /ping - Health check endpoint
+//   - GET /.well-known/jwks.json - Public keys verifying user tokens
+//   - /debug - Profiler endpoint (for development)
+//   - GET /api/internal/stats - Link and user counts (trusted subnet only)
+//
+// Routes with optional authentication:
 //   - GET /{shortURL} - Redirect to original URL
+//   - POST /api/user/register - Register account and claim anonymous links
+//   - POST /api/user/login - Log in to account and claim anonymous links
+//   - POST /api/user/logout - Revoke current token and delete auth cookie
+//   - GET /api/auth/oidc/login - Start OpenID Connect login
+//   - GET /api/auth/oidc/callback - Finish OpenID Connect login
+//   - POST /api/report/{shortURL} - Report abusive link
+//
+// Routes creating anonymous users:
+//   - POST / - Create short URL from plain text
 //   - POST /api/shorten - Create short URL from JSON
 //   - POST /api/shorten/batch - Batch URL shortening
+//   - POST /api/bundle - Create bundle of links
+//
+// Routes with required authentication:
+//   - POST /api/user/keys - Create API key
+//   - GET /api/user/keys - Get user's API keys
+//   - DELETE /api/user/keys/{keyID} - Revoke API key
 //   - GET /api/user/urls - Get user's URLs
+//   - GET /api/lookup - Find short URL of an original URL
 //   - DELETE /api/user/urls - Delete user's URLs
-//   - /debug - Profiler endpoint (for development)
+//   - GET /api/user/urls/trash - Get user's deleted URLs
+//   - POST /api/user/urls/restore - Restore user's deleted URLs
+//   - POST /api/workspaces - Create workspace
+//   - GET /api/workspaces - Get user's workspaces
+//   - GET /api/workspaces/{workspaceID}/members - Get workspace members
+//   - PUT /api/workspaces/{workspaceID}/members - Add or update workspace member
+//   - DELETE /api/workspaces/{workspaceID}/members/{userID} - Remove workspace member
+//   - POST /api/workspaces/{workspaceID}/shorten - Create short URL in workspace
+//   - GET /api/workspaces/{workspaceID}/urls - Get workspace URLs
+//   - DELETE /api/workspaces/{workspaceID}/urls - Delete workspace URLs
+//   - PATCH /api/workspaces/{workspaceID}/urls/{shortURL} - Change original URL of workspace link
+//   - GET /api/workspaces/{workspaceID}/urls/trash - Get workspace's deleted URLs
+//   - POST /api/workspaces/{workspaceID}/urls/restore - Restore workspace's deleted URLs
+//
+// Routes for administrators only:
+//   - GET /api/admin/urls - Search links by code, destination or owner
+//   - POST /api/admin/urls/{shortURL}/disable - Disable link
+//   - POST /api/admin/urls/{shortURL}/enable - Enable disabled link
+//   - POST /api/admin/urls/{shortURL}/clear-review - Clear link flagged by phishing heuristics
+//   - GET /api/admin/reports - Get abuse reports by status
+//   - POST /api/admin/reports/{reportID}/dismiss - Dismiss abuse report
+//   - POST /api/admin/reports/{reportID}/takedown - Take down link of abuse report
+//   - POST /api/admin/users/{userID}/ban - Ban user
+//   - DELETE /api/admin/users/{userID}/ban - Lift ban of user
+//   - GET /api/admin/users/{userID}/usage - Get usage of user
+//   - POST /api/admin/users/{userID}/revoke-tokens - Revoke all tokens of a user
+//   - PUT /api/admin/users/{userID}/quota - Assign quota limits to user
 func NewRouter(logger *logger.Logger,
 	authorizer service.Authorizer,
+	apiKeyService service.APIKeyService,
+	oidcService service.OIDCService,
+	adminService service.AdminService,
+	quotaService service.QuotaService,
 	shortenerHandler handler.ShortenerHandler,
+	accountHandler handler.AccountHandler,
+	adminHandler handler.AdminHandler,
+	trustedSubnet *net.IPNet,
+	trustedHosts []string,
+	rateLimiter *middleware.RateLimitMiddleware,
+	bodyLimits middleware.BodyLimits,
 ) *chi.Mux {
 	r := chi.NewRouter()
-	authMiddleware := middleware.NewAuthMiddleware(authorizer, logger)
+	authMiddleware := middleware.NewAuthMiddleware(authorizer, apiKeyService, oidcService, logger)
+	adminMiddleware := middleware.NewAdminMiddleware(adminService, logger)
+	trustedSubnetMiddleware := middleware.NewTrustedSubnetMiddleware(trustedSubnet)
+	csrfMiddleware := middleware.NewCSRFMiddleware(trustedHosts, logger)
+	quotaMiddleware := middleware.NewQuotaMiddleware(quotaService, logger)
 	loggingMiddleware := middleware.NewLoggingMiddleware(logger)
 
-	gzipMiddleware := middleware.NewGzipMiddleware(logger)
+	gzipMiddleware := middleware.NewGzipMiddleware(logger, bodyLimits)
+	bodyLimitMiddleware := middleware.NewBodyLimitMiddleware(bodyLimits)
 
 	r.Use(
-		authMiddleware.WithAuth,
 		loggingMiddleware.WithLogging,
+		bodyLimitMiddleware.WithBodyLimit,
 		gzipMiddleware.WithGzipRequestDecompression,
 		gzipMiddleware.WithGzipResponseCompression)
 
-	r.Post("/", shortenerHandler.HandlePostShortURLTextPlain)
-	r.Get("/ping", shortenerHandler.HandlePingRepository)
-	r.Get("/{shortURL}", shortenerHandler.HandleGetShortURLRedirect)
-	r.Post("/api/shorten", shortenerHandler.HandlePostShortURLJSON)
-	r.Post("/api/shorten/batch", shortenerHandler.HandlePostShortURLBatchJSON)
-	r.Get("/api/user/urls", shortenerHandler.HandleGetUserURLsJSON)
-	r.Delete("/api/user/urls", shortenerHandler.HandleDeleteShortURLsBatchJSON)
+	r.Group(func(r chi.Router) {
+		r.Use(authMiddleware.WithPolicy(middleware.AuthNone))
+		r.Get("/ping", shortenerHandler.HandlePingRepository)
+		r.Get("/.well-known/jwks.json", accountHandler.HandleGetJWKS)
+		r.Mount("/debug", chimiddleware.Profiler())
+		r.With(trustedSubnetMiddleware.RequireTrustedSubnet).Get("/api/internal/stats",
+			shortenerHandler.HandleGetInternalStatsJSON)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(authMiddleware.WithPolicy(middleware.AuthOptional), csrfMiddleware.WithCSRFProtection)
+		r.With(rateLimiter.LimitRedirect).Get("/{shortURL}", shortenerHandler.HandleGetShortURLRedirect)
+		r.Post("/api/user/register", accountHandler.HandleRegisterJSON)
+		r.Post("/api/user/login", accountHandler.HandleLoginJSON)
+		r.Post("/api/user/logout", accountHandler.HandleLogout)
+		r.Get("/api/auth/oidc/login", accountHandler.HandleOIDCLogin)
+		r.Get("/api/auth/oidc/callback", accountHandler.HandleOIDCCallback)
+		r.With(rateLimiter.LimitCreate).Post("/api/report/{shortURL}", adminHandler.HandlePostReportJSON)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(authMiddleware.WithPolicy(middleware.AuthAnonymousCreate),
+			rateLimiter.LimitCreate,
+			csrfMiddleware.WithCSRFProtection,
+			adminMiddleware.RejectBanned,
+			quotaMiddleware.WithQuotaHeaders)
+		r.Post("/", shortenerHandler.HandlePostShortURLTextPlain)
+		r.Post("/api/shorten", shortenerHandler.HandlePostShortURLJSON)
+		r.With(bodyLimitMiddleware.WithBatchBodyLimit).Post("/api/shorten/batch",
+			shortenerHandler.HandlePostShortURLBatchJSON)
+		r.With(bodyLimitMiddleware.WithBatchBodyLimit).Post("/api/bundle", shortenerHandler.HandlePostBundleJSON)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(authMiddleware.WithPolicy(middleware.AuthRequired),
+			csrfMiddleware.WithCSRFProtection,
+			adminMiddleware.RejectBanned)
+		r.Post("/api/user/keys", accountHandler.HandlePostAPIKeyJSON)
+		r.Get("/api/user/keys", accountHandler.HandleGetAPIKeysJSON)
+		r.Delete("/api/user/keys/{keyID}", accountHandler.HandleDeleteAPIKey)
+		r.Get("/api/user/urls", shortenerHandler.HandleGetUserURLsJSON)
+		r.Get("/api/lookup", shortenerHandler.HandleGetLookupJSON)
+		r.With(bodyLimitMiddleware.WithBatchBodyLimit, quotaMiddleware.WithQuotaHeaders).Delete("/api/user/urls",
+			shortenerHandler.HandleDeleteShortURLsBatchJSON)
+		r.Get("/api/user/urls/trash", shortenerHandler.HandleGetUserTrashURLsJSON)
+		r.With(bodyLimitMiddleware.WithBatchBodyLimit).Post("/api/user/urls/restore",
+			shortenerHandler.HandleRestoreShortURLsBatchJSON)
+		r.Post("/api/workspaces", shortenerHandler.HandlePostWorkspaceJSON)
+		r.Get("/api/workspaces", shortenerHandler.HandleGetWorkspacesJSON)
+		r.Get("/api/workspaces/{workspaceID}/members", shortenerHandler.HandleGetWorkspaceMembersJSON)
+		r.Put("/api/workspaces/{workspaceID}/members", shortenerHandler.HandlePutWorkspaceMemberJSON)
+		r.Delete("/api/workspaces/{workspaceID}/members/{userID}", shortenerHandler.HandleDeleteWorkspaceMember)
+		r.With(rateLimiter.LimitCreate, quotaMiddleware.WithQuotaHeaders).Post("/api/workspaces/{workspaceID}/shorten",
+			shortenerHandler.HandlePostWorkspaceShortURLJSON)
+		r.Get("/api/workspaces/{workspaceID}/urls", shortenerHandler.HandleGetWorkspaceURLsJSON)
+		r.With(bodyLimitMiddleware.WithBatchBodyLimit).Delete("/api/workspaces/{workspaceID}/urls",
+			shortenerHandler.HandleDeleteWorkspaceURLsJSON)
+		r.With(rateLimiter.LimitCreate).Patch("/api/workspaces/{workspaceID}/urls/{shortURL}",
+			shortenerHandler.HandlePatchWorkspaceURLJSON)
+		r.Get("/api/workspaces/{workspaceID}/urls/trash", shortenerHandler.HandleGetWorkspaceTrashURLsJSON)
+		r.With(bodyLimitMiddleware.WithBatchBodyLimit).Post("/api/workspaces/{workspaceID}/urls/restore",
+			shortenerHandler.HandleRestoreWorkspaceURLsJSON)
 
-	r.Mount("/debug", chimiddleware.Profiler())
+		r.Route("/api/admin", func(r chi.Router) {
+			r.Use(adminMiddleware.RequireAdmin)
+			r.Get("/urls", adminHandler.HandleSearchURLsJSON)
+			r.Post("/urls/{shortURL}/disable", adminHandler.HandleDisableURL)
+			r.Post("/urls/{shortURL}/enable", adminHandler.HandleEnableURL)
+			r.Post("/urls/{shortURL}/clear-review", adminHandler.HandleClearURLReview)
+			r.Get("/reports", adminHandler.HandleGetAbuseReportsJSON)
+			r.Post("/reports/{reportID}/dismiss", adminHandler.HandleDismissAbuseReport)
+			r.Post("/reports/{reportID}/takedown", adminHandler.HandleTakeDownReportedURLJSON)
+			r.Post("/users/{userID}/ban", adminHandler.HandleBanUser)
+			r.Delete("/users/{userID}/ban", adminHandler.HandleUnbanUser)
+			r.Get("/users/{userID}/usage", adminHandler.HandleGetUserUsageJSON)
+			r.Post("/users/{userID}/revoke-tokens", adminHandler.HandleRevokeUserTokens)
+			r.Put("/users/{userID}/quota", adminHandler.HandlePutUserQuotaJSON)
+		})
+	})
 
 	return r
 }
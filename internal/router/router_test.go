@@ -2,18 +2,26 @@ package router
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
 	"errors"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/bezjen/shortener/internal/config"
 	"github.com/bezjen/shortener/internal/handler"
 	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/middleware"
 	"github.com/bezjen/shortener/internal/mocks"
 	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/repository"
+	"github.com/bezjen/shortener/internal/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewRouter(t *testing.T) {
@@ -24,6 +32,7 @@ func TestNewRouter(t *testing.T) {
 		method       string
 		path         string
 		body         []byte
+		authToken    string
 		setupMocks   func(*mocks.Authorizer, *mocks.Shortener, *mocks.AuditService)
 		expectedCode int
 	}{
@@ -35,7 +44,7 @@ func TestNewRouter(t *testing.T) {
 			setupMocks: func(a *mocks.Authorizer, s *mocks.Shortener, audit *mocks.AuditService) {
 				// Настраиваем создание токена для нового пользователя
 				a.On("CreateToken", mock.AnythingOfType("string")).Return("test-token", nil)
-				s.On("GenerateShortURLPart", mock.Anything, mock.Anything, "https://example.com").Return("abc123", nil)
+				s.On("GenerateShortURLPart", mock.Anything, mock.Anything, "", "https://example.com").Return("abc123", nil)
 				audit.On("NotifyAll", mock.Anything).Return()
 			},
 			expectedCode: 201,
@@ -57,7 +66,6 @@ func TestNewRouter(t *testing.T) {
 			path:   "/ping",
 			body:   nil,
 			setupMocks: func(a *mocks.Authorizer, s *mocks.Shortener, audit *mocks.AuditService) {
-				a.On("CreateToken", mock.AnythingOfType("string")).Return("test-token", nil)
 				s.On("PingRepository", mock.Anything).Return(nil)
 			},
 			expectedCode: 200,
@@ -68,7 +76,6 @@ func TestNewRouter(t *testing.T) {
 			path:   "/ping",
 			body:   nil,
 			setupMocks: func(a *mocks.Authorizer, s *mocks.Shortener, audit *mocks.AuditService) {
-				a.On("CreateToken", mock.AnythingOfType("string")).Return("test-token", nil)
 				s.On("PingRepository", mock.Anything).Return(errors.New("db error"))
 			},
 			expectedCode: 500,
@@ -79,9 +86,8 @@ func TestNewRouter(t *testing.T) {
 			path:   "/abc123",
 			body:   nil,
 			setupMocks: func(a *mocks.Authorizer, s *mocks.Shortener, audit *mocks.AuditService) {
-				a.On("CreateToken", mock.AnythingOfType("string")).Return("test-token", nil)
 				url := model.NewURL("abc123", "https://example.com")
-				s.On("GetURLByShortURLPart", mock.Anything, "abc123").Return(url, nil)
+				s.On("GetURLByShortURLPart", mock.Anything, "", "abc123").Return(url, nil)
 				audit.On("NotifyAll", mock.Anything).Return()
 			},
 			expectedCode: 307,
@@ -92,8 +98,7 @@ func TestNewRouter(t *testing.T) {
 			path:   "/nonexistent",
 			body:   nil,
 			setupMocks: func(a *mocks.Authorizer, s *mocks.Shortener, audit *mocks.AuditService) {
-				a.On("CreateToken", mock.AnythingOfType("string")).Return("test-token", nil)
-				s.On("GetURLByShortURLPart", mock.Anything, "nonexistent").Return(nil, errors.New("not found"))
+				s.On("GetURLByShortURLPart", mock.Anything, "", "nonexistent").Return(nil, errors.New("not found"))
 			},
 			expectedCode: 500,
 		},
@@ -103,10 +108,9 @@ func TestNewRouter(t *testing.T) {
 			path:   "/deleted123",
 			body:   nil,
 			setupMocks: func(a *mocks.Authorizer, s *mocks.Shortener, audit *mocks.AuditService) {
-				a.On("CreateToken", mock.AnythingOfType("string")).Return("test-token", nil)
 				url := model.NewURL("deleted123", "https://example.com")
 				url.IsDeleted = true
-				s.On("GetURLByShortURLPart", mock.Anything, "deleted123").Return(url, nil)
+				s.On("GetURLByShortURLPart", mock.Anything, "", "deleted123").Return(url, nil)
 			},
 			expectedCode: 410,
 		},
@@ -117,7 +121,7 @@ func TestNewRouter(t *testing.T) {
 			body:   []byte(`{"url":"https://example.com"}`),
 			setupMocks: func(a *mocks.Authorizer, s *mocks.Shortener, audit *mocks.AuditService) {
 				a.On("CreateToken", mock.AnythingOfType("string")).Return("test-token", nil)
-				s.On("GenerateShortURLPart", mock.Anything, mock.Anything, "https://example.com").Return("abc123", nil)
+				s.On("GenerateShortURLPart", mock.Anything, mock.Anything, "", "https://example.com").Return("abc123", nil)
 				audit.On("NotifyAll", mock.Anything).Return()
 			},
 			expectedCode: 201,
@@ -155,63 +159,129 @@ func TestNewRouter(t *testing.T) {
 				responseItems := []model.ShortenBatchResponseItem{
 					*model.NewShortenBatchResponseItem("1", "abc123"),
 				}
-				s.On("GenerateShortURLPartBatch", mock.Anything, mock.Anything, requestItems).Return(responseItems, nil)
+				s.On("GenerateShortURLPartBatch", mock.Anything, mock.Anything, "", requestItems).Return(responseItems, nil)
 			},
 			expectedCode: 201,
 		},
 		{
-			name:   "GET /api/user/urls with no URLs",
-			method: "GET",
-			path:   "/api/user/urls",
-			body:   nil,
+			name:      "GET /api/user/urls with no URLs",
+			method:    "GET",
+			path:      "/api/user/urls",
+			authToken: "valid-token",
+			body:      nil,
 			setupMocks: func(a *mocks.Authorizer, s *mocks.Shortener, audit *mocks.AuditService) {
-				a.On("CreateToken", mock.AnythingOfType("string")).Return("test-token", nil)
+				a.On("ValidateToken", mock.Anything, "valid-token").Return("user-1", nil)
 				s.On("GetURLsByUserID", mock.Anything, mock.Anything).Return([]model.URL{}, nil)
 			},
 			expectedCode: 204,
 		},
 		{
-			name:   "DELETE /api/user/urls with valid JSON",
-			method: "DELETE",
-			path:   "/api/user/urls",
-			body:   []byte(`["abc123","def456"]`),
+			name:      "GET /api/lookup with existing link",
+			method:    "GET",
+			path:      "/api/lookup?url=https%3A%2F%2Fexample.com%2F",
+			authToken: "valid-token",
+			body:      nil,
 			setupMocks: func(a *mocks.Authorizer, s *mocks.Shortener, audit *mocks.AuditService) {
-				a.On("CreateToken", mock.AnythingOfType("string")).Return("test-token", nil)
-				s.On("DeleteUserShortURLsBatch", mock.Anything, mock.Anything, []string{"abc123", "def456"}).Return(nil)
+				a.On("ValidateToken", mock.Anything, "valid-token").Return("user-1", nil)
+				s.On("LookupShortURLPart", mock.Anything, "user-1", "", "https://example.com/").
+					Return(model.NewURL("abc123", "https://example.com/"), nil)
+			},
+			expectedCode: 200,
+		},
+		{
+			name:         "GET /api/lookup without authentication",
+			method:       "GET",
+			path:         "/api/lookup?url=https%3A%2F%2Fexample.com%2F",
+			body:         nil,
+			setupMocks:   func(a *mocks.Authorizer, s *mocks.Shortener, audit *mocks.AuditService) {},
+			expectedCode: 401,
+		},
+		{
+			name:      "DELETE /api/user/urls with valid JSON",
+			method:    "DELETE",
+			path:      "/api/user/urls",
+			authToken: "valid-token",
+			body:      []byte(`["abc123","def456"]`),
+			setupMocks: func(a *mocks.Authorizer, s *mocks.Shortener, audit *mocks.AuditService) {
+				a.On("ValidateToken", mock.Anything, "valid-token").Return("user-1", nil)
+				s.On("DeleteUserShortURLsBatch", mock.Anything, mock.Anything, "", []string{"abc123", "def456"}).Return(nil)
 			},
 			expectedCode: 202,
 		},
 		{
-			name:   "DELETE /api/user/urls with invalid JSON",
-			method: "DELETE",
-			path:   "/api/user/urls",
-			body:   []byte(`invalid json`),
+			name:      "DELETE /api/user/urls with invalid JSON",
+			method:    "DELETE",
+			path:      "/api/user/urls",
+			authToken: "valid-token",
+			body:      []byte(`invalid json`),
 			setupMocks: func(a *mocks.Authorizer, s *mocks.Shortener, audit *mocks.AuditService) {
-				a.On("CreateToken", mock.AnythingOfType("string")).Return("test-token", nil)
+				a.On("ValidateToken", mock.Anything, "valid-token").Return("user-1", nil)
 			},
 			expectedCode: 400,
 		},
 		{
-			name:   "DELETE /api/user/urls with queue full",
-			method: "DELETE",
-			path:   "/api/user/urls",
-			body:   []byte(`["abc123","def456"]`),
+			name:      "DELETE /api/user/urls with queue full",
+			method:    "DELETE",
+			path:      "/api/user/urls",
+			authToken: "valid-token",
+			body:      []byte(`["abc123","def456"]`),
 			setupMocks: func(a *mocks.Authorizer, s *mocks.Shortener, audit *mocks.AuditService) {
-				a.On("CreateToken", mock.AnythingOfType("string")).Return("test-token", nil)
-				s.On("DeleteUserShortURLsBatch", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("queue full"))
+				a.On("ValidateToken", mock.Anything, "valid-token").Return("user-1", nil)
+				s.On("DeleteUserShortURLsBatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(errors.New("queue full"))
 			},
 			expectedCode: 429,
 		},
+		{
+			name:   "POST /api/user/register with invalid JSON",
+			method: "POST",
+			path:   "/api/user/register",
+			body:   []byte(`invalid json`),
+			setupMocks: func(a *mocks.Authorizer, s *mocks.Shortener, audit *mocks.AuditService) {
+			},
+			expectedCode: 400,
+		},
+		{
+			name:      "POST /api/admin/users/{userID}/revoke-tokens without admin role",
+			method:    "POST",
+			path:      "/api/admin/users/user-2/revoke-tokens",
+			authToken: "valid-token",
+			body:      nil,
+			setupMocks: func(a *mocks.Authorizer, s *mocks.Shortener, audit *mocks.AuditService) {
+				a.On("ValidateToken", mock.Anything, "valid-token").Return("user-1", nil)
+			},
+			expectedCode: 403,
+		},
 		{
 			name:   "GET /debug/pprof/",
 			method: "GET",
 			path:   "/debug/pprof/",
 			body:   nil,
 			setupMocks: func(a *mocks.Authorizer, s *mocks.Shortener, audit *mocks.AuditService) {
-				a.On("CreateToken", mock.AnythingOfType("string")).Return("test-token", nil)
 			},
 			expectedCode: 200,
 		},
+		{
+			name:         "GET /api/user/urls without credentials",
+			method:       "GET",
+			path:         "/api/user/urls",
+			body:         nil,
+			setupMocks:   func(a *mocks.Authorizer, s *mocks.Shortener, audit *mocks.AuditService) {},
+			expectedCode: 401,
+		},
+		{
+			name:      "GET existing short URL with invalid cookie",
+			method:    "GET",
+			path:      "/abc123",
+			authToken: "stale-token",
+			setupMocks: func(a *mocks.Authorizer, s *mocks.Shortener, audit *mocks.AuditService) {
+				a.On("ValidateToken", mock.Anything, "stale-token").Return("", errors.New("invalid token"))
+				url := model.NewURL("abc123", "https://example.com")
+				s.On("GetURLByShortURLPart", mock.Anything, "", "abc123").Return(url, nil)
+				audit.On("NotifyAll", mock.Anything).Return()
+			},
+			expectedCode: 307,
+		},
 	}
 
 	for _, tt := range tests {
@@ -230,7 +300,15 @@ func TestNewRouter(t *testing.T) {
 				mockAudit,
 			)
 
-			router := NewRouter(testLogger, mockAuthorizer, *shortenerHandler)
+			accountHandler := handler.NewAccountHandler(testLogger, new(mocks.AccountService), new(mocks.APIKeyService),
+				nil, mockAuthorizer)
+
+			mockAdminService := new(mocks.AdminService)
+			mockAdminService.On("IsUserBanned", mock.Anything, mock.Anything).Return(false, nil).Maybe()
+			adminHandler := handler.NewAdminHandler(testLogger, mockAdminService)
+
+			router := NewRouter(testLogger, mockAuthorizer, new(mocks.APIKeyService), nil, mockAdminService, nil,
+				*shortenerHandler, *accountHandler, *adminHandler, nil, nil, nil, middleware.BodyLimits{})
 
 			// Создаем запрос
 			var req *http.Request
@@ -240,11 +318,11 @@ func TestNewRouter(t *testing.T) {
 				req = httptest.NewRequest(tt.method, tt.path, nil)
 			}
 
-			// Для теста с аутентифицированным пользователем добавляем cookie
-			if tt.name == "GET /api/user/urls with authenticated user" {
+			// Для тестов с аутентифицированным пользователем добавляем cookie
+			if tt.authToken != "" {
 				req.AddCookie(&http.Cookie{
 					Name:  "user_token",
-					Value: "valid-token",
+					Value: tt.authToken,
 				})
 			}
 
@@ -271,3 +349,422 @@ func TestNewRouter(t *testing.T) {
 		})
 	}
 }
+
+func TestNewRouter_Admin(t *testing.T) {
+	testLogger, _ := logger.NewLogger("error")
+	tokenIssuer := service.NewAuthorizer([]byte("secret"), testLogger)
+	tokenIssuer.SetAdminUsers([]string{"admin-user"})
+	adminToken, err := tokenIssuer.CreateToken("admin-user")
+	require.NoError(t, err)
+	userToken, err := tokenIssuer.CreateToken("user-1")
+	require.NoError(t, err)
+
+	mockAuthorizer := new(mocks.Authorizer)
+	mockAuthorizer.On("ValidateToken", mock.Anything, adminToken).Return("admin-user", nil)
+	mockAuthorizer.On("ValidateToken", mock.Anything, userToken).Return("user-1", nil)
+	mockAdminService := new(mocks.AdminService)
+	mockAdminService.On("IsUserBanned", mock.Anything, mock.Anything).Return(false, nil)
+	mockAdminService.On("RevokeUserTokens", mock.Anything, "admin-user", "user-2").Return(nil)
+	mockAdminService.On("SetURLDisabled", mock.Anything, "admin-user", "", "abc123", true).Return(nil)
+	mockAdminService.On("ClearURLReview", mock.Anything, "admin-user", "", "abc123").Return(nil)
+	mockAdminService.On("SetUserBanned", mock.Anything, "admin-user", "user-2", false).Return(nil)
+	mockAdminService.On("GetAbuseReports", mock.Anything, "admin-user", model.AbuseReportFilter{}).
+		Return([]model.AbuseReport{{ID: "report-1", ShortURL: "abc123"}}, nil)
+	mockAdminService.On("DismissAbuseReport", mock.Anything, "admin-user", "report-1").Return(nil)
+
+	shortenerHandler := handler.NewShortenerHandler(config.Config{BaseURL: "http://test.com"}, testLogger,
+		new(mocks.Shortener), new(mocks.AuditService))
+	accountHandler := handler.NewAccountHandler(testLogger, new(mocks.AccountService), new(mocks.APIKeyService),
+		nil, mockAuthorizer)
+	adminHandler := handler.NewAdminHandler(testLogger, mockAdminService)
+	router := NewRouter(testLogger, mockAuthorizer, new(mocks.APIKeyService), nil, mockAdminService, nil,
+		*shortenerHandler, *accountHandler, *adminHandler, nil, nil, nil, middleware.BodyLimits{})
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		token        string
+		expectedCode int
+	}{
+		{name: "revoke tokens", method: http.MethodPost, path: "/api/admin/users/user-2/revoke-tokens",
+			token: adminToken, expectedCode: http.StatusNoContent},
+		{name: "disable url", method: http.MethodPost, path: "/api/admin/urls/abc123/disable",
+			token: adminToken, expectedCode: http.StatusNoContent},
+		{name: "clear url review", method: http.MethodPost, path: "/api/admin/urls/abc123/clear-review",
+			token: adminToken, expectedCode: http.StatusNoContent},
+		{name: "unban user", method: http.MethodDelete, path: "/api/admin/users/user-2/ban",
+			token: adminToken, expectedCode: http.StatusNoContent},
+		{name: "abuse reports", method: http.MethodGet, path: "/api/admin/reports",
+			token: adminToken, expectedCode: http.StatusOK},
+		{name: "dismiss abuse report", method: http.MethodPost, path: "/api/admin/reports/report-1/dismiss",
+			token: adminToken, expectedCode: http.StatusNoContent},
+		{name: "regular user reports", method: http.MethodGet, path: "/api/admin/reports",
+			token: userToken, expectedCode: http.StatusForbidden},
+		{name: "regular user", method: http.MethodPost, path: "/api/admin/urls/abc123/disable",
+			token: userToken, expectedCode: http.StatusForbidden},
+		{name: "anonymous", method: http.MethodGet, path: "/api/admin/urls", expectedCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", tt.token)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+		})
+	}
+	mockAdminService.AssertExpectations(t)
+}
+
+func TestNewRouter_Report(t *testing.T) {
+	testLogger, _ := logger.NewLogger("error")
+	mockAuthorizer := new(mocks.Authorizer)
+	mockAdminService := new(mocks.AdminService)
+	mockAdminService.On("ReportURL", mock.Anything, "", "", "abc123",
+		model.AbuseReportRequest{Reason: model.ReportPhishing}).
+		Return(&model.AbuseReport{ID: "report-1", ShortURL: "abc123", Reason: model.ReportPhishing}, nil)
+
+	shortenerHandler := handler.NewShortenerHandler(config.Config{BaseURL: "http://test.com"}, testLogger,
+		new(mocks.Shortener), new(mocks.AuditService))
+	accountHandler := handler.NewAccountHandler(testLogger, new(mocks.AccountService), new(mocks.APIKeyService),
+		nil, mockAuthorizer)
+	adminHandler := handler.NewAdminHandler(testLogger, mockAdminService)
+	router := NewRouter(testLogger, mockAuthorizer, new(mocks.APIKeyService), nil, mockAdminService, nil,
+		*shortenerHandler, *accountHandler, *adminHandler, nil, nil, nil, middleware.BodyLimits{})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/report/abc123", bytes.NewBufferString(`{"reason":"phishing"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Empty(t, rr.Result().Cookies(), "reporting must not create users")
+	mockAdminService.AssertExpectations(t)
+	mockAuthorizer.AssertNotCalled(t, "CreateToken", mock.Anything)
+}
+
+func TestNewRouter_BannedUser(t *testing.T) {
+	testLogger, _ := logger.NewLogger("error")
+	mockAuthorizer := new(mocks.Authorizer)
+	mockAuthorizer.On("ValidateToken", mock.Anything, "banned-token").Return("banned-user", nil)
+	mockAdminService := new(mocks.AdminService)
+	mockAdminService.On("IsUserBanned", mock.Anything, "banned-user").Return(true, nil)
+	mockShortener := new(mocks.Shortener)
+
+	shortenerHandler := handler.NewShortenerHandler(config.Config{BaseURL: "http://test.com"}, testLogger,
+		mockShortener, new(mocks.AuditService))
+	accountHandler := handler.NewAccountHandler(testLogger, new(mocks.AccountService), new(mocks.APIKeyService),
+		nil, mockAuthorizer)
+	adminHandler := handler.NewAdminHandler(testLogger, mockAdminService)
+	router := NewRouter(testLogger, mockAuthorizer, new(mocks.APIKeyService), nil, mockAdminService, nil,
+		*shortenerHandler, *accountHandler, *adminHandler, nil, nil, nil, middleware.BodyLimits{})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/shorten", bytes.NewBufferString(`{"url":"https://example.com"}`))
+	req.Header.Set("Authorization", "banned-token")
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	mockShortener.AssertNotCalled(t, "Shorten", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestNewRouter_BodyLimits(t *testing.T) {
+	testLogger, _ := logger.NewLogger("error")
+	mockAuthorizer := new(mocks.Authorizer)
+	mockAuthorizer.On("ValidateToken", mock.Anything, "user-token").Return("user-1", nil)
+	mockAdminService := new(mocks.AdminService)
+	mockAdminService.On("IsUserBanned", mock.Anything, "user-1").Return(false, nil)
+	mockShortener := new(mocks.Shortener)
+	mockShortener.On("GenerateShortURLPartBatch", mock.Anything, "user-1", "", mock.Anything).
+		Return([]model.ShortenBatchResponseItem{*model.NewShortenBatchResponseItem("1", "qwerty12")}, nil)
+
+	shortenerHandler := handler.NewShortenerHandler(config.Config{BaseURL: "http://test.com"}, testLogger,
+		mockShortener, nil)
+	accountHandler := handler.NewAccountHandler(testLogger, new(mocks.AccountService), new(mocks.APIKeyService),
+		nil, mockAuthorizer)
+	adminHandler := handler.NewAdminHandler(testLogger, mockAdminService)
+	router := NewRouter(testLogger, mockAuthorizer, new(mocks.APIKeyService), nil, mockAdminService, nil,
+		*shortenerHandler, *accountHandler, *adminHandler, nil, nil, nil,
+		middleware.BodyLimits{Default: 64, Batch: 1024})
+
+	longURL := "https://example.com/" + strings.Repeat("a", 100)
+	tests := []struct {
+		name         string
+		path         string
+		body         string
+		gzipMembers  []string
+		expectedCode int
+	}{
+		{
+			name:         "default limit",
+			path:         "/api/shorten",
+			body:         `{"url":"` + longURL + `"}`,
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:         "batch limit",
+			path:         "/api/shorten/batch",
+			body:         `[{"correlation_id":"1","original_url":"` + longURL + `"}]`,
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "over batch limit",
+			path:         "/api/shorten/batch",
+			body:         `[{"correlation_id":"1","original_url":"` + strings.Repeat(longURL, 10) + `"}]`,
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:         "compressed body within batch limit",
+			path:         "/api/shorten/batch",
+			gzipMembers:  []string{`[{"correlation_id":"1","original_url":"` + strings.Repeat(longURL, 10) + `"}]`},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "empty gzip members over default limit",
+			path:         "/api/shorten",
+			gzipMembers:  append([]string{`{"url":"https://example.com/"}`}, make([]string, 10)...),
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := bytes.NewBufferString(tt.body)
+			for _, member := range tt.gzipMembers {
+				gz := gzip.NewWriter(body)
+				gz.Write([]byte(member))
+				gz.Close()
+			}
+			req := httptest.NewRequest(http.MethodPost, tt.path, body)
+			if tt.gzipMembers != nil {
+				req.Header.Set("Content-Encoding", "gzip")
+			}
+			req.Header.Set("Authorization", "user-token")
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+		})
+	}
+}
+
+func TestNewRouter_InternalStats(t *testing.T) {
+	testLogger, _ := logger.NewLogger("error")
+	_, trustedSubnet, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+	mockShortener := new(mocks.Shortener)
+	mockShortener.On("GetStats", mock.Anything).Return(&model.Stats{URLs: 3, Users: 2}, nil)
+	mockAuthorizer := new(mocks.Authorizer)
+
+	shortenerHandler := handler.NewShortenerHandler(config.Config{BaseURL: "http://test.com"}, testLogger,
+		mockShortener, new(mocks.AuditService))
+	accountHandler := handler.NewAccountHandler(testLogger, new(mocks.AccountService), new(mocks.APIKeyService),
+		nil, mockAuthorizer)
+	adminHandler := handler.NewAdminHandler(testLogger, new(mocks.AdminService))
+	router := NewRouter(testLogger, mockAuthorizer, new(mocks.APIKeyService), nil, new(mocks.AdminService), nil,
+		*shortenerHandler, *accountHandler, *adminHandler, trustedSubnet, nil, nil, middleware.BodyLimits{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/internal/stats", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Real-IP", "10.1.2.3")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"urls":3,"users":2}`, rr.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/api/internal/stats", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Real-IP", "192.168.1.1")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	// The header is ignored on connections from outside the trusted subnet.
+	req = httptest.NewRequest(http.MethodGet, "/api/internal/stats", nil)
+	req.Header.Set("X-Real-IP", "10.1.2.3")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	mockShortener.AssertNumberOfCalls(t, "GetStats", 1)
+}
+
+func TestNewRouter_CSRF(t *testing.T) {
+	testLogger, _ := logger.NewLogger("error")
+	mockAuthorizer := new(mocks.Authorizer)
+	mockAuthorizer.On("ValidateToken", mock.Anything, "valid-token").Return("user-1", nil)
+	mockShortener := new(mocks.Shortener)
+	mockShortener.On("DeleteUserShortURLsBatch", mock.Anything, "user-1", "", []string{"abc123"}).Return(nil)
+	mockAdminService := new(mocks.AdminService)
+	mockAdminService.On("IsUserBanned", mock.Anything, mock.Anything).Return(false, nil).Maybe()
+
+	shortenerHandler := handler.NewShortenerHandler(config.Config{BaseURL: "http://test.com"}, testLogger,
+		mockShortener, new(mocks.AuditService))
+	accountHandler := handler.NewAccountHandler(testLogger, new(mocks.AccountService), new(mocks.APIKeyService),
+		nil, mockAuthorizer)
+	adminHandler := handler.NewAdminHandler(testLogger, mockAdminService)
+	router := NewRouter(testLogger, mockAuthorizer, new(mocks.APIKeyService), nil, mockAdminService, nil,
+		*shortenerHandler, *accountHandler, *adminHandler, nil, []string{"app.test.com"}, nil, middleware.BodyLimits{})
+
+	tests := []struct {
+		name         string
+		origin       string
+		useHeader    bool
+		expectedCode int
+	}{
+		{name: "cookie from same origin", origin: "http://example.com", expectedCode: http.StatusAccepted},
+		{name: "cookie from trusted host", origin: "https://app.test.com", expectedCode: http.StatusAccepted},
+		{name: "cookie from foreign origin", origin: "https://evil.com", expectedCode: http.StatusForbidden},
+		{name: "header from foreign origin", origin: "https://evil.com", useHeader: true,
+			expectedCode: http.StatusAccepted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/api/user/urls", bytes.NewBufferString(`["abc123"]`))
+			req.Header.Set("Origin", tt.origin)
+			if tt.useHeader {
+				req.Header.Set("Authorization", "valid-token")
+			} else {
+				req.AddCookie(&http.Cookie{Name: "user_token", Value: "valid-token"})
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+		})
+	}
+	mockShortener.AssertNumberOfCalls(t, "DeleteUserShortURLsBatch", 3)
+}
+
+func TestNewRouter_LoginAfterOIDC(t *testing.T) {
+	testLogger, _ := logger.NewLogger("error")
+	// A real password hash, so that the account service verifies the password itself
+	account, err := service.NewUserAccountService(repository.NewInMemoryRepository(), testLogger).
+		Register(context.Background(), "", "alice", "secret-password")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		prepare      func(req *http.Request)
+		claimedUser  string
+		expectMerged bool
+	}{
+		{
+			name:    "issuer id token",
+			prepare: func(req *http.Request) { req.Header.Set("Authorization", "Bearer id-token") },
+		},
+		{
+			name: "anonymous cookie",
+			prepare: func(req *http.Request) {
+				req.AddCookie(&http.Cookie{Name: "user_token", Value: "anonymous-token"})
+				req.Header.Set("Origin", "http://example.com")
+			},
+			claimedUser:  "anonymous-user",
+			expectMerged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthorizer := new(mocks.Authorizer)
+			mockAuthorizer.On("ValidateToken", mock.Anything, "anonymous-token").Return("anonymous-user", nil).Maybe()
+			mockAuthorizer.On("CreateToken", account.ID).Return("account-token", nil)
+			mockOIDC := new(mocks.OIDCService)
+			mockOIDC.On("IssuedBy", "id-token").Return(true).Maybe()
+			mockOIDC.On("IssuedBy", mock.Anything).Return(false).Maybe()
+			mockOIDC.On("ValidateIDToken", mock.Anything, "id-token").Return("oidc-user", nil).Maybe()
+			mockRepo := new(mocks.Repository)
+			mockRepo.On("GetUserByLogin", mock.Anything, "alice").Return(account, nil)
+			mockRepo.On("GetUserByID", mock.Anything, "anonymous-user").Return(nil, repository.ErrNotFound).Maybe()
+			mockRepo.On("MergeUserURLs", mock.Anything, "anonymous-user", account.ID).Return(nil).Maybe()
+			mockAdminService := new(mocks.AdminService)
+			mockAdminService.On("IsUserBanned", mock.Anything, mock.Anything).Return(false, nil).Maybe()
+
+			shortenerHandler := handler.NewShortenerHandler(config.Config{BaseURL: "http://test.com"}, testLogger,
+				new(mocks.Shortener), new(mocks.AuditService))
+			accountHandler := handler.NewAccountHandler(testLogger,
+				service.NewUserAccountService(mockRepo, testLogger), new(mocks.APIKeyService), mockOIDC, mockAuthorizer)
+			adminHandler := handler.NewAdminHandler(testLogger, mockAdminService)
+			router := NewRouter(testLogger, mockAuthorizer, new(mocks.APIKeyService), mockOIDC, mockAdminService, nil,
+				*shortenerHandler, *accountHandler, *adminHandler, nil, nil, nil, middleware.BodyLimits{})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/user/login",
+				bytes.NewBufferString(`{"login":"alice","password":"secret-password"}`))
+			tt.prepare(req)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.JSONEq(t, `{"user_id":"`+account.ID+`"}`, rr.Body.String())
+			if tt.expectMerged {
+				mockRepo.AssertCalled(t, "MergeUserURLs", mock.Anything, tt.claimedUser, account.ID)
+			} else {
+				mockRepo.AssertNotCalled(t, "GetUserByID", mock.Anything, mock.Anything)
+				mockRepo.AssertNotCalled(t, "MergeUserURLs", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestNewRouter_RateLimit(t *testing.T) {
+	testLogger, _ := logger.NewLogger("error")
+	mockAuthorizer := new(mocks.Authorizer)
+	mockAuthorizer.On("CreateToken", mock.Anything).Return("new-token", nil)
+	mockShortener := new(mocks.Shortener)
+	mockShortener.On("GetURLByShortURLPart", mock.Anything, "", "abc123").
+		Return(model.NewURL("abc123", "https://example.com"), nil)
+	mockShortener.On("GenerateShortURLPart", mock.Anything, mock.Anything, "", "https://example.com").
+		Return("xyz789", nil)
+	mockAudit := new(mocks.AuditService)
+	mockAudit.On("NotifyAll", mock.Anything).Return(nil)
+	mockAdminService := new(mocks.AdminService)
+	mockAdminService.On("IsUserBanned", mock.Anything, mock.Anything).Return(false, nil)
+
+	shortenerHandler := handler.NewShortenerHandler(config.Config{BaseURL: "http://test.com"}, testLogger,
+		mockShortener, mockAudit)
+	accountHandler := handler.NewAccountHandler(testLogger, new(mocks.AccountService), new(mocks.APIKeyService),
+		nil, mockAuthorizer)
+	adminHandler := handler.NewAdminHandler(testLogger, mockAdminService)
+	_, proxySubnet, _ := net.ParseCIDR("192.0.2.0/24")
+	rateLimiter := middleware.NewRateLimitMiddleware(middleware.NewInMemoryRateLimitStore(),
+		model.RateLimit{RequestsPerMinute: 1}, model.RateLimit{RequestsPerMinute: 1, Burst: 2}, proxySubnet, testLogger)
+	router := NewRouter(testLogger, mockAuthorizer, new(mocks.APIKeyService), nil, mockAdminService, nil,
+		*shortenerHandler, *accountHandler, *adminHandler, nil, nil, rateLimiter, middleware.BodyLimits{})
+
+	redirect := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+		req.Header.Set("X-Real-IP", ip)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	create := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString("https://example.com"))
+		req.Header.Set("X-Real-IP", ip)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	assert.Equal(t, http.StatusTemporaryRedirect, redirect("10.0.0.1").Code)
+	rr := redirect("10.0.0.1")
+	assert.Equal(t, http.StatusTemporaryRedirect, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get(middleware.RateLimitLimitHeader))
+	assert.Equal(t, "0", rr.Header().Get(middleware.RateLimitRemainingHeader))
+	rr = redirect("10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "60", rr.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusTemporaryRedirect, redirect("10.0.0.2").Code, "budgets are kept per client IP")
+
+	assert.Equal(t, http.StatusCreated, create("10.0.0.1").Code, "creating links has a separate budget")
+	rr = create("10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code, "new anonymous users share the budget of the client IP")
+	mockShortener.AssertNumberOfCalls(t, "GenerateShortURLPart", 1)
+}
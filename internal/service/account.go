@@ -0,0 +1,265 @@
+// Package service provides business logic for URL shortening service.
+//
+//go:generate mockery --name=AccountService --output=../mocks --case=underscore
+package service
+
+import (
+	"context"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+)
+
+const (
+	// minPasswordLength defines the minimal length of an account password.
+	minPasswordLength = 8
+	// passwordHashAlgorithm identifies the password hashing scheme stored in hashes.
+	passwordHashAlgorithm = "pbkdf2-sha256"
+	// passwordHashIterations defines the PBKDF2 iteration count for new hashes.
+	passwordHashIterations = 600000
+	// passwordSaltLength defines the length of random password salts in bytes.
+	passwordSaltLength = 16
+	// passwordKeyLength defines the length of derived password keys in bytes.
+	passwordKeyLength = 32
+)
+
+// ErrEmptyLogin is returned when an account login is empty.
+var ErrEmptyLogin = errors.New("login is empty")
+
+// ErrPasswordTooShort is returned when a registration password is shorter than minPasswordLength.
+var ErrPasswordTooShort = fmt.Errorf("password must be at least %d characters long", minPasswordLength)
+
+// ErrInvalidCredentials is returned when a login does not exist or the password does not match.
+var ErrInvalidCredentials = errors.New("invalid login or password")
+
+// dummyPasswordHash is verified on logins of unknown accounts, so that they take as long as wrong passwords
+// and response times do not reveal which logins exist.
+var dummyPasswordHash = strings.Join([]string{
+	passwordHashAlgorithm,
+	strconv.Itoa(passwordHashIterations),
+	base64.RawStdEncoding.EncodeToString(make([]byte, passwordSaltLength)),
+	base64.RawStdEncoding.EncodeToString(make([]byte, passwordKeyLength)),
+}, "$")
+
+// AccountService defines the interface for registered account operations.
+type AccountService interface {
+	// Register creates an account and claims links of the current anonymous user for it.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - currentUserID: identifier of the user of a shortener token, empty for other users
+	//   - login: unique login of the new account
+	//   - password: plain password of the new account
+	//
+	// Returns:
+	//   - *model.User: created account
+	//   - error: ErrEmptyLogin, ErrPasswordTooShort, repository.ErrLoginConflict or storage error
+	Register(ctx context.Context, currentUserID string, login string, password string) (*model.User, error)
+
+	// Login verifies credentials and claims links of the current anonymous user for the account.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - currentUserID: identifier of the user of a shortener token, empty for other users
+	//   - login: login of the account
+	//   - password: plain password of the account
+	//
+	// Returns:
+	//   - *model.User: signed in account
+	//   - error: ErrInvalidCredentials or storage error
+	Login(ctx context.Context, currentUserID string, login string, password string) (*model.User, error)
+}
+
+// UserAccountService implements AccountService on top of the repository user store.
+type UserAccountService struct {
+	storage repository.Repository
+	logger  *logger.Logger
+}
+
+// NewUserAccountService creates a new UserAccountService instance.
+//
+// Parameters:
+//   - storage: repository implementation storing accounts and URLs
+//   - logger: logger instance for application logging
+//
+// Returns:
+//   - *UserAccountService: initialized account service
+func NewUserAccountService(storage repository.Repository, logger *logger.Logger) *UserAccountService {
+	return &UserAccountService{
+		storage: storage,
+		logger:  logger,
+	}
+}
+
+// Register creates an account with a salted password hash.
+// Links of the current user are moved to the account unless the current user is a registered account.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - currentUserID: identifier of the user of a shortener token, empty for other users
+//   - login: unique login of the new account, surrounding spaces are ignored
+//   - password: plain password of the new account
+//
+// Returns:
+//   - *model.User: created account
+//   - error: ErrEmptyLogin, ErrPasswordTooShort, repository.ErrLoginConflict or storage error
+func (s *UserAccountService) Register(ctx context.Context,
+	currentUserID string,
+	login string,
+	password string,
+) (*model.User, error) {
+	login = strings.TrimSpace(login)
+	if login == "" {
+		return nil, ErrEmptyLogin
+	}
+	if len(password) < minPasswordLength {
+		return nil, ErrPasswordTooShort
+	}
+	passwordHash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	user := model.NewUser(uuid.NewString(), login, passwordHash)
+	if err = s.storage.SaveUser(ctx, *user); err != nil {
+		return nil, err
+	}
+	if err = s.claimAnonymousURLs(ctx, currentUserID, user.ID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Login verifies credentials of an account.
+// Unknown logins are checked against a dummy hash and fail in the same time as wrong passwords.
+// Links of the current user are moved to the account unless the current user is a registered account.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - currentUserID: identifier of the user of a shortener token, empty for other users
+//   - login: login of the account, surrounding spaces are ignored
+//   - password: plain password of the account
+//
+// Returns:
+//   - *model.User: signed in account
+//   - error: ErrInvalidCredentials or storage error
+func (s *UserAccountService) Login(ctx context.Context,
+	currentUserID string,
+	login string,
+	password string,
+) (*model.User, error) {
+	user, err := s.storage.GetUserByLogin(ctx, strings.TrimSpace(login))
+	if errors.Is(err, repository.ErrNotFound) {
+		verifyPassword(dummyPasswordHash, password)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !verifyPassword(user.PasswordHash, password) {
+		return nil, ErrInvalidCredentials
+	}
+	if err = s.claimAnonymousURLs(ctx, currentUserID, user.ID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// claimAnonymousURLs moves URLs of an anonymous user to an account.
+// Nothing is moved when there is no current user or it is a registered account itself.
+// Users of issuer ID tokens have no registered accounts either, so callers must not pass them as current users.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - currentUserID: identifier of the user making the request
+//   - accountID: identifier of the account receiving the URLs
+//
+// Returns:
+//   - error: storage error
+func (s *UserAccountService) claimAnonymousURLs(ctx context.Context, currentUserID string, accountID string) error {
+	if currentUserID == "" || currentUserID == accountID {
+		return nil
+	}
+	_, err := s.storage.GetUserByID(ctx, currentUserID)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	if err = s.storage.MergeUserURLs(ctx, currentUserID, accountID); err != nil {
+		return err
+	}
+	s.logger.Infoln("Anonymous user links claimed",
+		zap.String("anonymousUserID", currentUserID),
+		zap.String("userID", accountID))
+	return nil
+}
+
+// hashPassword derives a salted PBKDF2-SHA256 hash of the password.
+//
+// Parameters:
+//   - password: plain password
+//
+// Returns:
+//   - string: hash in "algorithm$iterations$salt$hash" format
+//   - error: error if salt generation or key derivation fails
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordHashIterations, passwordKeyLength)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join([]string{
+		passwordHashAlgorithm,
+		strconv.Itoa(passwordHashIterations),
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	}, "$"), nil
+}
+
+// verifyPassword checks the password against a hash produced by hashPassword.
+// The comparison of derived keys runs in constant time.
+//
+// Parameters:
+//   - passwordHash: stored hash in "algorithm$iterations$salt$hash" format
+//   - password: plain password to check
+//
+// Returns:
+//   - bool: true if the password matches the hash
+func verifyPassword(passwordHash string, password string) bool {
+	parts := strings.Split(passwordHash, "$")
+	if len(parts) != 4 || parts[0] != passwordHashAlgorithm {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil || len(expected) == 0 {
+		return false
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(expected))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(key, expected) == 1
+}
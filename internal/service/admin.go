@@ -0,0 +1,615 @@
+// Package service provides business logic for URL shortening service.
+//
+//go:generate mockery --name=AdminService --output=../mocks --case=underscore
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"net/url"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultAdminSearchLimit defines how many links a search returns without a limit.
+	DefaultAdminSearchLimit = 100
+	// maxAdminSearchLimit defines the maximal number of links a search returns.
+	maxAdminSearchLimit = 1000
+	// maxReportDetailsLength defines the maximal number of characters in the details of an abuse report.
+	maxReportDetailsLength = 1000
+)
+
+// ErrInvalidSearchLimit is returned when a search limit or offset is out of range.
+var ErrInvalidSearchLimit = errors.New("search limit must be between 1 and 1000 and offset must not be negative")
+
+// ErrEmptyUserID is returned when an admin action targets no user.
+var ErrEmptyUserID = errors.New("user id is empty")
+
+// ErrInvalidQuotaLimits is returned when assigned quota limits are negative.
+var ErrInvalidQuotaLimits = errors.New("quota limits must not be negative")
+
+// ErrInvalidReportReason is returned when an abuse report names an unknown reason.
+var ErrInvalidReportReason = errors.New("reason must be one of phishing, malware, spam, illegal or other")
+
+// ErrReportDetailsTooLong is returned when the details of an abuse report exceed maxReportDetailsLength.
+var ErrReportDetailsTooLong = errors.New("report details must not exceed 1000 characters")
+
+// ErrInvalidReportStatus is returned when abuse reports are listed by an unknown status.
+var ErrInvalidReportStatus = errors.New("status must be one of open, dismissed or actioned")
+
+// ErrInvalidTakedownReason is returned when a takedown names an unknown ground.
+var ErrInvalidTakedownReason = errors.New("takedown reason must be abuse or legal")
+
+// AdminService defines the interface for moderation of links and users by administrators.
+// Every action is audited with the administrator as the acting user.
+type AdminService interface {
+	// SearchURLs finds links of all users by short URL, destination or owner.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - adminID: identifier of the administrator
+	//   - filter: search criteria, zero limit for DefaultAdminSearchLimit
+	//
+	// Returns:
+	//   - []model.AdminURL: matching links
+	//   - error: ErrInvalidSearchLimit or storage error
+	SearchURLs(ctx context.Context, adminID string, filter model.URLSearchFilter) ([]model.AdminURL, error)
+
+	// SetURLDisabled disables a link or enables it again.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - adminID: identifier of the administrator
+	//   - domain: domain the short URL belongs to, empty for the base URL host
+	//   - shortURL: short URL identifier
+	//   - disabled: true to disable the link, false to enable it
+	//
+	// Returns:
+	//   - error: repository.ErrNotFound if the link does not exist, or storage error
+	SetURLDisabled(ctx context.Context, adminID string, domain string, shortURL string, disabled bool) error
+
+	// ClearURLReview clears a link flagged by phishing heuristics after the administrator checked it.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - adminID: identifier of the administrator
+	//   - domain: domain the short URL belongs to, empty for the base URL host
+	//   - shortURL: short URL identifier
+	//
+	// Returns:
+	//   - error: repository.ErrNotFound if the link does not exist, or storage error
+	ClearURLReview(ctx context.Context, adminID string, domain string, shortURL string) error
+
+	// SetUserBanned bans a user or lifts the ban.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - adminID: identifier of the administrator
+	//   - userID: identifier of the user
+	//   - banned: true to ban the user, false to lift the ban
+	//
+	// Returns:
+	//   - error: ErrEmptyUserID or storage error
+	SetUserBanned(ctx context.Context, adminID string, userID string, banned bool) error
+
+	// GetUserUsage counts links and API keys of a user.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - adminID: identifier of the administrator
+	//   - userID: identifier of the user
+	//
+	// Returns:
+	//   - *model.UserUsage: usage of the user
+	//   - error: ErrEmptyUserID or storage error
+	GetUserUsage(ctx context.Context, adminID string, userID string) (*model.UserUsage, error)
+
+	// RevokeUserTokens revokes all tokens of a user issued so far.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - adminID: identifier of the administrator
+	//   - userID: identifier of the user
+	//
+	// Returns:
+	//   - error: ErrEmptyUserID, ErrRevocationDisabled or storage error
+	RevokeUserTokens(ctx context.Context, adminID string, userID string) error
+
+	// SetUserQuotaLimits assigns limits to a single user, replacing the default limits.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - adminID: identifier of the administrator
+	//   - userID: identifier of the user
+	//   - limits: limits of the user, zero limits are unlimited
+	//
+	// Returns:
+	//   - error: ErrEmptyUserID, ErrInvalidQuotaLimits or storage error
+	SetUserQuotaLimits(ctx context.Context, adminID string, userID string, limits model.QuotaLimits) error
+
+	// ReportURL adds a report of an abusive link to the moderation queue. Open to all visitors,
+	// audited with the reporter as the acting user.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - reporterID: identifier of the reporting user, empty for anonymous reporters
+	//   - domain: domain the short URL belongs to, empty for the base URL host
+	//   - shortURL: short URL identifier
+	//   - request: reason and details of the report
+	//
+	// Returns:
+	//   - *model.AbuseReport: queued report
+	//   - error: ErrInvalidReportReason, ErrReportDetailsTooLong, repository.ErrNotFound or storage error
+	ReportURL(ctx context.Context, reporterID string, domain string, shortURL string,
+		request model.AbuseReportRequest) (*model.AbuseReport, error)
+
+	// GetAbuseReports lists abuse reports in a state, oldest first.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - adminID: identifier of the administrator
+	//   - filter: state of the reports, empty for open reports, and zero limit for DefaultAdminSearchLimit
+	//
+	// Returns:
+	//   - []model.AbuseReport: matching reports
+	//   - error: ErrInvalidReportStatus, ErrInvalidSearchLimit or storage error
+	GetAbuseReports(ctx context.Context, adminID string, filter model.AbuseReportFilter) ([]model.AbuseReport, error)
+
+	// DismissAbuseReport resolves an open abuse report without acting on the link.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - adminID: identifier of the administrator
+	//   - reportID: identifier of the report
+	//
+	// Returns:
+	//   - error: repository.ErrNotFound if no open report has the identifier, or storage error
+	DismissAbuseReport(ctx context.Context, adminID string, reportID string) error
+
+	// TakeDownReportedURL disables the link of an abuse report and resolves all its open reports.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - adminID: identifier of the administrator
+	//   - reportID: identifier of the report
+	//   - reason: ground for the takedown, selecting 410 or 451 for the link
+	//
+	// Returns:
+	//   - error: ErrInvalidTakedownReason, repository.ErrNotFound or storage error
+	TakeDownReportedURL(ctx context.Context, adminID string, reportID string, reason model.TakedownReason) error
+
+	// IsUserBanned reports whether a user is banned. Not audited, used to reject writes of banned users.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the user
+	//
+	// Returns:
+	//   - bool: true if the user is banned
+	//   - error: storage error
+	IsUserBanned(ctx context.Context, userID string) (bool, error)
+}
+
+// ShortenerAdminService implements AdminService on top of the repository.
+type ShortenerAdminService struct {
+	storage      repository.Repository
+	authorizer   Authorizer
+	auditService AuditService
+	logger       *logger.Logger
+}
+
+// NewShortenerAdminService creates a new ShortenerAdminService instance.
+//
+// Parameters:
+//   - storage: repository implementation for links and bans
+//   - authorizer: authorizer revoking tokens of users
+//   - auditService: audit service recording admin actions
+//   - logger: logger instance for admin actions
+//
+// Returns:
+//   - *ShortenerAdminService: initialized admin service
+func NewShortenerAdminService(storage repository.Repository,
+	authorizer Authorizer,
+	auditService AuditService,
+	logger *logger.Logger,
+) *ShortenerAdminService {
+	return &ShortenerAdminService{
+		storage:      storage,
+		authorizer:   authorizer,
+		auditService: auditService,
+		logger:       logger,
+	}
+}
+
+// SearchURLs finds links of all users by short URL, destination or owner.
+// The search criteria are recorded as the audit target.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - adminID: identifier of the administrator
+//   - filter: search criteria, zero limit for DefaultAdminSearchLimit
+//
+// Returns:
+//   - []model.AdminURL: matching links
+//   - error: ErrInvalidSearchLimit or storage error
+func (s *ShortenerAdminService) SearchURLs(ctx context.Context,
+	adminID string,
+	filter model.URLSearchFilter,
+) ([]model.AdminURL, error) {
+	if filter.Limit == 0 {
+		filter.Limit = DefaultAdminSearchLimit
+	}
+	if filter.Limit < 0 || filter.Limit > maxAdminSearchLimit || filter.Offset < 0 {
+		return nil, ErrInvalidSearchLimit
+	}
+	urls, err := s.storage.SearchURLs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"code":        filter.ShortURL,
+		"destination": filter.OriginalURL,
+		"owner":       filter.UserID,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	if filter.UnderReview {
+		query.Set("under_review", "true")
+	}
+	s.audit(model.ActionAdminSearch, adminID, query.Encode())
+	return urls, nil
+}
+
+// SetURLDisabled disables a link or enables it again.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - adminID: identifier of the administrator
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - shortURL: short URL identifier
+//   - disabled: true to disable the link, false to enable it
+//
+// Returns:
+//   - error: repository.ErrNotFound if the link does not exist, or storage error
+func (s *ShortenerAdminService) SetURLDisabled(ctx context.Context,
+	adminID string,
+	domain string,
+	shortURL string,
+	disabled bool,
+) error {
+	if err := s.storage.SetURLDisabled(ctx, domain, shortURL, disabled); err != nil {
+		return err
+	}
+
+	target := linkTarget(domain, shortURL)
+	action := model.ActionAdminEnable
+	if disabled {
+		action = model.ActionAdminDisable
+	}
+	s.logger.Infoln("Link moderated", zap.String("adminID", adminID),
+		zap.String("action", string(action)), zap.String("target", target))
+	s.audit(action, adminID, target)
+	return nil
+}
+
+// ClearURLReview clears a link flagged by phishing heuristics after the administrator checked it.
+// The link redirects directly again instead of showing the warning page.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - adminID: identifier of the administrator
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - shortURL: short URL identifier
+//
+// Returns:
+//   - error: repository.ErrNotFound if the link does not exist, or storage error
+func (s *ShortenerAdminService) ClearURLReview(ctx context.Context,
+	adminID string,
+	domain string,
+	shortURL string,
+) error {
+	if err := s.storage.ClearURLReview(ctx, domain, shortURL); err != nil {
+		return err
+	}
+
+	target := linkTarget(domain, shortURL)
+	s.logger.Infoln("Link moderated", zap.String("adminID", adminID),
+		zap.String("action", string(model.ActionAdminClearReview)), zap.String("target", target))
+	s.audit(model.ActionAdminClearReview, adminID, target)
+	return nil
+}
+
+// ReportURL adds a report of an abusive link to the moderation queue.
+// The report is audited with the reporter as the acting user.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - reporterID: identifier of the reporting user, empty for anonymous reporters
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - shortURL: short URL identifier
+//   - request: reason and details of the report
+//
+// Returns:
+//   - *model.AbuseReport: queued report
+//   - error: ErrInvalidReportReason, ErrReportDetailsTooLong, repository.ErrNotFound or storage error
+func (s *ShortenerAdminService) ReportURL(ctx context.Context,
+	reporterID string,
+	domain string,
+	shortURL string,
+	request model.AbuseReportRequest,
+) (*model.AbuseReport, error) {
+	if !request.Reason.IsValid() {
+		return nil, ErrInvalidReportReason
+	}
+	if utf8.RuneCountInString(request.Details) > maxReportDetailsLength {
+		return nil, ErrReportDetailsTooLong
+	}
+
+	report := model.AbuseReport{
+		ID:         uuid.NewString(),
+		Domain:     domain,
+		ShortURL:   shortURL,
+		Reason:     request.Reason,
+		Details:    request.Details,
+		ReporterID: reporterID,
+		Status:     model.ReportOpen,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err := s.storage.SaveAbuseReport(ctx, report); err != nil {
+		return nil, err
+	}
+
+	target := linkTarget(domain, shortURL)
+	s.logger.Infoln("Link reported", zap.String("reporterID", reporterID),
+		zap.String("reason", string(report.Reason)), zap.String("target", target))
+	s.audit(model.ActionReport, reporterID, target)
+	return &report, nil
+}
+
+// GetAbuseReports lists abuse reports in a state, oldest first.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - adminID: identifier of the administrator
+//   - filter: state of the reports, empty for open reports, and zero limit for DefaultAdminSearchLimit
+//
+// Returns:
+//   - []model.AbuseReport: matching reports
+//   - error: ErrInvalidReportStatus, ErrInvalidSearchLimit or storage error
+func (s *ShortenerAdminService) GetAbuseReports(ctx context.Context,
+	adminID string,
+	filter model.AbuseReportFilter,
+) ([]model.AbuseReport, error) {
+	switch filter.Status {
+	case "":
+		filter.Status = model.ReportOpen
+	case model.ReportOpen, model.ReportDismissed, model.ReportActioned:
+	default:
+		return nil, ErrInvalidReportStatus
+	}
+	if filter.Limit == 0 {
+		filter.Limit = DefaultAdminSearchLimit
+	}
+	if filter.Limit < 0 || filter.Limit > maxAdminSearchLimit || filter.Offset < 0 {
+		return nil, ErrInvalidSearchLimit
+	}
+
+	reports, err := s.storage.GetAbuseReports(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	s.audit(model.ActionAdminSearch, adminID, "reports?status="+string(filter.Status))
+	return reports, nil
+}
+
+// DismissAbuseReport resolves an open abuse report without acting on the link.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - adminID: identifier of the administrator
+//   - reportID: identifier of the report
+//
+// Returns:
+//   - error: repository.ErrNotFound if no open report has the identifier, or storage error
+func (s *ShortenerAdminService) DismissAbuseReport(ctx context.Context, adminID string, reportID string) error {
+	if err := s.storage.DismissAbuseReport(ctx, reportID, adminID, time.Now().UTC()); err != nil {
+		return err
+	}
+	s.logger.Infoln("Report moderated", zap.String("adminID", adminID),
+		zap.String("action", string(model.ActionAdminDismissReport)), zap.String("reportID", reportID))
+	s.audit(model.ActionAdminDismissReport, adminID, reportID)
+	return nil
+}
+
+// TakeDownReportedURL disables the link of an abuse report and resolves all its open reports.
+// The link then answers with a takedown page instead of redirecting.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - adminID: identifier of the administrator
+//   - reportID: identifier of the report
+//   - reason: ground for the takedown, selecting 410 or 451 for the link
+//
+// Returns:
+//   - error: ErrInvalidTakedownReason, repository.ErrNotFound or storage error
+func (s *ShortenerAdminService) TakeDownReportedURL(ctx context.Context,
+	adminID string,
+	reportID string,
+	reason model.TakedownReason,
+) error {
+	if !reason.IsValid() {
+		return ErrInvalidTakedownReason
+	}
+	report, err := s.storage.GetAbuseReport(ctx, reportID)
+	if err != nil {
+		return err
+	}
+	err = s.storage.TakeDownURL(ctx, report.Domain, report.ShortURL, reason, adminID, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	target := linkTarget(report.Domain, report.ShortURL)
+	s.logger.Infoln("Link moderated", zap.String("adminID", adminID),
+		zap.String("action", string(model.ActionAdminTakedown)), zap.String("target", target),
+		zap.String("reason", string(reason)), zap.String("reportID", reportID))
+	s.audit(model.ActionAdminTakedown, adminID, target)
+	return nil
+}
+
+// SetUserBanned bans a user or lifts the ban.
+// Links of banned users stop resolving and their writes are rejected.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - adminID: identifier of the administrator
+//   - userID: identifier of the user
+//   - banned: true to ban the user, false to lift the ban
+//
+// Returns:
+//   - error: ErrEmptyUserID or storage error
+func (s *ShortenerAdminService) SetUserBanned(ctx context.Context, adminID string, userID string, banned bool) error {
+	if userID == "" {
+		return ErrEmptyUserID
+	}
+	if err := s.storage.SetUserBanned(ctx, userID, banned); err != nil {
+		return err
+	}
+
+	action := model.ActionAdminUnban
+	if banned {
+		action = model.ActionAdminBan
+	}
+	s.logger.Infoln("User moderated", zap.String("adminID", adminID),
+		zap.String("action", string(action)), zap.String("userID", userID))
+	s.audit(action, adminID, userID)
+	return nil
+}
+
+// GetUserUsage counts links and API keys of a user.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - adminID: identifier of the administrator
+//   - userID: identifier of the user
+//
+// Returns:
+//   - *model.UserUsage: usage of the user
+//   - error: ErrEmptyUserID or storage error
+func (s *ShortenerAdminService) GetUserUsage(ctx context.Context,
+	adminID string,
+	userID string,
+) (*model.UserUsage, error) {
+	if userID == "" {
+		return nil, ErrEmptyUserID
+	}
+	usage, err := s.storage.GetUserUsage(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.audit(model.ActionAdminUsage, adminID, userID)
+	return usage, nil
+}
+
+// RevokeUserTokens revokes all tokens of a user issued so far.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - adminID: identifier of the administrator
+//   - userID: identifier of the user
+//
+// Returns:
+//   - error: ErrEmptyUserID, ErrRevocationDisabled or storage error
+func (s *ShortenerAdminService) RevokeUserTokens(ctx context.Context, adminID string, userID string) error {
+	if userID == "" {
+		return ErrEmptyUserID
+	}
+	if err := s.authorizer.RevokeUserTokens(ctx, userID); err != nil {
+		return err
+	}
+	s.audit(model.ActionAdminRevokeTokens, adminID, userID)
+	return nil
+}
+
+// SetUserQuotaLimits assigns limits to a single user, replacing the default limits.
+// Links the user already created are kept even above the new limits.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - adminID: identifier of the administrator
+//   - userID: identifier of the user
+//   - limits: limits of the user, zero limits are unlimited
+//
+// Returns:
+//   - error: ErrEmptyUserID, ErrInvalidQuotaLimits or storage error
+func (s *ShortenerAdminService) SetUserQuotaLimits(ctx context.Context,
+	adminID string,
+	userID string,
+	limits model.QuotaLimits,
+) error {
+	if userID == "" {
+		return ErrEmptyUserID
+	}
+	if limits.LinksPerDay < 0 || limits.ActiveLinks < 0 || limits.BatchSize < 0 || limits.DeleteBatchSize < 0 {
+		return ErrInvalidQuotaLimits
+	}
+	if err := s.storage.SaveUserQuotaLimits(ctx, userID, limits); err != nil {
+		return err
+	}
+	s.logger.Infoln("User quota changed", zap.String("adminID", adminID), zap.String("userID", userID))
+	s.audit(model.ActionAdminQuota, adminID, userID)
+	return nil
+}
+
+// IsUserBanned reports whether a user is banned.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//
+// Returns:
+//   - bool: true if the user is banned
+//   - error: storage error
+func (s *ShortenerAdminService) IsUserBanned(ctx context.Context, userID string) (bool, error) {
+	return s.storage.IsUserBanned(ctx, userID)
+}
+
+// linkTarget formats a link as the target of audit events.
+//
+// Parameters:
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - shortURL: short URL identifier
+//
+// Returns:
+//   - string: short URL, prefixed by the domain if set
+func linkTarget(domain string, shortURL string) string {
+	if domain != "" {
+		return domain + "/" + shortURL
+	}
+	return shortURL
+}
+
+// audit sends an admin action to the audit observers.
+//
+// Parameters:
+//   - action: admin action performed
+//   - adminID: identifier of the administrator
+//   - target: link, user or search the administrator acted on
+func (s *ShortenerAdminService) audit(action model.AuditAction, adminID string, target string) {
+	if s.auditService == nil {
+		return
+	}
+	event := model.NewAuditEvent(time.Now().Unix(), action, adminID, "")
+	event.Target = target
+	s.auditService.NotifyAll(*event)
+}
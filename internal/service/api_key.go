@@ -0,0 +1,350 @@
+// Package service provides business logic for URL shortening service.
+//
+//go:generate mockery --name=APIKeyService --output=../mocks --case=underscore
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// apiKeyPrefix starts every API key, which makes leaked keys easy to recognize.
+	apiKeyPrefix = "shk_"
+	// apiKeySecretLength defines the length of the random part of API keys in bytes.
+	apiKeySecretLength = 32
+	// apiKeyDisplayPrefixLength defines how many leading characters of a key are stored for display.
+	apiKeyDisplayPrefixLength = 8
+	// DefaultAPIKeyRateLimit defines the requests per minute limit of keys created without a limit.
+	DefaultAPIKeyRateLimit = 60
+	// maxAPIKeyRateLimit defines the maximal requests per minute limit of a key.
+	maxAPIKeyRateLimit = 6000
+	// apiKeyRateWindow defines the window API key rate limits are counted in.
+	apiKeyRateWindow = time.Minute
+	// apiKeyLastUsedInterval defines how often the last used time of a key is written to storage.
+	apiKeyLastUsedInterval = time.Minute
+)
+
+// ErrEmptyAPIKeyName is returned when an API key is created without a name.
+var ErrEmptyAPIKeyName = errors.New("api key name is empty")
+
+// ErrInvalidAPIKeyScope is returned when API key scopes are missing or unknown.
+var ErrInvalidAPIKeyScope = errors.New("api key scopes must be read, write or delete")
+
+// ErrInvalidAPIKeyRateLimit is returned when an API key rate limit is out of range.
+var ErrInvalidAPIKeyRateLimit = fmt.Errorf("api key rate limit must be between 1 and %d", maxAPIKeyRateLimit)
+
+// ErrInvalidAPIKey is returned when an API key does not exist or was revoked.
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// APIKeyRateLimitError is returned when an API key exceeds its rate limit.
+type APIKeyRateLimitError struct {
+	// RetryAfter is the time until the key may be used again.
+	RetryAfter time.Duration
+}
+
+// Error returns the string representation of the rate limit error.
+// Implements the error interface.
+//
+// Returns:
+//   - string: error message
+func (err *APIKeyRateLimitError) Error() string {
+	return "api key rate limit exceeded"
+}
+
+// APIKeyService defines the interface for API key management and authentication.
+type APIKeyService interface {
+	// CreateAPIKey creates an API key for a user.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the user the key acts as
+	//   - name: label of the key
+	//   - scopes: operation classes the key is allowed to perform
+	//   - rateLimit: requests per minute limit, zero for the default limit
+	//
+	// Returns:
+	//   - *model.APIKey: created key
+	//   - string: secret key, available only on creation
+	//   - error: ErrEmptyAPIKeyName, ErrInvalidAPIKeyScope, ErrInvalidAPIKeyRateLimit or storage error
+	CreateAPIKey(ctx context.Context,
+		userID string,
+		name string,
+		scopes []model.APIKeyScope,
+		rateLimit int,
+	) (*model.APIKey, string, error)
+
+	// GetUserAPIKeys retrieves all API keys of a user.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the user
+	//
+	// Returns:
+	//   - []model.APIKey: keys of the user without secrets
+	//   - error: storage error
+	GetUserAPIKeys(ctx context.Context, userID string) ([]model.APIKey, error)
+
+	// RevokeAPIKey revokes an API key of a user.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the user owning the key
+	//   - keyID: identifier of the key
+	//
+	// Returns:
+	//   - error: repository.ErrNotFound if the user has no such key, or storage error
+	RevokeAPIKey(ctx context.Context, userID string, keyID string) error
+
+	// AuthenticateAPIKey resolves a secret key, applies its rate limit and records its use.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - secret: secret key sent by the client
+	//
+	// Returns:
+	//   - *model.APIKey: authenticated key
+	//   - error: ErrInvalidAPIKey, *APIKeyRateLimitError or storage error
+	AuthenticateAPIKey(ctx context.Context, secret string) (*model.APIKey, error)
+}
+
+// apiKeyWindow counts requests of a key in the current rate limit window.
+type apiKeyWindow struct {
+	start time.Time
+	count int
+}
+
+// UserAPIKeyService implements APIKeyService on top of the repository key store.
+// Rate limits are counted in fixed one minute windows per key in process memory.
+type UserAPIKeyService struct {
+	storage repository.Repository
+	logger  *logger.Logger
+	mu      sync.Mutex
+	windows map[string]*apiKeyWindow
+}
+
+// NewUserAPIKeyService creates a new UserAPIKeyService instance.
+//
+// Parameters:
+//   - storage: repository implementation storing API keys
+//   - logger: logger instance for application logging
+//
+// Returns:
+//   - *UserAPIKeyService: initialized API key service
+func NewUserAPIKeyService(storage repository.Repository, logger *logger.Logger) *UserAPIKeyService {
+	return &UserAPIKeyService{
+		storage: storage,
+		logger:  logger,
+		windows: make(map[string]*apiKeyWindow),
+	}
+}
+
+// CreateAPIKey creates an API key for a user.
+// Only the hash of the generated key is stored.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user the key acts as
+//   - name: label of the key, surrounding spaces are ignored
+//   - scopes: operation classes the key is allowed to perform, duplicates are ignored
+//   - rateLimit: requests per minute limit, zero for DefaultAPIKeyRateLimit
+//
+// Returns:
+//   - *model.APIKey: created key
+//   - string: secret key, available only on creation
+//   - error: ErrEmptyAPIKeyName, ErrInvalidAPIKeyScope, ErrInvalidAPIKeyRateLimit or storage error
+func (s *UserAPIKeyService) CreateAPIKey(ctx context.Context,
+	userID string,
+	name string,
+	scopes []model.APIKeyScope,
+	rateLimit int,
+) (*model.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", ErrEmptyAPIKeyName
+	}
+	uniqueScopes, err := normalizeAPIKeyScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	if rateLimit == 0 {
+		rateLimit = DefaultAPIKeyRateLimit
+	}
+	if rateLimit < 0 || rateLimit > maxAPIKeyRateLimit {
+		return nil, "", ErrInvalidAPIKeyRateLimit
+	}
+
+	secret, err := generateAPIKeySecret()
+	if err != nil {
+		return nil, "", err
+	}
+	key := model.APIKey{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    secret[:apiKeyDisplayPrefixLength],
+		KeyHash:   hashAPIKey(secret),
+		Scopes:    uniqueScopes,
+		RateLimit: rateLimit,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err = s.storage.SaveAPIKey(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return &key, secret, nil
+}
+
+// GetUserAPIKeys retrieves all API keys of a user.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//
+// Returns:
+//   - []model.APIKey: keys of the user without secrets
+//   - error: storage error
+func (s *UserAPIKeyService) GetUserAPIKeys(ctx context.Context, userID string) ([]model.APIKey, error) {
+	return s.storage.GetAPIKeysByUserID(ctx, userID)
+}
+
+// RevokeAPIKey revokes an API key of a user by removing it from storage.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user owning the key
+//   - keyID: identifier of the key
+//
+// Returns:
+//   - error: repository.ErrNotFound if the user has no such key, or storage error
+func (s *UserAPIKeyService) RevokeAPIKey(ctx context.Context, userID string, keyID string) error {
+	if err := s.storage.DeleteAPIKey(ctx, userID, keyID); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	delete(s.windows, keyID)
+	s.mu.Unlock()
+	return nil
+}
+
+// AuthenticateAPIKey resolves a secret key, applies its rate limit and records its use.
+// The last used time is written at most once per minute, failures to write it are only logged.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - secret: secret key sent by the client
+//
+// Returns:
+//   - *model.APIKey: authenticated key
+//   - error: ErrInvalidAPIKey, *APIKeyRateLimitError or storage error
+func (s *UserAPIKeyService) AuthenticateAPIKey(ctx context.Context, secret string) (*model.APIKey, error) {
+	if !strings.HasPrefix(secret, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	key, err := s.storage.GetAPIKeyByHash(ctx, hashAPIKey(secret))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if err = s.allow(key, now); err != nil {
+		return nil, err
+	}
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyLastUsedInterval {
+		if err = s.storage.UpdateAPIKeyLastUsed(ctx, key.ID, now); err != nil {
+			s.logger.Error("Failed to update api key last used time", zap.Error(err), zap.String("keyID", key.ID))
+		}
+		key.LastUsedAt = &now
+	}
+	return key, nil
+}
+
+// allow counts a request of the key in its current rate limit window.
+//
+// Parameters:
+//   - key: key making the request
+//   - now: time of the request
+//
+// Returns:
+//   - error: *APIKeyRateLimitError if the key exhausted its limit in the window
+func (s *UserAPIKeyService) allow(key *model.APIKey, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	window, exists := s.windows[key.ID]
+	if !exists || now.Sub(window.start) >= apiKeyRateWindow {
+		window = &apiKeyWindow{start: now}
+		s.windows[key.ID] = window
+	}
+	if window.count >= key.RateLimit {
+		return &APIKeyRateLimitError{RetryAfter: window.start.Add(apiKeyRateWindow).Sub(now)}
+	}
+	window.count++
+	return nil
+}
+
+// normalizeAPIKeyScopes validates scopes and removes duplicates keeping the order.
+//
+// Parameters:
+//   - scopes: requested scopes
+//
+// Returns:
+//   - []model.APIKeyScope: unique scopes
+//   - error: ErrInvalidAPIKeyScope if there are no scopes or one is unknown
+func normalizeAPIKeyScopes(scopes []model.APIKeyScope) ([]model.APIKeyScope, error) {
+	if len(scopes) == 0 {
+		return nil, ErrInvalidAPIKeyScope
+	}
+	var unique []model.APIKeyScope
+	seen := make(map[model.APIKeyScope]bool)
+	for _, scope := range scopes {
+		if !scope.Valid() {
+			return nil, ErrInvalidAPIKeyScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+	return unique, nil
+}
+
+// generateAPIKeySecret creates a random secret key with the API key prefix.
+//
+// Returns:
+//   - string: secret key
+//   - error: error if random generation fails
+func generateAPIKeySecret() (string, error) {
+	secret := make([]byte, apiKeySecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// hashAPIKey returns the hex-encoded SHA-256 digest of a secret key.
+// Keys are long random strings, so a fast unsalted hash is enough to protect them at rest.
+//
+// Parameters:
+//   - secret: secret key
+//
+// Returns:
+//   - string: 64-character hex digest
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -4,19 +4,37 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/repository"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"sync"
 	"time"
 )
 
+const (
+	// tokenLifetime defines how long issued tokens stay valid.
+	tokenLifetime = 30 * 24 * time.Hour
+	// revocationPurgeInterval defines how often expired token revocations are removed from storage.
+	revocationPurgeInterval = time.Hour
+
+	// RoleAdmin is the role claim of tokens issued to administrators.
+	RoleAdmin = "admin"
+)
+
 // ErrSign is returned when JWT token creation fails.
 var ErrSign = errors.New("failed to generate token")
 
 // ErrValidate is returned when JWT token validation fails.
 var ErrValidate = errors.New("failed to validate token")
 
+// ErrRevocationDisabled is returned when tokens are revoked without a revocation store.
+var ErrRevocationDisabled = errors.New("token revocation is not enabled")
+
 // Authorizer defines the interface for JWT token creation and validation.
 type Authorizer interface {
 	// CreateToken generates a JWT token for the given user ID.
@@ -32,23 +50,59 @@ type Authorizer interface {
 	// ValidateToken verifies a JWT token and extracts the user ID.
 	//
 	// Parameters:
+	//   - ctx: context of the request, cancels the revocation lookup
 	//   - tokenString: JWT token string to validate
 	//
 	// Returns:
 	//   - string: User ID extracted from the token
 	//   - error: Error if token validation fails
-	ValidateToken(tokenString string) (string, error)
+	ValidateToken(ctx context.Context, tokenString string) (string, error)
+
+	// RevokeToken revokes a single token until it expires.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - tokenString: valid JWT token string to revoke
+	//
+	// Returns:
+	//   - error: ErrValidate if the token is invalid, or storage error
+	RevokeToken(ctx context.Context, tokenString string) error
+
+	// RevokeUserTokens revokes all tokens of a user issued so far.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the user
+	//
+	// Returns:
+	//   - error: storage error
+	RevokeUserTokens(ctx context.Context, userID string) error
+
+	// JWKS returns the public keys other services can verify tokens with.
+	//
+	// Returns:
+	//   - model.JWKS: public verification keys, empty for HMAC only signing
+	JWKS() model.JWKS
 }
 
-// JWTAuthorizer implements Authorizer using JWT tokens with HMAC signing.
+// JWTAuthorizer implements Authorizer using JWT tokens.
+// Tokens are signed with the active key of the key set and carry its key ID in the kid header.
+// Without a key set, and for tokens without kid, the HMAC secret key is used.
+// With revocation enabled, tokens are checked against revocations kept in storage.
 type JWTAuthorizer struct {
-	secretKey []byte
-	logger    *logger.Logger
+	secretKey   []byte
+	keySet      *KeySet
+	revocations repository.Repository
+	lastPurge   time.Time
+	purgeMu     sync.Mutex
+	adminIDs    map[string]struct{}
+	logger      *logger.Logger
 }
 
 // ShortenerClaims defines the JWT claims structure for URL shortening service.
 type ShortenerClaims struct {
 	UserID string `json:"userID"`
+	Role   string `json:"role,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -67,8 +121,49 @@ func NewAuthorizer(secretKey []byte, logger *logger.Logger) *JWTAuthorizer {
 	}
 }
 
+// NewKeySetAuthorizer creates a new JWTAuthorizer instance signing tokens with a key set.
+// Tokens without kid, issued before the key set was configured, are verified with the secret key.
+//
+// Parameters:
+//   - keySet: RS256 and EdDSA keys for signing and verifying tokens
+//   - secretKey: HMAC secret key for verifying tokens without kid, empty to reject them
+//   - logger: Logger instance for error logging
+//
+// Returns:
+//   - *JWTAuthorizer: Initialized JWT authorizer
+func NewKeySetAuthorizer(keySet *KeySet, secretKey []byte, logger *logger.Logger) *JWTAuthorizer {
+	return &JWTAuthorizer{
+		secretKey: secretKey,
+		keySet:    keySet,
+		logger:    logger,
+	}
+}
+
+// EnableRevocation makes the authorizer check and store token revocations in storage.
+// Without it, tokens stay valid until they expire.
+//
+// Parameters:
+//   - storage: repository implementation storing token revocations
+func (a *JWTAuthorizer) EnableRevocation(storage repository.Repository) {
+	a.revocations = storage
+}
+
+// SetAdminUsers makes the authorizer issue tokens with the admin role claim to the given users.
+// Tokens issued earlier keep their role until they expire or are revoked.
+//
+// Parameters:
+//   - userIDs: IDs of administrators
+func (a *JWTAuthorizer) SetAdminUsers(userIDs []string) {
+	a.adminIDs = make(map[string]struct{}, len(userIDs))
+	for _, id := range userIDs {
+		a.adminIDs[id] = struct{}{}
+	}
+}
+
 // CreateToken generates a JWT token for the given user ID with 30-day expiration.
-// Uses HS256 signing method for token security.
+// Every token gets a unique jti claim so that it can be revoked.
+// Tokens of administrators get the admin role claim.
+// Uses the active key of the key set, or HS256 signing with the secret key without a key set.
 //
 // Parameters:
 //   - userID: User identifier to include in the token
@@ -80,15 +175,27 @@ func (a *JWTAuthorizer) CreateToken(userID string) (string, error) {
 	claims := ShortenerClaims{
 		UserID: userID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(30 * 24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(tokenLifetime)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "url-shortener",
+			ID:        uuid.NewString(),
 		},
 	}
+	if _, ok := a.adminIDs[userID]; ok {
+		claims.Role = RoleAdmin
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(a.secretKey)
+	var tokenString string
+	var err error
+	if a.keySet != nil {
+		key := a.keySet.Active()
+		token := jwt.NewWithClaims(key.Method, claims)
+		token.Header["kid"] = key.ID
+		tokenString, err = token.SignedString(key.PrivateKey)
+	} else {
+		tokenString, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(a.secretKey)
+	}
 	if err != nil {
 		a.logger.Error("Failed to sign token", zap.Error(err))
 		return "", ErrSign
@@ -98,32 +205,229 @@ func (a *JWTAuthorizer) CreateToken(userID string) (string, error) {
 }
 
 // ValidateToken verifies a JWT token's signature and extracts the user ID.
-// Returns the user ID if the token is valid and properly signed.
+// Returns the user ID if the token is valid, properly signed and not revoked.
+// Tokens are rejected when their revocation status cannot be checked.
 //
 // Parameters:
+//   - ctx: context of the request, cancels the revocation lookup
 //   - tokenString: JWT token string to validate
 //
 // Returns:
 //   - string: User ID extracted from the token
-//   - error: Error if token is invalid, expired, or signature verification fails
-func (a *JWTAuthorizer) ValidateToken(tokenString string) (string, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &ShortenerClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			a.logger.Error("Unexpected signing method")
-			return nil, ErrValidate
+//   - error: Error if token is invalid, expired, revoked, or signature verification fails
+func (a *JWTAuthorizer) ValidateToken(ctx context.Context, tokenString string) (string, error) {
+	claims, err := a.parseToken(tokenString)
+	if err != nil {
+		return "", err
+	}
+
+	if a.revocations != nil {
+		revoked, err := a.isRevoked(ctx, claims)
+		if err != nil {
+			a.logger.Error("Failed to check token revocation", zap.Error(err))
+			return "", ErrValidate
 		}
-		return a.secretKey, nil
-	})
+		if revoked {
+			return "", ErrValidate
+		}
+	}
+
+	return claims.UserID, nil
+}
+
+// RevokeToken revokes a single token until it expires.
+// Tokens issued without jti can only be revoked together with all tokens of their user.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - tokenString: valid JWT token string to revoke
+//
+// Returns:
+//   - error: ErrRevocationDisabled, ErrValidate if the token is invalid, or storage error
+func (a *JWTAuthorizer) RevokeToken(ctx context.Context, tokenString string) error {
+	if a.revocations == nil {
+		return ErrRevocationDisabled
+	}
+	claims, err := a.parseToken(tokenString)
+	if err != nil {
+		return err
+	}
+	if claims.ID == "" {
+		return a.RevokeUserTokens(ctx, claims.UserID)
+	}
+
+	expiresAt := time.Now().Add(tokenLifetime)
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	if err = a.revocations.SaveRevokedToken(ctx, claims.ID, expiresAt); err != nil {
+		return err
+	}
+	a.purgeExpiredRevocations(ctx)
+	return nil
+}
+
+// RevokeUserTokens revokes all tokens of a user issued so far.
+// Tokens issued within the current second are revoked as well.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//
+// Returns:
+//   - error: ErrRevocationDisabled or storage error
+func (a *JWTAuthorizer) RevokeUserTokens(ctx context.Context, userID string) error {
+	if a.revocations == nil {
+		return ErrRevocationDisabled
+	}
+	// Token issue times are stored with second precision
+	revokedBefore := time.Now().Truncate(time.Second).Add(time.Second)
+	err := a.revocations.SaveUserTokensRevocation(ctx, userID, revokedBefore, revokedBefore.Add(tokenLifetime))
+	if err != nil {
+		return err
+	}
+	a.logger.Infoln("User tokens revoked", zap.String("userID", userID))
+	a.purgeExpiredRevocations(ctx)
+	return nil
+}
+
+// JWKS returns the public keys of the key set.
+// HMAC secret keys are never published.
+//
+// Returns:
+//   - model.JWKS: public verification keys, empty without a key set
+func (a *JWTAuthorizer) JWKS() model.JWKS {
+	if a.keySet == nil {
+		return model.JWKS{Keys: []model.JWK{}}
+	}
+	return a.keySet.JWKS()
+}
+
+// TokenExpiresAt reads the expiration time of a token without verifying it.
+// Must only be used for tokens already checked with ValidateToken.
+//
+// Parameters:
+//   - tokenString: JWT token string
+//
+// Returns:
+//   - time.Time: expiration time of the token
+//   - bool: false if the token cannot be parsed or has no expiration time
+func TokenExpiresAt(tokenString string) (time.Time, bool) {
+	claims := &ShortenerClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenString, claims); err != nil || claims.ExpiresAt == nil {
+		return time.Time{}, false
+	}
+	return claims.ExpiresAt.Time, true
+}
+
+// TokenRole reads the role claim of a token without verifying it.
+// Must only be used for tokens already checked with ValidateToken.
+//
+// Parameters:
+//   - tokenString: JWT token string
+//
+// Returns:
+//   - string: role of the token, empty for regular users and unparsable tokens
+func TokenRole(tokenString string) string {
+	claims := &ShortenerClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenString, claims); err != nil {
+		return ""
+	}
+	return claims.Role
+}
+
+// parseToken verifies a JWT token's signature and expiration and returns its claims.
+//
+// Parameters:
+//   - tokenString: JWT token string to parse
+//
+// Returns:
+//   - *ShortenerClaims: claims of the valid token
+//   - error: ErrValidate if token is invalid, expired, or signature verification fails
+func (a *JWTAuthorizer) parseToken(tokenString string) (*ShortenerClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ShortenerClaims{}, a.verificationKey)
 
 	if err != nil {
 		a.logger.Error("Failed to parse token", zap.Error(err), zap.String("token", tokenString))
-		return "", ErrValidate
+		return nil, ErrValidate
 	}
 
 	if claims, ok := token.Claims.(*ShortenerClaims); ok && token.Valid {
-		return claims.UserID, nil
+		return claims, nil
 	}
 
 	a.logger.Error("Invalid token", zap.String("token", tokenString))
-	return "", ErrValidate
+	return nil, ErrValidate
+}
+
+// isRevoked checks whether the token itself or all tokens of its user were revoked.
+// Tokens without issue time are revoked by any revocation of their user.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - claims: claims of a valid token
+//
+// Returns:
+//   - bool: true if the token is revoked
+//   - error: storage error
+func (a *JWTAuthorizer) isRevoked(ctx context.Context, claims *ShortenerClaims) (bool, error) {
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+	return a.revocations.IsTokenRevoked(ctx, claims.ID, claims.UserID, issuedAt)
+}
+
+// purgeExpiredRevocations removes expired revocations from storage at most once per revocationPurgeInterval.
+// Failures are only logged, expired revocations do not affect validation.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+func (a *JWTAuthorizer) purgeExpiredRevocations(ctx context.Context) {
+	a.purgeMu.Lock()
+	defer a.purgeMu.Unlock()
+	now := time.Now()
+	if now.Sub(a.lastPurge) < revocationPurgeInterval {
+		return
+	}
+	a.lastPurge = now
+	if _, err := a.revocations.PurgeExpiredRevocations(ctx, now); err != nil {
+		a.logger.Error("Failed to purge expired token revocations", zap.Error(err))
+	}
+}
+
+// verificationKey selects the key verifying a token by its kid header.
+// The signing method of the token must match the method of the key,
+// which prevents algorithm confusion between HMAC and public keys.
+//
+// Parameters:
+//   - token: parsed token with unverified header
+//
+// Returns:
+//   - interface{}: key verifying the token signature
+//   - error: ErrValidate for unknown key IDs or unexpected signing methods
+func (a *JWTAuthorizer) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, hasKid := token.Header["kid"].(string)
+	if !hasKid {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || (a.keySet != nil && len(a.secretKey) == 0) {
+			a.logger.Error("Unexpected signing method")
+			return nil, ErrValidate
+		}
+		return a.secretKey, nil
+	}
+
+	if a.keySet == nil {
+		a.logger.Error("Unknown key id", zap.String("kid", kid))
+		return nil, ErrValidate
+	}
+	key := a.keySet.Key(kid)
+	if key == nil {
+		a.logger.Error("Unknown key id", zap.String("kid", kid))
+		return nil, ErrValidate
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		a.logger.Error("Unexpected signing method", zap.String("kid", kid))
+		return nil, ErrValidate
+	}
+	return key.PublicKey, nil
 }
@@ -0,0 +1,156 @@
+// Package service provides business logic for URL shortening service.
+package service
+
+import (
+	"encoding/json"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/model"
+	"strings"
+	"sync"
+	"time"
+)
+
+// botSignaturesReloadInterval defines how often the bot signatures file is checked for changes.
+const botSignaturesReloadInterval = 30 * time.Second
+
+// defaultBotSignatures are used when no signatures file is configured.
+// Link preview fetchers come first, generic fragments last, so that specific signatures win.
+var defaultBotSignatures = []model.BotSignature{
+	{Name: "Facebook", Pattern: "facebookexternalhit", Unfurler: true},
+	{Name: "Facebook", Pattern: "Facebot", Unfurler: true},
+	{Name: "Twitter", Pattern: "Twitterbot", Unfurler: true},
+	{Name: "Slack", Pattern: "Slackbot-LinkExpanding", Unfurler: true},
+	{Name: "LinkedIn", Pattern: "LinkedInBot", Unfurler: true},
+	{Name: "Discord", Pattern: "Discordbot", Unfurler: true},
+	{Name: "Telegram", Pattern: "TelegramBot", Unfurler: true},
+	{Name: "WhatsApp", Pattern: "WhatsApp", Unfurler: true},
+	{Name: "Skype", Pattern: "SkypeUriPreview", Unfurler: true},
+	{Name: "Mastodon", Pattern: "Mastodon/", Unfurler: true},
+	{Name: "Googlebot", Pattern: "Googlebot"},
+	{Name: "Bingbot", Pattern: "bingbot"},
+	{Name: "YandexBot", Pattern: "YandexBot"},
+	{Name: "DuckDuckBot", Pattern: "DuckDuckBot"},
+	{Name: "Baiduspider", Pattern: "Baiduspider"},
+	{Name: "Applebot", Pattern: "Applebot"},
+	{Name: "AhrefsBot", Pattern: "AhrefsBot"},
+	{Name: "SemrushBot", Pattern: "SemrushBot"},
+	{Name: "Headless Chrome", Pattern: "HeadlessChrome"},
+	{Name: "curl", Pattern: "curl/"},
+	{Name: "Wget", Pattern: "Wget/"},
+	{Name: "Python Requests", Pattern: "python-requests"},
+	{Name: "Generic bot", Pattern: "bot"},
+	{Name: "Generic crawler", Pattern: "crawler"},
+	{Name: "Generic spider", Pattern: "spider"},
+}
+
+// BotDetector classifies requests as bot traffic by their User-Agent header.
+// The signature list can be loaded from a JSON file and is reloaded when the file changes.
+type BotDetector struct {
+	mu         sync.RWMutex
+	signatures []model.BotSignature
+
+	reloader *fileReloader
+}
+
+// NewBotDetector creates a new BotDetector instance with defaultBotSignatures.
+//
+// Parameters:
+//   - logger: logger instance for reload errors
+//
+// Returns:
+//   - *BotDetector: initialized bot detector
+func NewBotDetector(logger *logger.Logger) *BotDetector {
+	d := &BotDetector{
+		signatures: normalizeBotSignatures(defaultBotSignatures),
+	}
+	d.reloader = newFileReloader("bot signatures", botSignaturesReloadInterval, d.applySignatures, logger)
+	return d
+}
+
+// LoadSignatures reads the bot signatures from a JSON file, replacing the signatures in use.
+// The signatures in use are kept if the file cannot be read or parsed.
+//
+// Parameters:
+//   - path: path to the JSON file with model.BotSignatures
+//
+// Returns:
+//   - error: error if the file cannot be read or parsed
+func (d *BotDetector) LoadSignatures(path string) error {
+	return d.reloader.load(path)
+}
+
+// applySignatures parses a bot signatures file and replaces the signatures in use.
+//
+// Parameters:
+//   - data: contents of the JSON file with model.BotSignatures
+//
+// Returns:
+//   - error: error if the contents cannot be parsed
+func (d *BotDetector) applySignatures(data []byte) error {
+	var signatures model.BotSignatures
+	if err := json.Unmarshal(data, &signatures); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.signatures = normalizeBotSignatures(signatures.Signatures)
+	d.mu.Unlock()
+	return nil
+}
+
+// StartReload starts a background worker reloading the bot signatures file when it changes.
+// The file is checked every botSignaturesReloadInterval until Close is called.
+//
+// Parameters:
+//   - path: path to the JSON file with model.BotSignatures
+func (d *BotDetector) StartReload(path string) {
+	d.reloader.start(path)
+}
+
+// Close stops the reload worker and waits for it to finish.
+func (d *BotDetector) Close() {
+	d.reloader.close()
+}
+
+// Detect finds the first signature matching a User-Agent header.
+// Requests without a User-Agent header are not classified as bots.
+//
+// Parameters:
+//   - userAgent: User-Agent header of the request
+//
+// Returns:
+//   - model.BotSignature: matching signature
+//   - bool: true if the request comes from a bot
+func (d *BotDetector) Detect(userAgent string) (model.BotSignature, bool) {
+	userAgent = strings.ToLower(userAgent)
+	if userAgent == "" {
+		return model.BotSignature{}, false
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, signature := range d.signatures {
+		if strings.Contains(userAgent, signature.Pattern) {
+			return signature, true
+		}
+	}
+	return model.BotSignature{}, false
+}
+
+// normalizeBotSignatures lowercases patterns for matching and drops signatures without a pattern,
+// which would match every request.
+//
+// Parameters:
+//   - signatures: signatures as configured
+//
+// Returns:
+//   - []model.BotSignature: signatures ready for matching
+func normalizeBotSignatures(signatures []model.BotSignature) []model.BotSignature {
+	normalized := make([]model.BotSignature, 0, len(signatures))
+	for _, signature := range signatures {
+		signature.Pattern = strings.ToLower(strings.TrimSpace(signature.Pattern))
+		if signature.Pattern != "" {
+			normalized = append(normalized, signature)
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,259 @@
+// Package service provides business logic for URL shortening service.
+package service
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// trackingParamPrefixes are prefixes of query parameter names added by campaign tracking.
+var trackingParamPrefixes = []string{"utm_"}
+
+// trackingParams are query parameter names added by ad networks and mail campaigns to identify clicks.
+var trackingParams = map[string]struct{}{
+	"fbclid": {}, "gclid": {}, "dclid": {}, "gbraid": {}, "wbraid": {}, "msclkid": {},
+	"yclid": {}, "igshid": {}, "mc_cid": {}, "mc_eid": {},
+}
+
+// defaultPorts maps schemes to the ports dropped from canonical URLs.
+var defaultPorts = map[string]string{"http": "80", "https": "443"}
+
+// URLCanonicalizer normalizes destinations of new links, so that spellings of the same address
+// are deduplicated to one short URL. The normalized form is compared only, links keep the URL as given.
+type URLCanonicalizer struct {
+	dropTrackingParams bool
+}
+
+// NewURLCanonicalizer creates a new URLCanonicalizer instance.
+//
+// Parameters:
+//   - dropTrackingParams: true to drop utm_* and click identifier query parameters
+//
+// Returns:
+//   - *URLCanonicalizer: initialized canonicalizer
+func NewURLCanonicalizer(dropTrackingParams bool) *URLCanonicalizer {
+	return &URLCanonicalizer{dropTrackingParams: dropTrackingParams}
+}
+
+// Canonicalize normalizes a destination URL.
+// It lowercases scheme and host, strips the default port of the scheme, removes dot segments
+// and unneeded percent-encoding from the path and sorts query parameters by name.
+//
+// Parameters:
+//   - rawURL: destination URL of a new link
+//
+// Returns:
+//   - string: canonical URL, rawURL unchanged if it is not an absolute URL with a host
+func (c *URLCanonicalizer) Canonicalize(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return rawURL
+	}
+
+	var b strings.Builder
+	scheme := strings.ToLower(parsed.Scheme)
+	b.WriteString(scheme)
+	b.WriteString("://")
+	if parsed.User != nil {
+		b.WriteString(parsed.User.String())
+		b.WriteByte('@')
+	}
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	b.WriteString(host)
+	if port := parsed.Port(); port != "" && port != defaultPorts[scheme] {
+		b.WriteByte(':')
+		b.WriteString(port)
+	}
+
+	path := removeDotSegments(normalizePercentEncoding(parsed.EscapedPath()))
+	if path == "" {
+		path = "/"
+	}
+	b.WriteString(path)
+	if query := c.canonicalQuery(parsed.RawQuery); query != "" {
+		b.WriteByte('?')
+		b.WriteString(query)
+	}
+	if parsed.Fragment != "" {
+		b.WriteByte('#')
+		b.WriteString(parsed.EscapedFragment())
+	}
+	return b.String()
+}
+
+// canonicalQuery sorts query parameters by name and drops empty and, if enabled, tracking parameters.
+// Parameters with equal names keep their order, as applications may read them as lists.
+//
+// Parameters:
+//   - rawQuery: encoded query without the question mark
+//
+// Returns:
+//   - string: canonical encoded query, empty if no parameter is left
+func (c *URLCanonicalizer) canonicalQuery(rawQuery string) string {
+	type param struct {
+		name string
+		pair string
+	}
+	var params []param
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		pair = normalizePercentEncoding(pair)
+		name, _, _ := strings.Cut(pair, "=")
+		if decoded, err := url.QueryUnescape(name); err == nil {
+			name = decoded
+		}
+		if c.dropTrackingParams && isTrackingParam(name) {
+			continue
+		}
+		params = append(params, param{name: name, pair: pair})
+	}
+	sort.SliceStable(params, func(i, j int) bool {
+		return params[i].name < params[j].name
+	})
+
+	pairs := make([]string, len(params))
+	for i, p := range params {
+		pairs[i] = p.pair
+	}
+	return strings.Join(pairs, "&")
+}
+
+// isTrackingParam reports whether a query parameter only identifies the campaign or click.
+//
+// Parameters:
+//   - name: decoded parameter name
+//
+// Returns:
+//   - bool: true for utm_* and click identifier parameters
+func isTrackingParam(name string) bool {
+	name = strings.ToLower(name)
+	if _, ok := trackingParams[name]; ok {
+		return true
+	}
+	for _, prefix := range trackingParamPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizePercentEncoding decodes percent-encoded unreserved characters
+// and uppercases the hex digits of other percent-encodings as described in RFC 3986 section 6.2.2.
+//
+// Parameters:
+//   - s: encoded path or query
+//
+// Returns:
+//   - string: equivalent encoding in normal form
+func normalizePercentEncoding(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+2 >= len(s) || !isHexDigit(s[i+1]) || !isHexDigit(s[i+2]) {
+			b.WriteByte(s[i])
+			continue
+		}
+		decoded := hexValue(s[i+1])<<4 | hexValue(s[i+2])
+		if isUnreserved(decoded) {
+			b.WriteByte(decoded)
+		} else {
+			b.WriteByte('%')
+			b.WriteString(strings.ToUpper(s[i+1 : i+3]))
+		}
+		i += 2
+	}
+	return b.String()
+}
+
+// removeDotSegments resolves "." and ".." segments of a path as described in RFC 3986 section 5.2.4.
+// Unlike path.Clean it keeps empty segments and the trailing slash, which servers may tell apart.
+//
+// Parameters:
+//   - path: encoded absolute path
+//
+// Returns:
+//   - string: path without dot segments
+func removeDotSegments(path string) string {
+	if !strings.Contains(path, ".") {
+		return path
+	}
+	segments := strings.Split(path, "/")
+	output := make([]string, 0, len(segments))
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		switch segment {
+		case ".":
+			if last {
+				output = append(output, "")
+			}
+		case "..":
+			if len(output) > 1 {
+				output = output[:len(output)-1]
+			}
+			if last {
+				output = append(output, "")
+			}
+		default:
+			output = append(output, segment)
+		}
+	}
+	return strings.Join(output, "/")
+}
+
+// isUnreserved reports whether a character may appear in URLs without percent-encoding.
+//
+// Parameters:
+//   - c: decoded character
+//
+// Returns:
+//   - bool: true for letters, digits and "-", ".", "_", "~"
+func isUnreserved(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+// isHexDigit reports whether a character is a hexadecimal digit.
+//
+// Parameters:
+//   - c: character
+//
+// Returns:
+//   - bool: true for 0-9, a-f and A-F
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// hexValue returns the value of a hexadecimal digit.
+//
+// Parameters:
+//   - c: hexadecimal digit
+//
+// Returns:
+//   - byte: digit value from 0 to 15
+func hexValue(c byte) byte {
+	switch {
+	case c >= 'a':
+		return c - 'a' + 10
+	case c >= 'A':
+		return c - 'A' + 10
+	}
+	return c - '0'
+}
+
+// SetURLCanonicalizer replaces the canonicalizer normalizing destinations of new links before deduplication.
+// By default destinations are normalized without dropping tracking parameters.
+//
+// Parameters:
+//   - canonicalizer: canonicalizer to apply
+func (u *URLShortener) SetURLCanonicalizer(canonicalizer *URLCanonicalizer) {
+	u.canonicalizer = canonicalizer
+}
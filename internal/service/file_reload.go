@@ -0,0 +1,130 @@
+// Package service provides business logic for URL shortening service.
+package service
+
+import (
+	"fmt"
+	"github.com/bezjen/shortener/internal/logger"
+	"go.uber.org/zap"
+	"os"
+	"sync"
+	"time"
+)
+
+// fileReloader loads a configuration file and reloads it in the background when it changes.
+// A file is reloaded when its modification time differs from the time of the last successful load.
+type fileReloader struct {
+	name     string
+	interval time.Duration
+	apply    func(data []byte) error
+	logger   *logger.Logger
+
+	mu      sync.Mutex
+	modTime time.Time
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newFileReloader creates a new fileReloader instance.
+//
+// Parameters:
+//   - name: description of the file contents for errors and logs, e.g. "bot signatures"
+//   - interval: how often the file is checked for changes
+//   - apply: function parsing the file contents and replacing the configuration in use
+//   - logger: logger instance for reload errors
+//
+// Returns:
+//   - *fileReloader: initialized file reloader
+func newFileReloader(name string,
+	interval time.Duration,
+	apply func(data []byte) error,
+	logger *logger.Logger,
+) *fileReloader {
+	return &fileReloader{
+		name:     name,
+		interval: interval,
+		apply:    apply,
+		logger:   logger,
+		done:     make(chan struct{}),
+	}
+}
+
+// load reads a file and applies its contents.
+// The configuration in use is kept if the file cannot be read or parsed.
+//
+// Parameters:
+//   - path: path to the file
+//
+// Returns:
+//   - error: error if the file cannot be read or parsed
+func (r *fileReloader) load(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err = r.apply(data); err != nil {
+		return fmt.Errorf("parse %s %s: %w", r.name, path, err)
+	}
+
+	r.mu.Lock()
+	r.modTime = info.ModTime()
+	r.mu.Unlock()
+	return nil
+}
+
+// start starts a background worker reloading the file when it changes.
+// The file is checked every interval until close is called.
+//
+// Parameters:
+//   - path: path to the file
+func (r *fileReloader) start(path string) {
+	r.wg.Add(1)
+	go r.worker(path)
+}
+
+// close stops the reload worker and waits for it to finish.
+func (r *fileReloader) close() {
+	close(r.done)
+	r.wg.Wait()
+}
+
+// worker periodically reloads the file if its modification time changed.
+// It runs in its own goroutine until the reloader is closed.
+//
+// Parameters:
+//   - path: path to the file
+func (r *fileReloader) worker(path string) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			r.logger.Error("Failed to check "+r.name, zap.Error(err))
+			continue
+		}
+		r.mu.Lock()
+		changed := !info.ModTime().Equal(r.modTime)
+		r.mu.Unlock()
+		if !changed {
+			continue
+		}
+		if err = r.load(path); err != nil {
+			r.logger.Error("Failed to reload "+r.name, zap.Error(err))
+			continue
+		}
+		r.logger.Infoln("Reloaded "+r.name, zap.String("path", path))
+	}
+}
@@ -0,0 +1,296 @@
+// Package service provides business logic for URL shortening service.
+package service
+
+import (
+	"context"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/repository"
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// healthCheckTimeout limits a single request to a destination, including the response headers.
+const healthCheckTimeout = 10 * time.Second
+
+// healthCheckBatchSize defines how many due links are loaded from storage at once.
+const healthCheckBatchSize = 100
+
+// defaultHealthCheckConcurrency defines how many destinations are checked at once when no limit is configured.
+const defaultHealthCheckConcurrency = 4
+
+// defaultHealthCheckHostDelay defines the minimum time between requests to one host when no delay is configured.
+const defaultHealthCheckHostDelay = time.Second
+
+// healthCheckUserAgent identifies health check requests to the owners of destinations.
+const healthCheckUserAgent = "shortener-link-checker/1.0"
+
+// HealthChecker periodically checks whether link destinations still respond.
+// Each destination gets a HEAD request, falling back to GET for servers that reject HEAD.
+// Redirects are not followed, a redirect response marks the link as redirected.
+// Checks run concurrently up to a limit, requests to the same host are spaced by a minimum delay.
+// Like metadata fetches, checks refuse connections to private addresses.
+type HealthChecker struct {
+	storage     repository.Repository
+	logger      *logger.Logger
+	client      *http.Client
+	concurrency int
+	hostDelay   time.Duration
+
+	mu       sync.Mutex
+	hostNext map[string]time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewHealthChecker creates a new HealthChecker instance.
+//
+// Parameters:
+//   - storage: repository with the links to check
+//   - logger: logger instance for check results and errors
+//   - concurrency: maximum number of destinations checked at once, 0 for defaultHealthCheckConcurrency
+//   - hostDelay: minimum time between requests to the same host, 0 for defaultHealthCheckHostDelay
+//
+// Returns:
+//   - *HealthChecker: initialized health checker
+func NewHealthChecker(storage repository.Repository,
+	logger *logger.Logger,
+	concurrency int,
+	hostDelay time.Duration,
+) *HealthChecker {
+	if concurrency <= 0 {
+		concurrency = defaultHealthCheckConcurrency
+	}
+	if hostDelay <= 0 {
+		hostDelay = defaultHealthCheckHostDelay
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &HealthChecker{
+		storage:     storage,
+		logger:      logger,
+		client:      newDestinationClient(healthCheckTimeout, 0),
+		concurrency: concurrency,
+		hostDelay:   hostDelay,
+		hostNext:    make(map[string]time.Time),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// SetHTTPClient replaces the client checking destinations, e.g. to route checks through a proxy.
+// The replacement has to guard against private targets and must not follow redirects itself.
+// Must be called before checks are started.
+//
+// Parameters:
+//   - client: HTTP client to check with
+func (c *HealthChecker) SetHTTPClient(client *http.Client) {
+	c.client = client
+}
+
+// Check requests a destination and classifies the response.
+// 2xx responses are healthy, 3xx responses are redirected, other responses and network errors are broken.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - rawURL: destination URL of a link
+//
+// Returns:
+//   - model.URLHealth: result of the check, status code 0 if no response was received
+func (c *HealthChecker) Check(ctx context.Context, rawURL string) model.URLHealth {
+	statusCode, err := c.request(ctx, http.MethodHead, rawURL)
+	if err != nil || statusCode >= http.StatusBadRequest {
+		statusCode, err = c.request(ctx, http.MethodGet, rawURL)
+	}
+	health := model.URLHealth{StatusCode: statusCode, CheckedAt: time.Now().UTC()}
+	switch {
+	case err != nil:
+		health.Status = model.HealthBroken
+	case statusCode >= http.StatusOK && statusCode < http.StatusMultipleChoices:
+		health.Status = model.HealthHealthy
+	case statusCode >= http.StatusMultipleChoices && statusCode < http.StatusBadRequest:
+		health.Status = model.HealthRedirected
+	default:
+		health.Status = model.HealthBroken
+	}
+	return health
+}
+
+// CheckDue checks all links not checked since checkedBefore and records the results.
+// Links are loaded in batches of healthCheckBatchSize, never checked links first.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - checkedBefore: links checked at or after this time are skipped
+//
+// Returns:
+//   - int: number of checked links
+//   - error: error if links cannot be loaded, results cannot be saved, or the context is cancelled
+func (c *HealthChecker) CheckDue(ctx context.Context, checkedBefore time.Time) (int, error) {
+	checked := 0
+	for {
+		urls, err := c.storage.GetURLsForHealthCheck(ctx, checkedBefore, healthCheckBatchSize)
+		if err != nil {
+			return checked, err
+		}
+		results := c.checkBatch(ctx, urls)
+		if err = ctx.Err(); err != nil {
+			return checked, err
+		}
+		for i, url := range urls {
+			if err = c.storage.SaveURLHealth(ctx, url.Domain, url.ShortURL, results[i]); err != nil {
+				return checked, err
+			}
+			checked++
+		}
+		c.pruneHosts()
+		if len(urls) < healthCheckBatchSize {
+			return checked, nil
+		}
+	}
+}
+
+// Start starts a background worker checking links not checked for longer than interval.
+// The first run starts immediately, next ones every interval until Close is called.
+//
+// Parameters:
+//   - interval: how often each link is checked
+func (c *HealthChecker) Start(interval time.Duration) {
+	c.wg.Add(1)
+	go c.checkWorker(interval)
+}
+
+// Close stops the background worker, aborting checks in flight, and waits for it to finish.
+func (c *HealthChecker) Close() {
+	c.cancel()
+	c.wg.Wait()
+}
+
+// checkWorker periodically checks links that are due.
+// It runs in its own goroutine until the checker is closed.
+func (c *HealthChecker) checkWorker(interval time.Duration) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		checked, err := c.CheckDue(c.ctx, time.Now().Add(-interval))
+		if err != nil && c.ctx.Err() == nil {
+			c.logger.Error("Failed to check link destinations", zap.Error(err))
+		} else if checked > 0 {
+			c.logger.Infoln("Checked link destinations", zap.Int("count", checked))
+		}
+
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkBatch checks destinations of links concurrently, at most concurrency at once.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - urls: links to check
+//
+// Returns:
+//   - []model.URLHealth: results in the order of urls
+func (c *HealthChecker) checkBatch(ctx context.Context, urls []model.URL) []model.URLHealth {
+	results := make([]model.URLHealth, len(urls))
+	slots := make(chan struct{}, c.concurrency)
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i] = c.Check(ctx, url.OriginalURL)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// request sends a request to a destination after waiting for the turn of its host.
+// The response body is not read.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - method: HTTP method, HEAD or GET
+//   - rawURL: destination URL
+//
+// Returns:
+//   - int: response status code
+//   - error: error if the URL is invalid or no response was received
+func (c *HealthChecker) request(ctx context.Context, method string, rawURL string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", healthCheckUserAgent)
+	if err = c.waitHost(ctx, req.URL); err != nil {
+		return 0, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// waitHost reserves the next request slot of a host and waits for it.
+// Slots of a host are hostDelay apart, so concurrent checks do not hit one server at once.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - target: URL of the request
+//
+// Returns:
+//   - error: context error if the context is cancelled while waiting
+func (c *HealthChecker) waitHost(ctx context.Context, target *url.URL) error {
+	host := strings.ToLower(target.Hostname())
+	now := time.Now()
+
+	c.mu.Lock()
+	slot := c.hostNext[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	c.hostNext[host] = slot.Add(c.hostDelay)
+	c.mu.Unlock()
+
+	wait := slot.Sub(now)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// pruneHosts forgets hosts whose next request slot has passed, so the map does not grow without bound.
+func (c *HealthChecker) pruneHosts() {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for host, next := range c.hostNext {
+		if next.Before(now) {
+			delete(c.hostNext, host)
+		}
+	}
+}
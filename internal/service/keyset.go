@@ -0,0 +1,253 @@
+// Package service provides business logic for URL shortening service.
+package service
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/golang-jwt/jwt/v5"
+	"math"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// minRSAKeyBits defines the minimal accepted size of RSA signing keys.
+const minRSAKeyBits = 2048
+
+// ErrUnsupportedKey is returned when a key file holds a key of an unsupported type.
+var ErrUnsupportedKey = errors.New("unsupported key, expected RSA or Ed25519 key in PEM format")
+
+// SigningKey is a token key identified by its key ID.
+// Keys without a private part only verify tokens.
+type SigningKey struct {
+	// ID is the key ID written to the kid header of tokens.
+	ID string
+
+	// Method is the signing method of the key: RS256 or EdDSA.
+	Method jwt.SigningMethod
+
+	// PrivateKey signs tokens, nil for verification only keys.
+	PrivateKey crypto.Signer
+
+	// PublicKey verifies tokens.
+	PublicKey crypto.PublicKey
+}
+
+// KeySet holds token verification keys and the active key new tokens are signed with.
+// Rotation is done by adding a new key, making it active and removing the old key
+// once tokens signed with it have expired.
+type KeySet struct {
+	keys   []*SigningKey
+	byID   map[string]*SigningKey
+	active *SigningKey
+}
+
+// NewKeySet creates a new KeySet instance.
+//
+// Parameters:
+//   - keys: verification keys with unique key IDs
+//   - activeKeyID: key ID of the key signing new tokens
+//
+// Returns:
+//   - *KeySet: initialized key set
+//   - error: error if key IDs repeat or the active key is missing or cannot sign
+func NewKeySet(keys []*SigningKey, activeKeyID string) (*KeySet, error) {
+	set := &KeySet{
+		keys: keys,
+		byID: make(map[string]*SigningKey, len(keys)),
+	}
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, errors.New("key id is empty")
+		}
+		if _, exists := set.byID[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+		set.byID[key.ID] = key
+	}
+	set.active = set.byID[activeKeyID]
+	if set.active == nil {
+		return nil, fmt.Errorf("active key %q is not in the key set", activeKeyID)
+	}
+	if set.active.PrivateKey == nil {
+		return nil, fmt.Errorf("active key %q has no private key", activeKeyID)
+	}
+	return set, nil
+}
+
+// LoadKeySet loads keys from PEM files and creates a KeySet.
+//
+// Parameters:
+//   - keyFiles: key files in "kid=path" format
+//   - activeKeyID: key ID of the key signing new tokens
+//
+// Returns:
+//   - *KeySet: initialized key set
+//   - error: error if a file cannot be read or parsed, or the key set is invalid
+func LoadKeySet(keyFiles []string, activeKeyID string) (*KeySet, error) {
+	keys := make([]*SigningKey, 0, len(keyFiles))
+	for _, keyFile := range keyFiles {
+		id, path, found := strings.Cut(keyFile, "=")
+		if !found || id == "" || path == "" {
+			return nil, fmt.Errorf("key file %q is not in kid=path format", keyFile)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := ParseSigningKey(id, data)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		keys = append(keys, key)
+	}
+	return NewKeySet(keys, activeKeyID)
+}
+
+// ParseSigningKey parses an RSA or Ed25519 key in PEM format.
+// Private keys may be PKCS #8 or PKCS #1 encoded, public keys PKIX or PKCS #1 encoded.
+//
+// Parameters:
+//   - id: key ID of the key
+//   - data: PEM encoded private or public key
+//
+// Returns:
+//   - *SigningKey: parsed key, without private part for public keys
+//   - error: ErrUnsupportedKey or parsing error
+func ParseSigningKey(id string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrUnsupportedKey
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, ErrUnsupportedKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{ID: id}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.PrivateKey, key.PublicKey = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.PrivateKey, key.PublicKey = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, ErrUnsupportedKey
+	}
+	if rsaKey, ok := key.PublicKey.(*rsa.PublicKey); ok && rsaKey.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("rsa key must be at least %d bits long", minRSAKeyBits)
+	}
+	return key, nil
+}
+
+// Active returns the key new tokens are signed with.
+//
+// Returns:
+//   - *SigningKey: active key
+func (s *KeySet) Active() *SigningKey {
+	return s.active
+}
+
+// Key returns the verification key with the key ID.
+//
+// Parameters:
+//   - id: key ID from the kid header of a token
+//
+// Returns:
+//   - *SigningKey: found key, nil for unknown key IDs
+func (s *KeySet) Key(id string) *SigningKey {
+	return s.byID[id]
+}
+
+// JWKS returns the public keys of the set in JSON Web Key Set format.
+//
+// Returns:
+//   - model.JWKS: public keys in the order they were configured
+func (s *KeySet) JWKS() model.JWKS {
+	jwks := model.JWKS{Keys: make([]model.JWK, 0, len(s.keys))}
+	for _, key := range s.keys {
+		jwk := model.JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Method.Alg()}
+		switch publicKey := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.Modulus = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.Exponent = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
+
+// ParseJWK parses a public RSA or Ed25519 key in JSON Web Key format.
+// Keys without alg get the algorithm of their key type.
+//
+// Parameters:
+//   - jwk: public key in JSON Web Key format
+//
+// Returns:
+//   - *SigningKey: verification key without private part
+//   - error: ErrUnsupportedKey or decoding error
+func ParseJWK(jwk model.JWK) (*SigningKey, error) {
+	key := &SigningKey{ID: jwk.KeyID}
+	switch {
+	case jwk.KeyType == "RSA" && (jwk.Algorithm == "" || jwk.Algorithm == jwt.SigningMethodRS256.Alg()):
+		modulus, err := base64.RawURLEncoding.DecodeString(jwk.Modulus)
+		if err != nil {
+			return nil, err
+		}
+		exponent, err := base64.RawURLEncoding.DecodeString(jwk.Exponent)
+		if err != nil {
+			return nil, err
+		}
+		e := new(big.Int).SetBytes(exponent)
+		if !e.IsInt64() || e.Int64() > math.MaxInt32 {
+			return nil, ErrUnsupportedKey
+		}
+		publicKey := &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: int(e.Int64())}
+		if publicKey.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("rsa key must be at least %d bits long", minRSAKeyBits)
+		}
+		key.Method, key.PublicKey = jwt.SigningMethodRS256, publicKey
+	case jwk.KeyType == "OKP" && jwk.Curve == "Ed25519" &&
+		(jwk.Algorithm == "" || jwk.Algorithm == jwt.SigningMethodEdDSA.Alg()):
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, ErrUnsupportedKey
+		}
+		key.Method, key.PublicKey = jwt.SigningMethodEdDSA, ed25519.PublicKey(x)
+	default:
+		return nil, ErrUnsupportedKey
+	}
+	return key, nil
+}
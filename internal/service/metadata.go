@@ -0,0 +1,428 @@
+// Package service provides business logic for URL shortening service.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/repository"
+	"go.uber.org/zap"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+)
+
+// Limits of metadata fetches.
+const (
+	// metadataFetchTimeout limits a fetch of a destination page, including redirects and reading the body.
+	metadataFetchTimeout = 5 * time.Second
+
+	// maxMetadataBodySize defines how much of a destination page is read, metadata further on is ignored.
+	maxMetadataBodySize = 512 << 10
+
+	// maxMetadataRedirects defines how many redirects are followed to the destination page.
+	maxMetadataRedirects = 5
+
+	// maxMetadataTitleLength defines the maximum length of a stored title in characters.
+	maxMetadataTitleLength = 300
+
+	// maxMetadataDescriptionLength defines the maximum length of a stored description in characters.
+	maxMetadataDescriptionLength = 1000
+
+	// maxMetadataImageLength defines the maximum length of a stored preview image URL.
+	maxMetadataImageLength = 2048
+)
+
+// metadataQueueSize defines how many new links may wait for their metadata fetch.
+const metadataQueueSize = 1000
+
+// metadataWorkers defines how many destination pages are fetched at once.
+const metadataWorkers = 4
+
+// metadataUserAgent identifies metadata fetches to the owners of destinations.
+const metadataUserAgent = "shortener-metadata-fetcher/1.0"
+
+// Metadata fetch errors.
+var (
+	// ErrPrivateTarget is returned when a destination resolves to an address not reachable from the internet.
+	ErrPrivateTarget = errors.New("destination resolves to a private address")
+
+	// ErrNotHTMLPage is returned when a destination does not answer with an HTML page.
+	ErrNotHTMLPage = errors.New("destination is not an html page")
+)
+
+// MetadataFetcher reads the title, description and preview image of link destinations in the background.
+// New links are queued and fetched by a fixed number of workers. Connections to private addresses
+// are refused after name resolution, so that links cannot be used to probe internal services.
+type MetadataFetcher struct {
+	storage repository.Repository
+	logger  *logger.Logger
+	client  *http.Client
+	queue   chan metadataTask
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// metadataTask represents a link waiting for its metadata fetch.
+type metadataTask struct {
+	domain      string
+	shortURL    string
+	originalURL string
+}
+
+// NewMetadataFetcher creates a new MetadataFetcher instance with background workers.
+//
+// Parameters:
+//   - storage: repository the fetched metadata is stored in
+//   - logger: logger instance for fetch errors
+//
+// Returns:
+//   - *MetadataFetcher: initialized metadata fetcher
+func NewMetadataFetcher(storage repository.Repository, logger *logger.Logger) *MetadataFetcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &MetadataFetcher{
+		storage: storage,
+		logger:  logger,
+		client:  newDestinationClient(metadataFetchTimeout, maxMetadataRedirects),
+		queue:   make(chan metadataTask, metadataQueueSize),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	for i := 0; i < metadataWorkers; i++ {
+		f.wg.Add(1)
+		go f.worker()
+	}
+	return f
+}
+
+// SetHTTPClient replaces the client fetching destination pages, e.g. to route fetches through a proxy.
+// The replacement has to guard against private targets itself. Must be called before links are queued.
+//
+// Parameters:
+//   - client: HTTP client to fetch with
+func (f *MetadataFetcher) SetHTTPClient(client *http.Client) {
+	f.client = client
+}
+
+// Fetch reads the metadata of a destination page.
+// Open Graph tags take precedence over the title element and the meta description.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - rawURL: destination URL of a link
+//
+// Returns:
+//   - *model.URLMetadata: metadata of the page, empty fields for missing tags
+//   - error: ErrPrivateTarget, ErrNotHTMLPage, or error if the page cannot be fetched
+func (f *MetadataFetcher) Fetch(ctx context.Context, rawURL string) (*model.URLMetadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkMetadataScheme(req.URL); err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", metadataUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("destination answered with status %d", resp.StatusCode)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, ErrNotHTMLPage
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxMetadataBodySize))
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := parseHTMLMetadata(strings.ToValidUTF8(string(body), ""), resp.Request.URL)
+	metadata.FetchedAt = time.Now().UTC()
+	return &metadata, nil
+}
+
+// Enqueue queues a new link for its metadata fetch.
+// Links are skipped if the queue is full or the fetcher is closed.
+//
+// Parameters:
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - shortURL: short URL identifier
+//   - originalURL: destination of the link
+func (f *MetadataFetcher) Enqueue(domain string, shortURL string, originalURL string) {
+	if f.ctx.Err() != nil {
+		return
+	}
+	select {
+	case f.queue <- metadataTask{domain: domain, shortURL: shortURL, originalURL: originalURL}:
+	default:
+		f.logger.Infoln("Metadata queue is full, skipping link", zap.String("shortURL", shortURL))
+	}
+}
+
+// Close stops the workers, aborting fetches in flight, and waits for them to finish.
+// Links still queued are not fetched.
+func (f *MetadataFetcher) Close() {
+	f.cancel()
+	f.wg.Wait()
+}
+
+// worker fetches metadata of queued links and stores it.
+// Each worker runs in its own goroutine until the fetcher is closed.
+func (f *MetadataFetcher) worker() {
+	defer f.wg.Done()
+
+	for {
+		select {
+		case <-f.ctx.Done():
+			return
+		case task := <-f.queue:
+			f.enrich(task)
+		}
+	}
+}
+
+// enrich fetches and stores the metadata of a link. Failures are logged and the link is left without metadata.
+//
+// Parameters:
+//   - task: link to fetch the metadata for
+func (f *MetadataFetcher) enrich(task metadataTask) {
+	metadata, err := f.Fetch(f.ctx, task.originalURL)
+	if err != nil {
+		if f.ctx.Err() == nil {
+			f.logger.Infoln("Failed to fetch link metadata",
+				zap.String("shortURL", task.shortURL),
+				zap.String("originalURL", task.originalURL),
+				zap.String("reason", err.Error()),
+			)
+		}
+		return
+	}
+	err = f.storage.SaveURLMetadata(f.ctx, task.domain, task.shortURL, *metadata)
+	if err != nil && f.ctx.Err() == nil {
+		f.logger.Error("Failed to save link metadata", zap.Error(err), zap.String("shortURL", task.shortURL))
+	}
+}
+
+// EnableMetadataEnrichment makes the shortener queue new links for a metadata fetch of their destination.
+// Without it, links are listed without metadata.
+//
+// Parameters:
+//   - fetcher: metadata fetcher to queue new links to
+func (u *URLShortener) EnableMetadataEnrichment(fetcher *MetadataFetcher) {
+	u.metadataFetcher = fetcher
+}
+
+// enqueueMetadata queues new links for a metadata fetch if enrichment is enabled.
+//
+// Parameters:
+//   - urls: stored links
+func (u *URLShortener) enqueueMetadata(urls ...model.URL) {
+	if u.metadataFetcher == nil {
+		return
+	}
+	for _, url := range urls {
+		u.metadataFetcher.Enqueue(url.Domain, url.ShortURL, url.OriginalURL)
+	}
+}
+
+// newDestinationClient creates an HTTP client for requests to link destinations.
+// Connections to private addresses are refused after name resolution and proxies from the environment
+// are ignored, so that links cannot be used to probe internal services.
+//
+// Parameters:
+//   - timeout: limit of a request including redirects and reading the body
+//   - maxRedirects: number of redirects followed to web pages, 0 to return redirect responses as they are
+//
+// Returns:
+//   - *http.Client: client guarded against private targets
+func newDestinationClient(timeout time.Duration, maxRedirects int) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout: timeout,
+		Control: refusePrivateAddress,
+	}).DialContext
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if maxRedirects == 0 {
+				return http.ErrUseLastResponse
+			}
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return checkMetadataScheme(req.URL)
+		},
+	}
+}
+
+// refusePrivateAddress is a dialer control function refusing connections to private addresses.
+// It runs after name resolution, so host names resolving to internal addresses are refused as well.
+//
+// Parameters:
+//   - network: network of the connection
+//   - address: resolved IP address with port
+//   - conn: raw connection, unused
+//
+// Returns:
+//   - error: ErrPrivateTarget if the address is not public
+func refusePrivateAddress(_ string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || isPrivateIP(ip) {
+		return ErrPrivateTarget
+	}
+	return nil
+}
+
+// checkMetadataScheme allows fetches of web pages only.
+//
+// Parameters:
+//   - target: URL of the request
+//
+// Returns:
+//   - error: error for schemes other than http and https
+func checkMetadataScheme(target *url.URL) error {
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return fmt.Errorf("scheme %s is not fetched", target.Scheme)
+	}
+	return nil
+}
+
+// parseHTMLMetadata reads the title element and meta tags of an HTML head.
+// The page is tokenized up to the end of the head, comments and script and style contents are skipped.
+//
+// Parameters:
+//   - page: HTML page, possibly cut off
+//   - base: URL of the page for resolving relative image URLs
+//
+// Returns:
+//   - model.URLMetadata: metadata without fetch time
+func parseHTMLMetadata(page string, base *url.URL) model.URLMetadata {
+	var title string
+	meta := make(map[string]string)
+	tokenizer := html.NewTokenizer(strings.NewReader(page))
+scan:
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			break scan
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttrs := tokenizer.TagName()
+			switch atom.Lookup(name) {
+			case atom.Title:
+				if tokenizer.Next() == html.TextToken && title == "" {
+					title = string(tokenizer.Text())
+				}
+			case atom.Meta:
+				attrs := make(map[string]string)
+				for hasAttrs {
+					var key, value []byte
+					key, value, hasAttrs = tokenizer.TagAttr()
+					attrs[string(key)] = string(value)
+				}
+				key := strings.ToLower(attrs["property"])
+				if key == "" {
+					key = strings.ToLower(attrs["name"])
+				}
+				if _, ok := meta[key]; key != "" && !ok {
+					meta[key] = attrs["content"]
+				}
+			case atom.Body:
+				break scan
+			}
+		case html.EndTagToken:
+			if name, _ := tokenizer.TagName(); atom.Lookup(name) == atom.Head {
+				break scan
+			}
+		}
+	}
+
+	description := firstNonEmpty(meta["og:description"], meta["description"])
+	return model.URLMetadata{
+		Title:       cleanMetadataText(firstNonEmpty(meta["og:title"], title), maxMetadataTitleLength),
+		Description: cleanMetadataText(description, maxMetadataDescriptionLength),
+		Image:       resolveMetadataImage(firstNonEmpty(meta["og:image"], meta["og:image:url"]), base),
+		SiteName:    cleanMetadataText(meta["og:site_name"], maxMetadataTitleLength),
+	}
+}
+
+// cleanMetadataText collapses whitespace of a text and cuts it to a maximum length.
+//
+// Parameters:
+//   - text: unescaped text
+//   - maxLength: maximum length in characters
+//
+// Returns:
+//   - string: single-line text of at most maxLength characters
+func cleanMetadataText(text string, maxLength int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= maxLength {
+		return text
+	}
+	return strings.TrimSpace(string([]rune(text)[:maxLength]))
+}
+
+// resolveMetadataImage resolves a preview image URL against the page URL.
+//
+// Parameters:
+//   - image: image URL as written in the page
+//   - base: URL of the page
+//
+// Returns:
+//   - string: absolute http or https URL, empty for invalid or overlong URLs
+func resolveMetadataImage(image string, base *url.URL) string {
+	image = strings.TrimSpace(image)
+	if image == "" {
+		return ""
+	}
+	ref, err := url.Parse(image)
+	if err != nil {
+		return ""
+	}
+	resolved := base.ResolveReference(ref)
+	if checkMetadataScheme(resolved) != nil || len(resolved.String()) > maxMetadataImageLength {
+		return ""
+	}
+	return resolved.String()
+}
+
+// firstNonEmpty returns the first value that is not blank.
+//
+// Parameters:
+//   - values: candidate values in order of preference
+//
+// Returns:
+//   - string: first value with non-space characters, empty if there is none
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,539 @@
+// Package service provides business logic for URL shortening service.
+//
+//go:generate mockery --name=OIDCService --output=../mocks --case=underscore
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bezjen/shortener/internal/config"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// oidcHTTPTimeout defines the timeout of requests to the OIDC issuer.
+	oidcHTTPTimeout = 10 * time.Second
+	// oidcMaxResponseSize defines the maximal size of responses read from the OIDC issuer.
+	oidcMaxResponseSize = 1 << 20
+	// oidcJWKSRefreshInterval defines how often the issuer keys may be fetched again for unknown key IDs.
+	oidcJWKSRefreshInterval = time.Minute
+	// oidcLeeway defines the allowed clock skew when validating ID tokens.
+	oidcLeeway = time.Minute
+)
+
+// ErrInvalidIDToken is returned when an ID token is not valid for the configured issuer and client.
+var ErrInvalidIDToken = errors.New("invalid id token")
+
+// ErrOIDCExchange is returned when the authorization code cannot be exchanged for an ID token.
+var ErrOIDCExchange = errors.New("failed to exchange authorization code")
+
+// OIDCLogin holds a started authorization code flow.
+// State, CodeVerifier and Nonce must be kept by the client until the callback.
+type OIDCLogin struct {
+	// AuthURL is the issuer URL the user is redirected to.
+	AuthURL string
+
+	// State protects the callback against cross-site request forgery.
+	State string
+
+	// CodeVerifier is the PKCE secret sent with the authorization code.
+	CodeVerifier string
+
+	// Nonce binds the ID token to the login.
+	Nonce string
+}
+
+// OIDCService defines the interface for OpenID Connect login against a configured issuer.
+type OIDCService interface {
+	// StartLogin starts the authorization code flow with PKCE.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//
+	// Returns:
+	//   - *OIDCLogin: issuer URL and secrets of the login
+	//   - error: discovery error
+	StartLogin(ctx context.Context) (*OIDCLogin, error)
+
+	// FinishLogin exchanges the authorization code for an ID token and maps it to a user ID.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - code: authorization code from the callback
+	//   - codeVerifier: PKCE secret of the login
+	//   - nonce: nonce of the login
+	//
+	// Returns:
+	//   - string: user ID of the issuer subject
+	//   - error: ErrOIDCExchange, ErrInvalidIDToken or discovery error
+	FinishLogin(ctx context.Context, code string, codeVerifier string, nonce string) (string, error)
+
+	// IssuedBy reports whether a token claims to be issued by the configured issuer.
+	// The token is not verified.
+	//
+	// Parameters:
+	//   - tokenString: JWT token string
+	//
+	// Returns:
+	//   - bool: true if the iss claim matches the issuer
+	IssuedBy(tokenString string) bool
+
+	// ValidateIDToken validates an ID token of the issuer presented as a bearer token.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - tokenString: ID token
+	//
+	// Returns:
+	//   - string: user ID of the issuer subject
+	//   - error: ErrInvalidIDToken or discovery error
+	ValidateIDToken(ctx context.Context, tokenString string) (string, error)
+}
+
+// oidcDiscovery is the subset of the issuer discovery document used by the shortener.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcTokenResponse is the subset of the token endpoint response used by the shortener.
+type oidcTokenResponse struct {
+	IDToken string `json:"id_token"`
+}
+
+// oidcClaims represents ID token claims.
+type oidcClaims struct {
+	jwt.RegisteredClaims
+	Nonce string `json:"nonce,omitempty"`
+}
+
+// IssuerOIDCService implements OIDCService with discovery and JWKS of the configured issuer.
+// The discovery document is fetched once, issuer keys are fetched again for unknown key IDs.
+// Requests to the issuer run outside of the lock guarding the cached document and keys.
+type IssuerOIDCService struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	client       *http.Client
+	logger       *logger.Logger
+
+	fetches     singleflight.Group
+	mu          sync.Mutex
+	discovery   *oidcDiscovery
+	keys        map[string]*SigningKey
+	keysFetched time.Time
+}
+
+// NewIssuerOIDCService creates a new IssuerOIDCService instance.
+//
+// Parameters:
+//   - cfg: application configuration with the OIDC issuer and client
+//   - logger: logger instance for OIDC events
+//
+// Returns:
+//   - *IssuerOIDCService: initialized OIDC service
+func NewIssuerOIDCService(cfg config.Config, logger *logger.Logger) *IssuerOIDCService {
+	return &IssuerOIDCService{
+		issuer:       strings.TrimSuffix(cfg.OIDCIssuer, "/"),
+		clientID:     cfg.OIDCClientID,
+		clientSecret: cfg.OIDCSecret,
+		redirectURL:  cfg.OIDCRedirectURL,
+		client:       &http.Client{Timeout: oidcHTTPTimeout},
+		logger:       logger,
+	}
+}
+
+// StartLogin starts the authorization code flow with PKCE using the S256 challenge method.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//
+// Returns:
+//   - *OIDCLogin: issuer URL and secrets of the login
+//   - error: discovery error
+func (s *IssuerOIDCService) StartLogin(ctx context.Context) (*OIDCLogin, error) {
+	discovery, err := s.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	login := &OIDCLogin{}
+	for _, secret := range []*string{&login.State, &login.CodeVerifier, &login.Nonce} {
+		if *secret, err = randomURLSafeString(32); err != nil {
+			return nil, err
+		}
+	}
+	challenge := sha256.Sum256([]byte(login.CodeVerifier))
+
+	authURL, err := url.Parse(discovery.AuthorizationEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", s.clientID)
+	query.Set("redirect_uri", s.redirectURL)
+	query.Set("scope", "openid")
+	query.Set("state", login.State)
+	query.Set("nonce", login.Nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+	login.AuthURL = authURL.String()
+	return login, nil
+}
+
+// FinishLogin exchanges the authorization code for an ID token and maps it to a user ID.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - code: authorization code from the callback
+//   - codeVerifier: PKCE secret of the login
+//   - nonce: nonce of the login
+//
+// Returns:
+//   - string: user ID of the issuer subject
+//   - error: ErrOIDCExchange, ErrInvalidIDToken or discovery error
+func (s *IssuerOIDCService) FinishLogin(ctx context.Context, code string, codeVerifier string, nonce string) (string, error) {
+	discovery, err := s.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", s.redirectURL)
+	form.Set("client_id", s.clientID)
+	form.Set("code_verifier", codeVerifier)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if s.clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(s.clientID), url.QueryEscape(s.clientSecret))
+	}
+
+	var token oidcTokenResponse
+	if err = s.doJSON(req, &token); err != nil {
+		s.logger.Error("Failed to exchange authorization code", zap.Error(err))
+		return "", ErrOIDCExchange
+	}
+	if token.IDToken == "" {
+		return "", ErrOIDCExchange
+	}
+
+	claims, err := s.validate(ctx, token.IDToken)
+	if err != nil {
+		return "", err
+	}
+	if claims.Nonce != nonce {
+		s.logger.Infoln("ID token nonce mismatch", zap.String("sub", claims.Subject))
+		return "", ErrInvalidIDToken
+	}
+	return s.userID(claims.Subject), nil
+}
+
+// IssuedBy reports whether a token claims to be issued by the configured issuer.
+// The token is not verified.
+//
+// Parameters:
+//   - tokenString: JWT token string
+//
+// Returns:
+//   - bool: true if the iss claim matches the issuer
+func (s *IssuerOIDCService) IssuedBy(tokenString string) bool {
+	claims := &jwt.RegisteredClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenString, claims); err != nil {
+		return false
+	}
+	return claims.Issuer == s.issuer
+}
+
+// ValidateIDToken validates an ID token of the issuer presented as a bearer token.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - tokenString: ID token
+//
+// Returns:
+//   - string: user ID of the issuer subject
+//   - error: ErrInvalidIDToken or discovery error
+func (s *IssuerOIDCService) ValidateIDToken(ctx context.Context, tokenString string) (string, error) {
+	claims, err := s.validate(ctx, tokenString)
+	if err != nil {
+		return "", err
+	}
+	return s.userID(claims.Subject), nil
+}
+
+// validate verifies the ID token signature with the issuer keys and checks issuer, audience and expiration.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - tokenString: ID token
+//
+// Returns:
+//   - *oidcClaims: claims of the valid token
+//   - error: ErrInvalidIDToken or discovery error
+func (s *IssuerOIDCService) validate(ctx context.Context, tokenString string) (*oidcClaims, error) {
+	if _, err := s.getDiscovery(ctx); err != nil {
+		return nil, err
+	}
+
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(s.issuer),
+		jwt.WithAudience(s.clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(oidcLeeway),
+	)
+	token, err := parser.ParseWithClaims(tokenString, &oidcClaims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := s.getKey(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		if key.Method.Alg() != token.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method for key %q: %v", kid, token.Header["alg"])
+		}
+		return key.PublicKey, nil
+	})
+	if err != nil {
+		s.logger.Debugln("Invalid id token rejected", zap.Error(err))
+		return nil, ErrInvalidIDToken
+	}
+
+	claims, ok := token.Claims.(*oidcClaims)
+	if !ok || !token.Valid || claims.Subject == "" {
+		return nil, ErrInvalidIDToken
+	}
+	return claims, nil
+}
+
+// userID maps an issuer subject to a stable shortener user ID.
+//
+// Parameters:
+//   - subject: sub claim of an ID token
+//
+// Returns:
+//   - string: name-based UUID of the issuer and subject
+func (s *IssuerOIDCService) userID(subject string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(s.issuer+"#"+subject)).String()
+}
+
+// getDiscovery returns the issuer discovery document, fetching it on first use.
+// The lock is not held during the request, concurrent first uses share a single fetch.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//
+// Returns:
+//   - *oidcDiscovery: discovery document of the issuer
+//   - error: error if the document cannot be fetched or belongs to another issuer
+func (s *IssuerOIDCService) getDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	s.mu.Lock()
+	discovery := s.discovery
+	s.mu.Unlock()
+	if discovery != nil {
+		return discovery, nil
+	}
+
+	if err := s.fetchShared(ctx, "discovery", s.fetchDiscovery); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.discovery, nil
+}
+
+// fetchDiscovery fetches and checks the issuer discovery document and stores it under the lock.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//
+// Returns:
+//   - error: error if the document cannot be fetched or belongs to another issuer
+func (s *IssuerOIDCService) fetchDiscovery(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return err
+	}
+	var discovery oidcDiscovery
+	if err = s.doJSON(req, &discovery); err != nil {
+		return fmt.Errorf("oidc discovery: %w", err)
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != s.issuer {
+		return fmt.Errorf("oidc discovery: issuer %q does not match %q", discovery.Issuer, s.issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return errors.New("oidc discovery: endpoints are missing")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.discovery = &discovery
+	return nil
+}
+
+// getKey returns the issuer key with the key ID.
+// Keys are fetched again for unknown key IDs at most once per oidcJWKSRefreshInterval.
+// The lock is not held during the request, concurrent lookups of unknown keys share a single fetch.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - kid: key ID from the token header
+//
+// Returns:
+//   - *SigningKey: verification key
+//   - error: error if the key is unknown or keys cannot be fetched
+func (s *IssuerOIDCService) getKey(ctx context.Context, kid string) (*SigningKey, error) {
+	s.mu.Lock()
+	key, ok := s.keys[kid]
+	keysFetched := s.keysFetched
+	s.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if time.Since(keysFetched) < oidcJWKSRefreshInterval {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	if err := s.fetchShared(ctx, "jwks", s.fetchKeys); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key, ok = s.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+// fetchKeys fetches the issuer keys and swaps them in under the lock.
+// Keys fetched within oidcJWKSRefreshInterval by a previous fetch are kept.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//
+// Returns:
+//   - error: error if keys cannot be fetched
+func (s *IssuerOIDCService) fetchKeys(ctx context.Context) error {
+	s.mu.Lock()
+	jwksURI := s.discovery.JWKSURI
+	recentlyFetched := time.Since(s.keysFetched) < oidcJWKSRefreshInterval
+	s.mu.Unlock()
+	if recentlyFetched {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return err
+	}
+	var jwks model.JWKS
+	if err = s.doJSON(req, &jwks); err != nil {
+		return fmt.Errorf("oidc jwks: %w", err)
+	}
+	keys := make(map[string]*SigningKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := ParseJWK(jwk)
+		if err != nil {
+			s.logger.Infoln("Skipping unsupported issuer key", zap.String("kid", jwk.KeyID), zap.Error(err))
+			continue
+		}
+		keys[key.ID] = key
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+	s.keysFetched = time.Now()
+	return nil
+}
+
+// fetchShared runs a fetch once for concurrent callers with the same key.
+// The fetch is not canceled with the context of the caller that started it and is bounded by oidcHTTPTimeout,
+// every caller stops waiting when its own context is done.
+//
+// Parameters:
+//   - ctx: context of the caller
+//   - key: name of the fetched resource
+//   - fetch: function fetching the resource
+//
+// Returns:
+//   - error: error of the fetch or of the caller context
+func (s *IssuerOIDCService) fetchShared(ctx context.Context, key string, fetch func(ctx context.Context) error) error {
+	result := s.fetches.DoChan(key, func() (interface{}, error) {
+		return nil, fetch(context.WithoutCancel(ctx))
+	})
+	select {
+	case res := <-result:
+		return res.Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// doJSON sends a request to the issuer and decodes the JSON response.
+//
+// Parameters:
+//   - req: HTTP request to send
+//   - v: value to decode the response into
+//
+// Returns:
+//   - error: error if the request fails, the status is not 200 OK or the body is invalid
+func (s *IssuerOIDCService) doJSON(req *http.Request, v interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body := io.LimitReader(resp.Body, oidcMaxResponseSize)
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, body)
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, req.URL.Redacted())
+	}
+	return json.NewDecoder(body).Decode(v)
+}
+
+// randomURLSafeString generates a random base64url string.
+//
+// Parameters:
+//   - size: number of random bytes
+//
+// Returns:
+//   - string: base64url encoded random bytes
+//   - error: error if random generation fails
+func randomURLSafeString(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,241 @@
+// Package service provides business logic for URL shortening service.
+package service
+
+import (
+	"github.com/bezjen/shortener/internal/model"
+	"go.uber.org/zap"
+	"golang.org/x/net/idna"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// defaultSuspiciousTLDs are the top-level domains flagged when none are configured.
+// They are cheap or free to register and popular with phishing campaigns,
+// or easy to confuse with file names.
+var defaultSuspiciousTLDs = []string{"cf", "click", "ga", "gq", "loan", "ml", "mov", "tk", "top", "work", "zip"}
+
+// homoglyphs maps characters to the ASCII letters they are easily confused with.
+// Cyrillic and Greek letters look identical to Latin ones in most fonts, digits pass for letters.
+var homoglyphs = map[rune]rune{
+	'а': 'a', 'в': 'b', 'е': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p', 'с': 'c', 'т': 't',
+	'у': 'y', 'х': 'x', 'і': 'i', 'ј': 'j', 'ѕ': 's', 'ԁ': 'd', 'һ': 'h', 'ӏ': 'l', 'ԛ': 'q', 'ԝ': 'w',
+	'α': 'a', 'β': 'b', 'ε': 'e', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u',
+	'χ': 'x', 'ɡ': 'g', 'ı': 'i', '0': 'o', '1': 'l', '3': 'e', '5': 's', '|': 'l',
+}
+
+// homoglyphSequences maps letter pairs to the letters they imitate side by side.
+var homoglyphSequences = strings.NewReplacer("rn", "m", "vv", "w", "cl", "d")
+
+// PhishingDetector scores destinations of new links by phishing heuristics.
+// It flags hosts imitating configured brand names with punycode or look-alike characters,
+// hosts in suspicious top-level domains, IP address hosts and URLs with embedded credentials.
+type PhishingDetector struct {
+	brands []phishingBrand
+	tlds   map[string]struct{}
+}
+
+// phishingBrand is a brand name together with its homoglyph skeleton.
+type phishingBrand struct {
+	name     string
+	skeleton string
+}
+
+// NewPhishingDetector creates a new PhishingDetector instance.
+//
+// Parameters:
+//   - brands: brand names imitations of which are flagged, e.g. the name of the service
+//   - tlds: suspicious top-level domains, empty for defaultSuspiciousTLDs
+//
+// Returns:
+//   - *PhishingDetector: initialized phishing detector
+func NewPhishingDetector(brands []string, tlds []string) *PhishingDetector {
+	if len(tlds) == 0 {
+		tlds = defaultSuspiciousTLDs
+	}
+	d := &PhishingDetector{tlds: make(map[string]struct{}, len(tlds))}
+	for _, brand := range brands {
+		brand = strings.ToLower(strings.TrimSpace(brand))
+		if brand != "" {
+			d.brands = append(d.brands, phishingBrand{name: brand, skeleton: homoglyphSkeleton(brand)})
+		}
+	}
+	for _, tld := range tlds {
+		d.tlds[strings.TrimPrefix(strings.ToLower(strings.TrimSpace(tld)), ".")] = struct{}{}
+	}
+	return d
+}
+
+// Check applies the phishing heuristics to a destination URL.
+//
+// Parameters:
+//   - rawURL: destination URL of a new link
+//
+// Returns:
+//   - []model.ReviewReason: matched heuristics, nil if the URL looks safe or cannot be parsed
+func (d *PhishingDetector) Check(rawURL string) []model.ReviewReason {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	var reasons []model.ReviewReason
+	if parsed.User != nil {
+		reasons = append(reasons, model.ReviewEmbeddedCredentials)
+	}
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if host == "" {
+		return reasons
+	}
+	if isIPHost(host) {
+		return append(reasons, model.ReviewIPHost)
+	}
+
+	labels := strings.Split(host, ".")
+	if _, ok := d.tlds[labels[len(labels)-1]]; ok && len(labels) > 1 {
+		reasons = append(reasons, model.ReviewSuspiciousTLD)
+	}
+	if reason, ok := d.brandImitation(labels); ok {
+		reasons = append(reasons, reason)
+	}
+	return reasons
+}
+
+// brandImitation looks for a host label that reads as a brand name without spelling it.
+// Punycode labels are decoded first and reported as punycode imitations.
+//
+// Parameters:
+//   - labels: lower case labels of the host
+//
+// Returns:
+//   - model.ReviewReason: ReviewPunycodeBrand or ReviewHomoglyphBrand
+//   - bool: true if a label imitates a brand
+func (d *PhishingDetector) brandImitation(labels []string) (model.ReviewReason, bool) {
+	for _, label := range labels {
+		reason := model.ReviewHomoglyphBrand
+		if strings.HasPrefix(label, "xn--") {
+			decoded, err := idna.Lookup.ToUnicode(label)
+			if err != nil {
+				continue
+			}
+			label = strings.ToLower(decoded)
+			reason = model.ReviewPunycodeBrand
+		}
+		skeleton := homoglyphSkeleton(label)
+		for _, brand := range d.brands {
+			if strings.Contains(skeleton, brand.skeleton) && !strings.Contains(label, brand.name) {
+				return reason, true
+			}
+		}
+	}
+	return "", false
+}
+
+// EnablePhishingChecks makes the shortener score destinations of new links with the detector.
+// Flagged links are stored under review. Without it, links are never flagged.
+//
+// Parameters:
+//   - detector: phishing heuristics to apply
+func (u *URLShortener) EnablePhishingChecks(detector *PhishingDetector) {
+	u.phishingDetector = detector
+}
+
+// reviewReasons applies the phishing heuristics to a destination and logs flagged links.
+//
+// Parameters:
+//   - userID: identifier of the user creating the link
+//   - originalURL: destination of the link
+//
+// Returns:
+//   - []model.ReviewReason: matched heuristics, nil if phishing checks are not enabled
+func (u *URLShortener) reviewReasons(userID string, originalURL string) []model.ReviewReason {
+	if u.phishingDetector == nil {
+		return nil
+	}
+	reasons := u.phishingDetector.Check(originalURL)
+	if len(reasons) > 0 {
+		u.logger.Infoln("Link flagged for review",
+			zap.String("userID", userID),
+			zap.String("originalURL", originalURL),
+			zap.Any("reasons", reasons),
+		)
+	}
+	return reasons
+}
+
+// isIPHost reports whether a host is an IP address, including the numeric IPv4 forms browsers accept.
+//
+// Parameters:
+//   - host: lower case host without port
+//
+// Returns:
+//   - bool: true for hosts parseIPHost accepts
+func isIPHost(host string) bool {
+	return parseIPHost(host) != nil
+}
+
+// parseIPHost parses a host as an IP address the way browsers do.
+// Besides IPv6 and dotted decimal IPv4 literals, IPv4 addresses may have one to four parts,
+// each decimal, octal with a leading 0 or hex with a leading 0x, the last part filling the remaining bytes,
+// e.g. 127.1, 0x7f000001 and 2130706433 all name 127.0.0.1.
+//
+// Parameters:
+//   - host: lower case host without port
+//
+// Returns:
+//   - net.IP: parsed address, nil if the host is a name
+func parseIPHost(host string) net.IP {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip
+	}
+	parts := strings.Split(host, ".")
+	if len(parts) > 4 {
+		return nil
+	}
+	numbers := make([]uint64, len(parts))
+	for i, part := range parts {
+		base, digits := 10, part
+		switch {
+		case strings.HasPrefix(part, "0x"):
+			base, digits = 16, part[2:]
+		case len(part) > 1 && part[0] == '0':
+			base, digits = 8, part[1:]
+		}
+		number, err := strconv.ParseUint(digits, base, 32)
+		if err != nil && !(base == 16 && digits == "") {
+			return nil
+		}
+		numbers[i] = number
+	}
+
+	last := len(numbers) - 1
+	var address uint64
+	for i, number := range numbers[:last] {
+		if number > 0xff {
+			return nil
+		}
+		address |= number << (8 * (3 - i))
+	}
+	if numbers[last] >= 1<<(8*(4-last)) {
+		return nil
+	}
+	address |= numbers[last]
+	return net.IPv4(byte(address>>24), byte(address>>16), byte(address>>8), byte(address))
+}
+
+// homoglyphSkeleton replaces look-alike characters with the ASCII letters they imitate.
+//
+// Parameters:
+//   - label: lower case host label
+//
+// Returns:
+//   - string: label as a reader would likely read it
+func homoglyphSkeleton(label string) string {
+	mapped := strings.Map(func(r rune) rune {
+		if ascii, ok := homoglyphs[r]; ok {
+			return ascii
+		}
+		return r
+	}, label)
+	return homoglyphSequences.Replace(mapped)
+}
@@ -0,0 +1,247 @@
+// Package service provides business logic for URL shortening service.
+//
+//go:generate mockery --name=QuotaService --output=../mocks --case=underscore
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/repository"
+	"go.uber.org/zap"
+	"time"
+)
+
+// QuotaExceededError is returned when a request exceeds a limit of the user plan.
+type QuotaExceededError struct {
+	// Quota is the exceeded limit.
+	Quota model.QuotaName
+
+	// Limit is the value of the exceeded limit.
+	Limit int64
+}
+
+// Error returns the string representation of the quota error.
+// Implements the error interface.
+//
+// Returns:
+//   - string: error message naming the exceeded limit
+func (err *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s quota of %d exceeded", err.Quota, err.Limit)
+}
+
+// requestQuotaKey is the context key type for the quota read while serving a request.
+type requestQuotaKey struct{}
+
+// requestQuota holds the quota of a user read while serving a request.
+type requestQuota struct {
+	userID string
+	quota  *model.Quota
+}
+
+// WithRequestQuota returns a context that keeps the quota read by quota checks of the request,
+// so that RequestQuota reports it without reading it again.
+//
+// Parameters:
+//   - ctx: request context
+//
+// Returns:
+//   - context.Context: context keeping the quota of the request
+func WithRequestQuota(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestQuotaKey{}, &requestQuota{})
+}
+
+// RequestQuota returns the quota of the user read by quota checks of the request, including links created since.
+//
+// Parameters:
+//   - ctx: request context prepared by WithRequestQuota
+//   - userID: identifier of the user
+//
+// Returns:
+//   - *model.Quota: quota of the user, nil if the request did not read it
+func RequestQuota(ctx context.Context, userID string) *model.Quota {
+	held, _ := ctx.Value(requestQuotaKey{}).(*requestQuota)
+	if held == nil || held.userID != userID {
+		return nil
+	}
+	return held.quota
+}
+
+// QuotaService defines the interface for reading limits and usage of users.
+type QuotaService interface {
+	// GetQuota retrieves the limits of a user together with the current usage.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the user
+	//
+	// Returns:
+	//   - *model.Quota: limits and usage, zero limits are unlimited
+	//   - error: error if lookup fails
+	GetQuota(ctx context.Context, userID string) (*model.Quota, error)
+}
+
+// EnableQuotas makes the shortener enforce limits of user plans kept in storage.
+// Users without own limits get the default limits. Without it, users are unlimited.
+// Quotas are counted per user, so they do not limit clients creating links as new anonymous users:
+// every request without credentials gets a fresh user with unused quotas. Such clients are limited
+// by the per-IP rate limits only.
+//
+// Parameters:
+//   - defaults: limits of users without own limits, zero limits are unlimited
+func (u *URLShortener) EnableQuotas(defaults model.QuotaLimits) {
+	u.quotasEnabled = true
+	u.defaultQuota = defaults
+}
+
+// GetQuota retrieves the limits of a user together with the current usage.
+// Links are counted per UTC day.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//
+// Returns:
+//   - *model.Quota: limits and usage, zero limits if quotas are not enabled
+//   - error: error if lookup fails
+func (u *URLShortener) GetQuota(ctx context.Context, userID string) (*model.Quota, error) {
+	if !u.quotasEnabled {
+		return &model.Quota{}, nil
+	}
+	limits, err := u.quotaLimits(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := u.storage.GetQuotaUsage(ctx, userID, quotaDay(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	return &model.Quota{Limits: limits, Usage: *usage}, nil
+}
+
+// reserveLinks verifies that the user may create more links and reserves them in storage,
+// so that concurrent requests of the user cannot exceed the limits together.
+// The returned function must be called once the links are created or could not be created.
+// The quota is kept in the request context, see WithRequestQuota.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user creating links
+//   - count: number of links to create
+//
+// Returns:
+//   - func(created int64): ends the reservation, created is the number of links actually created
+//   - error: *QuotaExceededError if a limit would be exceeded, or storage error
+func (u *URLShortener) reserveLinks(ctx context.Context, userID string, count int64) (func(created int64), error) {
+	if !u.quotasEnabled {
+		return func(int64) {}, nil
+	}
+	limits, err := u.quotaLimits(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	day := quotaDay(time.Now())
+	usage, reserved, err := u.storage.ReserveLinks(ctx, userID, day, count, limits)
+	if err != nil {
+		return nil, err
+	}
+	quota := &model.Quota{Limits: limits, Usage: *usage}
+	if held, _ := ctx.Value(requestQuotaKey{}).(*requestQuota); held != nil {
+		held.userID, held.quota = userID, quota
+	}
+	if !reserved {
+		if remaining, limited := quota.RemainingLinksToday(); limited && count > remaining {
+			return nil, &QuotaExceededError{Quota: model.QuotaLinksPerDay, Limit: limits.LinksPerDay}
+		}
+		return nil, &QuotaExceededError{Quota: model.QuotaActiveLinks, Limit: limits.ActiveLinks}
+	}
+	return func(created int64) {
+		u.releaseLinks(ctx, userID, day, count, created)
+	}, nil
+}
+
+// checkBatchQuota verifies that a batch request does not exceed the batch size limit of the user.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user sending the batch
+//   - quotaName: QuotaBatchSize or QuotaDeleteBatchSize
+//   - size: number of links in the batch
+//
+// Returns:
+//   - error: *QuotaExceededError if the batch is too large, or storage error
+func (u *URLShortener) checkBatchQuota(ctx context.Context,
+	userID string,
+	quotaName model.QuotaName,
+	size int,
+) error {
+	if !u.quotasEnabled {
+		return nil
+	}
+	limits, err := u.quotaLimits(ctx, userID)
+	if err != nil {
+		return err
+	}
+	limit := limits.BatchSize
+	if quotaName == model.QuotaDeleteBatchSize {
+		limit = limits.DeleteBatchSize
+	}
+	if limit > 0 && int64(size) > limit {
+		return &QuotaExceededError{Quota: quotaName, Limit: limit}
+	}
+	return nil
+}
+
+// releaseLinks ends a reservation of links and adds the created links to the quota kept in the request context.
+// Links that were not created are no longer counted against the daily limit.
+// Failures are logged only, the links are already stored.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user who reserved the links
+//   - day: UTC day the links were reserved in
+//   - reserved: number of reserved links
+//   - created: number of created links
+func (u *URLShortener) releaseLinks(ctx context.Context, userID string, day time.Time, reserved int64, created int64) {
+	if quota := RequestQuota(ctx, userID); quota != nil {
+		quota.Usage.LinksToday += created
+		quota.Usage.ActiveLinks += created
+	}
+	err := u.storage.ReleaseLinks(context.WithoutCancel(ctx), userID, day, reserved, reserved-created)
+	if err != nil {
+		u.logger.Error("Failed to release reserved links", zap.Error(err), zap.String("userID", userID))
+	}
+}
+
+// quotaLimits returns the own limits of the user, or the default limits without them.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//
+// Returns:
+//   - model.QuotaLimits: limits of the user
+//   - error: error if lookup fails
+func (u *URLShortener) quotaLimits(ctx context.Context, userID string) (model.QuotaLimits, error) {
+	limits, err := u.storage.GetUserQuotaLimits(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return u.defaultQuota, nil
+	}
+	if err != nil {
+		return model.QuotaLimits{}, err
+	}
+	return *limits, nil
+}
+
+// quotaDay returns the UTC day links created at the given time are counted for.
+//
+// Parameters:
+//   - t: time of creation
+//
+// Returns:
+//   - time.Time: midnight UTC of the day
+func quotaDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
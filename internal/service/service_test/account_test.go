@@ -0,0 +1,145 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/mocks"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/repository"
+	"github.com/bezjen/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUserAccountService_RegisterAndLogin(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	accounts := service.NewUserAccountService(repository.NewInMemoryRepository(), testLogger)
+
+	user, err := accounts.Register(context.Background(), "", " alice ", "secret-password")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, user.ID)
+	assert.Equal(t, "alice", user.Login)
+	assert.True(t, strings.HasPrefix(user.PasswordHash, "pbkdf2-sha256$"))
+	assert.NotContains(t, user.PasswordHash, "secret-password")
+
+	other, err := accounts.Register(context.Background(), "", "bob", "secret-password")
+	assert.NoError(t, err)
+	assert.NotEqual(t, user.PasswordHash, other.PasswordHash, "Hashes of equal passwords should use different salts")
+
+	loggedIn, err := accounts.Login(context.Background(), "", "alice", "secret-password")
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, loggedIn.ID)
+
+	_, err = accounts.Login(context.Background(), "", "alice", "wrong-password")
+	assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+
+	_, err = accounts.Login(context.Background(), "", "carol", "secret-password")
+	assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+
+	_, err = accounts.Register(context.Background(), "", "alice", "another-password")
+	assert.ErrorIs(t, err, repository.ErrLoginConflict)
+}
+
+func TestUserAccountService_RegisterValidation(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	accounts := service.NewUserAccountService(mockRepo, testLogger)
+
+	_, err := accounts.Register(context.Background(), "", "  ", "secret-password")
+	assert.ErrorIs(t, err, service.ErrEmptyLogin)
+
+	_, err = accounts.Register(context.Background(), "", "alice", "short")
+	assert.ErrorIs(t, err, service.ErrPasswordTooShort)
+
+	mockRepo.AssertNotCalled(t, "SaveUser", mock.Anything, mock.Anything)
+}
+
+func TestUserAccountService_ClaimsAnonymousURLs(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	accounts := service.NewUserAccountService(mockRepo, testLogger)
+
+	mockRepo.On("SaveUser", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("GetUserByID", mock.Anything, "anonymous-user").Return(nil, repository.ErrNotFound)
+	mockRepo.On("MergeUserURLs", mock.Anything, "anonymous-user", mock.Anything).Return(nil)
+
+	user, err := accounts.Register(context.Background(), "anonymous-user", "alice", "secret-password")
+	assert.NoError(t, err)
+	mockRepo.AssertCalled(t, "MergeUserURLs", mock.Anything, "anonymous-user", user.ID)
+}
+
+func TestUserAccountService_LoginClaimsAnonymousURLs(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	storage := repository.NewInMemoryRepository()
+	accounts := service.NewUserAccountService(storage, testLogger)
+	alice, err := accounts.Register(context.Background(), "", "alice", "secret-password")
+	assert.NoError(t, err)
+	bob, err := accounts.Register(context.Background(), "", "bob", "secret-password")
+	assert.NoError(t, err)
+
+	mockRepo := new(mocks.Repository)
+	accounts = service.NewUserAccountService(mockRepo, testLogger)
+	mockRepo.On("GetUserByLogin", mock.Anything, "alice").Return(alice, nil)
+	mockRepo.On("GetUserByID", mock.Anything, "anonymous-user").Return(nil, repository.ErrNotFound)
+	mockRepo.On("GetUserByID", mock.Anything, bob.ID).Return(bob, nil)
+	mockRepo.On("MergeUserURLs", mock.Anything, "anonymous-user", alice.ID).Return(nil)
+
+	_, err = accounts.Login(context.Background(), "anonymous-user", "alice", "secret-password")
+	assert.NoError(t, err)
+	mockRepo.AssertCalled(t, "MergeUserURLs", mock.Anything, "anonymous-user", alice.ID)
+
+	_, err = accounts.Login(context.Background(), bob.ID, "alice", "secret-password")
+	assert.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "MergeUserURLs", mock.Anything, bob.ID, alice.ID)
+
+	_, err = accounts.Login(context.Background(), alice.ID, "alice", "secret-password")
+	assert.NoError(t, err)
+	mockRepo.AssertNumberOfCalls(t, "MergeUserURLs", 1)
+}
+
+func TestUserAccountService_LoginStorageError(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	accounts := service.NewUserAccountService(mockRepo, testLogger)
+
+	mockRepo.On("GetUserByLogin", mock.Anything, "alice").Return(nil, errors.New("db error"))
+
+	_, err := accounts.Login(context.Background(), "", "alice", "secret-password")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, service.ErrInvalidCredentials)
+}
+
+func TestUserAccountService_LoginRejectsMalformedHash(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	accounts := service.NewUserAccountService(mockRepo, testLogger)
+
+	mockRepo.On("GetUserByLogin", mock.Anything, "alice").
+		Return(model.NewUser("user-1", "alice", "plain-text-password"), nil)
+
+	_, err := accounts.Login(context.Background(), "", "alice", "plain-text-password")
+	assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+}
+
+func TestUserAccountService_LoginUnknownAccountHashesPassword(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	accounts := service.NewUserAccountService(repository.NewInMemoryRepository(), testLogger)
+	_, err := accounts.Register(context.Background(), "", "alice", "secret-password")
+	assert.NoError(t, err)
+
+	start := time.Now()
+	_, err = accounts.Login(context.Background(), "", "alice", "wrong-password")
+	wrongPassword := time.Since(start)
+	assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+
+	start = time.Now()
+	_, err = accounts.Login(context.Background(), "", "carol", "wrong-password")
+	unknownLogin := time.Since(start)
+	assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+
+	assert.Greater(t, unknownLogin, wrongPassword/4, "Unknown logins should run the password hash as well")
+}
@@ -0,0 +1,251 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/mocks"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/repository"
+	"github.com/bezjen/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"strings"
+	"testing"
+)
+
+func TestShortenerAdminService_SearchURLs(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	storage := new(mocks.Repository)
+	audit := new(mocks.AuditService)
+	admin := service.NewShortenerAdminService(storage, nil, audit, testLogger)
+
+	found := []model.AdminURL{{ShortURL: "abc123", OriginalURL: "https://example.com", UserID: "user-1"}}
+	storage.On("SearchURLs", mock.Anything, model.URLSearchFilter{OriginalURL: "example", Limit: 100}).
+		Return(found, nil)
+	audit.On("NotifyAll", mock.MatchedBy(func(event model.AuditEvent) bool {
+		return event.Action == model.ActionAdminSearch && event.UserID == "admin-1" &&
+			event.Target == "destination=example"
+	})).Return()
+
+	urls, err := admin.SearchURLs(context.Background(), "admin-1", model.URLSearchFilter{OriginalURL: "example"})
+	assert.NoError(t, err)
+	assert.Equal(t, found, urls)
+
+	for _, filter := range []model.URLSearchFilter{{Limit: -1}, {Limit: 1001}, {Offset: -1}} {
+		_, err = admin.SearchURLs(context.Background(), "admin-1", filter)
+		assert.ErrorIs(t, err, service.ErrInvalidSearchLimit)
+	}
+	storage.AssertExpectations(t)
+	audit.AssertExpectations(t)
+}
+
+func TestShortenerAdminService_SetURLDisabled(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	storage := new(mocks.Repository)
+	audit := new(mocks.AuditService)
+	admin := service.NewShortenerAdminService(storage, nil, audit, testLogger)
+
+	storage.On("SetURLDisabled", mock.Anything, "go.brand.com", "abc123", true).Return(nil)
+	storage.On("SetURLDisabled", mock.Anything, "", "abc123", false).Return(nil)
+	storage.On("SetURLDisabled", mock.Anything, "", "missing", true).Return(repository.ErrNotFound)
+	audit.On("NotifyAll", mock.MatchedBy(func(event model.AuditEvent) bool {
+		return event.Action == model.ActionAdminDisable && event.Target == "go.brand.com/abc123"
+	})).Return().Once()
+	audit.On("NotifyAll", mock.MatchedBy(func(event model.AuditEvent) bool {
+		return event.Action == model.ActionAdminEnable && event.Target == "abc123"
+	})).Return().Once()
+
+	assert.NoError(t, admin.SetURLDisabled(context.Background(), "admin-1", "go.brand.com", "abc123", true))
+	assert.NoError(t, admin.SetURLDisabled(context.Background(), "admin-1", "", "abc123", false))
+	assert.ErrorIs(t, admin.SetURLDisabled(context.Background(), "admin-1", "", "missing", true), repository.ErrNotFound)
+	storage.AssertExpectations(t)
+	audit.AssertExpectations(t)
+}
+
+func TestShortenerAdminService_ClearURLReview(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	storage := new(mocks.Repository)
+	audit := new(mocks.AuditService)
+	admin := service.NewShortenerAdminService(storage, nil, audit, testLogger)
+
+	storage.On("ClearURLReview", mock.Anything, "go.brand.com", "abc123").Return(nil)
+	storage.On("ClearURLReview", mock.Anything, "", "missing").Return(repository.ErrNotFound)
+	audit.On("NotifyAll", mock.MatchedBy(func(event model.AuditEvent) bool {
+		return event.Action == model.ActionAdminClearReview && event.Target == "go.brand.com/abc123"
+	})).Return().Once()
+
+	assert.NoError(t, admin.ClearURLReview(context.Background(), "admin-1", "go.brand.com", "abc123"))
+	assert.ErrorIs(t, admin.ClearURLReview(context.Background(), "admin-1", "", "missing"), repository.ErrNotFound)
+	storage.AssertExpectations(t)
+	audit.AssertExpectations(t)
+}
+
+func TestShortenerAdminService_ReportURL(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	storage := new(mocks.Repository)
+	audit := new(mocks.AuditService)
+	admin := service.NewShortenerAdminService(storage, nil, audit, testLogger)
+
+	storage.On("SaveAbuseReport", mock.Anything, mock.MatchedBy(func(report model.AbuseReport) bool {
+		return report.ID != "" && report.Domain == "go.brand.com" && report.ShortURL == "abc123" &&
+			report.Reason == model.ReportPhishing && report.ReporterID == "user-1" &&
+			report.Status == model.ReportOpen && !report.CreatedAt.IsZero()
+	})).Return(nil).Once()
+	storage.On("SaveAbuseReport", mock.Anything, mock.MatchedBy(func(report model.AbuseReport) bool {
+		return report.ShortURL == "missing"
+	})).Return(repository.ErrNotFound).Once()
+	audit.On("NotifyAll", mock.MatchedBy(func(event model.AuditEvent) bool {
+		return event.Action == model.ActionReport && event.UserID == "user-1" && event.Target == "go.brand.com/abc123"
+	})).Return().Once()
+
+	report, err := admin.ReportURL(context.Background(), "user-1", "go.brand.com", "abc123",
+		model.AbuseReportRequest{Reason: model.ReportPhishing, Details: "fake bank login"})
+	assert.NoError(t, err)
+	assert.Equal(t, "fake bank login", report.Details)
+	assert.Equal(t, model.ReportOpen, report.Status)
+
+	_, err = admin.ReportURL(context.Background(), "", "", "missing",
+		model.AbuseReportRequest{Reason: model.ReportSpam})
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	_, err = admin.ReportURL(context.Background(), "", "", "abc123",
+		model.AbuseReportRequest{Reason: "annoying"})
+	assert.ErrorIs(t, err, service.ErrInvalidReportReason)
+	_, err = admin.ReportURL(context.Background(), "", "", "abc123",
+		model.AbuseReportRequest{Reason: model.ReportOther, Details: strings.Repeat("ж", 1001)})
+	assert.ErrorIs(t, err, service.ErrReportDetailsTooLong)
+	storage.AssertExpectations(t)
+	audit.AssertExpectations(t)
+}
+
+func TestShortenerAdminService_GetAbuseReports(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	storage := new(mocks.Repository)
+	audit := new(mocks.AuditService)
+	admin := service.NewShortenerAdminService(storage, nil, audit, testLogger)
+
+	found := []model.AbuseReport{{ID: "report-1", ShortURL: "abc123", Reason: model.ReportSpam}}
+	storage.On("GetAbuseReports", mock.Anything, model.AbuseReportFilter{Status: model.ReportOpen, Limit: 100}).
+		Return(found, nil)
+	audit.On("NotifyAll", mock.MatchedBy(func(event model.AuditEvent) bool {
+		return event.Action == model.ActionAdminSearch && event.UserID == "admin-1" &&
+			event.Target == "reports?status=open"
+	})).Return().Once()
+
+	reports, err := admin.GetAbuseReports(context.Background(), "admin-1", model.AbuseReportFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, found, reports)
+
+	_, err = admin.GetAbuseReports(context.Background(), "admin-1", model.AbuseReportFilter{Status: "closed"})
+	assert.ErrorIs(t, err, service.ErrInvalidReportStatus)
+	for _, filter := range []model.AbuseReportFilter{{Limit: -1}, {Limit: 1001}, {Offset: -1}} {
+		_, err = admin.GetAbuseReports(context.Background(), "admin-1", filter)
+		assert.ErrorIs(t, err, service.ErrInvalidSearchLimit)
+	}
+	storage.AssertExpectations(t)
+	audit.AssertExpectations(t)
+}
+
+func TestShortenerAdminService_DismissAbuseReport(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	storage := new(mocks.Repository)
+	audit := new(mocks.AuditService)
+	admin := service.NewShortenerAdminService(storage, nil, audit, testLogger)
+
+	storage.On("DismissAbuseReport", mock.Anything, "report-1", "admin-1", mock.AnythingOfType("time.Time")).
+		Return(nil)
+	storage.On("DismissAbuseReport", mock.Anything, "missing", "admin-1", mock.AnythingOfType("time.Time")).
+		Return(repository.ErrNotFound)
+	audit.On("NotifyAll", mock.MatchedBy(func(event model.AuditEvent) bool {
+		return event.Action == model.ActionAdminDismissReport && event.UserID == "admin-1" &&
+			event.Target == "report-1"
+	})).Return().Once()
+
+	assert.NoError(t, admin.DismissAbuseReport(context.Background(), "admin-1", "report-1"))
+	assert.ErrorIs(t, admin.DismissAbuseReport(context.Background(), "admin-1", "missing"), repository.ErrNotFound)
+	storage.AssertExpectations(t)
+	audit.AssertExpectations(t)
+}
+
+func TestShortenerAdminService_TakeDownReportedURL(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	storage := new(mocks.Repository)
+	audit := new(mocks.AuditService)
+	admin := service.NewShortenerAdminService(storage, nil, audit, testLogger)
+
+	storage.On("GetAbuseReport", mock.Anything, "report-1").
+		Return(&model.AbuseReport{ID: "report-1", Domain: "go.brand.com", ShortURL: "abc123"}, nil)
+	storage.On("GetAbuseReport", mock.Anything, "missing").Return(nil, repository.ErrNotFound)
+	storage.On("TakeDownURL", mock.Anything, "go.brand.com", "abc123", model.TakedownLegal, "admin-1",
+		mock.AnythingOfType("time.Time")).Return(nil).Once()
+	audit.On("NotifyAll", mock.MatchedBy(func(event model.AuditEvent) bool {
+		return event.Action == model.ActionAdminTakedown && event.UserID == "admin-1" &&
+			event.Target == "go.brand.com/abc123"
+	})).Return().Once()
+
+	assert.NoError(t, admin.TakeDownReportedURL(context.Background(), "admin-1", "report-1", model.TakedownLegal))
+	assert.ErrorIs(t, admin.TakeDownReportedURL(context.Background(), "admin-1", "missing", model.TakedownAbuse),
+		repository.ErrNotFound)
+	assert.ErrorIs(t, admin.TakeDownReportedURL(context.Background(), "admin-1", "report-1", "spam"),
+		service.ErrInvalidTakedownReason)
+	storage.AssertExpectations(t)
+	audit.AssertExpectations(t)
+}
+
+func TestShortenerAdminService_Users(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	storage := new(mocks.Repository)
+	authorizer := new(mocks.Authorizer)
+	audit := new(mocks.AuditService)
+	admin := service.NewShortenerAdminService(storage, authorizer, audit, testLogger)
+
+	storage.On("SetUserBanned", mock.Anything, "user-1", true).Return(nil)
+	storage.On("IsUserBanned", mock.Anything, "user-1").Return(true, nil)
+	storage.On("GetUserUsage", mock.Anything, "user-1").Return(&model.UserUsage{UserID: "user-1", Links: 2}, nil)
+	authorizer.On("RevokeUserTokens", mock.Anything, "user-1").Return(nil)
+	authorizer.On("RevokeUserTokens", mock.Anything, "user-2").Return(errors.New("db error"))
+	for _, action := range []model.AuditAction{
+		model.ActionAdminBan, model.ActionAdminUsage, model.ActionAdminRevokeTokens,
+	} {
+		audit.On("NotifyAll", mock.MatchedBy(func(event model.AuditEvent) bool {
+			return event.Action == action && event.UserID == "admin-1" && event.Target == "user-1"
+		})).Return().Once()
+	}
+
+	assert.NoError(t, admin.SetUserBanned(context.Background(), "admin-1", "user-1", true))
+	banned, err := admin.IsUserBanned(context.Background(), "user-1")
+	assert.NoError(t, err)
+	assert.True(t, banned)
+	usage, err := admin.GetUserUsage(context.Background(), "admin-1", "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), usage.Links)
+	assert.NoError(t, admin.RevokeUserTokens(context.Background(), "admin-1", "user-1"))
+	assert.Error(t, admin.RevokeUserTokens(context.Background(), "admin-1", "user-2"))
+
+	assert.ErrorIs(t, admin.SetUserBanned(context.Background(), "admin-1", "", true), service.ErrEmptyUserID)
+	_, err = admin.GetUserUsage(context.Background(), "admin-1", "")
+	assert.ErrorIs(t, err, service.ErrEmptyUserID)
+	storage.AssertExpectations(t)
+	authorizer.AssertExpectations(t)
+	audit.AssertExpectations(t)
+}
+
+func TestShortenerAdminService_SetUserQuotaLimits(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	storage := new(mocks.Repository)
+	audit := new(mocks.AuditService)
+	admin := service.NewShortenerAdminService(storage, nil, audit, testLogger)
+
+	limits := model.QuotaLimits{LinksPerDay: 500, BatchSize: 100}
+	storage.On("SaveUserQuotaLimits", mock.Anything, "user-1", limits).Return(nil)
+	audit.On("NotifyAll", mock.MatchedBy(func(event model.AuditEvent) bool {
+		return event.Action == model.ActionAdminQuota && event.UserID == "admin-1" && event.Target == "user-1"
+	})).Return().Once()
+
+	assert.NoError(t, admin.SetUserQuotaLimits(context.Background(), "admin-1", "user-1", limits))
+	assert.ErrorIs(t, admin.SetUserQuotaLimits(context.Background(), "admin-1", "", limits), service.ErrEmptyUserID)
+	assert.ErrorIs(t, admin.SetUserQuotaLimits(context.Background(), "admin-1", "user-1",
+		model.QuotaLimits{ActiveLinks: -1}), service.ErrInvalidQuotaLimits)
+	storage.AssertExpectations(t)
+	audit.AssertExpectations(t)
+}
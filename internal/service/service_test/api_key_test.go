@@ -0,0 +1,117 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/mocks"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/repository"
+	"github.com/bezjen/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUserAPIKeyService_Lifecycle(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	storage := repository.NewInMemoryRepository()
+	apiKeys := service.NewUserAPIKeyService(storage, testLogger)
+
+	key, secret, err := apiKeys.CreateAPIKey(context.Background(), "user-1", " CI ",
+		[]model.APIKeyScope{model.ScopeRead, model.ScopeWrite, model.ScopeRead}, 0)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(secret, "shk_"))
+	assert.Equal(t, "CI", key.Name)
+	assert.Equal(t, secret[:8], key.Prefix)
+	assert.Equal(t, []model.APIKeyScope{model.ScopeRead, model.ScopeWrite}, key.Scopes)
+	assert.Equal(t, service.DefaultAPIKeyRateLimit, key.RateLimit)
+	assert.NotContains(t, key.KeyHash, secret)
+	assert.Nil(t, key.LastUsedAt)
+
+	authenticated, err := apiKeys.AuthenticateAPIKey(context.Background(), secret)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", authenticated.UserID)
+	assert.NotNil(t, authenticated.LastUsedAt)
+
+	keys, err := apiKeys.GetUserAPIKeys(context.Background(), "user-1")
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.NotNil(t, keys[0].LastUsedAt, "Last used time should be stored")
+
+	_, err = apiKeys.AuthenticateAPIKey(context.Background(), "shk_unknown")
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKey)
+	_, err = apiKeys.AuthenticateAPIKey(context.Background(), "not-a-key")
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKey)
+
+	assert.ErrorIs(t, apiKeys.RevokeAPIKey(context.Background(), "user-2", key.ID), repository.ErrNotFound)
+	assert.NoError(t, apiKeys.RevokeAPIKey(context.Background(), "user-1", key.ID))
+	_, err = apiKeys.AuthenticateAPIKey(context.Background(), secret)
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKey)
+}
+
+func TestUserAPIKeyService_RateLimit(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	apiKeys := service.NewUserAPIKeyService(repository.NewInMemoryRepository(), testLogger)
+
+	_, limited, err := apiKeys.CreateAPIKey(context.Background(), "user-1", "limited", []model.APIKeyScope{model.ScopeRead}, 2)
+	assert.NoError(t, err)
+	_, other, err := apiKeys.CreateAPIKey(context.Background(), "user-1", "other", []model.APIKeyScope{model.ScopeRead}, 2)
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err = apiKeys.AuthenticateAPIKey(context.Background(), limited)
+		assert.NoError(t, err)
+	}
+	_, err = apiKeys.AuthenticateAPIKey(context.Background(), limited)
+	var rateLimitErr *service.APIKeyRateLimitError
+	assert.True(t, errors.As(err, &rateLimitErr))
+	assert.True(t, rateLimitErr.RetryAfter > 0 && rateLimitErr.RetryAfter <= time.Minute)
+
+	_, err = apiKeys.AuthenticateAPIKey(context.Background(), other)
+	assert.NoError(t, err, "Rate limits should be counted per key")
+}
+
+func TestUserAPIKeyService_CreateValidation(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	apiKeys := service.NewUserAPIKeyService(mockRepo, testLogger)
+
+	_, _, err := apiKeys.CreateAPIKey(context.Background(), "user-1", " ", []model.APIKeyScope{model.ScopeRead}, 0)
+	assert.ErrorIs(t, err, service.ErrEmptyAPIKeyName)
+
+	_, _, err = apiKeys.CreateAPIKey(context.Background(), "user-1", "CI", nil, 0)
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKeyScope)
+
+	_, _, err = apiKeys.CreateAPIKey(context.Background(), "user-1", "CI", []model.APIKeyScope{"admin"}, 0)
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKeyScope)
+
+	_, _, err = apiKeys.CreateAPIKey(context.Background(), "user-1", "CI", []model.APIKeyScope{model.ScopeRead}, -1)
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKeyRateLimit)
+
+	_, _, err = apiKeys.CreateAPIKey(context.Background(), "user-1", "CI", []model.APIKeyScope{model.ScopeRead}, 1000000)
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKeyRateLimit)
+
+	mockRepo.AssertNotCalled(t, "SaveAPIKey", mock.Anything, mock.Anything)
+}
+
+func TestUserAPIKeyService_AuthenticateIgnoresLastUsedError(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	apiKeys := service.NewUserAPIKeyService(mockRepo, testLogger)
+
+	key := &model.APIKey{ID: "key-1", UserID: "user-1", Scopes: []model.APIKeyScope{model.ScopeRead}, RateLimit: 10}
+	mockRepo.On("GetAPIKeyByHash", mock.Anything, mock.Anything).Return(key, nil)
+	mockRepo.On("UpdateAPIKeyLastUsed", mock.Anything, "key-1", mock.Anything).Return(errors.New("db error"))
+
+	authenticated, err := apiKeys.AuthenticateAPIKey(context.Background(), "shk_secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", authenticated.UserID)
+
+	mockRepo.ExpectedCalls = nil
+	mockRepo.On("GetAPIKeyByHash", mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+	_, err = apiKeys.AuthenticateAPIKey(context.Background(), "shk_secret")
+	assert.EqualError(t, err, "db error")
+}
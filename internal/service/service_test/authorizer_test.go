@@ -1,11 +1,17 @@
 package service_test
 
 import (
+	"context"
 	"errors"
 	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/mocks"
+	"github.com/bezjen/shortener/internal/repository"
 	"github.com/bezjen/shortener/internal/service"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"os"
 	"testing"
 	"time"
 )
@@ -72,7 +78,7 @@ func TestValidateTokenSuccess(t *testing.T) {
 	token, err := authorizer.CreateToken(userID)
 	assert.NoError(t, err)
 
-	validatedUserID, err := authorizer.ValidateToken(token)
+	validatedUserID, err := authorizer.ValidateToken(context.Background(), token)
 
 	assert.NoError(t, err)
 	assert.Equal(t, userID, validatedUserID)
@@ -103,7 +109,7 @@ func TestValidateTokenError(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			userID, err := authorizer.ValidateToken(tt.tokenString)
+			userID, err := authorizer.ValidateToken(context.Background(), tt.tokenString)
 
 			assert.Error(t, err)
 			assert.True(t, errors.Is(err, service.ErrValidate))
@@ -132,7 +138,7 @@ func TestValidateToken_ExpiredToken(t *testing.T) {
 	tokenString, err := token.SignedString(secretKey)
 	assert.NoError(t, err)
 
-	userID, err := authorizer.ValidateToken(tokenString)
+	userID, err := authorizer.ValidateToken(context.Background(), tokenString)
 	assert.Error(t, err)
 	assert.True(t, errors.Is(err, service.ErrValidate))
 	assert.Empty(t, userID)
@@ -157,7 +163,7 @@ func TestValidateToken_InvalidSignature(t *testing.T) {
 	tokenString, err := token.SignedString([]byte("wrong-secret"))
 	assert.NoError(t, err)
 
-	userID, err := authorizer.ValidateToken(tokenString)
+	userID, err := authorizer.ValidateToken(context.Background(), tokenString)
 	assert.Error(t, err)
 	assert.True(t, errors.Is(err, service.ErrValidate))
 	assert.Empty(t, userID)
@@ -174,8 +180,194 @@ func TestValidateToken_InvalidMethod(t *testing.T) {
 
 	if err == nil {
 		// Если по какой-то причине удалось подписать, проверяем валидацию
-		_, err = authorizer.ValidateToken(tokenString)
+		_, err = authorizer.ValidateToken(context.Background(), tokenString)
 		assert.Error(t, err)
 		assert.True(t, errors.Is(err, service.ErrValidate))
 	}
 }
+
+func TestKeySetAuthorizer_Rotation(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	oldPath, _ := generateEdKeyFiles(t)
+	newPath, _ := generateEdKeyFiles(t)
+	secretKey := []byte("legacy-secret")
+
+	legacyToken, err := service.NewAuthorizer(secretKey, testLogger).CreateToken("legacy-user")
+	require.NoError(t, err)
+
+	oldSet, err := service.LoadKeySet([]string{"old=" + oldPath}, "old")
+	require.NoError(t, err)
+	oldToken, err := service.NewKeySetAuthorizer(oldSet, nil, testLogger).CreateToken("old-user")
+	require.NoError(t, err)
+
+	rotatedSet, err := service.LoadKeySet([]string{"old=" + oldPath, "new=" + newPath}, "new")
+	require.NoError(t, err)
+	authorizer := service.NewKeySetAuthorizer(rotatedSet, secretKey, testLogger)
+	newToken, err := authorizer.CreateToken("new-user")
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &service.ShortenerClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "new", parsed.Header["kid"])
+	assert.Equal(t, "EdDSA", parsed.Header["alg"])
+
+	for token, expectedUserID := range map[string]string{
+		legacyToken: "legacy-user",
+		oldToken:    "old-user",
+		newToken:    "new-user",
+	} {
+		userID, err := authorizer.ValidateToken(context.Background(), token)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedUserID, userID)
+	}
+
+	_, err = service.NewKeySetAuthorizer(rotatedSet, nil, testLogger).ValidateToken(context.Background(), legacyToken)
+	assert.ErrorIs(t, err, service.ErrValidate, "Tokens without kid should be rejected without secret key")
+
+	_, err = service.NewAuthorizer(secretKey, testLogger).ValidateToken(context.Background(), newToken)
+	assert.ErrorIs(t, err, service.ErrValidate, "Tokens with kid should be rejected without key set")
+
+	assert.Len(t, authorizer.JWKS().Keys, 2)
+	assert.Empty(t, service.NewAuthorizer(secretKey, testLogger).JWKS().Keys)
+}
+
+func TestKeySetAuthorizer_RejectsForgedTokens(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	keyPath, publicPath := generateEdKeyFiles(t)
+	keySet, err := service.LoadKeySet([]string{"main=" + keyPath}, "main")
+	require.NoError(t, err)
+	authorizer := service.NewKeySetAuthorizer(keySet, nil, testLogger)
+
+	publicPEM, err := os.ReadFile(publicPath)
+	require.NoError(t, err)
+	claims := service.ShortenerClaims{
+		UserID:           "attacker",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	}
+
+	// HMAC token signed with the public key as secret must not be accepted for the EdDSA key
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	confused.Header["kid"] = "main"
+	confusedString, err := confused.SignedString(publicPEM)
+	require.NoError(t, err)
+	_, err = authorizer.ValidateToken(context.Background(), confusedString)
+	assert.ErrorIs(t, err, service.ErrValidate)
+
+	unknown := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	unknown.Header["kid"] = "unknown"
+	unknownString, err := unknown.SignedString([]byte("secret"))
+	require.NoError(t, err)
+	_, err = authorizer.ValidateToken(context.Background(), unknownString)
+	assert.ErrorIs(t, err, service.ErrValidate)
+}
+
+func TestAuthorizer_RevokeToken(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	authorizer := service.NewAuthorizer([]byte("test-secret-key"), testLogger)
+	authorizer.EnableRevocation(repository.NewInMemoryRepository())
+
+	first, err := authorizer.CreateToken("user-1")
+	require.NoError(t, err)
+	second, err := authorizer.CreateToken("user-1")
+	require.NoError(t, err)
+
+	require.NoError(t, authorizer.RevokeToken(context.Background(), first))
+
+	_, err = authorizer.ValidateToken(context.Background(), first)
+	assert.ErrorIs(t, err, service.ErrValidate)
+	userID, err := authorizer.ValidateToken(context.Background(), second)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", userID)
+
+	assert.ErrorIs(t, authorizer.RevokeToken(context.Background(), "invalid.token.string"), service.ErrValidate)
+}
+
+func TestAuthorizer_RevokeUserTokens(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	storage := repository.NewInMemoryRepository()
+	authorizer := service.NewAuthorizer([]byte("test-secret-key"), testLogger)
+	authorizer.EnableRevocation(storage)
+
+	userToken, err := authorizer.CreateToken("user-1")
+	require.NoError(t, err)
+	otherToken, err := authorizer.CreateToken("user-2")
+	require.NoError(t, err)
+
+	require.NoError(t, authorizer.RevokeUserTokens(context.Background(), "user-1"))
+
+	_, err = authorizer.ValidateToken(context.Background(), userToken)
+	assert.ErrorIs(t, err, service.ErrValidate)
+	userID, err := authorizer.ValidateToken(context.Background(), otherToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-2", userID)
+
+	// Tokens issued after the revocation stay valid
+	revokedBefore := time.Now().Add(-time.Minute)
+	require.NoError(t, storage.SaveUserTokensRevocation(context.Background(), "user-2", revokedBefore, time.Now().Add(time.Hour)))
+	userID, err = authorizer.ValidateToken(context.Background(), otherToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-2", userID)
+}
+
+func TestAuthorizer_RevocationStorageError(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	storage := new(mocks.Repository)
+	storage.On("IsTokenRevoked", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(false, errors.New("db error"))
+	authorizer := service.NewAuthorizer([]byte("test-secret-key"), testLogger)
+	authorizer.EnableRevocation(storage)
+
+	token, err := authorizer.CreateToken("user-1")
+	require.NoError(t, err)
+
+	_, err = authorizer.ValidateToken(context.Background(), token)
+	assert.ErrorIs(t, err, service.ErrValidate)
+}
+
+func TestAuthorizer_RevocationUsesRequestContext(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	storage := new(mocks.Repository)
+	authorizer := service.NewAuthorizer([]byte("test-secret-key"), testLogger)
+	authorizer.EnableRevocation(storage)
+
+	token, err := authorizer.CreateToken("user-1")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	storage.On("IsTokenRevoked", ctx, mock.Anything, "user-1", mock.Anything).Return(false, ctx.Err()).Once()
+
+	_, err = authorizer.ValidateToken(ctx, token)
+	assert.ErrorIs(t, err, service.ErrValidate)
+	storage.AssertExpectations(t)
+}
+
+func TestAuthorizer_RevocationDisabled(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	authorizer := service.NewAuthorizer([]byte("test-secret-key"), testLogger)
+
+	token, err := authorizer.CreateToken("user-1")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, authorizer.RevokeToken(context.Background(), token), service.ErrRevocationDisabled)
+	assert.ErrorIs(t, authorizer.RevokeUserTokens(context.Background(), "user-1"), service.ErrRevocationDisabled)
+}
+
+func TestAuthorizer_AdminRole(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	authorizer := service.NewAuthorizer([]byte("secret"), testLogger)
+	authorizer.SetAdminUsers([]string{"admin-1"})
+
+	adminToken, err := authorizer.CreateToken("admin-1")
+	assert.NoError(t, err)
+	userToken, err := authorizer.CreateToken("user-1")
+	assert.NoError(t, err)
+
+	assert.Equal(t, service.RoleAdmin, service.TokenRole(adminToken))
+	assert.Empty(t, service.TokenRole(userToken))
+	assert.Empty(t, service.TokenRole("invalid.token.string"))
+
+	userID, err := authorizer.ValidateToken(context.Background(), adminToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "admin-1", userID)
+}
@@ -0,0 +1,87 @@
+package service_test
+
+import (
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBotDetector_Detect(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	detector := service.NewBotDetector(testLogger)
+
+	tests := []struct {
+		name         string
+		userAgent    string
+		wantBot      string
+		wantUnfurler bool
+	}{
+		{
+			name:         "slack unfurler",
+			userAgent:    "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)",
+			wantBot:      "Slack",
+			wantUnfurler: true,
+		},
+		{
+			name:         "facebook unfurler",
+			userAgent:    "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)",
+			wantBot:      "Facebook",
+			wantUnfurler: true,
+		},
+		{
+			name:      "search crawler",
+			userAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			wantBot:   "Googlebot",
+		},
+		{name: "command line client", userAgent: "curl/8.5.0", wantBot: "curl"},
+		{name: "unknown bot", userAgent: "ExampleMonitorBot/3.2", wantBot: "Generic bot"},
+		{
+			name: "browser",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 " +
+				"(KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36",
+		},
+		{name: "no user agent"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot, ok := detector.Detect(tt.userAgent)
+			assert.Equal(t, tt.wantBot != "", ok)
+			assert.Equal(t, tt.wantBot, bot.Name)
+			assert.Equal(t, tt.wantUnfurler, bot.Unfurler)
+		})
+	}
+}
+
+func TestBotDetector_LoadSignatures(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	detector := service.NewBotDetector(testLogger)
+	signaturesPath := filepath.Join(t.TempDir(), "bots.json")
+
+	assert.Error(t, detector.LoadSignatures(signaturesPath), "missing file")
+	_, ok := detector.Detect("Googlebot/2.1")
+	assert.True(t, ok, "default signatures are kept")
+
+	require.NoError(t, os.WriteFile(signaturesPath, []byte(`{"signatures": [
+		{"name": "Chat", "pattern": "ChatPreview", "unfurler": true},
+		{"name": "Empty", "pattern": "  "}
+	]}`), 0o600))
+	require.NoError(t, detector.LoadSignatures(signaturesPath))
+	bot, ok := detector.Detect("Mozilla/5.0 (compatible; chatpreview/1.0)")
+	assert.True(t, ok)
+	assert.Equal(t, "Chat", bot.Name)
+	assert.True(t, bot.Unfurler)
+	_, ok = detector.Detect("Googlebot/2.1")
+	assert.False(t, ok, "loaded signatures replace the defaults")
+	_, ok = detector.Detect("Mozilla/5.0")
+	assert.False(t, ok, "signatures without a pattern are dropped")
+
+	require.NoError(t, os.WriteFile(signaturesPath, []byte(`{"signatures": [`), 0o600))
+	assert.Error(t, detector.LoadSignatures(signaturesPath))
+	_, ok = detector.Detect("ChatPreview/1.0")
+	assert.True(t, ok, "signatures in use are kept on broken file")
+}
@@ -0,0 +1,240 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/mocks"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealthChecker_Check(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	checker := service.NewHealthChecker(new(mocks.Repository), testLogger, 1, time.Millisecond)
+	checker.SetHTTPClient(newLoopbackHealthClient())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closedURL := closed.URL
+	closed.Close()
+
+	tests := []struct {
+		name           string
+		url            string
+		wantStatus     model.LinkHealth
+		wantStatusCode int
+	}{
+		{name: "healthy", url: server.URL + "/ok", wantStatus: model.HealthHealthy, wantStatusCode: 200},
+		{name: "get fallback", url: server.URL + "/no-head", wantStatus: model.HealthHealthy, wantStatusCode: 200},
+		{name: "redirect not followed", url: server.URL + "/moved", wantStatus: model.HealthRedirected,
+			wantStatusCode: 301},
+		{name: "not found", url: server.URL + "/gone", wantStatus: model.HealthBroken, wantStatusCode: 404},
+		{name: "connection refused", url: closedURL, wantStatus: model.HealthBroken},
+		{name: "invalid url", url: "://invalid", wantStatus: model.HealthBroken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health := checker.Check(context.Background(), tt.url)
+			assert.Equal(t, tt.wantStatus, health.Status)
+			assert.Equal(t, tt.wantStatusCode, health.StatusCode)
+			assert.WithinDuration(t, time.Now(), health.CheckedAt, time.Second)
+		})
+	}
+}
+
+func TestHealthChecker_CheckPrivateTarget(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	checker := service.NewHealthChecker(new(mocks.Repository), testLogger, 1, time.Millisecond)
+
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	health := checker.Check(context.Background(), server.URL)
+	assert.Equal(t, model.HealthBroken, health.Status)
+	assert.Zero(t, health.StatusCode)
+	health = checker.Check(context.Background(), strings.Replace(server.URL, "127.0.0.1", "localhost", 1))
+	assert.Equal(t, model.HealthBroken, health.Status)
+	assert.False(t, requested)
+}
+
+func TestHealthChecker_CheckDue(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	checker := service.NewHealthChecker(mockRepo, testLogger, 2, time.Millisecond)
+	checker.SetHTTPClient(newLoopbackHealthClient())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	checkedBefore := time.Now().Add(-time.Hour)
+	mockRepo.On("GetURLsForHealthCheck", mock.Anything, checkedBefore, 100).Return([]model.URL{
+		{ShortURL: "abc123", OriginalURL: server.URL + "/page"},
+		{ShortURL: "def456", Domain: "go.brand.com", OriginalURL: server.URL + "/broken"},
+	}, nil).Once()
+	mockRepo.On("SaveURLHealth", mock.Anything, "", "abc123", mock.MatchedBy(func(health model.URLHealth) bool {
+		return health.Status == model.HealthHealthy && health.StatusCode == http.StatusNoContent
+	})).Return(nil).Once()
+	mockRepo.On("SaveURLHealth", mock.Anything, "go.brand.com", "def456",
+		mock.MatchedBy(func(health model.URLHealth) bool {
+			return health.Status == model.HealthBroken && health.StatusCode == http.StatusInternalServerError
+		})).Return(nil).Once()
+
+	checked, err := checker.CheckDue(context.Background(), checkedBefore)
+	require.NoError(t, err)
+	assert.Equal(t, 2, checked)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHealthChecker_CheckDue_StorageError(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	checker := service.NewHealthChecker(mockRepo, testLogger, 1, time.Millisecond)
+
+	dbErr := errors.New("db error")
+	mockRepo.On("GetURLsForHealthCheck", mock.Anything, mock.Anything, 100).Return(nil, dbErr).Once()
+
+	checked, err := checker.CheckDue(context.Background(), time.Now())
+	assert.ErrorIs(t, err, dbErr)
+	assert.Zero(t, checked)
+	mockRepo.AssertNotCalled(t, "SaveURLHealth", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHealthChecker_Concurrency(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	checker := service.NewHealthChecker(mockRepo, testLogger, 2, time.Millisecond)
+	checker.SetHTTPClient(newLoopbackHealthClient())
+
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			seen := maxInFlight.Load()
+			if current <= seen || maxInFlight.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// Links to different hosts are not spaced by the host delay.
+	port := strconv.Itoa(server.Listener.Addr().(*net.TCPAddr).Port)
+	hosts := []string{server.URL, "http://localhost:" + port}
+	var urls []model.URL
+	for i := 0; i < 6; i++ {
+		urls = append(urls, model.URL{ShortURL: string(rune('a' + i)), OriginalURL: hosts[i%2] + "/page"})
+	}
+	mockRepo.On("GetURLsForHealthCheck", mock.Anything, mock.Anything, 100).Return(urls, nil).Once()
+	mockRepo.On("SaveURLHealth", mock.Anything, "", mock.Anything, mock.Anything).Return(nil)
+
+	checked, err := checker.CheckDue(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 6, checked)
+	assert.Equal(t, int32(2), maxInFlight.Load())
+}
+
+func TestHealthChecker_HostDelay(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	hostDelay := 50 * time.Millisecond
+	checker := service.NewHealthChecker(mockRepo, testLogger, 3, hostDelay)
+	checker.SetHTTPClient(newLoopbackHealthClient())
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	urls := []model.URL{
+		{ShortURL: "a", OriginalURL: server.URL + "/1"},
+		{ShortURL: "b", OriginalURL: server.URL + "/2"},
+		{ShortURL: "c", OriginalURL: server.URL + "/3"},
+	}
+	mockRepo.On("GetURLsForHealthCheck", mock.Anything, mock.Anything, 100).Return(urls, nil).Once()
+	mockRepo.On("SaveURLHealth", mock.Anything, "", mock.Anything, mock.Anything).Return(nil)
+
+	// Three requests to one host take at least two delays, although all three could run at once.
+	start := time.Now()
+	_, err := checker.CheckDue(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 2*hostDelay)
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+func TestHealthChecker_Start(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	checker := service.NewHealthChecker(mockRepo, testLogger, 1, time.Millisecond)
+
+	started := make(chan struct{}, 1)
+	mockRepo.On("GetURLsForHealthCheck", mock.Anything, mock.Anything, 100).
+		Run(func(args mock.Arguments) { started <- struct{}{} }).
+		Return(nil, nil).Once()
+
+	checker.Start(time.Hour)
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("Expected health check to run on start")
+	}
+	checker.Close()
+	mockRepo.AssertNumberOfCalls(t, "GetURLsForHealthCheck", 1)
+}
+
+// newLoopbackHealthClient creates a client reaching the loopback test servers, which the checker refuses,
+// without following redirects like the client of the checker.
+func newLoopbackHealthClient() *http.Client {
+	return &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,114 @@
+package service_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/service"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeKeyFile writes a PEM encoded key to a temporary file and returns its path.
+func writeKeyFile(t *testing.T, name string, blockType string, der []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600))
+	return path
+}
+
+func generateEdKeyFiles(t *testing.T) (privatePath string, publicPath string) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+	return writeKeyFile(t, "ed.pem", "PRIVATE KEY", privateDER), writeKeyFile(t, "ed.pub", "PUBLIC KEY", publicDER)
+}
+
+func TestLoadKeySet(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaPath := writeKeyFile(t, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+	edPath, edPublicPath := generateEdKeyFiles(t)
+
+	keySet, err := service.LoadKeySet([]string{"old=" + rsaPath, "new=" + edPath, "partner=" + edPublicPath}, "new")
+	require.NoError(t, err)
+	assert.Equal(t, "new", keySet.Active().ID)
+	assert.Equal(t, jwt.SigningMethodEdDSA, keySet.Active().Method)
+	assert.Equal(t, jwt.SigningMethodRS256, keySet.Key("old").Method)
+	assert.Nil(t, keySet.Key("partner").PrivateKey)
+	assert.Nil(t, keySet.Key("unknown"))
+
+	jwks := keySet.JWKS()
+	require.Len(t, jwks.Keys, 3)
+	assert.Equal(t, "old", jwks.Keys[0].KeyID)
+	assert.Equal(t, "RSA", jwks.Keys[0].KeyType)
+	assert.Equal(t, "RS256", jwks.Keys[0].Algorithm)
+	assert.Equal(t, "AQAB", jwks.Keys[0].Exponent)
+	assert.NotEmpty(t, jwks.Keys[0].Modulus)
+	assert.Equal(t, "OKP", jwks.Keys[1].KeyType)
+	assert.Equal(t, "Ed25519", jwks.Keys[1].Curve)
+	assert.Equal(t, "EdDSA", jwks.Keys[1].Algorithm)
+	assert.NotEmpty(t, jwks.Keys[1].X)
+	assert.Equal(t, "sig", jwks.Keys[2].Use)
+}
+
+func TestLoadKeySet_Errors(t *testing.T) {
+	edPath, edPublicPath := generateEdKeyFiles(t)
+	weakKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	weakPath := writeKeyFile(t, "weak.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(weakKey))
+	certPath := writeKeyFile(t, "cert.pem", "CERTIFICATE", []byte("not a key"))
+
+	tests := []struct {
+		name     string
+		keyFiles []string
+		activeID string
+	}{
+		{name: "missing kid", keyFiles: []string{edPath}, activeID: "new"},
+		{name: "missing file", keyFiles: []string{"new=" + filepath.Join(t.TempDir(), "missing.pem")}, activeID: "new"},
+		{name: "unsupported block", keyFiles: []string{"new=" + certPath}, activeID: "new"},
+		{name: "weak rsa key", keyFiles: []string{"new=" + weakPath}, activeID: "new"},
+		{name: "duplicate kid", keyFiles: []string{"new=" + edPath, "new=" + edPath}, activeID: "new"},
+		{name: "unknown active key", keyFiles: []string{"new=" + edPath}, activeID: "other"},
+		{name: "active key without private key", keyFiles: []string{"new=" + edPublicPath}, activeID: "new"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.LoadKeySet(tt.keyFiles, tt.activeID)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseJWK(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaPath := writeKeyFile(t, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+	edPath, _ := generateEdKeyFiles(t)
+	keySet, err := service.LoadKeySet([]string{"rsa=" + rsaPath, "ed=" + edPath}, "ed")
+	require.NoError(t, err)
+
+	for _, jwk := range keySet.JWKS().Keys {
+		key, err := service.ParseJWK(jwk)
+		require.NoError(t, err)
+		assert.Equal(t, jwk.KeyID, key.ID)
+		assert.Equal(t, keySet.Key(jwk.KeyID).Method, key.Method)
+		assert.Equal(t, keySet.Key(jwk.KeyID).PublicKey, key.PublicKey)
+		assert.Nil(t, key.PrivateKey)
+	}
+
+	_, err = service.ParseJWK(model.JWK{KeyType: "EC", KeyID: "ec", Algorithm: "ES256"})
+	assert.ErrorIs(t, err, service.ErrUnsupportedKey)
+	_, err = service.ParseJWK(model.JWK{KeyType: "OKP", KeyID: "short", Curve: "Ed25519", X: "AQAB"})
+	assert.ErrorIs(t, err, service.ErrUnsupportedKey)
+}
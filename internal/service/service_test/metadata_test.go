@@ -0,0 +1,163 @@
+package service_test
+
+import (
+	"context"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/mocks"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetadataFetcher_Fetch(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	fetcher := service.NewMetadataFetcher(new(mocks.Repository), testLogger)
+	defer fetcher.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/og", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<!DOCTYPE html>
+<html><head>
+<!-- <title>Commented out</title> -->
+<title>Plain title</title>
+<meta name="description" content="Plain description">
+<META property="og:title" content="Caf&eacute; &amp; Bar">
+<meta property='og:description' content='  Best coffee
+  in town  '>
+<meta property="og:image" content="/images/preview.png">
+<meta property="og:site_name" content=Cafe>
+<script>document.write("<title>Script title</title>")</script>
+</head><body><meta property="og:title" content="Body title"></body></html>`))
+	})
+	mux.HandleFunc("/plain", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><head><TITLE>
+			Tom &amp; Jerry
+		</TITLE><meta content="Cartoon" name="Description"></head></html>`))
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/plain", http.StatusFound)
+	})
+	mux.HandleFunc("/truncated", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html><head><!-- " + strings.Repeat("x", 600<<10) + " --><title>Too late</title>"))
+	})
+	mux.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"title": "not a page"}`))
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	fetcher.SetHTTPClient(server.Client())
+
+	tests := []struct {
+		name     string
+		url      string
+		want     model.URLMetadata
+		wantErr  error
+		anyError bool
+	}{
+		{
+			name: "open graph tags",
+			url:  server.URL + "/og",
+			want: model.URLMetadata{
+				Title:       "Café & Bar",
+				Description: "Best coffee in town",
+				Image:       server.URL + "/images/preview.png",
+				SiteName:    "Cafe",
+			},
+		},
+		{
+			name: "title and meta description",
+			url:  server.URL + "/plain",
+			want: model.URLMetadata{Title: "Tom & Jerry", Description: "Cartoon"},
+		},
+		{
+			name: "redirect followed",
+			url:  server.URL + "/moved",
+			want: model.URLMetadata{Title: "Tom & Jerry", Description: "Cartoon"},
+		},
+		{name: "metadata beyond size limit", url: server.URL + "/truncated"},
+		{name: "not html", url: server.URL + "/json", wantErr: service.ErrNotHTMLPage},
+		{name: "not found", url: server.URL + "/missing", anyError: true},
+		{name: "unsupported scheme", url: "ftp://example.com/file", anyError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata, err := fetcher.Fetch(context.Background(), tt.url)
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.anyError:
+				assert.Error(t, err)
+			default:
+				require.NoError(t, err)
+				assert.WithinDuration(t, time.Now(), metadata.FetchedAt, time.Second)
+				metadata.FetchedAt = time.Time{}
+				assert.Equal(t, tt.want, *metadata)
+			}
+		})
+	}
+}
+
+func TestMetadataFetcher_FetchPrivateTarget(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	fetcher := service.NewMetadataFetcher(new(mocks.Repository), testLogger)
+	defer fetcher.Close()
+
+	var requested bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer server.Close()
+
+	_, err := fetcher.Fetch(context.Background(), server.URL)
+	assert.ErrorIs(t, err, service.ErrPrivateTarget)
+	_, err = fetcher.Fetch(context.Background(), strings.Replace(server.URL, "127.0.0.1", "localhost", 1))
+	assert.ErrorIs(t, err, service.ErrPrivateTarget)
+	assert.False(t, requested)
+}
+
+func TestURLShortener_EnableMetadataEnrichment(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	shortener := service.NewURLShortener(mockRepo, testLogger)
+	defer shortener.Close()
+	fetcher := service.NewMetadataFetcher(mockRepo, testLogger)
+	defer fetcher.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><head><title>Landing page</title></head></html>`))
+	}))
+	defer server.Close()
+	fetcher.SetHTTPClient(server.Client())
+	shortener.EnableMetadataEnrichment(fetcher)
+
+	saved := make(chan model.URLMetadata, 1)
+	mockRepo.On("Save", mock.Anything, "test-user", mock.Anything).Return(nil).Once()
+	mockRepo.On("SaveURLMetadata", mock.Anything, "go.brand.com", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { saved <- args.Get(3).(model.URLMetadata) }).
+		Return(nil).Once()
+
+	shortURL, err := shortener.GenerateShortURLPart(context.Background(), "test-user", "go.brand.com", server.URL)
+	require.NoError(t, err)
+
+	select {
+	case metadata := <-saved:
+		assert.Equal(t, "Landing page", metadata.Title)
+	case <-time.After(time.Second):
+		t.Fatal("Expected metadata to be fetched after the link is created")
+	}
+	mockRepo.AssertCalled(t, "SaveURLMetadata", mock.Anything, "go.brand.com", shortURL, mock.Anything)
+}
@@ -0,0 +1,303 @@
+package service_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/bezjen/shortener/internal/config"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/service"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	testOIDCClientID    = "shortener"
+	testOIDCSecret      = "client-secret"
+	testOIDCRedirectURL = "https://short.example.com/api/auth/oidc/callback"
+)
+
+// testOIDCCode is an authorization code issued by the test provider.
+type testOIDCCode struct {
+	challenge string
+	nonce     string
+}
+
+// testOIDCProvider is an in-process OpenID Connect provider supporting
+// discovery, the authorization code flow with PKCE and JWKS.
+type testOIDCProvider struct {
+	server  *httptest.Server
+	kid     string
+	key     ed25519.PrivateKey
+	subject string
+
+	mu    sync.Mutex
+	codes map[string]testOIDCCode
+}
+
+func newTestOIDCProvider(t *testing.T) *testOIDCProvider {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	p := &testOIDCProvider{kid: "provider-key", key: key, subject: "alice", codes: make(map[string]testOIDCCode)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.server.URL,
+			"authorization_endpoint": p.server.URL + "/authorize",
+			"token_endpoint":         p.server.URL + "/token",
+			"jwks_uri":               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/authorize", p.handleAuthorize)
+	mux.HandleFunc("/token", p.handleToken)
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(model.JWKS{Keys: []model.JWK{{
+			KeyType:   "OKP",
+			KeyID:     p.kid,
+			Use:       "sig",
+			Algorithm: "EdDSA",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(p.key.Public().(ed25519.PublicKey)),
+		}}})
+	})
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+// handleAuthorize signs the user in right away and redirects back with a code.
+func (p *testOIDCProvider) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != testOIDCClientID || query.Get("redirect_uri") != testOIDCRedirectURL ||
+		query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	code := rand.Text()
+	p.mu.Lock()
+	p.codes[code] = testOIDCCode{challenge: query.Get("code_challenge"), nonce: query.Get("nonce")}
+	p.mu.Unlock()
+	http.Redirect(w, r, testOIDCRedirectURL+"?code="+code+"&state="+url.QueryEscape(query.Get("state")), http.StatusFound)
+}
+
+// handleToken exchanges a code for an ID token after checking the client and the PKCE verifier.
+func (p *testOIDCProvider) handleToken(w http.ResponseWriter, r *http.Request) {
+	clientID, secret, _ := r.BasicAuth()
+	if r.Method != http.MethodPost || clientID != testOIDCClientID || secret != testOIDCSecret ||
+		r.PostFormValue("grant_type") != "authorization_code" || r.PostFormValue("redirect_uri") != testOIDCRedirectURL {
+		http.Error(w, "invalid_client", http.StatusUnauthorized)
+		return
+	}
+	p.mu.Lock()
+	code, ok := p.codes[r.PostFormValue("code")]
+	delete(p.codes, r.PostFormValue("code"))
+	p.mu.Unlock()
+	challenge := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(challenge[:]) != code.challenge {
+		http.Error(w, "invalid_grant", http.StatusBadRequest)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"id_token":     p.idToken(testOIDCClientID, code.nonce, time.Now().Add(time.Hour)),
+	})
+}
+
+func (p *testOIDCProvider) idToken(audience string, nonce string, expiresAt time.Time) string {
+	claims := jwt.MapClaims{
+		"iss": p.server.URL,
+		"sub": p.subject,
+		"aud": audience,
+		"iat": time.Now().Unix(),
+		"exp": expiresAt.Unix(),
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = p.kid
+	signed, _ := token.SignedString(p.key)
+	return signed
+}
+
+func (p *testOIDCProvider) service() *service.IssuerOIDCService {
+	testLogger, _ := logger.NewLogger("debug")
+	return service.NewIssuerOIDCService(config.Config{
+		OIDCIssuer:      p.server.URL,
+		OIDCClientID:    testOIDCClientID,
+		OIDCSecret:      testOIDCSecret,
+		OIDCRedirectURL: testOIDCRedirectURL,
+	}, testLogger)
+}
+
+// authorize follows the login URL and returns the callback parameters.
+func (p *testOIDCProvider) authorize(t *testing.T, authURL string) url.Values {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	return location.Query()
+}
+
+func TestOIDCService_Login(t *testing.T) {
+	provider := newTestOIDCProvider(t)
+	oidc := provider.service()
+
+	login, err := oidc.StartLogin(context.Background())
+	require.NoError(t, err)
+	callback := provider.authorize(t, login.AuthURL)
+	assert.Equal(t, login.State, callback.Get("state"))
+
+	userID, err := oidc.FinishLogin(context.Background(), callback.Get("code"), login.CodeVerifier, login.Nonce)
+	require.NoError(t, err)
+	assert.NotEmpty(t, userID)
+
+	// The same subject always maps to the same user
+	login, err = oidc.StartLogin(context.Background())
+	require.NoError(t, err)
+	callback = provider.authorize(t, login.AuthURL)
+	sameUserID, err := oidc.FinishLogin(context.Background(), callback.Get("code"), login.CodeVerifier, login.Nonce)
+	require.NoError(t, err)
+	assert.Equal(t, userID, sameUserID)
+
+	provider.subject = "bob"
+	login, err = oidc.StartLogin(context.Background())
+	require.NoError(t, err)
+	callback = provider.authorize(t, login.AuthURL)
+	otherUserID, err := oidc.FinishLogin(context.Background(), callback.Get("code"), login.CodeVerifier, login.Nonce)
+	require.NoError(t, err)
+	assert.NotEqual(t, userID, otherUserID)
+}
+
+func TestOIDCService_LoginErrors(t *testing.T) {
+	provider := newTestOIDCProvider(t)
+	oidc := provider.service()
+
+	login, err := oidc.StartLogin(context.Background())
+	require.NoError(t, err)
+	callback := provider.authorize(t, login.AuthURL)
+	_, err = oidc.FinishLogin(context.Background(), callback.Get("code"), "wrong-verifier", login.Nonce)
+	assert.ErrorIs(t, err, service.ErrOIDCExchange)
+
+	login, err = oidc.StartLogin(context.Background())
+	require.NoError(t, err)
+	callback = provider.authorize(t, login.AuthURL)
+	_, err = oidc.FinishLogin(context.Background(), callback.Get("code"), login.CodeVerifier, "wrong-nonce")
+	assert.ErrorIs(t, err, service.ErrInvalidIDToken)
+}
+
+func TestOIDCService_ValidateIDToken(t *testing.T) {
+	provider := newTestOIDCProvider(t)
+	oidc := provider.service()
+
+	token := provider.idToken(testOIDCClientID, "", time.Now().Add(time.Hour))
+	assert.True(t, oidc.IssuedBy(token))
+	userID, err := oidc.ValidateIDToken(context.Background(), token)
+	require.NoError(t, err)
+	assert.NotEmpty(t, userID)
+
+	_, forgedKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	forged := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.MapClaims{
+		"iss": provider.server.URL, "sub": "alice", "aud": testOIDCClientID, "exp": time.Now().Add(time.Hour).Unix(),
+	})
+	forged.Header["kid"] = provider.kid
+	forgedToken, err := forged.SignedString(forgedKey)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "other audience", token: provider.idToken("other-client", "", time.Now().Add(time.Hour))},
+		{name: "expired", token: provider.idToken(testOIDCClientID, "", time.Now().Add(-time.Hour))},
+		{name: "forged signature", token: forgedToken},
+		{name: "shortener token", token: "invalid.token.string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := oidc.ValidateIDToken(context.Background(), tt.token)
+			assert.ErrorIs(t, err, service.ErrInvalidIDToken)
+		})
+	}
+
+	testLogger, _ := logger.NewLogger("debug")
+	shortenerToken, err := service.NewAuthorizer([]byte("secret"), testLogger).CreateToken("user-1")
+	require.NoError(t, err)
+	assert.False(t, oidc.IssuedBy(shortenerToken))
+}
+
+func TestOIDCService_DiscoveryIssuerMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 "https://attacker.example.com",
+			"authorization_endpoint": "https://attacker.example.com/authorize",
+			"token_endpoint":         "https://attacker.example.com/token",
+			"jwks_uri":               "https://attacker.example.com/jwks",
+		})
+	}))
+	defer server.Close()
+	testLogger, _ := logger.NewLogger("debug")
+	oidc := service.NewIssuerOIDCService(config.Config{OIDCIssuer: server.URL, OIDCClientID: testOIDCClientID}, testLogger)
+
+	_, err := oidc.StartLogin(context.Background())
+	assert.Error(t, err)
+}
+
+func TestOIDCService_ConcurrentKeyFetch(t *testing.T) {
+	provider := newTestOIDCProvider(t)
+	release := make(chan struct{})
+	var jwksRequests atomic.Int32
+	handler := provider.server.Config.Handler
+	provider.server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/jwks" {
+			jwksRequests.Add(1)
+			<-release
+		}
+		handler.ServeHTTP(w, r)
+	})
+	oidc := provider.service()
+	token := provider.idToken(testOIDCClientID, "", time.Now().Add(time.Hour))
+
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = oidc.ValidateIDToken(context.Background(), token)
+		}()
+	}
+
+	// A caller with a short deadline gives up while the fetch is in flight instead of waiting for the lock.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	_, err := oidc.ValidateIDToken(ctx, token)
+	assert.ErrorIs(t, err, service.ErrInvalidIDToken)
+	assert.Less(t, time.Since(started), time.Second)
+
+	close(release)
+	wg.Wait()
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), jwksRequests.Load())
+}
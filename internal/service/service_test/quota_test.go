@@ -0,0 +1,232 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/mocks"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/repository"
+	"github.com/bezjen/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestURLShortener_QuotaLinksPerDay(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	u := service.NewURLShortener(repository.NewInMemoryRepository(), testLogger)
+	defer u.Close()
+	u.EnableQuotas(model.QuotaLimits{LinksPerDay: 2})
+	ctx := context.Background()
+
+	_, err := u.GenerateShortURLPart(ctx, "user-1", "", "https://example.com/1")
+	require.NoError(t, err)
+	_, err = u.GenerateBundle(ctx, "user-1", "", "Bundle", []model.BundleLink{{Title: "A", URL: "https://a.com"}})
+	require.NoError(t, err)
+
+	_, err = u.GenerateShortURLPart(ctx, "user-1", "", "https://example.com/3")
+	var quotaErr *service.QuotaExceededError
+	require.True(t, errors.As(err, &quotaErr))
+	assert.Equal(t, model.QuotaLinksPerDay, quotaErr.Quota)
+	assert.Equal(t, int64(2), quotaErr.Limit)
+
+	_, err = u.GenerateShortURLPart(ctx, "user-2", "", "https://example.com/3")
+	assert.NoError(t, err, "quotas are counted per user")
+
+	quota, err := u.GetQuota(ctx, "user-1")
+	require.NoError(t, err)
+	remaining, limited := quota.RemainingLinksToday()
+	assert.True(t, limited)
+	assert.Equal(t, int64(0), remaining)
+}
+
+func TestURLShortener_QuotaBatch(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	u := service.NewURLShortener(repository.NewInMemoryRepository(), testLogger)
+	defer u.Close()
+	u.EnableQuotas(model.QuotaLimits{ActiveLinks: 3, BatchSize: 2, DeleteBatchSize: 1})
+	ctx := context.Background()
+	batch := func(size int) []model.ShortenBatchRequestItem {
+		items := make([]model.ShortenBatchRequestItem, size)
+		for i := range items {
+			items[i] = model.ShortenBatchRequestItem{CorrelationID: string(rune('a' + i)), OriginalURL: "https://example.com"}
+		}
+		return items
+	}
+
+	_, err := u.GenerateShortURLPartBatch(ctx, "user-1", "", batch(3))
+	var quotaErr *service.QuotaExceededError
+	require.True(t, errors.As(err, &quotaErr))
+	assert.Equal(t, model.QuotaBatchSize, quotaErr.Quota)
+
+	_, err = u.GenerateShortURLPartBatch(ctx, "user-1", "", batch(2))
+	require.NoError(t, err)
+
+	_, err = u.GenerateShortURLPartBatch(ctx, "user-1", "", batch(2))
+	require.True(t, errors.As(err, &quotaErr))
+	assert.Equal(t, model.QuotaActiveLinks, quotaErr.Quota)
+
+	err = u.DeleteUserShortURLsBatch(ctx, "user-1", "", []string{"abc", "def"})
+	require.True(t, errors.As(err, &quotaErr))
+	assert.Equal(t, model.QuotaDeleteBatchSize, quotaErr.Quota)
+	assert.Equal(t, int64(1), quotaErr.Limit)
+}
+
+func TestURLShortener_QuotaConcurrentRequests(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits model.QuotaLimits
+		quota  model.QuotaName
+	}{
+		{name: "links per day", limits: model.QuotaLimits{LinksPerDay: 5}, quota: model.QuotaLinksPerDay},
+		{name: "active links", limits: model.QuotaLimits{ActiveLinks: 5}, quota: model.QuotaActiveLinks},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testLogger, _ := logger.NewLogger("debug")
+			storage := repository.NewInMemoryRepository()
+			u := service.NewURLShortener(storage, testLogger)
+			defer u.Close()
+			u.EnableQuotas(tt.limits)
+			ctx := context.Background()
+
+			var wg sync.WaitGroup
+			var created, rejected atomic.Int64
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					items := []model.ShortenBatchRequestItem{
+						{CorrelationID: "a", OriginalURL: fmt.Sprintf("https://example.com/%d/a", i)},
+						{CorrelationID: "b", OriginalURL: fmt.Sprintf("https://example.com/%d/b", i)},
+					}
+					var err error
+					if i%2 == 0 {
+						_, err = u.GenerateShortURLPart(ctx, "user-1", "", items[0].OriginalURL)
+					} else {
+						_, err = u.GenerateShortURLPartBatch(ctx, "user-1", "", items)
+					}
+					var quotaErr *service.QuotaExceededError
+					switch {
+					case err == nil && i%2 == 0:
+						created.Add(1)
+					case err == nil:
+						created.Add(2)
+					case errors.As(err, &quotaErr) && quotaErr.Quota == tt.quota:
+						rejected.Add(1)
+					default:
+						t.Errorf("unexpected error: %v", err)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			assert.LessOrEqual(t, created.Load(), int64(5))
+			assert.Positive(t, rejected.Load())
+			stats, err := storage.GetStats(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, created.Load(), stats.URLs)
+			quota, err := u.GetQuota(ctx, "user-1")
+			require.NoError(t, err)
+			assert.Equal(t, model.QuotaUsage{LinksToday: created.Load(), ActiveLinks: created.Load()}, quota.Usage)
+		})
+	}
+}
+
+func TestURLShortener_QuotaReleasedOnFailure(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	dbErr := errors.New("db error")
+	mockRepo := new(mocks.Repository)
+	mockRepo.On("GetUserQuotaLimits", mock.Anything, "user-1").Return(nil, repository.ErrNotFound)
+	mockRepo.On("ReserveLinks", mock.Anything, "user-1", mock.Anything, int64(1), model.QuotaLimits{LinksPerDay: 1}).
+		Return(&model.QuotaUsage{}, true, nil)
+	mockRepo.On("Save", mock.Anything, "user-1", mock.Anything).Return(dbErr)
+	mockRepo.On("ReleaseLinks", mock.Anything, "user-1", mock.Anything, int64(1), int64(1)).Return(nil)
+	u := service.NewURLShortener(mockRepo, testLogger)
+	defer u.Close()
+	u.EnableQuotas(model.QuotaLimits{LinksPerDay: 1})
+
+	_, err := u.GenerateShortURLPart(context.Background(), "user-1", "", "https://example.com")
+	assert.ErrorIs(t, err, dbErr)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestURLShortener_QuotaUserLimits(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	storage := repository.NewInMemoryRepository()
+	u := service.NewURLShortener(storage, testLogger)
+	defer u.Close()
+	u.EnableQuotas(model.QuotaLimits{LinksPerDay: 1})
+	ctx := context.Background()
+	require.NoError(t, storage.SaveUserQuotaLimits(ctx, "premium", model.QuotaLimits{LinksPerDay: 10}))
+
+	for i := 0; i < 2; i++ {
+		_, err := u.GenerateShortURLPart(ctx, "premium", "", "https://example.com")
+		require.NoError(t, err)
+	}
+	quota, err := u.GetQuota(ctx, "premium")
+	require.NoError(t, err)
+	assert.Equal(t, model.Quota{
+		Limits: model.QuotaLimits{LinksPerDay: 10},
+		Usage:  model.QuotaUsage{LinksToday: 2, ActiveLinks: 2},
+	}, *quota)
+}
+
+func TestURLShortener_QuotasDisabled(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	mockRepo.On("Save", mock.Anything, "user-1", mock.Anything).Return(nil)
+	u := service.NewURLShortener(mockRepo, testLogger)
+	defer u.Close()
+
+	_, err := u.GenerateShortURLPart(context.Background(), "user-1", "", "https://example.com")
+	require.NoError(t, err)
+	quota, err := u.GetQuota(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, model.Quota{}, *quota)
+	mockRepo.AssertNotCalled(t, "GetUserQuotaLimits", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "ReserveLinks", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestURLShortener_QuotaStorageError(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	dbErr := errors.New("db error")
+	mockRepo := new(mocks.Repository)
+	mockRepo.On("GetUserQuotaLimits", mock.Anything, "user-1").Return(nil, dbErr)
+	u := service.NewURLShortener(mockRepo, testLogger)
+	defer u.Close()
+	u.EnableQuotas(model.QuotaLimits{LinksPerDay: 1})
+
+	_, err := u.GenerateShortURLPart(context.Background(), "user-1", "", "https://example.com")
+	assert.ErrorIs(t, err, dbErr)
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestURLShortener_RequestQuota(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	u := service.NewURLShortener(repository.NewInMemoryRepository(), testLogger)
+	defer u.Close()
+	u.EnableQuotas(model.QuotaLimits{LinksPerDay: 5, ActiveLinks: 10})
+
+	ctx := service.WithRequestQuota(context.Background())
+	assert.Nil(t, service.RequestQuota(ctx, "user-1"), "quota is not read before a check")
+
+	_, err := u.GenerateShortURLPart(ctx, "user-1", "", "https://example.com/1")
+	require.NoError(t, err)
+
+	quota := service.RequestQuota(ctx, "user-1")
+	require.NotNil(t, quota)
+	assert.Equal(t, model.QuotaUsage{LinksToday: 1, ActiveLinks: 1}, quota.Usage, "created link is counted")
+	stored, err := u.GetQuota(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, stored, quota)
+
+	assert.Nil(t, service.RequestQuota(ctx, "user-2"), "quota of another user is not reported")
+	assert.Nil(t, service.RequestQuota(context.Background(), "user-1"))
+}
@@ -50,7 +50,7 @@ func TestGenerateShortURLPart(t *testing.T) {
 			if err != nil {
 				t.Fatalf("Failed to generate uuid: %v", err)
 			}
-			shortURL, err := u.GenerateShortURLPart(context.TODO(), userID.String(), tt.url)
+			shortURL, err := u.GenerateShortURLPart(context.TODO(), userID.String(), "", tt.url)
 			if err != nil {
 				if !errors.Is(err, tt.wantErr) {
 					t.Errorf("GenerateShortURLPart() error = %v, wantErr %v", err, tt.wantErr)
@@ -101,7 +101,7 @@ func TestGenerateShortURLPartBatch(t *testing.T) {
 			if err != nil {
 				t.Fatalf("Failed to generate uuid: %v", err)
 			}
-			shortURL, err := u.GenerateShortURLPartBatch(context.TODO(), userID.String(), tt.urls)
+			shortURL, err := u.GenerateShortURLPartBatch(context.TODO(), userID.String(), "", tt.urls)
 			if err != nil {
 				if !errors.Is(err, tt.wantErr) {
 					t.Errorf("GenerateShortURLPart() error = %v, wantErr %v", err, tt.wantErr)
@@ -116,10 +116,10 @@ func TestGenerateShortURLPartBatch(t *testing.T) {
 func TestGetURLByShortURLPart(t *testing.T) {
 	testLogger, _ := logger.NewLogger("debug")
 	mockRepoPositive := new(mocks.Repository)
-	mockRepoPositive.On("GetByShortURL", mock.Anything, "qwerty12").
+	mockRepoPositive.On("GetByShortURL", mock.Anything, "", "qwerty12").
 		Return(model.NewURL("qwerty12", "https://practicum.yandex.ru/"), nil)
 	mockRepoNotFound := new(mocks.Repository)
-	mockRepoNotFound.On("GetByShortURL", mock.Anything, "qwerty12").
+	mockRepoNotFound.On("GetByShortURL", mock.Anything, "", "qwerty12").
 		Return(nil, repository.ErrNotFound)
 
 	tests := []struct {
@@ -147,7 +147,7 @@ func TestGetURLByShortURLPart(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			u := service.NewURLShortener(tt.storage, testLogger)
-			got, err := u.GetURLByShortURLPart(context.TODO(), tt.shortURLPart)
+			got, err := u.GetURLByShortURLPart(context.TODO(), "", tt.shortURLPart)
 			if err != nil {
 				if !errors.Is(err, tt.wantErr) {
 					t.Errorf("GenerateShortURLPart() error = %v, wantErr %v", err, tt.wantErr)
@@ -159,6 +159,62 @@ func TestGetURLByShortURLPart(t *testing.T) {
 	}
 }
 
+func TestLookupShortURLPart(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	found := model.NewURL("qwerty12", "https://example.com/a?b=1")
+
+	tests := []struct {
+		name        string
+		originalURL string
+		setup       func(m *mocks.Repository)
+		want        *model.URL
+		wantErr     error
+	}{
+		{
+			name:        "found by canonical URL",
+			originalURL: "HTTPS://Example.com:443/a?b=1",
+			setup: func(m *mocks.Repository) {
+				m.On("GetByOriginalURL", mock.Anything, "go.brand.com", "user-1", "https://example.com/a?b=1").
+					Return(found, nil).Once()
+			},
+			want: found,
+		},
+		{
+			name:        "found by URL as given",
+			originalURL: "HTTPS://Example.com:443/a?b=1",
+			setup: func(m *mocks.Repository) {
+				m.On("GetByOriginalURL", mock.Anything, "go.brand.com", "user-1", "https://example.com/a?b=1").
+					Return(nil, repository.ErrNotFound).Once()
+				m.On("GetByOriginalURL", mock.Anything, "go.brand.com", "user-1", "HTTPS://Example.com:443/a?b=1").
+					Return(found, nil).Once()
+			},
+			want: found,
+		},
+		{
+			name:        "not found",
+			originalURL: "https://example.com/a?b=1",
+			setup: func(m *mocks.Repository) {
+				m.On("GetByOriginalURL", mock.Anything, "go.brand.com", "user-1", "https://example.com/a?b=1").
+					Return(nil, repository.ErrNotFound).Once()
+			},
+			wantErr: repository.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mocks.Repository)
+			tt.setup(mockRepo)
+			u := service.NewURLShortener(mockRepo, testLogger)
+			defer u.Close()
+
+			got, err := u.LookupShortURLPart(context.TODO(), "user-1", "go.brand.com", tt.originalURL)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
 func TestDeleteUserShortURLsBatch(t *testing.T) {
 	testLogger, _ := logger.NewLogger("debug")
 	mockRepo := new(mocks.Repository)
@@ -169,16 +225,16 @@ func TestDeleteUserShortURLsBatch(t *testing.T) {
 	shortURLs := []string{"abc123", "def456"}
 
 	// Настраиваем ожидание вызова DeleteBatch
-	mockRepo.On("DeleteBatch", mock.Anything, userID, shortURLs).Return(nil)
+	mockRepo.On("DeleteBatch", mock.Anything, "go.brand.com", userID, shortURLs).Return(nil)
 
-	err := shortener.DeleteUserShortURLsBatch(context.Background(), userID, shortURLs)
+	err := shortener.DeleteUserShortURLsBatch(context.Background(), userID, "go.brand.com", shortURLs)
 	assert.NoError(t, err)
 
 	// Даем время воркеру обработать задачу
 	time.Sleep(100 * time.Millisecond)
 
 	// Проверяем что DeleteBatch был вызван
-	mockRepo.AssertCalled(t, "DeleteBatch", mock.Anything, userID, shortURLs)
+	mockRepo.AssertCalled(t, "DeleteBatch", mock.Anything, "go.brand.com", userID, shortURLs)
 }
 
 func TestGetURLsByUserID(t *testing.T) {
@@ -245,11 +301,11 @@ func TestURLShortener_Close(t *testing.T) {
 	shortener := service.NewURLShortener(mockRepo, testLogger)
 
 	// Настраиваем ожидание для вызовов DeleteBatch
-	mockRepo.On("DeleteBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("DeleteBatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
 	// Добавляем несколько задач в очередь
 	for i := 0; i < 3; i++ {
-		err := shortener.DeleteUserShortURLsBatch(context.Background(), "user", []string{string(rune('a' + i))})
+		err := shortener.DeleteUserShortURLsBatch(context.Background(), "user", "", []string{string(rune('a' + i))})
 		assert.NoError(t, err)
 	}
 
@@ -271,7 +327,7 @@ func TestGenerateShortURLPart_StorageError(t *testing.T) {
 	userID := "test-user"
 	url := "https://example.com"
 
-	shortURL, err := shortener.GenerateShortURLPart(context.Background(), userID, url)
+	shortURL, err := shortener.GenerateShortURLPart(context.Background(), userID, "", url)
 	assert.Error(t, err)
 	assert.Equal(t, storageError, err)
 	assert.Empty(t, shortURL)
@@ -290,8 +346,140 @@ func TestGenerateShortURLPartBatch_StorageError(t *testing.T) {
 		*model.NewShortenBatchRequestItem("1", "https://example.com/1"),
 	}
 
-	result, err := shortener.GenerateShortURLPartBatch(context.Background(), userID, urls)
+	result, err := shortener.GenerateShortURLPartBatch(context.Background(), userID, "", urls)
 	assert.Error(t, err)
 	assert.Equal(t, storageError, err)
 	assert.Nil(t, result)
 }
+
+func TestGetDeletedURLsByUserID(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	shortener := service.NewURLShortener(mockRepo, testLogger)
+
+	deletedURL := model.NewURL("abc123", "https://example.com/1")
+	deletedURL.IsDeleted = true
+	deletedURL.DeletedAt = time.Now()
+	mockRepo.On("GetDeletedByUserID", mock.Anything, "test-user").Return([]model.URL{*deletedURL}, nil)
+
+	urls, err := shortener.GetDeletedURLsByUserID(context.Background(), "test-user")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.URL{*deletedURL}, urls)
+}
+
+func TestRestoreUserShortURLsBatch(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	shortener := service.NewURLShortener(mockRepo, testLogger)
+
+	shortURLs := []string{"abc123", "def456"}
+	mockRepo.On("RestoreBatch", mock.Anything, "go.brand.com", "test-user", shortURLs).Return(nil)
+
+	err := shortener.RestoreUserShortURLsBatch(context.Background(), "test-user", "go.brand.com", shortURLs)
+	assert.NoError(t, err)
+	mockRepo.AssertCalled(t, "RestoreBatch", mock.Anything, "go.brand.com", "test-user", shortURLs)
+}
+
+func TestPurgeDeletedURLs(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	shortener := service.NewURLShortener(mockRepo, testLogger)
+
+	retention := 24 * time.Hour
+	mockRepo.On("PurgeDeleted", mock.Anything, mock.MatchedBy(func(deletedBefore time.Time) bool {
+		expected := time.Now().Add(-retention)
+		return deletedBefore.Sub(expected).Abs() < time.Minute
+	})).Return(int64(2), nil)
+
+	purged, err := shortener.PurgeDeletedURLs(context.Background(), retention)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+}
+
+func TestStartTrashPurge(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	shortener := service.NewURLShortener(mockRepo, testLogger)
+
+	purged := make(chan struct{}, 1)
+	mockRepo.On("PurgeDeleted", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { purged <- struct{}{} }).
+		Return(int64(1), nil).Once()
+
+	shortener.StartTrashPurge(time.Hour)
+
+	select {
+	case <-purged:
+	case <-time.After(time.Second):
+		t.Fatal("Expected purge to run on start")
+	}
+	shortener.Close()
+	mockRepo.AssertNumberOfCalls(t, "PurgeDeleted", 1)
+}
+
+func TestGenerateBundle(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	shortener := service.NewURLShortener(mockRepo, testLogger)
+
+	links := []model.BundleLink{
+		{Title: "Third", URL: "https://example.com/3", Position: 3},
+		{Title: "First", URL: "https://example.com/1", Position: 1},
+		{Title: "Unordered", URL: "https://example.com/0"},
+		{Title: "Second", URL: "https://example.com/2", Position: 1},
+	}
+	mockRepo.On("SaveBundle", mock.Anything, "test-user", mock.MatchedBy(func(bundle model.Bundle) bool {
+		titles := make([]string, 0, len(bundle.Links))
+		for _, link := range bundle.Links {
+			titles = append(titles, link.Title)
+		}
+		return bundle.Title == "Conference" &&
+			assert.ObjectsAreEqual([]string{"Unordered", "First", "Second", "Third"}, titles)
+	})).Return(nil)
+
+	shortURL, err := shortener.GenerateBundle(context.Background(), "test-user", "", "Conference", links)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, len(shortURL))
+	assert.Equal(t, "Third", links[0].Title, "Request links should not be reordered in place")
+}
+
+func TestGenerateBundle_TooManyCollisions(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	shortener := service.NewURLShortener(mockRepo, testLogger)
+
+	mockRepo.On("SaveBundle", mock.Anything, mock.Anything, mock.Anything).Return(repository.ErrShortURLConflict)
+
+	shortURL, err := shortener.GenerateBundle(context.Background(), "test-user", "", "Conference",
+		[]model.BundleLink{{Title: "First", URL: "https://example.com/1"}})
+	assert.ErrorIs(t, err, service.ErrGenerate)
+	assert.Empty(t, shortURL)
+}
+
+func TestGetBundleByShortURLPart(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	shortener := service.NewURLShortener(mockRepo, testLogger)
+
+	bundle := model.NewBundle("abc123", "Conference", []model.BundleLink{{Title: "First", URL: "https://example.com/1"}})
+	mockRepo.On("GetBundleByShortURL", mock.Anything, "", "abc123").Return(bundle, nil)
+
+	result, err := shortener.GetBundleByShortURLPart(context.Background(), "", "abc123")
+	assert.NoError(t, err)
+	assert.Equal(t, bundle, result)
+}
+
+func TestGenerateShortURLPart_Domain(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	shortener := service.NewURLShortener(mockRepo, testLogger)
+
+	mockRepo.On("Save", mock.Anything, "test-user", mock.MatchedBy(func(url model.URL) bool {
+		return url.Domain == "go.brand.com" && url.OriginalURL == "https://brand.com/"
+	})).Return(nil)
+
+	shortURL, err := shortener.GenerateShortURLPart(context.Background(), "test-user", "go.brand.com", "https://brand.com/")
+	assert.NoError(t, err)
+	assert.Equal(t, 8, len(shortURL))
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,85 @@
+package service_test
+
+import (
+	"errors"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestURLPolicy_Check(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	policy := service.NewURLPolicy(nil, []string{"short.example.com:8080", "go.brand.com"}, testLogger)
+	listsPath := filepath.Join(t.TempDir(), "policy.json")
+	require.NoError(t, os.WriteFile(listsPath,
+		[]byte(`{"allow": ["example.com", "*.example.com", "*.evil.com"], "deny": ["*.evil.com"]}`), 0o600))
+	require.NoError(t, policy.LoadDomainLists(listsPath))
+
+	tests := []struct {
+		name     string
+		url      string
+		wantRule model.URLPolicyRule
+		wantErr  error
+	}{
+		{name: "allowed domain", url: "https://example.com/page"},
+		{name: "allowed subdomain", url: "http://www.Example.com/page"},
+		{name: "javascript scheme", url: "javascript:alert(1)", wantRule: model.URLPolicyScheme},
+		{name: "file scheme", url: "file:///etc/passwd", wantRule: model.URLPolicyScheme},
+		{name: "no host", url: "http:///page", wantErr: service.ErrURLWithoutHost},
+		{name: "base url host", url: "http://short.example.com:8080/abc123", wantRule: model.URLPolicySelfLink},
+		{name: "vanity domain", url: "https://GO.brand.com/abc123", wantRule: model.URLPolicySelfLink},
+		{name: "localhost", url: "http://localhost:3000", wantRule: model.URLPolicyPrivateTarget},
+		{name: "loopback ip", url: "http://127.0.0.1/admin", wantRule: model.URLPolicyPrivateTarget},
+		{name: "private ip", url: "http://192.168.1.10", wantRule: model.URLPolicyPrivateTarget},
+		{name: "short loopback ip", url: "http://127.1/admin", wantRule: model.URLPolicyPrivateTarget},
+		{name: "hex loopback ip", url: "http://0x7f000001/admin", wantRule: model.URLPolicyPrivateTarget},
+		{name: "decimal loopback ip", url: "http://2130706433/admin", wantRule: model.URLPolicyPrivateTarget},
+		{name: "octal private ip", url: "http://012.0.0.1/", wantRule: model.URLPolicyPrivateTarget},
+		{name: "link-local ipv6", url: "http://[fe80::1]/", wantRule: model.URLPolicyPrivateTarget},
+		{name: "cloud metadata", url: "http://169.254.169.254/latest", wantRule: model.URLPolicyPrivateTarget},
+		{name: "carrier-grade nat ip", url: "http://100.64.0.1/", wantRule: model.URLPolicyPrivateTarget},
+		{name: "ipv4-mapped loopback", url: "http://[::ffff:127.0.0.1]/", wantRule: model.URLPolicyPrivateTarget},
+		{name: "denied subdomain", url: "https://phish.evil.com", wantRule: model.URLPolicyDomainDenied},
+		{name: "domain not allowed", url: "https://other.org", wantRule: model.URLPolicyDomainNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(tt.url)
+			switch {
+			case tt.wantRule != "":
+				var violationErr *service.URLPolicyViolationError
+				require.True(t, errors.As(err, &violationErr), "got %v", err)
+				assert.Equal(t, tt.wantRule, violationErr.Rule)
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestURLPolicy_LoadDomainLists(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	policy := service.NewURLPolicy([]string{"https"}, nil, testLogger)
+	listsPath := filepath.Join(t.TempDir(), "policy.json")
+
+	assert.Error(t, policy.LoadDomainLists(listsPath), "missing file")
+	assert.NoError(t, policy.Check("https://evil.com"))
+	assert.Error(t, policy.Check("http://evil.com"), "only configured schemes are allowed")
+
+	require.NoError(t, os.WriteFile(listsPath, []byte(`{"deny": ["evil.com"]}`), 0o600))
+	require.NoError(t, policy.LoadDomainLists(listsPath))
+	assert.Error(t, policy.Check("https://evil.com"))
+	assert.NoError(t, policy.Check("https://sub.evil.com"), "plain patterns match the domain only")
+
+	require.NoError(t, os.WriteFile(listsPath, []byte(`{"deny": [`), 0o600))
+	assert.Error(t, policy.LoadDomainLists(listsPath))
+	assert.Error(t, policy.Check("https://evil.com"), "lists in use are kept on broken file")
+}
@@ -0,0 +1,312 @@
+package service_test
+
+import (
+	"context"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/mocks"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/repository"
+	"github.com/bezjen/shortener/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestCreateWorkspace(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	shortener := service.NewURLShortener(mockRepo, testLogger)
+
+	mockRepo.On("CreateWorkspace", mock.Anything, mock.MatchedBy(func(workspace model.Workspace) bool {
+		return workspace.ID != "" && workspace.Name == "Marketing"
+	}), "owner-user").Return(nil)
+
+	workspace, err := shortener.CreateWorkspace(context.Background(), "owner-user", "Marketing")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, workspace.ID)
+	assert.Equal(t, "Marketing", workspace.Name)
+	assert.Equal(t, model.RoleOwner, workspace.Role)
+}
+
+func TestGenerateWorkspaceShortURLPart(t *testing.T) {
+	tests := []struct {
+		name        string
+		role        model.WorkspaceRole
+		roleErr     error
+		expectSave  bool
+		expectedErr error
+	}{
+		{name: "Owner creates link", role: model.RoleOwner, expectSave: true},
+		{name: "Editor creates link", role: model.RoleEditor, expectSave: true},
+		{name: "Viewer is forbidden", role: model.RoleViewer, expectedErr: service.ErrWorkspaceForbidden},
+		{name: "Non-member is forbidden", roleErr: repository.ErrNotFound, expectedErr: service.ErrWorkspaceForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testLogger, _ := logger.NewLogger("debug")
+			mockRepo := new(mocks.Repository)
+			shortener := service.NewURLShortener(mockRepo, testLogger)
+
+			mockRepo.On("GetWorkspaceRole", mock.Anything, "ws1", "test-user").Return(tt.role, tt.roleErr)
+			if tt.expectSave {
+				mockRepo.On("Save", mock.Anything, "test-user", mock.MatchedBy(func(url model.URL) bool {
+					return url.WorkspaceID == "ws1" && url.OriginalURL == "https://example.com"
+				})).Return(nil)
+			}
+
+			shortURL, err := shortener.GenerateWorkspaceShortURLPart(context.Background(),
+				"test-user", "ws1", "", "https://example.com")
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 8, len(shortURL))
+		})
+	}
+}
+
+func TestGetURLsByWorkspaceID(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	shortener := service.NewURLShortener(mockRepo, testLogger)
+
+	expected := []model.URL{{ShortURL: "abc123", OriginalURL: "https://example.com", WorkspaceID: "ws1"}}
+	mockRepo.On("GetWorkspaceRole", mock.Anything, "ws1", "viewer-user").Return(model.RoleViewer, nil)
+	mockRepo.On("GetByWorkspaceID", mock.Anything, "ws1").Return(expected, nil)
+
+	urls, err := shortener.GetURLsByWorkspaceID(context.Background(), "viewer-user", "ws1")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, urls)
+}
+
+func TestDeleteWorkspaceShortURLsBatch(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	shortener := service.NewURLShortener(mockRepo, testLogger)
+
+	mockRepo.On("GetWorkspaceRole", mock.Anything, "ws1", "editor-user").Return(model.RoleEditor, nil)
+	mockRepo.On("GetWorkspaceRole", mock.Anything, "ws1", "viewer-user").Return(model.RoleViewer, nil)
+	mockRepo.On("DeleteWorkspaceBatch", mock.Anything, "go.brand.com", "ws1", []string{"abc123"}).Return(nil)
+
+	err := shortener.DeleteWorkspaceShortURLsBatch(context.Background(), "viewer-user", "ws1", "go.brand.com",
+		[]string{"abc123"})
+	assert.ErrorIs(t, err, service.ErrWorkspaceForbidden)
+	mockRepo.AssertNotCalled(t, "DeleteWorkspaceBatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	err = shortener.DeleteWorkspaceShortURLsBatch(context.Background(), "editor-user", "ws1", "go.brand.com",
+		[]string{"abc123"})
+	assert.NoError(t, err)
+	mockRepo.AssertCalled(t, "DeleteWorkspaceBatch", mock.Anything, "go.brand.com", "ws1", []string{"abc123"})
+}
+
+func TestGetDeletedURLsByWorkspaceID(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	shortener := service.NewURLShortener(mockRepo, testLogger)
+
+	expected := []model.URL{{ShortURL: "abc123", OriginalURL: "https://example.com", WorkspaceID: "ws1", IsDeleted: true}}
+	mockRepo.On("GetWorkspaceRole", mock.Anything, "ws1", "viewer-user").Return(model.RoleViewer, nil)
+	mockRepo.On("GetWorkspaceRole", mock.Anything, "ws1", "stranger").
+		Return(model.WorkspaceRole(""), repository.ErrNotFound)
+	mockRepo.On("GetDeletedByWorkspaceID", mock.Anything, "ws1").Return(expected, nil)
+
+	urls, err := shortener.GetDeletedURLsByWorkspaceID(context.Background(), "stranger", "ws1")
+	assert.ErrorIs(t, err, service.ErrWorkspaceForbidden)
+	assert.Nil(t, urls)
+
+	urls, err = shortener.GetDeletedURLsByWorkspaceID(context.Background(), "viewer-user", "ws1")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, urls)
+}
+
+func TestRestoreWorkspaceShortURLsBatch(t *testing.T) {
+	testLogger, _ := logger.NewLogger("debug")
+	mockRepo := new(mocks.Repository)
+	shortener := service.NewURLShortener(mockRepo, testLogger)
+
+	mockRepo.On("GetWorkspaceRole", mock.Anything, "ws1", "editor-user").Return(model.RoleEditor, nil)
+	mockRepo.On("GetWorkspaceRole", mock.Anything, "ws1", "viewer-user").Return(model.RoleViewer, nil)
+	mockRepo.On("RestoreWorkspaceBatch", mock.Anything, "go.brand.com", "ws1", []string{"abc123"}).Return(nil)
+
+	err := shortener.RestoreWorkspaceShortURLsBatch(context.Background(), "viewer-user", "ws1", "go.brand.com",
+		[]string{"abc123"})
+	assert.ErrorIs(t, err, service.ErrWorkspaceForbidden)
+	mockRepo.AssertNotCalled(t, "RestoreWorkspaceBatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	err = shortener.RestoreWorkspaceShortURLsBatch(context.Background(), "editor-user", "ws1", "go.brand.com",
+		[]string{"abc123"})
+	assert.NoError(t, err)
+	mockRepo.AssertCalled(t, "RestoreWorkspaceBatch", mock.Anything, "go.brand.com", "ws1", []string{"abc123"})
+}
+
+func TestUpdateWorkspaceShortURL(t *testing.T) {
+	tests := []struct {
+		name         string
+		role         model.WorkspaceRole
+		expectUpdate bool
+		updateErr    error
+		expectedErr  error
+	}{
+		{name: "Editor changes link", role: model.RoleEditor, expectUpdate: true},
+		{name: "Viewer is forbidden", role: model.RoleViewer, expectedErr: service.ErrWorkspaceForbidden},
+		{
+			name:         "Link not in workspace",
+			role:         model.RoleOwner,
+			expectUpdate: true,
+			updateErr:    repository.ErrNotFound,
+			expectedErr:  repository.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testLogger, _ := logger.NewLogger("debug")
+			mockRepo := new(mocks.Repository)
+			shortener := service.NewURLShortener(mockRepo, testLogger)
+
+			mockRepo.On("GetWorkspaceRole", mock.Anything, "ws1", "test-user").Return(tt.role, nil)
+			if tt.expectUpdate {
+				mockRepo.On("UpdateWorkspaceURL", mock.Anything, "ws1", mock.MatchedBy(func(url model.URL) bool {
+					return url.ShortURL == "abc123" && url.Domain == "go.brand.com" &&
+						url.WorkspaceID == "ws1" && url.OriginalURL == "https://Example.com" &&
+						url.CanonicalURL == "https://example.com/"
+				})).Return(tt.updateErr)
+			}
+
+			err := shortener.UpdateWorkspaceShortURL(context.Background(),
+				"test-user", "ws1", "go.brand.com", "abc123", "https://Example.com")
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			if !tt.expectUpdate {
+				mockRepo.AssertNotCalled(t, "UpdateWorkspaceURL", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestSetWorkspaceMember(t *testing.T) {
+	singleOwner := []model.WorkspaceMember{
+		{WorkspaceID: "ws1", UserID: "owner-user", Role: model.RoleOwner},
+		{WorkspaceID: "ws1", UserID: "editor-user", Role: model.RoleEditor},
+	}
+
+	tests := []struct {
+		name        string
+		userID      string
+		member      model.WorkspaceMember
+		expectSave  bool
+		expectedErr error
+	}{
+		{
+			name:       "Owner adds viewer",
+			userID:     "owner-user",
+			member:     model.WorkspaceMember{WorkspaceID: "ws1", UserID: "new-user", Role: model.RoleViewer},
+			expectSave: true,
+		},
+		{
+			name:       "Owner promotes editor to owner",
+			userID:     "owner-user",
+			member:     model.WorkspaceMember{WorkspaceID: "ws1", UserID: "editor-user", Role: model.RoleOwner},
+			expectSave: true,
+		},
+		{
+			name:        "Editor cannot manage members",
+			userID:      "editor-user",
+			member:      model.WorkspaceMember{WorkspaceID: "ws1", UserID: "new-user", Role: model.RoleViewer},
+			expectedErr: service.ErrWorkspaceForbidden,
+		},
+		{
+			name:        "Unknown role",
+			userID:      "owner-user",
+			member:      model.WorkspaceMember{WorkspaceID: "ws1", UserID: "new-user", Role: "admin"},
+			expectedErr: service.ErrInvalidWorkspaceRole,
+		},
+		{
+			name:        "Last owner cannot be demoted",
+			userID:      "owner-user",
+			member:      model.WorkspaceMember{WorkspaceID: "ws1", UserID: "owner-user", Role: model.RoleEditor},
+			expectedErr: service.ErrLastWorkspaceOwner,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testLogger, _ := logger.NewLogger("debug")
+			mockRepo := new(mocks.Repository)
+			shortener := service.NewURLShortener(mockRepo, testLogger)
+
+			mockRepo.On("GetWorkspaceRole", mock.Anything, "ws1", "owner-user").Return(model.RoleOwner, nil)
+			mockRepo.On("GetWorkspaceRole", mock.Anything, "ws1", "editor-user").Return(model.RoleEditor, nil)
+			mockRepo.On("GetWorkspaceMembers", mock.Anything, "ws1").Return(singleOwner, nil)
+			mockRepo.On("SaveWorkspaceMember", mock.Anything, tt.member).Return(nil)
+
+			err := shortener.SetWorkspaceMember(context.Background(), tt.userID, tt.member)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				mockRepo.AssertNotCalled(t, "SaveWorkspaceMember", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			mockRepo.AssertCalled(t, "SaveWorkspaceMember", mock.Anything, tt.member)
+		})
+	}
+}
+
+func TestRemoveWorkspaceMember(t *testing.T) {
+	members := []model.WorkspaceMember{
+		{WorkspaceID: "ws1", UserID: "owner-user", Role: model.RoleOwner},
+		{WorkspaceID: "ws1", UserID: "editor-user", Role: model.RoleEditor},
+		{WorkspaceID: "ws1", UserID: "viewer-user", Role: model.RoleViewer},
+	}
+
+	tests := []struct {
+		name        string
+		userID      string
+		memberID    string
+		expectedErr error
+	}{
+		{name: "Owner removes editor", userID: "owner-user", memberID: "editor-user"},
+		{name: "Viewer leaves workspace", userID: "viewer-user", memberID: "viewer-user"},
+		{
+			name:        "Editor cannot remove others",
+			userID:      "editor-user",
+			memberID:    "viewer-user",
+			expectedErr: service.ErrWorkspaceForbidden,
+		},
+		{
+			name:        "Last owner cannot leave",
+			userID:      "owner-user",
+			memberID:    "owner-user",
+			expectedErr: service.ErrLastWorkspaceOwner,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testLogger, _ := logger.NewLogger("debug")
+			mockRepo := new(mocks.Repository)
+			shortener := service.NewURLShortener(mockRepo, testLogger)
+
+			for _, member := range members {
+				mockRepo.On("GetWorkspaceRole", mock.Anything, "ws1", member.UserID).Return(member.Role, nil)
+			}
+			mockRepo.On("GetWorkspaceMembers", mock.Anything, "ws1").Return(members, nil)
+			mockRepo.On("DeleteWorkspaceMember", mock.Anything, "ws1", tt.memberID).Return(nil)
+
+			err := shortener.RemoveWorkspaceMember(context.Background(), tt.userID, "ws1", tt.memberID)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				mockRepo.AssertNotCalled(t, "DeleteWorkspaceMember", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			mockRepo.AssertCalled(t, "DeleteWorkspaceMember", mock.Anything, "ws1", tt.memberID)
+		})
+	}
+}
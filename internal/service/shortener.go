@@ -10,7 +10,10 @@ import (
 	"github.com/bezjen/shortener/internal/repository"
 	"go.uber.org/zap"
 	"math/big"
+	"slices"
+	"sort"
 	"sync"
+	"time"
 )
 
 const (
@@ -20,6 +23,8 @@ const (
 	charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 	// maxAttemptsCount defines maximum attempts to generate unique short URL.
 	maxAttemptsCount = 10
+	// trashPurgeInterval defines how often deleted URLs are checked for purging.
+	trashPurgeInterval = time.Hour
 )
 
 // ErrGenerate is returned when short URL generation fails after maximum attempts.
@@ -33,47 +38,91 @@ type Shortener interface {
 	// Parameters:
 	//   - ctx: context for request cancellation and timeouts
 	//   - userID: identifier of the user creating the short URL
+	//   - domain: domain the short URL is created on, empty for the base URL host
 	//   - url: original URL to be shortened
 	//
 	// Returns:
 	//   - string: generated short URL identifier
-	//   - error: error if generation fails
-	GenerateShortURLPart(ctx context.Context, userID string, url string) (string, error)
+	//   - error: *QuotaExceededError, or error if generation fails
+	GenerateShortURLPart(ctx context.Context, userID string, domain string, url string) (string, error)
 
 	// GenerateShortURLPartBatch creates multiple short URLs in a single batch operation.
 	//
 	// Parameters:
 	//   - ctx: context for request cancellation and timeouts
 	//   - userID: identifier of the user creating the short URLs
+	//   - domain: domain the short URLs are created on, empty for the base URL host
 	//   - urls: slice of URLs to be shortened with correlation IDs
 	//
 	// Returns:
 	//   - []model.ShortenBatchResponseItem: slice of generated short URLs with correlation IDs
-	//   - error: error if batch generation fails
-	GenerateShortURLPartBatch(ctx context.Context, userID string,
+	//   - error: *QuotaExceededError, or error if batch generation fails
+	GenerateShortURLPartBatch(ctx context.Context, userID string, domain string,
 		urls []model.ShortenBatchRequestItem) ([]model.ShortenBatchResponseItem, error)
 
+	// GenerateBundle creates a short identifier resolving to a page with several links.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the user creating the bundle
+	//   - domain: domain the bundle is created on, empty for the base URL host
+	//   - title: bundle page title
+	//   - links: bundle destinations, ordered by their position
+	//
+	// Returns:
+	//   - string: generated short identifier of the bundle
+	//   - error: *QuotaExceededError, or error if generation fails
+	GenerateBundle(ctx context.Context, userID string, domain string, title string,
+		links []model.BundleLink) (string, error)
+
+	// GetBundleByShortURLPart retrieves a bundle with its links by short identifier within a domain.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - domain: domain the bundle belongs to, empty for the base URL host
+	//   - shortURLPart: short identifier of the bundle
+	//
+	// Returns:
+	//   - *model.Bundle: found bundle with links in display order
+	//   - error: error if bundle is not found or lookup fails
+	GetBundleByShortURLPart(ctx context.Context, domain string, shortURLPart string) (*model.Bundle, error)
+
 	// DeleteUserShortURLsBatch marks user's short URLs as deleted using async processing.
 	//
 	// Parameters:
 	//   - ctx: context for request cancellation and timeouts
 	//   - userID: identifier of the user owning the short URLs
+	//   - domain: domain the short URLs belong to, empty for the base URL host
 	//   - shortURLs: slice of short URL identifiers to mark as deleted
 	//
 	// Returns:
-	//   - error: error if the deletion queue is full or processing fails
-	DeleteUserShortURLsBatch(ctx context.Context, userID string, shortURLs []string) error
+	//   - error: *QuotaExceededError if the batch is too large, or error if the deletion queue is full
+	DeleteUserShortURLsBatch(ctx context.Context, userID string, domain string, shortURLs []string) error
 
-	// GetURLByShortURLPart retrieves the original URL by its short identifier.
+	// GetURLByShortURLPart retrieves the original URL by its short identifier within a domain.
 	//
 	// Parameters:
 	//   - ctx: context for request cancellation and timeouts
+	//   - domain: domain the short URL belongs to, empty for the base URL host
 	//   - shortURLPart: short URL identifier to look up
 	//
 	// Returns:
 	//   - *model.URL: found URL object containing original URL and metadata
 	//   - error: error if URL is not found or lookup fails
-	GetURLByShortURLPart(ctx context.Context, shortURLPart string) (*model.URL, error)
+	GetURLByShortURLPart(ctx context.Context, domain string, shortURLPart string) (*model.URL, error)
+
+	// LookupShortURLPart finds the existing link to an original URL within a domain without creating one.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: owner of the link, empty to find links of any user
+	//   - domain: domain the short URL belongs to, empty for the base URL host
+	//   - originalURL: original URL to look up
+	//
+	// Returns:
+	//   - *model.URL: found URL object
+	//   - error: repository.ErrNotFound if no link to the original URL exists, or error if lookup fails
+	LookupShortURLPart(ctx context.Context, userID string, domain string, originalURL string) (*model.URL, error)
 
 	// GetURLsByUserID retrieves all URLs created by a specific user.
 	//
@@ -86,6 +135,180 @@ type Shortener interface {
 	//   - error: error if lookup fails
 	GetURLsByUserID(ctx context.Context, userID string) ([]model.URL, error)
 
+	// GetDeletedURLsByUserID retrieves all URLs of a specific user that were moved to the trash.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: user identifier to look up deleted URLs for
+	//
+	// Returns:
+	//   - []model.URL: slice of deleted URLs with deletion time
+	//   - error: error if lookup fails
+	GetDeletedURLsByUserID(ctx context.Context, userID string) ([]model.URL, error)
+
+	// RestoreUserShortURLsBatch restores user's short URLs from the trash.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the user owning the short URLs
+	//   - domain: domain the short URLs belong to, empty for the base URL host
+	//   - shortURLs: slice of short URL identifiers to restore
+	//
+	// Returns:
+	//   - error: error if restore fails
+	RestoreUserShortURLsBatch(ctx context.Context, userID string, domain string, shortURLs []string) error
+
+	// CreateWorkspace creates a new workspace owned by the user.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the user becoming the workspace owner
+	//   - name: display name of the workspace
+	//
+	// Returns:
+	//   - *model.Workspace: created workspace with the owner role
+	//   - error: error if creation fails
+	CreateWorkspace(ctx context.Context, userID string, name string) (*model.Workspace, error)
+
+	// GetUserWorkspaces retrieves all workspaces the user is a member of.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: user identifier to look up workspaces for
+	//
+	// Returns:
+	//   - []model.Workspace: slice of workspaces with the user's role
+	//   - error: error if lookup fails
+	GetUserWorkspaces(ctx context.Context, userID string) ([]model.Workspace, error)
+
+	// GetWorkspaceMembers retrieves all members of a workspace visible to the user.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the requesting user
+	//   - workspaceID: identifier of the workspace
+	//
+	// Returns:
+	//   - []model.WorkspaceMember: slice of members with their roles
+	//   - error: ErrWorkspaceForbidden if the user is not a member, or lookup error
+	GetWorkspaceMembers(ctx context.Context, userID string, workspaceID string) ([]model.WorkspaceMember, error)
+
+	// SetWorkspaceMember adds a member to a workspace or changes the role of an existing member.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the requesting user, must be an owner
+	//   - member: workspace member with role
+	//
+	// Returns:
+	//   - error: ErrWorkspaceForbidden, ErrInvalidWorkspaceRole, ErrLastWorkspaceOwner or storage error
+	SetWorkspaceMember(ctx context.Context, userID string, member model.WorkspaceMember) error
+
+	// RemoveWorkspaceMember removes a member from a workspace.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the requesting user, must be an owner or the member itself
+	//   - workspaceID: identifier of the workspace
+	//   - memberID: identifier of the member to remove
+	//
+	// Returns:
+	//   - error: ErrWorkspaceForbidden, ErrLastWorkspaceOwner or storage error
+	RemoveWorkspaceMember(ctx context.Context, userID string, workspaceID string, memberID string) error
+
+	// GenerateWorkspaceShortURLPart creates a short URL identifier owned by a workspace.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the user creating the short URL, must be an editor or owner
+	//   - workspaceID: identifier of the workspace owning the short URL
+	//   - domain: domain the short URL is created on, empty for the base URL host
+	//   - url: original URL to be shortened
+	//
+	// Returns:
+	//   - string: generated short URL identifier
+	//   - error: ErrWorkspaceForbidden, *QuotaExceededError or error if generation fails
+	GenerateWorkspaceShortURLPart(ctx context.Context, userID string, workspaceID string, domain string,
+		url string) (string, error)
+
+	// GetURLsByWorkspaceID retrieves all URLs of a workspace.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the requesting user
+	//   - workspaceID: identifier of the workspace
+	//
+	// Returns:
+	//   - []model.URL: slice of URLs of the workspace
+	//   - error: ErrWorkspaceForbidden if the user is not a member, or lookup error
+	GetURLsByWorkspaceID(ctx context.Context, userID string, workspaceID string) ([]model.URL, error)
+
+	// DeleteWorkspaceShortURLsBatch moves short URLs of a workspace to the trash.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the requesting user, must be an editor or owner
+	//   - workspaceID: identifier of the workspace owning the short URLs
+	//   - domain: domain the short URLs belong to, empty for the base URL host
+	//   - shortURLs: slice of short URL identifiers to delete
+	//
+	// Returns:
+	//   - error: ErrWorkspaceForbidden, *QuotaExceededError or error if deletion fails
+	DeleteWorkspaceShortURLsBatch(ctx context.Context, userID string, workspaceID string, domain string,
+		shortURLs []string) error
+
+	// GetDeletedURLsByWorkspaceID retrieves all URLs of a workspace that were moved to the trash.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the requesting user
+	//   - workspaceID: identifier of the workspace
+	//
+	// Returns:
+	//   - []model.URL: slice of deleted URLs with deletion time
+	//   - error: ErrWorkspaceForbidden if the user is not a member, or lookup error
+	GetDeletedURLsByWorkspaceID(ctx context.Context, userID string, workspaceID string) ([]model.URL, error)
+
+	// RestoreWorkspaceShortURLsBatch restores short URLs of a workspace from the trash.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the requesting user
+	//   - workspaceID: identifier of the workspace owning the short URLs
+	//   - domain: domain the short URLs belong to, empty for the base URL host
+	//   - shortURLs: slice of short URL identifiers to restore
+	//
+	// Returns:
+	//   - error: ErrWorkspaceForbidden or error if restore fails
+	RestoreWorkspaceShortURLsBatch(ctx context.Context, userID string, workspaceID string, domain string,
+		shortURLs []string) error
+
+	// UpdateWorkspaceShortURL changes the original URL of a short URL owned by a workspace.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//   - userID: identifier of the requesting user
+	//   - workspaceID: identifier of the workspace owning the short URL
+	//   - domain: domain of the short URL, empty for the base URL host
+	//   - shortURL: short URL identifier
+	//   - url: new original URL
+	//
+	// Returns:
+	//   - error: ErrWorkspaceForbidden, repository.ErrNotFound, *repository.ErrURLConflict,
+	//     repository.ErrURLReserved or error if update fails
+	UpdateWorkspaceShortURL(ctx context.Context, userID string, workspaceID string, domain string,
+		shortURL string, url string) error
+
+	// GetStats counts links that are not deleted and distinct users who created links.
+	//
+	// Parameters:
+	//   - ctx: context for request cancellation and timeouts
+	//
+	// Returns:
+	//   - *model.Stats: link and user counts
+	//   - error: error if counting fails
+	GetStats(ctx context.Context) (*model.Stats, error)
+
 	// PingRepository checks the connectivity to the underlying data storage.
 	//
 	// Parameters:
@@ -99,15 +322,23 @@ type Shortener interface {
 // URLShortener implements the Shortener interface with background deletion workers.
 // It provides URL shortening functionality with async batch deletion support.
 type URLShortener struct {
-	storage     repository.Repository
-	logger      *logger.Logger
-	deleteQueue chan deleteTask
-	wg          sync.WaitGroup
+	storage       repository.Repository
+	logger        *logger.Logger
+	deleteQueue   chan deleteTask
+	done          chan struct{}
+	wg            sync.WaitGroup
+	quotasEnabled bool
+	defaultQuota  model.QuotaLimits
+
+	phishingDetector *PhishingDetector
+	canonicalizer    *URLCanonicalizer
+	metadataFetcher  *MetadataFetcher
 }
 
 // deleteTask represents a batch deletion request for background processing.
 type deleteTask struct {
 	userID    string
+	domain    string
 	shortURLs []string
 }
 
@@ -121,9 +352,11 @@ type deleteTask struct {
 //   - *URLShortener: initialized URL shortener service
 func NewURLShortener(storage repository.Repository, logger *logger.Logger) *URLShortener {
 	shortener := &URLShortener{
-		storage:     storage,
-		logger:      logger,
-		deleteQueue: make(chan deleteTask, 1000),
+		storage:       storage,
+		logger:        logger,
+		deleteQueue:   make(chan deleteTask, 1000),
+		done:          make(chan struct{}),
+		canonicalizer: NewURLCanonicalizer(false),
 	}
 	for i := 0; i < 5; i++ {
 		shortener.wg.Add(1)
@@ -138,24 +371,66 @@ func NewURLShortener(storage repository.Repository, logger *logger.Logger) *URLS
 // Parameters:
 //   - ctx: context for request cancellation and timeouts
 //   - userID: identifier of the user creating the short URL
+//   - domain: domain the short URL is created on, empty for the base URL host
 //   - url: original URL to be shortened
 //
 // Returns:
 //   - string: generated short URL identifier
-//   - error: error if generation fails after maximum attempts
-func (u *URLShortener) GenerateShortURLPart(ctx context.Context, userID string, url string) (string, error) {
+//   - error: *QuotaExceededError, or error if generation fails after maximum attempts
+func (u *URLShortener) GenerateShortURLPart(ctx context.Context,
+	userID string,
+	domain string,
+	url string,
+) (string, error) {
+	return u.saveShortURL(ctx, userID, "", domain, url)
+}
+
+// saveShortURL generates a unique short URL identifier and stores the URL.
+// It attempts to generate a unique identifier up to maxAttemptsCount times.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user creating the short URL
+//   - workspaceID: identifier of the owning workspace, empty for personal URLs
+//   - domain: domain the short URL is created on, empty for the base URL host
+//   - url: original URL to be shortened
+//
+// Returns:
+//   - string: generated short URL identifier
+//   - error: *QuotaExceededError, or error if generation fails after maximum attempts
+func (u *URLShortener) saveShortURL(ctx context.Context,
+	userID string,
+	workspaceID string,
+	domain string,
+	url string,
+) (string, error) {
+	release, err := u.reserveLinks(ctx, userID, 1)
+	if err != nil {
+		return "", err
+	}
+	var created int64
+	defer func() { release(created) }()
+	reviewReasons := u.reviewReasons(userID, url)
+	canonicalURL := u.canonicalizer.Canonicalize(url)
 	for i := 0; i < maxAttemptsCount; i++ {
 		shortURL, err := generateRandomString(shortURLLength)
 		if err != nil {
 			return "", err
 		}
-		err = u.storage.Save(ctx, userID, *model.NewURL(shortURL, url))
+		newURL := model.NewURL(shortURL, url)
+		newURL.Domain = domain
+		newURL.WorkspaceID = workspaceID
+		newURL.CanonicalURL = canonicalURL
+		newURL.ReviewReasons = reviewReasons
+		err = u.storage.Save(ctx, userID, *newURL)
 		if err != nil {
 			if errors.Is(err, repository.ErrShortURLConflict) {
 				continue
 			}
 			return "", err
 		}
+		created = 1
+		u.enqueueMetadata(*newURL)
 		return shortURL, nil
 	}
 	return "", ErrGenerate
@@ -167,24 +442,45 @@ func (u *URLShortener) GenerateShortURLPart(ctx context.Context, userID string,
 // Parameters:
 //   - ctx: context for request cancellation and timeouts
 //   - userID: identifier of the user creating the short URLs
+//   - domain: domain the short URLs are created on, empty for the base URL host
 //   - urls: slice of URLs to be shortened with correlation IDs
 //
 // Returns:
 //   - []model.ShortenBatchResponseItem: slice of generated short URLs with correlation IDs
-//   - error: error if batch generation fails after maximum attempts
+//   - error: *QuotaExceededError, or error if batch generation fails after maximum attempts
 func (u *URLShortener) GenerateShortURLPartBatch(ctx context.Context,
 	userID string,
+	domain string,
 	urls []model.ShortenBatchRequestItem,
 ) ([]model.ShortenBatchResponseItem, error) {
+	if err := u.checkBatchQuota(ctx, userID, model.QuotaBatchSize, len(urls)); err != nil {
+		return nil, err
+	}
+	release, err := u.reserveLinks(ctx, userID, int64(len(urls)))
+	if err != nil {
+		return nil, err
+	}
+	var created int64
+	defer func() { release(created) }()
+	reviewReasons := make([][]model.ReviewReason, len(urls))
+	canonicalURLs := make([]string, len(urls))
+	for i, url := range urls {
+		reviewReasons[i] = u.reviewReasons(userID, url.OriginalURL)
+		canonicalURLs[i] = u.canonicalizer.Canonicalize(url.OriginalURL)
+	}
 	for i := 0; i < maxAttemptsCount; i++ {
 		var generatedURLs []model.URL
 		var response []model.ShortenBatchResponseItem
-		for _, url := range urls {
+		for j, url := range urls {
 			shortURL, err := generateRandomString(shortURLLength)
 			if err != nil {
 				return nil, err
 			}
-			generatedURLs = append(generatedURLs, *model.NewURL(shortURL, url.OriginalURL))
+			generatedURL := model.NewURL(shortURL, url.OriginalURL)
+			generatedURL.Domain = domain
+			generatedURL.CanonicalURL = canonicalURLs[j]
+			generatedURL.ReviewReasons = reviewReasons[j]
+			generatedURLs = append(generatedURLs, *generatedURL)
 			response = append(response, *model.NewShortenBatchResponseItem(url.CorrelationID, shortURL))
 		}
 		err := u.storage.SaveBatch(ctx, userID, generatedURLs)
@@ -194,48 +490,167 @@ func (u *URLShortener) GenerateShortURLPartBatch(ctx context.Context,
 			}
 			return nil, err
 		}
+		created = int64(len(generatedURLs))
+		u.enqueueMetadata(generatedURLs...)
 		return response, nil
 	}
 	return nil, ErrGenerate
 }
 
+// GenerateBundle creates a short identifier resolving to a page with several links.
+// Links are sorted by position, links with equal positions keep their order.
+// Every destination is checked by the phishing heuristics, the bundle is held for review if any of them matches.
+// It attempts to generate a unique identifier up to maxAttemptsCount times.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user creating the bundle
+//   - domain: domain the bundle is created on, empty for the base URL host
+//   - title: bundle page title
+//   - links: bundle destinations
+//
+// Returns:
+//   - string: generated short identifier of the bundle
+//   - error: *QuotaExceededError, or error if generation fails after maximum attempts
+func (u *URLShortener) GenerateBundle(ctx context.Context,
+	userID string,
+	domain string,
+	title string,
+	links []model.BundleLink,
+) (string, error) {
+	release, err := u.reserveLinks(ctx, userID, 1)
+	if err != nil {
+		return "", err
+	}
+	var created int64
+	defer func() { release(created) }()
+	orderedLinks := make([]model.BundleLink, len(links))
+	copy(orderedLinks, links)
+	sort.SliceStable(orderedLinks, func(i, j int) bool {
+		return orderedLinks[i].Position < orderedLinks[j].Position
+	})
+	var reviewReasons []model.ReviewReason
+	for _, link := range orderedLinks {
+		for _, reason := range u.reviewReasons(userID, link.URL) {
+			if !slices.Contains(reviewReasons, reason) {
+				reviewReasons = append(reviewReasons, reason)
+			}
+		}
+	}
+
+	for i := 0; i < maxAttemptsCount; i++ {
+		shortURL, err := generateRandomString(shortURLLength)
+		if err != nil {
+			return "", err
+		}
+		bundle := model.NewBundle(shortURL, title, orderedLinks)
+		bundle.Domain = domain
+		bundle.ReviewReasons = reviewReasons
+		err = u.storage.SaveBundle(ctx, userID, *bundle)
+		if err != nil {
+			if errors.Is(err, repository.ErrShortURLConflict) {
+				continue
+			}
+			return "", err
+		}
+		created = 1
+		return shortURL, nil
+	}
+	return "", ErrGenerate
+}
+
+// GetBundleByShortURLPart retrieves a bundle with its links by short identifier within a domain.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - domain: domain the bundle belongs to, empty for the base URL host
+//   - shortURLPart: short identifier of the bundle
+//
+// Returns:
+//   - *model.Bundle: found bundle with links in display order
+//   - error: error if bundle is not found or lookup fails
+func (u *URLShortener) GetBundleByShortURLPart(ctx context.Context,
+	domain string,
+	shortURLPart string,
+) (*model.Bundle, error) {
+	return u.storage.GetBundleByShortURL(ctx, domain, shortURLPart)
+}
+
 // DeleteUserShortURLsBatch marks user's short URLs as deleted using async processing.
 // The deletion requests are queued and processed by background workers.
 //
 // Parameters:
 //   - ctx: context for request cancellation and timeouts
 //   - userID: identifier of the user owning the short URLs
+//   - domain: domain the short URLs belong to, empty for the base URL host
 //   - shortURLs: slice of short URL identifiers to mark as deleted
 //
 // Returns:
-//   - error: error if the deletion queue is full
-func (u *URLShortener) DeleteUserShortURLsBatch(_ context.Context, userID string, shortURLs []string) error {
+//   - error: *QuotaExceededError if the batch is too large, or error if the deletion queue is full
+func (u *URLShortener) DeleteUserShortURLsBatch(ctx context.Context,
+	userID string,
+	domain string,
+	shortURLs []string,
+) error {
+	if err := u.checkBatchQuota(ctx, userID, model.QuotaDeleteBatchSize, len(shortURLs)); err != nil {
+		return err
+	}
 	select {
-	case u.deleteQueue <- deleteTask{userID: userID, shortURLs: shortURLs}:
+	case u.deleteQueue <- deleteTask{userID: userID, domain: domain, shortURLs: shortURLs}:
 		return nil
 	default:
 		return errors.New("delete queue is full")
 	}
 }
 
-// GetURLByShortURLPart retrieves the original URL by its short identifier.
+// GetURLByShortURLPart retrieves the original URL by its short identifier within a domain.
 // Returns the URL object containing the original URL and metadata.
 //
 // Parameters:
 //   - ctx: context for request cancellation and timeouts
+//   - domain: domain the short URL belongs to, empty for the base URL host
 //   - shortURLPart: short URL identifier to look up
 //
 // Returns:
 //   - *model.URL: found URL object containing original URL and metadata
 //   - error: error if URL is not found or lookup fails
-func (u *URLShortener) GetURLByShortURLPart(ctx context.Context, shortURLPart string) (*model.URL, error) {
-	resultURL, err := u.storage.GetByShortURL(ctx, shortURLPart)
+func (u *URLShortener) GetURLByShortURLPart(ctx context.Context,
+	domain string,
+	shortURLPart string,
+) (*model.URL, error) {
+	resultURL, err := u.storage.GetByShortURL(ctx, domain, shortURLPart)
 	if err != nil {
 		return nil, err
 	}
 	return resultURL, nil
 }
 
+// LookupShortURLPart finds the existing link to an original URL within a domain without creating one.
+// The original URL is canonicalized like destinations of new links. Links created before canonicalization
+// are found by the original URL as given.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: owner of the link, empty to find links of any user
+//   - domain: domain the short URL belongs to, empty for the base URL host
+//   - originalURL: original URL to look up
+//
+// Returns:
+//   - *model.URL: found URL object
+//   - error: repository.ErrNotFound if no link to the original URL exists, or error if lookup fails
+func (u *URLShortener) LookupShortURLPart(ctx context.Context,
+	userID string,
+	domain string,
+	originalURL string,
+) (*model.URL, error) {
+	canonicalURL := u.canonicalizer.Canonicalize(originalURL)
+	resultURL, err := u.storage.GetByOriginalURL(ctx, domain, userID, canonicalURL)
+	if errors.Is(err, repository.ErrNotFound) && canonicalURL != originalURL {
+		return u.storage.GetByOriginalURL(ctx, domain, userID, originalURL)
+	}
+	return resultURL, err
+}
+
 // GetURLsByUserID retrieves all URLs created by a specific user.
 // Returns an empty slice if no URLs are found for the user.
 //
@@ -250,6 +665,72 @@ func (u *URLShortener) GetURLsByUserID(ctx context.Context, userID string) ([]mo
 	return u.storage.GetByUserID(ctx, userID)
 }
 
+// GetDeletedURLsByUserID retrieves all URLs of a specific user that were moved to the trash.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: user identifier to look up deleted URLs for
+//
+// Returns:
+//   - []model.URL: slice of deleted URLs with deletion time
+//   - error: error if lookup fails
+func (u *URLShortener) GetDeletedURLsByUserID(ctx context.Context, userID string) ([]model.URL, error) {
+	return u.storage.GetDeletedByUserID(ctx, userID)
+}
+
+// RestoreUserShortURLsBatch restores user's short URLs from the trash synchronously.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user owning the short URLs
+//   - domain: domain the short URLs belong to, empty for the base URL host
+//   - shortURLs: slice of short URL identifiers to restore
+//
+// Returns:
+//   - error: error if restore fails
+func (u *URLShortener) RestoreUserShortURLsBatch(ctx context.Context,
+	userID string,
+	domain string,
+	shortURLs []string,
+) error {
+	return u.storage.RestoreBatch(ctx, domain, userID, shortURLs)
+}
+
+// PurgeDeletedURLs permanently removes URLs that have been in the trash longer than retention.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - retention: how long deleted URLs are kept before purging
+//
+// Returns:
+//   - int64: number of purged URLs
+//   - error: error if purge fails
+func (u *URLShortener) PurgeDeletedURLs(ctx context.Context, retention time.Duration) (int64, error) {
+	return u.storage.PurgeDeleted(ctx, time.Now().Add(-retention))
+}
+
+// StartTrashPurge starts a background worker that purges deleted URLs older than retention.
+// The first purge runs immediately, next ones every trashPurgeInterval until Close is called.
+//
+// Parameters:
+//   - retention: how long deleted URLs are kept before purging
+func (u *URLShortener) StartTrashPurge(retention time.Duration) {
+	u.wg.Add(1)
+	go u.purgeWorker(retention)
+}
+
+// GetStats counts links that are not deleted and distinct users who created links.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//
+// Returns:
+//   - *model.Stats: link and user counts
+//   - error: error if counting fails
+func (u *URLShortener) GetStats(ctx context.Context) (*model.Stats, error) {
+	return u.storage.GetStats(ctx)
+}
+
 // PingRepository checks the connectivity to the underlying data storage.
 // Used for health checks and monitoring.
 //
@@ -265,6 +746,7 @@ func (u *URLShortener) PingRepository(ctx context.Context) error {
 // Close gracefully shuts down the URLShortener by stopping background workers.
 // It waits for all queued deletion tasks to complete before returning.
 func (u *URLShortener) Close() {
+	close(u.done)
 	close(u.deleteQueue)
 	u.wg.Wait()
 }
@@ -275,17 +757,42 @@ func (u *URLShortener) deleteWorker() {
 	defer u.wg.Done()
 
 	for task := range u.deleteQueue {
-		err := u.storage.DeleteBatch(context.Background(), task.userID, task.shortURLs)
+		err := u.storage.DeleteBatch(context.Background(), task.domain, task.userID, task.shortURLs)
 		if err != nil {
 			u.logger.Error("Failed to delete short urls for user",
 				zap.Error(err),
 				zap.Strings("shortURLs", task.shortURLs),
-				zap.String("userID", task.userID))
+				zap.String("userID", task.userID),
+				zap.String("domain", task.domain))
 			continue
 		}
 	}
 }
 
+// purgeWorker periodically removes URLs deleted more than retention ago.
+// It runs in its own goroutine until the shortener is closed.
+func (u *URLShortener) purgeWorker(retention time.Duration) {
+	defer u.wg.Done()
+
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := u.PurgeDeletedURLs(context.Background(), retention)
+		if err != nil {
+			u.logger.Error("Failed to purge deleted urls", zap.Error(err))
+		} else if purged > 0 {
+			u.logger.Infoln("Purged deleted urls", zap.Int64("count", purged))
+		}
+
+		select {
+		case <-u.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 // generateRandomString creates a random string of specified length using crypto/rand.
 // The string is composed of characters from the defined charset.
 //
@@ -0,0 +1,264 @@
+// Package service provides business logic for URL shortening service.
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bezjen/shortener/internal/logger"
+	"github.com/bezjen/shortener/internal/model"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// urlPolicyReloadInterval defines how often the domain lists file is checked for changes.
+const urlPolicyReloadInterval = 30 * time.Second
+
+// defaultURLSchemes are the schemes allowed when no schemes are configured.
+var defaultURLSchemes = []string{"http", "https"}
+
+// blockedIPPrefixes are the address ranges that are not reachable from the internet.
+// Destinations in these ranges are rejected and requests to them are refused.
+var blockedIPPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // this network
+	netip.MustParsePrefix("10.0.0.0/8"),     // private
+	netip.MustParsePrefix("100.64.0.0/10"),  // carrier-grade NAT
+	netip.MustParsePrefix("127.0.0.0/8"),    // loopback
+	netip.MustParsePrefix("169.254.0.0/16"), // link-local
+	netip.MustParsePrefix("172.16.0.0/12"),  // private
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("192.168.0.0/16"), // private
+	netip.MustParsePrefix("198.18.0.0/15"),  // benchmarking
+	netip.MustParsePrefix("224.0.0.0/4"),    // multicast
+	netip.MustParsePrefix("240.0.0.0/4"),    // reserved and broadcast
+	netip.MustParsePrefix("::/128"),         // unspecified
+	netip.MustParsePrefix("::1/128"),        // loopback
+	netip.MustParsePrefix("64:ff9b::/96"),   // IPv4/IPv6 translation
+	netip.MustParsePrefix("fc00::/7"),       // unique local
+	netip.MustParsePrefix("fe80::/10"),      // link-local
+	netip.MustParsePrefix("ff00::/8"),       // multicast
+}
+
+// ErrURLWithoutHost is returned when a destination URL has no host to redirect to.
+var ErrURLWithoutHost = errors.New("url has no host")
+
+// URLPolicyViolationError is returned when a destination URL violates a rule of the URL policy.
+type URLPolicyViolationError struct {
+	// Rule is the violated rule.
+	Rule model.URLPolicyRule
+
+	// Reason describes which part of the URL violates the rule.
+	Reason string
+}
+
+// Error returns the string representation of the policy violation.
+// Implements the error interface.
+//
+// Returns:
+//   - string: error message naming the violated rule
+func (err *URLPolicyViolationError) Error() string {
+	return fmt.Sprintf("url violates %s policy: %s", err.Rule, err.Reason)
+}
+
+// URLPolicy decides which destination URLs may be shortened.
+// It allows configured schemes only, applies the domain allow and deny lists,
+// and rejects private targets and links to the hosts of the service.
+// Hosts are checked as written, names are not resolved.
+type URLPolicy struct {
+	schemes   map[string]struct{}
+	selfHosts map[string]struct{}
+
+	mu    sync.RWMutex
+	lists model.DomainLists
+
+	reloader *fileReloader
+}
+
+// NewURLPolicy creates a new URLPolicy instance without domain lists.
+//
+// Parameters:
+//   - schemes: allowed URL schemes, empty for http and https
+//   - selfHosts: hosts of the service links must not point to, with or without port
+//   - logger: logger instance for reload errors
+//
+// Returns:
+//   - *URLPolicy: initialized URL policy
+func NewURLPolicy(schemes []string, selfHosts []string, logger *logger.Logger) *URLPolicy {
+	if len(schemes) == 0 {
+		schemes = defaultURLSchemes
+	}
+	p := &URLPolicy{
+		schemes:   make(map[string]struct{}, len(schemes)),
+		selfHosts: make(map[string]struct{}, len(selfHosts)),
+	}
+	p.reloader = newFileReloader("domain lists", urlPolicyReloadInterval, p.applyDomainLists, logger)
+	for _, scheme := range schemes {
+		p.schemes[strings.ToLower(scheme)] = struct{}{}
+	}
+	for _, host := range selfHosts {
+		host = strings.ToLower(host)
+		if name, _, err := net.SplitHostPort(host); err == nil {
+			host = name
+		}
+		if host != "" {
+			p.selfHosts[host] = struct{}{}
+		}
+	}
+	return p
+}
+
+// LoadDomainLists reads the domain allow and deny lists from a JSON file.
+// The lists in use are kept if the file cannot be read or parsed.
+//
+// Parameters:
+//   - path: path to the JSON file with model.DomainLists
+//
+// Returns:
+//   - error: error if the file cannot be read or parsed
+func (p *URLPolicy) LoadDomainLists(path string) error {
+	return p.reloader.load(path)
+}
+
+// applyDomainLists parses a domain lists file and replaces the lists in use.
+//
+// Parameters:
+//   - data: contents of the JSON file with model.DomainLists
+//
+// Returns:
+//   - error: error if the contents cannot be parsed
+func (p *URLPolicy) applyDomainLists(data []byte) error {
+	var lists model.DomainLists
+	if err := json.Unmarshal(data, &lists); err != nil {
+		return err
+	}
+	for i, pattern := range lists.Allow {
+		lists.Allow[i] = strings.ToLower(strings.TrimSpace(pattern))
+	}
+	for i, pattern := range lists.Deny {
+		lists.Deny[i] = strings.ToLower(strings.TrimSpace(pattern))
+	}
+
+	p.mu.Lock()
+	p.lists = lists
+	p.mu.Unlock()
+	return nil
+}
+
+// StartReload starts a background worker reloading the domain lists file when it changes.
+// The file is checked every urlPolicyReloadInterval until Close is called.
+//
+// Parameters:
+//   - path: path to the JSON file with model.DomainLists
+func (p *URLPolicy) StartReload(path string) {
+	p.reloader.start(path)
+}
+
+// Close stops the reload worker and waits for it to finish.
+func (p *URLPolicy) Close() {
+	p.reloader.close()
+}
+
+// Check verifies a destination URL against all rules of the policy.
+//
+// Parameters:
+//   - rawURL: destination URL to check
+//
+// Returns:
+//   - error: *URLPolicyViolationError naming the violated rule, ErrURLWithoutHost, or parse error
+func (p *URLPolicy) Check(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	scheme := strings.ToLower(parsed.Scheme)
+	if _, ok := p.schemes[scheme]; !ok {
+		return &URLPolicyViolationError{Rule: model.URLPolicyScheme, Reason: "scheme " + scheme + " is not allowed"}
+	}
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if host == "" {
+		return ErrURLWithoutHost
+	}
+	if _, ok := p.selfHosts[host]; ok {
+		return &URLPolicyViolationError{Rule: model.URLPolicySelfLink, Reason: host + " is served by this service"}
+	}
+	if isPrivateHost(host) {
+		return &URLPolicyViolationError{Rule: model.URLPolicyPrivateTarget, Reason: host + " is not a public host"}
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, pattern := range p.lists.Deny {
+		if matchDomain(pattern, host) {
+			return &URLPolicyViolationError{Rule: model.URLPolicyDomainDenied, Reason: host + " matches " + pattern}
+		}
+	}
+	if len(p.lists.Allow) == 0 {
+		return nil
+	}
+	for _, pattern := range p.lists.Allow {
+		if matchDomain(pattern, host) {
+			return nil
+		}
+	}
+	return &URLPolicyViolationError{Rule: model.URLPolicyDomainNotAllowed, Reason: host + " is not in the allow list"}
+}
+
+// matchDomain reports whether a host matches a domain list pattern.
+// *.domain matches subdomains of the domain but not the domain itself.
+//
+// Parameters:
+//   - pattern: lower case domain or *.domain
+//   - host: lower case host
+//
+// Returns:
+//   - bool: true if the host matches
+func matchDomain(pattern string, host string) bool {
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return host == pattern
+}
+
+// isPrivateHost reports whether a host names the local machine or a non-public IP address.
+// Numeric hosts are parsed the way browsers do, so that forms like 127.1 or 2130706433 are recognized.
+// DNS names are not resolved: their addresses may change after the check, so requests the service sends
+// to destinations refuse private addresses when dialing instead, see refusePrivateAddress.
+//
+// Parameters:
+//   - host: lower case host without port
+//
+// Returns:
+//   - bool: true for localhost names and addresses not reachable from the internet
+func isPrivateHost(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := parseIPHost(host)
+	return ip != nil && isPrivateIP(ip)
+}
+
+// isPrivateIP reports whether an IP address is not reachable from the internet.
+// IPv4-mapped IPv6 addresses are checked as IPv4 addresses.
+//
+// Parameters:
+//   - ip: IPv4 or IPv6 address
+//
+// Returns:
+//   - bool: true for addresses in blockedIPPrefixes and addresses of invalid length
+func isPrivateIP(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return true
+	}
+	addr = addr.Unmap()
+	for _, prefix := range blockedIPPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
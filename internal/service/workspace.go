@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/bezjen/shortener/internal/model"
+	"github.com/bezjen/shortener/internal/repository"
+	"github.com/google/uuid"
+)
+
+// ErrWorkspaceForbidden is returned when the user lacks the role required for a workspace operation.
+var ErrWorkspaceForbidden = errors.New("workspace access forbidden")
+
+// ErrLastWorkspaceOwner is returned when an operation would leave a workspace without an owner.
+var ErrLastWorkspaceOwner = errors.New("workspace must keep at least one owner")
+
+// ErrInvalidWorkspaceRole is returned when a member is assigned an unknown role.
+var ErrInvalidWorkspaceRole = errors.New("invalid workspace role")
+
+// CreateWorkspace creates a new workspace owned by the user.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user becoming the workspace owner
+//   - name: display name of the workspace
+//
+// Returns:
+//   - *model.Workspace: created workspace with the owner role
+//   - error: error if creation fails
+func (u *URLShortener) CreateWorkspace(ctx context.Context, userID string, name string) (*model.Workspace, error) {
+	workspace := model.NewWorkspace(uuid.NewString(), name)
+	err := u.storage.CreateWorkspace(ctx, *workspace, userID)
+	if err != nil {
+		return nil, err
+	}
+	workspace.Role = model.RoleOwner
+	return workspace, nil
+}
+
+// GetUserWorkspaces retrieves all workspaces the user is a member of.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: user identifier to look up workspaces for
+//
+// Returns:
+//   - []model.Workspace: slice of workspaces with the user's role
+//   - error: error if lookup fails
+func (u *URLShortener) GetUserWorkspaces(ctx context.Context, userID string) ([]model.Workspace, error) {
+	return u.storage.GetWorkspacesByUserID(ctx, userID)
+}
+
+// GetWorkspaceMembers retrieves all members of a workspace.
+// Any member of the workspace may list its members.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the requesting user
+//   - workspaceID: identifier of the workspace
+//
+// Returns:
+//   - []model.WorkspaceMember: slice of members with their roles
+//   - error: ErrWorkspaceForbidden if the user is not a member, or lookup error
+func (u *URLShortener) GetWorkspaceMembers(ctx context.Context,
+	userID string,
+	workspaceID string,
+) ([]model.WorkspaceMember, error) {
+	if _, err := u.checkWorkspaceRole(ctx, userID, workspaceID, model.RoleViewer); err != nil {
+		return nil, err
+	}
+	return u.storage.GetWorkspaceMembers(ctx, workspaceID)
+}
+
+// SetWorkspaceMember adds a member to a workspace or changes the role of an existing member.
+// Only owners may manage members, the last owner cannot be demoted.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the requesting user
+//   - member: workspace member with role
+//
+// Returns:
+//   - error: ErrWorkspaceForbidden, ErrInvalidWorkspaceRole, ErrLastWorkspaceOwner or storage error
+func (u *URLShortener) SetWorkspaceMember(ctx context.Context, userID string, member model.WorkspaceMember) error {
+	if !member.Role.Valid() {
+		return ErrInvalidWorkspaceRole
+	}
+	if _, err := u.checkWorkspaceRole(ctx, userID, member.WorkspaceID, model.RoleOwner); err != nil {
+		return err
+	}
+	if member.Role != model.RoleOwner {
+		if err := u.checkNotLastOwner(ctx, member.WorkspaceID, member.UserID); err != nil {
+			return err
+		}
+	}
+	return u.storage.SaveWorkspaceMember(ctx, member)
+}
+
+// RemoveWorkspaceMember removes a member from a workspace.
+// Owners may remove any member, other members may only leave the workspace themselves.
+// The last owner cannot be removed.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the requesting user
+//   - workspaceID: identifier of the workspace
+//   - memberID: identifier of the member to remove
+//
+// Returns:
+//   - error: ErrWorkspaceForbidden, ErrLastWorkspaceOwner or storage error
+func (u *URLShortener) RemoveWorkspaceMember(ctx context.Context,
+	userID string,
+	workspaceID string,
+	memberID string,
+) error {
+	required := model.RoleOwner
+	if userID == memberID {
+		required = model.RoleViewer
+	}
+	if _, err := u.checkWorkspaceRole(ctx, userID, workspaceID, required); err != nil {
+		return err
+	}
+	if err := u.checkNotLastOwner(ctx, workspaceID, memberID); err != nil {
+		return err
+	}
+	return u.storage.DeleteWorkspaceMember(ctx, workspaceID, memberID)
+}
+
+// GenerateWorkspaceShortURLPart creates a short URL identifier owned by a workspace.
+// Only editors and owners may create links in a workspace.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user creating the short URL
+//   - workspaceID: identifier of the workspace owning the short URL
+//   - domain: domain the short URL is created on, empty for the base URL host
+//   - url: original URL to be shortened
+//
+// Returns:
+//   - string: generated short URL identifier
+//   - error: ErrWorkspaceForbidden, *QuotaExceededError or error if generation fails
+func (u *URLShortener) GenerateWorkspaceShortURLPart(ctx context.Context,
+	userID string,
+	workspaceID string,
+	domain string,
+	url string,
+) (string, error) {
+	if _, err := u.checkWorkspaceRole(ctx, userID, workspaceID, model.RoleEditor); err != nil {
+		return "", err
+	}
+	return u.saveShortURL(ctx, userID, workspaceID, domain, url)
+}
+
+// GetURLsByWorkspaceID retrieves all URLs of a workspace.
+// Any member of the workspace may list its URLs.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the requesting user
+//   - workspaceID: identifier of the workspace
+//
+// Returns:
+//   - []model.URL: slice of URLs of the workspace
+//   - error: ErrWorkspaceForbidden if the user is not a member, or lookup error
+func (u *URLShortener) GetURLsByWorkspaceID(ctx context.Context,
+	userID string,
+	workspaceID string,
+) ([]model.URL, error) {
+	if _, err := u.checkWorkspaceRole(ctx, userID, workspaceID, model.RoleViewer); err != nil {
+		return nil, err
+	}
+	return u.storage.GetByWorkspaceID(ctx, workspaceID)
+}
+
+// DeleteWorkspaceShortURLsBatch moves short URLs of a workspace to the trash synchronously.
+// Only editors and owners may delete links of a workspace.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the requesting user
+//   - workspaceID: identifier of the workspace owning the short URLs
+//   - domain: domain the short URLs belong to, empty for the base URL host
+//   - shortURLs: slice of short URL identifiers to delete
+//
+// Returns:
+//   - error: ErrWorkspaceForbidden, *QuotaExceededError or error if deletion fails
+func (u *URLShortener) DeleteWorkspaceShortURLsBatch(ctx context.Context,
+	userID string,
+	workspaceID string,
+	domain string,
+	shortURLs []string,
+) error {
+	if _, err := u.checkWorkspaceRole(ctx, userID, workspaceID, model.RoleEditor); err != nil {
+		return err
+	}
+	if err := u.checkBatchQuota(ctx, userID, model.QuotaDeleteBatchSize, len(shortURLs)); err != nil {
+		return err
+	}
+	return u.storage.DeleteWorkspaceBatch(ctx, domain, workspaceID, shortURLs)
+}
+
+// GetDeletedURLsByWorkspaceID retrieves all URLs of a workspace that were moved to the trash.
+// Any member of the workspace may list its trash.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the requesting user
+//   - workspaceID: identifier of the workspace
+//
+// Returns:
+//   - []model.URL: slice of deleted URLs with deletion time
+//   - error: ErrWorkspaceForbidden if the user is not a member, or lookup error
+func (u *URLShortener) GetDeletedURLsByWorkspaceID(ctx context.Context,
+	userID string,
+	workspaceID string,
+) ([]model.URL, error) {
+	if _, err := u.checkWorkspaceRole(ctx, userID, workspaceID, model.RoleViewer); err != nil {
+		return nil, err
+	}
+	return u.storage.GetDeletedByWorkspaceID(ctx, workspaceID)
+}
+
+// RestoreWorkspaceShortURLsBatch restores short URLs of a workspace from the trash synchronously.
+// Only editors and owners may restore links of a workspace, regardless of who deleted them.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the requesting user
+//   - workspaceID: identifier of the workspace owning the short URLs
+//   - domain: domain the short URLs belong to, empty for the base URL host
+//   - shortURLs: slice of short URL identifiers to restore
+//
+// Returns:
+//   - error: ErrWorkspaceForbidden or error if restore fails
+func (u *URLShortener) RestoreWorkspaceShortURLsBatch(ctx context.Context,
+	userID string,
+	workspaceID string,
+	domain string,
+	shortURLs []string,
+) error {
+	if _, err := u.checkWorkspaceRole(ctx, userID, workspaceID, model.RoleEditor); err != nil {
+		return err
+	}
+	return u.storage.RestoreWorkspaceBatch(ctx, domain, workspaceID, shortURLs)
+}
+
+// UpdateWorkspaceShortURL changes the original URL of a short URL owned by a workspace.
+// Only editors and owners may change links of a workspace, regardless of who created them.
+// The new destination goes through phishing review and metadata is fetched again.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the requesting user
+//   - workspaceID: identifier of the workspace owning the short URL
+//   - domain: domain of the short URL, empty for the base URL host
+//   - shortURL: short URL identifier
+//   - url: new original URL
+//
+// Returns:
+//   - error: ErrWorkspaceForbidden, repository.ErrNotFound, *repository.ErrURLConflict,
+//     repository.ErrURLReserved or error if update fails
+func (u *URLShortener) UpdateWorkspaceShortURL(ctx context.Context,
+	userID string,
+	workspaceID string,
+	domain string,
+	shortURL string,
+	url string,
+) error {
+	if _, err := u.checkWorkspaceRole(ctx, userID, workspaceID, model.RoleEditor); err != nil {
+		return err
+	}
+	updatedURL := model.NewURL(shortURL, url)
+	updatedURL.Domain = domain
+	updatedURL.WorkspaceID = workspaceID
+	updatedURL.CanonicalURL = u.canonicalizer.Canonicalize(url)
+	updatedURL.ReviewReasons = u.reviewReasons(userID, url)
+	if err := u.storage.UpdateWorkspaceURL(ctx, workspaceID, *updatedURL); err != nil {
+		return err
+	}
+	u.enqueueMetadata(*updatedURL)
+	return nil
+}
+
+// checkWorkspaceRole verifies that the user has at least the required role in the workspace.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - userID: identifier of the user
+//   - workspaceID: identifier of the workspace
+//   - required: minimal role needed for the operation
+//
+// Returns:
+//   - model.WorkspaceRole: actual role of the user
+//   - error: ErrWorkspaceForbidden if the user is not a member or lacks the role, or storage error
+func (u *URLShortener) checkWorkspaceRole(ctx context.Context,
+	userID string,
+	workspaceID string,
+	required model.WorkspaceRole,
+) (model.WorkspaceRole, error) {
+	role, err := u.storage.GetWorkspaceRole(ctx, workspaceID, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return "", ErrWorkspaceForbidden
+	}
+	if err != nil {
+		return "", err
+	}
+	if !role.Allows(required) {
+		return role, ErrWorkspaceForbidden
+	}
+	return role, nil
+}
+
+// checkNotLastOwner verifies that the member is not the only owner of the workspace.
+//
+// Parameters:
+//   - ctx: context for request cancellation and timeouts
+//   - workspaceID: identifier of the workspace
+//   - memberID: identifier of the member losing the owner role
+//
+// Returns:
+//   - error: ErrLastWorkspaceOwner if the member is the only owner, or storage error
+func (u *URLShortener) checkNotLastOwner(ctx context.Context, workspaceID string, memberID string) error {
+	members, err := u.storage.GetWorkspaceMembers(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	owners := 0
+	isOwner := false
+	for _, member := range members {
+		if member.Role == model.RoleOwner {
+			owners++
+			if member.UserID == memberID {
+				isOwner = true
+			}
+		}
+	}
+	if isOwner && owners == 1 {
+		return ErrLastWorkspaceOwner
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+drop index if exists idx_short_url_original_url_hash;
+
+alter table if exists t_short_url drop column original_url_hash;
+
+alter table if exists t_short_url alter column original_url type varchar(255);
+
+create unique index idx_short_url_original_url on t_short_url (original_url);
@@ -0,0 +1,11 @@
+drop index if exists idx_short_url_original_url;
+
+alter table t_short_url alter column original_url type text;
+
+alter table t_short_url add column original_url_hash char(64);
+
+update t_short_url set original_url_hash = encode(sha256(convert_to(original_url, 'UTF8')), 'hex');
+
+alter table t_short_url alter column original_url_hash set not null;
+
+create unique index idx_short_url_original_url_hash on t_short_url (original_url_hash);
@@ -0,0 +1,3 @@
+drop index if exists idx_short_url_deleted_at;
+
+alter table if exists t_short_url drop column deleted_at;
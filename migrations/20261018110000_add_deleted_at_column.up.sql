@@ -0,0 +1,5 @@
+alter table t_short_url add column deleted_at timestamptz;
+
+update t_short_url set deleted_at = now() where is_deleted = true;
+
+create index idx_short_url_deleted_at on t_short_url (deleted_at);
@@ -0,0 +1,9 @@
+drop table if exists t_bundle_link;
+
+delete from t_short_url where is_bundle = true;
+
+alter table if exists t_short_url alter column original_url_hash set not null;
+
+alter table if exists t_short_url drop column title;
+
+alter table if exists t_short_url drop column is_bundle;
@@ -0,0 +1,13 @@
+alter table t_short_url add column is_bundle boolean not null default false;
+
+alter table t_short_url add column title text;
+
+alter table t_short_url alter column original_url_hash drop not null;
+
+create table t_bundle_link(
+    short_url varchar(8) not null references t_short_url (short_url) on delete cascade,
+    position integer not null,
+    title text not null,
+    url text not null,
+    primary key (short_url, position)
+);
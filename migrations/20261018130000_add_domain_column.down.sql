@@ -0,0 +1,20 @@
+alter table t_bundle_link drop constraint t_bundle_link_short_url_fkey;
+
+alter table t_bundle_link drop constraint t_bundle_link_pkey;
+
+alter table t_bundle_link drop column domain;
+
+alter table t_bundle_link add primary key (short_url, position);
+
+drop index if exists idx_short_url_domain_original_url_hash;
+
+create unique index idx_short_url_original_url_hash on t_short_url (original_url_hash);
+
+alter table t_short_url drop constraint t_short_url_pkey;
+
+alter table t_short_url drop column domain;
+
+alter table t_short_url add primary key (short_url);
+
+alter table t_bundle_link add constraint t_bundle_link_short_url_fkey
+    foreign key (short_url) references t_short_url (short_url) on delete cascade;
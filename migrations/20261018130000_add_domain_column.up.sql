@@ -0,0 +1,20 @@
+alter table t_bundle_link drop constraint t_bundle_link_short_url_fkey;
+
+alter table t_short_url add column domain varchar(255) not null default '';
+
+alter table t_short_url drop constraint t_short_url_pkey;
+
+alter table t_short_url add primary key (domain, short_url);
+
+drop index if exists idx_short_url_original_url_hash;
+
+create unique index idx_short_url_domain_original_url_hash on t_short_url (domain, original_url_hash);
+
+alter table t_bundle_link add column domain varchar(255) not null default '';
+
+alter table t_bundle_link drop constraint t_bundle_link_pkey;
+
+alter table t_bundle_link add primary key (domain, short_url, position);
+
+alter table t_bundle_link add constraint t_bundle_link_short_url_fkey
+    foreign key (domain, short_url) references t_short_url (domain, short_url) on delete cascade;
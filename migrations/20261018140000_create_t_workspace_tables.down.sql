@@ -0,0 +1,7 @@
+drop index if exists idx_short_url_workspace_id;
+
+alter table if exists t_short_url drop column workspace_id;
+
+drop table if exists t_workspace_member;
+
+drop table if exists t_workspace;
@@ -0,0 +1,19 @@
+create table t_workspace(
+    id varchar(36) not null,
+    name text not null,
+    created_at timestamptz not null default now(),
+    primary key (id)
+);
+
+create table t_workspace_member(
+    workspace_id varchar(36) not null references t_workspace (id) on delete cascade,
+    user_id varchar(50) not null,
+    role varchar(16) not null,
+    primary key (workspace_id, user_id)
+);
+
+create index idx_workspace_member_user_id on t_workspace_member (user_id);
+
+alter table t_short_url add column workspace_id varchar(36) references t_workspace (id);
+
+create index idx_short_url_workspace_id on t_short_url (workspace_id);
@@ -0,0 +1,3 @@
+drop index if exists idx_user_login;
+
+drop table if exists t_user;
@@ -0,0 +1,9 @@
+create table t_user(
+    id varchar(50) not null,
+    login varchar(255) not null,
+    password_hash text not null,
+    created_at timestamptz not null default now(),
+    primary key (id)
+);
+
+create unique index idx_user_login on t_user (login);
@@ -0,0 +1,4 @@
+drop index if exists idx_api_key_user_id;
+drop index if exists idx_api_key_key_hash;
+
+drop table if exists t_api_key;
@@ -0,0 +1,15 @@
+create table t_api_key(
+    id varchar(50) not null,
+    user_id varchar(50) not null,
+    name varchar(255) not null,
+    prefix varchar(20) not null,
+    key_hash char(64) not null,
+    scopes varchar(50) not null,
+    rate_limit integer not null,
+    created_at timestamptz not null default now(),
+    last_used_at timestamptz,
+    primary key (id)
+);
+
+create unique index idx_api_key_key_hash on t_api_key (key_hash);
+create index idx_api_key_user_id on t_api_key (user_id);
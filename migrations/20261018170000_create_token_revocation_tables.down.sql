@@ -0,0 +1,5 @@
+drop table if exists t_user_token_revocation;
+
+drop index if exists idx_revoked_token_expires_at;
+
+drop table if exists t_revoked_token;
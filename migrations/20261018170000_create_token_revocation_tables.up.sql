@@ -0,0 +1,14 @@
+create table t_revoked_token(
+    token_id varchar(50) not null,
+    expires_at timestamptz not null,
+    primary key (token_id)
+);
+
+create index idx_revoked_token_expires_at on t_revoked_token (expires_at);
+
+create table t_user_token_revocation(
+    user_id varchar(50) not null,
+    revoked_before timestamptz not null,
+    expires_at timestamptz not null,
+    primary key (user_id)
+);
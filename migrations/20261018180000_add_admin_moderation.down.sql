@@ -0,0 +1,3 @@
+drop table if exists t_banned_user;
+
+alter table t_short_url drop column if exists is_disabled;
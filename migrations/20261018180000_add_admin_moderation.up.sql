@@ -0,0 +1,7 @@
+alter table t_short_url add column is_disabled boolean not null default false;
+
+create table t_banned_user(
+    user_id varchar(50) not null,
+    banned_at timestamptz not null default now(),
+    primary key (user_id)
+);
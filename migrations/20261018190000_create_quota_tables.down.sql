@@ -0,0 +1,3 @@
+drop table if exists t_user_daily_links;
+
+drop table if exists t_user_quota;
@@ -0,0 +1,15 @@
+create table t_user_quota(
+    user_id varchar(50) not null,
+    links_per_day bigint not null default 0,
+    active_links bigint not null default 0,
+    batch_size bigint not null default 0,
+    delete_batch_size bigint not null default 0,
+    primary key (user_id)
+);
+
+create table t_user_daily_links(
+    user_id varchar(50) not null,
+    day date not null,
+    links bigint not null default 0,
+    primary key (user_id, day)
+);
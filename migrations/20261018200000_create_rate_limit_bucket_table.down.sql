@@ -0,0 +1 @@
+drop table if exists t_rate_limit_bucket;
@@ -0,0 +1,9 @@
+create table t_rate_limit_bucket(
+    key varchar(300) not null,
+    tokens double precision not null,
+    updated_at timestamptz not null,
+    full_at timestamptz not null,
+    primary key (key)
+);
+
+create index idx_rate_limit_bucket_full_at on t_rate_limit_bucket (full_at);
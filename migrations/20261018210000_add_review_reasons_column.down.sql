@@ -0,0 +1 @@
+alter table t_short_url drop column if exists review_reasons;
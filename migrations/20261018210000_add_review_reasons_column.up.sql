@@ -0,0 +1 @@
+alter table t_short_url add column review_reasons varchar(200) not null default '';
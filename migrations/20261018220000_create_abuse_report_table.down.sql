@@ -0,0 +1,3 @@
+drop table if exists t_abuse_report;
+
+alter table t_short_url drop column if exists takedown;
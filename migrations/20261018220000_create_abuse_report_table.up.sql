@@ -0,0 +1,20 @@
+alter table t_short_url add column takedown varchar(16) not null default '';
+
+create table t_abuse_report(
+    id varchar(36) not null,
+    domain varchar(255) not null default '',
+    short_url varchar(8) not null,
+    reason varchar(16) not null,
+    details varchar(1000) not null default '',
+    reporter_id varchar(50),
+    status varchar(16) not null default 'open',
+    created_at timestamptz not null default now(),
+    resolved_by varchar(50),
+    resolved_at timestamptz,
+    primary key (id),
+    foreign key (domain, short_url) references t_short_url (domain, short_url) on delete cascade
+);
+
+create index idx_abuse_report_status_created_at on t_abuse_report (status, created_at);
+
+create index idx_abuse_report_domain_short_url on t_abuse_report (domain, short_url);
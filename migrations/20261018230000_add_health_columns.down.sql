@@ -0,0 +1,5 @@
+drop index if exists idx_short_url_health_checked_at;
+
+alter table t_short_url drop column if exists health_checked_at;
+alter table t_short_url drop column if exists health_status_code;
+alter table t_short_url drop column if exists health_status;
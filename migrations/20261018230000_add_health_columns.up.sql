@@ -0,0 +1,5 @@
+alter table t_short_url add column health_status varchar(16) not null default '';
+alter table t_short_url add column health_status_code integer not null default 0;
+alter table t_short_url add column health_checked_at timestamptz;
+
+create index idx_short_url_health_checked_at on t_short_url (health_checked_at nulls first);
@@ -0,0 +1,5 @@
+alter table t_short_url drop column if exists meta_fetched_at;
+alter table t_short_url drop column if exists meta_site_name;
+alter table t_short_url drop column if exists meta_image;
+alter table t_short_url drop column if exists meta_description;
+alter table t_short_url drop column if exists meta_title;
@@ -0,0 +1,5 @@
+alter table t_short_url add column meta_title text not null default '';
+alter table t_short_url add column meta_description text not null default '';
+alter table t_short_url add column meta_image text not null default '';
+alter table t_short_url add column meta_site_name text not null default '';
+alter table t_short_url add column meta_fetched_at timestamptz;
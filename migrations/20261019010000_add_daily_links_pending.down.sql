@@ -0,0 +1 @@
+alter table t_user_daily_links drop column if exists pending;
@@ -0,0 +1 @@
+alter table t_user_daily_links add column pending bigint not null default 0;